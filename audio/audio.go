@@ -0,0 +1,132 @@
+/*
+Copyright © 2023 Mikael Schultz <bitcanon@proton.me>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package audio
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"time"
+)
+
+// ErrNoPlayer is returned when no command playing
+// audio files is installed
+var ErrNoPlayer = errors.New("no audio player found (afplay, ffplay, mpg123, paplay or aplay)")
+
+// Duration returns how long the audio clip in the file lasts. MP3
+// and WAV files are supported
+func Duration(filename string) (time.Duration, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return 0, err
+	}
+	var d time.Duration
+	switch ext := strings.ToLower(filepath.Ext(filename)); ext {
+	case ".mp3":
+		d, err = mp3Duration(data)
+	case ".wav":
+		d, err = wavDuration(data)
+	default:
+		return 0, fmt.Errorf("%s: unsupported audio format %q (mp3 or wav)", filename, ext)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", filename, err)
+	}
+	return d, nil
+}
+
+// players are the commands playing an audio file on Linux and the
+// other systems, in the order they are tried, with the extensions of
+// the files they play (all if empty)
+var players = []struct {
+	command    []string
+	extensions []string
+}{
+	{command: []string{"ffplay", "-nodisp", "-autoexit", "-loglevel", "quiet"}},
+	{command: []string{"mpg123", "-q"}, extensions: []string{".mp3"}},
+	{command: []string{"paplay"}, extensions: []string{".wav"}},
+	{command: []string{"aplay", "-q"}, extensions: []string{".wav"}},
+}
+
+// windowsPlayer is the PowerShell script playing the file given as
+// its second argument with the media player of Windows, for as long
+// as the clip lasts in milliseconds, given first
+const windowsPlayer = `Add-Type -AssemblyName PresentationCore; ` +
+	`$p = New-Object System.Windows.Media.MediaPlayer; ` +
+	`$p.Open([uri]$args[1]); $p.Play(); Start-Sleep -Milliseconds $args[0]; $p.Close()`
+
+// Play plays the audio clip in the file with the audio player of the
+// system, and returns once the clip has been played or the context
+// is done
+func Play(ctx context.Context, filename string) error {
+	command, err := playCommand(runtime.GOOS, filename)
+	if err != nil {
+		return err
+	}
+	slog.Debug("playing audio", "file", filename, "player", command[0])
+	if err := exec.CommandContext(ctx, command[0], command[1:]...).Run(); err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return fmt.Errorf("failed to play %s with %s: %w", filename, command[0], err)
+	}
+	return nil
+}
+
+// playCommand returns the command playing the file on the system:
+// afplay on macOS, the media player of Windows, or else the first
+// player installed that supports the format of the file
+func playCommand(goos, filename string) ([]string, error) {
+	switch goos {
+	case "darwin":
+		return []string{"afplay", filename}, nil
+	case "windows":
+		d, err := Duration(filename)
+		if err != nil {
+			return nil, err
+		}
+		abs, err := filepath.Abs(filename)
+		if err != nil {
+			return nil, err
+		}
+		return []string{"powershell", "-NoProfile", "-Command", windowsPlayer, fmt.Sprint(d.Milliseconds()), abs}, nil
+	}
+
+	ext := strings.ToLower(filepath.Ext(filename))
+	for _, player := range players {
+		if len(player.extensions) > 0 && !slices.Contains(player.extensions, ext) {
+			continue
+		}
+		if _, err := exec.LookPath(player.command[0]); err != nil {
+			continue
+		}
+		return append(slices.Clip(player.command), filename), nil
+	}
+	return nil, ErrNoPlayer
+}
@@ -0,0 +1,105 @@
+package audio_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/bitcanon/autotyper/audio"
+)
+
+// wavFile returns a WAV file of silence lasting the duration,
+// in 16-bit mono at 8000 Hz
+func wavFile(d time.Duration) []byte {
+	size := uint32(d.Seconds() * 8000 * 2)
+	var b bytes.Buffer
+	b.WriteString("RIFF")
+	binary.Write(&b, binary.LittleEndian, uint32(36+size))
+	b.WriteString("WAVEfmt ")
+	binary.Write(&b, binary.LittleEndian, struct {
+		Size                 uint32
+		Format, Channels     uint16
+		SampleRate, ByteRate uint32
+		Align, Bits          uint16
+	}{16, 1, 1, 8000, 16000, 2, 16})
+	b.WriteString("data")
+	binary.Write(&b, binary.LittleEndian, size)
+	b.Write(make([]byte, size))
+	return b.Bytes()
+}
+
+// mp3File returns an MP3 file of frames of MPEG-1 layer III at
+// 128 kbit/s and 44.1 kHz, after an ID3v2 tag
+func mp3File(frames int) []byte {
+	var b bytes.Buffer
+	b.Write([]byte{'I', 'D', '3', 4, 0, 0, 0, 0, 0, 20})
+	b.Write(make([]byte, 20))
+	for i := 0; i < frames; i++ {
+		frame := make([]byte, 417)
+		copy(frame, []byte{0xff, 0xfb, 0x90, 0x00})
+		b.Write(frame)
+	}
+	return b.Bytes()
+}
+
+// TestDuration tests that the durations of the WAV and MP3 files
+// are read, and that other files are refused
+func TestDuration(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		name     string
+		data     []byte
+		expected time.Duration
+		fail     bool
+	}{
+		{name: "clip.wav", data: wavFile(1500 * time.Millisecond), expected: 1500 * time.Millisecond},
+		{name: "clip.mp3", data: mp3File(100), expected: 2612 * time.Millisecond},
+		{name: "empty.mp3", data: []byte("ID3"), fail: true},
+		{name: "text.wav", data: []byte("not a wav file"), fail: true},
+		{name: "clip.ogg", data: []byte("OggS"), fail: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			filename := filepath.Join(dir, test.name)
+			if err := os.WriteFile(filename, test.data, 0644); err != nil {
+				t.Fatalf("failed to write the file: %v", err)
+			}
+			d, err := audio.Duration(filename)
+			if test.fail {
+				if err == nil {
+					t.Errorf("expected error, but got a duration of %v", d)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected no error, but got %v", err)
+			}
+			if d.Round(time.Millisecond) != test.expected {
+				t.Errorf("expected %v, but got %v", test.expected, d)
+			}
+		})
+	}
+}
+
+// TestPlayNoPlayer tests that ErrNoPlayer is returned
+// when no audio player is installed
+func TestPlayNoPlayer(t *testing.T) {
+	if runtime.GOOS == "darwin" || runtime.GOOS == "windows" {
+		t.Skip("the audio player of the system is always used")
+	}
+	filename := filepath.Join(t.TempDir(), "clip.wav")
+	if err := os.WriteFile(filename, wavFile(time.Second), 0644); err != nil {
+		t.Fatalf("failed to write the file: %v", err)
+	}
+	t.Setenv("PATH", t.TempDir())
+	if err := audio.Play(context.Background(), filename); !errors.Is(err, audio.ErrNoPlayer) {
+		t.Errorf("expected ErrNoPlayer, but got %v", err)
+	}
+}
@@ -0,0 +1,126 @@
+/*
+Copyright © 2023 Mikael Schultz <bitcanon@proton.me>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package audio
+
+import (
+	"errors"
+	"time"
+)
+
+// Define the versions of MPEG audio, as coded in the frame headers
+const (
+	mpeg25 = 0
+	mpeg2  = 2
+	mpeg1  = 3
+)
+
+// bitrates are the bit rates in kbit/s of the MPEG-1 layers I, II
+// and III, and of the MPEG-2 layer I and layers II and III, by the
+// index in the frame header
+var bitrates = [5][16]int{
+	{0, 32, 64, 96, 128, 160, 192, 224, 256, 288, 320, 352, 384, 416, 448},
+	{0, 32, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320, 384},
+	{0, 32, 40, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320},
+	{0, 32, 48, 56, 64, 80, 96, 112, 128, 144, 160, 176, 192, 224, 256},
+	{0, 8, 16, 24, 32, 40, 48, 56, 64, 80, 96, 112, 128, 144, 160},
+}
+
+// sampleRates are the sample rates in Hz of MPEG-1,
+// by the index in the frame header
+var sampleRates = [3]int{44100, 48000, 32000}
+
+// mp3Duration returns the duration of an MP3 file, adding up the
+// samples of its frames so that files with a variable bit rate are
+// timed exactly. The ID3 tags are skipped
+func mp3Duration(data []byte) (time.Duration, error) {
+	// Skip the ID3v2 tag, whose size is coded on 7 bits per byte
+	if len(data) >= 10 && string(data[0:3]) == "ID3" {
+		size := int(data[6])<<21 | int(data[7])<<14 | int(data[8])<<7 | int(data[9])
+		size += 10
+		if data[5]&0x10 != 0 {
+			size += 10
+		}
+		if size > len(data) {
+			size = len(data)
+		}
+		data = data[size:]
+	}
+
+	var seconds float64
+	frames := 0
+	for i := 0; i+4 <= len(data); {
+		length, samples, rate, ok := frameHeader(data[i : i+4])
+		if !ok || i+length > len(data) {
+			// Look for the next frame, past the garbage or the ID3v1 tag
+			i++
+			continue
+		}
+		seconds += float64(samples) / float64(rate)
+		frames++
+		i += length
+	}
+	if frames == 0 {
+		return 0, errors.New("no MP3 frames")
+	}
+	return time.Duration(seconds * float64(time.Second)), nil
+}
+
+// frameHeader returns the length in bytes, the number of samples and
+// the sample rate of the MPEG audio frame starting with the header
+func frameHeader(h []byte) (length, samples, rate int, ok bool) {
+	if h[0] != 0xff || h[1]&0xe0 != 0xe0 {
+		return 0, 0, 0, false
+	}
+	version := int(h[1]>>3) & 3
+	layer := 4 - int(h[1]>>1)&3
+	bitrateIndex := int(h[2] >> 4)
+	rateIndex := int(h[2]>>2) & 3
+	padding := int(h[2]>>1) & 1
+	if version == 1 || layer == 4 || bitrateIndex == 0 || bitrateIndex == 15 || rateIndex == 3 {
+		return 0, 0, 0, false
+	}
+
+	rate = sampleRates[rateIndex]
+	table := layer - 1
+	switch version {
+	case mpeg2:
+		rate /= 2
+	case mpeg25:
+		rate /= 4
+	}
+	if version != mpeg1 {
+		table = 3
+		if layer > 1 {
+			table = 4
+		}
+	}
+	bitrate := bitrates[table][bitrateIndex] * 1000
+
+	switch {
+	case layer == 1:
+		return (12*bitrate/rate + padding) * 4, 384, rate, true
+	case layer == 3 && version != mpeg1:
+		return 72*bitrate/rate + padding, 576, rate, true
+	default:
+		return 144*bitrate/rate + padding, 1152, rate, true
+	}
+}
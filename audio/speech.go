@@ -0,0 +1,121 @@
+/*
+Copyright © 2023 Mikael Schultz <bitcanon@proton.me>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package audio
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// ErrNoSpeech is returned when no speech synthesizer is installed
+var ErrNoSpeech = errors.New("no speech synthesizer found (say, espeak-ng, espeak or festival)")
+
+// Speaker speaks text with the speech synthesizer of the system,
+// or with an external engine
+type Speaker struct {
+	// The voice of the synthesizer (e.g. "Samantha", "en-us"),
+	// its default voice if empty
+	Voice string
+
+	// The command of an external engine used instead of the
+	// synthesizer of the system (e.g. "piper-say --model en.onnx").
+	// The text is written to the standard input of the command
+	Command string
+}
+
+// synthesizers are the speech synthesizers of Linux and the other
+// systems, in the order they are tried, reading the text on their
+// standard input, with the option selecting a voice (none if empty)
+var synthesizers = []struct {
+	command []string
+	voice   string
+}{
+	{command: []string{"espeak-ng", "--stdin"}, voice: "-v"},
+	{command: []string{"espeak", "--stdin"}, voice: "-v"},
+	{command: []string{"festival", "--tts"}},
+}
+
+// windowsSynthesizer is the PowerShell script speaking the standard
+// input with the speech synthesizer of Windows, in the voice given
+// as its argument, if any
+const windowsSynthesizer = `Add-Type -AssemblyName System.Speech; ` +
+	`$s = New-Object System.Speech.Synthesis.SpeechSynthesizer; ` +
+	`if ($args.Count -gt 0) { $s.SelectVoice($args[0]) }; $s.Speak([Console]::In.ReadToEnd())`
+
+// Speak speaks the text and returns once it has been spoken
+// or the context is done
+func (s Speaker) Speak(ctx context.Context, text string) error {
+	command, err := s.speakCommand(runtime.GOOS)
+	if err != nil {
+		return err
+	}
+	slog.Debug("speaking", "text", text, "synthesizer", command[0])
+	cmd := exec.CommandContext(ctx, command[0], command[1:]...)
+	cmd.Stdin = strings.NewReader(text)
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return fmt.Errorf("failed to speak with %s: %w", command[0], err)
+	}
+	return nil
+}
+
+// speakCommand returns the command speaking its standard input on
+// the system: the external engine if there is one, say on macOS,
+// the synthesizer of Windows, or else the first synthesizer installed
+func (s Speaker) speakCommand(goos string) ([]string, error) {
+	if command := strings.Fields(s.Command); len(command) > 0 {
+		return command, nil
+	}
+
+	switch goos {
+	case "darwin":
+		if s.Voice != "" {
+			return []string{"say", "-v", s.Voice}, nil
+		}
+		return []string{"say"}, nil
+	case "windows":
+		command := []string{"powershell", "-NoProfile", "-Command", windowsSynthesizer}
+		if s.Voice != "" {
+			command = append(command, s.Voice)
+		}
+		return command, nil
+	}
+
+	for _, synthesizer := range synthesizers {
+		if _, err := exec.LookPath(synthesizer.command[0]); err != nil {
+			continue
+		}
+		command := append([]string{}, synthesizer.command...)
+		if s.Voice != "" && synthesizer.voice != "" {
+			command = append(command, synthesizer.voice, s.Voice)
+		}
+		return command, nil
+	}
+	return nil, ErrNoSpeech
+}
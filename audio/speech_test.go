@@ -0,0 +1,57 @@
+package audio_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/bitcanon/autotyper/audio"
+)
+
+// writeEngine writes a shell script standing in for a speech engine,
+// saving the text it reads to a file, and returns both paths
+func writeEngine(t *testing.T) (string, string) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("the engine is a shell script")
+	}
+	dir := t.TempDir()
+	engine := filepath.Join(dir, "engine")
+	spoken := filepath.Join(dir, "spoken.txt")
+	if err := os.WriteFile(engine, []byte("#!/bin/sh\ncat > "+spoken+"\n"), 0755); err != nil {
+		t.Fatalf("failed to write the engine: %v", err)
+	}
+	return engine, spoken
+}
+
+// TestSpeakCommand tests that the text is written to the standard
+// input of an external engine
+func TestSpeakCommand(t *testing.T) {
+	engine, spoken := writeEngine(t)
+	speaker := audio.Speaker{Command: engine}
+	if err := speaker.Speak(context.Background(), "Let's list the files"); err != nil {
+		t.Fatalf("expected no error, but got %v", err)
+	}
+	data, err := os.ReadFile(spoken)
+	if err != nil {
+		t.Fatalf("expected the text to be spoken, but got %v", err)
+	}
+	if string(data) != "Let's list the files" {
+		t.Errorf("expected %q, but got %q", "Let's list the files", data)
+	}
+}
+
+// TestSpeakNoSynthesizer tests that ErrNoSpeech is returned when
+// no synthesizer is installed
+func TestSpeakNoSynthesizer(t *testing.T) {
+	if runtime.GOOS == "darwin" || runtime.GOOS == "windows" {
+		t.Skip("the synthesizer of the system is always used")
+	}
+	t.Setenv("PATH", t.TempDir())
+	if err := (audio.Speaker{}).Speak(context.Background(), "hello"); !errors.Is(err, audio.ErrNoSpeech) {
+		t.Errorf("expected ErrNoSpeech, but got %v", err)
+	}
+}
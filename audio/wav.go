@@ -0,0 +1,64 @@
+/*
+Copyright © 2023 Mikael Schultz <bitcanon@proton.me>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package audio
+
+import (
+	"encoding/binary"
+	"errors"
+	"time"
+)
+
+// wavDuration returns the duration of a WAV file: the size of its
+// data chunk divided by the bytes per second of its format chunk
+func wavDuration(data []byte) (time.Duration, error) {
+	if len(data) < 12 || string(data[0:4]) != "RIFF" || string(data[8:12]) != "WAVE" {
+		return 0, errors.New("not a WAV file")
+	}
+	var byteRate uint32
+	for chunk := data[12:]; len(chunk) >= 8; {
+		id, size := string(chunk[0:4]), binary.LittleEndian.Uint32(chunk[4:8])
+		body := chunk[8:]
+		switch id {
+		case "fmt ":
+			if len(body) < 12 {
+				return 0, errors.New("invalid WAV format chunk")
+			}
+			byteRate = binary.LittleEndian.Uint32(body[8:12])
+		case "data":
+			if byteRate == 0 {
+				return 0, errors.New("WAV data before its format")
+			}
+			if uint64(size) > uint64(len(body)) {
+				size = uint32(len(body))
+			}
+			return time.Duration(uint64(size) * uint64(time.Second) / uint64(byteRate)), nil
+		}
+
+		// Chunks are aligned on two bytes
+		next := uint64(size) + uint64(size%2)
+		if next > uint64(len(body)) {
+			break
+		}
+		chunk = body[next:]
+	}
+	return 0, errors.New("no WAV data")
+}
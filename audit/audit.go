@@ -0,0 +1,106 @@
+/*
+Copyright © 2023 Mikael Schultz <bitcanon@proton.me>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package audit
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// Syslog is the target of Open writing the records to the system log
+const Syslog = "syslog"
+
+// Record describes a command executed by a step of a script
+type Record struct {
+	// The time the command was started
+	Time time.Time `json:"time"`
+
+	// The script the command came from and the number of its step,
+	// from 1. The script is empty if it was not read from a file
+	Script string `json:"script,omitempty"`
+	Step   int    `json:"step"`
+
+	// The command, the working directory it ran in and its exit
+	// code, or -1 if it did not run until it exited (see Error)
+	Command  string `json:"command"`
+	Dir      string `json:"cwd"`
+	ExitCode int    `json:"exit_code"`
+	Error    string `json:"error,omitempty"`
+
+	// How long the command ran, in milliseconds
+	Duration int64 `json:"duration_ms"`
+}
+
+// Log appends the records of the executed commands to a writer,
+// one JSON object per line. It is safe for concurrent use
+type Log struct {
+	mu     sync.Mutex
+	w      io.Writer
+	closer io.Closer
+}
+
+// New returns a log writing the records to w
+func New(w io.Writer) *Log {
+	return &Log{w: w}
+}
+
+// Open opens the audit log: the system log if the target is Syslog,
+// or else the file, created if needed and only ever appended to
+func Open(target string) (*Log, error) {
+	if target == Syslog {
+		w, err := openSyslog()
+		if err != nil {
+			return nil, err
+		}
+		return &Log{w: w, closer: w}, nil
+	}
+
+	file, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, err
+	}
+	return &Log{w: file, closer: file}, nil
+}
+
+// Record appends the record to the log, in a single write
+func (l *Log) Record(r Record) error {
+	data, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	_, err = l.w.Write(append(data, '\n'))
+	return err
+}
+
+// Close closes the file or the connection to the system log
+func (l *Log) Close() error {
+	if l.closer == nil {
+		return nil
+	}
+	return l.closer.Close()
+}
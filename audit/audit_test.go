@@ -0,0 +1,64 @@
+package audit_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bitcanon/autotyper/audit"
+)
+
+// TestOpen tests that the records are appended to the audit log
+// file, one JSON object per line, keeping the previous records
+func TestOpen(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "audit.log")
+	if err := os.WriteFile(filename, []byte("previous\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	log, err := audit.Open(filename)
+	if err != nil {
+		t.Fatalf("expected no error, but got %v", err)
+	}
+	r := audit.Record{
+		Time:     time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC),
+		Script:   "demo.txt",
+		Step:     2,
+		Command:  "ls -la",
+		Dir:      "/home/demo",
+		ExitCode: 1,
+		Duration: 12,
+	}
+	if err := log.Record(r); err != nil {
+		t.Fatalf("expected no error, but got %v", err)
+	}
+	if err := log.Close(); err != nil {
+		t.Fatalf("expected no error, but got %v", err)
+	}
+
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "previous\n" + `{"time":"2024-03-01T10:00:00Z","script":"demo.txt","step":2,"command":"ls -la","cwd":"/home/demo","exit_code":1,"duration_ms":12}` + "\n"
+	if string(data) != expected {
+		t.Errorf("expected %q, but got %q", expected, string(data))
+	}
+}
+
+// TestRecord tests that the records are written in a single line,
+// without the empty script and error
+func TestRecord(t *testing.T) {
+	var out strings.Builder
+	log := audit.New(&out)
+	if err := log.Record(audit.Record{Step: 1, Command: "echo hi", ExitCode: -1, Error: "exec: not found"}); err != nil {
+		t.Fatalf("expected no error, but got %v", err)
+	}
+
+	expected := `{"time":"0001-01-01T00:00:00Z","step":1,"command":"echo hi","cwd":"","exit_code":-1,"error":"exec: not found","duration_ms":0}` + "\n"
+	if out.String() != expected {
+		t.Errorf("expected %q, but got %q", expected, out.String())
+	}
+}
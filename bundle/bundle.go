@@ -0,0 +1,387 @@
+/*
+Copyright © 2023 Mikael Schultz <bitcanon@proton.me>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package bundle
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"filippo.io/age"
+	"github.com/bitcanon/autotyper/cli"
+	"github.com/bitcanon/autotyper/script"
+	"github.com/bitcanon/autotyper/simulate"
+)
+
+// Extension is the file extension of the bundles
+const Extension = ".atd"
+
+// Version is the version of the format of the bundles written.
+// Bundles of a later version cannot be read
+const Version = 1
+
+// The names of the files describing the bundle in the archive
+const (
+	manifestName = "manifest.json"
+	scenarioName = "scenario.json"
+)
+
+// Bundle is a self-contained demo: a scenario with the recorded output
+// of its commands, the colors of the theme, and the files it needs to
+// be played anywhere (the external simulators, the hooks and the output
+// filters). A bundle is a zip archive with the manifest, the scenario
+// as JSON, and the files by their path in the directory of the scenario
+type Bundle struct {
+	Scenario *script.Scenario
+
+	// The colors of the theme by name (e.g. "username": "green")
+	Theme map[string]string
+
+	// The paths of the hooks and of the output filters in the files
+	Hooks   string
+	Filters []string
+
+	// The contents of the files by slash-separated path
+	Files map[string][]byte
+
+	// The time the bundle was created
+	Created time.Time
+}
+
+// manifest describes the bundle in the archive
+type manifest struct {
+	Version int               `json:"version"`
+	Created time.Time         `json:"created"`
+	Theme   map[string]string `json:"theme,omitempty"`
+	Hooks   string            `json:"hooks,omitempty"`
+	Filters []string          `json:"filters,omitempty"`
+
+	// The iterations of the steps expanded from repeat blocks, by
+	// index, which are not part of the scenario
+	Iterations map[int]int `json:"iterations,omitempty"`
+}
+
+// New returns a bundle of the scenario, with the files of the external
+// simulators found in the directory of the scenario. The files are the
+// arguments of the commands of the simulators naming a file
+func New(s *script.Scenario) (*Bundle, error) {
+	b := &Bundle{Scenario: s, Theme: map[string]string{}, Files: map[string][]byte{}, Created: time.Now()}
+	for name, command := range s.Simulators {
+		for _, arg := range strings.Fields(command) {
+			if !filepath.IsLocal(arg) {
+				continue
+			}
+			data, err := os.ReadFile(filepath.Join(s.Dir, arg))
+			if err != nil {
+				if strings.HasSuffix(arg, ".wasm") {
+					return nil, fmt.Errorf("simulator for %s: %w", name, err)
+				}
+				continue
+			}
+			if err := b.AddFile(filepath.ToSlash(arg), data); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return b, nil
+}
+
+// AddFile adds a file to the bundle. The same path cannot be added
+// twice with different contents, and the names of the manifest and
+// the scenario are reserved
+func (b *Bundle) AddFile(name string, data []byte) error {
+	if !validName(name) {
+		return fmt.Errorf("invalid file name %q in bundle", name)
+	}
+	if name == manifestName || name == scenarioName {
+		return fmt.Errorf("file name %s is reserved in bundles", name)
+	}
+	if old, ok := b.Files[name]; ok && !bytes.Equal(old, data) {
+		return fmt.Errorf("file %s added twice to the bundle", name)
+	}
+	slog.Debug("adding file to bundle", "file", name, "bytes", len(data))
+	b.Files[name] = data
+	return nil
+}
+
+// AddHooks adds the file of the JavaScript hooks to the bundle
+func (b *Bundle) AddHooks(filename string) error {
+	name, err := b.addAsset("hooks", filename)
+	if err != nil {
+		return err
+	}
+	b.Hooks = name
+	return nil
+}
+
+// AddFilter adds the WebAssembly module of an output filter
+// to the bundle, after the filters already added
+func (b *Bundle) AddFilter(filename string) error {
+	name, err := b.addAsset("filters", filename)
+	if err != nil {
+		return err
+	}
+	b.Filters = append(b.Filters, name)
+	return nil
+}
+
+// addAsset adds the file in the directory, and returns its name
+func (b *Bundle) addAsset(dir, filename string) (string, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return "", err
+	}
+	name := path.Join(dir, filepath.Base(filename))
+	return name, b.AddFile(name, data)
+}
+
+// Record executes the commands of the steps without an output, and
+// records their output as the canned output of the steps, so that
+// nothing is executed when the bundle is played. Simulated, Lua and
+// interactive steps, and commands with variables, are not recorded.
+// The commands run in one shell, starting with the prompt, whose
+// builtins are interpreted and left for the player to interpret again
+func Record(s *script.Scenario, prompt cli.Prompt, run func(shell *cli.Shell, command string) (string, error)) error {
+	shell := &cli.Shell{}
+	for i := range s.Steps {
+		step := &s.Steps[i]
+		if !recordable(*step) {
+			continue
+		}
+		command := shell.Expand(cli.StripReadings(step.Command))
+		if cli.CommandPolicy.Check(command) == nil {
+			if builtin, err := shell.Builtin(command, &prompt, io.Discard); builtin {
+				if err != nil {
+					return fmt.Errorf("step %d: %w", i+1, err)
+				}
+				continue
+			}
+		}
+		output, err := run(shell, command)
+		if err != nil {
+			return fmt.Errorf("step %d: %w", i+1, err)
+		}
+		slog.Debug("recorded output", "step", i+1, "command", step.Command, "bytes", len(output))
+
+		// An empty output would execute the command,
+		// so the template prints nothing instead
+		step.Output = simulate.EscapeOutput(output)
+		if output == "" {
+			step.Output = "{{/* no output */}}"
+		}
+	}
+	return nil
+}
+
+// recordable reports whether the output of the step can be recorded
+func recordable(step script.Step) bool {
+	if step.Command == "" || step.Output != "" || step.Simulate != "" || step.Lua != "" {
+		return false
+	}
+	if len(step.Input) > 0 || len(step.Expect) > 0 {
+		return false
+	}
+	return !strings.Contains(step.Command, "${")
+}
+
+// Write writes the bundle as a zip archive
+func (b *Bundle) Write(w io.Writer) error {
+	m := manifest{Version: Version, Created: b.Created, Theme: b.Theme, Hooks: b.Hooks, Filters: b.Filters}
+	for i, step := range b.Scenario.Steps {
+		if step.Iteration > 0 {
+			if m.Iterations == nil {
+				m.Iterations = map[int]int{}
+			}
+			m.Iterations[i] = step.Iteration
+		}
+	}
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	scenario, err := json.MarshalIndent(b.Scenario, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	archive := zip.NewWriter(w)
+	files := map[string][]byte{manifestName: data, scenarioName: scenario}
+	for _, name := range append([]string{manifestName, scenarioName}, sortedNames(b.Files)...) {
+		contents, ok := files[name]
+		if !ok {
+			contents = b.Files[name]
+		}
+		f, err := archive.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: b.Created})
+		if err != nil {
+			return err
+		}
+		if _, err := f.Write(contents); err != nil {
+			return err
+		}
+	}
+	return archive.Close()
+}
+
+// Save writes the bundle to the file. If there are recipients, the
+// bundle is encrypted so that only they can read it
+func (b *Bundle) Save(filename string, recipients ...age.Recipient) error {
+	var buf bytes.Buffer
+	if err := b.Write(&buf); err != nil {
+		return err
+	}
+	data := buf.Bytes()
+	if len(recipients) > 0 {
+		var err error
+		if data, err = encrypt(data, recipients); err != nil {
+			return err
+		}
+	}
+	return os.WriteFile(filename, data, 0644)
+}
+
+// Read reads a bundle from the contents of a zip archive. Encrypted
+// bundles are decrypted with the identities, or ErrEncrypted is
+// returned if there are none
+func Read(data []byte, identities ...age.Identity) (*Bundle, error) {
+	if IsEncrypted(data) {
+		var err error
+		if data, err = decrypt(data, identities); err != nil {
+			return nil, err
+		}
+	}
+
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("invalid bundle: %w", err)
+	}
+
+	files := map[string][]byte{}
+	for _, f := range archive.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		if !validName(f.Name) {
+			return nil, fmt.Errorf("invalid bundle: invalid file name %q", f.Name)
+		}
+		r, err := f.Open()
+		if err != nil {
+			return nil, fmt.Errorf("invalid bundle: %w", err)
+		}
+		files[f.Name], err = io.ReadAll(r)
+		r.Close()
+		if err != nil {
+			return nil, fmt.Errorf("invalid bundle: %s: %w", f.Name, err)
+		}
+	}
+
+	var m manifest
+	if err := json.Unmarshal(files[manifestName], &m); err != nil {
+		return nil, fmt.Errorf("invalid bundle: %s: %w", manifestName, err)
+	}
+	if m.Version < 1 || m.Version > Version {
+		return nil, fmt.Errorf("unsupported bundle version %d (expected at most %d)", m.Version, Version)
+	}
+	s, err := script.ParseJSON(files[scenarioName])
+	if err != nil {
+		return nil, fmt.Errorf("invalid bundle: %w", err)
+	}
+	for i, iteration := range m.Iterations {
+		if i < 0 || i >= len(s.Steps) {
+			return nil, fmt.Errorf("invalid bundle: %s: no step %d", manifestName, i+1)
+		}
+		s.Steps[i].Iteration = iteration
+	}
+	delete(files, manifestName)
+	delete(files, scenarioName)
+
+	b := &Bundle{Scenario: s, Theme: m.Theme, Hooks: m.Hooks, Filters: m.Filters, Files: files, Created: m.Created}
+	if b.Theme == nil {
+		b.Theme = map[string]string{}
+	}
+	for _, name := range append([]string{b.Hooks}, b.Filters...) {
+		if _, ok := files[name]; name != "" && !ok {
+			return nil, fmt.Errorf("invalid bundle: missing file %s", name)
+		}
+	}
+	return b, nil
+}
+
+// Load reads a bundle from the file, see Read
+func Load(filename string, identities ...age.Identity) (*Bundle, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	b, err := Read(data, identities...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", filename, err)
+	}
+	slog.Debug("loaded bundle", "file", filename, "steps", len(b.Scenario.Steps), "files", len(b.Files))
+	return b, nil
+}
+
+// Extract writes the files of the bundle to the directory, where the
+// external simulators of the scenario run
+func (b *Bundle) Extract(dir string) error {
+	for name, data := range b.Files {
+		filename := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(filename, data, 0644); err != nil {
+			return err
+		}
+	}
+	b.Scenario.Dir = dir
+	return nil
+}
+
+// IsBundle reports whether the file is a bundle, by its extension
+func IsBundle(filename string) bool {
+	return strings.EqualFold(filepath.Ext(filename), Extension)
+}
+
+// validName reports whether the name is a slash-separated
+// path inside the bundle, on any operating system
+func validName(name string) bool {
+	return fs.ValidPath(name) && name != "." && !strings.ContainsAny(name, `\:`)
+}
+
+// sortedNames returns the names of the files in order
+func sortedNames(files map[string][]byte) []string {
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,201 @@
+package bundle_test
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/bitcanon/autotyper/bundle"
+	"github.com/bitcanon/autotyper/cli"
+	"github.com/bitcanon/autotyper/script"
+)
+
+// TestBundle tests that a bundle is read back with its scenario,
+// theme and files, and that the files are extracted for playing
+func TestBundle(t *testing.T) {
+	dir := t.TempDir()
+	for name, data := range map[string]string{
+		"sim/kubectl.py":   "print('pods')\n",
+		"sim/helm.wasm":    "\x00asm",
+		"hooks/demo.js":    "function onStepEnd(step) {}\n",
+		"filters/up.wasm":  "\x00asm up",
+		"unrelated/a.json": "{}",
+	} {
+		filename := filepath.Join(dir, filepath.FromSlash(name))
+		os.MkdirAll(filepath.Dir(filename), 0755)
+		if err := os.WriteFile(filename, []byte(data), 0644); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+	}
+
+	s := &script.Scenario{
+		Simulators: map[string]string{"kubectl": "python3 sim/kubectl.py", "helm": "sim/helm.wasm"},
+		Steps:      []script.Step{{Simulate: "kubectl get pods"}, {Command: "ls", Output: "a b\n", Iteration: 2}},
+		Dir:        dir,
+	}
+	b, err := bundle.New(s)
+	if err != nil {
+		t.Fatalf("expected no error, but got %v", err)
+	}
+	b.Theme["username"] = "green"
+	if err := b.AddHooks(filepath.Join(dir, "hooks", "demo.js")); err != nil {
+		t.Fatalf("expected no error, but got %v", err)
+	}
+	if err := b.AddFilter(filepath.Join(dir, "filters", "up.wasm")); err != nil {
+		t.Fatalf("expected no error, but got %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := b.Write(&buf); err != nil {
+		t.Fatalf("expected no error, but got %v", err)
+	}
+	read, err := bundle.Read(buf.Bytes())
+	if err != nil {
+		t.Fatalf("expected no error, but got %v", err)
+	}
+	if len(read.Scenario.Steps) != 2 || read.Scenario.Steps[1].Output != "a b\n" || read.Scenario.Steps[1].Iteration != 2 || read.Scenario.Simulators["kubectl"] != "python3 sim/kubectl.py" {
+		t.Errorf("expected the scenario, but got %+v", read.Scenario)
+	}
+	if read.Theme["username"] != "green" || read.Hooks != "hooks/demo.js" || len(read.Filters) != 1 || read.Filters[0] != "filters/up.wasm" {
+		t.Errorf("expected the theme, the hooks and the filters, but got %+v", read)
+	}
+	if len(read.Files) != 4 {
+		t.Errorf("expected 4 files, but got %d", len(read.Files))
+	}
+
+	extracted := t.TempDir()
+	if err := read.Extract(extracted); err != nil {
+		t.Fatalf("expected no error, but got %v", err)
+	}
+	if read.Scenario.Dir != extracted {
+		t.Errorf("expected the directory of the scenario %q, but got %q", extracted, read.Scenario.Dir)
+	}
+	data, err := os.ReadFile(filepath.Join(extracted, "sim", "kubectl.py"))
+	if err != nil || string(data) != "print('pods')\n" {
+		t.Errorf("expected the simulator to be extracted, but got %q (%v)", data, err)
+	}
+}
+
+// TestBundleMissingSimulator tests that the WebAssembly
+// simulators must be found in the directory of the scenario
+func TestBundleMissingSimulator(t *testing.T) {
+	s := &script.Scenario{Simulators: map[string]string{"helm": "sim/helm.wasm"}, Dir: t.TempDir()}
+	if _, err := bundle.New(s); err == nil {
+		t.Errorf("expected error, but got nil")
+	}
+}
+
+// TestAddFile tests that files outside of the bundle
+// and reserved names are rejected
+func TestAddFile(t *testing.T) {
+	tests := []struct {
+		name    string
+		wantErr bool
+	}{
+		{name: "sim/kubectl.py"},
+		{name: "../secret", wantErr: true},
+		{name: "/etc/passwd", wantErr: true},
+		{name: `sim\kubectl.py`, wantErr: true},
+		{name: "C:/data", wantErr: true},
+		{name: "manifest.json", wantErr: true},
+		{name: "scenario.json", wantErr: true},
+	}
+
+	for _, test := range tests {
+		b, err := bundle.New(&script.Scenario{})
+		if err != nil {
+			t.Fatalf("expected no error, but got %v", err)
+		}
+		err = b.AddFile(test.name, []byte("data"))
+		if test.wantErr && err == nil {
+			t.Errorf("%s: expected error, but got nil", test.name)
+		}
+		if !test.wantErr && err != nil {
+			t.Errorf("%s: expected no error, but got %v", test.name, err)
+		}
+	}
+}
+
+// TestRead tests that invalid bundles are rejected
+func TestRead(t *testing.T) {
+	if _, err := bundle.Read([]byte("not a zip archive")); err == nil {
+		t.Errorf("expected error for an invalid archive, but got nil")
+	}
+	if _, err := bundle.Read(nil); err == nil {
+		t.Errorf("expected error for an empty archive, but got nil")
+	}
+}
+
+// TestRecord tests that the output of the commands is recorded as
+// canned output printed as is, and that other steps are left alone
+func TestRecord(t *testing.T) {
+	s := &script.Scenario{Steps: []script.Step{
+		{Command: "echo {{.Step}}"},
+		{Command: "true"},
+		{Command: "ls", Output: "canned\n"},
+		{Command: "echo ${name}"},
+		{Simulate: "ping ::1"},
+		{Command: "rm -i x", Input: []script.Input{{Text: "y\n"}}},
+	}}
+	var run []string
+	err := bundle.Record(s, cli.Prompt{}, func(shell *cli.Shell, command string) (string, error) {
+		run = append(run, command)
+		if command == "true" {
+			return "", nil
+		}
+		return strings.TrimPrefix(command, "echo ") + "\n", nil
+	})
+	if err != nil {
+		t.Fatalf("expected no error, but got %v", err)
+	}
+	if strings.Join(run, ",") != "echo {{.Step}},true" {
+		t.Errorf("expected the commands to be recorded, but got %q", run)
+	}
+	expected := []string{`{{"{{"}}.Step}}` + "\n", "{{/* no output */}}", "canned\n", "", "", ""}
+	for i, output := range expected {
+		if s.Steps[i].Output != output {
+			t.Errorf("step %d: expected the output %q, but got %q", i+1, output, s.Steps[i].Output)
+		}
+	}
+
+	failed := errors.New("failed")
+	err = bundle.Record(&script.Scenario{Steps: []script.Step{{Command: "ls"}}}, cli.Prompt{}, func(*cli.Shell, string) (string, error) { return "", failed })
+	if !errors.Is(err, failed) {
+		t.Errorf("expected the error of the command, but got %v", err)
+	}
+}
+
+// TestRecordShell tests that the commands are recorded in one shell,
+// whose builtins are interpreted and left unrecorded
+func TestRecordShell(t *testing.T) {
+	dir := t.TempDir()
+	s := &script.Scenario{Steps: []script.Step{
+		{Command: "cd " + dir},
+		{Command: "export GREETING=hello"},
+		{Command: "alias l=ls"},
+		{Command: "l -a"},
+	}}
+	var run []string
+	err := bundle.Record(s, cli.Prompt{Shell: cli.Bash}, func(shell *cli.Shell, command string) (string, error) {
+		run = append(run, command)
+		if shell.Dir != dir || !slices.Contains(shell.Env, "GREETING=hello") {
+			t.Errorf("expected the directory and the environment of the builtins, but got %q and %q", shell.Dir, shell.Env)
+		}
+		return ".\n", nil
+	})
+	if err != nil {
+		t.Fatalf("expected no error, but got %v", err)
+	}
+	if strings.Join(run, ",") != "ls -a" {
+		t.Errorf("expected only the aliased command to be recorded, but got %q", run)
+	}
+	for i, step := range s.Steps[:3] {
+		if step.Output != "" {
+			t.Errorf("step %d: expected the builtin not to be recorded, but got %q", i+1, step.Output)
+		}
+	}
+}
@@ -0,0 +1,130 @@
+/*
+Copyright © 2023 Mikael Schultz <bitcanon@proton.me>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package bundle
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"filippo.io/age"
+)
+
+// ErrEncrypted is returned when an encrypted bundle is read without
+// the password or the identities it is encrypted for
+var ErrEncrypted = errors.New("the bundle is encrypted")
+
+// encryptedPrefix starts the header of files encrypted with age
+const encryptedPrefix = "age-encryption.org/"
+
+// IsEncrypted reports whether the contents of the bundle are encrypted
+func IsEncrypted(data []byte) bool {
+	return bytes.HasPrefix(data, []byte(encryptedPrefix))
+}
+
+// PasswordRecipient returns the recipient encrypting bundles with
+// the password. A password cannot be used with other recipients
+func PasswordRecipient(password string) (age.Recipient, error) {
+	return age.NewScryptRecipient(password)
+}
+
+// PasswordIdentity returns the identity decrypting
+// the bundles encrypted with the password
+func PasswordIdentity(password string) (age.Identity, error) {
+	return age.NewScryptIdentity(password)
+}
+
+// ParseRecipients parses the age public keys (e.g. "age1...")
+// of the people the bundles are encrypted for
+func ParseRecipients(keys []string) ([]age.Recipient, error) {
+	var recipients []age.Recipient
+	for _, key := range keys {
+		r, err := age.ParseX25519Recipient(key)
+		if err != nil {
+			return nil, err
+		}
+		recipients = append(recipients, r)
+	}
+	return recipients, nil
+}
+
+// LoadRecipients reads the age public keys in the file, one per
+// line, with comments starting with "#"
+func LoadRecipients(filename string) ([]age.Recipient, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	recipients, err := age.ParseRecipients(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", filename, err)
+	}
+	return recipients, nil
+}
+
+// LoadIdentities reads the age identities (e.g. "AGE-SECRET-KEY-1...")
+// in the file, one per line, like the files of age-keygen
+func LoadIdentities(filename string) ([]age.Identity, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	identities, err := age.ParseIdentities(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", filename, err)
+	}
+	return identities, nil
+}
+
+// encrypt encrypts the contents of the bundle for the recipients
+func encrypt(data []byte, recipients []age.Recipient) ([]byte, error) {
+	var buf bytes.Buffer
+	w, err := age.Encrypt(&buf, recipients...)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(data); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// decrypt decrypts the contents of the bundle with the identities
+func decrypt(data []byte, identities []age.Identity) ([]byte, error) {
+	if len(identities) == 0 {
+		return nil, ErrEncrypted
+	}
+	r, err := age.Decrypt(bytes.NewReader(data), identities...)
+	if err != nil {
+		return nil, fmt.Errorf("cannot decrypt the bundle: %w", err)
+	}
+	return io.ReadAll(r)
+}
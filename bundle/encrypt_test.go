@@ -0,0 +1,98 @@
+package bundle_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"filippo.io/age"
+	"github.com/bitcanon/autotyper/bundle"
+	"github.com/bitcanon/autotyper/script"
+)
+
+// TestEncryptedBundle tests that bundles encrypted with a password or
+// for public keys are read back only with the password or the keys
+func TestEncryptedBundle(t *testing.T) {
+	key, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatalf("failed to generate a key: %v", err)
+	}
+	dir := t.TempDir()
+	keys := filepath.Join(dir, "keys.txt")
+	recipients := filepath.Join(dir, "recipients.txt")
+	os.WriteFile(keys, []byte("# created: now\n"+key.String()+"\n"), 0600)
+	os.WriteFile(recipients, []byte("# field engineers\n"+key.Recipient().String()+"\n"), 0644)
+
+	password, err := bundle.PasswordRecipient("s3cret")
+	if err != nil {
+		t.Fatalf("expected no error, but got %v", err)
+	}
+	public, err := bundle.LoadRecipients(recipients)
+	if err != nil {
+		t.Fatalf("expected no error, but got %v", err)
+	}
+	identities, err := bundle.LoadIdentities(keys)
+	if err != nil {
+		t.Fatalf("expected no error, but got %v", err)
+	}
+	right, _ := bundle.PasswordIdentity("s3cret")
+	wrong, _ := bundle.PasswordIdentity("guess")
+
+	tests := []struct {
+		name       string
+		recipients []age.Recipient
+		identities []age.Identity
+	}{
+		{name: "Password", recipients: []age.Recipient{password}, identities: []age.Identity{right}},
+		{name: "PublicKeys", recipients: public, identities: identities},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			b, err := bundle.New(&script.Scenario{Steps: []script.Step{{Command: "kubectl get secrets", Output: "redacted\n"}}})
+			if err != nil {
+				t.Fatalf("expected no error, but got %v", err)
+			}
+			filename := filepath.Join(t.TempDir(), "demo.atd")
+			if err := b.Save(filename, test.recipients...); err != nil {
+				t.Fatalf("expected no error, but got %v", err)
+			}
+			data, err := os.ReadFile(filename)
+			if err != nil {
+				t.Fatalf("failed to read the bundle: %v", err)
+			}
+			if !bundle.IsEncrypted(data) {
+				t.Fatalf("expected the bundle to be encrypted")
+			}
+
+			if _, err := bundle.Load(filename); !errors.Is(err, bundle.ErrEncrypted) {
+				t.Errorf("expected ErrEncrypted without identities, but got %v", err)
+			}
+			if _, err := bundle.Load(filename, wrong); err == nil {
+				t.Errorf("expected error with the wrong password, but got nil")
+			}
+			read, err := bundle.Load(filename, test.identities...)
+			if err != nil {
+				t.Fatalf("expected no error, but got %v", err)
+			}
+			if read.Scenario.Steps[0].Output != "redacted\n" {
+				t.Errorf("expected the scenario, but got %+v", read.Scenario)
+			}
+		})
+	}
+}
+
+// TestParseRecipients tests that only age public keys are recipients
+func TestParseRecipients(t *testing.T) {
+	key, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatalf("failed to generate a key: %v", err)
+	}
+	if recipients, err := bundle.ParseRecipients([]string{key.Recipient().String()}); err != nil || len(recipients) != 1 {
+		t.Errorf("expected a recipient, but got %v (%v)", recipients, err)
+	}
+	if _, err := bundle.ParseRecipients([]string{"ssh-ed25519 AAAA"}); err == nil {
+		t.Errorf("expected error for an invalid key, but got nil")
+	}
+}
@@ -0,0 +1,171 @@
+/*
+Copyright © 2023 Mikael Schultz <bitcanon@proton.me>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/bitcanon/autotyper/cli"
+)
+
+// Entry is the output of a command cached by a previous run
+type Entry struct {
+	// The command and the working directory it ran in
+	Command string `json:"command"`
+	Dir     string `json:"cwd"`
+
+	// The chunks of the output, as they were written, and the
+	// delay in milliseconds from the last chunk to the exit
+	Chunks []Chunk `json:"chunks"`
+	Tail   int     `json:"tail"`
+
+	// The exit code of the command, and the error it failed
+	// with, if it did not succeed
+	ExitCode int    `json:"exit_code"`
+	Error    string `json:"error,omitempty"`
+}
+
+// Chunk is a chunk of the output, written after a delay in
+// milliseconds since the previous chunk
+type Chunk struct {
+	Delay int    `json:"delay"`
+	Text  string `json:"text"`
+}
+
+// Cache stores the output of the commands in a directory, keyed by
+// the command and the working directory, so a demo rendered again
+// replays the output instead of running slow commands again
+type Cache struct {
+	dir string
+}
+
+// New returns a cache storing the output in the directory
+func New(dir string) *Cache {
+	return &Cache{dir: dir}
+}
+
+// Lookup returns the cached output of the command run in the
+// directory. The second return value reports whether there is one
+func (c *Cache) Lookup(command, dir string) (*Entry, bool) {
+	data, err := os.ReadFile(c.filename(command, dir))
+	if err != nil {
+		return nil, false
+	}
+	var e Entry
+	if err := json.Unmarshal(data, &e); err != nil || e.Command != command || e.Dir != dir {
+		return nil, false
+	}
+	return &e, true
+}
+
+// Record returns a recording of the output of the command running in
+// the directory, which is cached once it is saved. The delays of the
+// output are measured on the clock
+func (c *Cache) Record(command, dir string, clock cli.Clock) *Recording {
+	return &Recording{
+		cache: c,
+		clock: clock,
+		entry: Entry{Command: command, Dir: dir},
+		last:  clock.Now(),
+	}
+}
+
+// filename returns the file of the output of the command
+func (c *Cache) filename(command, dir string) string {
+	sum := sha256.Sum256([]byte(dir + "\x00" + command))
+	return filepath.Join(c.dir, hex.EncodeToString(sum[:])+".json")
+}
+
+// Recording records the output of a command as it is written.
+// It is safe for concurrent use
+type Recording struct {
+	mu    sync.Mutex
+	cache *Cache
+	clock cli.Clock
+	entry Entry
+	last  time.Time
+}
+
+// Write records a chunk of the output
+func (r *Recording) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := r.clock.Now()
+	delay := int(now.Sub(r.last) / time.Millisecond)
+	r.last = now
+	r.entry.Chunks = append(r.entry.Chunks, Chunk{Delay: delay, Text: string(p)})
+	return len(p), nil
+}
+
+// Save caches the output recorded, with the exit code and
+// the error of the command
+func (r *Recording) Save(exitCode int, err error) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.entry.Tail = int(r.clock.Now().Sub(r.last) / time.Millisecond)
+	r.entry.ExitCode = exitCode
+	if err != nil {
+		r.entry.Error = err.Error()
+	}
+	data, err := json.Marshal(r.entry)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(r.cache.dir, 0o700); err != nil {
+		return err
+	}
+
+	// Write the entry beside its file first, so an interrupted
+	// run does not leave half an entry
+	filename := r.cache.filename(r.entry.Command, r.entry.Dir)
+	tmp := filename + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, filename)
+}
+
+// Replay writes the cached output to out, each chunk after its delay,
+// and returns once the command exited in the run it was cached from.
+// The delays are waited for with sleep, which returns an error if the
+// replay is to stop
+func (e *Entry) Replay(ctx context.Context, out io.Writer, sleep func(ctx context.Context, ms int) error) error {
+	for _, chunk := range e.Chunks {
+		if err := sleep(ctx, chunk.Delay); err != nil {
+			return err
+		}
+		if _, err := io.WriteString(out, chunk.Text); err != nil {
+			return err
+		}
+	}
+	return sleep(ctx, e.Tail)
+}
@@ -0,0 +1,70 @@
+package cache_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bitcanon/autotyper/cache"
+	"github.com/bitcanon/autotyper/cli"
+)
+
+// TestCache tests that the recorded output is looked up by the
+// command and the directory, and replayed with its delays
+func TestCache(t *testing.T) {
+	clock := cli.NewFakeClock(time.Now())
+
+	c := cache.New(t.TempDir())
+	if _, ok := c.Lookup("make", "/src"); ok {
+		t.Fatalf("expected no entry in an empty cache")
+	}
+
+	recording := c.Record("make", "/src", clock)
+	clock.Sleep(100 * time.Millisecond)
+	recording.Write([]byte("compiling\n"))
+	clock.Sleep(250 * time.Millisecond)
+	recording.Write([]byte("linking\n"))
+	clock.Sleep(50 * time.Millisecond)
+	if err := recording.Save(2, errors.New("exit status 2")); err != nil {
+		t.Fatalf("expected no error, but got %v", err)
+	}
+
+	tests := []struct {
+		command string
+		dir     string
+		found   bool
+	}{
+		{command: "make", dir: "/src", found: true},
+		{command: "make", dir: "/other", found: false},
+		{command: "make test", dir: "/src", found: false},
+	}
+	for _, test := range tests {
+		if _, ok := c.Lookup(test.command, test.dir); ok != test.found {
+			t.Errorf("%q in %s: expected found %v, but got %v", test.command, test.dir, test.found, ok)
+		}
+	}
+
+	entry, _ := c.Lookup("make", "/src")
+	if entry.ExitCode != 2 || entry.Error != "exit status 2" {
+		t.Errorf("expected exit code 2 and its error, but got %d and %q", entry.ExitCode, entry.Error)
+	}
+
+	var out strings.Builder
+	var delays []int
+	sleep := func(ctx context.Context, ms int) error {
+		delays = append(delays, ms)
+		return nil
+	}
+	if err := entry.Replay(context.Background(), &out, sleep); err != nil {
+		t.Fatalf("expected no error, but got %v", err)
+	}
+	if out.String() != "compiling\nlinking\n" {
+		t.Errorf("expected the recorded output, but got %q", out.String())
+	}
+	if expected := []int{100, 250, 50}; !slices.Equal(delays, expected) {
+		t.Errorf("expected the delays %v, but got %v", expected, delays)
+	}
+}
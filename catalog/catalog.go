@@ -0,0 +1,178 @@
+/*
+Copyright © 2023 Mikael Schultz <bitcanon@proton.me>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package catalog
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/bitcanon/autotyper/locale"
+	"gopkg.in/yaml.v3"
+)
+
+// Entry is a scenario of a catalog
+type Entry struct {
+	// The name the scenario is played with (e.g. "k8s-intro")
+	Name string `yaml:"name"`
+
+	// The description of the scenario, on one line
+	Description string `yaml:"description,omitempty"`
+
+	// How long the scenario takes to play (e.g. "2m30s")
+	Duration time.Duration `yaml:"duration,omitempty"`
+
+	// The tags the scenarios are filtered by (e.g. "k8s")
+	Tags []string `yaml:"tags,omitempty"`
+
+	// The script of the scenario: a URL, a script in a git
+	// repository, or a file relative to the index
+	Script string `yaml:"script"`
+}
+
+// HasTag reports whether the entry has the tag
+func (e Entry) HasTag(tag string) bool {
+	for _, t := range e.Tags {
+		if strings.EqualFold(t, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// Index is the list of scenarios maintained by a team, usually
+// served over HTTPS or kept in a git repository
+type Index struct {
+	Scenarios []Entry `yaml:"scenarios"`
+}
+
+// Parse parses a YAML (or JSON) index read from the source, the URL or
+// the file of the index. The scripts relative to the index are resolved
+// against the source
+func Parse(data []byte, source string) (*Index, error) {
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+
+	var index Index
+	if err := decoder.Decode(&index); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("invalid index %s: %w", source, err)
+	}
+
+	names := make(map[string]bool)
+	for i, entry := range index.Scenarios {
+		switch {
+		case entry.Name == "":
+			return nil, fmt.Errorf("invalid index %s: scenario %d has no name", source, i+1)
+		case strings.ContainsAny(entry.Name, " \t\n"):
+			return nil, fmt.Errorf("invalid index %s: scenario name %q has spaces", source, entry.Name)
+		case names[entry.Name]:
+			return nil, fmt.Errorf("invalid index %s: duplicate scenario %s", source, entry.Name)
+		case entry.Script == "":
+			return nil, fmt.Errorf("invalid index %s: scenario %s has no script", source, entry.Name)
+		}
+		names[entry.Name] = true
+		index.Scenarios[i].Script = resolve(source, entry.Script)
+	}
+	return &index, nil
+}
+
+// resolve returns the script relative to the source of the index.
+// Absolute URLs, absolute files and scripts in git repositories
+// (with a double slash) are returned as they are
+func resolve(source, script string) string {
+	if u, err := url.Parse(script); err == nil && u.IsAbs() {
+		return script
+	}
+	if strings.Contains(script, "//") || filepath.IsAbs(script) {
+		return script
+	}
+
+	if base, err := url.Parse(source); err == nil && base.IsAbs() && base.Host != "" {
+		ref, err := url.Parse(script)
+		if err != nil {
+			return script
+		}
+		return base.ResolveReference(ref).String()
+	}
+	return filepath.Join(filepath.Dir(source), filepath.FromSlash(script))
+}
+
+// Catalog is the scenarios of several indexes. Scenarios of the
+// earlier indexes take precedence over scenarios with the same name
+type Catalog struct {
+	Entries []Entry
+}
+
+// Add adds the scenarios of the index not already in the catalog
+func (c *Catalog) Add(index *Index) {
+	for _, entry := range index.Scenarios {
+		if _, ok := c.Find(entry.Name); !ok {
+			c.Entries = append(c.Entries, entry)
+		}
+	}
+}
+
+// Find returns the scenario with the name. The second return
+// value reports whether the scenario is in the catalog
+func (c *Catalog) Find(name string) (Entry, bool) {
+	for _, entry := range c.Entries {
+		if entry.Name == name {
+			return entry, true
+		}
+	}
+	return Entry{}, false
+}
+
+// Filter returns the scenarios with all of the tags
+func (c *Catalog) Filter(tags ...string) []Entry {
+	var entries []Entry
+	for _, entry := range c.Entries {
+		matched := true
+		for _, tag := range tags {
+			matched = matched && entry.HasTag(tag)
+		}
+		if matched {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+// Print writes the scenarios as a table, one row per scenario
+func Print(out io.Writer, entries []Entry) error {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", locale.T("NAME"), locale.T("DURATION"), locale.T("TAGS"), locale.T("DESCRIPTION"))
+	for _, entry := range entries {
+		duration := "-"
+		if entry.Duration > 0 {
+			duration = entry.Duration.String()
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", entry.Name, duration, strings.Join(entry.Tags, ","), entry.Description)
+	}
+	return w.Flush()
+}
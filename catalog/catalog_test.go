@@ -0,0 +1,118 @@
+package catalog_test
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bitcanon/autotyper/catalog"
+)
+
+// TestParse tests that indexes are parsed, that the scripts are
+// resolved against the source, and that invalid indexes are refused
+func TestParse(t *testing.T) {
+	data := []byte(`scenarios:
+  - name: k8s-intro
+    description: Deploy an app to Kubernetes
+    duration: 2m30s
+    tags: [k8s, intro]
+    script: k8s/intro.yaml
+  - name: git-basics
+    script: github.com/org/demos//git-basics
+  - name: tls
+    script: https://cdn.example.com/tls.yaml
+`)
+
+	tests := []struct {
+		source   string
+		expected []string
+	}{
+		{source: "https://example.com/demos/index.yaml", expected: []string{"https://example.com/demos/k8s/intro.yaml", "github.com/org/demos//git-basics", "https://cdn.example.com/tls.yaml"}},
+		{source: filepath.Join("demos", "index.yaml"), expected: []string{filepath.Join("demos", "k8s", "intro.yaml"), "github.com/org/demos//git-basics", "https://cdn.example.com/tls.yaml"}},
+	}
+	for _, test := range tests {
+		index, err := catalog.Parse(data, test.source)
+		if err != nil {
+			t.Fatalf("%s: expected no error, but got %v", test.source, err)
+		}
+		if len(index.Scenarios) != len(test.expected) {
+			t.Fatalf("%s: expected %d scenarios, but got %d", test.source, len(test.expected), len(index.Scenarios))
+		}
+		for i, entry := range index.Scenarios {
+			if entry.Script != test.expected[i] {
+				t.Errorf("%s: expected the script %s, but got %s", test.source, test.expected[i], entry.Script)
+			}
+		}
+		if entry := index.Scenarios[0]; entry.Duration != 150*time.Second || !entry.HasTag("K8S") {
+			t.Errorf("%s: expected the duration and the tags, but got %+v", test.source, entry)
+		}
+	}
+
+	for _, invalid := range []string{
+		"scenarios:\n  - script: intro.yaml\n",
+		"scenarios:\n  - name: intro\n",
+		"scenarios:\n  - name: my intro\n    script: intro.yaml\n",
+		"scenarios:\n  - name: intro\n    script: a.yaml\n  - name: intro\n    script: b.yaml\n",
+		"scenarios:\n  - name: intro\n    script: intro.yaml\n    author: me\n",
+	} {
+		if _, err := catalog.Parse([]byte(invalid), "index.yaml"); err == nil {
+			t.Errorf("expected error for %q, but got nil", invalid)
+		}
+	}
+}
+
+// TestCatalog tests that the scenarios of the earlier indexes take
+// precedence, and that the scenarios are filtered by their tags
+func TestCatalog(t *testing.T) {
+	var c catalog.Catalog
+	c.Add(&catalog.Index{Scenarios: []catalog.Entry{
+		{Name: "intro", Script: "team.yaml", Tags: []string{"k8s", "intro"}},
+		{Name: "tls", Script: "tls.yaml"},
+	}})
+	c.Add(&catalog.Index{Scenarios: []catalog.Entry{
+		{Name: "intro", Script: "shared.yaml"},
+		{Name: "helm", Script: "helm.yaml", Tags: []string{"k8s"}},
+	}})
+
+	if entry, ok := c.Find("intro"); !ok || entry.Script != "team.yaml" {
+		t.Errorf("expected the scenario of the first index, but got %+v", entry)
+	}
+	if _, ok := c.Find("missing"); ok {
+		t.Errorf("expected no scenario for a missing name")
+	}
+
+	tests := []struct {
+		tags     []string
+		expected int
+	}{
+		{expected: 3},
+		{tags: []string{"k8s"}, expected: 2},
+		{tags: []string{"k8s", "intro"}, expected: 1},
+		{tags: []string{"windows"}, expected: 0},
+	}
+	for _, test := range tests {
+		if entries := c.Filter(test.tags...); len(entries) != test.expected {
+			t.Errorf("%v: expected %d scenarios, but got %d", test.tags, test.expected, len(entries))
+		}
+	}
+}
+
+// TestPrint tests that the scenarios are printed as a table
+func TestPrint(t *testing.T) {
+	var out strings.Builder
+	err := catalog.Print(&out, []catalog.Entry{
+		{Name: "k8s-intro", Description: "Deploy an app", Duration: 90 * time.Second, Tags: []string{"k8s", "intro"}},
+		{Name: "tls", Description: "Rotate certificates"},
+	})
+	if err != nil {
+		t.Fatalf("expected no error, but got %v", err)
+	}
+	expected := `NAME       DURATION  TAGS       DESCRIPTION
+k8s-intro  1m30s     k8s,intro  Deploy an app
+tls        -                    Rotate certificates
+`
+	if out.String() != expected {
+		t.Errorf("expected:\n%s\nbut got:\n%s", expected, out.String())
+	}
+}
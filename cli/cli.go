@@ -23,13 +23,17 @@ package cli
 
 import (
 	"bufio"
+	"errors"
 	"fmt"
 	"io"
+	"log/slog"
 	"os"
 	"os/exec"
 	"runtime"
 	"strings"
 	"time"
+
+	"github.com/bitcanon/autotyper/locale"
 )
 
 // Define a type for the prompt
@@ -42,6 +46,52 @@ const (
 	Bash
 )
 
+// ParseShellOption returns the shell option for the name of
+// a shell ("bash", "cmd" or "ps"). The second return value
+// reports whether the name is a known shell
+func ParseShellOption(name string) (ShellOption, bool) {
+	switch name {
+	case "cmd":
+		return Cmd, true
+	case "bash":
+		return Bash, true
+	case "ps":
+		return PS, true
+	default:
+		return PS, false
+	}
+}
+
+// DefaultPath returns the path printed in the prompt
+// of the shell when no path has been configured
+func DefaultPath(shell ShellOption) string {
+	switch shell {
+	case Cmd:
+		return "C:\\"
+	case Bash:
+		return "~"
+	default:
+		return "C:\\"
+	}
+}
+
+// ClearCommand returns the command clearing the screen in the shell
+func ClearCommand(shell ShellOption) string {
+	if shell == Bash {
+		return "clear"
+	}
+	return "cls"
+}
+
+// ExitMessage returns the line printed by the shell when the
+// session is left with "exit", or an empty string if there is none
+func ExitMessage(shell ShellOption) string {
+	if shell == Bash {
+		return "logout"
+	}
+	return ""
+}
+
 // Define a type for the prompt
 type Prompt struct {
 	// The prompt username and hostname (e.g. "user@host")
@@ -55,28 +105,27 @@ type Prompt struct {
 	Shell ShellOption
 }
 
-// ClearScreen clears the terminal screen. If the screen is not cleared,
-// an error is returned.
-func ClearScreen() error {
-	var cmdList []string
-	if runtime.GOOS == "windows" {
-		cmdList = []string{"cmd", "/c", "cls"}
-	} else {
-		cmdList = []string{"sh", "-c", "clear"}
+// ClearScreen clears the terminal screen and moves the cursor to the
+// top left corner. If scrollback is set, the scrollback buffer of the
+// terminal is cleared as well. If the screen is not cleared, an error
+// is returned.
+func ClearScreen(out io.Writer, scrollback bool) error {
+	seq := "\033[H\033[2J"
+	if scrollback {
+		seq += "\033[3J"
 	}
-	cmd := exec.Command(cmdList[0], cmdList[1:]...)
-	cmd.Stdout = os.Stdout
-	err := cmd.Run()
-	if err != nil {
-		return err
-	}
-
-	return nil
+	_, err := fmt.Fprint(out, seq)
+	return err
 }
 
 // PrintPrompt prints a prompt to the output. The prompt is printed
-// based on the shell option.
+// based on the shell option. If the path is empty, the default path
+// of the shell is printed.
 func PrintPrompt(p Prompt, out io.Writer) {
+	if p.Path == "" {
+		p.Path = DefaultPath(p.Shell)
+	}
+
 	switch p.Shell {
 	case PS:
 		// PowerShell prompt: "PS C:\> "
@@ -86,24 +135,71 @@ func PrintPrompt(p Prompt, out io.Writer) {
 		fmt.Fprintf(out, "%s> ", p.Path)
 	case Bash:
 		// Bash prompt: "user@host:~$ "
-		green := "\033[38;5;82m"
-		blue := "\033[38;5;32m"
-		white := "\033[0m"
-		fmt.Fprintf(out, "%s%s@%s%s:%s%s%s$ ", green, p.Username, p.Hostname, white, blue, p.Path, white)
+		user := colorText(ActiveTheme.Username, p.Username+"@"+p.Hostname)
+		fmt.Fprintf(out, "%s:%s$ ", user, colorText(ActiveTheme.Path, p.Path))
 	default:
 		// Unknown shell
 		fmt.Fprintf(out, "Unknown shell: %v\n", p.Shell)
 	}
 }
 
+// PrintCaption prints a caption on its own line in the caption color
+// of the active theme. The caption replaces anything on the current
+// line, so it should be followed by a new prompt.
+func PrintCaption(caption string, out io.Writer) {
+	fmt.Fprintf(out, "\r\033[K%s\n", colorText(ActiveTheme.Caption, caption))
+}
+
+// ColorCommand returns the command with its first word (the name of
+// the executable) in the command color of the active theme, as the
+// command is typed
+func ColorCommand(command string) string {
+	name, args, found := strings.Cut(command, " ")
+	if !found {
+		return colorText(ActiveTheme.Command, name)
+	}
+	return colorText(ActiveTheme.Command, name) + " " + args
+}
+
+// colorText returns the text in the color, followed by a reset
+// of the colors
+func colorText(c Color, text string) string {
+	return colorSequence(c) + text + "\033[0m"
+}
+
 // ExecuteCommand executes a command in the terminal and returns
 // the output of the command as a string. If the command fails,
-// an error is returned.
+// an error is returned. Commands refused by the CommandPolicy are
+// not executed and a *DeniedError is returned.
 func ExecuteCommand(command string, out io.Writer) error {
+	return ExecuteCommandInput(nil, command, nil, out)
+}
+
+// ExecuteCommandInput executes a command like ExecuteCommand in the
+// shell, with in connected to the standard input of the command. If
+// in is nil, the command reads from the null device, and if shell is
+// nil, the command runs in the directory and the environment of
+// autotyper.
+func ExecuteCommandInput(shell *Shell, command string, in io.Reader, out io.Writer) error {
+	if err := CommandPolicy.Check(command); err != nil {
+		slog.Info("command denied", "command", command, "error", err)
+		return err
+	}
+
 	cmdList := strings.Split(command, " ")
-	cmd := exec.Command(cmdList[0], cmdList[1:]...)
+	cmd := newCommand(shell, command, cmdList[0], cmdList[1:])
+	cmd.Stdin = in
 	cmd.Stdout = out
-	err := cmd.Run()
+
+	slog.Debug("executing command", "name", cmdList[0], "args", cmdList[1:])
+	start := time.Now()
+	err := cmd.Start()
+	if err == nil {
+		untrack := track(cmd.Process, false)
+		err = cmd.Wait()
+		untrack()
+	}
+	logExit(command, err, time.Since(start))
 	if err != nil {
 		return err
 	}
@@ -111,88 +207,121 @@ func ExecuteCommand(command string, out io.Writer) error {
 	return nil
 }
 
+// logExit logs the exit code of a command, or why it failed to run
+func logExit(command string, err error, elapsed time.Duration) {
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+		slog.Debug("command exited", "command", command, "exit_code", 0, "duration", elapsed)
+	case errors.As(err, &exitErr):
+		slog.Debug("command exited", "command", command, "exit_code", exitErr.ExitCode(), "duration", elapsed)
+	default:
+		slog.Warn("command failed", "command", command, "error", err)
+	}
+}
+
+// TypeText types a string character by character without any
+// colors, for example to show input typed into a running command.
+// The delayMs parameter is the delay in milliseconds between each
+// character, waited on the clock.
+func TypeText(clock Clock, str string, out io.Writer, delayMs int) error {
+	schedule := NewSchedule(clock)
+	for _, char := range str {
+		if _, err := out.Write([]byte(string(char))); err != nil {
+			return err
+		}
+		schedule.Wait(time.Duration(delayMs) * time.Millisecond)
+	}
+	return nil
+}
+
 // TypeAsHuman types a string as a human would. The delayMs parameter
 // is the delay in milliseconds between each character. If the delayMs
 // parameter is set to 0, there is no delay between each character.
-func TypeAsHuman(str string, out io.Writer, delayMs int) error {
+// The delays are waited on the clock. The colors are written to out with the text, so that everything
+// written to out (e.g. a recording) gets the same bytes as the screen.
+func TypeAsHuman(clock Clock, str string, out io.Writer, delayMs int) error {
 	// If delayMs is 0, just write the entire string to the output
 	if delayMs == 0 {
-		out.Write([]byte(str))
-		return nil
+		_, err := io.WriteString(out, str)
+		return err
 	}
 
 	// Colorize the first word in the string (the executable name)
 	// https://talyian.github.io/ansicolors/
-	fmt.Printf("\033[38;5;229m")
+	if _, err := io.WriteString(out, colorSequence(ActiveTheme.Command)); err != nil {
+		return err
+	}
 
 	// Otherwise, write each character to the output with a delay
 	// between each character
+	schedule := NewSchedule(clock)
+	colored := true
 	for _, char := range str {
-		// If the character is a space, reset the color
-		if string(char) == " " {
-			fmt.Printf("\033[0m")
+		// Reset the color at the end of the first word
+		text := string(char)
+		if colored && char == ' ' {
+			text = "\033[0m" + text
+			colored = false
 		}
 
 		// Write the character to the output
-		out.Write([]byte(string(char)))
+		if _, err := io.WriteString(out, text); err != nil {
+			return err
+		}
 
 		// Delay between each character
 		delay := time.Duration(delayMs) * time.Millisecond
-		time.Sleep(delay)
+		schedule.Wait(delay)
 	}
 
 	// Reset the color
-	fmt.Printf("\033[0m")
-
-	// No error
-	return nil
+	_, err := io.WriteString(out, "\033[0m")
+	return err
 }
 
 // ProcessStdin reads all data from standard input
 // and returns the input as a string
 func ProcessStdin() (string, error) {
-	// Read all data from standard input
-	input, err := io.ReadAll(os.Stdin)
-	if err != nil {
+	// Read all data from standard input, straight
+	// into the string returned
+	var input strings.Builder
+	if _, err := io.Copy(&input, os.Stdin); err != nil {
 		return "", err
 	}
 
 	// Return the input string on success
-	return string(input), nil
+	return input.String(), nil
 }
 
 // ProcessInteractiveInput processes the interactive input
 // and extracts MAC addresses from the input string
 func ProcessInteractiveInput() (string, error) {
-	// A string for the user input
-	var input string
-
-	// Create a scanner to read from standard input
-	scanner := bufio.NewScanner(os.Stdin)
-
 	// Tell the user how to finish the input
 	// based on the operating system
 	eofKeys := "CTRL+D"
 	if runtime.GOOS == "windows" {
 		eofKeys = "CTRL+Z"
 	}
-	fmt.Fprintf(os.Stderr, "Please enter the input text. Press %s to finish.\n", eofKeys)
+	fmt.Fprintln(os.Stderr, locale.T("Please enter the input text. Press %s to finish.", eofKeys))
 
 	// Read each line from standard input as the user types
-	for scanner.Scan() {
-		input += fmt.Sprintf("%s\n", scanner.Text())
-	}
+	return readLines(os.Stdin, 0)
+}
 
-	// Remove the trailing newline character
-	input = strings.TrimRight(input, "\n")
+// AskValue prints a question for the named value to out and
+// reads the answer (a single line) from in
+func AskValue(name string, in io.Reader, out io.Writer) (string, error) {
+	fmt.Fprintf(out, "%s: ", name)
 
-	// Check for errors that may have occurred while reading
-	if err := scanner.Err(); err != nil {
-		return "", err
+	reader := bufio.NewReader(in)
+	answer, err := reader.ReadString('\n')
+	if err != nil && (err != io.EOF || answer == "") {
+		return "", fmt.Errorf("no value for %s: %w", name, err)
 	}
 
-	// Return the input string on success
-	return input, nil
+	// Return the answer without the line ending
+	return strings.TrimRight(answer, "\r\n"), nil
 }
 
 // ProcessFile reads all data from the specified file
@@ -205,25 +334,46 @@ func ProcessFile(filename string) (string, error) {
 	}
 	defer file.Close()
 
-	// Create a scanner to read from the file
-	scanner := bufio.NewScanner(file)
-
-	// A string for the file contents
-	var input string
-
-	// Read each line from the file
-	for scanner.Scan() {
-		input += fmt.Sprintf("%s\n", scanner.Text())
+	// Read each line from the file, into a string
+	// of the size of the file
+	var size int64
+	if info, err := file.Stat(); err == nil {
+		size = info.Size()
 	}
+	return readLines(file, size)
+}
 
-	// Remove the trailing newline character
-	input = strings.TrimRight(input, "\n")
+// readLines reads the lines of r into a string, with the lines ending
+// with "\n" and without the newlines at the end. The size is that of
+// the input, if it is known, so the string is allocated only once
+func readLines(r io.Reader, size int64) (string, error) {
+	var input strings.Builder
+	input.Grow(int(size))
+
+	// Read each line, without its line ending
+	scanner := NewLineScanner(r)
+	lines := 0
+	for ; scanner.Scan(); lines++ {
+		input.Write(scanner.Bytes())
+		input.WriteByte('\n')
+	}
 
 	// Check for errors that may have occurred while reading
 	if err := scanner.Err(); err != nil {
-		return "", err
+		return "", LineError(err, lines)
 	}
 
-	// Return the input string on success
-	return input, nil
+	// Remove the trailing newline characters
+	return strings.TrimRight(input.String(), "\n"), nil
+}
+
+// SplitCommands splits the input string into a slice of commands,
+// one per line. Windows line endings are normalized before splitting
+func SplitCommands(input string) []string {
+	// Replace "\r\n" with "\n" to ensure consistent line endings
+	input = strings.ReplaceAll(input, "\r\n", "\n")
+
+	// Split the input string into a slice of strings
+	// based on the newline character
+	return strings.Split(input, "\n")
 }
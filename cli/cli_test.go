@@ -1,8 +1,16 @@
 package cli_test
 
 import (
+	"bytes"
+	"fmt"
+	"io"
+	"log/slog"
 	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/bitcanon/autotyper/cli"
 )
@@ -168,6 +176,37 @@ func TestProcessFile(t *testing.T) {
 		}
 	})
 
+	// Test to read a file with Windows line endings and trailing
+	// newlines, and a large file of generated commands
+	t.Run("LineEndings", func(t *testing.T) {
+		var large strings.Builder
+		for i := 0; i < 100000; i++ {
+			fmt.Fprintf(&large, "echo %d\n", i)
+		}
+
+		tests := []struct {
+			content  string
+			expected string
+		}{
+			{content: "Line 1\r\nLine 2\r\n\r\n", expected: "Line 1\nLine 2"},
+			{content: "Line 1\n\n\n", expected: "Line 1"},
+			{content: large.String(), expected: strings.TrimRight(large.String(), "\n")},
+		}
+		for _, test := range tests {
+			filename := filepath.Join(t.TempDir(), "commands.txt")
+			if err := os.WriteFile(filename, []byte(test.content), 0o644); err != nil {
+				t.Fatalf("failed to write the file: %v", err)
+			}
+			input, err := cli.ProcessFile(filename)
+			if err != nil {
+				t.Fatalf("expected no error, but got %v", err)
+			}
+			if input != test.expected {
+				t.Errorf("expected %d bytes %.40q, but got %d bytes %.40q", len(test.expected), test.expected, len(input), input)
+			}
+		}
+	})
+
 	// Test to read a file that does not exist
 	t.Run("FileNotFound", func(t *testing.T) {
 		_, err := cli.ProcessFile("nonexistent.txt")
@@ -194,3 +233,145 @@ func TestProcessFile(t *testing.T) {
 		}
 	})
 }
+
+// TestAskValue tests that a single line is read as the answer
+// and that the question is printed to the output
+func TestAskValue(t *testing.T) {
+	var out strings.Builder
+	value, err := cli.AskValue("ticket", strings.NewReader("JIRA-42\r\nignored\n"), &out)
+	if err != nil {
+		t.Fatalf("expected no error, but got %v", err)
+	}
+	if value != "JIRA-42" {
+		t.Errorf("expected %q, but got %q", "JIRA-42", value)
+	}
+	if out.String() != "ticket: " {
+		t.Errorf("expected question %q, but got %q", "ticket: ", out.String())
+	}
+
+	if _, err := cli.AskValue("ticket", strings.NewReader(""), &out); err == nil {
+		t.Errorf("expected error for empty input, but got nil")
+	}
+}
+
+// TestClearScreen tests that the screen is cleared with escape
+// sequences, and the scrollback only when asked to
+func TestClearScreen(t *testing.T) {
+	tests := []struct {
+		scrollback bool
+		expected   string
+	}{
+		{scrollback: false, expected: "\033[H\033[2J"},
+		{scrollback: true, expected: "\033[H\033[2J\033[3J"},
+	}
+
+	for _, test := range tests {
+		var out strings.Builder
+		if err := cli.ClearScreen(&out, test.scrollback); err != nil {
+			t.Fatalf("expected no error, but got %v", err)
+		}
+		if out.String() != test.expected {
+			t.Errorf("scrollback %v: expected %q, but got %q", test.scrollback, test.expected, out.String())
+		}
+	}
+}
+
+// TestExecuteCommandLogs tests that the exit codes of the executed
+// commands are logged at the debug level
+func TestExecuteCommandLogs(t *testing.T) {
+	if _, err := exec.LookPath("false"); err != nil {
+		t.Skip("false is not available")
+	}
+
+	var logs bytes.Buffer
+	defer slog.SetDefault(slog.Default())
+	slog.SetDefault(slog.New(slog.NewTextHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug})))
+
+	if err := cli.ExecuteCommand("false", &bytes.Buffer{}); err == nil {
+		t.Fatalf("expected error, but got nil")
+	}
+	for _, expected := range []string{`msg="executing command" name=false`, `msg="command exited" command=false exit_code=1`} {
+		if !strings.Contains(logs.String(), expected) {
+			t.Errorf("expected %q in the logs, but got %q", expected, logs.String())
+		}
+	}
+}
+
+// TestColorCommand tests that only the name of the executable
+// is drawn in the command color of the theme
+func TestColorCommand(t *testing.T) {
+	defer func(profile cli.ColorProfile) { cli.ActiveColorProfile = profile }(cli.ActiveColorProfile)
+	cli.ActiveColorProfile = cli.ANSI256
+
+	tests := []struct {
+		command  string
+		expected string
+	}{
+		{command: "ls", expected: "\033[38;5;229mls\033[0m"},
+		{command: "ls -l /tmp", expected: "\033[38;5;229mls\033[0m -l /tmp"},
+	}
+
+	for _, test := range tests {
+		if got := cli.ColorCommand(test.command); got != test.expected {
+			t.Errorf("%q: expected %q, but got %q", test.command, test.expected, got)
+		}
+	}
+}
+
+// TestTypeAsHumanColors tests that the colors of the typed command
+// are written to the output, and nothing to the standard output
+func TestTypeAsHumanColors(t *testing.T) {
+	defer func(profile cli.ColorProfile) { cli.ActiveColorProfile = profile }(cli.ActiveColorProfile)
+	cli.ActiveColorProfile = cli.ANSI256
+
+	// Capture the standard output during the typing
+	originalStdout := os.Stdout
+	defer func() { os.Stdout = originalStdout }()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("error returned from os.Pipe(): %v", err)
+	}
+	defer r.Close()
+	os.Stdout = w
+
+	var out strings.Builder
+	err = cli.TypeAsHuman(cli.NewFakeClock(time.Now()), "ls -l /tmp", &out, 10)
+	w.Close()
+	if err != nil {
+		t.Fatalf("expected no error, but got %v", err)
+	}
+
+	expected := "\033[38;5;229mls\033[0m -l /tmp\033[0m"
+	if out.String() != expected {
+		t.Errorf("expected %q, but got %q", expected, out.String())
+	}
+	if stdout, _ := io.ReadAll(r); len(stdout) > 0 {
+		t.Errorf("expected nothing on the standard output, but got %q", stdout)
+	}
+}
+
+// TestLongLines tests that lines far longer than the default buffer
+// of a scanner are read whole, and that the lines longer than the
+// limit are reported with their number
+func TestLongLines(t *testing.T) {
+	defer func(max int) { cli.MaxLineLength = max }(cli.MaxLineLength)
+
+	payload := "echo " + strings.Repeat("QUJD", 1<<18)
+	filename := filepath.Join(t.TempDir(), "commands.txt")
+	if err := os.WriteFile(filename, []byte("ls\n"+payload+"\n"), 0o644); err != nil {
+		t.Fatalf("failed to write the file: %v", err)
+	}
+
+	input, err := cli.ProcessFile(filename)
+	if err != nil {
+		t.Fatalf("expected no error, but got %v", err)
+	}
+	if input != "ls\n"+payload {
+		t.Errorf("expected the line of %d bytes, but got %d bytes", len(payload), len(input)-3)
+	}
+
+	cli.MaxLineLength = 1 << 16
+	if _, err := cli.ProcessFile(filename); err == nil || !strings.Contains(err.Error(), "line 2 is longer than 65536 bytes") {
+		t.Errorf("expected an error for line 2, but got %v", err)
+	}
+}
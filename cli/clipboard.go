@@ -0,0 +1,86 @@
+/*
+Copyright © 2023 Mikael Schultz <bitcanon@proton.me>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cli
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// ErrNoClipboard is returned when no command reading
+// the clipboard of the system is installed
+var ErrNoClipboard = errors.New("no clipboard command found (pbpaste, wl-paste, xclip or xsel)")
+
+// clipboardCommands returns the commands printing the text in the
+// clipboard on the operating system, in the order they are tried
+func clipboardCommands(goos string) [][]string {
+	switch goos {
+	case "darwin":
+		return [][]string{{"pbpaste"}}
+	case "windows":
+		return [][]string{{"powershell", "-NoProfile", "-Command", "Get-Clipboard -Raw"}}
+	}
+	var commands [][]string
+	if os.Getenv("WAYLAND_DISPLAY") != "" {
+		commands = append(commands, []string{"wl-paste", "--no-newline"})
+	}
+	return append(commands,
+		[]string{"xclip", "-selection", "clipboard", "-o"},
+		[]string{"xsel", "--clipboard", "--output"},
+	)
+}
+
+// ProcessClipboard reads the text in the clipboard of the system
+// with the first clipboard command installed, and returns it without
+// the trailing newlines. An error is returned if the clipboard is empty
+func ProcessClipboard() (string, error) {
+	for _, command := range clipboardCommands(runtime.GOOS) {
+		path, err := exec.LookPath(command[0])
+		if err != nil {
+			continue
+		}
+		output, err := exec.Command(path, command[1:]...).Output()
+		if err != nil {
+			return "", fmt.Errorf("failed to read the clipboard with %s: %w", command[0], err)
+		}
+		text := strings.TrimRight(string(output), "\r\n")
+		if strings.TrimSpace(text) == "" {
+			return "", errors.New("the clipboard is empty")
+		}
+		return text, nil
+	}
+	return "", ErrNoClipboard
+}
+
+// CopyOSC52 places the text in the clipboard of the terminal showing
+// out with an OSC 52 sequence, so the viewers of a shared screen can
+// paste it. Terminals without support for OSC 52 ignore the sequence
+func CopyOSC52(out io.Writer, text string) error {
+	_, err := fmt.Fprintf(out, "\033]52;c;%s\a", base64.StdEncoding.EncodeToString([]byte(text)))
+	return err
+}
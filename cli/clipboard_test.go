@@ -0,0 +1,66 @@
+package cli_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/bitcanon/autotyper/cli"
+)
+
+// TestProcessClipboard tests that the clipboard is read with the
+// clipboard command found, without the trailing newlines, and that
+// ErrNoClipboard is returned when there is none
+func TestProcessClipboard(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the clipboard command cannot be replaced on Windows")
+	}
+	command := "xclip"
+	if runtime.GOOS == "darwin" {
+		command = "pbpaste"
+	}
+	t.Setenv("WAYLAND_DISPLAY", "")
+
+	dir := t.TempDir()
+	t.Setenv("PATH", dir)
+	if _, err := cli.ProcessClipboard(); !errors.Is(err, cli.ErrNoClipboard) {
+		t.Fatalf("expected ErrNoClipboard, but got %v", err)
+	}
+
+	fake := "#!/bin/sh\nprintf 'echo one\\necho two\\n\\n'\n"
+	if err := os.WriteFile(filepath.Join(dir, command), []byte(fake), 0755); err != nil {
+		t.Fatalf("failed to write the clipboard command: %v", err)
+	}
+	text, err := cli.ProcessClipboard()
+	if err != nil {
+		t.Fatalf("expected no error, but got %v", err)
+	}
+	if text != "echo one\necho two" {
+		t.Errorf("expected %q, but got %q", "echo one\necho two", text)
+	}
+}
+
+// TestCopyOSC52 tests that the text is sent to the clipboard of
+// the terminal encoded in base64
+func TestCopyOSC52(t *testing.T) {
+	tests := []struct {
+		text     string
+		expected string
+	}{
+		{text: "ls -la", expected: "\033]52;c;bHMgLWxh\a"},
+		{text: "", expected: "\033]52;c;\a"},
+	}
+
+	for _, test := range tests {
+		var out strings.Builder
+		if err := cli.CopyOSC52(&out, test.text); err != nil {
+			t.Fatalf("expected no error, but got %v", err)
+		}
+		if out.String() != test.expected {
+			t.Errorf("%q: expected %q, but got %q", test.text, test.expected, out.String())
+		}
+	}
+}
@@ -0,0 +1,122 @@
+/*
+Copyright © 2023 Mikael Schultz <bitcanon@proton.me>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cli
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock tells the time and waits for the typing and the delays of
+// the playback, so that the timing can be tested without waiting,
+// and a demo exported faster than real time
+type Clock interface {
+	// Now returns the current time
+	Now() time.Time
+
+	// Sleep waits for the duration
+	Sleep(d time.Duration)
+
+	// After returns a channel receiving the time once
+	// the duration has passed
+	After(d time.Duration) <-chan time.Time
+}
+
+// SystemClock is the clock of the system, waiting in real time
+type SystemClock struct{}
+
+// Now returns the current time of the system
+func (SystemClock) Now() time.Time {
+	return time.Now()
+}
+
+// Sleep waits for the duration
+func (SystemClock) Sleep(d time.Duration) {
+	time.Sleep(d)
+}
+
+// After returns a channel receiving the time after the duration
+func (SystemClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
+// FakeClock is a clock whose time only passes when it is waited on.
+// Sleeping returns at once, moving the time forward by the duration,
+// so the delays are played without waiting while the time measured
+// is that of a real playback. It is safe for concurrent use
+type FakeClock struct {
+	mu    sync.Mutex
+	now   time.Time
+	late  time.Duration
+	slept time.Duration
+}
+
+// NewFakeClock creates a fake clock starting at the time
+func NewFakeClock(now time.Time) *FakeClock {
+	return &FakeClock{now: now}
+}
+
+// NewLateFakeClock creates a fake clock starting at the time, whose
+// waits wake up late by the duration, like the waits of a coarse timer
+func NewLateFakeClock(now time.Time, late time.Duration) *FakeClock {
+	return &FakeClock{now: now, late: late}
+}
+
+// Now returns the time of the clock
+func (c *FakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// Sleep moves the time of the clock forward by the duration
+func (c *FakeClock) Sleep(d time.Duration) {
+	c.advance(d)
+}
+
+// After moves the time of the clock forward by the duration, and
+// returns a channel that has already received the new time
+func (c *FakeClock) After(d time.Duration) <-chan time.Time {
+	ch := make(chan time.Time, 1)
+	ch <- c.advance(d)
+	return ch
+}
+
+// Slept returns the total time waited on the clock
+func (c *FakeClock) Slept() time.Duration {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.slept
+}
+
+// advance moves the time forward, waking up late,
+// and returns the new time
+func (c *FakeClock) advance(d time.Duration) time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	d += c.late
+	if d > 0 {
+		c.now = c.now.Add(d)
+		c.slept += d
+	}
+	return c.now
+}
@@ -0,0 +1,80 @@
+package cli_test
+
+import (
+	"io"
+	"testing"
+	"time"
+
+	"github.com/bitcanon/autotyper/cli"
+)
+
+// TestFakeClock tests that the time of a fake clock only passes
+// when it is waited on, without waiting in real time, and that the
+// waits of a late clock wake up late
+func TestFakeClock(t *testing.T) {
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	clock := cli.NewFakeClock(start)
+
+	before := time.Now()
+	clock.Sleep(time.Hour)
+	if got := <-clock.After(30 * time.Minute); !got.Equal(start.Add(90 * time.Minute)) {
+		t.Errorf("expected %v from After, but got %v", start.Add(90*time.Minute), got)
+	}
+	clock.Sleep(-time.Second)
+	if elapsed := time.Since(before); elapsed > time.Second {
+		t.Errorf("expected no real wait, but took %v", elapsed)
+	}
+
+	if got := clock.Now(); !got.Equal(start.Add(90 * time.Minute)) {
+		t.Errorf("expected the time %v, but got %v", start.Add(90*time.Minute), got)
+	}
+	if clock.Slept() != 90*time.Minute {
+		t.Errorf("expected 1h30m slept, but got %v", clock.Slept())
+	}
+
+	// The waits of a late clock wake up late
+	late := cli.NewLateFakeClock(start, 5*time.Millisecond)
+	late.Sleep(10 * time.Millisecond)
+	<-late.After(10 * time.Millisecond)
+	if late.Slept() != 30*time.Millisecond {
+		t.Errorf("expected 30ms slept, but got %v", late.Slept())
+	}
+}
+
+// TestTypingClock tests that the typing waits on its clock,
+// once for each character of the text
+func TestTypingClock(t *testing.T) {
+	tests := []struct {
+		name     string
+		typing   func(clock cli.Clock) error
+		expected time.Duration
+	}{
+		{
+			name:     "TypeText",
+			typing:   func(clock cli.Clock) error { return cli.TypeText(clock, "ls -l", io.Discard, 100) },
+			expected: 500 * time.Millisecond,
+		},
+		{
+			name:     "TypeAsHuman",
+			typing:   func(clock cli.Clock) error { return cli.TypeAsHuman(clock, "ls -l", io.Discard, 100) },
+			expected: 500 * time.Millisecond,
+		},
+		{
+			name:     "TypeAsHumanWithoutDelay",
+			typing:   func(clock cli.Clock) error { return cli.TypeAsHuman(clock, "ls -l", io.Discard, 0) },
+			expected: 0,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			clock := cli.NewFakeClock(time.Now())
+			if err := test.typing(clock); err != nil {
+				t.Fatalf("expected no error, but got %v", err)
+			}
+			if clock.Slept() != test.expected {
+				t.Errorf("expected %v slept, but got %v", test.expected, clock.Slept())
+			}
+		})
+	}
+}
@@ -0,0 +1,278 @@
+/*
+Copyright © 2023 Mikael Schultz <bitcanon@proton.me>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cli
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// ColorProfile is the range of colors supported by a terminal
+type ColorProfile int
+
+// Define constants for the color profiles
+const (
+	ANSI16 ColorProfile = iota
+	ANSI256
+	TrueColor
+)
+
+// ParseColorProfile returns the color profile for its name ("16",
+// "256" or "truecolor"). The second return value reports whether
+// the name is a known profile
+func ParseColorProfile(name string) (ColorProfile, bool) {
+	switch strings.ToLower(name) {
+	case "16":
+		return ANSI16, true
+	case "256":
+		return ANSI256, true
+	case "truecolor", "24bit":
+		return TrueColor, true
+	default:
+		return ANSI256, false
+	}
+}
+
+// DetectColorProfile returns the color profile of the terminal,
+// based on the COLORTERM and TERM environment variables
+func DetectColorProfile() ColorProfile {
+	switch strings.ToLower(os.Getenv("COLORTERM")) {
+	case "truecolor", "24bit":
+		return TrueColor
+	}
+
+	term := os.Getenv("TERM")
+	switch {
+	case strings.Contains(term, "truecolor") || strings.Contains(term, "24bit") || strings.Contains(term, "direct"):
+		return TrueColor
+	case strings.Contains(term, "256color"):
+		return ANSI256
+	case term == "" && os.Getenv("WT_SESSION") != "":
+		// Windows Terminal does not set TERM
+		return TrueColor
+	default:
+		return ANSI16
+	}
+}
+
+// Color is a color of the theme, either a color of the 256-color
+// palette or a 24-bit color
+type Color struct {
+	// The 24-bit value of the color
+	R, G, B uint8
+
+	// The index of the color in the 256-color
+	// palette, or -1 for a 24-bit color
+	Index int
+}
+
+// PaletteColor returns the color of the 256-color palette
+func PaletteColor(index uint8) Color {
+	r, g, b := paletteRGB(index)
+	return Color{R: r, G: g, B: b, Index: int(index)}
+}
+
+// RGBColor returns the 24-bit color
+func RGBColor(r, g, b uint8) Color {
+	return Color{R: r, G: g, B: b, Index: -1}
+}
+
+// ParseColor parses a color defined as a 24-bit hex value (e.g.
+// "#5fd700" or "#5d0") or as an index of the 256-color palette
+// (e.g. "82")
+func ParseColor(s string) (Color, error) {
+	if hex, ok := strings.CutPrefix(s, "#"); ok {
+		if len(hex) == 3 {
+			hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+		}
+		v, err := strconv.ParseUint(hex, 16, 32)
+		if err != nil || len(hex) != 6 {
+			return Color{}, fmt.Errorf("invalid color %q", s)
+		}
+		return RGBColor(uint8(v>>16), uint8(v>>8), uint8(v)), nil
+	}
+
+	index, err := strconv.ParseUint(s, 10, 8)
+	if err != nil {
+		return Color{}, fmt.Errorf("invalid color %q, expected #rrggbb or 0-255", s)
+	}
+	return PaletteColor(uint8(index)), nil
+}
+
+// String returns the definition of the color (e.g. "#5fd700" or "82")
+func (c Color) String() string {
+	if c.Index >= 0 {
+		return strconv.Itoa(c.Index)
+	}
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
+
+// Foreground returns the parameters of the escape sequence setting
+// the color as the foreground color (e.g. "38;5;82"). Colors not
+// supported by the profile are replaced by the closest supported one.
+func (c Color) Foreground(profile ColorProfile) string {
+	switch profile {
+	case TrueColor:
+		if c.Index >= 0 && c.Index < 16 {
+			// Keep the system colors of the terminal theme
+			return fmt.Sprintf("38;5;%d", c.Index)
+		}
+		return fmt.Sprintf("38;2;%d;%d;%d", c.R, c.G, c.B)
+	case ANSI256:
+		if c.Index >= 0 {
+			return fmt.Sprintf("38;5;%d", c.Index)
+		}
+		return fmt.Sprintf("38;5;%d", nearest256(c.R, c.G, c.B))
+	default:
+		index := c.Index
+		if index < 0 || index >= 16 {
+			index = nearest16(c.R, c.G, c.B)
+		}
+		if index < 8 {
+			return strconv.Itoa(30 + index)
+		}
+		return strconv.Itoa(90 + index - 8)
+	}
+}
+
+// Background returns the parameters of the escape sequence setting
+// the color as the background color (e.g. "48;5;82")
+func (c Color) Background(profile ColorProfile) string {
+	fg := c.Foreground(profile)
+	if rest, ok := strings.CutPrefix(fg, "38;"); ok {
+		return "48;" + rest
+	}
+	code, _ := strconv.Atoi(fg)
+	return strconv.Itoa(code + 10)
+}
+
+// Theme holds the colors used to render the prompt, the commands
+// and the captions
+type Theme struct {
+	Username Color
+	Path     Color
+	Command  Color
+	Caption  Color
+}
+
+// DefaultTheme is the theme used if none has been configured
+var DefaultTheme = Theme{
+	Username: PaletteColor(82),
+	Path:     PaletteColor(32),
+	Command:  PaletteColor(229),
+	Caption:  PaletteColor(244),
+}
+
+// ActiveTheme and ActiveColorProfile are the theme and the color
+// profile used to render the prompt, the commands and the captions
+var (
+	ActiveTheme        = DefaultTheme
+	ActiveColorProfile = ANSI256
+)
+
+// colorSequence returns the escape sequence setting the foreground
+// color of the active theme and profile
+func colorSequence(c Color) string {
+	return "\033[" + c.Foreground(ActiveColorProfile) + "m"
+}
+
+// Define the levels of the 6x6x6 color cube of the 256-color palette
+var cubeLevels = [6]uint8{0, 95, 135, 175, 215, 255}
+
+// Define the colors of the 16 system colors (the xterm defaults)
+var systemColors = [16][3]uint8{
+	{0, 0, 0}, {205, 0, 0}, {0, 205, 0}, {205, 205, 0},
+	{0, 0, 238}, {205, 0, 205}, {0, 205, 205}, {229, 229, 229},
+	{127, 127, 127}, {255, 0, 0}, {0, 255, 0}, {255, 255, 0},
+	{92, 92, 255}, {255, 0, 255}, {0, 255, 255}, {255, 255, 255},
+}
+
+// paletteRGB returns the 24-bit value of a color of the 256-color palette
+func paletteRGB(index uint8) (uint8, uint8, uint8) {
+	switch {
+	case index < 16:
+		c := systemColors[index]
+		return c[0], c[1], c[2]
+	case index < 232:
+		i := index - 16
+		return cubeLevels[i/36], cubeLevels[i/6%6], cubeLevels[i%6]
+	default:
+		v := 8 + 10*(index-232)
+		return v, v, v
+	}
+}
+
+// nearest256 returns the index of the closest color of the
+// color cube or the gray ramp of the 256-color palette
+func nearest256(r, g, b uint8) int {
+	level := func(v uint8) int {
+		best := 0
+		for i, l := range cubeLevels {
+			if absDiff(v, l) < absDiff(v, cubeLevels[best]) {
+				best = i
+			}
+		}
+		return best
+	}
+	cube := 16 + 36*level(r) + 6*level(g) + level(b)
+
+	// The closest gray of the ramp from 8 to 238
+	avg := (int(r) + int(g) + int(b)) / 3
+	gray := 232 + min(max((avg-3)/10, 0), 23)
+
+	if distance(r, g, b, uint8(gray)) < distance(r, g, b, uint8(cube)) {
+		return gray
+	}
+	return cube
+}
+
+// nearest16 returns the index of the closest system color
+func nearest16(r, g, b uint8) int {
+	best, bestDistance := 0, -1
+	for i, c := range systemColors {
+		d := sq(int(r)-int(c[0])) + sq(int(g)-int(c[1])) + sq(int(b)-int(c[2]))
+		if bestDistance < 0 || d < bestDistance {
+			best, bestDistance = i, d
+		}
+	}
+	return best
+}
+
+// distance returns the squared distance between a 24-bit
+// color and a color of the 256-color palette
+func distance(r, g, b, index uint8) int {
+	pr, pg, pb := paletteRGB(index)
+	return sq(int(r)-int(pr)) + sq(int(g)-int(pg)) + sq(int(b)-int(pb))
+}
+
+func absDiff(a, b uint8) int {
+	if a > b {
+		return int(a - b)
+	}
+	return int(b - a)
+}
+
+func sq(v int) int {
+	return v * v
+}
@@ -0,0 +1,96 @@
+package cli_test
+
+import (
+	"testing"
+
+	"github.com/bitcanon/autotyper/cli"
+)
+
+// TestParseColor tests the parsing of 24-bit and palette colors
+func TestParseColor(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+		err      bool
+	}{
+		{input: "#5fd700", expected: "#5fd700"},
+		{input: "#5D0", expected: "#55dd00"},
+		{input: "82", expected: "82"},
+		{input: "0", expected: "0"},
+		{input: "256", err: true},
+		{input: "#5fd70", err: true},
+		{input: "#zzzzzz", err: true},
+		{input: "green", err: true},
+	}
+
+	for _, test := range tests {
+		c, err := cli.ParseColor(test.input)
+		if test.err {
+			if err == nil {
+				t.Errorf("%q: expected error, but got %v", test.input, c)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: expected no error, but got %v", test.input, err)
+			continue
+		}
+		if c.String() != test.expected {
+			t.Errorf("%q: expected %q, but got %q", test.input, test.expected, c.String())
+		}
+	}
+}
+
+// TestColorForeground tests that colors are downsampled
+// to the closest color supported by the profile
+func TestColorForeground(t *testing.T) {
+	tests := []struct {
+		color    cli.Color
+		profile  cli.ColorProfile
+		expected string
+	}{
+		{color: cli.RGBColor(0x5f, 0xd7, 0x00), profile: cli.TrueColor, expected: "38;2;95;215;0"},
+		{color: cli.RGBColor(0x5f, 0xd7, 0x00), profile: cli.ANSI256, expected: "38;5;76"},
+		{color: cli.RGBColor(0x60, 0xd0, 0x10), profile: cli.ANSI256, expected: "38;5;76"},
+		{color: cli.RGBColor(0x80, 0x80, 0x80), profile: cli.ANSI256, expected: "38;5;244"},
+		{color: cli.RGBColor(0x5f, 0xd7, 0x00), profile: cli.ANSI16, expected: "32"},
+		{color: cli.RGBColor(0xff, 0x10, 0x10), profile: cli.ANSI16, expected: "91"},
+		{color: cli.PaletteColor(82), profile: cli.TrueColor, expected: "38;2;95;255;0"},
+		{color: cli.PaletteColor(82), profile: cli.ANSI256, expected: "38;5;82"},
+		{color: cli.PaletteColor(82), profile: cli.ANSI16, expected: "92"},
+		{color: cli.PaletteColor(4), profile: cli.TrueColor, expected: "38;5;4"},
+		{color: cli.PaletteColor(4), profile: cli.ANSI16, expected: "34"},
+	}
+
+	for _, test := range tests {
+		if fg := test.color.Foreground(test.profile); fg != test.expected {
+			t.Errorf("%v with profile %d: expected %q, but got %q", test.color, test.profile, test.expected, fg)
+		}
+	}
+}
+
+// TestDetectColorProfile tests the detection of the color
+// profile from the environment variables of the terminal
+func TestDetectColorProfile(t *testing.T) {
+	tests := []struct {
+		colorTerm string
+		term      string
+		expected  cli.ColorProfile
+	}{
+		{colorTerm: "truecolor", term: "xterm-256color", expected: cli.TrueColor},
+		{colorTerm: "24bit", term: "", expected: cli.TrueColor},
+		{term: "xterm-direct", expected: cli.TrueColor},
+		{term: "xterm-256color", expected: cli.ANSI256},
+		{term: "xterm", expected: cli.ANSI16},
+		{term: "", expected: cli.ANSI16},
+	}
+
+	for _, test := range tests {
+		t.Setenv("COLORTERM", test.colorTerm)
+		t.Setenv("TERM", test.term)
+		t.Setenv("WT_SESSION", "")
+		if profile := cli.DetectColorProfile(); profile != test.expected {
+			t.Errorf("COLORTERM=%q TERM=%q: expected %d, but got %d", test.colorTerm, test.term, test.expected, profile)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package cli_test
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bitcanon/autotyper/cli"
+	"github.com/bitcanon/autotyper/vt"
+)
+
+// update rewrites the golden files with the output of the tests
+var update = flag.Bool("update", false, "update the golden files in testdata/golden")
+
+// TestGolden tests the rendering of the prompts, the typing and the
+// clearing of the screen against the golden files, which hold the
+// bytes written to the terminal and the text left on its screen.
+// Run the tests with -update to write the golden files again.
+func TestGolden(t *testing.T) {
+	defer func(profile cli.ColorProfile) { cli.ActiveColorProfile = profile }(cli.ActiveColorProfile)
+	cli.ActiveColorProfile = cli.ANSI256
+
+	tests := []struct {
+		name string
+		play func(term vt.Terminal, clock cli.Clock)
+	}{
+		{
+			name: "prompt-bash",
+			play: func(term vt.Terminal, clock cli.Clock) {
+				cli.PrintPrompt(cli.Prompt{Username: "user", Hostname: "host", Path: "~/src", Shell: cli.Bash}, term)
+			},
+		},
+		{
+			name: "prompt-ps",
+			play: func(term vt.Terminal, clock cli.Clock) {
+				cli.PrintPrompt(cli.Prompt{Shell: cli.PS}, term)
+			},
+		},
+		{
+			name: "prompt-cmd",
+			play: func(term vt.Terminal, clock cli.Clock) {
+				cli.PrintPrompt(cli.Prompt{Path: "D:\\work", Shell: cli.Cmd}, term)
+			},
+		},
+		{
+			name: "typing",
+			play: func(term vt.Terminal, clock cli.Clock) {
+				cli.PrintPrompt(cli.Prompt{Shell: cli.Cmd}, term)
+				cli.TypeAsHuman(clock, "dir /w", term, 1)
+				cli.TypeText(clock, "\nyes", term, 1)
+			},
+		},
+		{
+			name: "clear",
+			play: func(term vt.Terminal, clock cli.Clock) {
+				cli.PrintPrompt(cli.Prompt{Shell: cli.Cmd}, term)
+				cli.ClearScreen(term, true)
+				cli.PrintCaption("Deploying", term)
+				cli.PrintPrompt(cli.Prompt{Shell: cli.Cmd}, term)
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			term := vt.NewMemory(40, 5)
+			test.play(term, cli.NewFakeClock(time.Time{}))
+			got := term.Transcript() + "\n-- screen --\n" + term.Text() + "\n"
+
+			filename := filepath.Join("..", "testdata", "golden", test.name+".txt")
+			if *update {
+				if err := os.WriteFile(filename, []byte(got), 0o644); err != nil {
+					t.Fatalf("failed to write the golden file: %v", err)
+				}
+			}
+			expected, err := os.ReadFile(filename)
+			if err != nil {
+				t.Fatalf("failed to read the golden file: %v", err)
+			}
+			if got != string(expected) {
+				t.Errorf("%s: expected\n%s\nbut got\n%s", filename, expected, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,256 @@
+/*
+Copyright © 2023 Mikael Schultz <bitcanon@proton.me>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cli
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// DefaultHighlightStyle is the style of the highlight rules without one
+const DefaultHighlightStyle = "yellow-bold"
+
+// Define the names of the system colors of the styles
+var colorNames = map[string]uint8{
+	"black":   0,
+	"red":     1,
+	"green":   2,
+	"yellow":  3,
+	"blue":    4,
+	"magenta": 5,
+	"cyan":    6,
+	"white":   7,
+	"gray":    8,
+	"grey":    8,
+}
+
+// Define the parameters of the escape sequences of the attributes
+var attributes = map[string]string{
+	"bold":      "1",
+	"dim":       "2",
+	"italic":    "3",
+	"underline": "4",
+	"blink":     "5",
+	"reverse":   "7",
+}
+
+// Accessible makes the output easier to follow without seeing the
+// colors or with a screen reader: the highlighted text is marked
+// with brackets as well, and does not blink
+var Accessible bool
+
+// Style is a combination of colors and attributes of text
+type Style struct {
+	Foreground *Color
+	Background *Color
+
+	// The parameters of the attributes (e.g. "1" for bold)
+	Attributes []string
+}
+
+// ParseStyle parses a style made of colors and attributes separated
+// by dashes (e.g. "red-bold" or "black-on-yellow"). A color is a name
+// (e.g. "red", or "bright-red"), a 24-bit hex value or an index of
+// the 256-color palette, and "on" makes the next color the background
+func ParseStyle(s string) (Style, error) {
+	var style Style
+	bright, background := false, false
+	for _, word := range strings.Split(strings.ToLower(s), "-") {
+		if code, ok := attributes[word]; ok {
+			style.Attributes = append(style.Attributes, code)
+			continue
+		}
+
+		var color Color
+		switch index, ok := colorNames[word]; {
+		case word == "bright":
+			bright = true
+			continue
+		case word == "on":
+			background = true
+			continue
+		case ok:
+			if bright && index < 8 {
+				index += 8
+			}
+			color = PaletteColor(index)
+		default:
+			var err error
+			if color, err = ParseColor(word); err != nil {
+				return Style{}, fmt.Errorf("invalid style %q: unknown color or attribute %q", s, word)
+			}
+		}
+
+		if background {
+			style.Background = &color
+		} else {
+			style.Foreground = &color
+		}
+		bright, background = false, false
+	}
+	if bright || background {
+		return Style{}, fmt.Errorf("invalid style %q: missing color", s)
+	}
+	return style, nil
+}
+
+// Sequence returns the escape sequence setting the style with the
+// active color profile, and the system colors of the active palette
+func (s Style) Sequence() string {
+	var params []string
+	for _, param := range s.Attributes {
+		if Accessible && param == attributes["blink"] {
+			continue
+		}
+		params = append(params, param)
+	}
+	if s.Foreground != nil {
+		params = append(params, ActivePalette.Replace(*s.Foreground).Foreground(ActiveColorProfile))
+	}
+	if s.Background != nil {
+		params = append(params, ActivePalette.Replace(*s.Background).Background(ActiveColorProfile))
+	}
+	return "\033[" + strings.Join(params, ";") + "m"
+}
+
+// Highlight is a rule highlighting the matches of a pattern in the
+// output of the commands, or the whole lines matching it
+type Highlight struct {
+	Pattern *regexp.Regexp
+	Style   Style
+	Line    bool
+}
+
+// ParseHighlight parses a highlight rule: a regular expression, in
+// double quotes if it has spaces, followed by the options style=<style>
+// (DefaultHighlightStyle if not set) and line, to highlight the
+// whole lines (e.g. `"error|failed" style=red-bold line`)
+func ParseHighlight(rule string) (Highlight, error) {
+	pattern, options, err := CutPattern(rule)
+	if err != nil {
+		return Highlight{}, fmt.Errorf("invalid highlight %q: %w", rule, err)
+	}
+
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return Highlight{}, fmt.Errorf("invalid highlight pattern %q: %w", pattern, err)
+	}
+	h := Highlight{Pattern: re}
+	style := DefaultHighlightStyle
+	for _, option := range strings.Fields(options) {
+		switch name, value, _ := strings.Cut(option, "="); name {
+		case "style":
+			style = value
+		case "line":
+			h.Line = true
+		default:
+			return Highlight{}, fmt.Errorf("invalid highlight %q: unknown option %q (expected style or line)", rule, option)
+		}
+	}
+	if h.Style, err = ParseStyle(style); err != nil {
+		return Highlight{}, err
+	}
+	return h, nil
+}
+
+// CutPattern cuts a regular expression from the start of s, in double
+// quotes if it has spaces (a double quote in the pattern is escaped as
+// \"), and returns the pattern and the rest of s
+func CutPattern(s string) (pattern, rest string, err error) {
+	s = strings.TrimSpace(s)
+	if quoted, ok := strings.CutPrefix(s, `"`); ok {
+		end := closingQuote(quoted)
+		if end < 0 {
+			return "", "", fmt.Errorf("missing closing quote")
+		}
+		pattern, rest = strings.ReplaceAll(quoted[:end], `\"`, `"`), quoted[end+1:]
+	} else {
+		pattern, rest, _ = strings.Cut(s, " ")
+	}
+	if pattern == "" {
+		return "", "", fmt.Errorf("missing pattern")
+	}
+	return pattern, strings.TrimSpace(rest), nil
+}
+
+// closingQuote returns the index of the first double quote not
+// escaped by a backslash, or -1 if there is none
+func closingQuote(s string) int {
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '"':
+			return i
+		}
+	}
+	return -1
+}
+
+// HighlightLine returns the line of output with the matches of the
+// rules highlighted. When rules overlap, the first rule wins
+func HighlightLine(line string, rules []Highlight) string {
+	if len(rules) == 0 || line == "" {
+		return line
+	}
+
+	// Find the rule of each byte of the line, if any
+	styles := make([]*Style, len(line))
+	for i := range rules {
+		rule := &rules[i]
+		var matches [][]int
+		if rule.Line {
+			if rule.Pattern.MatchString(line) {
+				matches = [][]int{{0, len(line)}}
+			}
+		} else {
+			matches = rule.Pattern.FindAllStringIndex(line, -1)
+		}
+		for _, m := range matches {
+			for j := m[0]; j < m[1]; j++ {
+				if styles[j] == nil {
+					styles[j] = &rule.Style
+				}
+			}
+		}
+	}
+
+	// Write the runs of bytes of the same style
+	var b strings.Builder
+	for start := 0; start < len(line); {
+		end := start + 1
+		for end < len(line) && styles[end] == styles[start] {
+			end++
+		}
+		switch {
+		case styles[start] != nil && Accessible:
+			b.WriteString(styles[start].Sequence() + "[" + line[start:end] + "]\033[0m")
+		case styles[start] != nil:
+			b.WriteString(styles[start].Sequence() + line[start:end] + "\033[0m")
+		default:
+			b.WriteString(line[start:end])
+		}
+		start = end
+	}
+	return b.String()
+}
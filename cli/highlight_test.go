@@ -0,0 +1,140 @@
+package cli_test
+
+import (
+	"testing"
+
+	"github.com/bitcanon/autotyper/cli"
+)
+
+// TestParseStyle tests the escape sequences of the styles, and
+// that unknown colors and attributes are errors
+func TestParseStyle(t *testing.T) {
+	defer func(profile cli.ColorProfile) { cli.ActiveColorProfile = profile }(cli.ActiveColorProfile)
+	cli.ActiveColorProfile = cli.ANSI256
+
+	tests := []struct {
+		input    string
+		expected string
+		err      bool
+	}{
+		{input: "red-bold", expected: "\033[1;38;5;1m"},
+		{input: "bold-underline", expected: "\033[1;4m"},
+		{input: "bright-red", expected: "\033[38;5;9m"},
+		{input: "black-on-yellow", expected: "\033[38;5;0;48;5;3m"},
+		{input: "on-#ff0000", expected: "\033[48;5;196m"},
+		{input: "208-reverse", expected: "\033[7;38;5;208m"},
+		{input: "Yellow-Bold", expected: "\033[1;38;5;3m"},
+		{input: "red-blod", err: true},
+		{input: "white-on", err: true},
+		{input: "bright", err: true},
+	}
+
+	for _, test := range tests {
+		style, err := cli.ParseStyle(test.input)
+		if test.err {
+			if err == nil {
+				t.Errorf("%q: expected error, but got %+v", test.input, style)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: expected no error, but got %v", test.input, err)
+			continue
+		}
+		if style.Sequence() != test.expected {
+			t.Errorf("%q: expected %q, but got %q", test.input, test.expected, style.Sequence())
+		}
+	}
+}
+
+// TestParseHighlight tests the parsing of the patterns and the
+// options of the highlight rules
+func TestParseHighlight(t *testing.T) {
+	tests := []struct {
+		input   string
+		pattern string
+		line    bool
+		err     bool
+	}{
+		{input: `"error|failed" style=red-bold`, pattern: "error|failed"},
+		{input: `WARN`, pattern: "WARN"},
+		{input: `"exit code \d+" line`, pattern: `exit code \d+`, line: true},
+		{input: `"say \"hi\"" style=cyan`, pattern: `say "hi"`},
+		{input: `"error`, err: true},
+		{input: `"" style=red`, err: true},
+		{input: `(unclosed style=red`, err: true},
+		{input: `error color=red`, err: true},
+		{input: `error style=purple`, err: true},
+	}
+
+	for _, test := range tests {
+		h, err := cli.ParseHighlight(test.input)
+		if test.err {
+			if err == nil {
+				t.Errorf("%q: expected error, but got %+v", test.input, h)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: expected no error, but got %v", test.input, err)
+			continue
+		}
+		if h.Pattern.String() != test.pattern || h.Line != test.line {
+			t.Errorf("%q: expected pattern %q (line %v), but got %q (line %v)", test.input, test.pattern, test.line, h.Pattern, h.Line)
+		}
+	}
+}
+
+// TestHighlightLine tests that the matches of the rules are
+// highlighted, the first rule winning when they overlap
+func TestHighlightLine(t *testing.T) {
+	defer func(profile cli.ColorProfile) { cli.ActiveColorProfile = profile }(cli.ActiveColorProfile)
+	cli.ActiveColorProfile = cli.ANSI16
+
+	mustParse := func(rule string) cli.Highlight {
+		h, err := cli.ParseHighlight(rule)
+		if err != nil {
+			t.Fatalf("failed to parse %q: %v", rule, err)
+		}
+		return h
+	}
+	red, bold := mustParse("error style=red"), mustParse("err|code style=bold")
+	line := mustParse("FATAL style=reverse line")
+
+	tests := []struct {
+		line     string
+		rules    []cli.Highlight
+		expected string
+	}{
+		{line: "no match", rules: []cli.Highlight{red}, expected: "no match"},
+		{line: "error: error", rules: []cli.Highlight{red}, expected: "\033[31merror\033[0m: \033[31merror\033[0m"},
+		{line: "error code", rules: []cli.Highlight{red, bold}, expected: "\033[31merror\033[0m \033[1mcode\033[0m"},
+		{line: "FATAL error", rules: []cli.Highlight{line, red}, expected: "\033[7mFATAL error\033[0m"},
+		{line: "FATAL error", rules: []cli.Highlight{red, line}, expected: "\033[7mFATAL \033[0m\033[31merror\033[0m"},
+		{line: "", rules: []cli.Highlight{line}, expected: ""},
+	}
+
+	for _, test := range tests {
+		if highlighted := cli.HighlightLine(test.line, test.rules); highlighted != test.expected {
+			t.Errorf("%q: expected %q, but got %q", test.line, test.expected, highlighted)
+		}
+	}
+}
+
+// TestHighlightLineAccessible tests that the highlights are marked
+// with brackets and do not blink in accessible mode
+func TestHighlightLineAccessible(t *testing.T) {
+	defer func(profile cli.ColorProfile) { cli.ActiveColorProfile = profile }(cli.ActiveColorProfile)
+	cli.ActiveColorProfile = cli.ANSI16
+	defer func() { cli.Accessible = false }()
+	cli.Accessible = true
+
+	h, err := cli.ParseHighlight("error style=red-bold-blink")
+	if err != nil {
+		t.Fatalf("failed to parse the rule: %v", err)
+	}
+	expected := "build \033[1;31m[error]\033[0m: exit 1"
+	if highlighted := cli.HighlightLine("build error: exit 1", []cli.Highlight{h}); highlighted != expected {
+		t.Errorf("expected %q, but got %q", expected, highlighted)
+	}
+}
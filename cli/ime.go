@@ -0,0 +1,201 @@
+/*
+Copyright © 2023 Mikael Schultz <bitcanon@proton.me>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cli
+
+import (
+	"io"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/bitcanon/autotyper/vt"
+)
+
+// InputMethod is the input method simulated when typing CJK text
+type InputMethod int
+
+// Define constants for the input methods
+const (
+	NoInputMethod InputMethod = iota
+	Japanese
+	Chinese
+	Korean
+)
+
+// ParseInputMethod returns the input method for the code of its
+// language ("ja", "zh" or "ko", or "" for none). The second return
+// value reports whether the code is a known input method
+func ParseInputMethod(code string) (InputMethod, bool) {
+	switch code {
+	case "":
+		return NoInputMethod, true
+	case "ja":
+		return Japanese, true
+	case "zh":
+		return Chinese, true
+	case "ko":
+		return Korean, true
+	default:
+		return NoInputMethod, false
+	}
+}
+
+// readingPattern matches the readings of CJK words written in the
+// commands as {text|reading} (e.g. "{日本語|nihongo}" or "{你好|nihao}")
+var readingPattern = regexp.MustCompile(`\{([^{}|]*\p{Han}[^{}|]*)\|([A-Za-z0-9' -]+)\}`)
+
+// StripReadings removes the readings of the CJK words from
+// a command, leaving the text that is executed
+func StripReadings(s string) string {
+	return readingPattern.ReplaceAllString(s, "$1")
+}
+
+// imeEdit is a state of the text typed with an input method: the
+// text committed since the previous state, and the text being
+// composed (the preedit), which is shown underlined until committed.
+// The key is the key pressed to reach the state, or 0 if unknown.
+type imeEdit struct {
+	key     rune
+	commit  string
+	preedit string
+}
+
+// TypeComposed types a string like TypeAsHuman, simulating the
+// composition of the CJK text by the input method: the reading is
+// typed first (e.g. "nihongo" as "にほんご"), and then converted to
+// the written text (e.g. "日本語"). The text of the words with a
+// reading is typed as {text|reading}. The delayMs parameter is the
+// delay in milliseconds between each key press.
+func TypeComposed(str string, im InputMethod, out io.Writer, delayMs int) error {
+	return Typist{Delay: delayMs, InputMethod: im}.Type(str, out)
+}
+
+// compose returns the states of the text typed with the input method
+func compose(s string, im InputMethod) []imeEdit {
+	var edits []imeEdit
+	readings := readingPattern.FindAllStringSubmatchIndex(s, -1)
+
+	for i := 0; i < len(s); {
+		// Words with a reading are converted after the reading
+		if len(readings) > 0 && readings[0][0] == i {
+			loc := readings[0]
+			edits = append(edits, convert(s[loc[4]:loc[5]], s[loc[2]:loc[3]], im)...)
+			readings = readings[1:]
+			i = loc[1]
+			continue
+		}
+
+		r, size := utf8.DecodeRuneInString(s[i:])
+		switch {
+		case im == Japanese && isKana(r):
+			end := i
+			for end < len(s) {
+				r, size := utf8.DecodeRuneInString(s[end:])
+				if !isKana(r) || (len(readings) > 0 && readings[0][0] == end) {
+					break
+				}
+				end += size
+			}
+			edits = append(edits, convert(kanaToRomaji(s[i:end]), s[i:end], im)...)
+			i = end
+		case im == Korean && isHangul(r):
+			end := i
+			for end < len(s) {
+				r, size := utf8.DecodeRuneInString(s[end:])
+				if !isHangul(r) {
+					break
+				}
+				end += size
+			}
+			edits = append(edits, composeHangul(s[i:end])...)
+			i = end
+		default:
+			edits = append(edits, imeEdit{key: r, commit: string(r)})
+			i += size
+		}
+	}
+	return edits
+}
+
+// convert returns the states of a word typed as its reading, shown
+// as kana with the Japanese input method, and then converted
+func convert(reading, text string, im InputMethod) []imeEdit {
+	var edits []imeEdit
+	reading = strings.ReplaceAll(reading, " ", "")
+
+	preedit := ""
+	for n := 1; n <= len(reading); n++ {
+		preedit = reading[:n]
+		if im == Japanese {
+			preedit = romajiToKana(preedit)
+		}
+		edits = append(edits, imeEdit{key: rune(reading[n-1]), preedit: preedit})
+	}
+
+	// Show the candidate with space, unless the reading is
+	// the text, and commit it with enter
+	if preedit != text {
+		edits = append(edits, imeEdit{key: ' ', preedit: text})
+	}
+	return append(edits, imeEdit{key: '\n', commit: text})
+}
+
+// Define the initial consonants of the Hangul syllables
+var hangulInitials = []rune("ㄱㄲㄴㄷㄸㄹㅁㅂㅃㅅㅆㅇㅈㅉㅊㅋㅌㅍㅎ")
+
+// isHangul reports whether the character is a Hangul syllable
+func isHangul(r rune) bool {
+	return r >= 0xac00 && r <= 0xd7a3
+}
+
+// composeHangul returns the states of Hangul syllables typed with
+// the Korean input method: the initial consonant, the syllable with
+// its vowel, and the syllable with its final consonant. A syllable
+// is committed when the next one is started.
+func composeHangul(s string) []imeEdit {
+	var edits []imeEdit
+	committed := ""
+	for _, r := range s {
+		index := r - 0xac00
+		initial, final := index/(21*28), index%28
+
+		steps := []string{string(hangulInitials[initial]), string(r - final)}
+		if final > 0 {
+			steps = append(steps, string(r))
+		}
+		for _, step := range steps {
+			edits = append(edits, imeEdit{commit: committed, preedit: step})
+			committed = ""
+		}
+		committed = string(r)
+	}
+	return append(edits, imeEdit{commit: committed})
+}
+
+// textWidth returns the number of columns used by the text
+func textWidth(s string) int {
+	w := 0
+	for _, r := range s {
+		w += vt.RuneWidth(r)
+	}
+	return w
+}
@@ -0,0 +1,97 @@
+package cli_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bitcanon/autotyper/cli"
+	"github.com/bitcanon/autotyper/vt"
+)
+
+// TestStripReadings tests that the readings of the CJK words are
+// removed, and that other braces in commands are kept
+func TestStripReadings(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{input: "echo {日本語|nihongo}", expected: "echo 日本語"},
+		{input: "echo {你好|ni hao} {世界|shijie}", expected: "echo 你好 世界"},
+		{input: "awk '{print $1 | \"sort\"}'", expected: "awk '{print $1 | \"sort\"}'"},
+		{input: "echo {a,b}", expected: "echo {a,b}"},
+	}
+
+	for _, test := range tests {
+		if s := cli.StripReadings(test.input); s != test.expected {
+			t.Errorf("%q: expected %q, but got %q", test.input, test.expected, s)
+		}
+	}
+}
+
+// TestTypeComposed tests that the CJK text is composed with the
+// input method before it is committed, and that the final text on
+// screen is the text of the command
+func TestTypeComposed(t *testing.T) {
+	tests := []struct {
+		name     string
+		im       cli.InputMethod
+		input    string
+		expected string
+		preedits []string
+	}{
+		{
+			name:     "Japanese",
+			im:       cli.Japanese,
+			input:    "echo {日本語|nihongo}です",
+			expected: "echo 日本語です",
+			preedits: []string{"にh", "にほんg", "にほんご", "日本語", "です"},
+		},
+		{
+			name:     "Katakana",
+			im:       cli.Japanese,
+			input:    "echo キッテ",
+			expected: "echo キッテ",
+			preedits: []string{"きっt", "きって", "キッテ"},
+		},
+		{
+			name:     "Chinese",
+			im:       cli.Chinese,
+			input:    "echo {你好|ni hao}",
+			expected: "echo 你好",
+			preedits: []string{"nih", "nihao", "你好"},
+		},
+		{
+			name:     "Korean",
+			im:       cli.Korean,
+			input:    "echo 한국",
+			expected: "echo 한국",
+			preedits: []string{"ㅎ", "하", "한", "ㄱ", "구", "국"},
+		},
+		{
+			name:     "None",
+			im:       cli.NoInputMethod,
+			input:    "echo {日本語|nihongo}",
+			expected: "echo 日本語",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var out strings.Builder
+			if err := cli.TypeComposed(test.input, test.im, &out, 1); err != nil {
+				t.Fatalf("expected no error, but got %v", err)
+			}
+
+			screen := vt.NewScreen(40, 2)
+			screen.Write([]byte(out.String()))
+			if screen.String() != test.expected {
+				t.Errorf("expected %q on screen, but got %q", test.expected, screen.String())
+			}
+			for _, preedit := range test.preedits {
+				if !strings.Contains(out.String(), "\033[4m"+preedit+"\033[24m") {
+					t.Errorf("expected preedit %q in %q", preedit, out.String())
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,164 @@
+/*
+Copyright © 2023 Mikael Schultz <bitcanon@proton.me>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cli
+
+import "strings"
+
+// Define the romaji typed for the hiragana, as with a Japanese
+// input method. The katakana are typed as the hiragana and then
+// converted.
+var romajiKana = map[string]string{
+	"a": "あ", "i": "い", "u": "う", "e": "え", "o": "お",
+	"ka": "か", "ki": "き", "ku": "く", "ke": "け", "ko": "こ",
+	"sa": "さ", "shi": "し", "su": "す", "se": "せ", "so": "そ",
+	"ta": "た", "chi": "ち", "tsu": "つ", "te": "て", "to": "と",
+	"na": "な", "ni": "に", "nu": "ぬ", "ne": "ね", "no": "の",
+	"ha": "は", "hi": "ひ", "fu": "ふ", "he": "へ", "ho": "ほ",
+	"ma": "ま", "mi": "み", "mu": "む", "me": "め", "mo": "も",
+	"ya": "や", "yu": "ゆ", "yo": "よ",
+	"ra": "ら", "ri": "り", "ru": "る", "re": "れ", "ro": "ろ",
+	"wa": "わ", "wo": "を", "nn": "ん",
+	"ga": "が", "gi": "ぎ", "gu": "ぐ", "ge": "げ", "go": "ご",
+	"za": "ざ", "ji": "じ", "zu": "ず", "ze": "ぜ", "zo": "ぞ",
+	"da": "だ", "di": "ぢ", "du": "づ", "de": "で", "do": "ど",
+	"ba": "ば", "bi": "び", "bu": "ぶ", "be": "べ", "bo": "ぼ",
+	"pa": "ぱ", "pi": "ぴ", "pu": "ぷ", "pe": "ぺ", "po": "ぽ",
+	"kya": "きゃ", "kyu": "きゅ", "kyo": "きょ",
+	"sha": "しゃ", "shu": "しゅ", "sho": "しょ",
+	"cha": "ちゃ", "chu": "ちゅ", "cho": "ちょ",
+	"nya": "にゃ", "nyu": "にゅ", "nyo": "にょ",
+	"hya": "ひゃ", "hyu": "ひゅ", "hyo": "ひょ",
+	"mya": "みゃ", "myu": "みゅ", "myo": "みょ",
+	"rya": "りゃ", "ryu": "りゅ", "ryo": "りょ",
+	"gya": "ぎゃ", "gyu": "ぎゅ", "gyo": "ぎょ",
+	"ja": "じゃ", "ju": "じゅ", "jo": "じょ",
+	"bya": "びゃ", "byu": "びゅ", "byo": "びょ",
+	"pya": "ぴゃ", "pyu": "ぴゅ", "pyo": "ぴょ",
+	"xa": "ぁ", "xi": "ぃ", "xu": "ぅ", "xe": "ぇ", "xo": "ぉ",
+	"xya": "ゃ", "xyu": "ゅ", "xyo": "ょ", "xtsu": "っ",
+	"-": "ー",
+}
+
+// kanaRomaji is the reverse of romajiKana
+var kanaRomaji = func() map[string]string {
+	m := make(map[string]string, len(romajiKana))
+	for romaji, kana := range romajiKana {
+		m[kana] = romaji
+	}
+	return m
+}()
+
+// romajiToKana converts the romaji typed so far to the kana shown
+// by a Japanese input method. Letters that do not form a kana yet
+// are kept (e.g. "nihonng" is "にほんg").
+func romajiToKana(romaji string) string {
+	var b strings.Builder
+	for i := 0; i < len(romaji); {
+		rest := romaji[i:]
+
+		// A doubled consonant is a small tsu (e.g. "tte" is "って")
+		if len(rest) > 1 && rest[0] == rest[1] && !isVowel(rest[0]) && rest[0] != 'n' {
+			b.WriteString("っ")
+			i++
+			continue
+		}
+
+		// An n before a consonant is already a n (e.g. "nk")
+		if len(rest) > 1 && rest[0] == 'n' && !isVowel(rest[1]) && rest[1] != 'y' && rest[1] != 'n' {
+			b.WriteString("ん")
+			i++
+			continue
+		}
+
+		matched := false
+		for n := min(4, len(rest)); n > 0; n-- {
+			if kana, ok := romajiKana[rest[:n]]; ok {
+				b.WriteString(kana)
+				i += n
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			b.WriteByte(rest[0])
+			i++
+		}
+	}
+	return b.String()
+}
+
+// kanaToRomaji returns the romaji typed for kana (e.g. "きって"
+// is "kitte"). Katakana are typed as hiragana.
+func kanaToRomaji(kana string) string {
+	runes := []rune(toHiragana(kana))
+
+	var b strings.Builder
+	for i := 0; i < len(runes); i++ {
+		// Combinations with a small ya, yu or yo (e.g. "きゃ")
+		if i+1 < len(runes) {
+			if romaji, ok := kanaRomaji[string(runes[i:i+2])]; ok {
+				b.WriteString(romaji)
+				i++
+				continue
+			}
+		}
+
+		switch r := runes[i]; {
+		case r == 'っ' && i+1 < len(runes):
+			// Double the consonant of the next kana
+			next := kanaToRomaji(string(runes[i+1]))
+			if next != "" && !isVowel(next[0]) {
+				b.WriteByte(next[0])
+				continue
+			}
+			b.WriteString("xtsu")
+		case r == 'ん':
+			b.WriteString("nn")
+		default:
+			if romaji, ok := kanaRomaji[string(r)]; ok {
+				b.WriteString(romaji)
+			} else {
+				b.WriteRune(r)
+			}
+		}
+	}
+	return b.String()
+}
+
+// toHiragana converts the katakana to hiragana
+func toHiragana(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= 'ァ' && r <= 'ヶ' {
+			return r - 0x60
+		}
+		return r
+	}, s)
+}
+
+// isKana reports whether the character is a hiragana or a katakana
+func isKana(r rune) bool {
+	return (r >= 'ぁ' && r <= 'ゖ') || (r >= 'ァ' && r <= 'ヶ') || r == 'ー'
+}
+
+func isVowel(c byte) bool {
+	return strings.IndexByte("aeiou", c) >= 0
+}
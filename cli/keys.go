@@ -0,0 +1,120 @@
+/*
+Copyright © 2023 Mikael Schultz <bitcanon@proton.me>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cli
+
+import (
+	"regexp"
+	"strings"
+)
+
+// keyPattern matches a key of the key notation, a named key
+// (e.g. "<Enter>", "<Up>") or a control key (e.g. "<C-c>")
+var keyPattern = regexp.MustCompile(`<((?i:c)-[^>]|[A-Za-z]+)>`)
+
+// namedKeys are the bytes sent for the named keys of the key
+// notation, by lowercase name. Enter sends a newline, which the
+// terminal and the programs reading from a pipe both accept
+var namedKeys = map[string]string{
+	"enter":    "\n",
+	"cr":       "\r",
+	"tab":      "\t",
+	"space":    " ",
+	"esc":      "\033",
+	"bs":       "\x7f",
+	"del":      "\033[3~",
+	"up":       "\033[A",
+	"down":     "\033[B",
+	"right":    "\033[C",
+	"left":     "\033[D",
+	"home":     "\033[H",
+	"end":      "\033[F",
+	"pageup":   "\033[5~",
+	"pagedown": "\033[6~",
+	"lt":       "<",
+}
+
+// keyBytes returns the bytes sent for the key of the notation
+// without its brackets (e.g. "C-c"). The second return value
+// reports whether the key is known
+func keyBytes(name string) (string, bool) {
+	if len(name) == 3 && (name[0] == 'C' || name[0] == 'c') && name[1] == '-' {
+		c := name[2]
+		switch {
+		case c >= 'a' && c <= 'z':
+			return string(rune(c - 'a' + 1)), true
+		case c >= '@' && c <= '_':
+			return string(rune(c - '@')), true
+		}
+		return "", false
+	}
+	b, ok := namedKeys[strings.ToLower(name)]
+	return b, ok
+}
+
+// ExpandKeys replaces the keys of the key notation in the text (e.g.
+// "<Enter>", "<Tab>", "<C-c>", "<Up>", "<Esc>") by the bytes sent to a
+// program when they are pressed. Unknown keys are left as they are,
+// and "<lt>" is a literal "<"
+func ExpandKeys(text string) string {
+	return keyPattern.ReplaceAllStringFunc(text, func(key string) string {
+		if b, ok := keyBytes(key[1 : len(key)-1]); ok {
+			return b
+		}
+		return key
+	})
+}
+
+// EndsWithKey reports whether the text ends with a known key of the
+// key notation (e.g. "<Tab>")
+func EndsWithKey(text string) bool {
+	locs := keyPattern.FindAllStringIndex(text, -1)
+	if len(locs) == 0 || locs[len(locs)-1][1] != len(text) {
+		return false
+	}
+	last := locs[len(locs)-1]
+	_, ok := keyBytes(text[last[0]+1 : last[1]-1])
+	return ok
+}
+
+// EchoControl returns the text as a terminal echoes it: the control
+// characters other than newlines and tabs are shown in the caret
+// notation (e.g. "^C" for Ctrl-C, "^[[A" for the Up arrow), and a
+// carriage return as a newline
+func EchoControl(text string) string {
+	var b strings.Builder
+	for _, r := range text {
+		switch {
+		case r == '\r':
+			b.WriteByte('\n')
+		case r == '\n' || r == '\t':
+			b.WriteRune(r)
+		case r < 0x20:
+			b.WriteByte('^')
+			b.WriteRune(r + '@')
+		case r == 0x7f:
+			b.WriteString("^?")
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,57 @@
+package cli_test
+
+import (
+	"testing"
+
+	"github.com/bitcanon/autotyper/cli"
+)
+
+// TestExpandKeys tests that the keys of the key notation are replaced
+// by the bytes sent, and that unknown keys are left as they are
+func TestExpandKeys(t *testing.T) {
+	tests := []struct {
+		text     string
+		expected string
+		endsKey  bool
+	}{
+		{text: "ls<Enter>", expected: "ls\n", endsKey: true},
+		{text: "<C-c>", expected: "\x03", endsKey: true},
+		{text: "<c-D>", expected: "\x04", endsKey: true},
+		{text: "<C-[><Esc>", expected: "\033\033", endsKey: true},
+		{text: "git ch<TAB>", expected: "git ch\t", endsKey: true},
+		{text: "<Up><Down><Left><Right>", expected: "\033[A\033[B\033[D\033[C", endsKey: true},
+		{text: "echo <lt>html>", expected: "echo <html>", endsKey: false},
+		{text: "echo <html> <b", expected: "echo <html> <b", endsKey: false},
+		{text: "<C-1><Unknown>", expected: "<C-1><Unknown>", endsKey: false},
+	}
+
+	for _, test := range tests {
+		if got := cli.ExpandKeys(test.text); got != test.expected {
+			t.Errorf("%q: expected %q, but got %q", test.text, test.expected, got)
+		}
+		if got := cli.EndsWithKey(test.text); got != test.endsKey {
+			t.Errorf("%q: expected ends with key %v, but got %v", test.text, test.endsKey, got)
+		}
+	}
+}
+
+// TestEchoControl tests that the control characters are shown in
+// the caret notation, as a terminal echoes them
+func TestEchoControl(t *testing.T) {
+	tests := []struct {
+		text     string
+		expected string
+	}{
+		{text: "yes\n", expected: "yes\n"},
+		{text: "a\tb\r", expected: "a\tb\n"},
+		{text: "\x03\x04", expected: "^C^D"},
+		{text: "\033[A\x7f", expected: "^[[A^?"},
+		{text: "héllo", expected: "héllo"},
+	}
+
+	for _, test := range tests {
+		if got := cli.EchoControl(test.text); got != test.expected {
+			t.Errorf("%q: expected %q, but got %q", test.text, test.expected, got)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+/*
+Copyright © 2023 Mikael Schultz <bitcanon@proton.me>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cli
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// DefaultMotd is the message of the day printed when none
+// has been configured, as printed by an Ubuntu server
+const DefaultMotd = `Welcome to Ubuntu 22.04.3 LTS (GNU/Linux 5.15.0-91-generic x86_64)
+
+ * Documentation:  https://help.ubuntu.com
+ * Management:     https://landscape.canonical.com
+ * Support:        https://ubuntu.com/pro
+`
+
+// SystemInfo is the system summary printed like neofetch does.
+// Empty fields are not printed
+type SystemInfo struct {
+	OS     string
+	Kernel string
+	Uptime string
+	Shell  string
+	CPU    string
+	Memory string
+}
+
+// DefaultSystemInfo is the summary of the system used for the
+// fields that have not been configured
+var DefaultSystemInfo = SystemInfo{
+	OS:     "Ubuntu 22.04.3 LTS x86_64",
+	Kernel: "5.15.0-91-generic",
+	Uptime: "12 days, 3 hours, 41 mins",
+	Shell:  "bash 5.1.16",
+	CPU:    "Intel Xeon Platinum 8272CL (4) @ 2.593GHz",
+	Memory: "1489MiB / 7937MiB",
+}
+
+// WithDefaults returns the summary with the empty fields
+// replaced by those of DefaultSystemInfo
+func (s SystemInfo) WithDefaults() SystemInfo {
+	for _, f := range []struct {
+		field *string
+		value string
+	}{
+		{&s.OS, DefaultSystemInfo.OS},
+		{&s.Kernel, DefaultSystemInfo.Kernel},
+		{&s.Uptime, DefaultSystemInfo.Uptime},
+		{&s.Shell, DefaultSystemInfo.Shell},
+		{&s.CPU, DefaultSystemInfo.CPU},
+		{&s.Memory, DefaultSystemInfo.Memory},
+	} {
+		if *f.field == "" {
+			*f.field = f.value
+		}
+	}
+	return s
+}
+
+// PrintMotd prints the message of the day, ending with a newline
+func PrintMotd(motd string, out io.Writer) {
+	fmt.Fprint(out, motd)
+	if !strings.HasSuffix(motd, "\n") {
+		fmt.Fprintln(out)
+	}
+}
+
+// PrintSystemInfo prints the summary of the system with the user
+// and the host as its title, in the colors of the active theme
+func PrintSystemInfo(info SystemInfo, user, host string, out io.Writer) {
+	title := user + "@" + host
+	key := colorSequence(ActiveTheme.Username)
+	fmt.Fprintf(out, "%s%s\033[0m\n%s\n", key, title, strings.Repeat("-", len(title)))
+
+	for _, line := range []struct{ name, value string }{
+		{"OS", info.OS},
+		{"Kernel", info.Kernel},
+		{"Uptime", info.Uptime},
+		{"Shell", info.Shell},
+		{"CPU", info.CPU},
+		{"Memory", info.Memory},
+	} {
+		if line.value != "" {
+			fmt.Fprintf(out, "%s%s\033[0m: %s\n", key, line.name, line.value)
+		}
+	}
+	fmt.Fprintln(out)
+}
@@ -0,0 +1,38 @@
+package cli_test
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/bitcanon/autotyper/cli"
+)
+
+// stripColors removes the color escape sequences from the output
+func stripColors(s string) string {
+	return regexp.MustCompile("\033\\[[0-9;]*m").ReplaceAllString(s, "")
+}
+
+// TestSystemInfoWithDefaults tests that only the empty fields
+// are replaced by the defaults
+func TestSystemInfoWithDefaults(t *testing.T) {
+	info := cli.SystemInfo{OS: "Alpine Linux v3.19", Memory: "64MiB / 512MiB"}.WithDefaults()
+	expected := cli.DefaultSystemInfo
+	expected.OS, expected.Memory = "Alpine Linux v3.19", "64MiB / 512MiB"
+	if info != expected {
+		t.Errorf("expected %+v, but got %+v", expected, info)
+	}
+}
+
+// TestPrintSystemInfo tests that the summary is titled with the
+// user and the host, and that empty fields are not printed
+func TestPrintSystemInfo(t *testing.T) {
+	var out strings.Builder
+	cli.PrintSystemInfo(cli.SystemInfo{OS: "Alpine Linux v3.19", Shell: "ash"}, "root", "box", &out)
+
+	lines := strings.Split(stripColors(out.String()), "\n")
+	expected := []string{"root@box", "--------", "OS: Alpine Linux v3.19", "Shell: ash", "", ""}
+	if strings.Join(lines, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("expected %q, but got %q", expected, lines)
+	}
+}
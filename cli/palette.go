@@ -0,0 +1,139 @@
+/*
+Copyright © 2023 Mikael Schultz <bitcanon@proton.me>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cli
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// Palette replaces the colors of the theme and the system colors of
+// the terminal (red, green, ...) with colors that stay apart for the
+// viewers with a color vision deficiency
+type Palette struct {
+	Theme Theme
+
+	// The colors replacing the system colors, by their index in
+	// the palette of the terminal (1 for red, 9 for bright red)
+	System map[int]Color
+}
+
+// Palettes are the colorblind-safe palettes by name. They are based
+// on the palette of Okabe and Ito, with the red and the green of the
+// output replaced by an orange and a blue, as in the colorblind
+// themes of GitHub, and the other colors picked to stay apart once
+// the deficiency is simulated
+var Palettes = map[string]Palette{
+	"deuteranopia": {
+		Theme: Theme{
+			Username: RGBColor(0x00, 0x9e, 0x73),
+			Path:     RGBColor(0x56, 0xb4, 0xe9),
+			Command:  RGBColor(0xf0, 0xe4, 0x42),
+			Caption:  PaletteColor(244),
+		},
+		System: map[int]Color{
+			1: RGBColor(0xd5, 0x5e, 0x00), 9: RGBColor(0xf0, 0x8a, 0x4b),
+			2: RGBColor(0x56, 0xb4, 0xe9), 10: RGBColor(0x9a, 0xd3, 0xf5),
+			3: RGBColor(0xf0, 0xe4, 0x42), 11: RGBColor(0xf7, 0xef, 0x8f),
+			4: RGBColor(0x00, 0x72, 0xb2), 12: RGBColor(0x33, 0x8e, 0xd1),
+			5: RGBColor(0xcc, 0x44, 0x88), 13: RGBColor(0xdd, 0x55, 0xaa),
+			6: RGBColor(0x44, 0xdd, 0xcc), 14: RGBColor(0x88, 0xee, 0xcc),
+		},
+	},
+	"protanopia": {
+		Theme: Theme{
+			Username: RGBColor(0x00, 0x9e, 0x73),
+			Path:     RGBColor(0x56, 0xb4, 0xe9),
+			Command:  RGBColor(0xf0, 0xe4, 0x42),
+			Caption:  PaletteColor(244),
+		},
+		System: map[int]Color{
+			1: RGBColor(0xe6, 0x61, 0x00), 9: RGBColor(0xff, 0x8f, 0x40),
+			2: RGBColor(0x56, 0xb4, 0xe9), 10: RGBColor(0x9a, 0xd3, 0xf5),
+			3: RGBColor(0xf0, 0xe4, 0x42), 11: RGBColor(0xf7, 0xef, 0x8f),
+			4: RGBColor(0x00, 0x72, 0xb2), 12: RGBColor(0x33, 0x8e, 0xd1),
+			5: RGBColor(0xbb, 0x33, 0xdd), 13: RGBColor(0xdd, 0x66, 0xff),
+			6: RGBColor(0x44, 0xcc, 0xaa), 14: RGBColor(0x88, 0xee, 0xcc),
+		},
+	},
+}
+
+// ActivePalette is the palette replacing the system colors of the
+// styles and of the terminal, none if nil
+var ActivePalette *Palette
+
+// PaletteNames returns the names of the palettes in order
+func PaletteNames() []string {
+	var names []string
+	for name := range Palettes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ParsePalette returns the palette for its name, or nil
+// for the default colors ("default" or "")
+func ParsePalette(name string) (*Palette, error) {
+	if name == "" || name == "default" {
+		return nil, nil
+	}
+	palette, ok := Palettes[strings.ToLower(name)]
+	if !ok {
+		return nil, fmt.Errorf("unknown palette %q (expected default, %s)", name, strings.Join(PaletteNames(), ", "))
+	}
+	return &palette, nil
+}
+
+// Replace returns the color replacing a system color in the palette,
+// or the color itself
+func (p *Palette) Replace(c Color) Color {
+	if p == nil {
+		return c
+	}
+	if replacement, ok := p.System[c.Index]; ok {
+		return replacement
+	}
+	return c
+}
+
+// SetSystemColors redefines the system colors of the terminal with
+// the colors of the palette, so that the output of the commands is
+// drawn in them too (e.g. the red of the errors)
+func SetSystemColors(out io.Writer, p *Palette) {
+	var b strings.Builder
+	for index := 0; index < 16; index++ {
+		c, ok := p.System[index]
+		if !ok {
+			continue
+		}
+		fmt.Fprintf(&b, "\033]4;%d;rgb:%02x/%02x/%02x\a", index, c.R, c.G, c.B)
+	}
+	fmt.Fprint(out, b.String())
+}
+
+// ResetSystemColors restores the system colors of the terminal
+func ResetSystemColors(out io.Writer) {
+	fmt.Fprint(out, "\033]104\a")
+}
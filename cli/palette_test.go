@@ -0,0 +1,153 @@
+package cli_test
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/bitcanon/autotyper/cli"
+)
+
+// deficiencies are the matrices of Machado et al. (2009) simulating
+// the colors seen with a severe color vision deficiency, applied to
+// linear RGB values
+var deficiencies = map[string][3][3]float64{
+	"deuteranopia": {
+		{0.367322, 0.860646, -0.227968},
+		{0.280085, 0.672501, 0.047413},
+		{-0.011820, 0.042940, 0.968881},
+	},
+	"protanopia": {
+		{0.152286, 1.052583, -0.204868},
+		{0.114503, 0.786281, 0.099216},
+		{-0.003882, -0.048116, 1.051998},
+	},
+}
+
+// lab returns the CIELAB values of a color seen with a deficiency
+func lab(c cli.Color, m [3][3]float64) [3]float64 {
+	linear := func(v uint8) float64 {
+		s := float64(v) / 255
+		if s <= 0.04045 {
+			return s / 12.92
+		}
+		return math.Pow((s+0.055)/1.055, 2.4)
+	}
+	rgb := [3]float64{linear(c.R), linear(c.G), linear(c.B)}
+	var sim [3]float64
+	for i := range sim {
+		sim[i] = math.Max(0, m[i][0]*rgb[0]+m[i][1]*rgb[1]+m[i][2]*rgb[2])
+	}
+
+	x := (0.4124*sim[0] + 0.3576*sim[1] + 0.1805*sim[2]) / 0.95047
+	y := 0.2126*sim[0] + 0.7152*sim[1] + 0.0722*sim[2]
+	z := (0.0193*sim[0] + 0.1192*sim[1] + 0.9505*sim[2]) / 1.08883
+	f := func(t float64) float64 {
+		if t > 0.008856 {
+			return math.Cbrt(t)
+		}
+		return 7.787*t + 16.0/116
+	}
+	return [3]float64{116*f(y) - 16, 500 * (f(x) - f(y)), 200 * (f(y) - f(z))}
+}
+
+// distance returns the color difference (CIE76) of two colors
+// seen with a deficiency
+func distance(a, b cli.Color, m [3][3]float64) float64 {
+	la, lb := lab(a, m), lab(b, m)
+	return math.Sqrt((la[0]-lb[0])*(la[0]-lb[0]) + (la[1]-lb[1])*(la[1]-lb[1]) + (la[2]-lb[2])*(la[2]-lb[2]))
+}
+
+// TestPalettesDistinct tests that the system colors and the colors
+// of the prompt of each palette remain apart when seen with its color
+// vision deficiency
+func TestPalettesDistinct(t *testing.T) {
+	const minDistance = 20
+	for name, m := range deficiencies {
+		p, err := cli.ParsePalette(name)
+		if err != nil {
+			t.Fatalf("expected no error, but got %v", err)
+		}
+
+		groups := [][]cli.Color{{p.Theme.Username, p.Theme.Path, p.Theme.Command}}
+		for _, base := range []int{1, 9} {
+			var group []cli.Color
+			for i := base; i < base+6; i++ {
+				group = append(group, p.System[i])
+			}
+			groups = append(groups, group)
+		}
+		for _, group := range groups {
+			for i := range group {
+				for j := i + 1; j < len(group); j++ {
+					if d := distance(group[i], group[j], m); d < minDistance {
+						t.Errorf("%s: colors %v and %v are %.1f apart, expected at least %d", name, group[i], group[j], d, minDistance)
+					}
+				}
+			}
+		}
+	}
+}
+
+// TestParsePalette tests that the palettes are found by name,
+// and that the default colors have none
+func TestParsePalette(t *testing.T) {
+	for _, name := range []string{"", "default"} {
+		if p, err := cli.ParsePalette(name); p != nil || err != nil {
+			t.Errorf("%q: expected no palette, but got %v, %v", name, p, err)
+		}
+	}
+	for _, name := range []string{"deuteranopia", "Protanopia"} {
+		if p, err := cli.ParsePalette(name); p == nil || err != nil {
+			t.Errorf("%q: expected a palette, but got %v, %v", name, p, err)
+		}
+	}
+	if _, err := cli.ParsePalette("sepia"); err == nil {
+		t.Errorf("expected error for an unknown palette, but got nil")
+	}
+}
+
+// TestPaletteStyles tests that the system colors of the styles are
+// replaced by the colors of the active palette, and the other colors
+// are kept
+func TestPaletteStyles(t *testing.T) {
+	defer func(p *cli.Palette, profile cli.ColorProfile) {
+		cli.ActivePalette, cli.ActiveColorProfile = p, profile
+	}(cli.ActivePalette, cli.ActiveColorProfile)
+	cli.ActivePalette, _ = cli.ParsePalette("deuteranopia")
+	cli.ActiveColorProfile = cli.TrueColor
+
+	tests := []struct {
+		style    string
+		expected string
+	}{
+		{style: "red-bold", expected: "\033[1;38;2;213;94;0m"},
+		{style: "black-on-green", expected: "\033[38;5;0;48;2;86;180;233m"},
+		{style: "bright-red", expected: "\033[38;2;240;138;75m"},
+		{style: "#123456", expected: "\033[38;2;18;52;86m"},
+	}
+
+	for _, test := range tests {
+		style, err := cli.ParseStyle(test.style)
+		if err != nil {
+			t.Fatalf("%s: expected no error, but got %v", test.style, err)
+		}
+		if got := style.Sequence(); got != test.expected {
+			t.Errorf("%s: expected %q, but got %q", test.style, test.expected, got)
+		}
+	}
+}
+
+// TestSetSystemColors tests that the system colors of the terminal are
+// redefined in order with OSC 4, and restored with OSC 104
+func TestSetSystemColors(t *testing.T) {
+	p := &cli.Palette{System: map[int]cli.Color{9: cli.RGBColor(0xf0, 0x8a, 0x4b), 1: cli.RGBColor(0xd5, 0x5e, 0x00)}}
+
+	var out strings.Builder
+	cli.SetSystemColors(&out, p)
+	cli.ResetSystemColors(&out)
+	expected := "\033]4;1;rgb:d5/5e/00\a\033]4;9;rgb:f0/8a/4b\a\033]104\a"
+	if out.String() != expected {
+		t.Errorf("expected %q, but got %q", expected, out.String())
+	}
+}
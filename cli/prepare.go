@@ -0,0 +1,104 @@
+/*
+Copyright © 2023 Mikael Schultz <bitcanon@proton.me>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cli
+
+import (
+	"log/slog"
+	"os/exec"
+	"strings"
+	"sync"
+)
+
+// resolution is the executable of a command resolved by Prepare
+type resolution struct {
+	command string
+	done    chan struct{}
+	path    string
+	err     error
+}
+
+// prepared is the command prepared last, until it is executed
+var (
+	preparedMu sync.Mutex
+	prepared   *resolution
+)
+
+// Prepare starts resolving the executable of the command (searching
+// the PATH, which can be slow on Windows and with network drives) in
+// the background, while the command is being typed, so that it starts
+// at once when it is executed. Only the command prepared last is kept.
+// Paths are not searched, and are relative to the working directory of
+// the shell of the command, so they are not prepared.
+func Prepare(command string) {
+	name, _, _ := strings.Cut(command, " ")
+	if strings.ContainsAny(name, `/\`) {
+		return
+	}
+	r := &resolution{command: command, done: make(chan struct{})}
+	go func() {
+		defer close(r.done)
+		r.path, r.err = exec.LookPath(name)
+	}()
+
+	preparedMu.Lock()
+	defer preparedMu.Unlock()
+	prepared = r
+}
+
+// newCommand returns the command of the command line split into its
+// name and arguments, in the working directory and the environment of
+// the shell if it is not nil, with the executable resolved by Prepare
+// if the command line was prepared
+func newCommand(shell *Shell, command string, name string, args []string) *exec.Cmd {
+	cmd := lookCommand(command, name, args)
+	if shell != nil {
+		cmd.Dir = shell.Dir
+		cmd.Env = shell.Env
+	}
+	return cmd
+}
+
+// lookCommand returns the command of the command line, with the
+// executable resolved by Prepare if the command line was prepared
+func lookCommand(command string, name string, args []string) *exec.Cmd {
+	preparedMu.Lock()
+	r := prepared
+	if r != nil && r.command == command {
+		prepared = nil
+	} else {
+		r = nil
+	}
+	preparedMu.Unlock()
+
+	// Commands that were not found are resolved again, for the
+	// error of exec.Command
+	if r != nil {
+		<-r.done
+		if r.err == nil {
+			slog.Debug("using prepared command", "name", name, "path", r.path)
+			cmd := exec.Command(r.path, args...)
+			cmd.Args[0] = name
+			return cmd
+		}
+	}
+	return exec.Command(name, args...)
+}
@@ -0,0 +1,55 @@
+package cli_test
+
+import (
+	"bytes"
+	"log/slog"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/bitcanon/autotyper/cli"
+)
+
+// TestPrepare tests that the executable of a prepared command is
+// used once it is executed, and only for that command line
+func TestPrepare(t *testing.T) {
+	if _, err := exec.LookPath("echo"); err != nil {
+		t.Skip("echo is not available")
+	}
+
+	var logs bytes.Buffer
+	defer slog.SetDefault(slog.Default())
+	slog.SetDefault(slog.New(slog.NewTextHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug})))
+
+	tests := []struct {
+		name     string
+		prepare  string
+		command  string
+		output   string
+		prepared bool
+		failed   bool
+	}{
+		{name: "Prepared", prepare: "echo hello", command: "echo hello", output: "hello\n", prepared: true},
+		{name: "OtherCommand", prepare: "echo other", command: "echo hello", output: "hello\n"},
+		{name: "NotFound", prepare: "no-such-command-1225", command: "no-such-command-1225", failed: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			logs.Reset()
+			cli.Prepare(test.prepare)
+
+			var out bytes.Buffer
+			err := cli.ExecuteCommand(test.command, &out)
+			if (err != nil) != test.failed {
+				t.Fatalf("expected failure %v, but got %v", test.failed, err)
+			}
+			if out.String() != test.output {
+				t.Errorf("expected output %q, but got %q", test.output, out.String())
+			}
+			if used := strings.Contains(logs.String(), "using prepared command"); used != test.prepared {
+				t.Errorf("expected the prepared command to be used %v, but got %v", test.prepared, used)
+			}
+		})
+	}
+}
@@ -0,0 +1,86 @@
+/*
+Copyright © 2023 Mikael Schultz <bitcanon@proton.me>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cli
+
+import (
+	"io"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/creack/pty"
+)
+
+// ErrPTYUnsupported is returned by ExecuteCommandPTY on
+// platforms without pseudo-terminals
+var ErrPTYUnsupported = pty.ErrUnsupported
+
+// PTYCols and PTYRows are the size of the pseudo-terminals of the
+// commands. If zero, the size of the pseudo-terminals is not set.
+var PTYCols, PTYRows int
+
+// ExecuteCommandPTY executes a command in a pseudo-terminal, so that
+// programs reading from the terminal (e.g. password prompts) can be
+// driven. The output of the terminal (including the echo of the input)
+// is copied to out, and session is called in its own goroutine with
+// the input of the terminal. The command runs in the shell, like with
+// ExecuteCommandInput. Commands refused by the CommandPolicy are not
+// executed and a *DeniedError is returned.
+func ExecuteCommandPTY(shell *Shell, command string, out io.Writer, session func(in io.Writer)) error {
+	if err := CommandPolicy.Check(command); err != nil {
+		slog.Info("command denied", "command", command, "error", err)
+		return err
+	}
+
+	cmdList := strings.Split(command, " ")
+	cmd := newCommand(shell, command, cmdList[0], cmdList[1:])
+	var size *pty.Winsize
+	if PTYCols > 0 && PTYRows > 0 {
+		size = &pty.Winsize{Cols: uint16(PTYCols), Rows: uint16(PTYRows)}
+	}
+	slog.Debug("executing command in a pseudo-terminal", "name", cmdList[0], "args", cmdList[1:])
+	start := time.Now()
+	ptmx, err := pty.StartWithSize(cmd, size)
+	if err != nil {
+		logExit(command, err, time.Since(start))
+		return err
+	}
+	defer ptmx.Close()
+
+	// Copy the output until the terminal is closed, which is
+	// reported as an I/O error on Linux once the command exits
+	copied := make(chan struct{})
+	go func() {
+		defer close(copied)
+		io.Copy(out, ptmx)
+	}()
+
+	go session(ptmx)
+
+	// The command leads a session of its own in the terminal
+	untrack := track(cmd.Process, true)
+	err = cmd.Wait()
+	untrack()
+	<-copied
+	logExit(command, err, time.Since(start))
+	return err
+}
@@ -0,0 +1,125 @@
+/*
+Copyright © 2023 Mikael Schultz <bitcanon@proton.me>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cli
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/bitcanon/autotyper/locale"
+)
+
+// The parts of the pattern of rm with the recursive and the force
+// flags, in any order, as short flags (together or apart) or as long
+// flags, among the other arguments of the same command
+const (
+	rmArgs      = `(\s+[^\s;&|]+)*\s+`
+	rmRecursive = `(-\w*[rR]\w*|--recursive)`
+	rmForce     = `(-\w*f\w*|--force)`
+	rmBoth      = `-\w*([rR]\w*f|f\w*[rR])\w*`
+)
+
+// DangerousPatterns are the default patterns of commands that
+// should not be executed without a confirmation
+var DangerousPatterns = []string{
+	`\brm` + rmArgs + `(` + rmBoth + `|` + rmRecursive + rmArgs + rmForce + `|` + rmForce + rmArgs + rmRecursive + `)(\s|[;&|]|$)`,
+	`\bdd\s`,
+	`\bmkfs\b`,
+	`(?i)\bdrop\s+(table|database)\b`,
+}
+
+// CompilePatterns compiles a list of regular expressions
+func CompilePatterns(patterns []string) ([]*regexp.Regexp, error) {
+	compiled := make([]*regexp.Regexp, 0, len(patterns))
+	for _, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+		}
+		compiled = append(compiled, re)
+	}
+	return compiled, nil
+}
+
+// MatchAny reports whether the command matches any of the patterns
+func MatchAny(command string, patterns []*regexp.Regexp) bool {
+	for _, re := range patterns {
+		if re.MatchString(command) {
+			return true
+		}
+	}
+	return false
+}
+
+// Policy decides which commands may be executed. A command matching
+// a pattern in Deny is refused. If Allow is not empty, a command must
+// also match one of its patterns to be executed
+type Policy struct {
+	Deny  []*regexp.Regexp
+	Allow []*regexp.Regexp
+}
+
+// CommandPolicy is the policy enforced by ExecuteCommand. The
+// zero value allows all commands
+var CommandPolicy Policy
+
+// DeniedError is returned when a command is refused by the policy
+type DeniedError struct {
+	Command string
+	Reason  string
+}
+
+// Error returns the reason the command was refused
+func (e *DeniedError) Error() string {
+	return fmt.Sprintf("command not executed (%s): %s", e.Reason, e.Command)
+}
+
+// Check returns a *DeniedError if the command may not be executed
+func (p Policy) Check(command string) error {
+	for _, re := range p.Deny {
+		if re.MatchString(command) {
+			return &DeniedError{Command: command, Reason: fmt.Sprintf("matches denylist pattern %q", re)}
+		}
+	}
+	if len(p.Allow) > 0 && !MatchAny(command, p.Allow) {
+		return &DeniedError{Command: command, Reason: "not in allowlist"}
+	}
+	return nil
+}
+
+// Confirm prints a yes/no question to out and reads the answer from
+// in. Only an answer starting with "y" (or "Y") is a confirmation
+func Confirm(question string, in io.Reader, out io.Writer) (bool, error) {
+	fmt.Fprintf(out, "%s %s ", question, locale.T("[y/N]"))
+
+	reader := bufio.NewReader(in)
+	answer, err := reader.ReadString('\n')
+	if err != nil && (err != io.EOF || answer == "") {
+		return false, err
+	}
+
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return strings.HasPrefix(answer, "y") || strings.HasPrefix(answer, locale.T("y")), nil
+}
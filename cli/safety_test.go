@@ -0,0 +1,148 @@
+package cli_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/bitcanon/autotyper/cli"
+)
+
+// TestDangerousPatterns tests that the default patterns match
+// destructive commands but not similar harmless commands
+func TestDangerousPatterns(t *testing.T) {
+	patterns, err := cli.CompilePatterns(cli.DangerousPatterns)
+	if err != nil {
+		t.Fatalf("expected no error, but got %v", err)
+	}
+
+	tests := []struct {
+		command  string
+		expected bool
+	}{
+		{"rm -rf /", true},
+		{"rm -fr build", true},
+		{"rm -v -Rf build", true},
+		{"rm -r -f /", true},
+		{"rm -f -r /", true},
+		{"rm -R -v -f build", true},
+		{"rm --recursive --force /", true},
+		{"rm --force --recursive /", true},
+		{"rm -r --force build", true},
+		{"rm build -rf", true},
+		{"sudo rm -rf /", true},
+		{"sudo dd if=/dev/zero of=/dev/sda", true},
+		{"mkfs.ext4 /dev/sdb1", true},
+		{"psql -c 'drop table users'", true},
+		{"rm file.txt", false},
+		{"rm -r build", false},
+		{"rm -f file.txt", false},
+		{"rm --recursive build", false},
+		{"rm -ri build", false},
+		{"rm -r build; cp -f a b", false},
+		{"rm -r build && rm -f file.txt", false},
+		{"rm build-r -f", false},
+		{"ls -la", false},
+		{"echo add", false},
+	}
+
+	for _, test := range tests {
+		if got := cli.MatchAny(test.command, patterns); got != test.expected {
+			t.Errorf("expected %v for %q, but got %v", test.expected, test.command, got)
+		}
+	}
+}
+
+// TestCompilePatterns tests that invalid patterns are reported
+func TestCompilePatterns(t *testing.T) {
+	if _, err := cli.CompilePatterns([]string{"rm -rf", "("}); err == nil {
+		t.Errorf("expected error, but got nil")
+	}
+}
+
+// TestConfirm tests that only answers starting with "y" confirm
+func TestConfirm(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		{"y\n", true},
+		{"Yes\n", true},
+		{"n\n", false},
+		{"\n", false},
+	}
+
+	for _, test := range tests {
+		var out strings.Builder
+		got, err := cli.Confirm("Really?", strings.NewReader(test.input), &out)
+		if err != nil {
+			t.Fatalf("expected no error, but got %v", err)
+		}
+		if got != test.expected {
+			t.Errorf("expected %v for %q, but got %v", test.expected, test.input, got)
+		}
+		if out.String() != "Really? [y/N] " {
+			t.Errorf("expected question %q, but got %q", "Really? [y/N] ", out.String())
+		}
+	}
+}
+
+// TestPolicyCheck tests the denylist and allowlist of a policy
+func TestPolicyCheck(t *testing.T) {
+	deny, _ := cli.CompilePatterns([]string{`^kubectl delete`})
+	allow, _ := cli.CompilePatterns([]string{`^kubectl `, `^echo `})
+
+	tests := []struct {
+		name    string
+		policy  cli.Policy
+		command string
+		denied  bool
+	}{
+		{name: "ZeroPolicy", command: "rm -rf /", denied: false},
+		{name: "Denied", policy: cli.Policy{Deny: deny}, command: "kubectl delete pod web", denied: true},
+		{name: "NotDenied", policy: cli.Policy{Deny: deny}, command: "kubectl get pods", denied: false},
+		{name: "Allowed", policy: cli.Policy{Allow: allow}, command: "echo hello", denied: false},
+		{name: "NotAllowed", policy: cli.Policy{Allow: allow}, command: "curl example.com", denied: true},
+		{name: "DenyBeforeAllow", policy: cli.Policy{Deny: deny, Allow: allow}, command: "kubectl delete pod web", denied: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := test.policy.Check(test.command)
+			if test.denied && err == nil {
+				t.Errorf("expected %q to be denied, but got nil", test.command)
+			}
+			if !test.denied && err != nil {
+				t.Errorf("expected %q to be allowed, but got %v", test.command, err)
+			}
+		})
+	}
+}
+
+// TestExecuteCommandPolicy tests that ExecuteCommand
+// does not execute commands refused by the policy
+func TestExecuteCommandPolicy(t *testing.T) {
+	original := cli.CommandPolicy
+	defer func() { cli.CommandPolicy = original }()
+
+	deny, _ := cli.CompilePatterns([]string{`^echo secret`})
+	cli.CommandPolicy = cli.Policy{Deny: deny}
+
+	var out strings.Builder
+	err := cli.ExecuteCommand("echo secret", &out)
+
+	var denied *cli.DeniedError
+	if !errors.As(err, &denied) {
+		t.Fatalf("expected a *cli.DeniedError, but got %v", err)
+	}
+	if out.Len() != 0 {
+		t.Errorf("expected no output, but got %q", out.String())
+	}
+
+	if err := cli.ExecuteCommand("echo public", &out); err != nil {
+		t.Errorf("expected no error, but got %v", err)
+	}
+	if out.String() != "public\n" {
+		t.Errorf("expected %q, but got %q", "public\n", out.String())
+	}
+}
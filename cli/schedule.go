@@ -0,0 +1,59 @@
+/*
+Copyright © 2023 Mikael Schultz <bitcanon@proton.me>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cli
+
+import "time"
+
+// maxLag is how far behind its deadlines a schedule may fall before
+// it starts over, so the keys are not rushed after a long hold
+const maxLag = time.Second
+
+// Schedule paces a series of delays (e.g. between the keys of a
+// command) against deadlines on a clock, rather than sleeping
+// each delay in turn. A sleep waking up late, as it often does with the
+// coarse timers of Windows, shortens the next delay, so the series
+// lasts the sum of its delays instead of drifting.
+type Schedule struct {
+	clock Clock
+	next  time.Time
+}
+
+// NewSchedule starts a schedule at the current time of the clock,
+// or of the system clock if nil
+func NewSchedule(clock Clock) *Schedule {
+	if clock == nil {
+		clock = SystemClock{}
+	}
+	return &Schedule{clock: clock, next: clock.Now()}
+}
+
+// Wait waits until the delay has passed since the previous deadline
+func (s *Schedule) Wait(d time.Duration) {
+	now := s.clock.Now()
+	if now.Sub(s.next) > maxLag {
+		s.next = now
+	}
+	s.next = s.next.Add(d)
+	if wait := s.next.Sub(now); wait > 0 {
+		s.clock.Sleep(wait)
+	}
+}
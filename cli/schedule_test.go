@@ -0,0 +1,56 @@
+package cli_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bitcanon/autotyper/cli"
+)
+
+// TestSchedule tests that the delays of a schedule make up for the
+// sleeps waking up late, unless the schedule is too far behind
+func TestSchedule(t *testing.T) {
+	tests := []struct {
+		name     string
+		late     time.Duration
+		delays   []time.Duration
+		expected time.Duration
+	}{
+		{
+			name:     "OnTime",
+			delays:   []time.Duration{10 * time.Millisecond, 20 * time.Millisecond, 30 * time.Millisecond},
+			expected: 60 * time.Millisecond,
+		},
+		{
+			name:     "Late",
+			late:     4 * time.Millisecond,
+			delays:   []time.Duration{10 * time.Millisecond, 10 * time.Millisecond, 10 * time.Millisecond, 10 * time.Millisecond},
+			expected: 44 * time.Millisecond,
+		},
+		{
+			name:     "LaterThanTheDelays",
+			late:     15 * time.Millisecond,
+			delays:   []time.Duration{10 * time.Millisecond, 10 * time.Millisecond, 10 * time.Millisecond, 10 * time.Millisecond},
+			expected: 45 * time.Millisecond,
+		},
+		{
+			name:     "TooFarBehind",
+			late:     2 * time.Second,
+			delays:   []time.Duration{10 * time.Millisecond, 10 * time.Millisecond},
+			expected: 4020 * time.Millisecond,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			clock := cli.NewLateFakeClock(time.Now(), test.late)
+			schedule := cli.NewSchedule(clock)
+			for _, d := range test.delays {
+				schedule.Wait(d)
+			}
+			if clock.Slept() != test.expected {
+				t.Errorf("expected %v slept, but got %v", test.expected, clock.Slept())
+			}
+		})
+	}
+}
@@ -0,0 +1,340 @@
+/*
+Copyright © 2023 Mikael Schultz <bitcanon@proton.me>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// Shell is the state of the shell simulated for the commands, which
+// are executed directly rather than by a shell: the working directory,
+// the environment, and the aliases changed by the builtins of the shell
+// (cd, export, set, alias, and unset), which cannot be executed.
+// The executables are still looked up in the PATH of autotyper.
+// The zero value is the shell of autotyper itself
+type Shell struct {
+	// The working directory of the commands, or the working
+	// directory of autotyper if empty. The directories changed
+	// to are relative to the path of the prompt until it is set
+	Dir string
+
+	// The environment of the commands, or the environment of
+	// autotyper if nil
+	Env []string
+
+	// The aliases of the commands, by name
+	Aliases map[string]string
+}
+
+// WorkDir returns the working directory of the commands
+func (s *Shell) WorkDir() string {
+	if s.Dir != "" {
+		return s.Dir
+	}
+	dir, _ := os.Getwd()
+	return dir
+}
+
+// Expand returns the command with the alias of its name, if there is
+// one, replaced by the command line of the alias
+func (s *Shell) Expand(command string) string {
+	name, args, found := strings.Cut(command, " ")
+	alias, ok := s.Aliases[name]
+	if !ok {
+		return command
+	}
+	if !found {
+		return alias
+	}
+	return alias + " " + args
+}
+
+// Builtin interprets the command if it is a builtin of the shell,
+// printing its output to out and changing the path of the prompt
+// with cd. The first return value reports whether the command is
+// a builtin, and the error whether it failed
+func (s *Shell) Builtin(command string, prompt *Prompt, out io.Writer) (bool, error) {
+	name, rest, _ := strings.Cut(strings.TrimSpace(command), " ")
+	rest = strings.TrimSpace(rest)
+	switch name {
+	case "cd", "chdir":
+		return true, s.cd(unquote(strings.TrimSpace(strings.TrimPrefix(rest, "/d "))), prompt, out)
+	case "set":
+		// Values are the rest of the line in cmd
+		if rest == "" {
+			return true, s.printEnv(out)
+		}
+		s.assign(unquote(rest))
+		return true, nil
+	case "export":
+		if rest == "" {
+			return true, s.printEnv(out)
+		}
+		for _, word := range splitWords(rest) {
+			s.assign(word)
+		}
+		return true, nil
+	case "unset":
+		for _, name := range splitWords(rest) {
+			s.unsetenv(name)
+		}
+		return true, nil
+	case "alias":
+		return true, s.alias(splitWords(rest), out)
+	default:
+		return false, nil
+	}
+}
+
+// cd changes the working directory of the commands to the directory,
+// and the path of the prompt to the directory as the shell shows it
+func (s *Shell) cd(dir string, prompt *Prompt, out io.Writer) error {
+	current := prompt.Path
+	if current == "" {
+		current = DefaultPath(prompt.Shell)
+	}
+
+	// Without a directory, cmd prints the current directory,
+	// and the other shells change to the home directory
+	if dir == "" && prompt.Shell == Cmd {
+		_, err := fmt.Fprintln(out, current)
+		return err
+	}
+	target := dir
+	if prompt.Shell != Bash {
+		target = filepath.FromSlash(strings.ReplaceAll(dir, "\\", "/"))
+	}
+	if dir == "" || dir == "~" || strings.HasPrefix(dir, "~/") {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return fmt.Errorf("cd: %w", err)
+		}
+		target = filepath.Join(home, strings.TrimPrefix(dir, "~"))
+	} else if !filepath.IsAbs(target) {
+		target = filepath.Join(s.baseDir(prompt), target)
+	}
+
+	info, err := os.Stat(target)
+	if err != nil {
+		var pathErr *os.PathError
+		if errors.As(err, &pathErr) {
+			err = pathErr.Err
+		}
+		return fmt.Errorf("cd: %s: %w", dir, err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("cd: %s: not a directory", dir)
+	}
+	s.Dir = filepath.Clean(target)
+	prompt.Path = promptPath(prompt.Shell, current, dir, s.Dir)
+	return nil
+}
+
+// baseDir returns the directory the relative directories changed to
+// are relative to: the working directory of the commands once it is
+// set, and until then the directory of the path of the prompt, if it
+// is a real one, so that "cd src" from "~" changes to the home
+func (s *Shell) baseDir(prompt *Prompt) string {
+	if s.Dir != "" {
+		return s.Dir
+	}
+	switch {
+	case prompt.Shell == Bash && (prompt.Path == "" || prompt.Path == "~" || strings.HasPrefix(prompt.Path, "~/")):
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, strings.TrimPrefix(prompt.Path, "~"))
+		}
+	case prompt.Path != "" && filepath.IsAbs(prompt.Path):
+		if info, err := os.Stat(prompt.Path); err == nil && info.IsDir() {
+			return prompt.Path
+		}
+	}
+	return s.WorkDir()
+}
+
+// promptPath returns the path of the prompt of the shell after changing
+// from the current path to the directory, whose real path is target
+func promptPath(shell ShellOption, current, dir, target string) string {
+	if shell != Bash {
+		// Paths of Windows, with the drive of the current
+		// path unless the directory has one of its own
+		dir = strings.ReplaceAll(dir, "\\", "/")
+		drive, rest, found := strings.Cut(current, ":")
+		if !found {
+			drive, rest = "C", current
+		}
+		if d, r, found := strings.Cut(dir, ":"); found {
+			drive, rest, dir = d, r, ""
+		}
+		if strings.HasPrefix(dir, "/") {
+			rest = ""
+		}
+		cleaned := path.Join("/", strings.ReplaceAll(rest, "\\", "/"), dir)
+		return drive + ":" + strings.ReplaceAll(cleaned, "/", "\\")
+	}
+
+	// Paths of bash, relative to the home directory "~" if the
+	// current path is, unless the directory leaves it
+	switch {
+	case dir == "":
+		return "~"
+	case strings.HasPrefix(dir, "~") || strings.HasPrefix(dir, "/"):
+		return path.Clean(dir)
+	}
+	joined := path.Join(current, dir)
+	if strings.HasPrefix(current, "~") && (joined == "." || strings.HasPrefix(joined, "..")) {
+		return filepath.ToSlash(target)
+	}
+	return joined
+}
+
+// assign sets the variable of an assignment (e.g. "NAME=value") in the
+// environment, with the variables in the value expanded. Names without
+// a value are left as they are
+func (s *Shell) assign(assignment string) {
+	name, value, found := strings.Cut(assignment, "=")
+	if !found || name == "" {
+		return
+	}
+	s.setenv(name, os.Expand(value, s.getenv))
+}
+
+// alias defines the aliases of the arguments (e.g. "ll='ls -l'"), or
+// prints the aliases named, or all aliases without any argument
+func (s *Shell) alias(args []string, out io.Writer) error {
+	if len(args) == 0 {
+		names := make([]string, 0, len(s.Aliases))
+		for name := range s.Aliases {
+			names = append(names, name)
+		}
+		slices.Sort(names)
+		args = names
+	}
+	for _, arg := range args {
+		name, value, found := strings.Cut(arg, "=")
+		if found {
+			if s.Aliases == nil {
+				s.Aliases = make(map[string]string)
+			}
+			s.Aliases[name] = value
+			continue
+		}
+		value, ok := s.Aliases[name]
+		if !ok {
+			return fmt.Errorf("alias: %s: not found", name)
+		}
+		if _, err := fmt.Fprintf(out, "alias %s='%s'\n", name, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// printEnv prints the variables of the environment, sorted by name
+func (s *Shell) printEnv(out io.Writer) error {
+	env := slices.Clone(s.environ())
+	slices.Sort(env)
+	for _, v := range env {
+		if _, err := fmt.Fprintln(out, v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// environ returns the environment of the commands
+func (s *Shell) environ() []string {
+	if s.Env == nil {
+		return os.Environ()
+	}
+	return s.Env
+}
+
+// getenv returns the value of the variable in the environment
+func (s *Shell) getenv(name string) string {
+	for _, v := range s.environ() {
+		if n, value, _ := strings.Cut(v, "="); n == name {
+			return value
+		}
+	}
+	return ""
+}
+
+// setenv sets the variable in the environment
+func (s *Shell) setenv(name, value string) {
+	s.unsetenv(name)
+	s.Env = append(s.Env, name+"="+value)
+}
+
+// unsetenv removes the variable from the environment
+func (s *Shell) unsetenv(name string) {
+	s.Env = slices.DeleteFunc(slices.Clone(s.environ()), func(v string) bool {
+		n, _, _ := strings.Cut(v, "=")
+		return n == name
+	})
+}
+
+// splitWords splits the arguments of a builtin into words, separated
+// by spaces outside of quotes, with the quotes removed
+func splitWords(args string) []string {
+	var words []string
+	var word strings.Builder
+	var quote rune
+	inWord := false
+	for _, r := range args {
+		switch {
+		case quote != 0 && r == quote:
+			quote = 0
+		case quote != 0:
+			word.WriteRune(r)
+		case r == '\'' || r == '"':
+			quote, inWord = r, true
+		case r == ' ' || r == '\t':
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		default:
+			word.WriteRune(r)
+			inWord = true
+		}
+	}
+	if inWord {
+		words = append(words, word.String())
+	}
+	return words
+}
+
+// unquote returns the text without the quotes around it, if any
+func unquote(text string) string {
+	if len(text) >= 2 && (text[0] == '"' || text[0] == '\'') && text[len(text)-1] == text[0] {
+		return text[1 : len(text)-1]
+	}
+	return text
+}
@@ -0,0 +1,125 @@
+package cli_test
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bitcanon/autotyper/cli"
+)
+
+// TestShellCd tests that cd changes the working directory of the
+// commands, and the path of the prompt as each shell shows it
+func TestShellCd(t *testing.T) {
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, "src", "app"), 0o755); err != nil {
+		t.Fatalf("failed to create the directories: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		shell    cli.ShellOption
+		path     string
+		commands []string
+		expected string
+		dir      string
+	}{
+		{name: "Bash", shell: cli.Bash, path: "~", commands: []string{"cd src/app"}, expected: "~/src/app", dir: "src/app"},
+		{name: "BashParent", shell: cli.Bash, path: "~/demo", commands: []string{"cd src/app", "cd ../.."}, expected: "~/demo", dir: ""},
+		{name: "BashAbsolute", shell: cli.Bash, path: "~", commands: []string{"cd " + filepath.Join(root, "src")}, expected: filepath.ToSlash(filepath.Join(root, "src")), dir: "src"},
+		{name: "Cmd", shell: cli.Cmd, path: "C:\\Users\\demo", commands: []string{"cd src\\app", "cd .."}, expected: "C:\\Users\\demo\\src", dir: "src"},
+		{name: "PS", shell: cli.PS, path: "", commands: []string{"cd \"src\""}, expected: "C:\\src", dir: "src"},
+		{name: "Missing", shell: cli.PS, path: "C:\\", commands: []string{"cd nowhere"}, expected: "C:\\", dir: ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			shell := &cli.Shell{Dir: root}
+			prompt := cli.Prompt{Shell: test.shell, Path: test.path}
+			for _, command := range test.commands {
+				builtin, err := shell.Builtin(command, &prompt, &strings.Builder{})
+				if !builtin {
+					t.Fatalf("%q: expected a builtin", command)
+				}
+				if (err != nil) != (test.name == "Missing") {
+					t.Errorf("%q: unexpected error %v", command, err)
+				}
+			}
+			if prompt.Path != test.expected {
+				t.Errorf("expected the path %q, but got %q", test.expected, prompt.Path)
+			}
+			if dir := filepath.Join(root, test.dir); shell.WorkDir() != dir {
+				t.Errorf("expected the directory %q, but got %q", dir, shell.WorkDir())
+			}
+		})
+	}
+}
+
+// TestShellHome tests that the directories changed to from the default
+// prompt of bash are relative to the home directory the prompt shows
+func TestShellHome(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	if err := os.Mkdir(filepath.Join(home, "src"), 0o755); err != nil {
+		t.Fatalf("failed to create the directory: %v", err)
+	}
+
+	shell := &cli.Shell{}
+	prompt := cli.Prompt{Shell: cli.Bash}
+	if _, err := shell.Builtin("cd src", &prompt, &strings.Builder{}); err != nil {
+		t.Fatalf("expected no error, but got %v", err)
+	}
+	if prompt.Path != "~/src" || shell.WorkDir() != filepath.Join(home, "src") {
+		t.Errorf("expected ~/src in %s, but got %s in %s", filepath.Join(home, "src"), prompt.Path, shell.WorkDir())
+	}
+}
+
+// TestShellEnvironment tests that the variables set and unset by the
+// builtins are in the environment of the commands, and that the
+// aliases are replaced
+func TestShellEnvironment(t *testing.T) {
+	if _, err := exec.LookPath("printenv"); err != nil {
+		t.Skip("printenv is not available")
+	}
+	shell := &cli.Shell{}
+	t.Setenv("AUTOTYPER_UNSET", "inherited")
+
+	prompt := cli.Prompt{Shell: cli.Bash}
+	for _, command := range []string{
+		`export GREETING="hello world" NAME=demo`,
+		`set TARGET=$NAME-prod`,
+		`unset AUTOTYPER_UNSET`,
+		`alias show='printenv GREETING'`,
+	} {
+		if builtin, err := shell.Builtin(command, &prompt, &strings.Builder{}); !builtin || err != nil {
+			t.Fatalf("%q: expected a builtin, but got %v and %v", command, builtin, err)
+		}
+	}
+
+	tests := []struct {
+		command  string
+		expected string
+	}{
+		{command: "show", expected: "hello world\n"},
+		{command: "printenv TARGET", expected: "demo-prod\n"},
+		{command: "printenv AUTOTYPER_UNSET", expected: ""},
+	}
+	for _, test := range tests {
+		var out strings.Builder
+		cli.ExecuteCommandInput(shell, shell.Expand(test.command), nil, &out)
+		if out.String() != test.expected {
+			t.Errorf("%q: expected %q, but got %q", test.command, test.expected, out.String())
+		}
+	}
+
+	var out strings.Builder
+	if _, err := shell.Builtin("alias", &prompt, &out); err != nil || out.String() != "alias show='printenv GREETING'\n" {
+		t.Errorf("expected the aliases to be printed, but got %q and %v", out.String(), err)
+	}
+	if builtin, _ := shell.Builtin("ls -l", &prompt, &out); builtin {
+		t.Errorf("expected ls not to be a builtin")
+	}
+}
@@ -0,0 +1,69 @@
+/*
+Copyright © 2023 Mikael Schultz <bitcanon@proton.me>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cli
+
+import (
+	"os"
+	"sync"
+)
+
+// running holds the processes of the commands being executed, and
+// whether each one leads a process group of its own (e.g. in a
+// pseudo-terminal)
+var running = struct {
+	sync.Mutex
+	processes map[*os.Process]bool
+}{processes: map[*os.Process]bool{}}
+
+// track adds the process of a command to the running commands,
+// until the returned function is called
+func track(process *os.Process, group bool) func() {
+	running.Lock()
+	defer running.Unlock()
+	running.processes[process] = group
+	return func() {
+		running.Lock()
+		defer running.Unlock()
+		delete(running.processes, process)
+	}
+}
+
+// SuspendCommands stops the commands being executed, with their
+// children, until ContinueCommands is called. The commands started in
+// a pseudo-terminal do not receive the job control signals of the
+// terminal of the presenter, so they are stopped explicitly.
+func SuspendCommands() {
+	running.Lock()
+	defer running.Unlock()
+	for process, group := range running.processes {
+		stopProcess(process, group)
+	}
+}
+
+// ContinueCommands continues the commands stopped by SuspendCommands
+func ContinueCommands() {
+	running.Lock()
+	defer running.Unlock()
+	for process, group := range running.processes {
+		continueProcess(process, group)
+	}
+}
@@ -0,0 +1,42 @@
+package cli_test
+
+import (
+	"bytes"
+	"os/exec"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/bitcanon/autotyper/cli"
+)
+
+// TestSuspendCommands tests that a running command is stopped
+// until the commands are continued
+func TestSuspendCommands(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("there is no job control on windows")
+	}
+	if _, err := exec.LookPath("sleep"); err != nil {
+		t.Skip("sleep is not available")
+	}
+
+	done := make(chan error, 1)
+	start := time.Now()
+	go func() { done <- cli.ExecuteCommand("sleep 0.2", &bytes.Buffer{}) }()
+
+	time.Sleep(50 * time.Millisecond)
+	cli.SuspendCommands()
+	select {
+	case err := <-done:
+		t.Fatalf("expected the command to be stopped, but it exited with %v", err)
+	case <-time.After(400 * time.Millisecond):
+	}
+	cli.ContinueCommands()
+
+	if err := <-done; err != nil {
+		t.Fatalf("expected no error, but got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 450*time.Millisecond {
+		t.Errorf("expected the command to be held while suspended, but it took %v", elapsed)
+	}
+}
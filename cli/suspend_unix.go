@@ -0,0 +1,49 @@
+//go:build aix || darwin || dragonfly || freebsd || linux || netbsd || openbsd || solaris || zos
+
+/*
+Copyright © 2023 Mikael Schultz <bitcanon@proton.me>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cli
+
+import (
+	"os"
+	"syscall"
+)
+
+// stopProcess stops the process, or its process group
+func stopProcess(process *os.Process, group bool) {
+	signalProcess(process, group, syscall.SIGSTOP)
+}
+
+// continueProcess continues the process, or its process group
+func continueProcess(process *os.Process, group bool) {
+	signalProcess(process, group, syscall.SIGCONT)
+}
+
+// signalProcess sends the signal to the process, or to its process
+// group if the process leads one
+func signalProcess(process *os.Process, group bool, sig syscall.Signal) {
+	if group {
+		syscall.Kill(-process.Pid, sig)
+		return
+	}
+	process.Signal(sig)
+}
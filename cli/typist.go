@@ -0,0 +1,182 @@
+/*
+Copyright © 2023 Mikael Schultz <bitcanon@proton.me>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cli
+
+import (
+	"io"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Typist types text as a human would, with a delay between each key
+// press that depends on the key, and optionally with an input method
+// for CJK text
+type Typist struct {
+	// Delay between each key press in milliseconds. If 0, the
+	// text is written at once
+	Delay int
+
+	// The input method simulated when typing CJK text
+	InputMethod InputMethod
+
+	// Hesitation lengthens the delay before the keys typed with
+	// shift or with an awkward reach (e.g. "|", "{", "~" and the
+	// capitals) by this fraction of the delay, while the keys of
+	// the home row are typed faster. If 0, every key is typed
+	// with the same delay
+	Hesitation float64
+
+	// Pauses before the tokens of the command, added to the delay
+	Pauses TokenPauses
+
+	// The clock the delays are waited on. If nil, the delays
+	// are waited on the clock of the system
+	Clock Clock
+}
+
+// TokenPauses holds the pauses in milliseconds before the tokens of
+// a command, as if the typist was deciding what comes next
+type TokenPauses struct {
+	// Before a pipe (e.g. "| grep")
+	Pipe int
+
+	// Before a flag (e.g. "-l" or "--force")
+	Flag int
+
+	// Before a path separator (e.g. "/" or "\")
+	Path int
+}
+
+// Pause returns the pause in milliseconds before the key,
+// typed after the previous key
+func (p TokenPauses) Pause(prev, key rune) int {
+	switch {
+	case key == '|' && prev != '|':
+		return p.Pipe
+	case key == '-' && (prev == ' ' || prev == '\t'):
+		return p.Flag
+	case key == '/' || key == '\\':
+		return p.Path
+	}
+	return 0
+}
+
+// Define the keys in the home row of a QWERTY keyboard
+const homeRow = "asdfghjkl; "
+
+// Define the keys typed without shift but with an awkward reach
+const awkwardKeys = "`1234567890-=[]\\'/"
+
+// Type types the string to the output, coloring the first word (the
+// executable name). Words with a reading, written as {text|reading},
+// are composed with the input method, or typed as their text if
+// there is none.
+func (t Typist) Type(str string, out io.Writer) error {
+	if t.InputMethod == NoInputMethod {
+		str = StripReadings(str)
+	}
+	if t.Delay == 0 {
+		_, err := io.WriteString(out, StripReadings(str))
+		return err
+	}
+
+	// Colorize the first word in the string (the executable name)
+	io.WriteString(out, colorSequence(ActiveTheme.Command))
+	colored := true
+
+	schedule := NewSchedule(t.Clock)
+	preedit := ""
+	var prev rune
+	for _, e := range compose(str, t.InputMethod) {
+		// Hesitate before pressing the key, and pause
+		// before the tokens of the command
+		delay := t.KeyDelay(e.key) + t.Pauses.Pause(prev, e.key)
+		schedule.Wait(time.Duration(delay) * time.Millisecond)
+		prev = e.key
+
+		var b strings.Builder
+
+		// Erase the previous preedit before replacing it
+		if w := textWidth(preedit); w > 0 {
+			back := strings.Repeat("\b", w)
+			b.WriteString(back + strings.Repeat(" ", w) + back)
+		}
+
+		if colored && strings.Contains(e.commit, " ") {
+			i := strings.Index(e.commit, " ")
+			b.WriteString(e.commit[:i] + "\033[0m" + e.commit[i:])
+			colored = false
+		} else {
+			b.WriteString(e.commit)
+		}
+		if e.preedit != "" {
+			b.WriteString("\033[4m" + e.preedit + "\033[24m")
+		}
+		preedit = e.preedit
+
+		if _, err := io.WriteString(out, b.String()); err != nil {
+			return err
+		}
+	}
+
+	// Reset the color
+	_, err := io.WriteString(out, "\033[0m")
+	return err
+}
+
+// Duration returns the time it takes to type the string, with
+// the same delays and pauses as Type
+func (t Typist) Duration(str string) time.Duration {
+	if t.InputMethod == NoInputMethod {
+		str = StripReadings(str)
+	}
+	if t.Delay == 0 {
+		return 0
+	}
+
+	ms := 0
+	var prev rune
+	for _, e := range compose(str, t.InputMethod) {
+		ms += t.KeyDelay(e.key) + t.Pauses.Pause(prev, e.key)
+		prev = e.key
+	}
+	return time.Duration(ms) * time.Millisecond
+}
+
+// KeyDelay returns the delay in milliseconds before pressing the key
+func (t Typist) KeyDelay(key rune) int {
+	if t.Hesitation <= 0 {
+		return t.Delay
+	}
+
+	factor := 1.0
+	switch {
+	case strings.ContainsRune(homeRow, key):
+		factor = 1 - t.Hesitation/4
+	case strings.ContainsRune(awkwardKeys, key):
+		factor = 1 + t.Hesitation/2
+	case unicode.IsUpper(key) || strings.ContainsRune(`~!@#$%^&*()_+{}|:"<>?`, key):
+		factor = 1 + t.Hesitation
+	}
+	return int(float64(t.Delay) * max(factor, 0.5))
+}
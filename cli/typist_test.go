@@ -0,0 +1,97 @@
+package cli_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bitcanon/autotyper/cli"
+)
+
+// TestTypistKeyDelay tests that the keys typed with shift or an
+// awkward reach are typed slower than the keys of the home row
+func TestTypistKeyDelay(t *testing.T) {
+	tests := []struct {
+		name       string
+		hesitation float64
+		key        rune
+		expected   int
+	}{
+		{name: "Disabled", hesitation: 0, key: '|', expected: 100},
+		{name: "HomeRow", hesitation: 0.8, key: 'f', expected: 80},
+		{name: "Letter", hesitation: 0.8, key: 'p', expected: 100},
+		{name: "Digit", hesitation: 0.8, key: '7', expected: 140},
+		{name: "Capital", hesitation: 0.8, key: 'P', expected: 180},
+		{name: "Pipe", hesitation: 0.8, key: '|', expected: 180},
+		{name: "Brace", hesitation: 0.8, key: '{', expected: 180},
+		{name: "Tilde", hesitation: 0.8, key: '~', expected: 180},
+	}
+
+	for _, test := range tests {
+		typist := cli.Typist{Delay: 100, Hesitation: test.hesitation}
+		if delay := typist.KeyDelay(test.key); delay != test.expected {
+			t.Errorf("%s: expected %d, but got %d", test.name, test.expected, delay)
+		}
+	}
+}
+
+// TestTypistType tests that the first word is colored, and
+// that the text is written at once without a delay
+func TestTypistType(t *testing.T) {
+	tests := []struct {
+		delay    int
+		expected string
+	}{
+		{delay: 0, expected: "ls -l"},
+		{delay: 1, expected: "\033[38;5;229mls\033[0m -l\033[0m"},
+	}
+
+	for _, test := range tests {
+		var out strings.Builder
+		if err := (cli.Typist{Delay: test.delay, Hesitation: 1}).Type("ls -l", &out); err != nil {
+			t.Fatalf("expected no error, but got %v", err)
+		}
+		if out.String() != test.expected {
+			t.Errorf("delay %d: expected %q, but got %q", test.delay, test.expected, out.String())
+		}
+	}
+}
+
+// TestTokenPauses tests the pauses before pipes, flags and
+// path separators, and only there
+func TestTokenPauses(t *testing.T) {
+	pauses := cli.TokenPauses{Pipe: 300, Flag: 200, Path: 100}
+	command := "ls -la /var/log | grep -v x-y"
+	expected := map[int]int{3: 200, 7: 100, 11: 100, 16: 300, 23: 200}
+
+	var prev rune
+	for i, key := range command {
+		if pause := pauses.Pause(prev, key); pause != expected[i] {
+			t.Errorf("%q at %d: expected %d, but got %d", key, i, expected[i], pause)
+		}
+		prev = key
+	}
+}
+
+// TestTypistDuration tests that the duration of the typing adds up
+// the delays of the keys and the pauses before the tokens
+func TestTypistDuration(t *testing.T) {
+	tests := []struct {
+		name     string
+		typist   cli.Typist
+		text     string
+		expected time.Duration
+	}{
+		{name: "NoDelay", typist: cli.Typist{}, text: "ls -l", expected: 0},
+		{name: "Delay", typist: cli.Typist{Delay: 100}, text: "ls -l", expected: 500 * time.Millisecond},
+		{name: "Hesitation", typist: cli.Typist{Delay: 100, Hesitation: 0.8}, text: "P|", expected: 360 * time.Millisecond},
+		{name: "Pauses", typist: cli.Typist{Delay: 100, Pauses: cli.TokenPauses{Flag: 250}}, text: "ls -l", expected: 750 * time.Millisecond},
+		{name: "Reading", typist: cli.Typist{Delay: 100}, text: "{日本|nihon}", expected: 200 * time.Millisecond},
+	}
+
+	for _, test := range tests {
+		if d := test.typist.Duration(test.text); d != test.expected {
+			t.Errorf("%s: expected %v, but got %v", test.name, test.expected, d)
+		}
+	}
+}
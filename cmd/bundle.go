@@ -0,0 +1,210 @@
+/*
+Copyright © 2023 Mikael Schultz <bitcanon@proton.me>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"filippo.io/age"
+	"github.com/bitcanon/autotyper/bundle"
+	"github.com/bitcanon/autotyper/cli"
+	"github.com/bitcanon/autotyper/locale"
+	"github.com/bitcanon/autotyper/script"
+	"github.com/bitcanon/autotyper/terminal"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// bundleCmd represents the bundle command
+var bundleCmd = &cobra.Command{
+	Use:   "bundle",
+	Short: "Pack a script and everything it needs into a single file",
+	Long: `Pack a script and everything it needs into a single file
+
+The bundle holds the scenario, the colors of the theme and the highlight rules
+of the config file, the files of the external simulators, the hooks and the
+output filters. With --record, the commands are executed once and their output
+is stored in the bundle, so that nothing is executed when the bundle is played
+with "autotyper play". The bundle can be encrypted with age, with a password or
+for the public keys of the people playing it.`,
+	Example: `  autotyper bundle -i demo.yaml
+  autotyper bundle -i demo.yaml -o k8s-intro.atd --record
+  autotyper bundle -i commands.txt --hooks hooks.js --filter redact.wasm
+  autotyper bundle -i demo.yaml --record --password
+  autotyper bundle -i demo.yaml -r age1ql3z7hjy54pw3hyww5ayyfg7zqgvc7w3j2elw8zmrj2kg5sfn9aqmcac8p`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		input, err := inputFile(viper.GetString("bundle-input-file"))
+		if err != nil {
+			return err
+		}
+		s, err := script.Load(input)
+		if err != nil {
+			return err
+		}
+		s.Highlights = append(s.Highlights, viper.GetStringSlice("highlights")...)
+
+		if viper.GetBool("bundle-record") {
+			if err := bundle.Record(s, promptOptions(), recordCommand); err != nil {
+				return err
+			}
+		}
+
+		b, err := bundle.New(s)
+		if err != nil {
+			return err
+		}
+		b.Theme = viper.GetStringMapString("theme")
+		if filename := viper.GetString("hooks"); filename != "" {
+			if err := b.AddHooks(filename); err != nil {
+				return err
+			}
+		}
+		for _, filename := range viper.GetStringSlice("filters") {
+			if err := b.AddFilter(filename); err != nil {
+				return err
+			}
+		}
+
+		output := viper.GetString("bundle-output")
+		if output == "" {
+			output = strings.TrimSuffix(input, filepath.Ext(input)) + bundle.Extension
+		}
+		recipients, err := bundleRecipients()
+		if err != nil {
+			return err
+		}
+		if err := b.Save(output, recipients...); err != nil {
+			return err
+		}
+		fmt.Fprintln(os.Stderr, locale.T("Wrote %s (%d steps, %d files)", output, len(s.Steps), len(b.Files)))
+		return nil
+	},
+}
+
+// bundleRecipients returns the recipients the bundle is encrypted for:
+// the password, or the age public keys of the flags, if any
+func bundleRecipients() ([]age.Recipient, error) {
+	recipients, err := bundle.ParseRecipients(viper.GetStringSlice("bundle-recipients"))
+	if err != nil {
+		return nil, err
+	}
+	if filename := viper.GetString("bundle-recipients-file"); filename != "" {
+		keys, err := bundle.LoadRecipients(filename)
+		if err != nil {
+			return nil, err
+		}
+		recipients = append(recipients, keys...)
+	}
+
+	if !viper.GetBool("bundle-password") {
+		return recipients, nil
+	}
+	if len(recipients) > 0 {
+		return nil, fmt.Errorf("a bundle cannot be encrypted both with a password and for recipients")
+	}
+	password, err := bundlePassword(true)
+	if err != nil {
+		return nil, err
+	}
+	r, err := bundle.PasswordRecipient(password)
+	if err != nil {
+		return nil, err
+	}
+	return []age.Recipient{r}, nil
+}
+
+// bundlePassword returns the password of the bundle, from the
+// environment or typed by the presenter, twice if confirm is set
+func bundlePassword(confirm bool) (string, error) {
+	if password := os.Getenv("AUTOTYPER_BUNDLE_PASSWORD"); password != "" {
+		return password, nil
+	}
+	password, err := terminal.ReadPassword("Bundle password: ", os.Stdin, os.Stderr)
+	if err != nil {
+		return "", fmt.Errorf("cannot ask for the bundle password (set $AUTOTYPER_BUNDLE_PASSWORD instead): %w", err)
+	}
+	if password == "" {
+		return "", fmt.Errorf("empty bundle password")
+	}
+	if confirm {
+		again, err := terminal.ReadPassword("Confirm the password: ", os.Stdin, os.Stderr)
+		if err != nil {
+			return "", err
+		}
+		if again != password {
+			return "", fmt.Errorf("the passwords do not match")
+		}
+	}
+	return password, nil
+}
+
+// recordCommand executes the command in the shell and returns its
+// output. A command failing with an exit code is recorded, so that
+// errors can be shown in demos, but dangerous and refused commands
+// are not executed
+func recordCommand(shell *cli.Shell, command string) (string, error) {
+	dangerous, err := cli.CompilePatterns(viper.GetStringSlice("dangerous-patterns"))
+	if err != nil {
+		return "", err
+	}
+	if cli.MatchAny(command, dangerous) {
+		return "", fmt.Errorf("not recording dangerous command %q, add its output to the step instead", command)
+	}
+
+	fmt.Fprintln(os.Stderr, locale.T("Recording %s", command))
+	var out bytes.Buffer
+	var exitErr *exec.ExitError
+	if err := cli.ExecuteCommandInput(shell, command, nil, &out); err != nil && !errors.As(err, &exitErr) {
+		return "", err
+	}
+	return out.String(), nil
+}
+
+func init() {
+	rootCmd.AddCommand(bundleCmd)
+
+	// Add flags for the input and the output file
+	bundleCmd.Flags().StringP("input-file", "i", "", "input file")
+	bundleCmd.MarkFlagRequired("input-file")
+	viper.BindPFlag("bundle-input-file", bundleCmd.Flags().Lookup("input-file"))
+	bundleCmd.Flags().StringP("output", "o", "", "bundle file (default is the input file with the .atd extension)")
+	viper.BindPFlag("bundle-output", bundleCmd.Flags().Lookup("output"))
+
+	// Add flags for recording the output of the commands
+	bundleCmd.Flags().Bool("record", false, "execute the commands and store their output in the bundle")
+	viper.BindPFlag("bundle-record", bundleCmd.Flags().Lookup("record"))
+
+	// Add flags for encrypting the bundle
+	bundleCmd.Flags().Bool("password", false, "encrypt the bundle with a password (or $AUTOTYPER_BUNDLE_PASSWORD)")
+	viper.BindPFlag("bundle-password", bundleCmd.Flags().Lookup("password"))
+	bundleCmd.Flags().StringArrayP("recipient", "r", nil, "encrypt the bundle for this age public key")
+	viper.BindPFlag("bundle-recipients", bundleCmd.Flags().Lookup("recipient"))
+	bundleCmd.Flags().StringP("recipients-file", "R", "", "encrypt the bundle for the age public keys in this file")
+	viper.BindPFlag("bundle-recipients-file", bundleCmd.Flags().Lookup("recipients-file"))
+}
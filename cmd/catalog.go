@@ -0,0 +1,120 @@
+/*
+Copyright © 2023 Mikael Schultz <bitcanon@proton.me>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/bitcanon/autotyper/catalog"
+	"github.com/bitcanon/autotyper/fetch"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// catalogCmd represents the catalog command
+var catalogCmd = &cobra.Command{
+	Use:   "catalog",
+	Short: "List the scenarios of the shared catalog",
+	Long: `List the scenarios of the shared catalog
+
+The catalog is made of the scenarios of the indexes set with --index or in
+the catalog-indexes list of the config file, so that a team maintains its
+library of demos in one place. An index is a YAML file with the name, the
+description, the duration, the tags and the script of each scenario, read
+from a file, an HTTPS URL or a git repository. When several indexes have a
+scenario with the same name, the first index takes precedence.`,
+	Example: `  autotyper catalog --index https://demos.example.com/index.yaml
+  autotyper catalog --tag k8s
+  autotyper catalog play k8s-intro`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c, err := loadCatalog()
+		if err != nil {
+			return err
+		}
+		return catalog.Print(os.Stdout, c.Filter(viper.GetStringSlice("catalog-tags")...))
+	},
+}
+
+// catalogPlayCmd represents the catalog play command
+var catalogPlayCmd = &cobra.Command{
+	Use:   "play <name>",
+	Short: "Fetch and play a scenario of the catalog",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c, err := loadCatalog()
+		if err != nil {
+			return err
+		}
+		entry, ok := c.Find(args[0])
+		if !ok {
+			return fmt.Errorf("no scenario %s in the catalog", args[0])
+		}
+		return playFile(cmd, entry.Script)
+	},
+}
+
+// loadCatalog reads the configured indexes into a catalog
+func loadCatalog() (*catalog.Catalog, error) {
+	sources := viper.GetStringSlice("catalog-indexes")
+	if len(sources) == 0 {
+		return nil, errors.New("no catalog index, set one with --index or catalog-indexes in the config file")
+	}
+
+	var c catalog.Catalog
+	for _, source := range sources {
+		filename, err := fetchFile(source, "")
+		if err != nil {
+			return nil, err
+		}
+		data, err := os.ReadFile(filename)
+		if err != nil {
+			return nil, err
+		}
+		// Scripts relative to an index fetched over HTTPS are
+		// fetched from the server, not from the cache
+		if !fetch.IsURL(source) {
+			source = filename
+		}
+		index, err := catalog.Parse(data, source)
+		if err != nil {
+			return nil, err
+		}
+		c.Add(index)
+	}
+	return &c, nil
+}
+
+func init() {
+	rootCmd.AddCommand(catalogCmd)
+	catalogCmd.AddCommand(catalogPlayCmd)
+
+	// Add flags for the indexes of the catalog
+	catalogCmd.PersistentFlags().StringArray("index", nil, "file, HTTPS URL or git repository script of an index")
+	viper.BindPFlag("catalog-indexes", catalogCmd.PersistentFlags().Lookup("index"))
+
+	// Add flags for filtering the scenarios
+	catalogCmd.Flags().StringArray("tag", nil, "list only the scenarios with this tag")
+	viper.BindPFlag("catalog-tags", catalogCmd.Flags().Lookup("tag"))
+}
@@ -0,0 +1,170 @@
+/*
+Copyright © 2023 Mikael Schultz <bitcanon@proton.me>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/bitcanon/autotyper/locale"
+	"github.com/bitcanon/autotyper/player"
+	"github.com/bitcanon/autotyper/trigger"
+	"github.com/spf13/viper"
+)
+
+// playbackControls applies the actions of the keyboard controls,
+// the hotkeys and the trigger endpoints to the playback
+type playbackControls struct {
+	p *player.Player
+
+	mu     sync.Mutex
+	paused bool
+}
+
+// fire applies an action to the playback
+func (c *playbackControls) fire(action trigger.Action) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	switch action {
+	case trigger.Next:
+		c.p.Step()
+		c.paused = true
+	case trigger.Pause:
+		if c.paused {
+			c.p.Resume()
+		} else {
+			c.p.Pause()
+		}
+		c.paused = !c.paused
+	case trigger.Restart:
+		c.p.Seek(0)
+	}
+}
+
+// controlKeys returns the handler of the keyboard controls of the
+// playback: space pauses and resumes, n steps to the next command,
+// g goes back to the first step and q quits by calling stop
+func controlKeys(c *playbackControls, stop func()) func(key rune) {
+	return func(key rune) {
+		switch key {
+		case ' ':
+			c.fire(trigger.Pause)
+		case 'n':
+			c.fire(trigger.Next)
+		case 'g':
+			c.fire(trigger.Restart)
+		case 'q':
+			stop()
+		}
+	}
+}
+
+// startTriggers starts the configured trigger endpoints and global
+// hotkeys, which fire the actions of c until the context is done
+func startTriggers(ctx context.Context, c *playbackControls) error {
+	// Parse the hotkeys first, so nothing is listening on failure
+	var bindings []trigger.Binding
+	for _, s := range viper.GetStringSlice("hotkeys") {
+		b, err := trigger.ParseBinding(s)
+		if err != nil {
+			return err
+		}
+		bindings = append(bindings, b)
+	}
+	if len(bindings) > 0 && !trigger.HotkeysSupported() {
+		return fmt.Errorf("--hotkey: %w (use --trigger-listen with a hotkey tool instead)", trigger.ErrHotkeysUnsupported)
+	}
+
+	// The triggers control the demo, so they must not be reachable
+	// from the network without credentials
+	auth, err := controlAuth("trigger")
+	if err != nil {
+		return err
+	}
+	// The datagrams can only carry the token, not the basic auth
+	insecure := viper.GetBool("trigger-insecure")
+	if addr := viper.GetString("trigger-listen"); addr != "" && !auth.Enabled() && !isLoopback(addr) && !insecure {
+		return fmt.Errorf("refusing to listen for triggers on %s without --trigger-token (use --trigger-insecure to allow it)", addr)
+	}
+	if addr := viper.GetString("trigger-udp"); addr != "" && auth.Token == "" && !isLoopback(addr) && !insecure {
+		return fmt.Errorf("refusing to listen for UDP triggers on %s without --trigger-token (use --trigger-insecure to allow it)", addr)
+	}
+
+	// Serve the HTTP endpoint of the buttons, such as Stream Deck
+	if addr := viper.GetString("trigger-listen"); addr != "" {
+		ln, err := net.Listen("tcp", addr)
+		if err != nil {
+			return err
+		}
+		srv := &http.Server{
+			Handler:           auth.Handler(trigger.Handler(c.fire)),
+			ReadHeaderTimeout: 5 * time.Second,
+			ReadTimeout:       10 * time.Second,
+			WriteTimeout:      10 * time.Second,
+			IdleTimeout:       time.Minute,
+		}
+		context.AfterFunc(ctx, func() {
+			shutdownCtx, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+			srv.Shutdown(shutdownCtx)
+		})
+		slog.Info("listening for triggers", "address", ln.Addr())
+		go func() {
+			if err := srv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				fmt.Fprintln(os.Stderr, locale.T("Error: %v", err))
+			}
+		}()
+	}
+
+	// Serve the UDP endpoint
+	if addr := viper.GetString("trigger-udp"); addr != "" {
+		conn, err := net.ListenPacket("udp", addr)
+		if err != nil {
+			return err
+		}
+		slog.Info("listening for triggers over UDP", "address", conn.LocalAddr())
+		go func() {
+			err := trigger.ServeUDP(ctx, conn, auth.Token, c.fire)
+			if err != nil && !errors.Is(err, context.Canceled) {
+				fmt.Fprintln(os.Stderr, locale.T("Error: %v", err))
+			}
+		}()
+	}
+
+	// Listen for the global hotkeys
+	if len(bindings) > 0 {
+		go func() {
+			err := trigger.ListenHotkeys(ctx, bindings, c.fire)
+			if err != nil && !errors.Is(err, context.Canceled) {
+				fmt.Fprintln(os.Stderr, locale.T("Error: %v", err))
+			}
+		}()
+	}
+	return nil
+}
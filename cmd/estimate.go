@@ -0,0 +1,96 @@
+/*
+Copyright © 2023 Mikael Schultz <bitcanon@proton.me>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/bitcanon/autotyper/player"
+	"github.com/bitcanon/autotyper/script"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// estimateCmd represents the estimate command
+var estimateCmd = &cobra.Command{
+	Use:   "estimate",
+	Short: "Estimate how long a script takes to play",
+	Long: `Estimate how long a script takes to play
+
+The expected duration of each command is computed from the delays and the
+typing speed set by the flags and the script, without executing anything.
+Commands are counted as instantaneous, except for the input typed into them.`,
+	Example: `  autotyper estimate -i demo.yaml
+  autotyper estimate -i commands.txt --char-delay 50 --post-delay 2000`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		filename, err := inputFile(viper.GetString("estimate-input-file"))
+		if err != nil {
+			return err
+		}
+		s, err := script.Load(filename)
+		if err != nil {
+			return err
+		}
+		if err := setHeaderFlags(cmd, s.Flags); err != nil {
+			return fmt.Errorf("%s: %w", filename, err)
+		}
+		opts, err := playerOptions()
+		if err != nil {
+			return err
+		}
+
+		if err := stepRange(s, &opts); err != nil {
+			return err
+		}
+		p := player.New(s, io.Discard, opts)
+		if err := fitDuration(p); err != nil {
+			return err
+		}
+		report := p.Estimate()
+		if viper.GetBool("estimate-json") {
+			data, err := json.MarshalIndent(report, "", "  ")
+			if err != nil {
+				return err
+			}
+			fmt.Println(string(data))
+			return nil
+		}
+		return report.Print(os.Stdout)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(estimateCmd)
+
+	// Add flags for input file
+	estimateCmd.Flags().StringP("input-file", "i", "", "input file")
+	estimateCmd.MarkFlagRequired("input-file")
+	viper.BindPFlag("estimate-input-file", estimateCmd.Flags().Lookup("input-file"))
+
+	// Add flags for the JSON output
+	estimateCmd.Flags().Bool("json", false, "print the estimate as JSON")
+	viper.BindPFlag("estimate-json", estimateCmd.Flags().Lookup("json"))
+}
@@ -0,0 +1,145 @@
+/*
+Copyright © 2023 Mikael Schultz <bitcanon@proton.me>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// headerFlags are the flags the interpreter line of a script can set.
+// They only change the presentation and the timing of the playback, so
+// a script cannot decide whether it is trusted, what else is executed
+// or where files are written
+var headerFlags = map[string]bool{
+	"a11y":             true,
+	"alt-screen":       true,
+	"chapter-bell":     true,
+	"char-delay":       true,
+	"clear-after":      true,
+	"clear-scrollback": true,
+	"colors":           true,
+	"cols":             true,
+	"duration":         true,
+	"exit-clear":       true,
+	"exit-message":     true,
+	"hesitation":       true,
+	"highlight":        true,
+	"hostname":         true,
+	"images":           true,
+	"ime":              true,
+	"no-cls":           true,
+	"palette":          true,
+	"path":             true,
+	"pause-flag":       true,
+	"pause-path":       true,
+	"pause-pipe":       true,
+	"post-delay":       true,
+	"pre-delay":        true,
+	"qrcode-duration":  true,
+	"ramp":             true,
+	"ramp-commands":    true,
+	"ramp-curve":       true,
+	"range":            true,
+	"rows":             true,
+	"scale-typing":     true,
+	"shell":            true,
+	"skip-tags":        true,
+	"slot":             true,
+	"start-at":         true,
+	"stop-after":       true,
+	"tags":             true,
+	"timer":            true,
+	"type-clear":       true,
+	"type-exit":        true,
+	"username":         true,
+}
+
+// setHeaderFlags sets the flags of the interpreter line of a script
+// (see script.Shebang) as the defaults of the playback flags. The flags
+// on the command line and the values in the config file take precedence
+func setHeaderFlags(cmd *cobra.Command, args []string) error {
+	flags := cmd.Root().PersistentFlags()
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if !strings.HasPrefix(arg, "-") || arg == "-" {
+			return fmt.Errorf("interpreter line: unexpected argument %q", arg)
+		}
+
+		// Long flags are given as "--name value" or "--name=value",
+		// short flags as "-n value"
+		name, value, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		var f *pflag.Flag
+		if strings.HasPrefix(arg, "--") {
+			f = flags.Lookup(name)
+		} else if len(name) == 1 {
+			f = flags.ShorthandLookup(name)
+		}
+		if f == nil {
+			return fmt.Errorf("interpreter line: unknown flag %s", arg)
+		}
+		if !headerFlags[f.Name] {
+			return fmt.Errorf("interpreter line: flag --%s cannot be set by the script", f.Name)
+		}
+		if !hasValue {
+			switch {
+			case f.NoOptDefVal != "":
+				value = f.NoOptDefVal
+			case i+1 < len(args):
+				i++
+				value = args[i]
+			default:
+				return fmt.Errorf("interpreter line: flag --%s needs an argument", f.Name)
+			}
+		}
+
+		// The value is set without marking the flag as changed, so that
+		// it stays a default below the config file
+		if f.Changed {
+			continue
+		}
+		if err := f.Value.Set(value); err != nil {
+			return fmt.Errorf("interpreter line: invalid value %q for --%s: %w", value, f.Name, err)
+		}
+	}
+	if len(args) > 0 {
+		return setupTheme()
+	}
+	return nil
+}
+
+// isExecutable reports whether the file is a script starting with an
+// interpreter line, as when the script is run directly
+func isExecutable(name string) bool {
+	f, err := os.Open(name)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+	magic := make([]byte, 2)
+	n, _ := f.Read(magic)
+	return string(magic[:n]) == "#!"
+}
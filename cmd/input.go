@@ -0,0 +1,62 @@
+/*
+Copyright © 2023 Mikael Schultz <bitcanon@proton.me>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"context"
+	"os"
+	"os/signal"
+
+	"github.com/bitcanon/autotyper/fetch"
+	"github.com/spf13/viper"
+)
+
+// inputFile returns the file of the script: the name itself, the
+// file of the script fetched into the cache if the name is a URL, or
+// the script in the cloned repository if the name is a script in a git
+// repository (e.g. "github.com/org/demos//k8s-intro"). Local files
+// take precedence over repositories
+func inputFile(name string) (string, error) {
+	return fetchFile(name, viper.GetString("sha256"))
+}
+
+// fetchFile returns the file of the script like inputFile, with the
+// checksum the script fetched over HTTPS must have, if any
+func fetchFile(name, sum string) (string, error) {
+	repo, isRepo := fetch.ParseRepo(name)
+	if isRepo {
+		if _, err := os.Stat(name); err == nil {
+			isRepo = false
+		}
+	}
+	if !fetch.IsURL(name) && !isRepo {
+		return name, nil
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	f := &fetch.Fetcher{CacheDir: fetch.DefaultCacheDir(), UserAgent: "autotyper/" + version}
+	if isRepo {
+		return f.FetchRepo(ctx, repo)
+	}
+	return f.Fetch(ctx, name, sum)
+}
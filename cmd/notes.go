@@ -0,0 +1,50 @@
+/*
+Copyright © 2023 Mikael Schultz <bitcanon@proton.me>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/bitcanon/autotyper/locale"
+)
+
+// openNotes opens the output of the presenter notes: the standard
+// error with "stderr", or else a file, such as a named pipe read in
+// another window or the device of another terminal (e.g. /dev/pts/3).
+// The output is closed when the program exits
+func openNotes(target string) (io.Writer, error) {
+	if target == "stderr" {
+		return os.Stderr, nil
+	}
+
+	// Opening a named pipe blocks until it is read
+	if info, err := os.Stat(target); err == nil && info.Mode()&os.ModeNamedPipe != 0 {
+		fmt.Fprintln(os.Stderr, locale.T("Waiting for the notes to be read from %s", target))
+	}
+	f, err := os.OpenFile(target, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("notes: %w", err)
+	}
+	return f, nil
+}
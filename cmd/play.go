@@ -0,0 +1,190 @@
+/*
+Copyright © 2023 Mikael Schultz <bitcanon@proton.me>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"filippo.io/age"
+	"github.com/bitcanon/autotyper/bundle"
+	"github.com/bitcanon/autotyper/hooks"
+	"github.com/bitcanon/autotyper/script"
+	"github.com/bitcanon/autotyper/wasm"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// playCmd represents the play command
+var playCmd = &cobra.Command{
+	Use:   "play <file>",
+	Short: "Play a bundle or a script",
+	Long: `Play a bundle or a script
+
+A bundle created with "autotyper bundle" is played with its theme, hooks,
+output filters and simulators, without the files it was created from. The
+colors of the theme in the config file take precedence over the bundle, and
+the filters set with --filter run after the filters of the bundle. Encrypted
+bundles are decrypted with the age identities of --identity, or else with the
+password. Other files are played as scripts, like with --input-file. HTTPS
+URLs are fetched first, and scripts in git repositories (a repository named
+like a Go module, the script after a double slash) are played from a clone
+in the cache.
+
+With --only, a single step is played, typed like in the whole demo, for
+example to record a corrected segment of a video again.`,
+	Example: `  autotyper play k8s-intro.atd
+  autotyper play k8s-intro.atd --char-delay 50
+  autotyper play k8s-intro.atd --identity ~/.config/age/keys.txt
+  autotyper play demo.yaml
+  autotyper play demo.yaml --only deploy
+  autotyper play github.com/org/demos//k8s-intro
+  autotyper play github.com/org/demos@v1.2//k8s-intro/demo.yaml`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return playFile(cmd, args[0])
+	},
+}
+
+// playFile plays the bundle or the script, fetched first if needed
+func playFile(cmd *cobra.Command, name string) error {
+	filename, err := inputFile(name)
+	if err != nil {
+		return err
+	}
+	if bundle.IsBundle(filename) {
+		return playBundle(filename)
+	}
+	s, err := script.Load(filename)
+	if err != nil {
+		return err
+	}
+	if err := setHeaderFlags(cmd, s.Flags); err != nil {
+		return fmt.Errorf("%s: %w", filename, err)
+	}
+	opts, err := playerOptions()
+	if err != nil {
+		return err
+	}
+	opts.Source = name
+	return playScenario(s, opts)
+}
+
+// playBundle plays the bundle, with its files
+// extracted to a temporary directory
+func playBundle(filename string) error {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+	if err := trust.Check(filename, data); err != nil {
+		return err
+	}
+	var identities []age.Identity
+	if bundle.IsEncrypted(data) {
+		if identities, err = bundleIdentities(); err != nil {
+			return err
+		}
+	}
+	b, err := bundle.Read(data, identities...)
+	if err != nil {
+		return fmt.Errorf("%s: %w", filename, err)
+	}
+	dir, err := os.MkdirTemp("", "autotyper-bundle-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+	if err := b.Extract(dir); err != nil {
+		return err
+	}
+
+	// The theme of the bundle is the default of the config
+	for name, value := range b.Theme {
+		viper.SetDefault("theme."+name, value)
+	}
+	if err := setupTheme(); err != nil {
+		return err
+	}
+
+	opts, err := playerOptions()
+	if err != nil {
+		return err
+	}
+	opts.Source = filename
+	if b.Hooks != "" && opts.Hooks == nil {
+		opts.Hooks, err = hooks.Load(filepath.Join(dir, filepath.FromSlash(b.Hooks)))
+		if err != nil {
+			return err
+		}
+	}
+	var filters []*wasm.Module
+	for _, name := range b.Filters {
+		module, err := wasm.Load(filepath.Join(dir, filepath.FromSlash(name)))
+		if err != nil {
+			return fmt.Errorf("filter %s: %w", name, err)
+		}
+		filters = append(filters, module)
+	}
+	opts.Filters = append(filters, opts.Filters...)
+
+	return playScenario(b.Scenario, opts)
+}
+
+// bundleIdentities returns the identities decrypting the bundle: the
+// age identities in the files of the flags, or else the password
+func bundleIdentities() ([]age.Identity, error) {
+	var identities []age.Identity
+	for _, filename := range viper.GetStringSlice("play-identities") {
+		ids, err := bundle.LoadIdentities(filename)
+		if err != nil {
+			return nil, err
+		}
+		identities = append(identities, ids...)
+	}
+	if len(identities) > 0 {
+		return identities, nil
+	}
+
+	password, err := bundlePassword(false)
+	if err != nil {
+		return nil, err
+	}
+	identity, err := bundle.PasswordIdentity(password)
+	if err != nil {
+		return nil, err
+	}
+	return []age.Identity{identity}, nil
+}
+
+func init() {
+	rootCmd.AddCommand(playCmd)
+
+	// Add flags for decrypting the bundle
+	playCmd.Flags().StringArray("identity", nil, "decrypt the bundle with the age identities in this file")
+	viper.BindPFlag("play-identities", playCmd.Flags().Lookup("identity"))
+
+	// Add flags for playing a single step
+	playCmd.Flags().String("only", "", "play only this step, by number or name")
+	viper.BindPFlag("play-only", playCmd.Flags().Lookup("only"))
+}
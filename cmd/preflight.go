@@ -0,0 +1,95 @@
+/*
+Copyright © 2023 Mikael Schultz <bitcanon@proton.me>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/bitcanon/autotyper/player"
+	"github.com/bitcanon/autotyper/script"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// preflightCmd represents the preflight command
+var preflightCmd = &cobra.Command{
+	Use:   "preflight",
+	Short: "Check that a script can be played on this machine",
+	Long: `Check that a script can be played on this machine
+
+The script is loaded with the hooks, filters and simulators set by the flags,
+and checked without executing anything: the programs of the executed commands
+and of the external simulators must be installed, the modules of the
+simulators must exist, and the estimated duration must be within the bounds
+of --min-duration and --max-duration. Run it before going on stage.`,
+	Example: `  autotyper preflight -i demo.yaml
+  autotyper preflight -i demo.yaml --max-duration 5m
+  autotyper preflight -i demo.yaml --duration 90s --min-duration 80s`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		filename, err := inputFile(viper.GetString("preflight-input-file"))
+		if err != nil {
+			return err
+		}
+		s, err := script.Load(filename)
+		if err != nil {
+			return err
+		}
+		opts, err := playerOptions()
+		if err != nil {
+			return err
+		}
+
+		if err := stepRange(s, &opts); err != nil {
+			return err
+		}
+		p := player.New(s, io.Discard, opts)
+		if err := fitDuration(p); err != nil {
+			return err
+		}
+		issues := p.Preflight(viper.GetDuration("preflight-min-duration"), viper.GetDuration("preflight-max-duration"))
+		for _, issue := range issues {
+			fmt.Println(issue)
+		}
+		if len(issues) > 0 {
+			return fmt.Errorf("%d issues found in %s", len(issues), filename)
+		}
+		fmt.Printf("%s is ready to play (%d steps, about %.0fs)\n", filename, len(s.Steps), p.Estimate().Total.Seconds())
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(preflightCmd)
+
+	// Add flags for input file
+	preflightCmd.Flags().StringP("input-file", "i", "", "input file")
+	preflightCmd.MarkFlagRequired("input-file")
+	viper.BindPFlag("preflight-input-file", preflightCmd.Flags().Lookup("input-file"))
+
+	// Add flags for the bounds of the estimated duration
+	preflightCmd.Flags().Duration("min-duration", 0, "report an estimated duration shorter than this")
+	viper.BindPFlag("preflight-min-duration", preflightCmd.Flags().Lookup("min-duration"))
+	preflightCmd.Flags().Duration("max-duration", 0, "report an estimated duration longer than this")
+	viper.BindPFlag("preflight-max-duration", preflightCmd.Flags().Lookup("max-duration"))
+}
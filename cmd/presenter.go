@@ -0,0 +1,60 @@
+/*
+Copyright © 2023 Mikael Schultz <bitcanon@proton.me>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/bitcanon/autotyper/player"
+	"github.com/bitcanon/autotyper/script"
+	"github.com/bitcanon/autotyper/terminal"
+	"github.com/bitcanon/autotyper/tui"
+)
+
+// startPresenter draws the control view of the playback on another
+// terminal (e.g. /dev/pts/3), whose keys control the playback as
+// well. Call the returned function to restore the terminal
+func startPresenter(ctx context.Context, name string, s *script.Scenario, p *player.Player, controls *playbackControls, stop func()) (func(), error) {
+	f, err := os.OpenFile(name, os.O_RDWR, 0)
+	if err != nil {
+		return nil, fmt.Errorf("presenter: %w", err)
+	}
+	term := terminal.New(f, f)
+	if err := term.EnableControls(); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("presenter: %s: %w", name, err)
+	}
+	term.HideCursor()
+
+	view := tui.NewPresenter(f, s.Steps, p.Status, func() int { return terminal.Width(f) })
+	p.OnEvent(view.Handle)
+	go view.Run(ctx)
+	go terminal.NewKeys(f, io.Discard).Run(controlKeys(controls, stop))
+
+	return func() {
+		term.Restore()
+		f.Close()
+	}, nil
+}
@@ -0,0 +1,83 @@
+/*
+Copyright © 2023 Mikael Schultz <bitcanon@proton.me>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"context"
+	"os"
+	"os/signal"
+
+	"github.com/bitcanon/autotyper/cli"
+	"github.com/bitcanon/autotyper/terminal"
+	"github.com/bitcanon/autotyper/tui"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// previewCmd represents the preview command
+var previewCmd = &cobra.Command{
+	Use:   "preview [command]",
+	Short: "Show the prompts of the shells in the built-in themes",
+	Long: `Show the prompts of the shells in the built-in themes
+
+The prompt of each shell is drawn in the default theme and in each
+colorblind-safe palette side by side, followed by a sample command, so that
+a look can be picked without editing and playing a script. The username,
+hostname and path of the prompts are set by the usual flags. With --type,
+the command is typed in all of them at once, with the delay of --char-delay.`,
+	Example: `  autotyper preview
+  autotyper preview "kubectl get pods -A" --shell bash --username demo
+  autotyper preview --type --char-delay 150`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		command := "git status --short"
+		if len(args) > 0 {
+			command = cli.StripReadings(args[0])
+		}
+
+		preview := &tui.Preview{
+			Prompt: cli.Prompt{
+				Username: viper.GetString("prompt-username"),
+				Hostname: viper.GetString("prompt-hostname"),
+				Path:     viper.GetString("prompt-path"),
+			},
+			Command: command,
+			Looks:   tui.Looks(),
+			Width:   terminal.Width(os.Stdout),
+		}
+		if !viper.GetBool("preview-type") {
+			return preview.Draw(os.Stdout)
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+		return preview.Type(ctx, os.Stdout, viper.GetInt("char-delay"))
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(previewCmd)
+
+	// Add a flag for typing the sample command
+	previewCmd.Flags().Bool("type", false, "type the sample command instead of printing it at once")
+	viper.BindPFlag("preview-type", previewCmd.Flags().Lookup("type"))
+}
@@ -0,0 +1,195 @@
+/*
+Copyright © 2023 Mikael Schultz <bitcanon@proton.me>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/bitcanon/autotyper/remote"
+	"github.com/bitcanon/autotyper/script"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// remoteCmd represents the remote command
+var remoteCmd = &cobra.Command{
+	Use:   "remote",
+	Short: "Control an agent running on the demo machine",
+	Long: `Control an agent running on the demo machine
+
+The remote commands send scripts and playback commands to an agent started
+with the serve command, so the demo can be driven from another machine.`,
+	Example: `  autotyper remote load -i commands.txt -a stage:7070 --token s3cret
+  autotyper remote play -a https://stage:7070 --ca-cert agent.crt --token s3cret
+  autotyper remote play -a stage:7070
+  autotyper remote pause -a stage:7070
+  autotyper remote step -a stage:7070
+  autotyper remote status -a stage:7070`,
+}
+
+// remoteLoadCmd represents the remote load command
+var remoteLoadCmd = &cobra.Command{
+	Use:   "load",
+	Short: "Send a script to the agent",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// Parse the script locally and send it as a JSON scenario,
+		// so the agent does not need to know the original format
+		filename, err := inputFile(viper.GetString("remote-input-file"))
+		if err != nil {
+			return err
+		}
+		s, err := script.Load(filename)
+		if err != nil {
+			return err
+		}
+		data, err := json.Marshal(s)
+		if err != nil {
+			return err
+		}
+		c, err := controller()
+		if err != nil {
+			return err
+		}
+		return printStatus(c.Load(string(data)))
+	},
+}
+
+// remotePlayCmd represents the remote play command
+var remotePlayCmd = &cobra.Command{
+	Use:   "play",
+	Short: "Start or resume the playback on the agent",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c, err := controller()
+		if err != nil {
+			return err
+		}
+		return printStatus(c.Play())
+	},
+}
+
+// remotePauseCmd represents the remote pause command
+var remotePauseCmd = &cobra.Command{
+	Use:   "pause",
+	Short: "Pause the playback on the agent",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c, err := controller()
+		if err != nil {
+			return err
+		}
+		return printStatus(c.Pause())
+	},
+}
+
+// remoteStepCmd represents the remote step command
+var remoteStepCmd = &cobra.Command{
+	Use:   "step",
+	Short: "Play the next command on the agent",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c, err := controller()
+		if err != nil {
+			return err
+		}
+		return printStatus(c.Step())
+	},
+}
+
+// remoteStatusCmd represents the remote status command
+var remoteStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show the playback status of the agent",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c, err := controller()
+		if err != nil {
+			return err
+		}
+		return printStatus(c.Status())
+	},
+}
+
+// controller creates a controller for the configured agent
+func controller() (*remote.Controller, error) {
+	c := remote.NewController(viper.GetString("remote-agent"))
+	auth, err := controlAuth("remote")
+	if err != nil {
+		return nil, err
+	}
+	c.Auth = auth
+
+	// Trust the certificate of an agent not signed by a known authority
+	if caFile := viper.GetString("remote-ca-cert"); caFile != "" {
+		pem, err := os.ReadFile(caFile)
+		if err != nil {
+			return nil, err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in %s", caFile)
+		}
+		c.Client = &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: pool}}}
+	}
+	return c, nil
+}
+
+// printStatus prints the status returned by the agent
+func printStatus(status remote.Status, err error) error {
+	if err != nil {
+		return err
+	}
+	if status.Queued > 0 {
+		fmt.Printf("%s (step %d of %d, %d queued)\n", status.State, status.Step, status.Total, status.Queued)
+		return nil
+	}
+	fmt.Printf("%s (step %d of %d)\n", status.State, status.Step, status.Total)
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(remoteCmd)
+	remoteCmd.AddCommand(remoteLoadCmd, remotePlayCmd, remotePauseCmd, remoteStepCmd, remoteStatusCmd)
+
+	// Add flags for the agent address
+	remoteCmd.PersistentFlags().StringP("agent", "a", "127.0.0.1:7070", "address of the agent")
+	viper.BindPFlag("remote-agent", remoteCmd.PersistentFlags().Lookup("agent"))
+
+	// Add flags for the credentials sent to the agent
+	remoteCmd.PersistentFlags().String("token", "", "bearer token of the agent (default $AUTOTYPER_TOKEN)")
+	viper.BindPFlag("remote-token", remoteCmd.PersistentFlags().Lookup("token"))
+	remoteCmd.PersistentFlags().String("basic-auth", "", "user:password of the agent with basic auth")
+	viper.BindPFlag("remote-basic-auth", remoteCmd.PersistentFlags().Lookup("basic-auth"))
+	remoteCmd.PersistentFlags().String("ca-cert", "", "certificate authority trusted for an https agent")
+	viper.BindPFlag("remote-ca-cert", remoteCmd.PersistentFlags().Lookup("ca-cert"))
+
+	// Add flags for input file
+	remoteLoadCmd.Flags().StringP("input-file", "i", "", "input file")
+	remoteLoadCmd.MarkFlagRequired("input-file")
+	viper.BindPFlag("remote-input-file", remoteLoadCmd.Flags().Lookup("input-file"))
+}
@@ -22,18 +22,45 @@ THE SOFTWARE.
 package cmd
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"log/slog"
 	"os"
+	"os/signal"
+	"path/filepath"
 	"strings"
+	"syscall"
 	"time"
 
+	"github.com/bitcanon/autotyper/audio"
+	"github.com/bitcanon/autotyper/audit"
+	"github.com/bitcanon/autotyper/bundle"
+	"github.com/bitcanon/autotyper/cache"
 	"github.com/bitcanon/autotyper/cli"
+	"github.com/bitcanon/autotyper/fetch"
+	"github.com/bitcanon/autotyper/frames"
+	"github.com/bitcanon/autotyper/graphics"
+	"github.com/bitcanon/autotyper/hooks"
+	"github.com/bitcanon/autotyper/locale"
+	"github.com/bitcanon/autotyper/player"
+	"github.com/bitcanon/autotyper/script"
+	"github.com/bitcanon/autotyper/sign"
+	"github.com/bitcanon/autotyper/simulate"
+	"github.com/bitcanon/autotyper/terminal"
+	"github.com/bitcanon/autotyper/vt"
+	"github.com/bitcanon/autotyper/wasm"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
 
 var cfgFile string
 
+// version is the version of AutoTyper
+const version = "1.0.0"
+
 // rootCmd represents the base command when called without any subcommands
 var rootCmd = &cobra.Command{
 	Use:   "autotyper [flags] <command>",
@@ -48,27 +75,94 @@ test command line applications or to create demos of command line applications.`
   autotyper -i commands.txt --no-cls
   autotyper -i commands.txt --pre-delay 250 --post-delay 2000
   autotyper -i commands.txt -u bitcanon -H code -p C:\Users\bitcanon\Documents -s bash
+  autotyper -i scenario.json
+  autotyper -i demo.atd
+  autotyper -i https://example.com/demos/k8s-intro.yaml
+  autotyper -i commands.txt --ask ticket --ask hostname
+  autotyper -i commands.txt --sandbox
+  autotyper -i commands.txt --alt-screen
+  autotyper -i commands.txt --cols 100 --rows 30
+  autotyper -i commands.txt --ramp 2 --ramp-commands 3
+  autotyper -i commands.txt --hesitation 0.8 --pause-pipe 400 --pause-flag 250
+  autotyper --ime ja "echo {日本語|nihongo}です"
+  autotyper --from-clipboard --char-delay 50
   autotyper ping one.one.one.one
   cat commands.txt | autotyper`,
-	Version:      "1.0.0",
+	Args:         cobra.ArbitraryArgs,
+	Version:      version,
 	SilenceUsage: true,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		if err := setupLogging(); err != nil {
+			return err
+		}
+		if err := setupTheme(); err != nil {
+			return err
+		}
+		if err := setupSigning(); err != nil {
+			return err
+		}
+		return setupCommandPolicy()
+	},
 	// Uncomment the following line if your bare application
 	// has an action associated with it:
 	RunE: func(cmd *cobra.Command, args []string) error {
-		// Input string to hold the processed input
-		var input string
+		// Scenario to hold the processed input
+		var s *script.Scenario
+		var source string
 		var err error
 
 		// Check if data is being piped, read from file or redirected to stdin
-		if viper.GetString("input-file") != "" {
-			// Read input from file
-			input, err = cli.ProcessFile(viper.GetString("input-file"))
+		if viper.GetBool("stream") {
+			// Play the script while it is read
+			return playStream()
+		} else if viper.GetString("input-file") != "" {
+			// Read input from file (plain text or JSON scenario,
+			// or a bundle), fetched first if it is a URL
+			filename, err := inputFile(viper.GetString("input-file"))
+			if err != nil {
+				return err
+			}
+			if bundle.IsBundle(filename) {
+				return playBundle(filename)
+			}
+			s, err = script.Load(filename)
+			if err != nil {
+				return err
+			}
+			if err := setHeaderFlags(cmd, s.Flags); err != nil {
+				return fmt.Errorf("%s: %w", filename, err)
+			}
+			source = viper.GetString("input-file")
+		} else if viper.GetBool("from-clipboard") {
+			// Read the script copied to the clipboard,
+			// which cannot be signed
+			if trust.Require {
+				return fmt.Errorf("clipboard: %w", sign.ErrUnsigned)
+			}
+			input, err := cli.ProcessClipboard()
 			if err != nil {
 				return err
 			}
+			s, err = script.Parse(input)
+			if err != nil {
+				return err
+			}
+			source = "clipboard"
+		} else if len(args) == 1 && isExecutable(args[0]) {
+			// The script is run directly, with an interpreter
+			// line like "#!/usr/bin/env autotyper"
+			return playFile(cmd, args[0])
 		} else if stat, _ := os.Stdin.Stat(); (stat.Mode() & os.ModeCharDevice) == 0 {
-			// Process data from pipe or redirection (stdin)
-			input, err = cli.ProcessStdin()
+			// Process data from pipe or redirection (stdin),
+			// which cannot be signed
+			if trust.Require {
+				return fmt.Errorf("standard input: %w", sign.ErrUnsigned)
+			}
+			input, err := cli.ProcessStdin()
+			if err != nil {
+				return err
+			}
+			s, err = script.Parse(input)
 			if err != nil {
 				return err
 			}
@@ -80,110 +174,615 @@ test command line applications or to create demos of command line applications.`
 			} else {
 				// If there are command line arguments, join them
 				// into a single string and use that as user input
-				input = strings.Join(args, " ")
+				s, err = script.ParseText(strings.Join(args, " "))
+				if err != nil {
+					return err
+				}
 			}
 		}
 
-		// Clear the screen before printing the prompt
-		if err := cli.ClearScreen(); err != nil {
-			fmt.Println(err)
-		}
-
-		// Prepare the prompt
-		var shellOption cli.ShellOption
-		switch viper.GetString("shell") {
-		case "cmd":
-			shellOption = cli.Cmd
-		case "bash":
-			shellOption = cli.Bash
-		default:
-			shellOption = cli.PS
-		}
-
-		// Setup the path
-		path := viper.GetString("prompt-path")
-		if path == "" {
-			switch shellOption {
-			case cli.Cmd:
-				path = "C:\\"
-			case cli.Bash:
-				path = "~"
-			default:
-				path = "C:\\"
-			}
+		opts, err := playerOptions()
+		if err != nil {
+			return err
 		}
+		opts.Source = source
+		return playScenario(s, opts)
+	},
+}
+
+// Execute adds all child commands to the root command and sets flags appropriately.
+// This is called by main.main(). It only needs to happen once to the rootCmd.
+func Execute() {
+	err := rootCmd.Execute()
+	if err != nil {
+		os.Exit(1)
+	}
+}
 
-		// Setup the prompt
-		p := cli.Prompt{
-			Username: viper.GetString("prompt-username"),
-			Hostname: viper.GetString("prompt-hostname"),
-			Path:     path,
-			Shell:    shellOption,
+// playScenario plays the scenario on the terminal with the options,
+// asking for the variables first and writing the timing report after
+func playScenario(s *script.Scenario, opts player.Options) error {
+	// The presenter types into the terminal directly when
+	// the script is piped to the standard input
+	in := os.Stdin
+	if !terminal.New(in, os.Stdout).IsTerminal() {
+		if tty, err := terminal.OpenTTY(); err == nil {
+			defer tty.Close()
+			in = tty
 		}
+	}
 
-		// Replace "\r\n" with "\n" to ensure consistent line endings
-		input = strings.ReplaceAll(input, "\r\n", "\n")
+	// Ask the presenter for the values of the variables
+	opts.Variables = map[string]string{}
+	for _, name := range viper.GetStringSlice("ask") {
+		if !script.ValidVariableName(name) {
+			return fmt.Errorf("invalid variable name %q", name)
+		}
+		value, err := cli.AskValue(name, in, os.Stderr)
+		if err != nil {
+			return err
+		}
+		opts.Variables[name] = value
+	}
 
-		// Split the input string into a slice of strings
-		// based on the newline character
-		commands := strings.Split(input, "\n")
+	// Stop the playback on interrupt, so the terminal is restored
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
-		// Print the prompt
-		cli.PrintPrompt(p, os.Stdout)
+	// Play in a window of another terminal application, if any
+	screen, width := io.Writer(os.Stdout), func() int { return terminal.Width(os.Stdout) }
+	if name := viper.GetString("target"); name != "" {
+		window, err := openTarget(ctx, name)
+		if err != nil {
+			return err
+		}
+		defer window.Close()
+		screen, width = window, window.Width
+	}
 
-		// Delay before typing the first character of each command
-		typeDelay := viper.GetInt("pre-delay")
+	// Play on a virtual screen of a fixed size, if any, and
+	// record the frames and the screenshots of the output
+	out, width := screenOutput(screen, width)
+	opts.Width = width
+	var recorder *frames.Recorder
+	if dir := viper.GetString("frames"); dir != "" {
+		// The fast frames are rendered on a fake clock, skipping
+		// the delays of the playback instead of waiting for them
+		opts.Clock = cli.SystemClock{}
+		if viper.GetBool("frames-fast") {
+			opts.Clock = cli.NewFakeClock(time.Now())
+		}
+		var err error
+		if recorder, err = newRecorder(dir, opts.Clock); err != nil {
+			return err
+		}
+		out = io.MultiWriter(out, recorder)
+	}
+	var snapshots *frames.Snapshots
+	if dir := viper.GetString("screenshots"); dir != "" {
+		var err error
+		if snapshots, err = newSnapshots(dir); err != nil {
+			return err
+		}
+		out = io.MultiWriter(out, snapshots)
+	}
+	// Restore the terminal on any return, including panics
+	term := terminal.New(in, os.Stdout)
+	defer term.Restore()
 
-		// Iterate over the slice of strings
-		for _, command := range commands {
+	// Enable the keyboard controls if the input is a terminal
+	var keys *terminal.Keys
+	if term.IsTerminal() {
+		if err := term.EnableControls(); err != nil {
+			return err
+		}
+		keys = terminal.NewKeys(in, out)
+		opts.Input = keys
+	}
 
-			// Delay before starting to type the command
-			if typeDelay > 0 {
-				time.Sleep(time.Duration(typeDelay) * time.Millisecond)
-			}
+	// Play the steps one by one, from the step to start at
+	if err := stepRange(s, &opts); err != nil {
+		return err
+	}
+	p := player.New(s, out, opts)
+	if err := fitDuration(p); err != nil {
+		return err
+	}
+	if snapshots != nil {
+		p.OnEvent(captureSteps(s, snapshots))
+	}
+	controls := &playbackControls{p: p}
+	if keys != nil {
+		go keys.Run(controlKeys(controls, stop))
+	}
+	if err := startTriggers(ctx, controls); err != nil {
+		return err
+	}
 
-			// Type command as human, with a delay between each character
-			charDelay := viper.GetInt("char-delay")
-			if err := cli.TypeAsHuman(command, os.Stdout, charDelay); err != nil {
-				fmt.Printf("Error: %v\n", err)
-			}
-			fmt.Println()
+	// Draw the control view on the terminal of the presenter, if any
+	if name := viper.GetString("presenter"); name != "" {
+		restore, err := startPresenter(ctx, name, s, p, controls, stop)
+		if err != nil {
+			return err
+		}
+		defer restore()
+	}
 
-			// Execute the command and print the output
-			if err := cli.ExecuteCommand(command, os.Stdout); err != nil {
-				fmt.Printf("Error: %v\n", err)
-			}
+	// Show the time elapsed and left of the slot, if asked to
+	if name := viper.GetString("timer"); name != "" {
+		remove, err := startTimer(ctx, name, out, p)
+		if err != nil {
+			return err
+		}
+		defer remove()
+	}
 
-			// Print the prompt after the command output
-			cli.PrintPrompt(p, os.Stdout)
+	// Draw the line being typed again when the window is resized
+	terminal.NotifyResize(ctx, p.Resize)
 
-			// Delay between each command
-			delay := viper.GetInt("post-delay")
-			if delay > 0 {
-				time.Sleep(time.Duration(delay) * time.Millisecond)
-			}
+	// Hold the playback and the commands while suspended by Ctrl+Z,
+	// with the terminal restored for the shell
+	terminal.NotifySuspend(ctx, func() {
+		p.Suspend()
+		cli.SuspendCommands()
+		term.Restore()
+	}, func() {
+		if keys != nil {
+			term.EnableControls()
+		}
+		cli.ContinueCommands()
+		p.Continue()
+	})
 
-			// Clear the screen between commands (not the last command)
-			lastLine := commands[len(commands)-1]
-			if !viper.GetBool("no-cls") && command != lastLine {
-				if err := cli.ClearScreen(); err != nil {
-					fmt.Println(err)
-				}
-				cli.PrintPrompt(p, os.Stdout)
-			}
+	// A playback stopped by the presenter is not an error
+	err := p.Run(ctx)
+	if recorder != nil {
+		if err := recorder.Close(); err != nil {
+			return fmt.Errorf("failed to write the frames: %w", err)
+		}
+	}
+	if err := writeReport(p.Report()); err != nil {
+		return err
+	}
+	if errors.Is(err, context.Canceled) {
+		return nil
+	}
+	return err
+}
+
+// promptOptions builds the prompt from the flags, the config file
+// and the environment, an unknown shell falls back to PowerShell
+func promptOptions() cli.Prompt {
+	shellOption, _ := cli.ParseShellOption(viper.GetString("shell"))
+	return cli.Prompt{
+		Username: viper.GetString("prompt-username"),
+		Hostname: viper.GetString("prompt-hostname"),
+		Path:     viper.GetString("prompt-path"),
+		Shell:    shellOption,
+	}
+}
+
+// playerOptions builds the playback options from
+// the flags, the config file and the environment
+func playerOptions() (player.Options, error) {
+	// Compile the patterns of dangerous commands
+	dangerous, err := cli.CompilePatterns(viper.GetStringSlice("dangerous-patterns"))
+	if err != nil {
+		return player.Options{}, err
+	}
+
+	// Prepare the input method simulated for CJK text
+	im, ok := cli.ParseInputMethod(viper.GetString("ime"))
+	if !ok {
+		return player.Options{}, fmt.Errorf("invalid input method %q, expected ja, zh or ko", viper.GetString("ime"))
+	}
+
+	// Prepare the ramp of the typing speed
+	curve, err := player.ParseCurve(viper.GetString("ramp-curve"))
+	if err != nil {
+		return player.Options{}, err
+	}
+
+	// Pick the protocol of the images, guessed from the terminal by default
+	images := graphics.Detect(os.Getenv)
+	if name := viper.GetString("images"); name != "auto" {
+		if images, err = graphics.ParseProtocol(name); err != nil {
+			return player.Options{}, err
+		}
+	}
+
+	opts := player.Options{
+		Prompt:    promptOptions(),
+		CharDelay: viper.GetInt("char-delay"),
+		PreDelay:  viper.GetInt("pre-delay"),
+		PostDelay: viper.GetInt("post-delay"),
+		NoClear:   viper.GetBool("no-cls"),
+		TypeClear: viper.GetBool("type-clear"),
+		AltScreen: viper.GetBool("alt-screen"),
+
+		Accessible: viper.GetBool("a11y"),
+
+		TypeExit:    viper.GetBool("type-exit"),
+		ExitMessage: viper.GetString("exit-message"),
+		ExitClear:   viper.GetBool("exit-clear"),
+
+		InputMethod: im,
+		Hesitation:  viper.GetFloat64("hesitation"),
+		Pauses: cli.TokenPauses{
+			Pipe: viper.GetInt("pause-pipe"),
+			Flag: viper.GetInt("pause-flag"),
+			Path: viper.GetInt("pause-path"),
+		},
+		CopyCommands:    viper.GetBool("copy-commands"),
+		Images:          images,
+		QRCodeDuration:  viper.GetInt("qrcode-duration"),
+		ChapterBell:     viper.GetBool("chapter-bell"),
+		ChapterCommand:  viper.GetString("chapter-command"),
+		PlayAudio:       viper.GetBool("play-audio"),
+		ClearScrollback: viper.GetBool("clear-scrollback"),
+		ClearAfter:      viper.GetInt("clear-after"),
+		Tags:            viper.GetStringSlice("tags"),
+		SkipTags:        viper.GetStringSlice("skip-tags"),
+		Ramp: player.Ramp{
+			Factor:   viper.GetFloat64("ramp"),
+			Commands: viper.GetInt("ramp-commands"),
+			Curve:    curve,
+		},
+
+		Motd: viper.GetString("motd"),
+		SystemInfo: cli.SystemInfo{
+			OS:     viper.GetString("sysinfo.os"),
+			Kernel: viper.GetString("sysinfo.kernel"),
+			Uptime: viper.GetString("sysinfo.uptime"),
+			Shell:  viper.GetString("sysinfo.shell"),
+			CPU:    viper.GetString("sysinfo.cpu"),
+			Memory: viper.GetString("sysinfo.memory"),
+		},
+
+		Dangerous:         dangerous,
+		TypeOnlyDangerous: viper.GetBool("type-only-dangerous"),
+	}
+
+	// Speak the narration of the steps, if asked to
+	if viper.GetBool("speak") {
+		opts.Speaker = &audio.Speaker{Voice: viper.GetString("voice"), Command: viper.GetString("speech-command")}
+	}
+
+	// Load the JavaScript hooks of the playback, if any
+	if filename := viper.GetString("hooks"); filename != "" {
+		opts.Hooks, err = hooks.Load(filename)
+		if err != nil {
+			return player.Options{}, err
+		}
+	}
+
+	// Fire the webhooks of the playback, if any
+	if opts.Webhooks, err = newWebhooks(); err != nil {
+		return player.Options{}, err
+	}
+
+	// Write the presenter notes of the steps, if asked to
+	if target := viper.GetString("notes"); target != "" {
+		if opts.Notes, err = openNotes(target); err != nil {
+			return player.Options{}, err
+		}
+	}
+
+	// Record the executed commands in the audit log, if any. The
+	// log is closed when the program exits
+	if target := viper.GetString("audit-log"); target != "" {
+		opts.Audit, err = audit.Open(target)
+		if err != nil {
+			return player.Options{}, fmt.Errorf("audit log: %w", err)
+		}
+	}
+
+	// Parse the rules highlighting the output of all commands
+	for _, rule := range viper.GetStringSlice("highlights") {
+		h, err := cli.ParseHighlight(rule)
+		if err != nil {
+			return player.Options{}, err
+		}
+		opts.Highlights = append(opts.Highlights, h)
+	}
+
+	// Load the WebAssembly filters of the output, if any
+	for _, filename := range viper.GetStringSlice("filters") {
+		module, err := wasm.Load(filename)
+		if err != nil {
+			return player.Options{}, fmt.Errorf("filter %s: %w", filename, err)
+		}
+		opts.Filters = append(opts.Filters, module)
+	}
+
+	// Replay the output of the commands cached by the previous runs
+	if viper.GetBool("cached") || viper.GetBool("refresh-cache") {
+		opts.Cache = cache.New(filepath.Join(fetch.DefaultCacheDir(), "output"))
+		opts.RefreshCache = viper.GetBool("refresh-cache")
+	}
+
+	// Simulate the output of the commands in sandbox mode
+	if viper.GetBool("sandbox") {
+		opts.Sandbox, err = newSandbox(opts.Prompt)
+		if err != nil {
+			return player.Options{}, err
+		}
+	}
+
+	return opts, nil
+}
+
+// screenOutput returns the output of the playback and its width: the
+// screen of the terminal, or a virtual screen of a fixed size drawn on
+// the screen if the number of columns or rows has been set. Headless
+// playbacks are played on a terminal in memory of that size instead.
+func screenOutput(screen io.Writer, width func() int) (io.Writer, func() int) {
+	headless := viper.GetBool("headless")
+	cols, rows := viper.GetInt("cols"), viper.GetInt("rows")
+	if cols <= 0 && rows <= 0 && !headless {
+		return screen, width
+	}
+	if cols <= 0 {
+		cols = 80
+	}
+	if rows <= 0 {
+		rows = 24
+	}
+
+	// Commands in pseudo-terminals see the same size
+	cli.PTYCols, cli.PTYRows = cols, rows
+	if headless {
+		return vt.NewMemory(cols, rows), func() int { return cols }
+	}
+	return vt.NewView(cols, rows, screen), func() int { return cols }
+}
+
+// newRecorder creates the recorder of the PNG frames of the
+// playback, at the pace of the clock of the playback
+func newRecorder(dir string, clock cli.Clock) (*frames.Recorder, error) {
+	cols, rows := recordedSize()
+	renderer, err := frames.NewRenderer(viper.GetFloat64("frames-font-size"))
+	if err != nil {
+		return nil, err
+	}
+	return frames.NewRecorder(dir, cols, rows, viper.GetInt("frames-fps"), renderer, clock)
+}
+
+// newSnapshots creates the snapshots of the screen taken after each
+// command, as text files or as PNG images
+func newSnapshots(dir string) (*frames.Snapshots, error) {
+	cols, rows := recordedSize()
+	switch format := viper.GetString("screenshot-format"); format {
+	case "text":
+		return frames.NewSnapshots(dir, cols, rows, nil)
+	case "png":
+		renderer, err := frames.NewRenderer(viper.GetFloat64("frames-font-size"))
+		if err != nil {
+			return nil, err
+		}
+		return frames.NewSnapshots(dir, cols, rows, renderer)
+	default:
+		return nil, fmt.Errorf("invalid screenshot format %q (text or png)", format)
+	}
+}
+
+// captureSteps returns the event handler writing a snapshot of the
+// screen after each command, named by its step
+func captureSteps(s *script.Scenario, snapshots *frames.Snapshots) func(player.Event) {
+	return func(e player.Event) {
+		if e.Type != player.StepFinished || e.Command == "" {
+			return
+		}
+		name := ""
+		if e.Step < len(s.Steps) {
+			name = s.Steps[e.Step].Name
+		}
+		filename, err := snapshots.Capture(frames.StepName(e.Step+1, name))
+		if err != nil {
+			slog.Warn("failed to write the screenshot", "step", e.Step+1, "error", err)
+			return
 		}
+		slog.Debug("wrote screenshot", "step", e.Step+1, "file", filename)
+	}
+}
+
+// recordedSize returns the size of the screen recorded into frames
+// and screenshots: the virtual screen, 80x24 if it has not been set
+func recordedSize() (cols, rows int) {
+	cols, rows = viper.GetInt("cols"), viper.GetInt("rows")
+	if cols <= 0 {
+		cols = 80
+	}
+	if rows <= 0 {
+		rows = 24
+	}
+	return cols, rows
+}
+
+// newSandbox creates a sandbox with the fake outputs from the config
+// file, reporting the user and host of the prompt
+func newSandbox(prompt cli.Prompt) (*simulate.Sandbox, error) {
+	var outputs []struct {
+		Match  string
+		Output string
+	}
+	if err := viper.UnmarshalKey("sandbox-outputs", &outputs); err != nil {
+		return nil, fmt.Errorf("sandbox-outputs: %w", err)
+	}
+
+	sandbox := &simulate.Sandbox{Username: prompt.Username, Hostname: prompt.Hostname}
+	for _, o := range outputs {
+		fake, err := simulate.NewFake(o.Match, o.Output)
+		if err != nil {
+			return nil, fmt.Errorf("sandbox-outputs: %w", err)
+		}
+		sandbox.Fakes = append(sandbox.Fakes, fake)
+	}
+	return sandbox, nil
+}
+
+// trust is the policy deciding which scripts are trusted
+var trust sign.Policy
+
+// setupSigning sets the policy of the signatures of the scripts
+// from the trusted keys, and verifies the scripts on loading
+func setupSigning() error {
+	trust = sign.Policy{Require: viper.GetBool("require-signed")}
+	for _, key := range viper.GetStringSlice("trusted-keys") {
+		public, err := sign.LoadPublicKey(key)
+		if err != nil {
+			return fmt.Errorf("trusted-keys: %w", err)
+		}
+		trust.Keys = append(trust.Keys, public)
+	}
+	if trust.Require && len(trust.Keys) == 0 {
+		return fmt.Errorf("--require-signed needs the public keys of trusted-keys")
+	}
+	script.Verify = trust.Check
+	return nil
+}
 
+// setupCommandPolicy sets the denylist and allowlist of
+// commands from the config file
+func setupCommandPolicy() error {
+	deny, err := cli.CompilePatterns(viper.GetStringSlice("denylist"))
+	if err != nil {
+		return fmt.Errorf("denylist: %w", err)
+	}
+	allow, err := cli.CompilePatterns(viper.GetStringSlice("allowlist"))
+	if err != nil {
+		return fmt.Errorf("allowlist: %w", err)
+	}
+
+	cli.CommandPolicy = cli.Policy{Deny: deny, Allow: allow}
+	return nil
+}
+
+// stepRange sets the steps of the scenario played, from the step
+// to start at up to the step to stop after, in the range, or only
+// the one step set by the flags, if any
+func stepRange(s *script.Scenario, opts *player.Options) error {
+	start, stop := viper.GetString("start-at"), viper.GetString("stop-after")
+	steps, only := viper.GetString("range"), viper.GetString("play-only")
+	switch {
+	case only != "" && (start != "" || stop != "" || steps != ""):
+		return errors.New("--only cannot be used with --start-at, --stop-after or --range")
+	case steps != "" && (start != "" || stop != ""):
+		return errors.New("--range cannot be used with --start-at or --stop-after")
+	case only != "":
+		steps = only
+	case start != "" || stop != "":
+		steps = start + ".." + stop
+	case steps == "":
 		return nil
-	},
+	}
+
+	var err error
+	opts.Start, opts.End, err = s.Range(steps)
+	return err
 }
 
-// Execute adds all child commands to the root command and sets flags appropriately.
-// This is called by main.main(). It only needs to happen once to the rootCmd.
-func Execute() {
-	err := rootCmd.Execute()
+// fitDuration scales the delays of the player, and the typing
+// speed if asked to, to the duration set by the flags
+func fitDuration(p *player.Player) error {
+	duration := viper.GetDuration("duration")
+	if duration <= 0 {
+		return nil
+	}
+	return p.Fit(duration, viper.GetBool("scale-typing"))
+}
+
+// writeReport prints the timing report of the playback to stderr,
+// and writes it as JSON to the report file, if asked to
+func writeReport(report player.Report) error {
+	if viper.GetBool("timing") {
+		fmt.Fprintln(os.Stderr)
+		if err := report.Print(os.Stderr); err != nil {
+			return err
+		}
+	}
+
+	name := viper.GetString("report")
+	if name == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(report, "", "  ")
 	if err != nil {
-		os.Exit(1)
+		return err
+	}
+	return os.WriteFile(name, append(data, '\n'), 0644)
+}
+
+// setupLogging sets the default logger from the log flags. The logs
+// are written to stderr, or appended to the log file if one is set
+func setupLogging() error {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(viper.GetString("log-level"))); err != nil {
+		return fmt.Errorf("invalid log level %q, expected debug, info, warn or error", viper.GetString("log-level"))
+	}
+	if viper.GetBool("verbose") {
+		level = slog.LevelDebug
+	}
+
+	var out io.Writer = os.Stderr
+	if name := viper.GetString("log-file"); name != "" {
+		// The file is closed when the program exits
+		file, err := os.OpenFile(name, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			return err
+		}
+		out = file
 	}
+
+	slog.SetDefault(slog.New(slog.NewTextHandler(out, &slog.HandlerOptions{Level: level})))
+	return nil
+}
+
+// setupTheme sets the colors of the theme from the palette and the
+// config file, and the color profile from the flags or the
+// capabilities of the terminal
+func setupTheme() error {
+	palette, err := cli.ParsePalette(viper.GetString("palette"))
+	if err != nil {
+		return err
+	}
+	cli.ActivePalette = palette
+
+	theme := cli.DefaultTheme
+	if palette != nil {
+		theme = palette.Theme
+	}
+	colors := map[string]*cli.Color{
+		"username": &theme.Username,
+		"path":     &theme.Path,
+		"command":  &theme.Command,
+		"caption":  &theme.Caption,
+	}
+	for name, color := range colors {
+		value := viper.GetString("theme." + name)
+		if value == "" {
+			continue
+		}
+		c, err := cli.ParseColor(value)
+		if err != nil {
+			return fmt.Errorf("theme.%s: %w", name, err)
+		}
+		*color = c
+	}
+	cli.ActiveTheme = theme
+
+	switch name := viper.GetString("colors"); name {
+	case "auto":
+		cli.ActiveColorProfile = cli.DetectColorProfile()
+	default:
+		profile, ok := cli.ParseColorProfile(name)
+		if !ok {
+			return fmt.Errorf("invalid color profile %q, expected auto, 16, 256 or truecolor", name)
+		}
+		cli.ActiveColorProfile = profile
+	}
+	cli.Accessible = viper.GetBool("a11y")
+	return nil
 }
 
 func init() {
@@ -198,38 +797,282 @@ func init() {
 	// Add flags for input file
 	rootCmd.Flags().StringP("input-file", "i", "", "input file")
 	viper.BindPFlag("input-file", rootCmd.Flags().Lookup("input-file"))
+	rootCmd.Flags().Bool("stream", false, "play the plain text script of the input file or the standard input while it is read")
+	viper.BindPFlag("stream", rootCmd.Flags().Lookup("stream"))
+	rootCmd.Flags().Int("max-line-size", 64, "size in MiB of the longest line of a script streamed with --stream")
+	viper.BindPFlag("max-line-size", rootCmd.Flags().Lookup("max-line-size"))
+
+	// Add flags for reading the script from the clipboard
+	rootCmd.Flags().Bool("from-clipboard", false, "read the script from the clipboard")
+	viper.BindPFlag("from-clipboard", rootCmd.Flags().Lookup("from-clipboard"))
+
+	// Add flags for the variables to ask for before the playback
+	rootCmd.Flags().StringSlice("ask", nil, "ask for the value of a variable used as ${name} in the commands")
+	viper.BindPFlag("ask", rootCmd.Flags().Lookup("ask"))
 
 	// Add flags for the delay between each character
-	rootCmd.Flags().IntP("char-delay", "c", 75, "delay between each character in milliseconds")
-	viper.BindPFlag("char-delay", rootCmd.Flags().Lookup("char-delay"))
+	rootCmd.PersistentFlags().IntP("char-delay", "c", 75, "delay between each character in milliseconds")
+	viper.BindPFlag("char-delay", rootCmd.PersistentFlags().Lookup("char-delay"))
 
 	// Add flags for the delay between each character
-	rootCmd.Flags().IntP("pre-delay", "d", 500, "delay before each command in milliseconds")
-	viper.BindPFlag("pre-delay", rootCmd.Flags().Lookup("pre-delay"))
+	rootCmd.PersistentFlags().IntP("pre-delay", "d", 500, "delay before each command in milliseconds")
+	viper.BindPFlag("pre-delay", rootCmd.PersistentFlags().Lookup("pre-delay"))
 
 	// Add flags for the shell prompt
-	rootCmd.Flags().StringP("shell", "s", "ps", "shell prompt to simulate: bash, cmd or ps")
-	viper.BindPFlag("shell", rootCmd.Flags().Lookup("shell"))
+	rootCmd.PersistentFlags().StringP("shell", "s", "ps", "shell prompt to simulate: bash, cmd or ps")
+	viper.BindPFlag("shell", rootCmd.PersistentFlags().Lookup("shell"))
 
 	// Add flags for the username
-	rootCmd.Flags().StringP("username", "u", "bitcanon", "username to print in the bash prompt")
-	viper.BindPFlag("prompt-username", rootCmd.Flags().Lookup("username"))
+	rootCmd.PersistentFlags().StringP("username", "u", "bitcanon", "username to print in the bash prompt")
+	viper.BindPFlag("prompt-username", rootCmd.PersistentFlags().Lookup("username"))
 
 	// Add flags for the hostname
-	rootCmd.Flags().StringP("hostname", "H", "code", "hostname to print in the bash prompt")
-	viper.BindPFlag("prompt-hostname", rootCmd.Flags().Lookup("hostname"))
+	rootCmd.PersistentFlags().StringP("hostname", "H", "code", "hostname to print in the bash prompt")
+	viper.BindPFlag("prompt-hostname", rootCmd.PersistentFlags().Lookup("hostname"))
 
 	// Add flags for the path
-	rootCmd.Flags().StringP("path", "p", "", "path to use in the prompt")
-	viper.BindPFlag("prompt-path", rootCmd.Flags().Lookup("path"))
+	rootCmd.PersistentFlags().StringP("path", "p", "", "path to use in the prompt")
+	viper.BindPFlag("prompt-path", rootCmd.PersistentFlags().Lookup("path"))
 
 	// Add flags for the delay between each command if multiple commands are entered
-	rootCmd.Flags().IntP("post-delay", "D", 3500, "delay after each command in milliseconds")
-	viper.BindPFlag("post-delay", rootCmd.Flags().Lookup("post-delay"))
+	rootCmd.PersistentFlags().IntP("post-delay", "D", 3500, "delay after each command in milliseconds")
+	viper.BindPFlag("post-delay", rootCmd.PersistentFlags().Lookup("post-delay"))
 
 	// Add flags for the option to clear the screen between commands
-	rootCmd.Flags().BoolP("no-cls", "n", false, "disable the clear screen between commands")
-	viper.BindPFlag("no-cls", rootCmd.Flags().Lookup("no-cls"))
+	rootCmd.PersistentFlags().BoolP("no-cls", "n", false, "disable the clear screen between commands")
+	viper.BindPFlag("no-cls", rootCmd.PersistentFlags().Lookup("no-cls"))
+
+	// Add flags for the option to type the clear command before clearing
+	rootCmd.PersistentFlags().Bool("type-clear", false, "type the clear command of the shell before clearing the screen")
+	viper.BindPFlag("type-clear", rootCmd.PersistentFlags().Lookup("type-clear"))
+
+	// Add flags for the option to end the session by typing exit
+	rootCmd.PersistentFlags().Bool("type-exit", false, "type exit after the last command and print the closing line of the shell")
+	viper.BindPFlag("type-exit", rootCmd.PersistentFlags().Lookup("type-exit"))
+	rootCmd.PersistentFlags().String("exit-message", "", "closing line printed after exit (default \"logout\" for bash)")
+	viper.BindPFlag("exit-message", rootCmd.PersistentFlags().Lookup("exit-message"))
+	rootCmd.PersistentFlags().Bool("exit-clear", false, "clear the screen after exit, as if the session had been left")
+	viper.BindPFlag("exit-clear", rootCmd.PersistentFlags().Lookup("exit-clear"))
+
+	// Add flags for the option to clear the scrollback with the screen
+	rootCmd.PersistentFlags().Bool("clear-scrollback", false, "clear the scrollback buffer as well when clearing the screen")
+	viper.BindPFlag("clear-scrollback", rootCmd.PersistentFlags().Lookup("clear-scrollback"))
+
+	// Add flags for the option to clear the screen during long outputs
+	rootCmd.PersistentFlags().Int("clear-after", 0, "clear the screen whenever the output of a command exceeds this many lines")
+	viper.BindPFlag("clear-after", rootCmd.PersistentFlags().Lookup("clear-after"))
+
+	// Add flags for the options to play only some of the steps
+	rootCmd.PersistentFlags().String("start-at", "", "start the playback at this step, by number or name")
+	viper.BindPFlag("start-at", rootCmd.PersistentFlags().Lookup("start-at"))
+	rootCmd.PersistentFlags().String("stop-after", "", "stop the playback after this step, by number or name")
+	viper.BindPFlag("stop-after", rootCmd.PersistentFlags().Lookup("stop-after"))
+	rootCmd.PersistentFlags().String("range", "", "play only the steps in this range (e.g. 4..9 or deploy..cleanup)")
+	viper.BindPFlag("range", rootCmd.PersistentFlags().Lookup("range"))
+
+	// Add flags for the options to filter the steps by their tags
+	rootCmd.PersistentFlags().StringSlice("tags", nil, "play only the steps with one of these tags")
+	viper.BindPFlag("tags", rootCmd.PersistentFlags().Lookup("tags"))
+	rootCmd.PersistentFlags().StringSlice("skip-tags", nil, "skip the steps with one of these tags")
+	viper.BindPFlag("skip-tags", rootCmd.PersistentFlags().Lookup("skip-tags"))
+
+	// Add flags for the option to pin the checksum of fetched scripts
+	rootCmd.PersistentFlags().String("sha256", "", "SHA-256 checksum the script fetched over HTTPS must have")
+	viper.BindPFlag("sha256", rootCmd.PersistentFlags().Lookup("sha256"))
+
+	// Add flags for the options to verify the signatures of the scripts
+	rootCmd.PersistentFlags().Bool("require-signed", false, "refuse to play scripts not signed with a trusted key")
+	viper.BindPFlag("require-signed", rootCmd.PersistentFlags().Lookup("require-signed"))
+	rootCmd.PersistentFlags().StringArray("trusted-key", nil, "public key (or file) of minisign trusted to sign scripts")
+	viper.BindPFlag("trusted-keys", rootCmd.PersistentFlags().Lookup("trusted-key"))
+
+	// Add flags for the option to play on the alternate screen buffer
+	rootCmd.PersistentFlags().Bool("alt-screen", false, "play on the alternate screen and restore the terminal afterwards")
+	viper.BindPFlag("alt-screen", rootCmd.PersistentFlags().Lookup("alt-screen"))
+
+	// Add a flag for the output easy to follow with a screen reader
+	rootCmd.PersistentFlags().Bool("a11y", false, "accessible mode: no clearing or blinking, highlights marked with brackets, and a plain status line before each command")
+	viper.BindPFlag("a11y", rootCmd.PersistentFlags().Lookup("a11y"))
+
+	// Add flags for the size of the virtual screen
+	rootCmd.PersistentFlags().Int("cols", 0, "play on a virtual screen with this number of columns (default 80 if --rows is set)")
+	viper.BindPFlag("cols", rootCmd.PersistentFlags().Lookup("cols"))
+	rootCmd.PersistentFlags().Int("rows", 0, "play on a virtual screen with this number of rows (default 24 if --cols is set)")
+	viper.BindPFlag("rows", rootCmd.PersistentFlags().Lookup("rows"))
+	rootCmd.PersistentFlags().Bool("headless", false, "play on a virtual screen in memory without showing it, to export it with --frames or --screenshots")
+	viper.BindPFlag("headless", rootCmd.PersistentFlags().Lookup("headless"))
+
+	// Add flags for the color profile of the terminal
+	rootCmd.PersistentFlags().String("colors", "auto", "colors supported by the terminal: auto, 16, 256 or truecolor")
+	viper.BindPFlag("colors", rootCmd.PersistentFlags().Lookup("colors"))
+
+	// Add a flag for the colorblind-safe palettes
+	rootCmd.PersistentFlags().String("palette", "default", "colors of the prompt, the highlights and the output: default, deuteranopia or protanopia")
+	viper.BindPFlag("palette", rootCmd.PersistentFlags().Lookup("palette"))
+
+	// Add flags for the hesitation before keys typed with shift
+	rootCmd.PersistentFlags().Float64("hesitation", 0, "lengthen the delay before capitals and symbols by this fraction (e.g. 0.8)")
+	viper.BindPFlag("hesitation", rootCmd.PersistentFlags().Lookup("hesitation"))
+
+	// Add flags for the pauses before the tokens of the commands
+	rootCmd.PersistentFlags().Int("pause-pipe", 0, "pause before typing a pipe in milliseconds")
+	viper.BindPFlag("pause-pipe", rootCmd.PersistentFlags().Lookup("pause-pipe"))
+	rootCmd.PersistentFlags().Int("pause-flag", 0, "pause before typing a flag in milliseconds")
+	viper.BindPFlag("pause-flag", rootCmd.PersistentFlags().Lookup("pause-flag"))
+	rootCmd.PersistentFlags().Int("pause-path", 0, "pause before typing a path separator in milliseconds")
+	viper.BindPFlag("pause-path", rootCmd.PersistentFlags().Lookup("pause-path"))
+
+	// Add a flag to play the narration of the wait-audio steps
+	rootCmd.PersistentFlags().Bool("play-audio", false, "play the clips of the wait-audio steps instead of waiting silently")
+	viper.BindPFlag("play-audio", rootCmd.PersistentFlags().Lookup("play-audio"))
+
+	// Add flags for speaking the captions while the commands are typed
+	rootCmd.PersistentFlags().Bool("speak", false, "speak the captions and the comments while the commands are typed")
+	viper.BindPFlag("speak", rootCmd.PersistentFlags().Lookup("speak"))
+	rootCmd.PersistentFlags().String("voice", "", "voice of the speech synthesizer used with --speak")
+	viper.BindPFlag("voice", rootCmd.PersistentFlags().Lookup("voice"))
+	rootCmd.PersistentFlags().String("speech-command", "", "speak with this command reading the text on stdin instead of the synthesizer of the system")
+	viper.BindPFlag("speech-command", rootCmd.PersistentFlags().Lookup("speech-command"))
+
+	// Add flags for the ramp of the typing speed
+	rootCmd.PersistentFlags().Float64("ramp", 0, "type the first command this many times slower, speeding up to the normal speed")
+	viper.BindPFlag("ramp", rootCmd.PersistentFlags().Lookup("ramp"))
+	rootCmd.PersistentFlags().Int("ramp-commands", 5, "number of commands until the normal typing speed is reached")
+	viper.BindPFlag("ramp-commands", rootCmd.PersistentFlags().Lookup("ramp-commands"))
+	rootCmd.PersistentFlags().String("ramp-curve", "linear", "curve of the typing speed ramp: linear, ease-in or ease-out")
+	viper.BindPFlag("ramp-curve", rootCmd.PersistentFlags().Lookup("ramp-curve"))
+
+	// Add flags for the input method simulated for CJK text
+	rootCmd.PersistentFlags().String("ime", "", "simulate the input method composition of CJK text: ja, zh or ko")
+	viper.BindPFlag("ime", rootCmd.PersistentFlags().Lookup("ime"))
+
+	// Add flags for the option to only type dangerous commands, instead
+	// of asking for a confirmation (the patterns are set in the config)
+	rootCmd.PersistentFlags().Bool("type-only-dangerous", false, "type dangerous commands without executing them instead of asking")
+	viper.BindPFlag("type-only-dangerous", rootCmd.PersistentFlags().Lookup("type-only-dangerous"))
+	viper.SetDefault("dangerous-patterns", cli.DangerousPatterns)
+
+	// Add flags for the sandbox mode, where nothing is executed
+	rootCmd.PersistentFlags().Bool("sandbox", false, "simulate the output of the commands instead of executing them")
+	viper.BindPFlag("sandbox", rootCmd.PersistentFlags().Lookup("sandbox"))
+	rootCmd.PersistentFlags().Bool("cached", false, "replay the output of the commands cached by the previous runs, and cache the output of the others")
+	viper.BindPFlag("cached", rootCmd.PersistentFlags().Lookup("cached"))
+	rootCmd.PersistentFlags().Bool("refresh-cache", false, "run the commands and cache their output again, like --cached without the replay")
+	viper.BindPFlag("refresh-cache", rootCmd.PersistentFlags().Lookup("refresh-cache"))
+
+	// Add flags for the JavaScript hooks of the playback
+	rootCmd.PersistentFlags().String("hooks", "", "run the JavaScript hooks (onStepStart, onOutputLine, onStepEnd) in this file")
+	viper.BindPFlag("hooks", rootCmd.PersistentFlags().Lookup("hooks"))
+
+	// Add flags for the rules highlighting the output
+	rootCmd.PersistentFlags().StringArray("highlight", nil, "highlight the matches of a pattern in the output (e.g. '\"error|failed\" style=red-bold')")
+	viper.BindPFlag("highlights", rootCmd.PersistentFlags().Lookup("highlight"))
+
+	// Add flags for the WebAssembly filters of the output
+	rootCmd.PersistentFlags().StringSlice("filter", nil, "stream the output of the commands through this WebAssembly (WASI) module")
+	viper.BindPFlag("filters", rootCmd.PersistentFlags().Lookup("filter"))
+
+	// Add flags for scaling the delays to a target duration
+	rootCmd.PersistentFlags().Duration("duration", 0, "scale the delays so that the playback lasts this long (e.g. 90s)")
+	viper.BindPFlag("duration", rootCmd.PersistentFlags().Lookup("duration"))
+	rootCmd.PersistentFlags().Bool("scale-typing", false, "scale the typing speed as well with --duration")
+	viper.BindPFlag("scale-typing", rootCmd.PersistentFlags().Lookup("scale-typing"))
+
+	// Add flags for the timing report printed after the playback
+	rootCmd.PersistentFlags().Bool("timing", false, "print the time spent typing, executing and pausing to stderr after the playback")
+	viper.BindPFlag("timing", rootCmd.PersistentFlags().Lookup("timing"))
+	rootCmd.PersistentFlags().String("report", "", "write the timing report as JSON to this file after the playback")
+	viper.BindPFlag("report", rootCmd.PersistentFlags().Lookup("report"))
+
+	// Add flags for the structured logs of the playback
+	rootCmd.PersistentFlags().String("log-level", "warn", "level of the logs: debug, info, warn or error")
+	viper.BindPFlag("log-level", rootCmd.PersistentFlags().Lookup("log-level"))
+	rootCmd.PersistentFlags().BoolP("verbose", "V", false, "log debug messages (same as --log-level debug)")
+	viper.BindPFlag("verbose", rootCmd.PersistentFlags().Lookup("verbose"))
+	rootCmd.PersistentFlags().String("log-file", "", "append the logs to this file instead of stderr")
+	viper.BindPFlag("log-file", rootCmd.PersistentFlags().Lookup("log-file"))
+
+	// Add flags for the language of the messages
+	rootCmd.PersistentFlags().String("lang", "", "language of the messages (e.g. de, fr-CA), from LANG if empty")
+	viper.BindPFlag("lang", rootCmd.PersistentFlags().Lookup("lang"))
+	rootCmd.PersistentFlags().String("messages", "", "YAML file translating the messages into the language of --lang")
+	viper.BindPFlag("messages", rootCmd.PersistentFlags().Lookup("messages"))
+
+	// Add flags for rendering the playback into PNG frames
+	rootCmd.PersistentFlags().String("frames", "", "render the playback into numbered PNG frames in this directory")
+	viper.BindPFlag("frames", rootCmd.PersistentFlags().Lookup("frames"))
+	rootCmd.PersistentFlags().Int("frames-fps", 25, "number of frames per second rendered with --frames")
+	viper.BindPFlag("frames-fps", rootCmd.PersistentFlags().Lookup("frames-fps"))
+	rootCmd.PersistentFlags().Float64("frames-font-size", 16, "font size in pixels of the frames rendered with --frames")
+	viper.BindPFlag("frames-font-size", rootCmd.PersistentFlags().Lookup("frames-font-size"))
+	rootCmd.PersistentFlags().Bool("frames-fast", false, "render the frames with --frames without waiting for the delays of the playback")
+	viper.BindPFlag("frames-fast", rootCmd.PersistentFlags().Lookup("frames-fast"))
+
+	// Add flags for the screenshots taken after each command
+	rootCmd.PersistentFlags().String("screenshots", "", "write a screenshot of the screen after each command into this directory")
+	viper.BindPFlag("screenshots", rootCmd.PersistentFlags().Lookup("screenshots"))
+	rootCmd.PersistentFlags().String("screenshot-format", "png", "format of the screenshots: png or text")
+	viper.BindPFlag("screenshot-format", rootCmd.PersistentFlags().Lookup("screenshot-format"))
+
+	// Add flags for playing in a window of another terminal application
+	rootCmd.PersistentFlags().String("target", "", "play in a new window of a terminal application: iterm, terminal, vscode or wt")
+	viper.BindPFlag("target", rootCmd.PersistentFlags().Lookup("target"))
+	rootCmd.PersistentFlags().String("target-profile", "", "profile of the terminal application the window is opened with")
+	viper.BindPFlag("target-profile", rootCmd.PersistentFlags().Lookup("target-profile"))
+	rootCmd.PersistentFlags().Bool("target-attach", false, "play in the front window of the terminal application instead of a new one")
+	viper.BindPFlag("target-attach", rootCmd.PersistentFlags().Lookup("target-attach"))
+
+	// Add a flag for the audit log of the executed commands
+	rootCmd.PersistentFlags().String("audit-log", "", "append a record of every executed command to this file, or to the system log with \"syslog\"")
+	viper.BindPFlag("audit-log", rootCmd.PersistentFlags().Lookup("audit-log"))
+
+	// Add a flag for the webhooks fired when the playback starts and ends
+	rootCmd.PersistentFlags().StringArray("webhook", nil, "POST a JSON description of the run to this URL when the playback starts, finishes or fails")
+	viper.BindPFlag("webhook", rootCmd.PersistentFlags().Lookup("webhook"))
+
+	// Add a flag for copying the commands to the clipboard of the viewers
+	rootCmd.PersistentFlags().Bool("copy-commands", false, "place each executed command on the clipboard of the terminal with OSC 52")
+	viper.BindPFlag("copy-commands", rootCmd.PersistentFlags().Lookup("copy-commands"))
+
+	// Add a flag for the protocol the images are drawn with
+	rootCmd.PersistentFlags().String("images", "auto", "draw the images of the image steps with \"iterm\", \"sixel\" or \"none\", guessed from the terminal with \"auto\"")
+	viper.BindPFlag("images", rootCmd.PersistentFlags().Lookup("images"))
+
+	// Add a flag for how long the QR codes are shown
+	rootCmd.PersistentFlags().Int("qrcode-duration", 5000, "show the QR codes of the qrcode steps for this many milliseconds")
+	viper.BindPFlag("qrcode-duration", rootCmd.PersistentFlags().Lookup("qrcode-duration"))
+
+	// Add a flag for the output of the presenter notes
+	rootCmd.PersistentFlags().String("notes", "", "write the presenter notes of the steps to stderr, or to this file, named pipe or terminal")
+	viper.BindPFlag("notes", rootCmd.PersistentFlags().Lookup("notes"))
+
+	// Add flags for the cues of the chapters
+	rootCmd.PersistentFlags().Bool("chapter-bell", false, "ring the bell of the terminal as each chapter of the scenario begins")
+	viper.BindPFlag("chapter-bell", rootCmd.PersistentFlags().Lookup("chapter-bell"))
+	rootCmd.PersistentFlags().String("chapter-command", "", "run this command as each chapter begins, with $AUTOTYPER_CHAPTER set to its title")
+	viper.BindPFlag("chapter-command", rootCmd.PersistentFlags().Lookup("chapter-command"))
+
+	// Add flags for the session timer
+	rootCmd.PersistentFlags().String("timer", "", "show the time elapsed, and left of --slot, in the window title (title) or on the last row (status)")
+	viper.BindPFlag("timer", rootCmd.PersistentFlags().Lookup("timer"))
+	rootCmd.PersistentFlags().Duration("slot", 0, "length of the slot of the talk for the time left shown by --timer (e.g. 20m)")
+	viper.BindPFlag("slot", rootCmd.PersistentFlags().Lookup("slot"))
+
+	// Add a flag for the terminal of the presenter
+	rootCmd.PersistentFlags().String("presenter", "", "draw the steps, the notes and the elapsed time on this other terminal (e.g. /dev/pts/3)")
+	viper.BindPFlag("presenter", rootCmd.PersistentFlags().Lookup("presenter"))
+
+	// Add flags for the triggers of buttons and global hotkeys
+	rootCmd.PersistentFlags().String("trigger-listen", "", "address to listen on for HTTP triggers of the next, pause and restart actions")
+	viper.BindPFlag("trigger-listen", rootCmd.PersistentFlags().Lookup("trigger-listen"))
+	rootCmd.PersistentFlags().String("trigger-udp", "", "address to listen on for UDP triggers of the next, pause and restart actions")
+	viper.BindPFlag("trigger-udp", rootCmd.PersistentFlags().Lookup("trigger-udp"))
+	rootCmd.PersistentFlags().String("trigger-token", "", "bearer token required by the triggers, also before the action in UDP datagrams (default $AUTOTYPER_TOKEN)")
+	viper.BindPFlag("trigger-token", rootCmd.PersistentFlags().Lookup("trigger-token"))
+	rootCmd.PersistentFlags().Bool("trigger-insecure", false, "allow listening for triggers on a non-loopback address without credentials")
+	viper.BindPFlag("trigger-insecure", rootCmd.PersistentFlags().Lookup("trigger-insecure"))
+	rootCmd.PersistentFlags().StringArray("hotkey", nil, "bind a global hotkey to an action, as action=hotkey (e.g. next=ctrl+alt+n, Windows only)")
+	viper.BindPFlag("hotkeys", rootCmd.PersistentFlags().Lookup("hotkey"))
 }
 
 // initConfig reads in config file and ENV variables if set.
@@ -251,7 +1094,24 @@ func initConfig() {
 	viper.AutomaticEnv() // read in environment variables that match
 
 	// If a config file is found, read it in.
-	if err := viper.ReadInConfig(); err == nil {
-		fmt.Fprintln(os.Stderr, "Using config file:", viper.ConfigFileUsed())
+	err := viper.ReadInConfig()
+
+	// Select the language of the messages, which can be set in the
+	// config file, before printing any
+	cobra.CheckErr(setupLocale())
+	if err == nil {
+		fmt.Fprintln(os.Stderr, locale.T("Using config file: %s", viper.ConfigFileUsed()))
+	}
+}
+
+// setupLocale selects the language of the messages from the flags,
+// or from the environment, and loads the translations file if any
+func setupLocale() error {
+	if err := locale.Set(viper.GetString("lang")); err != nil {
+		return err
+	}
+	if name := viper.GetString("messages"); name != "" {
+		return locale.Load(name)
 	}
+	return nil
 }
@@ -0,0 +1,208 @@
+/*
+Copyright © 2023 Mikael Schultz <bitcanon@proton.me>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/bitcanon/autotyper/locale"
+	"github.com/bitcanon/autotyper/remote"
+	"github.com/bitcanon/autotyper/terminal"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+)
+
+// serveCmd represents the serve command
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Run an agent that plays scripts sent by a controller",
+	Long: `Run an agent that plays scripts sent by a controller
+
+The agent runs on the demo machine. It renders the prompt, types and executes
+the commands of the scripts it receives from a controller (see the remote
+command), so a presenter can drive the demo from another machine.`,
+	Example: `  autotyper serve
+  autotyper serve --listen 0.0.0.0:7070 --shell bash --token s3cret
+  autotyper serve --listen 0.0.0.0:7070 --basic-auth presenter:s3cret --tls-cert agent.crt --tls-key agent.key
+  autotyper serve --grpc-listen 127.0.0.1:7071`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// The scripts sent by the controllers are not signed
+		if trust.Require {
+			return fmt.Errorf("the agent cannot verify the scripts it receives, so it does not support --require-signed")
+		}
+		auth, err := controlAuth("serve")
+		if err != nil {
+			return err
+		}
+		certFile, keyFile := viper.GetString("serve-tls-cert"), viper.GetString("serve-tls-key")
+		if (certFile == "") != (keyFile == "") {
+			return fmt.Errorf("--tls-cert and --tls-key must be given together")
+		}
+
+		// The agent executes the commands it receives, so it must
+		// not be reachable from the network without credentials
+		addrs := []string{viper.GetString("serve-listen"), viper.GetString("serve-grpc-listen")}
+		for _, addr := range addrs {
+			if addr != "" && !auth.Enabled() && !isLoopback(addr) && !viper.GetBool("serve-insecure") {
+				return fmt.Errorf("refusing to listen on %s without --token or --basic-auth (use --insecure to allow it)", addr)
+			}
+		}
+
+		opts, err := playerOptions()
+		if err != nil {
+			return err
+		}
+		out, width := screenOutput(os.Stdout, func() int { return terminal.Width(os.Stdout) })
+		opts.Width = width
+		agent := remote.NewAgent(out, opts)
+		agent.Queue = viper.GetInt("serve-queue")
+		errs := make(chan error, 2)
+
+		// Limit the requests of each client, before checking their
+		// credentials so that they cannot be guessed quickly either
+		var limiter *remote.Limiter
+		if rate := viper.GetFloat64("serve-rate-limit"); rate > 0 {
+			limiter = remote.NewLimiter(rate, viper.GetInt("serve-rate-burst"))
+		}
+
+		// Serve the gRPC API if an address has been given
+		if addr := viper.GetString("serve-grpc-listen"); addr != "" {
+			lis, err := net.Listen("tcp", addr)
+			if err != nil {
+				return err
+			}
+			var grpcOpts []grpc.ServerOption
+			if limiter != nil {
+				grpcOpts = append(grpcOpts, limiter.ServerOptions()...)
+			}
+			grpcOpts = append(grpcOpts, auth.ServerOptions()...)
+			if certFile != "" {
+				creds, err := credentials.NewServerTLSFromFile(certFile, keyFile)
+				if err != nil {
+					return err
+				}
+				grpcOpts = append(grpcOpts, grpc.Creds(creds))
+			}
+			fmt.Fprintln(os.Stderr, locale.T("Agent listening for gRPC on %s", addr))
+			go func() { errs <- remote.NewGRPCServer(agent, grpcOpts...).Serve(lis) }()
+		}
+
+		// Serve the HTTP API
+		addr := viper.GetString("serve-listen")
+		handler := auth.Handler(agent.Handler())
+		if limiter != nil {
+			handler = limiter.Handler(handler)
+		}
+		srv := &http.Server{
+			Addr:              addr,
+			Handler:           handler,
+			ReadHeaderTimeout: 5 * time.Second,
+			ReadTimeout:       30 * time.Second,
+			WriteTimeout:      30 * time.Second,
+			IdleTimeout:       time.Minute,
+		}
+		fmt.Fprintln(os.Stderr, locale.T("Agent listening on %s", addr))
+		go func() {
+			if certFile != "" {
+				errs <- srv.ListenAndServeTLS(certFile, keyFile)
+			} else {
+				errs <- srv.ListenAndServe()
+			}
+		}()
+
+		return <-errs
+	},
+}
+
+// controlAuth returns the credentials of the control API configured
+// for the command, with the token taken from $AUTOTYPER_TOKEN if unset
+func controlAuth(command string) (remote.Auth, error) {
+	auth := remote.Auth{Token: viper.GetString(command + "-token")}
+	if auth.Token == "" {
+		auth.Token = os.Getenv("AUTOTYPER_TOKEN")
+	}
+	if basic := viper.GetString(command + "-basic-auth"); basic != "" {
+		username, password, err := remote.ParseBasicAuth(basic)
+		if err != nil {
+			return remote.Auth{}, err
+		}
+		auth.Username, auth.Password = username, password
+	}
+	return auth, nil
+}
+
+// isLoopback reports whether a listen address only accepts
+// connections from the local machine
+func isLoopback(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return false
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+func init() {
+	rootCmd.AddCommand(serveCmd)
+
+	// Add flags for the listen address
+	serveCmd.Flags().StringP("listen", "l", "127.0.0.1:7070", "address to listen on for controllers")
+	viper.BindPFlag("serve-listen", serveCmd.Flags().Lookup("listen"))
+
+	// Add flags for the gRPC listen address
+	serveCmd.Flags().String("grpc-listen", "", "address to listen on for gRPC clients (disabled if empty)")
+	viper.BindPFlag("serve-grpc-listen", serveCmd.Flags().Lookup("grpc-listen"))
+
+	// Add flags for the credentials required from the controllers
+	serveCmd.Flags().String("token", "", "bearer token required from the controllers (default $AUTOTYPER_TOKEN)")
+	viper.BindPFlag("serve-token", serveCmd.Flags().Lookup("token"))
+	serveCmd.Flags().String("basic-auth", "", "user:password required from the controllers with basic auth")
+	viper.BindPFlag("serve-basic-auth", serveCmd.Flags().Lookup("basic-auth"))
+	serveCmd.Flags().Bool("insecure", false, "allow listening on a non-loopback address without credentials")
+	viper.BindPFlag("serve-insecure", serveCmd.Flags().Lookup("insecure"))
+
+	// Add flags for the rate limit of each client
+	serveCmd.Flags().Float64("rate-limit", 10, "requests per second allowed to each client (0 to disable)")
+	viper.BindPFlag("serve-rate-limit", serveCmd.Flags().Lookup("rate-limit"))
+	serveCmd.Flags().Int("rate-burst", 20, "requests allowed to each client in a burst")
+	viper.BindPFlag("serve-rate-burst", serveCmd.Flags().Lookup("rate-burst"))
+
+	// Add flags for the queue of scripts
+	serveCmd.Flags().Int("queue", 0, "scripts waiting for the current playback to end (0 to replace the playback)")
+	viper.BindPFlag("serve-queue", serveCmd.Flags().Lookup("queue"))
+
+	// Add flags for TLS
+	serveCmd.Flags().String("tls-cert", "", "certificate file to serve the control API over TLS")
+	viper.BindPFlag("serve-tls-cert", serveCmd.Flags().Lookup("tls-cert"))
+	serveCmd.Flags().String("tls-key", "", "private key file of the TLS certificate")
+	viper.BindPFlag("serve-tls-key", serveCmd.Flags().Lookup("tls-key"))
+}
@@ -0,0 +1,169 @@
+/*
+Copyright © 2023 Mikael Schultz <bitcanon@proton.me>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"aead.dev/minisign"
+	"github.com/bitcanon/autotyper/locale"
+	"github.com/bitcanon/autotyper/sign"
+	"github.com/bitcanon/autotyper/terminal"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// signCmd represents the sign command
+var signCmd = &cobra.Command{
+	Use:   "sign [files]",
+	Short: "Sign scripts so that they can be played with --require-signed",
+	Long: `Sign scripts so that they can be played with --require-signed
+
+The signature of each file is written next to it, with the .minisig extension,
+in the format of minisign: scripts can be signed with minisign as well, and
+the signatures verified with "minisign -V". The secret key is read from
+~/.minisign/minisign.key by default, and a new key pair is created with
+--generate. The public key is then added to the trusted-keys of the config
+file of the presenters.`,
+	Example: `  autotyper sign --generate
+  autotyper sign demo.yaml common/setup.txt
+  autotyper -i demo.yaml --require-signed --trusted-key minisign.pub`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		keyFile, err := secretKeyFile()
+		if err != nil {
+			return err
+		}
+		if viper.GetBool("sign-generate") {
+			return generateKey(keyFile)
+		}
+		if len(args) == 0 {
+			cmd.Help()
+			return nil
+		}
+
+		password, err := keyPassword(false)
+		if err != nil {
+			return err
+		}
+		key, err := minisign.PrivateKeyFromFile(password, keyFile)
+		if err != nil {
+			return fmt.Errorf("%s: %w", keyFile, err)
+		}
+		for _, filename := range args {
+			if err := sign.SignFile(filename, key); err != nil {
+				return err
+			}
+			fmt.Fprintln(os.Stderr, locale.T("Signed %s", filename))
+		}
+		return nil
+	},
+}
+
+// secretKeyFile returns the file of the secret key,
+// by default the file used by minisign
+func secretKeyFile() (string, error) {
+	if filename := viper.GetString("sign-key"); filename != "" {
+		return filename, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".minisign", "minisign.key"), nil
+}
+
+// generateKey creates a key pair, with the secret key encrypted with
+// a password in the file and the public key in the same directory
+func generateKey(keyFile string) error {
+	if _, err := os.Stat(keyFile); !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("not overwriting the secret key %s", keyFile)
+	}
+	password, err := keyPassword(true)
+	if err != nil {
+		return err
+	}
+
+	public, private, err := minisign.GenerateKey(rand.Reader)
+	if err != nil {
+		return err
+	}
+	encrypted, err := minisign.EncryptKey(password, private)
+	if err != nil {
+		return err
+	}
+	publicText, err := public.MarshalText()
+	if err != nil {
+		return err
+	}
+
+	publicFile := strings.TrimSuffix(keyFile, filepath.Ext(keyFile)) + ".pub"
+	if err := os.MkdirAll(filepath.Dir(keyFile), 0700); err != nil {
+		return err
+	}
+	if err := os.WriteFile(keyFile, append(encrypted, '\n'), 0600); err != nil {
+		return err
+	}
+	if err := os.WriteFile(publicFile, append(publicText, '\n'), 0644); err != nil {
+		return err
+	}
+	fmt.Fprintln(os.Stderr, locale.T("Wrote the secret key to %s and the public key to %s", keyFile, publicFile))
+	fmt.Fprintln(os.Stderr, locale.T("Trust the key with --trusted-key %s", public))
+	return nil
+}
+
+// keyPassword returns the password of the secret key, from the
+// environment or typed by the presenter, twice if confirm is set
+func keyPassword(confirm bool) (string, error) {
+	if password, ok := os.LookupEnv("AUTOTYPER_KEY_PASSWORD"); ok {
+		return password, nil
+	}
+	password, err := terminal.ReadPassword("Password of the secret key: ", os.Stdin, os.Stderr)
+	if err != nil {
+		return "", fmt.Errorf("cannot ask for the password of the secret key (set $AUTOTYPER_KEY_PASSWORD instead): %w", err)
+	}
+	if confirm {
+		again, err := terminal.ReadPassword("Confirm the password: ", os.Stdin, os.Stderr)
+		if err != nil {
+			return "", err
+		}
+		if again != password {
+			return "", fmt.Errorf("the passwords do not match")
+		}
+	}
+	return password, nil
+}
+
+func init() {
+	rootCmd.AddCommand(signCmd)
+
+	// Add flags for the secret key
+	signCmd.Flags().StringP("key", "k", "", "secret key file (default is $HOME/.minisign/minisign.key)")
+	viper.BindPFlag("sign-key", signCmd.Flags().Lookup("key"))
+	signCmd.Flags().Bool("generate", false, "create a new key pair instead of signing")
+	viper.BindPFlag("sign-generate", signCmd.Flags().Lookup("generate"))
+}
@@ -0,0 +1,80 @@
+/*
+Copyright © 2023 Mikael Schultz <bitcanon@proton.me>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/bitcanon/autotyper/cli"
+	"github.com/bitcanon/autotyper/script"
+	"github.com/bitcanon/autotyper/sign"
+	"github.com/spf13/viper"
+)
+
+// playStream plays the plain text script of the input file, or of
+// the standard input, while it is read (see --stream)
+func playStream() error {
+	// Streamed scripts are played before they are read to the
+	// end, so their signature cannot be verified
+	if trust.Require {
+		return fmt.Errorf("streamed script: %w", sign.ErrUnsigned)
+	}
+
+	if size := viper.GetInt("max-line-size"); size > 0 {
+		cli.MaxLineLength = size << 20
+	}
+
+	var in io.Reader
+	source := viper.GetString("input-file")
+	if source != "" {
+		filename, err := inputFile(source)
+		if err != nil {
+			return err
+		}
+		switch strings.ToLower(filepath.Ext(filename)) {
+		case ".json", ".toml", ".yaml", ".yml":
+			return fmt.Errorf("%s: only plain text scripts can be streamed", source)
+		}
+		f, err := os.Open(filename)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		in = f
+	} else if stat, _ := os.Stdin.Stat(); (stat.Mode() & os.ModeCharDevice) == 0 {
+		in = os.Stdin
+	} else {
+		return fmt.Errorf("--stream needs an input file or a script piped to the standard input")
+	}
+
+	opts, err := playerOptions()
+	if err != nil {
+		return err
+	}
+	opts.Source = source
+	opts.Stream = script.NewStream(in)
+	return playScenario(&script.Scenario{}, opts)
+}
@@ -0,0 +1,60 @@
+/*
+Copyright © 2023 Mikael Schultz <bitcanon@proton.me>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"context"
+	"os"
+
+	"github.com/bitcanon/autotyper/target"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// sinkCmd represents the sink command, run in the window of --target
+var sinkCmd = &cobra.Command{
+	Use:    "sink <address> <token>",
+	Short:  "Print the demo played in this window by another session",
+	Args:   cobra.ExactArgs(2),
+	Hidden: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return target.Sink(args[0], args[1], os.Stdout)
+	},
+}
+
+// openTarget opens the window of the terminal application the demo
+// is played in, which runs the sink command of this executable
+func openTarget(ctx context.Context, name string) (*target.Window, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return nil, err
+	}
+	return target.Open(ctx, name, target.Options{
+		Command: []string{exe, "sink"},
+		Profile: viper.GetString("target-profile"),
+		Attach:  viper.GetBool("target-attach"),
+	})
+}
+
+func init() {
+	rootCmd.AddCommand(sinkCmd)
+}
@@ -0,0 +1,57 @@
+/*
+Copyright © 2023 Mikael Schultz <bitcanon@proton.me>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/bitcanon/autotyper/player"
+	"github.com/bitcanon/autotyper/terminal"
+	"github.com/bitcanon/autotyper/tui"
+	"github.com/spf13/viper"
+)
+
+// startTimer draws the session timer of the playback on out, in the
+// title of the terminal or on a status row (see --timer). Call the
+// returned function to remove the timer
+func startTimer(ctx context.Context, name string, out io.Writer, p *player.Player) (func(), error) {
+	mode, ok := tui.ParseTimerMode(name)
+	if !ok {
+		return nil, fmt.Errorf("invalid timer %q, expected title or status", name)
+	}
+	timer := tui.NewTimer(out, mode, viper.GetDuration("slot"), screenSize)
+	p.OnEvent(timer.Handle)
+	go timer.Run(ctx)
+	return timer.Close, nil
+}
+
+// screenSize returns the size of the screen the demo is played on:
+// the virtual screen if --cols or --rows is set, or else the terminal
+func screenSize() (cols, rows int) {
+	if viper.GetInt("cols") > 0 || viper.GetInt("rows") > 0 {
+		return recordedSize()
+	}
+	return terminal.Size(os.Stdout)
+}
@@ -0,0 +1,76 @@
+/*
+Copyright © 2023 Mikael Schultz <bitcanon@proton.me>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"io"
+	"log/slog"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/bitcanon/autotyper/tui"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// tuiCmd represents the tui command
+var tuiCmd = &cobra.Command{
+	Use:   "tui [directory]",
+	Short: "Present the scripts in a directory from a terminal UI",
+	Long: `Present the scripts in a directory from a terminal UI
+
+The console lists the scripts in the directory (the current directory by
+default). A selected script is shown with its steps, the screen of the
+playback and a status bar, and is played with the keyboard: space plays and
+pauses, n plays the next step, the arrow keys seek, and esc goes back to the
+list of scripts.`,
+	Example: `  autotyper tui
+  autotyper tui demos --shell bash`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dir := "."
+		if len(args) > 0 {
+			dir = args[0]
+		}
+
+		opts, err := playerOptions()
+		if err != nil {
+			return err
+		}
+		m, err := tui.New(dir, opts)
+		if err != nil {
+			return err
+		}
+
+		// Logs written to stderr would be drawn over the console
+		if viper.GetString("log-file") == "" {
+			slog.SetDefault(slog.New(slog.NewTextHandler(io.Discard, nil)))
+		}
+
+		_, err = tea.NewProgram(m, tea.WithAltScreen()).Run()
+		return err
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(tuiCmd)
+}
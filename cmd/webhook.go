@@ -0,0 +1,64 @@
+/*
+Copyright © 2023 Mikael Schultz <bitcanon@proton.me>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/bitcanon/autotyper/webhook"
+	"github.com/spf13/viper"
+)
+
+// newWebhooks returns the notifier of the webhooks of the flags and
+// of the config file, or nil if there are none
+func newWebhooks() (*webhook.Notifier, error) {
+	var configured []struct {
+		URL     string
+		Events  []string
+		Payload string
+		Headers map[string]string
+	}
+	if err := viper.UnmarshalKey("webhooks", &configured); err != nil {
+		return nil, fmt.Errorf("webhooks: %w", err)
+	}
+
+	n := &webhook.Notifier{UserAgent: "autotyper/" + version}
+	for _, url := range viper.GetStringSlice("webhook") {
+		h, err := webhook.New(url, nil, "")
+		if err != nil {
+			return nil, err
+		}
+		n.Hooks = append(n.Hooks, h)
+	}
+	for _, c := range configured {
+		h, err := webhook.New(c.URL, c.Events, c.Payload)
+		if err != nil {
+			return nil, fmt.Errorf("webhooks: %w", err)
+		}
+		h.Headers = c.Headers
+		n.Hooks = append(n.Hooks, h)
+	}
+	if len(n.Hooks) == 0 {
+		return nil, nil
+	}
+	return n, nil
+}
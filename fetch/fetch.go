@@ -0,0 +1,256 @@
+/*
+Copyright © 2023 Mikael Schultz <bitcanon@proton.me>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package fetch
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// The extension of the signatures fetched next to the scripts
+const signatureExtension = ".minisig"
+
+// maxSize is the largest script fetched, in bytes
+const maxSize = 32 << 20
+
+// timeout is the longest a download may take
+const timeout = 30 * time.Second
+
+// maxRedirects is the number of redirects followed, as by http.Client
+const maxRedirects = 10
+
+// ErrChecksum is returned when a fetched script does not have the
+// checksum it is pinned to
+var ErrChecksum = errors.New("checksum mismatch")
+
+// IsURL reports whether the name of a script is a URL to fetch
+func IsURL(name string) bool {
+	return strings.HasPrefix(name, "https://") || strings.HasPrefix(name, "http://")
+}
+
+// SplitChecksum splits the SHA-256 checksum pinned in the fragment of
+// the URL (e.g. "https://example.com/demo.yaml#sha256=9f86d0...") from
+// the URL. The checksum is empty if the URL has none
+func SplitChecksum(rawURL string) (string, string) {
+	base, fragment, ok := strings.Cut(rawURL, "#")
+	if !ok {
+		return rawURL, ""
+	}
+	if sum, ok := strings.CutPrefix(fragment, "sha256="); ok {
+		return base, strings.ToLower(sum)
+	}
+	return rawURL, ""
+}
+
+// Fetcher fetches scripts over HTTPS into a cache directory, so that
+// they are loaded like local files, and played from the cache when
+// the server cannot be reached
+type Fetcher struct {
+	// The client of the requests, http.DefaultClient if nil. Its
+	// redirects are only followed to HTTPS URLs, and the requests
+	// time out after 30 seconds unless it has a timeout of its own
+	Client *http.Client
+
+	// The cache directory, with the fetched scripts in
+	// the scripts directory and the repositories in repos
+	CacheDir string
+
+	// The User-Agent header of the requests
+	UserAgent string
+}
+
+// DefaultCacheDir returns the cache directory of autotyper in the
+// cache of the user, or in the temporary directory if there is none
+func DefaultCacheDir() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		dir = os.TempDir()
+	}
+	return filepath.Join(dir, "autotyper")
+}
+
+// Fetch downloads the script at the URL, and its signature if there is
+// one (the URL with the .minisig extension), and returns the file of
+// the script in the cache. If sum is set, or pinned in the URL, the
+// SHA-256 checksum of the script must match. Only HTTPS URLs are
+// fetched. If the script cannot be downloaded, the cached copy is
+// returned, as long as it matches the checksum
+func (f *Fetcher) Fetch(ctx context.Context, rawURL, sum string) (string, error) {
+	rawURL, pinned := SplitChecksum(rawURL)
+	if sum == "" {
+		sum = pinned
+	}
+	sum = strings.ToLower(sum)
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", err
+	}
+	if u.Scheme != "https" {
+		return "", fmt.Errorf("%s: only HTTPS URLs are fetched", rawURL)
+	}
+
+	filename := f.cacheFile(u)
+	data, err := f.get(ctx, rawURL)
+	if err != nil {
+		cached, cacheErr := os.ReadFile(filename)
+		if cacheErr != nil || checkSum(cached, sum) != nil {
+			return "", err
+		}
+		slog.Warn("playing the cached script", "url", rawURL, "error", err)
+		return filename, nil
+	}
+	if err := checkSum(data, sum); err != nil {
+		return "", fmt.Errorf("%s: %w", rawURL, err)
+	}
+
+	// The signature is fetched with the script, and a
+	// missing signature removes the one of the cache
+	signature, err := f.get(ctx, rawURL+signatureExtension)
+	var status *StatusError
+	if errors.As(err, &status) && status.Code == http.StatusNotFound {
+		signature, err = nil, nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(filename, data, 0644); err != nil {
+		return "", err
+	}
+	if signature == nil {
+		err = os.Remove(filename + signatureExtension)
+		if errors.Is(err, os.ErrNotExist) {
+			err = nil
+		}
+	} else {
+		err = os.WriteFile(filename+signatureExtension, signature, 0644)
+	}
+	if err != nil {
+		return "", err
+	}
+	slog.Debug("fetched script", "url", rawURL, "file", filename, "bytes", len(data), "signed", signature != nil)
+	return filename, nil
+}
+
+// StatusError is returned when the server answers
+// a request with a status other than 200 OK
+type StatusError struct {
+	URL  string
+	Code int
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("%s: %d %s", e.URL, e.Code, http.StatusText(e.Code))
+}
+
+// get downloads the contents at the URL
+func (f *Fetcher) get(ctx context.Context, rawURL string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	if f.UserAgent != "" {
+		req.Header.Set("User-Agent", f.UserAgent)
+	}
+	resp, err := f.client().Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, &StatusError{URL: rawURL, Code: resp.StatusCode}
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxSize {
+		return nil, fmt.Errorf("%s: larger than %d bytes", rawURL, maxSize)
+	}
+	return data, nil
+}
+
+// client returns a copy of the client of the requests, which follows
+// the redirects to HTTPS URLs only and times out
+func (f *Fetcher) client() *http.Client {
+	client := *http.DefaultClient
+	if f.Client != nil {
+		client = *f.Client
+	}
+	client.CheckRedirect = checkRedirect
+	if client.Timeout == 0 {
+		client.Timeout = timeout
+	}
+	return &client
+}
+
+// checkRedirect refuses the redirects to other URLs than HTTPS, so
+// that a script fetched over HTTPS is never downloaded in the clear
+func checkRedirect(req *http.Request, via []*http.Request) error {
+	if req.URL.Scheme != "https" {
+		return fmt.Errorf("%s: only HTTPS URLs are fetched", req.URL.Redacted())
+	}
+	if len(via) >= maxRedirects {
+		return fmt.Errorf("stopped after %d redirects", maxRedirects)
+	}
+	return nil
+}
+
+// cacheFile returns the file of the script at the URL in the cache,
+// in a directory of its own named after the URL, with the name of the
+// script, so that its format is known by its extension
+func (f *Fetcher) cacheFile(u *url.URL) string {
+	key := sha256.Sum256([]byte(u.String()))
+	name := path.Base(u.Path)
+	if !filepath.IsLocal(name) || strings.ContainsAny(name, `\:`) {
+		name = "script"
+	}
+	return filepath.Join(f.CacheDir, "scripts", hex.EncodeToString(key[:8]), name)
+}
+
+// checkSum checks that the data has the SHA-256 checksum, if any
+func checkSum(data []byte, sum string) error {
+	if sum == "" {
+		return nil
+	}
+	actual := sha256.Sum256(data)
+	if hex.EncodeToString(actual[:]) != sum {
+		return fmt.Errorf("%w: expected sha256 %s, but got %x", ErrChecksum, sum, actual)
+	}
+	return nil
+}
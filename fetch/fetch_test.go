@@ -0,0 +1,98 @@
+package fetch_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bitcanon/autotyper/fetch"
+)
+
+// TestSplitChecksum tests that the checksum pinned in
+// the fragment of the URL is split from the URL
+func TestSplitChecksum(t *testing.T) {
+	tests := []struct {
+		url, expectedURL, expectedSum string
+	}{
+		{url: "https://example.com/demo.yaml", expectedURL: "https://example.com/demo.yaml"},
+		{url: "https://example.com/demo.yaml#sha256=ABC123", expectedURL: "https://example.com/demo.yaml", expectedSum: "abc123"},
+		{url: "https://example.com/demo.yaml#intro", expectedURL: "https://example.com/demo.yaml#intro"},
+	}
+
+	for _, test := range tests {
+		u, sum := fetch.SplitChecksum(test.url)
+		if u != test.expectedURL || sum != test.expectedSum {
+			t.Errorf("%s: expected %q and %q, but got %q and %q", test.url, test.expectedURL, test.expectedSum, u, sum)
+		}
+	}
+}
+
+// TestFetch tests that scripts are fetched with their signature into
+// the cache, that the checksums are checked, and that the cached copy
+// is used when the server cannot be reached
+func TestFetch(t *testing.T) {
+	script := "echo hello\n"
+	sum := sha256.Sum256([]byte(script))
+	online := true
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case !online:
+			http.Error(w, "down", http.StatusServiceUnavailable)
+		case r.URL.Path == "/demos/intro.txt":
+			w.Write([]byte(script))
+		case r.URL.Path == "/demos/intro.txt.minisig":
+			w.Write([]byte("signature"))
+		case r.URL.Path == "/demos/moved.txt":
+			http.Redirect(w, r, "http://"+r.Host+"/demos/intro.txt", http.StatusFound)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	f := &fetch.Fetcher{Client: server.Client(), CacheDir: t.TempDir()}
+	ctx := context.Background()
+	filename, err := f.Fetch(ctx, server.URL+"/demos/intro.txt#sha256="+hex.EncodeToString(sum[:]), "")
+	if err != nil {
+		t.Fatalf("expected no error, but got %v", err)
+	}
+	if filepath.Base(filename) != "intro.txt" {
+		t.Errorf("expected the name of the script, but got %s", filename)
+	}
+	if data, _ := os.ReadFile(filename); string(data) != script {
+		t.Errorf("expected the script %q, but got %q", script, data)
+	}
+	if data, _ := os.ReadFile(filename + ".minisig"); string(data) != "signature" {
+		t.Errorf("expected the signature, but got %q", data)
+	}
+
+	if _, err := f.Fetch(ctx, server.URL+"/demos/intro.txt", "0000"); !errors.Is(err, fetch.ErrChecksum) {
+		t.Errorf("expected ErrChecksum, but got %v", err)
+	}
+	var status *fetch.StatusError
+	if _, err := f.Fetch(ctx, server.URL+"/demos/missing.txt", ""); !errors.As(err, &status) || status.Code != http.StatusNotFound {
+		t.Errorf("expected a 404 error, but got %v", err)
+	}
+	if _, err := f.Fetch(ctx, "http://example.com/demo.txt", ""); err == nil {
+		t.Errorf("expected error for a plain HTTP URL, but got nil")
+	}
+	if _, err := f.Fetch(ctx, server.URL+"/demos/moved.txt", ""); err == nil || !strings.Contains(err.Error(), "only HTTPS URLs are fetched") {
+		t.Errorf("expected error for a redirect to a plain HTTP URL, but got %v", err)
+	}
+
+	online = false
+	cached, err := f.Fetch(ctx, server.URL+"/demos/intro.txt", "")
+	if err != nil || cached != filename {
+		t.Errorf("expected the cached script %s, but got %s (%v)", filename, cached, err)
+	}
+	if _, err := f.Fetch(ctx, server.URL+"/demos/intro.txt", "0000"); err == nil {
+		t.Errorf("expected error for a cached script with another checksum, but got nil")
+	}
+}
@@ -0,0 +1,212 @@
+/*
+Copyright © 2023 Mikael Schultz <bitcanon@proton.me>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package fetch
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// scriptExtensions are the extensions tried to find a script
+// named in a repository without its extension
+var scriptExtensions = []string{".yaml", ".yml", ".json", ".toml", ".txt", ".atd"}
+
+// Repo is a script in a git repository, named like a Go module with
+// the path of the script in the repository after a double slash
+// (e.g. "github.com/org/demos@v1.2//k8s-intro")
+type Repo struct {
+	// The host and the path of the repository (e.g. "github.com" and
+	// "org/demos"), cloned over HTTPS
+	Host string
+	Path string
+
+	// The branch, tag or commit checked out, or the default
+	// branch if empty
+	Ref string
+
+	// The slash-separated path of the script, or of its directory,
+	// in the repository. Empty for the root of the repository
+	File string
+}
+
+// ParseRepo parses the name of a script in a git repository. The second
+// return value reports whether the name is a script in a repository
+func ParseRepo(name string) (Repo, bool) {
+	repo, file, ok := strings.Cut(name, "//")
+	if !ok || IsURL(name) {
+		return Repo{}, false
+	}
+	var r Repo
+	if at := strings.LastIndex(repo, "@"); at >= 0 {
+		repo, r.Ref = repo[:at], repo[at+1:]
+		if !validRef(r.Ref) {
+			return Repo{}, false
+		}
+	}
+	r.Host, r.Path, ok = strings.Cut(repo, "/")
+	if !ok || !strings.Contains(r.Host, ".") || !validPath(r.Path) {
+		return Repo{}, false
+	}
+	if file = strings.Trim(file, "/"); file != "" && !validPath(file) {
+		return Repo{}, false
+	}
+	r.File = file
+	return r, true
+}
+
+// validPath reports whether the slash-separated path is
+// made of names, without "." and ".." elements
+func validPath(p string) bool {
+	if p == "" || path.Clean(p) != p || strings.HasPrefix(p, "/") {
+		return false
+	}
+	for _, name := range strings.Split(p, "/") {
+		if name == "." || name == ".." || strings.ContainsAny(name, `\:`) {
+			return false
+		}
+	}
+	return true
+}
+
+// validRef reports whether the ref is a valid name of a branch, tag or
+// commit, following the rules of git check-ref-format, so it cannot be
+// read as an option by git nor leave the directory of its clone
+func validRef(ref string) bool {
+	if ref == "" || ref == "@" || strings.HasPrefix(ref, "-") || strings.HasPrefix(ref, "/") ||
+		strings.HasSuffix(ref, "/") || strings.HasSuffix(ref, ".") || strings.HasSuffix(ref, ".lock") ||
+		strings.Contains(ref, "..") || strings.Contains(ref, "//") || strings.Contains(ref, "@{") {
+		return false
+	}
+	for _, r := range ref {
+		if r < 0x20 || r == 0x7f || strings.ContainsRune(" ~^:?*[\\", r) {
+			return false
+		}
+	}
+	for _, name := range strings.Split(ref, "/") {
+		if strings.HasPrefix(name, ".") {
+			return false
+		}
+	}
+	return true
+}
+
+// URL returns the URL the repository is cloned from
+func (r Repo) URL() string {
+	return "https://" + r.Host + "/" + r.Path
+}
+
+// String returns the name of the script in the repository
+func (r Repo) String() string {
+	s := r.Host + "/" + r.Path
+	if r.Ref != "" {
+		s += "@" + r.Ref
+	}
+	return s + "//" + r.File
+}
+
+// FetchRepo clones the repository of the script into the cache, or
+// refreshes the clone, and returns the file of the script. The script
+// is the file, the file with a script extension (e.g. "k8s-intro.yaml"),
+// or a demo or scenario script in the directory. If the repository
+// cannot be refreshed, the script of the clone is returned
+func (f *Fetcher) FetchRepo(ctx context.Context, r Repo) (string, error) {
+	name := r.Host + "/" + r.Path
+	if r.Ref != "" {
+		name += "@" + r.Ref
+	}
+	dir := filepath.Join(f.CacheDir, "repos", filepath.FromSlash(name))
+
+	if err := f.refresh(ctx, dir, r); err != nil {
+		if _, statErr := os.Stat(filepath.Join(dir, ".git", "FETCH_HEAD")); statErr != nil {
+			return "", err
+		}
+		slog.Warn("playing the cached repository", "repo", r.URL(), "error", err)
+	}
+	return findScript(filepath.Join(dir, filepath.FromSlash(r.File)))
+}
+
+// refresh checks out the latest commit of the ref in the directory,
+// creating the repository first if needed. Only the commit is fetched
+func (f *Fetcher) refresh(ctx context.Context, dir string, r Repo) error {
+	if _, err := os.Stat(filepath.Join(dir, ".git")); err != nil {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+		if err := git(ctx, dir, "init", "--quiet"); err != nil {
+			return err
+		}
+		if err := git(ctx, dir, "remote", "add", "origin", r.URL()); err != nil {
+			return err
+		}
+	}
+
+	ref := r.Ref
+	if ref == "" {
+		ref = "HEAD"
+	}
+	slog.Debug("fetching repository", "repo", r.URL(), "ref", ref, "dir", dir)
+	if err := git(ctx, dir, "fetch", "--quiet", "--depth", "1", "origin", ref); err != nil {
+		return err
+	}
+	return git(ctx, dir, "checkout", "--quiet", "--force", "FETCH_HEAD")
+}
+
+// git runs the git command in the directory
+func git(ctx context.Context, dir string, args ...string) error {
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("git %s: %w: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
+
+// findScript returns the script named by the path: the file itself,
+// the file with a script extension, or the demo or scenario script
+// in the directory
+func findScript(name string) (string, error) {
+	candidates := []string{name}
+	for _, ext := range scriptExtensions {
+		candidates = append(candidates, name+ext)
+	}
+	for _, base := range []string{"demo", "scenario"} {
+		for _, ext := range scriptExtensions {
+			candidates = append(candidates, filepath.Join(name, base+ext))
+		}
+	}
+
+	for _, filename := range candidates {
+		if info, err := os.Stat(filename); err == nil && info.Mode().IsRegular() {
+			return filename, nil
+		}
+	}
+	return "", fmt.Errorf("no script %s in the repository", filepath.Base(name))
+}
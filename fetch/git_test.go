@@ -0,0 +1,123 @@
+package fetch_test
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/bitcanon/autotyper/fetch"
+)
+
+// TestParseRepo tests that names of scripts in git repositories
+// are parsed, and that other names are not
+func TestParseRepo(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected fetch.Repo
+		ok       bool
+	}{
+		{name: "github.com/org/demos//k8s-intro", expected: fetch.Repo{Host: "github.com", Path: "org/demos", File: "k8s-intro"}, ok: true},
+		{name: "gitlab.com/org/group/demos@v1.2//intro/demo.yaml", expected: fetch.Repo{Host: "gitlab.com", Path: "org/group/demos", Ref: "v1.2", File: "intro/demo.yaml"}, ok: true},
+		{name: "github.com/org/demos//", expected: fetch.Repo{Host: "github.com", Path: "org/demos"}, ok: true},
+		{name: "github.com/org/demos"},
+		{name: "demos/intro//demo.yaml"},
+		{name: "github.com/org/demos//../secret"},
+		{name: "github.com/org/../demos//intro"},
+		{name: "github.com/org/demos@//intro"},
+		{name: "github.com/org/demos@--upload-pack=x//intro"},
+		{name: "github.com/org/demos@feature/login//intro", expected: fetch.Repo{Host: "github.com", Path: "org/demos", Ref: "feature/login", File: "intro"}, ok: true},
+		{name: "github.com/a/b@../../../../../tmp/pwn//f"},
+		{name: "github.com/org/demos@v1..v2//intro"},
+		{name: "github.com/org/demos@/etc//intro"},
+		{name: "github.com/org/demos@main/.hidden//intro"},
+		{name: "github.com/org/demos@main~1//intro"},
+		{name: "github.com/org/demos@a\\b//intro"},
+		{name: "https://github.com/org/demos//intro"},
+	}
+
+	for _, test := range tests {
+		r, ok := fetch.ParseRepo(test.name)
+		if ok != test.ok || r != test.expected {
+			t.Errorf("%s: expected %+v (%v), but got %+v (%v)", test.name, test.expected, test.ok, r, ok)
+		}
+	}
+}
+
+// TestFetchRepo tests that scripts are found in a cloned repository,
+// that the clone is refreshed, and that it is used when the
+// repository cannot be reached
+func TestFetchRepo(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not available")
+	}
+
+	// Redirect https://example.com/ to local repositories
+	origin := t.TempDir()
+	t.Setenv("GIT_CONFIG_COUNT", "1")
+	t.Setenv("GIT_CONFIG_KEY_0", "url.file://"+filepath.ToSlash(origin)+"/.insteadOf")
+	t.Setenv("GIT_CONFIG_VALUE_0", "https://example.com/")
+
+	repo := filepath.Join(origin, "org", "demos")
+	commit := func(files map[string]string) {
+		t.Helper()
+		for name, content := range files {
+			filename := filepath.Join(repo, filepath.FromSlash(name))
+			os.MkdirAll(filepath.Dir(filename), 0755)
+			if err := os.WriteFile(filename, []byte(content), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		for _, args := range [][]string{{"init", "--quiet"}, {"add", "-A"}, {"-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "--quiet", "-m", "update"}} {
+			cmd := exec.Command("git", args...)
+			cmd.Dir = repo
+			if out, err := cmd.CombinedOutput(); err != nil {
+				t.Fatalf("git %s: %v: %s", args[0], err, out)
+			}
+		}
+	}
+	commit(map[string]string{"k8s-intro/demo.yaml": "v1", "basics.txt": "basics"})
+
+	f := &fetch.Fetcher{CacheDir: t.TempDir()}
+	ctx := context.Background()
+	tests := []struct {
+		name, expected string
+	}{
+		{name: "example.com/org/demos//k8s-intro", expected: "v1"},
+		{name: "example.com/org/demos//k8s-intro/demo.yaml", expected: "v1"},
+		{name: "example.com/org/demos//basics", expected: "basics"},
+	}
+	for _, test := range tests {
+		r, _ := fetch.ParseRepo(test.name)
+		filename, err := f.FetchRepo(ctx, r)
+		if err != nil {
+			t.Fatalf("%s: expected no error, but got %v", test.name, err)
+		}
+		if data, _ := os.ReadFile(filename); string(data) != test.expected {
+			t.Errorf("%s: expected the script %q, but got %q", test.name, test.expected, data)
+		}
+	}
+
+	r, _ := fetch.ParseRepo("example.com/org/demos//missing")
+	if _, err := f.FetchRepo(ctx, r); err == nil {
+		t.Errorf("expected error for a missing script, but got nil")
+	}
+
+	commit(map[string]string{"k8s-intro/demo.yaml": "v2"})
+	r, _ = fetch.ParseRepo("example.com/org/demos//k8s-intro")
+	filename, err := f.FetchRepo(ctx, r)
+	if data, _ := os.ReadFile(filename); err != nil || string(data) != "v2" {
+		t.Errorf("expected the refreshed script, but got %q (%v)", data, err)
+	}
+
+	os.RemoveAll(repo)
+	filename, err = f.FetchRepo(ctx, r)
+	if data, _ := os.ReadFile(filename); err != nil || string(data) != "v2" {
+		t.Errorf("expected the cached script, but got %q (%v)", data, err)
+	}
+	r, _ = fetch.ParseRepo("example.com/org/other//intro")
+	if _, err := f.FetchRepo(ctx, r); err == nil {
+		t.Errorf("expected error for a missing repository, but got nil")
+	}
+}
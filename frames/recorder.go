@@ -0,0 +1,190 @@
+/*
+Copyright © 2023 Mikael Schultz <bitcanon@proton.me>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package frames
+
+import (
+	"fmt"
+	"image/png"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/bitcanon/autotyper/cli"
+	"github.com/bitcanon/autotyper/vt"
+)
+
+// Recorder renders the output written to it into numbered PNG frames
+// (frame-00001.png, ...) at a constant frame rate, for composing the
+// demo in a video editor. The frames of an unchanged screen are links
+// to the previous frame. The time of the frames is that of the clock
+// of the playback, so a playback on a fake clock is recorded at its
+// pace without being played in real time
+type Recorder struct {
+	mu       sync.Mutex
+	screen   *vt.Screen
+	renderer *Renderer
+	changed  bool
+
+	// Held while the frames due are written
+	capturing sync.Mutex
+
+	dir      string
+	clock    cli.Clock
+	interval time.Duration
+	start    time.Time
+	frames   int
+	last     string
+	err      error
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// NewRecorder creates a recorder of a screen of cols columns and rows
+// rows, writing fps frames per second of the clock into the directory,
+// which is created if needed. The recording starts with the first frame
+func NewRecorder(dir string, cols, rows, fps int, renderer *Renderer, clock cli.Clock) (*Recorder, error) {
+	if fps <= 0 {
+		return nil, fmt.Errorf("invalid frame rate %d", fps)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	rec := &Recorder{
+		screen:   vt.NewScreen(cols, rows),
+		renderer: renderer,
+		changed:  true,
+		dir:      dir,
+		clock:    clock,
+		interval: time.Second / time.Duration(fps),
+		start:    clock.Now(),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	go rec.run()
+	return rec, nil
+}
+
+// Write interprets the output on the screen of the recording, after
+// writing the frames due before it
+func (rec *Recorder) Write(p []byte) (int, error) {
+	rec.capture()
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	rec.screen.Write(p)
+	rec.changed = true
+	return len(p), nil
+}
+
+// Frames returns the number of frames written
+func (rec *Recorder) Frames() int {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	return rec.frames
+}
+
+// Close writes the frames up to now and stops the recording. The
+// first error writing a frame is returned
+func (rec *Recorder) Close() error {
+	close(rec.stop)
+	<-rec.done
+	rec.capture()
+	return rec.err
+}
+
+// run writes the frames at the frame rate until the recording stops
+func (rec *Recorder) run() {
+	defer close(rec.done)
+	rec.capture()
+	ticker := time.NewTicker(rec.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-rec.stop:
+			return
+		case <-ticker.C:
+			rec.capture()
+		}
+	}
+}
+
+// capture writes the frames due since the start of the recording,
+// so that the frames keep the pace of the playback even if a frame
+// takes longer to write than the interval
+func (rec *Recorder) capture() {
+	rec.capturing.Lock()
+	defer rec.capturing.Unlock()
+	due := int(rec.clock.Now().Sub(rec.start)/rec.interval) + 1
+	for rec.err == nil && rec.frames < due {
+		rec.err = rec.writeFrame()
+	}
+}
+
+// writeFrame writes the next frame, rendering the screen
+// if it changed since the last frame
+func (rec *Recorder) writeFrame() error {
+	rec.mu.Lock()
+	rec.frames++
+	name := filepath.Join(rec.dir, fmt.Sprintf("frame-%05d.png", rec.frames))
+	if !rec.changed {
+		rec.mu.Unlock()
+		return link(rec.last, name)
+	}
+	img := rec.renderer.Render(rec.screen)
+	rec.changed = false
+	rec.mu.Unlock()
+
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	if err := png.Encode(f, img); err != nil {
+		f.Close()
+		return err
+	}
+	rec.last = name
+	return f.Close()
+}
+
+// link links the frame to the previous one, or copies it
+// if the file system has no hard links
+func link(previous, name string) error {
+	if err := os.Link(previous, name); err == nil {
+		return nil
+	}
+	src, err := os.Open(previous)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	dst, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(dst, src); err != nil {
+		dst.Close()
+		return err
+	}
+	return dst.Close()
+}
@@ -0,0 +1,54 @@
+package frames_test
+
+import (
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bitcanon/autotyper/cli"
+	"github.com/bitcanon/autotyper/frames"
+)
+
+// TestRecorder tests that the frames are written at the frame rate
+// and show the output written to the recorder
+func TestRecorder(t *testing.T) {
+	r, err := frames.NewRenderer(12)
+	if err != nil {
+		t.Fatalf("expected no error, but got %v", err)
+	}
+	dir := filepath.Join(t.TempDir(), "frames")
+	rec, err := frames.NewRecorder(dir, 20, 5, 20, r, cli.SystemClock{})
+	if err != nil {
+		t.Fatalf("expected no error, but got %v", err)
+	}
+	rec.Write([]byte("$ echo hi\nhi\n"))
+	time.Sleep(300 * time.Millisecond)
+	if err := rec.Close(); err != nil {
+		t.Fatalf("expected no error, but got %v", err)
+	}
+
+	n := rec.Frames()
+	if n < 5 || n > 10 {
+		t.Errorf("expected about 6 frames at 20 frames per second, but got %d", n)
+	}
+	for _, name := range []string{"frame-00001.png", "frame-00005.png"} {
+		f, err := os.Open(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatalf("expected the frame %s, but got %v", name, err)
+		}
+		img, err := png.Decode(f)
+		f.Close()
+		if err != nil {
+			t.Fatalf("%s: expected a PNG image, but got %v", name, err)
+		}
+		if width, height := r.Size(20, 5); img.Bounds().Dx() != width || img.Bounds().Dy() != height {
+			t.Errorf("%s: expected an image of %dx%d, but got %v", name, width, height, img.Bounds())
+		}
+	}
+
+	if _, err := frames.NewRecorder(dir, 20, 5, 0, r, cli.SystemClock{}); err == nil {
+		t.Errorf("expected an error for a frame rate of 0, but got nil")
+	}
+}
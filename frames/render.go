@@ -0,0 +1,132 @@
+/*
+Copyright © 2023 Mikael Schultz <bitcanon@proton.me>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package frames
+
+import (
+	"image"
+	"image/draw"
+
+	"github.com/bitcanon/autotyper/vt"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/gomono"
+	"golang.org/x/image/font/gofont/gomonobold"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/math/fixed"
+)
+
+// padding is the margin around the screen in pixels
+const padding = 8
+
+// Renderer draws a virtual screen as an image, in the Go Mono font
+type Renderer struct {
+	regular, bold font.Face
+
+	// The size of a cell and the baseline of the text in pixels
+	cellWidth, cellHeight, ascent int
+}
+
+// NewRenderer creates a renderer drawing the text
+// in the font size in pixels (e.g. 16)
+func NewRenderer(size float64) (*Renderer, error) {
+	regular, err := newFace(gomono.TTF, size)
+	if err != nil {
+		return nil, err
+	}
+	bold, err := newFace(gomonobold.TTF, size)
+	if err != nil {
+		return nil, err
+	}
+
+	metrics := regular.Metrics()
+	advance, _ := regular.GlyphAdvance('M')
+	return &Renderer{
+		regular:    regular,
+		bold:       bold,
+		cellWidth:  advance.Ceil(),
+		cellHeight: (metrics.Ascent + metrics.Descent).Ceil(),
+		ascent:     metrics.Ascent.Ceil(),
+	}, nil
+}
+
+// newFace returns the face of the TrueType font in the size
+func newFace(ttf []byte, size float64) (font.Face, error) {
+	f, err := opentype.Parse(ttf)
+	if err != nil {
+		return nil, err
+	}
+	return opentype.NewFace(f, &opentype.FaceOptions{Size: size, DPI: 72, Hinting: font.HintingFull})
+}
+
+// Size returns the size in pixels of the image of a screen of
+// cols columns and rows rows
+func (r *Renderer) Size(cols, rows int) (width, height int) {
+	return cols*r.cellWidth + 2*padding, rows*r.cellHeight + 2*padding
+}
+
+// Render draws the screen with its colors, and its cursor
+// as a block unless the cursor is hidden
+func (r *Renderer) Render(s *vt.Screen) *image.RGBA {
+	cols, rows := s.Size()
+	width, height := r.Size(cols, rows)
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(img, img.Bounds(), image.NewUniform(defaultBG), image.Point{}, draw.Src)
+
+	cursorCol, cursorRow := s.Cursor()
+	for row := 0; row < rows; row++ {
+		for col, cell := range s.Cells(row) {
+			st := parseStyle(cell.Style)
+			if row == cursorRow && col == cursorCol && !s.CursorHidden() {
+				st.fg, st.bg = st.bg, st.fg
+			}
+			r.drawCell(img, col, row, cell.Rune, st)
+		}
+	}
+	return img
+}
+
+// drawCell draws the background and the character of a cell
+func (r *Renderer) drawCell(img *image.RGBA, col, row int, char rune, st style) {
+	x, y := padding+col*r.cellWidth, padding+row*r.cellHeight
+	cell := image.Rect(x, y, x+r.cellWidth, y+r.cellHeight)
+	if st.bg != defaultBG {
+		draw.Draw(img, cell, image.NewUniform(st.bg), image.Point{}, draw.Src)
+	}
+	if st.underline {
+		line := image.Rect(x, y+r.ascent+1, x+r.cellWidth, y+r.ascent+2)
+		draw.Draw(img, line, image.NewUniform(st.fg), image.Point{}, draw.Src)
+	}
+	if char == 0 || char == ' ' {
+		return
+	}
+
+	face := r.regular
+	if st.bold {
+		face = r.bold
+	}
+	d := font.Drawer{
+		Dst:  img,
+		Src:  image.NewUniform(st.fg),
+		Face: face,
+		Dot:  fixed.P(x, y+r.ascent),
+	}
+	d.DrawString(string(char))
+}
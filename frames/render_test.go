@@ -0,0 +1,58 @@
+package frames_test
+
+import (
+	"image/color"
+	"testing"
+
+	"github.com/bitcanon/autotyper/frames"
+	"github.com/bitcanon/autotyper/vt"
+)
+
+// TestRender tests that the image of the screen has the size of its
+// cells, and that the text and the backgrounds are drawn in their colors
+func TestRender(t *testing.T) {
+	r, err := frames.NewRenderer(16)
+	if err != nil {
+		t.Fatalf("expected no error, but got %v", err)
+	}
+	screen := vt.NewScreen(10, 2)
+	screen.Write([]byte("\033[?25l\033[41m   \033[0m\033[1;32mok\033[0m"))
+
+	img := r.Render(screen)
+	width, height := r.Size(10, 2)
+	if img.Bounds().Dx() != width || img.Bounds().Dy() != height {
+		t.Fatalf("expected an image of %dx%d, but got %v", width, height, img.Bounds())
+	}
+	if width <= 10*8 || height <= 2*16 {
+		t.Errorf("expected cells of at least 8x16 pixels, but got an image of %dx%d", width, height)
+	}
+
+	cellWidth := (width - 16) / 10
+	tests := []struct {
+		name  string
+		x, y  int
+		color color.RGBA
+	}{
+		{name: "Margin", x: 1, y: 1, color: color.RGBA{0x1e, 0x1e, 0x1e, 0xff}},
+		{name: "RedBackground", x: 8 + cellWidth, y: 10, color: color.RGBA{0xcd, 0x00, 0x00, 0xff}},
+		{name: "EmptyRow", x: 8 + cellWidth, y: height - 10, color: color.RGBA{0x1e, 0x1e, 0x1e, 0xff}},
+	}
+	for _, test := range tests {
+		if got := img.RGBAAt(test.x, test.y); got != test.color {
+			t.Errorf("%s: expected %v at (%d, %d), but got %v", test.name, test.color, test.x, test.y, got)
+		}
+	}
+
+	// The bold green text is drawn in bright green
+	green := false
+	for x := 8 + 3*cellWidth; x < 8+5*cellWidth; x++ {
+		for y := 8; y < height/2; y++ {
+			if img.RGBAAt(x, y) == (color.RGBA{0x00, 0xff, 0x00, 0xff}) {
+				green = true
+			}
+		}
+	}
+	if !green {
+		t.Errorf("expected the text to be drawn in bright green")
+	}
+}
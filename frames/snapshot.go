@@ -0,0 +1,93 @@
+/*
+Copyright © 2023 Mikael Schultz <bitcanon@proton.me>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package frames
+
+import (
+	"fmt"
+	"image/png"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/bitcanon/autotyper/vt"
+)
+
+// Snapshots keeps the screen of the output written to it and writes
+// snapshots of the screen into a directory, as text files or as PNG
+// images, for example after each command of the demo
+type Snapshots struct {
+	mu       sync.Mutex
+	screen   *vt.Screen
+	renderer *Renderer
+	dir      string
+}
+
+// NewSnapshots creates the snapshots of a screen of cols columns
+// and rows rows, written into the directory, which is created if
+// needed. The snapshots are PNG images drawn by the renderer, or
+// text files if the renderer is nil
+func NewSnapshots(dir string, cols, rows int, renderer *Renderer) (*Snapshots, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	return &Snapshots{screen: vt.NewScreen(cols, rows), renderer: renderer, dir: dir}, nil
+}
+
+// Write interprets the output on the screen of the snapshots
+func (s *Snapshots) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.screen.Write(p)
+}
+
+// Capture writes the snapshot of the screen named name (e.g.
+// "step-01"), with the extension of its format, and returns
+// the name of the file written
+func (s *Snapshots) Capture(name string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.renderer == nil {
+		filename := filepath.Join(s.dir, name+".txt")
+		return filename, os.WriteFile(filename, []byte(s.screen.String()+"\n"), 0644)
+	}
+	filename := filepath.Join(s.dir, name+".png")
+	f, err := os.Create(filename)
+	if err != nil {
+		return "", err
+	}
+	if err := png.Encode(f, s.renderer.Render(s.screen)); err != nil {
+		f.Close()
+		return "", err
+	}
+	return filename, f.Close()
+}
+
+// StepName returns the name of the snapshot of the step with the
+// number (from 1), followed by the name of the step if it has one
+// (e.g. "step-03-deploy")
+func StepName(number int, name string) string {
+	if name == "" {
+		return fmt.Sprintf("step-%02d", number)
+	}
+	return fmt.Sprintf("step-%02d-%s", number, name)
+}
@@ -0,0 +1,58 @@
+package frames_test
+
+import (
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bitcanon/autotyper/frames"
+)
+
+// TestSnapshots tests that the screen is captured as text without
+// the escape sequences, and as a PNG image of the screen
+func TestSnapshots(t *testing.T) {
+	dir := t.TempDir()
+	text, err := frames.NewSnapshots(dir, 20, 5, nil)
+	if err != nil {
+		t.Fatalf("expected no error, but got %v", err)
+	}
+	text.Write([]byte("\033[32m$\033[0m echo hi\nhi\n$ "))
+	filename, err := text.Capture(frames.StepName(1, "greet"))
+	if err != nil {
+		t.Fatalf("expected no error, but got %v", err)
+	}
+	if filename != filepath.Join(dir, "step-01-greet.txt") {
+		t.Errorf("expected the file step-01-greet.txt, but got %s", filename)
+	}
+	data, _ := os.ReadFile(filename)
+	if string(data) != "$ echo hi\nhi\n$\n" {
+		t.Errorf("expected the text of the screen, but got %q", data)
+	}
+
+	r, err := frames.NewRenderer(12)
+	if err != nil {
+		t.Fatalf("expected no error, but got %v", err)
+	}
+	images, err := frames.NewSnapshots(dir, 20, 5, r)
+	if err != nil {
+		t.Fatalf("expected no error, but got %v", err)
+	}
+	images.Write([]byte("$ ls\n"))
+	filename, err = images.Capture(frames.StepName(12, ""))
+	if err != nil {
+		t.Fatalf("expected no error, but got %v", err)
+	}
+	f, err := os.Open(filepath.Join(dir, "step-12.png"))
+	if err != nil {
+		t.Fatalf("expected the file step-12.png, but got %v (%s)", err, filename)
+	}
+	defer f.Close()
+	img, err := png.Decode(f)
+	if err != nil {
+		t.Fatalf("expected a PNG image, but got %v", err)
+	}
+	if width, height := r.Size(20, 5); img.Bounds().Dx() != width || img.Bounds().Dy() != height {
+		t.Errorf("expected an image of %dx%d, but got %v", width, height, img.Bounds())
+	}
+}
@@ -0,0 +1,154 @@
+/*
+Copyright © 2023 Mikael Schultz <bitcanon@proton.me>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package frames
+
+import (
+	"image/color"
+	"strconv"
+	"strings"
+)
+
+// style is the look of a cell, from the parameters of its SGR
+// escape sequence
+type style struct {
+	fg, bg    color.RGBA
+	bold      bool
+	underline bool
+}
+
+// Define the default colors of the screen
+var (
+	defaultFG = color.RGBA{0xd4, 0xd4, 0xd4, 0xff}
+	defaultBG = color.RGBA{0x1e, 0x1e, 0x1e, 0xff}
+)
+
+// basicColors are the 16 colors of the terminal, as in xterm
+var basicColors = [16]color.RGBA{
+	{0x00, 0x00, 0x00, 0xff}, {0xcd, 0x00, 0x00, 0xff}, {0x00, 0xcd, 0x00, 0xff}, {0xcd, 0xcd, 0x00, 0xff},
+	{0x00, 0x00, 0xee, 0xff}, {0xcd, 0x00, 0xcd, 0xff}, {0x00, 0xcd, 0xcd, 0xff}, {0xe5, 0xe5, 0xe5, 0xff},
+	{0x7f, 0x7f, 0x7f, 0xff}, {0xff, 0x00, 0x00, 0xff}, {0x00, 0xff, 0x00, 0xff}, {0xff, 0xff, 0x00, 0xff},
+	{0x5c, 0x5c, 0xff, 0xff}, {0xff, 0x00, 0xff, 0xff}, {0x00, 0xff, 0xff, 0xff}, {0xff, 0xff, 0xff, 0xff},
+}
+
+// parseStyle returns the look of the cells with the parameters of
+// an SGR escape sequence (e.g. "1;38;5;82"). Unknown parameters are
+// ignored
+func parseStyle(params string) style {
+	st := style{fg: defaultFG, bg: defaultBG}
+	if params == "" {
+		return st
+	}
+	var values []int
+	for _, p := range strings.Split(params, ";") {
+		n, _ := strconv.Atoi(p)
+		values = append(values, n)
+	}
+
+	reverse := false
+	for i := 0; i < len(values); i++ {
+		switch n := values[i]; {
+		case n == 0:
+			st, reverse = style{fg: defaultFG, bg: defaultBG}, false
+		case n == 1:
+			st.bold = true
+		case n == 4:
+			st.underline = true
+		case n == 7:
+			reverse = true
+		case n == 22:
+			st.bold = false
+		case n == 24:
+			st.underline = false
+		case n == 27:
+			reverse = false
+		case n >= 30 && n <= 37:
+			st.fg = basicColors[n-30]
+		case n >= 90 && n <= 97:
+			st.fg = basicColors[n-90+8]
+		case n == 39:
+			st.fg = defaultFG
+		case n >= 40 && n <= 47:
+			st.bg = basicColors[n-40]
+		case n >= 100 && n <= 107:
+			st.bg = basicColors[n-100+8]
+		case n == 49:
+			st.bg = defaultBG
+		case n == 38 || n == 48:
+			c, used, ok := extendedColor(values[i+1:])
+			if ok && n == 38 {
+				st.fg = c
+			} else if ok {
+				st.bg = c
+			}
+			i += used
+		}
+	}
+
+	// Bold text is drawn in the bright variant of the basic colors
+	if st.bold {
+		for i, c := range basicColors[:8] {
+			if st.fg == c {
+				st.fg = basicColors[i+8]
+				break
+			}
+		}
+	}
+	if reverse {
+		st.fg, st.bg = st.bg, st.fg
+	}
+	return st
+}
+
+// extendedColor returns the color of the parameters following 38 or
+// 48: "5;n" of the 256 colors, or "2;r;g;b" of the true colors, and
+// the number of parameters used
+func extendedColor(values []int) (color.RGBA, int, bool) {
+	switch {
+	case len(values) >= 2 && values[0] == 5:
+		return color256(values[1]), 2, true
+	case len(values) >= 4 && values[0] == 2:
+		return color.RGBA{uint8(values[1]), uint8(values[2]), uint8(values[3]), 0xff}, 4, true
+	}
+	return color.RGBA{}, len(values), false
+}
+
+// color256 returns one of the 256 colors of xterm: the 16 basic
+// colors, a 6x6x6 color cube and 24 shades of gray
+func color256(n int) color.RGBA {
+	switch {
+	case n < 0 || n > 255:
+		return defaultFG
+	case n < 16:
+		return basicColors[n]
+	case n < 232:
+		n -= 16
+		level := func(v int) uint8 {
+			if v == 0 {
+				return 0
+			}
+			return uint8(55 + v*40)
+		}
+		return color.RGBA{level(n / 36), level(n / 6 % 6), level(n % 6), 0xff}
+	}
+	gray := uint8(8 + (n-232)*10)
+	return color.RGBA{gray, gray, gray, 0xff}
+}
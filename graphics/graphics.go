@@ -0,0 +1,167 @@
+/*
+Copyright © 2023 Mikael Schultz <bitcanon@proton.me>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package graphics
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/image/draw"
+)
+
+// Protocol is a way of drawing images in a terminal
+type Protocol int
+
+// Define the protocols
+const (
+	None Protocol = iota
+	ITerm2
+	Sixel
+)
+
+// CellWidth is the assumed width of a terminal cell in pixels,
+// used to fit the images to the width of the terminal
+const CellWidth = 10
+
+// MaxWidth is the widest image drawn in pixels, whatever
+// the width of the terminal
+const MaxWidth = 800
+
+// ParseProtocol returns the protocol for its name ("iterm",
+// "sixel" or "none")
+func ParseProtocol(name string) (Protocol, error) {
+	switch name {
+	case "iterm", "iterm2":
+		return ITerm2, nil
+	case "sixel":
+		return Sixel, nil
+	case "none":
+		return None, nil
+	}
+	return None, fmt.Errorf("unknown image protocol %q (expected auto, iterm, sixel or none)", name)
+}
+
+// Detect returns the protocol supported by the terminal, guessed
+// from its environment variables, or None if it is not known to
+// draw images
+func Detect(getenv func(string) string) Protocol {
+	switch getenv("TERM_PROGRAM") {
+	case "iTerm.app", "WezTerm":
+		return ITerm2
+	}
+	if getenv("LC_TERMINAL") == "iTerm2" {
+		return ITerm2
+	}
+	term := getenv("TERM")
+	for _, name := range []string{"sixel", "foot", "mlterm", "contour", "yaft"} {
+		if strings.Contains(term, name) {
+			return Sixel
+		}
+	}
+	return None
+}
+
+// Draw draws the image in the file at the cursor, on its own lines,
+// scaled down to fit in the width of the terminal in columns (if not
+// zero). Images are not drawn with the None protocol
+func Draw(out io.Writer, filename string, protocol Protocol, cols int) error {
+	switch protocol {
+	case ITerm2:
+		data, err := os.ReadFile(filename)
+		if err != nil {
+			return err
+		}
+		config, _, err := image.DecodeConfig(bytes.NewReader(data))
+		if err != nil {
+			return fmt.Errorf("%s: %w", filename, err)
+		}
+		return writeITerm2(out, filepath.Base(filename), data, fit(config.Width, cols))
+	case Sixel:
+		img, err := decode(filename)
+		if err != nil {
+			return err
+		}
+		return writeSixel(out, scale(img, fit(img.Bounds().Dx(), cols)))
+	}
+	return nil
+}
+
+// Check checks that the file is an image of a supported format,
+// without decoding all of it
+func Check(filename string) error {
+	f, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if _, _, err := image.DecodeConfig(f); err != nil {
+		return fmt.Errorf("%s: %w", filename, err)
+	}
+	return nil
+}
+
+// decode reads the image in the file
+func decode(filename string) (image.Image, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	img, _, err := image.Decode(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", filename, err)
+	}
+	return img, nil
+}
+
+// fit returns the width in pixels an image of this width is
+// drawn with in a terminal of this many columns
+func fit(width, cols int) int {
+	limit := MaxWidth
+	if cols > 0 {
+		limit = min(limit, cols*CellWidth)
+	}
+	return min(width, limit)
+}
+
+// scale returns the image scaled down to the width in pixels,
+// keeping its aspect ratio
+func scale(img image.Image, width int) image.Image {
+	bounds := img.Bounds()
+	if width >= bounds.Dx() || width <= 0 {
+		return img
+	}
+	height := max(1, bounds.Dy()*width/bounds.Dx())
+	scaled := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.CatmullRom.Scale(scaled, scaled.Bounds(), img, bounds, draw.Src, nil)
+	return scaled
+}
@@ -0,0 +1,144 @@
+package graphics_test
+
+import (
+	"encoding/base64"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bitcanon/autotyper/graphics"
+)
+
+// writeImage writes a PNG image of the size, in one color
+// with a transparent right half, to a temporary file
+func writeImage(t *testing.T, width, height int) string {
+	t.Helper()
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width/2; x++ {
+			img.Set(x, y, color.NRGBA{R: 255, A: 255})
+		}
+	}
+
+	filename := filepath.Join(t.TempDir(), "diagram.png")
+	f, err := os.Create(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if err := png.Encode(f, img); err != nil {
+		t.Fatal(err)
+	}
+	return filename
+}
+
+// TestDetect tests that the protocol is guessed from
+// the environment variables of the terminal
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		env      map[string]string
+		expected graphics.Protocol
+	}{
+		{env: map[string]string{"TERM_PROGRAM": "iTerm.app"}, expected: graphics.ITerm2},
+		{env: map[string]string{"TERM_PROGRAM": "WezTerm"}, expected: graphics.ITerm2},
+		{env: map[string]string{"LC_TERMINAL": "iTerm2", "TERM": "xterm-256color"}, expected: graphics.ITerm2},
+		{env: map[string]string{"TERM": "foot"}, expected: graphics.Sixel},
+		{env: map[string]string{"TERM": "xterm-sixel"}, expected: graphics.Sixel},
+		{env: map[string]string{"TERM": "xterm-256color"}, expected: graphics.None},
+		{env: map[string]string{}, expected: graphics.None},
+	}
+
+	for _, test := range tests {
+		if got := graphics.Detect(func(key string) string { return test.env[key] }); got != test.expected {
+			t.Errorf("%v: expected %v, but got %v", test.env, test.expected, got)
+		}
+	}
+}
+
+// TestParseProtocol tests that the protocols are parsed
+// by name and that unknown names are refused
+func TestParseProtocol(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected graphics.Protocol
+		err      bool
+	}{
+		{name: "iterm", expected: graphics.ITerm2},
+		{name: "sixel", expected: graphics.Sixel},
+		{name: "none", expected: graphics.None},
+		{name: "kitty", err: true},
+	}
+
+	for _, test := range tests {
+		got, err := graphics.ParseProtocol(test.name)
+		if (err != nil) != test.err {
+			t.Errorf("%s: expected error %v, but got %v", test.name, test.err, err)
+		}
+		if got != test.expected {
+			t.Errorf("%s: expected %v, but got %v", test.name, test.expected, got)
+		}
+	}
+}
+
+// TestDrawITerm2 tests that the image file is sent whole with the
+// inline images protocol, drawn no wider than the terminal
+func TestDrawITerm2(t *testing.T) {
+	filename := writeImage(t, 400, 100)
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var out strings.Builder
+	if err := graphics.Draw(&out, filename, graphics.ITerm2, 20); err != nil {
+		t.Fatalf("expected no error, but got %v", err)
+	}
+	for _, expected := range []string{"\033]1337;File=", "name=" + base64.StdEncoding.EncodeToString([]byte("diagram.png")), "width=200px", "inline=1:" + base64.StdEncoding.EncodeToString(data) + "\a\n"} {
+		if !strings.Contains(out.String(), expected) {
+			t.Errorf("expected %q in %q", expected, out.String())
+		}
+	}
+}
+
+// TestDrawSixel tests that the image is scaled down to the width of
+// the terminal, and that the transparent pixels are not drawn
+func TestDrawSixel(t *testing.T) {
+	var out strings.Builder
+	if err := graphics.Draw(&out, writeImage(t, 400, 12), graphics.Sixel, 10); err != nil {
+		t.Fatalf("expected no error, but got %v", err)
+	}
+	if got := out.String(); !strings.HasPrefix(got, "\033P0;1q\"1;1;100;3") || !strings.HasSuffix(got, "\033\\\n") {
+		t.Fatalf("expected a Sixel image of 100x3 pixels, but got %q", got)
+	}
+
+	// Three rows of 50 red pixels in the only band, and
+	// nothing drawn in the transparent right half
+	out.Reset()
+	if err := graphics.Draw(&out, writeImage(t, 100, 3), graphics.Sixel, 0); err != nil {
+		t.Fatalf("expected no error, but got %v", err)
+	}
+	expected := "\033P0;1q\"1;1;100;3#240;2;100;0;0#240!50F-\033\\\n"
+	if out.String() != expected {
+		t.Errorf("expected %q, but got %q", expected, out.String())
+	}
+}
+
+// TestCheck tests that images are checked without being drawn
+func TestCheck(t *testing.T) {
+	if err := graphics.Check(writeImage(t, 2, 2)); err != nil {
+		t.Errorf("expected no error, but got %v", err)
+	}
+
+	text := filepath.Join(t.TempDir(), "notes.png")
+	os.WriteFile(text, []byte("not an image"), 0o644)
+	if err := graphics.Check(text); err == nil {
+		t.Errorf("expected error for %s, but got nil", text)
+	}
+	if err := graphics.Check(filepath.Join(t.TempDir(), "missing.png")); err == nil {
+		t.Errorf("expected error for a missing file, but got nil")
+	}
+}
@@ -0,0 +1,36 @@
+/*
+Copyright © 2023 Mikael Schultz <bitcanon@proton.me>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package graphics
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io"
+)
+
+// writeITerm2 writes the image file data with the inline images
+// protocol of iTerm2, drawn this many pixels wide
+func writeITerm2(out io.Writer, name string, data []byte, width int) error {
+	_, err := fmt.Fprintf(out, "\033]1337;File=name=%s;size=%d;width=%dpx;preserveAspectRatio=1;inline=1:%s\a\n",
+		base64.StdEncoding.EncodeToString([]byte(name)), len(data), width, base64.StdEncoding.EncodeToString(data))
+	return err
+}
@@ -0,0 +1,124 @@
+/*
+Copyright © 2023 Mikael Schultz <bitcanon@proton.me>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package graphics
+
+import (
+	"bufio"
+	"fmt"
+	"image"
+	"image/color/palette"
+	"io"
+
+	"golang.org/x/image/draw"
+)
+
+// writeSixel writes the image as Sixel graphics, dithered to the
+// 256 colors of the Plan 9 palette. Transparent pixels are left as
+// the background of the terminal
+func writeSixel(out io.Writer, img image.Image) error {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	paletted := image.NewPaletted(image.Rect(0, 0, width, height), palette.Plan9)
+	draw.FloydSteinberg.Draw(paletted, paletted.Bounds(), img, bounds.Min)
+
+	opaque := make([]bool, width*height)
+	used := make([]bool, len(palette.Plan9))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			if _, _, _, a := img.At(bounds.Min.X+x, bounds.Min.Y+y).RGBA(); a >= 0x8000 {
+				opaque[y*width+x] = true
+				used[paletted.ColorIndexAt(x, y)] = true
+			}
+		}
+	}
+
+	w := bufio.NewWriter(out)
+	fmt.Fprintf(w, "\033P0;1q\"1;1;%d;%d", width, height)
+	for i, c := range palette.Plan9 {
+		if used[i] {
+			r, g, b, _ := c.RGBA()
+			fmt.Fprintf(w, "#%d;2;%d;%d;%d", i, r*100/0xffff, g*100/0xffff, b*100/0xffff)
+		}
+	}
+
+	// Each band of six rows is drawn once for every color in it
+	sixels := make([]byte, width)
+	for top := 0; top < height; top += 6 {
+		bottom := min(top+6, height)
+		present := make([]bool, len(palette.Plan9))
+		for y := top; y < bottom; y++ {
+			for x := 0; x < width; x++ {
+				if opaque[y*width+x] {
+					present[paletted.ColorIndexAt(x, y)] = true
+				}
+			}
+		}
+
+		first := true
+		for i := range present {
+			if !present[i] {
+				continue
+			}
+			for x := range sixels {
+				bits := byte(0)
+				for y := top; y < bottom; y++ {
+					if opaque[y*width+x] && int(paletted.ColorIndexAt(x, y)) == i {
+						bits |= 1 << (y - top)
+					}
+				}
+				sixels[x] = '?' + bits
+			}
+			if !first {
+				w.WriteByte('$')
+			}
+			first = false
+			fmt.Fprintf(w, "#%d", i)
+			writeRuns(w, sixels)
+		}
+		w.WriteByte('-')
+	}
+	w.WriteString("\033\\\n")
+	return w.Flush()
+}
+
+// writeRuns writes the sixels of a row, the repeated ones with
+// run-length encoding, leaving out the empty ones at the end
+func writeRuns(w *bufio.Writer, sixels []byte) {
+	end := len(sixels)
+	for end > 0 && sixels[end-1] == '?' {
+		end--
+	}
+	for start := 0; start < end; {
+		n := 1
+		for start+n < end && sixels[start+n] == sixels[start] {
+			n++
+		}
+		if n > 3 {
+			fmt.Fprintf(w, "!%d%c", n, sixels[start])
+		} else {
+			for j := 0; j < n; j++ {
+				w.WriteByte(sixels[start])
+			}
+		}
+		start += n
+	}
+}
@@ -0,0 +1,195 @@
+/*
+Copyright © 2023 Mikael Schultz <bitcanon@proton.me>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package hooks
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/dop251/goja"
+)
+
+// Step describes the step of the scenario passed to the hooks. The
+// delays and the duration of the command are in milliseconds
+type Step struct {
+	Number    int    `json:"number"`
+	Command   string `json:"command"`
+	CharDelay int    `json:"charDelay"`
+	PreDelay  int    `json:"preDelay"`
+	PostDelay int    `json:"postDelay"`
+	Duration  int    `json:"duration"`
+}
+
+// Change holds the changes to the step returned by a hook. A nil
+// delay is left unchanged, and a skipped step is not played
+type Change struct {
+	CharDelay *int
+	PreDelay  *int
+	PostDelay *int
+	Skip      bool
+}
+
+// Hooks runs the JavaScript hooks called during the playback:
+//
+//	onStepStart(step)         before the command is typed, may return
+//	                          {charDelay, preDelay, postDelay, skip}
+//	onOutputLine(line, step)  for each line of output, may return
+//	                          a new line, or null to drop it
+//	onStepEnd(step)           after the output, may return {postDelay}
+//
+// The hooks that are not defined are not called
+type Hooks struct {
+	mu  sync.Mutex
+	vm  *goja.Runtime
+	fns map[string]goja.Callable
+}
+
+// Load reads the hooks from a JavaScript file
+func Load(filename string) (*Hooks, error) {
+	source, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	return New(string(source), filename)
+}
+
+// New evaluates the JavaScript source defining the hooks. The name
+// is used in the error messages
+func New(source, name string) (*Hooks, error) {
+	vm := goja.New()
+	vm.SetFieldNameMapper(goja.TagFieldNameMapper("json", true))
+
+	// Log the messages of the scripts, which must not
+	// print anything on the screen of the demo
+	console := vm.NewObject()
+	console.Set("log", func(call goja.FunctionCall) goja.Value {
+		args := make([]string, len(call.Arguments))
+		for i, arg := range call.Arguments {
+			args[i] = arg.String()
+		}
+		slog.Info("hook: "+strings.Join(args, " "), "script", name)
+		return goja.Undefined()
+	})
+	vm.Set("console", console)
+
+	if _, err := vm.RunScript(name, source); err != nil {
+		return nil, err
+	}
+
+	h := &Hooks{vm: vm, fns: make(map[string]goja.Callable)}
+	for _, hook := range []string{"onStepStart", "onOutputLine", "onStepEnd"} {
+		value := vm.Get(hook)
+		if value == nil || goja.IsUndefined(value) {
+			continue
+		}
+		fn, ok := goja.AssertFunction(value)
+		if !ok {
+			return nil, fmt.Errorf("%s: %s is not a function", name, hook)
+		}
+		h.fns[hook] = fn
+	}
+	return h, nil
+}
+
+// FiltersOutput reports whether the output lines are passed to a hook
+func (h *Hooks) FiltersOutput() bool {
+	return h.fns["onOutputLine"] != nil
+}
+
+// StepStart calls onStepStart before the command of the step is typed
+func (h *Hooks) StepStart(step Step) (Change, error) {
+	return h.change("onStepStart", step)
+}
+
+// StepEnd calls onStepEnd after the output of the step is printed,
+// with the duration of the command
+func (h *Hooks) StepEnd(step Step) (Change, error) {
+	return h.change("onStepEnd", step)
+}
+
+// OutputLine calls onOutputLine for a line of output, without the
+// line ending. It returns the line to print, which is unchanged if
+// the hook returns nothing, and false if the line is dropped
+func (h *Hooks) OutputLine(step Step, line string) (string, bool, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	fn := h.fns["onOutputLine"]
+	if fn == nil {
+		return line, true, nil
+	}
+	result, err := fn(goja.Undefined(), h.vm.ToValue(line), h.vm.ToValue(step))
+	switch {
+	case err != nil:
+		return line, true, fmt.Errorf("onOutputLine: %w", err)
+	case goja.IsUndefined(result):
+		return line, true, nil
+	case goja.IsNull(result):
+		return "", false, nil
+	default:
+		return result.String(), true, nil
+	}
+}
+
+// change calls the hook and reads the changes to the step it returns
+func (h *Hooks) change(hook string, step Step) (Change, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	fn := h.fns[hook]
+	if fn == nil {
+		return Change{}, nil
+	}
+	result, err := fn(goja.Undefined(), h.vm.ToValue(step))
+	if err != nil {
+		return Change{}, fmt.Errorf("%s: %w", hook, err)
+	}
+	if goja.IsUndefined(result) || goja.IsNull(result) {
+		return Change{}, nil
+	}
+	obj := result.ToObject(h.vm)
+
+	var change Change
+	change.CharDelay = h.delay(obj, "charDelay")
+	change.PreDelay = h.delay(obj, "preDelay")
+	change.PostDelay = h.delay(obj, "postDelay")
+	if skip := obj.Get("skip"); skip != nil {
+		change.Skip = skip.ToBoolean()
+	}
+	return change, nil
+}
+
+// delay returns the delay set in the object, or nil if it is not set
+func (h *Hooks) delay(obj *goja.Object, name string) *int {
+	value := obj.Get(name)
+	if value == nil || goja.IsUndefined(value) || goja.IsNull(value) {
+		return nil
+	}
+	ms := int(value.ToInteger())
+	if ms < 0 {
+		ms = 0
+	}
+	return &ms
+}
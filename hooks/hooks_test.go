@@ -0,0 +1,141 @@
+package hooks_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bitcanon/autotyper/hooks"
+)
+
+// TestOutputLine tests that the lines returned by the output hook
+// replace the lines, and that null drops them
+func TestOutputLine(t *testing.T) {
+	h, err := hooks.New(`
+		function onOutputLine(line, step) {
+			if (line.startsWith("debug")) return null;
+			if (line === "secret") return "*".repeat(line.length);
+			if (step.number === 2) return step.command + ": " + line;
+		}`, "hooks.js")
+	if err != nil {
+		t.Fatalf("expected no error, but got %v", err)
+	}
+	if !h.FiltersOutput() {
+		t.Fatalf("expected the hooks to filter the output")
+	}
+
+	tests := []struct {
+		line     string
+		step     hooks.Step
+		expected string
+		keep     bool
+	}{
+		{line: "hello", step: hooks.Step{Number: 1}, expected: "hello", keep: true},
+		{line: "secret", step: hooks.Step{Number: 1}, expected: "******", keep: true},
+		{line: "debug: x=1", step: hooks.Step{Number: 1}, keep: false},
+		{line: "hello", step: hooks.Step{Number: 2, Command: "echo"}, expected: "echo: hello", keep: true},
+	}
+
+	for _, test := range tests {
+		line, keep, err := h.OutputLine(test.step, test.line)
+		if err != nil {
+			t.Fatalf("%q: expected no error, but got %v", test.line, err)
+		}
+		if line != test.expected || keep != test.keep {
+			t.Errorf("%q: expected %q (%v), but got %q (%v)", test.line, test.expected, test.keep, line, keep)
+		}
+	}
+}
+
+// TestStepStart tests that the changes returned by the hook run
+// before a step are read, and that nothing is changed otherwise
+func TestStepStart(t *testing.T) {
+	h, err := hooks.New(`
+		function onStepStart(step) {
+			if (step.command.startsWith("rm")) return {skip: true};
+			if (step.command === "ls") return {charDelay: step.charDelay * 2, preDelay: 0};
+		}
+		function onStepEnd(step) {
+			return {postDelay: step.duration > 1000 ? 0 : step.postDelay};
+		}`, "hooks.js")
+	if err != nil {
+		t.Fatalf("expected no error, but got %v", err)
+	}
+
+	change, err := h.StepStart(hooks.Step{Command: "ls", CharDelay: 50, PreDelay: 500})
+	if err != nil {
+		t.Fatalf("expected no error, but got %v", err)
+	}
+	if change.CharDelay == nil || *change.CharDelay != 100 || change.PreDelay == nil || *change.PreDelay != 0 {
+		t.Errorf("expected the delays to be changed, but got %+v", change)
+	}
+	if change.PostDelay != nil || change.Skip {
+		t.Errorf("expected the delay after the step to be unchanged, but got %+v", change)
+	}
+
+	if change, _ := h.StepStart(hooks.Step{Command: "rm -rf build"}); !change.Skip {
+		t.Errorf("expected the step to be skipped")
+	}
+	if change, _ := h.StepStart(hooks.Step{Command: "pwd"}); change != (hooks.Change{}) {
+		t.Errorf("expected no change, but got %+v", change)
+	}
+
+	change, err = h.StepEnd(hooks.Step{Duration: 2000, PostDelay: 800})
+	if err != nil {
+		t.Fatalf("expected no error, but got %v", err)
+	}
+	if change.PostDelay == nil || *change.PostDelay != 0 {
+		t.Errorf("expected no delay after a slow command, but got %+v", change)
+	}
+	if h.FiltersOutput() {
+		t.Errorf("expected the hooks not to filter the output")
+	}
+}
+
+// TestHookErrors tests that invalid scripts and failing hooks
+// return errors
+func TestHookErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+	}{
+		{name: "SyntaxError", source: "function onStepStart( {"},
+		{name: "NotAFunction", source: "var onOutputLine = 42;"},
+		{name: "ThrownError", source: "throw new Error('boom');"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := hooks.New(test.source, "hooks.js"); err == nil {
+				t.Errorf("expected error, but got nil")
+			}
+		})
+	}
+
+	h, err := hooks.New("function onStepEnd(step) { throw new Error('boom'); }", "hooks.js")
+	if err != nil {
+		t.Fatalf("expected no error, but got %v", err)
+	}
+	if _, err := h.StepEnd(hooks.Step{}); err == nil {
+		t.Errorf("expected the error of the hook, but got nil")
+	}
+}
+
+// TestLoad tests that the hooks are read from a file
+func TestLoad(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "hooks.js")
+	if err := os.WriteFile(filename, []byte("function onOutputLine(line) { return line.toUpperCase(); }"), 0o644); err != nil {
+		t.Fatalf("failed to write hooks: %v", err)
+	}
+	h, err := hooks.Load(filename)
+	if err != nil {
+		t.Fatalf("expected no error, but got %v", err)
+	}
+	if line, _, _ := h.OutputLine(hooks.Step{}, "ok"); line != "OK" {
+		t.Errorf("expected %q, but got %q", "OK", line)
+	}
+
+	if _, err := hooks.Load(filepath.Join(t.TempDir(), "missing.js")); err == nil {
+		t.Errorf("expected error for a missing file, but got nil")
+	}
+}
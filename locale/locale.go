@@ -0,0 +1,127 @@
+/*
+Copyright © 2023 Mikael Schultz <bitcanon@proton.me>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package locale
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+	"golang.org/x/text/message/catalog"
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	// builder holds the translations of the messages, by language
+	builder = catalog.NewBuilder(catalog.Fallback(language.English))
+
+	// tag is the selected language, and printer formats
+	// the messages in it
+	tag     = language.English
+	printer = message.NewPrinter(language.English, message.Catalog(builder))
+)
+
+// init adds the built-in translations to the catalog
+func init() {
+	for lang, messages := range translations {
+		add(language.MustParse(lang), messages)
+	}
+}
+
+// add adds the translations of the messages, keyed by the English
+// messages, to the catalog in the language
+func add(t language.Tag, messages map[string]string) {
+	for key, msg := range messages {
+		builder.SetString(t, key, msg)
+	}
+}
+
+// Set selects the language of the messages (e.g. "de", "sv-SE" or
+// "sv_SE.UTF-8"), or the language of the environment if lang is empty.
+// The messages not translated to the language are printed in English
+func Set(lang string) error {
+	if lang == "" {
+		lang = Detect()
+	}
+	t, err := language.Parse(normalize(lang))
+	if err != nil {
+		return fmt.Errorf("invalid language %q: %w", lang, err)
+	}
+	tag = t
+	printer = message.NewPrinter(tag, message.Catalog(builder))
+	return nil
+}
+
+// Language returns the selected language
+func Language() string {
+	return tag.String()
+}
+
+// Detect returns the language of the environment, from the LC_ALL,
+// LC_MESSAGES or LANG variables (e.g. "de_DE.UTF-8" is "de-DE"), or
+// English if none is set
+func Detect() string {
+	for _, name := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		if value := os.Getenv(name); value != "" {
+			return normalize(value)
+		}
+	}
+	return "en"
+}
+
+// normalize returns the language of a POSIX locale (e.g. "de-DE" for
+// "de_DE.UTF-8"), which is English for the "C" and "POSIX" locales
+func normalize(lang string) string {
+	// Drop the encoding and the modifier (e.g. ".UTF-8", "@euro")
+	lang, _, _ = strings.Cut(lang, ".")
+	lang, _, _ = strings.Cut(lang, "@")
+	if lang == "C" || lang == "POSIX" {
+		return "en"
+	}
+	return strings.ReplaceAll(lang, "_", "-")
+}
+
+// Load adds the translations to the selected language from a YAML
+// file mapping the English messages to their translations, for
+// languages without built-in translations or to change them
+func Load(filename string) error {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+	var messages map[string]string
+	if err := yaml.Unmarshal(data, &messages); err != nil {
+		return fmt.Errorf("%s: %w", filename, err)
+	}
+	add(tag, messages)
+	printer = message.NewPrinter(tag, message.Catalog(builder))
+	return nil
+}
+
+// T returns the message in the selected language, formatted with the
+// arguments like fmt.Sprintf. Messages without a translation are
+// returned in English
+func T(msg string, args ...any) string {
+	return printer.Sprintf(msg, args...)
+}
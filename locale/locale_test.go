@@ -0,0 +1,92 @@
+package locale_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bitcanon/autotyper/locale"
+)
+
+// TestT tests that the messages are translated to the selected
+// language, and printed in English without a translation
+func TestT(t *testing.T) {
+	defer locale.Set("en")
+	tests := []struct {
+		lang     string
+		expected string
+	}{
+		{lang: "en", expected: "Not executing dangerous command: rm -rf /"},
+		{lang: "de", expected: "Gefährlicher Befehl wird nicht ausgeführt: rm -rf /"},
+		{lang: "sv-SE", expected: "Kör inte det farliga kommandot: rm -rf /"},
+		{lang: "fr-CA", expected: "Commande dangereuse non exécutée : rm -rf /"},
+		{lang: "es_ES.UTF-8", expected: "No se ejecuta el comando peligroso: rm -rf /"},
+		{lang: "C", expected: "Not executing dangerous command: rm -rf /"},
+		{lang: "ja", expected: "Not executing dangerous command: rm -rf /"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.lang, func(t *testing.T) {
+			if err := locale.Set(test.lang); err != nil {
+				t.Fatalf("expected no error, but got %v", err)
+			}
+			if msg := locale.T("Not executing dangerous command: %s", "rm -rf /"); msg != test.expected {
+				t.Errorf("expected %q, but got %q", test.expected, msg)
+			}
+		})
+	}
+
+	if err := locale.Set("not a language"); err == nil {
+		t.Errorf("expected error for an invalid language, but got nil")
+	}
+}
+
+// TestDetect tests that the language is read from the environment
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		all, messages, lang string
+		expected            string
+	}{
+		{lang: "de_DE.UTF-8", expected: "de-DE"},
+		{messages: "sv_SE", lang: "de_DE.UTF-8", expected: "sv-SE"},
+		{all: "fr_FR@euro", lang: "de_DE", expected: "fr-FR"},
+		{lang: "C.UTF-8", expected: "en"},
+		{expected: "en"},
+	}
+
+	for _, test := range tests {
+		t.Setenv("LC_ALL", test.all)
+		t.Setenv("LC_MESSAGES", test.messages)
+		t.Setenv("LANG", test.lang)
+		if lang := locale.Detect(); lang != test.expected {
+			t.Errorf("%+v: expected %q, but got %q", test, test.expected, lang)
+		}
+	}
+}
+
+// TestLoad tests that translations are loaded for the selected
+// language from a file
+func TestLoad(t *testing.T) {
+	defer locale.Set("en")
+	filename := filepath.Join(t.TempDir(), "nl.yaml")
+	content := "\"Scripts in %s\": \"Scripts in %s (NL)\"\n\"No scripts found\": \"Geen scripts gevonden\"\n"
+	if err := os.WriteFile(filename, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write the file: %v", err)
+	}
+
+	if err := locale.Set("nl"); err != nil {
+		t.Fatalf("expected no error, but got %v", err)
+	}
+	if err := locale.Load(filename); err != nil {
+		t.Fatalf("expected no error, but got %v", err)
+	}
+	if msg := locale.T("No scripts found"); msg != "Geen scripts gevonden" {
+		t.Errorf("expected %q, but got %q", "Geen scripts gevonden", msg)
+	}
+	if msg := locale.T("Scripts in %s", "demos"); msg != "Scripts in demos (NL)" {
+		t.Errorf("expected %q, but got %q", "Scripts in demos (NL)", msg)
+	}
+	if err := locale.Load(filepath.Join(t.TempDir(), "missing.yaml")); err == nil {
+		t.Errorf("expected error for a missing file, but got nil")
+	}
+}
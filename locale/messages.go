@@ -0,0 +1,235 @@
+/*
+Copyright © 2023 Mikael Schultz <bitcanon@proton.me>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package locale
+
+// translations are the built-in translations of the messages, by
+// language, keyed by the English messages
+var translations = map[string]map[string]string{
+	"de": {
+		"Please enter the input text. Press %s to finish.": "Bitte den Eingabetext eingeben. Zum Beenden %s drücken.",
+		"Waiting for the notes to be read from %s":         "Warte darauf, dass die Notizen aus %s gelesen werden",
+		"[y/N]": "[j/N]",
+		"y":     "j",
+
+		"Really execute this command?":        "Diesen Befehl wirklich ausführen?",
+		"Not executing dangerous command: %s": "Gefährlicher Befehl wird nicht ausgeführt: %s",
+		"Error: %v":                           "Fehler: %v",
+		"error: %v":                           "Fehler: %v",
+		"Using config file: %s":               "Konfigurationsdatei: %s",
+		"Wrote %s (%d steps, %d files)":       "%s geschrieben (%d Schritte, %d Dateien)",
+		"Recording %s":                        "Aufnahme von %s",
+		"Error: no simulator for %q":          "Fehler: kein Simulator für %q",
+		"Go to step %s?":                      "Zu Schritt %s gehen?",
+		"Signed %s":                           "%s signiert",
+		"Agent listening on %s":               "Agent lauscht auf %s",
+		"Agent listening for gRPC on %s":      "Agent lauscht für gRPC auf %s",
+		"Scripts in %s":                       "Skripte in %s",
+		"No scripts found":                    "Keine Skripte gefunden",
+		"step %d/%d":                          "Schritt %d/%d",
+		"Step %d of %d":                       "Schritt %d von %d",
+		"%s elapsed":                          "%s vergangen",
+		"%s left of %s":                       "%s übrig von %s",
+		"%s over %s":                          "%s über %s",
+		"↑/↓ select  enter present  q quit":   "↑/↓ auswählen  Enter vorführen  q beenden",
+		"space play/pause  n step  ←/→ seek  esc back  q quit": "Leertaste Start/Pause  n Schritt  ←/→ springen  Esc zurück  q beenden",
+
+		"Wrote the secret key to %s and the public key to %s":                         "Geheimer Schlüssel in %s und öffentlicher Schlüssel in %s geschrieben",
+		"Trust the key with --trusted-key %s":                                         "Dem Schlüssel mit --trusted-key %s vertrauen",
+		"Run this command in a new terminal of VS Code (Terminal > New Terminal):":    "Diesen Befehl in einem neuen Terminal von VS Code ausführen (Terminal > Neues Terminal):",
+		"Run this command in a new %s terminal of VS Code (Terminal > New Terminal):": "Diesen Befehl in einem neuen %s-Terminal von VS Code ausführen (Terminal > Neues Terminal):",
+		"Run this command in the terminal of VS Code (Terminal > New Terminal):":      "Diesen Befehl im Terminal von VS Code ausführen (Terminal > Neues Terminal):",
+
+		"idle":     "bereit",
+		"playing":  "läuft",
+		"paused":   "pausiert",
+		"finished": "beendet",
+
+		"STEP":      "SCHRITT",
+		"TYPING":    "TIPPEN",
+		"EXECUTION": "AUSFÜHRUNG",
+		"PAUSES":    "PAUSEN",
+		"TOTAL":     "GESAMT",
+		"COMMAND":   "BEFEHL",
+		"total":     "gesamt",
+
+		"NAME":        "NAME",
+		"DURATION":    "DAUER",
+		"TAGS":        "TAGS",
+		"DESCRIPTION": "BESCHREIBUNG",
+	},
+	"es": {
+		"Please enter the input text. Press %s to finish.": "Introduzca el texto de entrada. Pulse %s para terminar.",
+		"Waiting for the notes to be read from %s":         "Esperando a que se lean las notas de %s",
+		"[y/N]": "[s/N]",
+		"y":     "s",
+
+		"Really execute this command?":        "¿Ejecutar realmente este comando?",
+		"Not executing dangerous command: %s": "No se ejecuta el comando peligroso: %s",
+		"Error: %v":                           "Error: %v",
+		"error: %v":                           "error: %v",
+		"Using config file: %s":               "Archivo de configuración: %s",
+		"Wrote %s (%d steps, %d files)":       "%s escrito (%d pasos, %d archivos)",
+		"Recording %s":                        "Grabando %s",
+		"Error: no simulator for %q":          "Error: no hay simulador para %q",
+		"Go to step %s?":                      "¿Ir al paso %s?",
+		"Signed %s":                           "%s firmado",
+		"Agent listening on %s":               "Agente escuchando en %s",
+		"Agent listening for gRPC on %s":      "Agente escuchando gRPC en %s",
+		"Scripts in %s":                       "Scripts en %s",
+		"No scripts found":                    "No se encontraron scripts",
+		"step %d/%d":                          "paso %d/%d",
+		"Step %d of %d":                       "Paso %d de %d",
+		"%s elapsed":                          "%s transcurrido",
+		"%s left of %s":                       "quedan %s de %s",
+		"%s over %s":                          "%s pasado de %s",
+		"↑/↓ select  enter present  q quit":   "↑/↓ elegir  intro presentar  q salir",
+		"space play/pause  n step  ←/→ seek  esc back  q quit": "espacio reproducir/pausa  n paso  ←/→ saltar  esc volver  q salir",
+
+		"Wrote the secret key to %s and the public key to %s":                         "Clave secreta escrita en %s y clave pública en %s",
+		"Trust the key with --trusted-key %s":                                         "Confíe en la clave con --trusted-key %s",
+		"Run this command in a new terminal of VS Code (Terminal > New Terminal):":    "Ejecute este comando en un terminal nuevo de VS Code (Terminal > Nuevo terminal):",
+		"Run this command in a new %s terminal of VS Code (Terminal > New Terminal):": "Ejecute este comando en un terminal %s nuevo de VS Code (Terminal > Nuevo terminal):",
+		"Run this command in the terminal of VS Code (Terminal > New Terminal):":      "Ejecute este comando en el terminal de VS Code (Terminal > Nuevo terminal):",
+
+		"idle":     "inactivo",
+		"playing":  "reproduciendo",
+		"paused":   "en pausa",
+		"finished": "terminado",
+
+		"STEP":      "PASO",
+		"TYPING":    "ESCRITURA",
+		"EXECUTION": "EJECUCIÓN",
+		"PAUSES":    "PAUSAS",
+		"TOTAL":     "TOTAL",
+		"COMMAND":   "COMANDO",
+		"total":     "total",
+
+		"NAME":        "NOMBRE",
+		"DURATION":    "DURACIÓN",
+		"TAGS":        "ETIQUETAS",
+		"DESCRIPTION": "DESCRIPCIÓN",
+	},
+	"fr": {
+		"Please enter the input text. Press %s to finish.": "Veuillez saisir le texte. Appuyez sur %s pour terminer.",
+		"Waiting for the notes to be read from %s":         "En attente de la lecture des notes depuis %s",
+		"[y/N]": "[o/N]",
+		"y":     "o",
+
+		"Really execute this command?":        "Exécuter vraiment cette commande ?",
+		"Not executing dangerous command: %s": "Commande dangereuse non exécutée : %s",
+		"Error: %v":                           "Erreur : %v",
+		"error: %v":                           "erreur : %v",
+		"Using config file: %s":               "Fichier de configuration : %s",
+		"Wrote %s (%d steps, %d files)":       "%s écrit (%d étapes, %d fichiers)",
+		"Recording %s":                        "Enregistrement de %s",
+		"Error: no simulator for %q":          "Erreur : aucun simulateur pour %q",
+		"Go to step %s?":                      "Aller à l'étape %s ?",
+		"Signed %s":                           "%s signé",
+		"Agent listening on %s":               "Agent à l'écoute sur %s",
+		"Agent listening for gRPC on %s":      "Agent à l'écoute pour gRPC sur %s",
+		"Scripts in %s":                       "Scripts dans %s",
+		"No scripts found":                    "Aucun script trouvé",
+		"step %d/%d":                          "étape %d/%d",
+		"Step %d of %d":                       "Étape %d sur %d",
+		"%s elapsed":                          "%s écoulé",
+		"%s left of %s":                       "%s restant sur %s",
+		"%s over %s":                          "%s de dépassement sur %s",
+		"↑/↓ select  enter present  q quit":   "↑/↓ choisir  entrée présenter  q quitter",
+		"space play/pause  n step  ←/→ seek  esc back  q quit": "espace lecture/pause  n étape  ←/→ avancer  échap retour  q quitter",
+
+		"Wrote the secret key to %s and the public key to %s":                         "Clé secrète écrite dans %s et clé publique dans %s",
+		"Trust the key with --trusted-key %s":                                         "Faites confiance à la clé avec --trusted-key %s",
+		"Run this command in a new terminal of VS Code (Terminal > New Terminal):":    "Exécutez cette commande dans un nouveau terminal de VS Code (Terminal > Nouveau terminal) :",
+		"Run this command in a new %s terminal of VS Code (Terminal > New Terminal):": "Exécutez cette commande dans un nouveau terminal %s de VS Code (Terminal > Nouveau terminal) :",
+		"Run this command in the terminal of VS Code (Terminal > New Terminal):":      "Exécutez cette commande dans le terminal de VS Code (Terminal > Nouveau terminal) :",
+
+		"idle":     "prêt",
+		"playing":  "en cours",
+		"paused":   "en pause",
+		"finished": "terminé",
+
+		"STEP":      "ÉTAPE",
+		"TYPING":    "SAISIE",
+		"EXECUTION": "EXÉCUTION",
+		"PAUSES":    "PAUSES",
+		"TOTAL":     "TOTAL",
+		"COMMAND":   "COMMANDE",
+		"total":     "total",
+
+		"NAME":        "NOM",
+		"DURATION":    "DURÉE",
+		"TAGS":        "TAGS",
+		"DESCRIPTION": "DESCRIPTION",
+	},
+	"sv": {
+		"Please enter the input text. Press %s to finish.": "Skriv in texten. Tryck %s för att avsluta.",
+		"Waiting for the notes to be read from %s":         "Väntar på att anteckningarna läses från %s",
+		"[y/N]": "[j/N]",
+		"y":     "j",
+
+		"Really execute this command?":        "Vill du verkligen köra kommandot?",
+		"Not executing dangerous command: %s": "Kör inte det farliga kommandot: %s",
+		"Error: %v":                           "Fel: %v",
+		"error: %v":                           "fel: %v",
+		"Using config file: %s":               "Använder konfigurationsfilen: %s",
+		"Wrote %s (%d steps, %d files)":       "Skrev %s (%d steg, %d filer)",
+		"Recording %s":                        "Spelar in %s",
+		"Error: no simulator for %q":          "Fel: ingen simulator för %q",
+		"Go to step %s?":                      "Gå till steg %s?",
+		"Signed %s":                           "Signerade %s",
+		"Agent listening on %s":               "Agenten lyssnar på %s",
+		"Agent listening for gRPC on %s":      "Agenten lyssnar efter gRPC på %s",
+		"Scripts in %s":                       "Skript i %s",
+		"No scripts found":                    "Inga skript hittades",
+		"step %d/%d":                          "steg %d/%d",
+		"Step %d of %d":                       "Steg %d av %d",
+		"%s elapsed":                          "%s förflutet",
+		"%s left of %s":                       "%s kvar av %s",
+		"%s over %s":                          "%s över %s",
+		"↑/↓ select  enter present  q quit":   "↑/↓ välj  enter visa  q avsluta",
+		"space play/pause  n step  ←/→ seek  esc back  q quit": "mellanslag spela/pausa  n steg  ←/→ spola  esc tillbaka  q avsluta",
+
+		"Wrote the secret key to %s and the public key to %s":                         "Skrev den hemliga nyckeln till %s och den offentliga nyckeln till %s",
+		"Trust the key with --trusted-key %s":                                         "Lita på nyckeln med --trusted-key %s",
+		"Run this command in a new terminal of VS Code (Terminal > New Terminal):":    "Kör det här kommandot i en ny terminal i VS Code (Terminal > New Terminal):",
+		"Run this command in a new %s terminal of VS Code (Terminal > New Terminal):": "Kör det här kommandot i en ny %s-terminal i VS Code (Terminal > New Terminal):",
+		"Run this command in the terminal of VS Code (Terminal > New Terminal):":      "Kör det här kommandot i terminalen i VS Code (Terminal > New Terminal):",
+
+		"idle":     "redo",
+		"playing":  "spelar",
+		"paused":   "pausad",
+		"finished": "klar",
+
+		"STEP":      "STEG",
+		"TYPING":    "SKRIVNING",
+		"EXECUTION": "KÖRNING",
+		"PAUSES":    "PAUSER",
+		"TOTAL":     "TOTALT",
+		"COMMAND":   "KOMMANDO",
+		"total":     "totalt",
+
+		"NAME":        "NAMN",
+		"DURATION":    "LÄNGD",
+		"TAGS":        "TAGGAR",
+		"DESCRIPTION": "BESKRIVNING",
+	},
+}
@@ -0,0 +1,243 @@
+/*
+Copyright © 2023 Mikael Schultz <bitcanon@proton.me>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package player
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"regexp"
+	"strings"
+
+	"github.com/bitcanon/autotyper/cli"
+	"github.com/bitcanon/autotyper/script"
+	"github.com/bitcanon/autotyper/vt"
+)
+
+// Define the defaults of the annotations
+const (
+	annotationStyle    = "bright-yellow-bold"
+	annotationDuration = 3000
+
+	// The width of the layout of the output if the width
+	// of the terminal is unknown, wide enough to never wrap
+	unknownWidth = 1000
+)
+
+// annotation draws the marks of an annotation over the output on the
+// terminal, laid out on a screen. The cursor of the terminal is at
+// the cursor of the screen, where it is moved back after each drawing
+type annotation struct {
+	out      io.Writer
+	screen   *vt.Screen
+	col, row int
+	width    int
+}
+
+// annotate draws the annotations of the step one after the other on
+// the recorded command line and output, the output starting at the
+// offset. Each mark is shown for its duration, and then erased by
+// drawing the output again
+func (p *Player) annotate(ctx context.Context, annotations []script.Annotation, recorded []byte, offset int) error {
+	// Lay the output out like the terminal does,
+	// to find the rows of the lines of output
+	width := p.width()
+	if width <= 0 {
+		width = unknownWidth
+	}
+	screen := vt.NewScreen(width, bytes.Count(recorded, []byte("\n"))+len(recorded)/width+2)
+	screen.Write(recorded[:offset])
+	_, first := screen.Cursor()
+	screen.Write(recorded[offset:])
+	col, row := screen.Cursor()
+	end := row
+	if col > 0 {
+		end++
+	}
+
+	a := annotation{out: p.line.aside(), screen: screen, col: col, row: row, width: p.width()}
+	for _, note := range annotations {
+		target, ok := findRow(screen, first, end, note)
+		if !ok {
+			slog.Debug("no line to annotate", "mark", note.Mark, "match", note.Match, "line", note.Line)
+			continue
+		}
+
+		// The style is validated with the scenario
+		style, _ := cli.ParseStyle(annotationStyle)
+		if note.Style != "" {
+			style, _ = cli.ParseStyle(note.Style)
+		}
+
+		delay := p.annotationDelay(note)
+		slog.Debug("annotating output", "mark", note.Mark, "line", target-first+1, "delay", delay)
+		rows := a.draw(note.Mark, target, note.Text, style.Sequence())
+		err := p.sleep(ctx, delay)
+		a.restore(rows)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// annotationDelay returns the time the annotation is shown in
+// milliseconds, scaled like the other delays
+func (p *Player) annotationDelay(a script.Annotation) int {
+	if a.Duration == 0 {
+		return scale(annotationDuration, p.delayScale)
+	}
+	return scale(a.Duration, p.delayScale)
+}
+
+// findRow returns the row of the line of output annotated, in the
+// rows of output from first to end (excluded)
+func findRow(screen *vt.Screen, first, end int, a script.Annotation) (int, bool) {
+	if a.Match == "" {
+		row := first + a.Line - 1
+		if a.Line < 0 {
+			row = end + a.Line
+		}
+		return row, row >= first && row < end
+	}
+
+	re, err := regexp.Compile(a.Match)
+	if err != nil {
+		return 0, false
+	}
+	for row := first; row < end; row++ {
+		if re.MatchString(screen.Line(row)) {
+			return row, true
+		}
+	}
+	return 0, false
+}
+
+// draw draws the mark on the row, with the text next to it, and
+// returns the rows drawn over
+func (a annotation) draw(mark string, row int, text, style string) []int {
+	const reset = "\033[0m"
+	cells := trimCells(a.screen.Cells(row))
+	w := len(cells)
+	if text != "" {
+		text = " " + text
+	}
+
+	// Draw a box only if it fits, and an underline otherwise
+	if mark == script.MarkBox && a.width > 0 && w+4 > a.width {
+		mark = script.MarkUnderline
+	}
+
+	var b strings.Builder
+	var rows []int
+	switch mark {
+	case script.MarkBox:
+		border := strings.Repeat("─", w+2)
+		b.WriteString(a.moveTo(row-1) + style + "┌" + border + "┐" + reset + a.moveBack(row-1))
+		b.WriteString(a.moveTo(row) + style + "│" + reset + " " + vt.Render(cells) + reset + " " + style + "│" + a.fit(text, w+4) + reset + a.moveBack(row))
+		rows = append(rows, row-1, row)
+		if row+1 <= a.row {
+			b.WriteString(a.moveTo(row+1) + style + "└" + border + "┘" + reset + a.moveBack(row+1))
+			rows = append(rows, row+1)
+		}
+	case script.MarkUnderline:
+		b.WriteString(a.moveTo(row) + style + "\033[4m" + a.screen.Line(row) + "\033[24m" + a.fit(text, w) + reset + a.moveBack(row))
+		rows = append(rows, row)
+	case script.MarkArrow:
+		b.WriteString(a.moveTo(row) + vt.Render(cells) + reset + style + a.fit(" ◀──"+text, w) + reset + a.moveBack(row))
+		rows = append(rows, row)
+	}
+	io.WriteString(a.out, b.String())
+	return rows
+}
+
+// restore draws the rows of the output again, erasing the marks
+func (a annotation) restore(rows []int) {
+	var b strings.Builder
+	for _, row := range rows {
+		b.WriteString(a.moveTo(row) + vt.Render(trimCells(a.screen.Cells(row))) + "\033[0m\033[K" + a.moveBack(row))
+	}
+	io.WriteString(a.out, b.String())
+}
+
+// moveTo returns the escape sequences moving the cursor from the
+// cursor of the screen to the first column of the row
+func (a annotation) moveTo(row int) string {
+	switch up := a.row - row; {
+	case up > 0:
+		return fmt.Sprintf("\033[%dA\r", up)
+	case up < 0:
+		return fmt.Sprintf("\033[%dB\r", -up)
+	default:
+		return "\r"
+	}
+}
+
+// moveBack returns the escape sequences moving the cursor from
+// the row back to the cursor of the screen
+func (a annotation) moveBack(row int) string {
+	seq := ""
+	switch down := a.row - row; {
+	case down > 0:
+		seq = fmt.Sprintf("\033[%dB", down)
+	case down < 0:
+		seq = fmt.Sprintf("\033[%dA", -down)
+	}
+	seq += "\r"
+	if a.col > 0 {
+		seq += fmt.Sprintf("\033[%dC", a.col)
+	}
+	return seq
+}
+
+// fit cuts the text written after the column so that it does not
+// wrap onto the next row, if the width of the terminal is known
+func (a annotation) fit(text string, col int) string {
+	if a.width <= 0 {
+		return text
+	}
+	room := a.width - col - 1
+	if room <= 0 {
+		return ""
+	}
+	if runes := []rune(text); len(runes) > room {
+		return string(runes[:room])
+	}
+	return text
+}
+
+// trimCells returns the cells of a row without the trailing blanks
+func trimCells(cells []vt.Cell) []vt.Cell {
+	n := 0
+	for i, c := range cells {
+		if c.Rune != ' ' && c.Rune != 0 || c.Style != "" {
+			n = i + 1
+		}
+	}
+	// Keep the continuation of a wide character
+	if n < len(cells) && cells[n].Rune == 0 {
+		n++
+	}
+	return cells[:n]
+}
@@ -0,0 +1,100 @@
+package player_test
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/bitcanon/autotyper/player"
+	"github.com/bitcanon/autotyper/script"
+	"github.com/bitcanon/autotyper/vt"
+)
+
+// screenRecorder lays the output out on a virtual screen, keeping
+// the text of the screen after each write
+type screenRecorder struct {
+	mu        sync.Mutex
+	screen    *vt.Screen
+	snapshots []string
+}
+
+func (r *screenRecorder) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.screen.Write(p)
+	r.snapshots = append(r.snapshots, r.screen.String())
+	return len(p), nil
+}
+
+// TestPlayerAnnotate tests that the marks of the annotations are
+// drawn on the lines of output, and erased afterwards
+func TestPlayerAnnotate(t *testing.T) {
+	output := "NAME    STATUS\nweb-1   Running\nweb-2   Pending\n"
+	tests := []struct {
+		name       string
+		annotation script.Annotation
+		expected   string
+	}{
+		{
+			name:       "Box",
+			annotation: script.Annotation{Mark: script.MarkBox, Match: "Pending", Text: "still starting", Duration: 1},
+			expected:   "C:\\> kubectl get pods\nNAME    STATUS\n┌─────────────────┐\n│ web-2   Pending │ still starting\n└─────────────────┘",
+		},
+		{
+			name:       "Arrow",
+			annotation: script.Annotation{Mark: script.MarkArrow, Line: 2, Text: "up", Duration: 1},
+			expected:   "C:\\> kubectl get pods\nNAME    STATUS\nweb-1   Running ◀── up\nweb-2   Pending",
+		},
+		{
+			name:       "Underline",
+			annotation: script.Annotation{Mark: script.MarkUnderline, Line: -1, Text: "a long text cut at the edge of the screen", Duration: 1},
+			expected:   "C:\\> kubectl get pods\nNAME    STATUS\nweb-1   Running\nweb-2   Pending a long text cut at the",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s := &script.Scenario{Steps: []script.Step{
+				{Command: "kubectl get pods", Output: output, Annotate: []script.Annotation{test.annotation}},
+			}}
+			opts := testOptions()
+			opts.Width = func() int { return 40 }
+
+			out := &screenRecorder{screen: vt.NewScreen(40, 10)}
+			if err := player.New(s, out, opts).Run(context.Background()); err != nil {
+				t.Fatalf("expected no error, but got %v", err)
+			}
+
+			found := false
+			for _, snapshot := range out.snapshots {
+				found = found || snapshot == test.expected
+			}
+			if !found {
+				t.Errorf("expected the annotation drawn as %q, but got %q", test.expected, out.snapshots)
+			}
+			expected := "C:\\> kubectl get pods\n" + output + "C:\\>"
+			if out.screen.String() != expected {
+				t.Errorf("expected the annotation erased as %q, but got %q", expected, out.screen.String())
+			}
+		})
+	}
+}
+
+// TestPlayerAnnotateMissingLine tests that annotations of lines that
+// are not in the output are skipped
+func TestPlayerAnnotateMissingLine(t *testing.T) {
+	s := &script.Scenario{Steps: []script.Step{
+		{Command: "ls", Output: "a.txt\n", Annotate: []script.Annotation{
+			{Mark: script.MarkArrow, Match: "b.txt"},
+			{Mark: script.MarkArrow, Line: 2},
+		}},
+	}}
+	var out syncBuffer
+	if err := player.New(s, &out, testOptions()).Run(context.Background()); err != nil {
+		t.Fatalf("expected no error, but got %v", err)
+	}
+	if strings.Contains(out.String(), "◀") {
+		t.Errorf("expected no annotation, but got %q", out.String())
+	}
+}
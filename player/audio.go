@@ -0,0 +1,88 @@
+/*
+Copyright © 2023 Mikael Schultz <bitcanon@proton.me>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package player
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/bitcanon/autotyper/audio"
+	"github.com/bitcanon/autotyper/script"
+)
+
+// waitAudio holds the demo at the prompt until the audio clip of the
+// narration has been played, or for as long as the clip lasts if the
+// audio is not played
+func (p *Player) waitAudio(ctx context.Context, name string) error {
+	filename := p.scenario.AudioFile(name)
+	if p.opts.PlayAudio {
+		slog.Debug("playing narration", "file", filename)
+		return audio.Play(ctx, filename)
+	}
+	d, err := audio.Duration(filename)
+	if err != nil {
+		return err
+	}
+	slog.Debug("waiting for narration", "file", filename, "duration", d)
+	return p.sleep(ctx, int(d/time.Millisecond))
+}
+
+// audioDuration returns how long the audio clip of a wait-audio
+// step lasts, or 0 if it cannot be read
+func (p *Player) audioDuration(name string) time.Duration {
+	d, err := audio.Duration(p.scenario.AudioFile(name))
+	if err != nil {
+		slog.Warn("failed to read the narration", "file", name, "error", err)
+		return 0
+	}
+	return d
+}
+
+// narration returns the text spoken for the step: its caption, or
+// else the text of the command if it is a comment (e.g. "# Deploy")
+func narration(step script.Step, command string) string {
+	if step.Caption != "" {
+		return strings.TrimSpace(strings.TrimLeft(step.Caption, "#"))
+	}
+	if comment, ok := strings.CutPrefix(command, "#"); ok {
+		return strings.TrimSpace(comment)
+	}
+	return ""
+}
+
+// speak starts speaking the text with the speaker of the options, and
+// returns a function waiting until it has been spoken. Nothing is
+// spoken without a speaker or text
+func (p *Player) speak(ctx context.Context, text string) func() error {
+	if p.opts.Speaker == nil || text == "" {
+		return func() error { return nil }
+	}
+	done := make(chan error, 1)
+	go func() {
+		done <- p.opts.Speaker.Speak(ctx, text)
+	}()
+	return func() error {
+		return <-done
+	}
+}
@@ -0,0 +1,102 @@
+package player_test
+
+import (
+	"context"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bitcanon/autotyper/audio"
+	"github.com/bitcanon/autotyper/player"
+	"github.com/bitcanon/autotyper/script"
+)
+
+// writeWAV writes a WAV file of silence lasting the duration, in
+// 8-bit mono at 8000 Hz
+func writeWAV(t *testing.T, filename string, d time.Duration) {
+	t.Helper()
+	size := uint32(d.Seconds() * 8000)
+	header := []byte("RIFF\x00\x00\x00\x00WAVEfmt \x10\x00\x00\x00\x01\x00\x01\x00\x40\x1f\x00\x00\x40\x1f\x00\x00\x01\x00\x08\x00data\x00\x00\x00\x00")
+	binary.LittleEndian.PutUint32(header[4:], 36+size)
+	binary.LittleEndian.PutUint32(header[40:], size)
+	if err := os.WriteFile(filename, append(header, make([]byte, size)...), 0644); err != nil {
+		t.Fatalf("failed to write the audio file: %v", err)
+	}
+}
+
+// TestPlayerWaitAudio tests that the demo is held for as long as the
+// narration lasts, relative to the directory of the scenario, and
+// that the narration is counted in the estimate
+func TestPlayerWaitAudio(t *testing.T) {
+	dir := t.TempDir()
+	writeWAV(t, filepath.Join(dir, "intro.wav"), 300*time.Millisecond)
+	s := &script.Scenario{Dir: dir, Steps: []script.Step{
+		{WaitAudio: "intro.wav"},
+		{Command: "echo done", Output: "done"},
+	}}
+
+	var out syncBuffer
+	p := player.New(s, &out, testOptions())
+	started := time.Now()
+	if err := p.Run(context.Background()); err != nil {
+		t.Fatalf("expected no error, but got %v", err)
+	}
+	if elapsed := time.Since(started); elapsed < 300*time.Millisecond {
+		t.Errorf("expected the demo to wait for the narration, but it took %v", elapsed)
+	}
+	if !strings.HasSuffix(out.String(), "C:\\> echo done\ndone\nC:\\> ") {
+		t.Errorf("expected the command after the narration, but got %q", out.String())
+	}
+
+	report := p.Estimate()
+	if len(report.Steps) != 2 || report.Steps[0].Pauses != 300*time.Millisecond {
+		t.Errorf("expected the narration to last 300ms in the estimate, but got %+v", report.Steps)
+	}
+
+	// A missing narration stops the demo
+	s.Steps[0].WaitAudio = "missing.wav"
+	if err := player.New(s, &out, testOptions()).Run(context.Background()); err == nil {
+		t.Errorf("expected error for a missing narration, but got nil")
+	}
+}
+
+// TestPlayerSpeak tests that the captions and the commands that are
+// comments are spoken, and that the commands wait for the narration
+func TestPlayerSpeak(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the engine is a shell script")
+	}
+	dir := t.TempDir()
+	engine := filepath.Join(dir, "engine")
+	spoken := filepath.Join(dir, "spoken.txt")
+	content := "#!/bin/sh\ncat >> " + spoken + "\necho >> " + spoken + "\nsleep 0.2\n"
+	if err := os.WriteFile(engine, []byte(content), 0755); err != nil {
+		t.Fatalf("failed to write the engine: %v", err)
+	}
+
+	s := &script.Scenario{Steps: []script.Step{
+		{Caption: "# Let's list the files", Command: "ls", Output: "demo.txt"},
+		{Command: "# Now the disk usage", Output: ""},
+		{Command: "du", Output: "4 ."},
+	}}
+	opts := testOptions()
+	opts.Speaker = &audio.Speaker{Command: engine}
+	started := time.Now()
+	if err := player.New(s, &syncBuffer{}, opts).Run(context.Background()); err != nil {
+		t.Fatalf("expected no error, but got %v", err)
+	}
+	if elapsed := time.Since(started); elapsed < 400*time.Millisecond {
+		t.Errorf("expected the commands to wait for the narration, but it took %v", elapsed)
+	}
+	data, err := os.ReadFile(spoken)
+	if err != nil {
+		t.Fatalf("expected the narration to be spoken, but got %v", err)
+	}
+	if expected := "Let's list the files\nNow the disk usage\n"; string(data) != expected {
+		t.Errorf("expected %q, but got %q", expected, data)
+	}
+}
@@ -0,0 +1,74 @@
+/*
+Copyright © 2023 Mikael Schultz <bitcanon@proton.me>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package player
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+
+	"github.com/bitcanon/autotyper/cache"
+	"github.com/bitcanon/autotyper/cli"
+	"github.com/bitcanon/autotyper/locale"
+	"github.com/bitcanon/autotyper/script"
+)
+
+// cached replays the output of the command cached by a previous run,
+// if the output of the step is cached. Otherwise, it returns out with
+// the recording of the output to save once the command has run, if it
+// is cached. The first return value reports whether it was replayed
+func (p *Player) cached(ctx context.Context, step script.Step, command string, out io.Writer) (bool, io.Writer, *cache.Recording, error) {
+	// Commands typed into are not cached, nor refused commands,
+	// whose output is not shown
+	if p.opts.Cache == nil || len(step.Input) > 0 || len(step.Expect) > 0 || cli.CommandPolicy.Check(command) != nil {
+		return false, out, nil, nil
+	}
+
+	dir := p.shell.WorkDir()
+	if entry, ok := p.opts.Cache.Lookup(command, dir); ok && !p.opts.RefreshCache {
+		slog.Debug("replaying cached output", "command", command, "chunks", len(entry.Chunks))
+		if err := entry.Replay(ctx, out, p.sleep); err != nil {
+			return true, out, nil, err
+		}
+		p.exitCode = entry.ExitCode
+		if entry.Error != "" {
+			fmt.Fprintln(out, locale.T("Error: %v", entry.Error))
+		}
+		return true, out, nil, nil
+	}
+
+	recording := p.opts.Cache.Record(command, dir, p.clock())
+	return false, io.MultiWriter(out, recording), recording, nil
+}
+
+// saveCached caches the output of a command that has run, and
+// exited with the error, unless it could not be started
+func saveCached(recording *cache.Recording, err error) {
+	code := exitCode(err)
+	if recording == nil || code == -1 {
+		return
+	}
+	if err := recording.Save(code, err); err != nil {
+		slog.Warn("cannot cache the output", "error", err)
+	}
+}
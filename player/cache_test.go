@@ -0,0 +1,42 @@
+package player_test
+
+import (
+	"context"
+	"os/exec"
+	"testing"
+
+	"github.com/bitcanon/autotyper/cache"
+	"github.com/bitcanon/autotyper/player"
+)
+
+// TestPlayerCache tests that the output of a command is replayed
+// from the cache instead of running the command again, unless the
+// cache is refreshed
+func TestPlayerCache(t *testing.T) {
+	if _, err := exec.LookPath("date"); err != nil {
+		t.Skip("date is not available")
+	}
+
+	opts := testOptions()
+	opts.Cache = cache.New(t.TempDir())
+	play := func(refresh bool) string {
+		opts.RefreshCache = refresh
+		var out syncBuffer
+		if err := player.New(mustParse(t, "date +%s%N"), &out, opts).Run(context.Background()); err != nil {
+			t.Fatalf("expected no error, but got %v", err)
+		}
+		return out.String()
+	}
+
+	first := play(false)
+	if replayed := play(false); replayed != first {
+		t.Errorf("expected the cached output %q, but got %q", first, replayed)
+	}
+	refreshed := play(true)
+	if refreshed == first {
+		t.Errorf("expected the command to run again, but got the cached output %q", refreshed)
+	}
+	if replayed := play(false); replayed != refreshed {
+		t.Errorf("expected the refreshed output %q, but got %q", refreshed, replayed)
+	}
+}
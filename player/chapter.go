@@ -0,0 +1,97 @@
+/*
+Copyright © 2023 Mikael Schultz <bitcanon@proton.me>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package player
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/bitcanon/autotyper/script"
+)
+
+// startChapter cues the chapter beginning with the step with the
+// index, if any: the bell of the terminal is rung, and the chapter
+// command is run with the chapter in its environment, for example to
+// add a chapter marker to a recording. Since the audience does not
+// depend on the cues, a failed command is logged instead of stopping
+// the demo
+func (p *Player) startChapter(ctx context.Context, i int, step script.Step) {
+	if step.Chapter == "" {
+		return
+	}
+	title := script.Expand(step.Chapter, p.vars)
+	number := p.chapterNumber(i)
+	slog.Debug("chapter started", "chapter", number, "title", title, "step", i+1)
+
+	if p.opts.ChapterBell {
+		fmt.Fprint(p.line.aside(), "\a")
+	}
+
+	command := strings.Fields(p.opts.ChapterCommand)
+	if len(command) == 0 {
+		return
+	}
+	cmd := exec.CommandContext(ctx, command[0], command[1:]...)
+	cmd.Env = append(os.Environ(),
+		"AUTOTYPER_CHAPTER="+title,
+		fmt.Sprintf("AUTOTYPER_CHAPTER_NUMBER=%d", number),
+		fmt.Sprintf("AUTOTYPER_STEP=%d", i+1),
+	)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		slog.Warn("chapter command failed", "chapter", number, "error", err, "output", strings.TrimSpace(string(output)))
+	}
+}
+
+// chapterNumber returns the number of the chapter beginning with
+// the step with the index, counting the chapters from 1
+func (p *Player) chapterNumber(i int) int {
+	number := 0
+	for _, step := range p.steps[:i+1] {
+		if step.Chapter != "" {
+			number++
+		}
+	}
+	return number
+}
+
+// checkChapterCommand checks that the program of the chapter
+// command is found, if the scenario has chapters
+func (p *Player) checkChapterCommand() []Issue {
+	command := strings.Fields(p.opts.ChapterCommand)
+	if len(command) == 0 {
+		return nil
+	}
+	for _, step := range p.steps {
+		if step.Chapter == "" {
+			continue
+		}
+		if _, err := exec.LookPath(command[0]); err != nil {
+			return []Issue{{Step: -1, Message: fmt.Sprintf("the chapter command %s is not installed", command[0])}}
+		}
+		return nil
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package player_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/bitcanon/autotyper/player"
+)
+
+// TestPlayerChapters tests that the bell is rung and the chapter
+// command is run with the chapter in its environment as each
+// chapter begins
+func TestPlayerChapters(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the chapter command is a shell script")
+	}
+	dir := t.TempDir()
+	log := filepath.Join(dir, "chapters.log")
+	command := filepath.Join(dir, "chapter")
+	script := "#!/bin/sh\necho \"$AUTOTYPER_CHAPTER_NUMBER $AUTOTYPER_STEP $AUTOTYPER_CHAPTER\" >> " + log + "\n"
+	if err := os.WriteFile(command, []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	opts := testOptions()
+	opts.ChapterBell = true
+	opts.ChapterCommand = command
+	opts.Variables = map[string]string{"app": "shop"}
+
+	var out syncBuffer
+	s := mustParse(t, "#chapter Setting up\necho one\necho two\n#chapter Deploying ${app}\necho three")
+	p := player.New(s, &out, opts)
+	if err := p.Run(context.Background()); err != nil {
+		t.Fatalf("expected no error, but got %v", err)
+	}
+
+	if bells := strings.Count(out.String(), "\a"); bells != 2 {
+		t.Errorf("expected the bell to be rung twice, but got %d in %q", bells, out.String())
+	}
+	cues, err := os.ReadFile(log)
+	if err != nil {
+		t.Fatalf("expected the chapter command to run, but got %v", err)
+	}
+	if expected := "1 1 Setting up\n2 3 Deploying shop\n"; string(cues) != expected {
+		t.Errorf("expected the chapters %q, but got %q", expected, cues)
+	}
+
+	opts.ChapterCommand = "missing-chapter-command"
+	issues := player.New(s, &out, opts).Preflight(0, 0)
+	if len(issues) != 1 || issues[0].String() != "the chapter command missing-chapter-command is not installed" {
+		t.Errorf("expected the chapter command to be reported, but got %v", issues)
+	}
+}
@@ -0,0 +1,115 @@
+/*
+Copyright © 2023 Mikael Schultz <bitcanon@proton.me>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package player
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/bitcanon/autotyper/cli"
+	"github.com/bitcanon/autotyper/script"
+	"github.com/bitcanon/autotyper/simulate"
+)
+
+// Estimate returns the expected time spent on each step, computed
+// from the delays and the typing speed without executing anything.
+// The execution time of a command is the time until its last input
+// is typed, or the expected duration of a simulated command, so other
+// commands without input count as instantaneous, and the time spent
+// answering questions, running Lua code and going back to earlier
+// steps is not known
+func (p *Player) Estimate() Report {
+	var report Report
+	commands := 0
+	start, end := p.bounds()
+	for i := start; i < end; i++ {
+		step := p.steps[i]
+		if !p.selected(i) {
+			continue
+		}
+		var timing StepTiming
+		switch {
+		case step.Ask != "":
+			timing = StepTiming{Step: i, Command: "#ask " + step.Ask}
+		case step.Motd != "":
+			timing = StepTiming{Step: i, Command: "#motd " + step.Motd}
+		case step.Lua != "":
+			timing = StepTiming{Step: i, Command: "#lua"}
+		case step.Goto != nil:
+			timing = StepTiming{Step: i, Command: "#goto " + step.Goto.Step}
+		case step.Think > 0:
+			timing = StepTiming{Step: i, Command: fmt.Sprintf("#think %d", step.Think), Pauses: milliseconds(scale(step.Think, p.delayScale))}
+		case step.WaitAudio != "":
+			timing = StepTiming{Step: i, Command: "#wait-audio " + step.WaitAudio, Pauses: p.audioDuration(step.WaitAudio)}
+		case step.Image != "":
+			timing = StepTiming{Step: i, Command: "#image " + step.Image}
+		case step.QRCode != "":
+			timing = StepTiming{Step: i, Command: "#qrcode " + step.QRCode, Pauses: milliseconds(p.opts.QRCodeDuration)}
+		default:
+			// Apply the overrides, the ramp and the scales like Run does
+			opts := p.stepOptions(step, commands)
+			commands++
+			typed := script.Expand(step.Text(), p.vars)
+
+			timing = StepTiming{
+				Step:      i,
+				Command:   cli.StripReadings(typed),
+				Typing:    p.typist(opts).Duration(typed),
+				Execution: inputDuration(p.scaledInputs(step.Input), opts.CharDelay),
+				Pauses:    milliseconds(opts.PreDelay + opts.PostDelay),
+			}
+			if simulator, ok := p.scenario.Simulator(step.Simulate); ok {
+				timing.Execution += simulator.Duration(simulate.NewSimulation(timing.Command, step.Params, opts.Prompt.Shell != cli.Bash))
+			}
+			timing.Pauses += milliseconds(p.moreDuration(step.Output, step.More))
+			for _, a := range step.Annotate {
+				timing.Pauses += milliseconds(p.annotationDelay(a))
+			}
+			if p.opts.TypeClear && p.clears(i, step) {
+				timing.Typing += p.typist(opts).Duration(cli.ClearCommand(p.opts.Prompt.Shell))
+			}
+		}
+		report.Steps = append(report.Steps, timing)
+		report.Total += timing.Total()
+	}
+	if p.login != nil && start == 0 {
+		report.Total += p.loginDuration(p.login)
+	}
+	if p.opts.TypeExit {
+		report.Total += p.typist(p.opts).Duration("exit")
+	}
+	return report
+}
+
+// inputDuration returns the time until the last input is typed
+func inputDuration(inputs []script.Input, delayMs int) time.Duration {
+	var d time.Duration
+	for _, input := range inputs {
+		d += milliseconds(input.After + len([]rune(cli.EchoControl(cli.ExpandKeys(input.Text))))*delayMs)
+	}
+	return d
+}
+
+// milliseconds converts a number of milliseconds to a duration
+func milliseconds(ms int) time.Duration {
+	return time.Duration(ms) * time.Millisecond
+}
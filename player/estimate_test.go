@@ -0,0 +1,56 @@
+package player_test
+
+import (
+	"io"
+	"testing"
+	"time"
+
+	"github.com/bitcanon/autotyper/player"
+	"github.com/bitcanon/autotyper/script"
+	"github.com/bitcanon/autotyper/simulate"
+)
+
+// TestPlayerEstimate tests that the duration of each step is
+// computed from the delays, the typing speed, the inputs, the
+// simulated commands and the annotations
+func TestPlayerEstimate(t *testing.T) {
+	opts := testOptions()
+	opts.CharDelay = 100
+	opts.PreDelay = 500
+	opts.PostDelay = 2000
+
+	fast := 10
+	s := &script.Scenario{Steps: []script.Step{
+		{Command: "ls"},
+		{Think: 800},
+		{Command: "rm -i x", Input: []script.Input{{After: 300, Text: "y\n"}}},
+		{Command: "pwd", Timing: &script.Timing{CharDelay: &fast}},
+		{Simulate: "ping -n 2 ::1", Params: simulate.Params{"latency": 1}},
+		{Command: "ls", Annotate: []script.Annotation{{Mark: script.MarkArrow, Line: 1}, {Mark: script.MarkBox, Line: 1, Duration: 1000}}},
+		{Command: "show run", Output: "a\nb\nc\nd\ne\n", More: &script.More{Lines: 2, Delay: 400}},
+	}}
+	report := player.New(s, io.Discard, opts).Estimate()
+
+	expected := []player.StepTiming{
+		{Step: 0, Command: "ls", Typing: 200 * time.Millisecond, Pauses: 2500 * time.Millisecond},
+		{Step: 1, Command: "#think 800", Pauses: 800 * time.Millisecond},
+		{Step: 2, Command: "rm -i x", Typing: 700 * time.Millisecond, Execution: 500 * time.Millisecond, Pauses: 2500 * time.Millisecond},
+		{Step: 3, Command: "pwd", Typing: 30 * time.Millisecond, Pauses: 2500 * time.Millisecond},
+		{Step: 4, Command: "ping -n 2 ::1", Typing: 1300 * time.Millisecond, Execution: 1001 * time.Millisecond, Pauses: 2500 * time.Millisecond},
+		{Step: 5, Command: "ls", Typing: 200 * time.Millisecond, Pauses: 6500 * time.Millisecond},
+		{Step: 6, Command: "show run", Typing: 800 * time.Millisecond, Pauses: 3300 * time.Millisecond},
+	}
+	if len(report.Steps) != len(expected) {
+		t.Fatalf("expected %d steps, but got %d", len(expected), len(report.Steps))
+	}
+	var total time.Duration
+	for i, step := range report.Steps {
+		if step != expected[i] {
+			t.Errorf("step %d: expected %+v, but got %+v", i, expected[i], step)
+		}
+		total += expected[i].Total()
+	}
+	if report.Total != total {
+		t.Errorf("expected a total of %v, but got %v", total, report.Total)
+	}
+}
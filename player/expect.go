@@ -0,0 +1,138 @@
+/*
+Copyright © 2023 Mikael Schultz <bitcanon@proton.me>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package player
+
+import (
+	"context"
+	"errors"
+	"io"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/bitcanon/autotyper/cli"
+	"github.com/bitcanon/autotyper/script"
+)
+
+// maxExpectBuffer is the number of bytes of output kept
+// for matching the pattern of the current expect rule
+const maxExpectBuffer = 64 * 1024
+
+// expecter receives the output of a command and lets the
+// expect rules wait for their patterns to appear in it
+type expecter struct {
+	mu      sync.Mutex
+	buf     []byte
+	changed chan struct{}
+}
+
+// newExpecter creates an expecter without any output
+func newExpecter() *expecter {
+	return &expecter{changed: make(chan struct{})}
+}
+
+// Write adds output of the command to the buffer
+func (e *expecter) Write(p []byte) (int, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.buf = append(e.buf, p...)
+	if len(e.buf) > maxExpectBuffer {
+		e.buf = e.buf[len(e.buf)-maxExpectBuffer:]
+	}
+	close(e.changed)
+	e.changed = make(chan struct{})
+	return len(p), nil
+}
+
+// wait blocks until the pattern matches the output received since
+// the previous match, or until the context is cancelled
+func (e *expecter) wait(ctx context.Context, re *regexp.Regexp) error {
+	for {
+		e.mu.Lock()
+		if loc := re.FindIndex(e.buf); loc != nil {
+			e.buf = e.buf[loc[1]:]
+			e.mu.Unlock()
+			return nil
+		}
+		changed := e.changed
+		e.mu.Unlock()
+
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// runExpect executes the command in a pseudo-terminal and answers
+// its prompts with the expect rules. The timed inputs are written to
+// the terminal as well. On platforms without pseudo-terminals, the
+// rules are evaluated against the output written to a pipe instead
+func (p *Player) runExpect(ctx context.Context, command string, step script.Step, charDelay int) error {
+	exp := newExpecter()
+	screen := &syncWriter{w: p.out}
+	out := io.MultiWriter(screen, exp)
+
+	// The terminal echoes the input, so it is not typed on the screen
+	sessionCtx, cancel := context.WithCancel(ctx)
+	err := cli.ExecuteCommandPTY(p.shell, command, out, func(in io.Writer) {
+		go p.typeInput(sessionCtx, step.Input, io.Discard, in, charDelay)
+		answer(sessionCtx, p.clock(), exp, step.Expect, in, charDelay)
+	})
+	cancel()
+	if !errors.Is(err, cli.ErrPTYUnsupported) {
+		return err
+	}
+
+	// Without a terminal there is no echo, so the answers
+	// and inputs are typed on the screen as they are sent
+	return runPipe(ctx, p.shell, command, out, func(ctx context.Context, in io.Writer) {
+		go p.typeInput(ctx, step.Input, screen, in, charDelay)
+		answer(ctx, p.clock(), exp, step.Expect, &echoWriter{in: in, screen: screen}, charDelay)
+	})
+}
+
+// answer waits for the pattern of each rule in turn and types
+// the response into in. It returns if the context is cancelled
+func answer(ctx context.Context, clock cli.Clock, exp *expecter, rules []script.Expect, in io.Writer, charDelay int) {
+	for _, rule := range rules {
+		if err := exp.wait(ctx, regexp.MustCompile(rule.Expect)); err != nil {
+			return
+		}
+		if err := cli.TypeText(clock, response(rule), in, charDelay); err != nil {
+			return
+		}
+	}
+}
+
+// response returns the text sent by the rule, with the keys of the
+// key notation expanded, ending with Enter unless it already ends
+// with a newline or a key (e.g. "<Tab>" or "<C-c>")
+func response(rule script.Expect) string {
+	send := cli.ExpandKeys(rule.Send)
+	if strings.HasSuffix(send, "\n") || cli.EndsWithKey(rule.Send) {
+		return send
+	}
+	return send + "\n"
+}
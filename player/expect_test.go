@@ -0,0 +1,60 @@
+package player_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/bitcanon/autotyper/player"
+	"github.com/bitcanon/autotyper/script"
+)
+
+// TestPlayerExpect tests that the prompts of an interactive command
+// running in a pseudo-terminal are answered by the expect rules
+func TestPlayerExpect(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("pseudo-terminals are not supported on Windows")
+	}
+
+	// A login that reads the user with echo and the password without
+	login := filepath.Join(t.TempDir(), "login.sh")
+	err := os.WriteFile(login, []byte(`printf 'Username: '
+read user
+stty -echo
+printf 'Password: '
+read pass
+stty echo
+echo
+echo "Welcome $user ($pass)"
+`), 0o644)
+	if err != nil {
+		t.Fatalf("failed to write script: %v", err)
+	}
+
+	var out syncBuffer
+	s := &script.Scenario{Steps: []script.Step{
+		{Command: "sh " + login, Expect: []script.Expect{
+			{Expect: "Username:", Send: "bitcanon"},
+			{Expect: "Password:", Send: "hunter2\n"},
+		}},
+	}}
+
+	p := player.New(s, &out, testOptions())
+	if err := p.Run(context.Background()); err != nil {
+		t.Fatalf("expected no error, but got %v", err)
+	}
+
+	// The terminal uses CRLF line endings
+	output := strings.ReplaceAll(out.String(), "\r\n", "\n")
+	for _, expected := range []string{"Username: bitcanon\n", "Welcome bitcanon (hunter2)\n"} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("expected output to contain %q, but got %q", expected, output)
+		}
+	}
+	if strings.Contains(output, "Password: hunter2") {
+		t.Errorf("expected the password not to be echoed, but got %q", output)
+	}
+}
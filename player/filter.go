@@ -0,0 +1,246 @@
+/*
+Copyright © 2023 Mikael Schultz <bitcanon@proton.me>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package player
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log/slog"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bitcanon/autotyper/cli"
+	"github.com/bitcanon/autotyper/script"
+	"github.com/bitcanon/autotyper/wasm"
+)
+
+// outputFilter returns the writer of the output of the step, passing
+// the lines through the output hook, the WebAssembly filters, in
+// order, the highlight rules, and then clearing the screen after too
+// many lines. The returned function waits for the filters to write the
+// end of the output. The output of interactive steps is not filtered,
+// so that their prompts are shown before the line ends
+func (p *Player) outputFilter(ctx context.Context, i int, step script.Step, command string, opts Options) (io.Writer, func()) {
+	if len(step.Input) > 0 || len(step.Expect) > 0 {
+		return p.out, func() {}
+	}
+
+	// Chain the filters from the screen, so that the output
+	// written to the last one goes through all of them
+	out := p.out
+	var closers []func()
+	if limit := p.clearAfter(step); limit > 0 {
+		out = &clearWriter{out: out, limit: limit, scrollback: p.opts.ClearScrollback}
+	}
+	if rules := append(parseHighlights(step.Highlight), p.highlights...); len(rules) > 0 {
+		filter := &lineFilter{out: out, filter: func(line string) (string, bool) {
+			return cli.HighlightLine(line, rules), true
+		}}
+		closers = append(closers, func() { filter.Flush() })
+		out = filter
+	}
+	env := map[string]string{"AUTOTYPER_COMMAND": command, "AUTOTYPER_STEP": strconv.Itoa(i + 1)}
+	for j := len(p.opts.Filters) - 1; j >= 0; j-- {
+		module := p.opts.Filters[j]
+		filter := wasm.NewFilter(ctx, module, out, env)
+		closers = append(closers, func() {
+			if err := filter.Close(); err != nil && ctx.Err() == nil {
+				slog.Warn("filter failed", "filter", module.Name(), "step", i+1, "error", err)
+			}
+		})
+		out = filter
+	}
+
+	if p.opts.Hooks != nil && p.opts.Hooks.FiltersOutput() {
+		described := hookStep(i, command, opts)
+		filter := &lineFilter{out: out, filter: func(line string) (string, bool) {
+			line, keep, err := p.opts.Hooks.OutputLine(described, line)
+			if err != nil {
+				slog.Warn("hook failed", "step", i+1, "error", err)
+			}
+			return line, keep
+		}}
+		closers = append(closers, func() { filter.Flush() })
+		out = filter
+	}
+
+	return out, func() {
+		for j := len(closers) - 1; j >= 0; j-- {
+			closers[j]()
+		}
+	}
+}
+
+// parseHighlights parses the highlight rules, skipping the invalid
+// rules, which are reported when the scenario is validated
+func parseHighlights(rules []string) []cli.Highlight {
+	var highlights []cli.Highlight
+	for _, rule := range rules {
+		if h, err := cli.ParseHighlight(rule); err == nil {
+			highlights = append(highlights, h)
+		}
+	}
+	return highlights
+}
+
+// lineIdle is the time after which an incomplete line is filtered,
+// so that prompts and progress bars are not held back
+const lineIdle = 50 * time.Millisecond
+
+// lineFilter passes each line written through the filter before
+// writing it to out. The filter returns the new line, or false if
+// the line is dropped. Lines end with a newline, or with a carriage
+// return redrawing the line (e.g. a progress bar). An incomplete line
+// is filtered after lineIdle without writes, or on Flush
+type lineFilter struct {
+	out    io.Writer
+	filter func(line string) (string, bool)
+
+	mu    sync.Mutex
+	buf   []byte
+	timer *time.Timer
+}
+
+func (f *lineFilter) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.buf = append(f.buf, p...)
+	for {
+		end := lineEnd(f.buf)
+		if end < 0 {
+			break
+		}
+		line, ending := string(f.buf[:end]), string(f.buf[end])
+		if f.buf[end] == '\r' {
+			line, ending = line+"\r", ""
+		}
+		f.buf = f.buf[end+1:]
+		if err := f.write(line, ending); err != nil {
+			return len(p), err
+		}
+	}
+
+	// Filter the incomplete line if nothing more is written
+	if f.timer != nil {
+		f.timer.Stop()
+	}
+	if len(f.buf) > 0 {
+		f.timer = time.AfterFunc(lineIdle, func() { f.Flush() })
+	}
+	return len(p), nil
+}
+
+// lineEnd returns the index of the end of the first line, or -1 if
+// the line is incomplete. A carriage return ends a line unless it
+// is followed by a newline, or is the last byte written so far
+func lineEnd(buf []byte) int {
+	for i, c := range buf {
+		switch {
+		case c == '\n':
+			return i
+		case c == '\r' && i+1 < len(buf) && buf[i+1] != '\n':
+			return i
+		}
+	}
+	return -1
+}
+
+// Flush filters and writes the incomplete last line, if any
+func (f *lineFilter) Flush() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.timer != nil {
+		f.timer.Stop()
+	}
+	if len(f.buf) == 0 {
+		return nil
+	}
+	line := string(f.buf)
+	f.buf = nil
+	return f.write(line, "")
+}
+
+// write filters the line and writes it with the line ending. The
+// carriage return ending a line is kept out of the filter
+func (f *lineFilter) write(line, ending string) error {
+	if strings.HasSuffix(line, "\r") {
+		line, ending = strings.TrimSuffix(line, "\r"), "\r"+ending
+	}
+	line, keep := f.filter(line)
+	if !keep {
+		return nil
+	}
+	_, err := io.WriteString(f.out, line+ending)
+	return err
+}
+
+// clearAfter returns the number of lines of output of the
+// step after which the screen is cleared, or zero
+func (p *Player) clearAfter(step script.Step) int {
+	if p.opts.Accessible {
+		return 0
+	}
+	if step.ClearAfter > 0 {
+		return step.ClearAfter
+	}
+	return p.opts.ClearAfter
+}
+
+// clearWriter clears the screen before writing more than limit lines,
+// so that the output goes on at the top of the screen
+type clearWriter struct {
+	out        io.Writer
+	limit      int
+	scrollback bool
+	lines      int
+}
+
+func (w *clearWriter) Write(p []byte) (int, error) {
+	for rest := p; len(rest) > 0; {
+		if w.lines >= w.limit {
+			slog.Debug("clearing the screen after the output", "lines", w.lines)
+			if err := cli.ClearScreen(w.out, w.scrollback); err != nil {
+				return 0, err
+			}
+			w.lines = 0
+		}
+
+		end := bytes.IndexByte(rest, '\n')
+		if end < 0 {
+			if _, err := w.out.Write(rest); err != nil {
+				return 0, err
+			}
+			break
+		}
+		if _, err := w.out.Write(rest[:end+1]); err != nil {
+			return 0, err
+		}
+		w.lines++
+		rest = rest[end+1:]
+	}
+	return len(p), nil
+}
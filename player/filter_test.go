@@ -0,0 +1,122 @@
+package player_test
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bitcanon/autotyper/cli"
+	"github.com/bitcanon/autotyper/hooks"
+	"github.com/bitcanon/autotyper/player"
+	"github.com/bitcanon/autotyper/script"
+	"github.com/bitcanon/autotyper/wasm"
+)
+
+// TestPlayerFilters tests that the output of the steps is streamed
+// through the WebAssembly filters after the output hook
+func TestPlayerFilters(t *testing.T) {
+	if testing.Short() {
+		t.Skip("building WebAssembly modules is slow")
+	}
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go is not available")
+	}
+	path := filepath.Join(t.TempDir(), "upper.wasm")
+	cmd := exec.Command("go", "build", "-o", path, filepath.Join("..", "testdata", "wasm", "upper"))
+	cmd.Env = append(os.Environ(), "GOOS=wasip1", "GOARCH=wasm")
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("failed to build the filter: %v\n%s", err, output)
+	}
+	module, err := wasm.Load(path)
+	if err != nil {
+		t.Fatalf("expected no error, but got %v", err)
+	}
+	h, err := hooks.New(`function onOutputLine(line) { return line === "noise" ? null : line; }`, "hooks.js")
+	if err != nil {
+		t.Fatalf("expected no error, but got %v", err)
+	}
+
+	s := &script.Scenario{Steps: []script.Step{
+		{Command: "make", Output: "compiling\nnoise\nlinked"},
+		{Command: "make install", Output: "installing\n", Input: []script.Input{{Text: "y"}}},
+	}}
+	opts := testOptions()
+	opts.Filters = []*wasm.Module{module}
+	opts.Hooks = h
+
+	var out syncBuffer
+	if err := player.New(s, &out, opts).Run(context.Background()); err != nil {
+		t.Fatalf("expected no error, but got %v", err)
+	}
+	if !strings.Contains(out.String(), "C:\\> make\n1| COMPILING\n1| LINKED\nC:\\> ") {
+		t.Errorf("expected the filtered output, but got %q", out.String())
+	}
+	if !strings.Contains(out.String(), "C:\\> make install\ninstalling\n") {
+		t.Errorf("expected the output of the interactive step unfiltered, but got %q", out.String())
+	}
+}
+
+// TestPlayerHighlights tests that the matches of the highlight rules
+// of the step, the scenario and the options are highlighted, also in
+// lines redrawn with a carriage return
+func TestPlayerHighlights(t *testing.T) {
+	defer func(profile cli.ColorProfile) { cli.ActiveColorProfile = profile }(cli.ActiveColorProfile)
+	cli.ActiveColorProfile = cli.ANSI16
+
+	s := &script.Scenario{
+		Highlights: []string{"error style=red"},
+		Steps: []script.Step{
+			{Command: "make", Output: "10%\r100%\nerror: done\nwarning\n", Highlight: []string{`\d+% style=bold`}},
+			{Command: "make test", Output: "error in test\n"},
+		},
+	}
+	opts := testOptions()
+	rule, err := cli.ParseHighlight("warning style=yellow line")
+	if err != nil {
+		t.Fatalf("expected no error, but got %v", err)
+	}
+	opts.Highlights = []cli.Highlight{rule}
+
+	var out syncBuffer
+	if err := player.New(s, &out, opts).Run(context.Background()); err != nil {
+		t.Fatalf("expected no error, but got %v", err)
+	}
+	for _, expected := range []string{
+		"\033[1m10%\033[0m\r\033[1m100%\033[0m\n\033[31merror\033[0m: done\n\033[33mwarning\033[0m\n",
+		"C:\\> make test\n\033[31merror\033[0m in test\n",
+	} {
+		if !strings.Contains(out.String(), expected) {
+			t.Errorf("expected %q in the output, but got %q", expected, out.String())
+		}
+	}
+}
+
+// TestPlayerClearAfter tests that the screen is cleared whenever the
+// output of a step exceeds the number of lines, and that the number
+// of lines of the step takes precedence over the option
+func TestPlayerClearAfter(t *testing.T) {
+	s := &script.Scenario{Steps: []script.Step{
+		{Command: "tail log", Output: "1\n2\n3\n4\n5\n"},
+		{Command: "tail other", Output: "a\nb\nc\n", ClearAfter: 1},
+		{Command: "ls", Output: "x\ny\n"},
+	}}
+	opts := testOptions()
+	opts.ClearAfter = 2
+
+	var out syncBuffer
+	if err := player.New(s, &out, opts).Run(context.Background()); err != nil {
+		t.Fatalf("expected no error, but got %v", err)
+	}
+	for _, expected := range []string{
+		"tail log\n1\n2\n\033[H\033[2J3\n4\n\033[H\033[2J5\n",
+		"tail other\na\n\033[H\033[2Jb\n\033[H\033[2Jc\n",
+		"ls\nx\ny\n",
+	} {
+		if !strings.Contains(out.String(), expected) {
+			t.Errorf("expected %q in the output, but got %q", expected, out.String())
+		}
+	}
+}
@@ -0,0 +1,89 @@
+/*
+Copyright © 2023 Mikael Schultz <bitcanon@proton.me>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package player
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/bitcanon/autotyper/script"
+)
+
+// ErrCannotFit is returned by Fit when the playback cannot be made
+// to last the target duration by scaling the delays
+var ErrCannotFit = errors.New("cannot fit the playback into the duration")
+
+// Fit scales the delays before and after the commands, the thinking
+// pauses and the delays of the input, so that the estimated duration
+// of the playback is the target duration. If typing is set, the delay
+// between each character is scaled as well. The time spent executing
+// the commands is not known, so the playback may last longer
+func (p *Player) Fit(target time.Duration, typing bool) error {
+	// The estimate grows linearly with the scale of the delays,
+	// so the scale is found from the estimates at 0 and 1
+	p.delayScale, p.typingScale = 0, 1
+	if typing {
+		p.typingScale = 0
+	}
+	fixed := p.Estimate().Total
+	p.delayScale, p.typingScale = 1, 1
+	scalable := p.Estimate().Total - fixed
+
+	if scalable <= 0 || target <= fixed {
+		return fmt.Errorf("%w: at least %s is needed", ErrCannotFit, fixed.Round(100*time.Millisecond))
+	}
+	scale := float64(target-fixed) / float64(scalable)
+	p.delayScale = scale
+	if typing {
+		p.typingScale = scale
+	}
+	return nil
+}
+
+// stepOptions returns the options for the step at the index, with
+// the overrides of the step, the ramp of the typing speed for the
+// number of commands typed before it, and the scales of Fit applied
+func (p *Player) stepOptions(step script.Step, commands int) Options {
+	opts := p.opts.override(step.Prompt, step.Timing)
+	opts.CharDelay = scale(opts.CharDelay, p.opts.Ramp.Scale(commands)*p.typingScale)
+	opts.PreDelay = scale(opts.PreDelay, p.delayScale)
+	opts.PostDelay = scale(opts.PostDelay, p.delayScale)
+	return opts
+}
+
+// scaledInputs returns the inputs with their delays scaled
+func (p *Player) scaledInputs(inputs []script.Input) []script.Input {
+	if p.delayScale == 1 || len(inputs) == 0 {
+		return inputs
+	}
+	scaled := make([]script.Input, len(inputs))
+	for i, input := range inputs {
+		scaled[i] = script.Input{After: scale(input.After, p.delayScale), Text: input.Text}
+	}
+	return scaled
+}
+
+// scale multiplies a delay in milliseconds by the factor
+func scale(ms int, factor float64) int {
+	return int(float64(ms) * factor)
+}
@@ -0,0 +1,53 @@
+package player_test
+
+import (
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/bitcanon/autotyper/player"
+)
+
+// TestPlayerFit tests that the delays, and optionally the typing
+// speed, are scaled to the target duration
+func TestPlayerFit(t *testing.T) {
+	tests := []struct {
+		name   string
+		target time.Duration
+		typing bool
+		err    error
+	}{
+		{name: "Shorter", target: 4 * time.Second},
+		{name: "Longer", target: 20 * time.Second},
+		{name: "Typing", target: 2 * time.Second, typing: true},
+		{name: "TooShort", target: 500 * time.Millisecond, err: player.ErrCannotFit},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			opts := testOptions()
+			opts.CharDelay = 100
+			opts.PreDelay = 500
+			opts.PostDelay = 2000
+
+			p := player.New(mustParse(t, "ls -l\n#think 1000\ncat notes.txt"), io.Discard, opts)
+			typing := p.Estimate().Steps[0].Typing
+			err := p.Fit(test.target, test.typing)
+			if !errors.Is(err, test.err) {
+				t.Fatalf("expected error %v, but got %v", test.err, err)
+			}
+			if err != nil {
+				return
+			}
+
+			report := p.Estimate()
+			if diff := report.Total - test.target; diff < -50*time.Millisecond || diff > 50*time.Millisecond {
+				t.Errorf("expected a total of %v, but got %v", test.target, report.Total)
+			}
+			if scaled := report.Steps[0].Typing != typing; scaled != test.typing {
+				t.Errorf("expected typing scaled %v, but got %v (%v)", test.typing, scaled, report.Steps[0].Typing)
+			}
+		})
+	}
+}
@@ -0,0 +1,98 @@
+/*
+Copyright © 2023 Mikael Schultz <bitcanon@proton.me>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package player
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os/exec"
+	"strconv"
+
+	"github.com/bitcanon/autotyper/cli"
+	"github.com/bitcanon/autotyper/script"
+)
+
+// jump returns the index of the step gone to by the goto step with
+// the index i, and whether the goto jumps: if its condition is met and
+// it has not already jumped as many times as it can
+func (p *Player) jump(i int, g *script.Goto, shown cli.Prompt) (int, bool, error) {
+	target, err := p.scenario.StepIndex(g.Step)
+	if err != nil {
+		return 0, false, err
+	}
+	if p.jumps[i] >= g.MaxJumps() {
+		slog.Debug("not going to the step again", "step", i+1, "goto", g.Step, "jumps", p.jumps[i])
+		return 0, false, nil
+	}
+
+	var jump bool
+	switch g.If {
+	case "":
+		jump = true
+	case script.GotoFailed:
+		jump = p.exitCode != 0
+	case script.GotoSucceeded:
+		jump = p.exitCode == 0
+	case script.GotoKey:
+		if jump, err = p.askJump(g.Question(), shown); err != nil {
+			return 0, false, err
+		}
+	default:
+		code, _ := strconv.Atoi(g.If)
+		jump = p.exitCode == code
+	}
+	slog.Debug("evaluated goto", "step", i+1, "goto", g.Step, "if", g.If, "exit_code", p.exitCode, "jump", jump)
+	if jump {
+		p.jumps[i]++
+	}
+	return target, jump, nil
+}
+
+// askJump asks the presenter whether to jump, on the line of the
+// prompt, and prints the prompt again once answered
+func (p *Player) askJump(question string, shown cli.Prompt) (bool, error) {
+	p.eraseLine()
+	jump, err := cli.Confirm(question, p.input(), p.out)
+	if err != nil {
+		return false, err
+	}
+
+	// Move up to the line with the question and erase it
+	fmt.Fprint(p.out, "\033[A\r\033[K")
+	cli.PrintPrompt(shown, p.out)
+	return jump, nil
+}
+
+// exitCode returns the exit code of a command that returned the
+// error, or -1 if the command did not run until it exited
+func exitCode(err error) int {
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+		return 0
+	case errors.As(err, &exitErr):
+		return exitErr.ExitCode()
+	default:
+		return -1
+	}
+}
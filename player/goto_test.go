@@ -0,0 +1,136 @@
+package player_test
+
+import (
+	"context"
+	"os/exec"
+	"strings"
+	"sync"
+	"testing"
+	"testing/iotest"
+
+	"github.com/bitcanon/autotyper/player"
+	"github.com/bitcanon/autotyper/script"
+)
+
+// TestPlayerGoto tests that the goto steps jump on their conditions,
+// and that they stop jumping after their maximum number of jumps
+func TestPlayerGoto(t *testing.T) {
+	if _, err := exec.LookPath("false"); err != nil {
+		t.Skip("false is not available")
+	}
+
+	tests := []struct {
+		name     string
+		steps    []script.Step
+		input    string
+		command  string
+		expected int
+	}{
+		{
+			name: "Failed",
+			steps: []script.Step{
+				{Name: "retry", Command: "false"},
+				{Goto: &script.Goto{Step: "retry", If: script.GotoFailed, Max: 2}},
+			},
+			command:  "false",
+			expected: 3,
+		},
+		{
+			name: "Succeeded",
+			steps: []script.Step{
+				{Name: "retry", Command: "echo ok", Output: "ok"},
+				{Goto: &script.Goto{Step: "retry", If: script.GotoSucceeded}},
+			},
+			command:  "echo ok",
+			expected: 4,
+		},
+		{
+			name: "ExitCode",
+			steps: []script.Step{
+				{Name: "retry", Command: "false"},
+				{Goto: &script.Goto{Step: "retry", If: "2"}},
+			},
+			command:  "false",
+			expected: 1,
+		},
+		{
+			name: "Key",
+			steps: []script.Step{
+				{Command: "echo again", Output: "again"},
+				{Goto: &script.Goto{Step: "1", If: script.GotoKey, Prompt: "Again?"}},
+			},
+			input:    "y\nn\n",
+			command:  "echo again",
+			expected: 2,
+		},
+		{
+			name: "SkipAhead",
+			steps: []script.Step{
+				{Goto: &script.Goto{Step: "end"}},
+				{Command: "echo skipped", Output: "skipped"},
+				{Name: "end", Command: "echo end", Output: "end"},
+			},
+			command:  "echo skipped",
+			expected: 0,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var out syncBuffer
+			opts := testOptions()
+			// Read the answers one line at a time, like from a terminal
+			opts.Input = iotest.OneByteReader(strings.NewReader(test.input))
+			s := &script.Scenario{Steps: test.steps}
+			if err := s.Validate(); err != nil {
+				t.Fatalf("invalid scenario: %v", err)
+			}
+
+			p := player.New(s, &out, opts)
+			if err := p.Run(context.Background()); err != nil {
+				t.Fatalf("expected no error, but got %v", err)
+			}
+			if count := strings.Count(out.String(), "> "+test.command+"\n"); count != test.expected {
+				t.Errorf("expected %q typed %d times, but got %d in %q", test.command, test.expected, count, out.String())
+			}
+			if test.input != "" && !strings.Contains(out.String(), "Again? [y/N] ") {
+				t.Errorf("expected the question in %q", out.String())
+			}
+		})
+	}
+}
+
+// TestPlayerGotoRestart tests that the jumps of the goto steps are
+// counted again when the playback is restarted from the first step
+func TestPlayerGotoRestart(t *testing.T) {
+	s := &script.Scenario{Steps: []script.Step{
+		{Name: "again", Command: "echo again", Output: "again"},
+		{Goto: &script.Goto{Step: "again", Max: 1}},
+		{Command: "echo end", Output: "end"},
+		{Command: "echo bye", Output: "bye"},
+	}}
+	if err := s.Validate(); err != nil {
+		t.Fatalf("invalid scenario: %v", err)
+	}
+
+	var out syncBuffer
+	p := player.New(s, &out, testOptions())
+	var once sync.Once
+	p.OnEvent(func(e player.Event) {
+		if e.Type == player.StepStarted && e.Command == "echo end" {
+			once.Do(p.Pause)
+		}
+	})
+
+	done := make(chan error)
+	go func() { done <- p.Run(context.Background()) }()
+	waitFor(t, p, func(s player.Status) bool { return s.State == player.Paused })
+	p.Seek(0)
+	p.Resume()
+	if err := <-done; err != nil {
+		t.Fatalf("expected no error, but got %v", err)
+	}
+	if count := strings.Count(out.String(), "> echo again\n"); count != 4 {
+		t.Errorf("expected the command typed 4 times, but got %d in %q", count, out.String())
+	}
+}
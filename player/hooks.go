@@ -0,0 +1,81 @@
+/*
+Copyright © 2023 Mikael Schultz <bitcanon@proton.me>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package player
+
+import (
+	"log/slog"
+	"time"
+
+	"github.com/bitcanon/autotyper/hooks"
+)
+
+// hookStep describes the step with the number i to the hooks
+func hookStep(i int, command string, opts Options) hooks.Step {
+	return hooks.Step{
+		Number:    i + 1,
+		Command:   command,
+		CharDelay: opts.CharDelay,
+		PreDelay:  opts.PreDelay,
+		PostDelay: opts.PostDelay,
+	}
+}
+
+// startHook calls the hook run before the step is typed, applying
+// its changes to the options. It reports whether the step is skipped
+func (p *Player) startHook(i int, command string, opts *Options) bool {
+	if p.opts.Hooks == nil {
+		return false
+	}
+	change, err := p.opts.Hooks.StepStart(hookStep(i, command, *opts))
+	if err != nil {
+		slog.Warn("hook failed", "step", i+1, "error", err)
+		return false
+	}
+	if change.CharDelay != nil {
+		opts.CharDelay = *change.CharDelay
+	}
+	if change.PreDelay != nil {
+		opts.PreDelay = *change.PreDelay
+	}
+	if change.PostDelay != nil {
+		opts.PostDelay = *change.PostDelay
+	}
+	return change.Skip
+}
+
+// endHook calls the hook run after the output of the step, with the
+// duration of the command, applying the delay after the command
+func (p *Player) endHook(i int, command string, opts *Options, elapsed time.Duration) {
+	if p.opts.Hooks == nil {
+		return
+	}
+	step := hookStep(i, command, *opts)
+	step.Duration = int(elapsed.Milliseconds())
+	change, err := p.opts.Hooks.StepEnd(step)
+	if err != nil {
+		slog.Warn("hook failed", "step", i+1, "error", err)
+		return
+	}
+	if change.PostDelay != nil {
+		opts.PostDelay = *change.PostDelay
+	}
+}
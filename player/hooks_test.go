@@ -0,0 +1,45 @@
+package player_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/bitcanon/autotyper/hooks"
+	"github.com/bitcanon/autotyper/player"
+	"github.com/bitcanon/autotyper/script"
+)
+
+// TestPlayerHooks tests that the output of the steps is passed
+// through the output hook, and that skipped steps are not played
+func TestPlayerHooks(t *testing.T) {
+	h, err := hooks.New(`
+		function onStepStart(step) {
+			if (step.command === "skip me") return {skip: true};
+		}
+		function onOutputLine(line, step) {
+			if (line === "noise") return null;
+			return step.number + "> " + line;
+		}`, "hooks.js")
+	if err != nil {
+		t.Fatalf("expected no error, but got %v", err)
+	}
+
+	s := &script.Scenario{Steps: []script.Step{
+		{Command: "skip me", Output: "never shown"},
+		{Command: "build", Output: "compiling\r\nnoise\nlinked"},
+	}}
+	opts := testOptions()
+	opts.Hooks = h
+
+	var out syncBuffer
+	if err := player.New(s, &out, opts).Run(context.Background()); err != nil {
+		t.Fatalf("expected no error, but got %v", err)
+	}
+	if strings.Contains(out.String(), "skip me") || strings.Contains(out.String(), "never shown") {
+		t.Errorf("expected the skipped step not to be played, but got %q", out.String())
+	}
+	if !strings.Contains(out.String(), "C:\\> build\n2> compiling\r\n2> linked\n") {
+		t.Errorf("expected the filtered output, but got %q", out.String())
+	}
+}
@@ -0,0 +1,65 @@
+package player_test
+
+import (
+	"context"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bitcanon/autotyper/graphics"
+	"github.com/bitcanon/autotyper/player"
+	"github.com/bitcanon/autotyper/script"
+)
+
+// TestPlayerImage tests that the images are drawn between the
+// commands, relative to the directory of the scenario, and that
+// only their names are printed when the terminal draws no images
+func TestPlayerImage(t *testing.T) {
+	dir := t.TempDir()
+	f, err := os.Create(filepath.Join(dir, "diagram.png"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	png.Encode(f, image.NewRGBA(image.Rect(0, 0, 4, 4)))
+	f.Close()
+
+	s := &script.Scenario{Dir: dir, Steps: []script.Step{
+		{Image: "diagram.png"},
+		{Command: "echo done", Output: "done"},
+	}}
+	tests := []struct {
+		protocol graphics.Protocol
+		expected string
+	}{
+		{protocol: graphics.None, expected: "[diagram.png]\033[0m\nC:\\> echo done"},
+		{protocol: graphics.ITerm2, expected: "\033]1337;File="},
+		{protocol: graphics.Sixel, expected: "\033P0;1q"},
+	}
+
+	for _, test := range tests {
+		opts := testOptions()
+		opts.Images = test.protocol
+
+		var out syncBuffer
+		if err := player.New(s, &out, opts).Run(context.Background()); err != nil {
+			t.Fatalf("expected no error, but got %v", err)
+		}
+		if !strings.Contains(out.String(), test.expected) {
+			t.Errorf("protocol %v: expected %q in %q", test.protocol, test.expected, out.String())
+		}
+	}
+
+	// A missing image stops the demo, and is found by the preflight
+	missing := &script.Scenario{Dir: dir, Steps: []script.Step{{Image: "missing.png"}}}
+	opts := testOptions()
+	opts.Images = graphics.Sixel
+	if err := player.New(missing, &syncBuffer{}, opts).Run(context.Background()); err == nil {
+		t.Errorf("expected error for a missing image, but got nil")
+	}
+	if issues := player.New(missing, &syncBuffer{}, opts).Preflight(0, 0); len(issues) != 1 {
+		t.Errorf("expected an issue for the missing image, but got %+v", issues)
+	}
+}
@@ -0,0 +1,121 @@
+/*
+Copyright © 2023 Mikael Schultz <bitcanon@proton.me>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package player
+
+import (
+	"context"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/bitcanon/autotyper/cli"
+	"github.com/bitcanon/autotyper/script"
+)
+
+// syncWriter serializes the writes of the command output
+// and the typed input, which happen in different goroutines
+type syncWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (s *syncWriter) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.w.Write(p)
+}
+
+// run executes the command writing to out, typing the inputs into
+// its standard input at their times while it runs. Inputs that are
+// not yet typed when the command exits are dropped
+func (p *Player) run(ctx context.Context, command string, inputs []script.Input, out io.Writer, charDelay int) error {
+	if len(inputs) == 0 {
+		return cli.ExecuteCommandInput(p.shell, command, nil, out)
+	}
+
+	screen := &syncWriter{w: out}
+	return runPipe(ctx, p.shell, command, screen, func(ctx context.Context, in io.Writer) {
+		p.typeInput(ctx, inputs, screen, in, charDelay)
+	})
+}
+
+// runPipe executes the command in the shell with its standard input
+// connected to a pipe, which session writes to in its own goroutine.
+// The context passed to session is cancelled when the command exits
+func runPipe(ctx context.Context, shell *cli.Shell, command string, out io.Writer, session func(context.Context, io.Writer)) error {
+	// Use an OS pipe rather than an io.Pipe, so that waiting
+	// for the command does not wait for the session to end
+	r, w, err := os.Pipe()
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		defer w.Close()
+		session(ctx, w)
+	}()
+
+	err = cli.ExecuteCommandInput(shell, command, r, out)
+	cancel()
+	<-done
+	return err
+}
+
+// echoWriter writes to the standard input of a command and to the
+// screen, for commands that do not echo their input themselves
+type echoWriter struct {
+	in     io.Writer
+	screen io.Writer
+}
+
+func (e *echoWriter) Write(p []byte) (int, error) {
+	if _, err := io.WriteString(e.screen, cli.EchoControl(string(p))); err != nil {
+		return 0, err
+	}
+	return e.in.Write(p)
+}
+
+// typeInput types the inputs on out at their times and writes them
+// to in, if not nil, with the keys of the key notation (e.g. "<C-c>")
+// sent as such and shown as a terminal echoes them. It returns early
+// if writing to in fails, or with an error if the context is cancelled
+func (p *Player) typeInput(ctx context.Context, inputs []script.Input, out io.Writer, in io.Writer, charDelay int) error {
+	for _, input := range inputs {
+		if err := p.sleep(ctx, input.After); err != nil {
+			return err
+		}
+		text := cli.ExpandKeys(input.Text)
+		if err := cli.TypeText(p.clock(), cli.EchoControl(text), out, charDelay); err != nil {
+			return err
+		}
+		if in != nil {
+			if _, err := io.WriteString(in, text); err != nil {
+				return nil
+			}
+		}
+	}
+	return nil
+}
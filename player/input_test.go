@@ -0,0 +1,90 @@
+package player_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/bitcanon/autotyper/player"
+	"github.com/bitcanon/autotyper/script"
+)
+
+// TestPlayerInput tests that the input of a step is written to the
+// running command and typed on the screen
+func TestPlayerInput(t *testing.T) {
+	var out syncBuffer
+	s := &script.Scenario{Steps: []script.Step{
+		{Command: "sed -u s/^/got:/", Input: []script.Input{
+			{After: 10, Text: "yes\n"},
+			{Text: "no\n"},
+		}},
+	}}
+
+	p := player.New(s, &out, testOptions())
+	if err := p.Run(context.Background()); err != nil {
+		t.Fatalf("expected no error, but got %v", err)
+	}
+
+	for _, expected := range []string{"yes\n", "no\n", "got:yes\n", "got:no\n"} {
+		if !strings.Contains(out.String(), expected) {
+			t.Errorf("expected output to contain %q, but got %q", expected, out.String())
+		}
+	}
+}
+
+// TestPlayerInputAfterExit tests that the command is not kept
+// waiting for inputs that come after it has exited
+func TestPlayerInputAfterExit(t *testing.T) {
+	var out syncBuffer
+	s := &script.Scenario{Steps: []script.Step{
+		{Command: "echo done", Input: []script.Input{{After: 60000, Text: "never\n"}}},
+	}}
+
+	p := player.New(s, &out, testOptions())
+	if err := p.Run(context.Background()); err != nil {
+		t.Fatalf("expected no error, but got %v", err)
+	}
+	if strings.Contains(out.String(), "never") {
+		t.Errorf("expected the input to be dropped, but got %q", out.String())
+	}
+}
+
+// TestPlayerInputCannedOutput tests that the input is typed
+// after a canned output
+func TestPlayerInputCannedOutput(t *testing.T) {
+	var out syncBuffer
+	s := &script.Scenario{Steps: []script.Step{
+		{Command: "apt upgrade", Output: "Do you want to continue? [Y/n] ", Input: []script.Input{{Text: "Y\n"}}},
+	}}
+
+	p := player.New(s, &out, testOptions())
+	if err := p.Run(context.Background()); err != nil {
+		t.Fatalf("expected no error, but got %v", err)
+	}
+
+	expected := "Do you want to continue? [Y/n] Y\n"
+	if !strings.Contains(out.String(), expected) {
+		t.Errorf("expected output to contain %q, but got %q", expected, out.String())
+	}
+}
+
+// TestPlayerInputKeys tests that the keys of the key notation are
+// sent to the command and shown as a terminal echoes them
+func TestPlayerInputKeys(t *testing.T) {
+	var out syncBuffer
+	s := &script.Scenario{Steps: []script.Step{
+		{Command: "sed -u s/^/got:/", Input: []script.Input{{Text: "a<Tab>b<Enter>"}}},
+		{Command: "python3", Output: ">>> ", Input: []script.Input{{Text: "<Up><Esc><lt>x><C-c><Enter>"}}},
+	}}
+
+	p := player.New(s, &out, testOptions())
+	if err := p.Run(context.Background()); err != nil {
+		t.Fatalf("expected no error, but got %v", err)
+	}
+
+	for _, expected := range []string{"got:a\tb\n", ">>> ^[[A^[<x>^C\n"} {
+		if !strings.Contains(out.String(), expected) {
+			t.Errorf("expected output to contain %q, but got %q", expected, out.String())
+		}
+	}
+}
@@ -0,0 +1,122 @@
+/*
+Copyright © 2023 Mikael Schultz <bitcanon@proton.me>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package player
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bitcanon/autotyper/cli"
+	"github.com/bitcanon/autotyper/script"
+)
+
+// Define the length of the password typed when none is given
+const defaultPasswordLength = 10
+
+// logIn simulates the login before the first prompt: the username
+// is typed, the password is typed without echo, and the banner is
+// printed after logging in
+func (p *Player) logIn(ctx context.Context, login *script.Login) error {
+	username := login.Username
+	if username == "" {
+		username = p.opts.Prompt.Username
+	}
+	host := login.Host
+	if host == "" {
+		host = p.opts.Prompt.Hostname
+	}
+
+	switch login.Style {
+	case script.LoginConsole:
+		fmt.Fprintf(p.out, "%s login: ", host)
+		if err := p.sleep(ctx, p.opts.PreDelay); err != nil {
+			return err
+		}
+		cli.TypeText(p.clock(), username, p.out, p.opts.CharDelay)
+		fmt.Fprint(p.out, "\nPassword: ")
+		if err := p.typePassword(ctx, login.Password); err != nil {
+			return err
+		}
+	case script.LoginSSH:
+		fmt.Fprintf(p.out, "%s@%s's password: ", username, host)
+		if err := p.typePassword(ctx, login.Password); err != nil {
+			return err
+		}
+	case script.LoginCloudShell:
+		fmt.Fprint(p.out, "Connecting to Cloud Shell")
+		for i := 0; i < 3; i++ {
+			if err := p.sleep(ctx, p.opts.PreDelay); err != nil {
+				return err
+			}
+			fmt.Fprint(p.out, ".")
+		}
+		fmt.Fprintln(p.out)
+	}
+
+	if login.Banner != "" {
+		fmt.Fprint(p.out, login.Banner)
+		if !strings.HasSuffix(login.Banner, "\n") {
+			fmt.Fprintln(p.out)
+		}
+	}
+	return nil
+}
+
+// loginDuration returns the time the login of logIn takes
+func (p *Player) loginDuration(login *script.Login) time.Duration {
+	n := len([]rune(login.Password))
+	if n == 0 {
+		n = defaultPasswordLength
+	}
+	switch login.Style {
+	case script.LoginConsole:
+		username := login.Username
+		if username == "" {
+			username = p.opts.Prompt.Username
+		}
+		return milliseconds(2*p.opts.PreDelay + (len([]rune(username))+n)*p.opts.CharDelay)
+	case script.LoginSSH:
+		return milliseconds(p.opts.PreDelay + n*p.opts.CharDelay)
+	case script.LoginCloudShell:
+		return milliseconds(3 * p.opts.PreDelay)
+	}
+	return 0
+}
+
+// typePassword waits as long as typing the password takes, since
+// it is not echoed, and then presses Enter
+func (p *Player) typePassword(ctx context.Context, password string) error {
+	if err := p.sleep(ctx, p.opts.PreDelay); err != nil {
+		return err
+	}
+	n := len([]rune(password))
+	if n == 0 {
+		n = defaultPasswordLength
+	}
+	if err := p.sleep(ctx, n*p.opts.CharDelay); err != nil {
+		return err
+	}
+	fmt.Fprintln(p.out)
+	return nil
+}
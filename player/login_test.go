@@ -0,0 +1,80 @@
+package player_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/bitcanon/autotyper/cli"
+	"github.com/bitcanon/autotyper/player"
+	"github.com/bitcanon/autotyper/script"
+)
+
+// TestPlayerLogin tests that the login is simulated before the first
+// prompt, with the username and hostname of the prompt as defaults
+func TestPlayerLogin(t *testing.T) {
+	tests := []struct {
+		name     string
+		login    script.Login
+		expected string
+	}{
+		{
+			name:     "Console",
+			login:    script.Login{Style: script.LoginConsole, Password: "secret"},
+			expected: "box login: root\nPassword: \nC:\\> ",
+		},
+		{
+			name:     "SSH",
+			login:    script.Login{Style: script.LoginSSH, Username: "demo", Host: "stage", Banner: "Welcome to Ubuntu"},
+			expected: "demo@stage's password: \nWelcome to Ubuntu\nC:\\> ",
+		},
+		{
+			name:     "CloudShell",
+			login:    script.Login{Style: script.LoginCloudShell, Banner: "Welcome to Cloud Shell\n"},
+			expected: "Connecting to Cloud Shell...\nWelcome to Cloud Shell\nC:\\> ",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var out syncBuffer
+			opts := testOptions()
+			opts.Prompt.Username, opts.Prompt.Hostname = "root", "box"
+
+			login := test.login
+			s := &script.Scenario{Login: &login, Steps: []script.Step{{Command: "echo done"}}}
+			if err := player.New(s, &out, opts).Run(context.Background()); err != nil {
+				t.Fatalf("expected no error, but got %v", err)
+			}
+
+			_, output, _ := strings.Cut(out.String(), "\033[2J")
+			if !strings.HasPrefix(output, test.expected) {
+				t.Errorf("expected output starting with %q, but got %q", test.expected, output)
+			}
+		})
+	}
+}
+
+// TestPlayerLoginPassword tests that the password is not echoed
+// but takes as long to type as it would with echo
+func TestPlayerLoginPassword(t *testing.T) {
+	opts := testOptions()
+	opts.CharDelay = 10
+	opts.Prompt = cli.Prompt{Username: "root", Shell: cli.Cmd}
+
+	s := &script.Scenario{
+		Login: &script.Login{Style: script.LoginConsole, Password: "hunter2"},
+		Steps: []script.Step{{Command: "echo done"}},
+	}
+	var out syncBuffer
+	report := player.New(s, &out, opts).Estimate()
+	if expected := int64(10 * (4 + 7)); report.Total.Milliseconds() < expected {
+		t.Errorf("expected at least %dms for the login, but got %v", expected, report.Total)
+	}
+	if err := player.New(s, &out, opts).Run(context.Background()); err != nil {
+		t.Fatalf("expected no error, but got %v", err)
+	}
+	if strings.Contains(out.String(), "hunter2") {
+		t.Errorf("expected the password not to be echoed, but got %q", out.String())
+	}
+}
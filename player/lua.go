@@ -0,0 +1,232 @@
+/*
+Copyright © 2023 Mikael Schultz <bitcanon@proton.me>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package player
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/bitcanon/autotyper/cli"
+	"github.com/bitcanon/autotyper/script"
+	lua "github.com/yuin/gopher-lua"
+)
+
+// luaStep is the Lua step being run, for the functions of the demo
+// table called by its code
+type luaStep struct {
+	ctx      context.Context
+	index    int
+	shown    *cli.Prompt
+	commands *int
+
+	// Whether output was printed since the last prompt
+	printed bool
+}
+
+// runLua runs the Lua code of the step with the number i. The code
+// drives the playback with the functions of the demo table:
+//
+//	demo.run(command [, options])  type and run a command, with the
+//	                               options output, caption, char_delay,
+//	                               pre_delay and post_delay
+//	demo.print(...)                print a line of output (also print)
+//	demo.write(text)               print text without a newline
+//	demo.sleep(ms)                 pause for a number of milliseconds
+//	demo.think(ms)                 pause at the prompt with a blinking cursor
+//	demo.get(name)                 the value of a variable, or nil
+//	demo.set(name, value)          set the value of a variable
+//	demo.step                      the number of the step, from 1
+//
+// All Lua steps of a scenario share the global variables
+func (p *Player) runLua(ctx context.Context, i int, code string, shown *cli.Prompt, commands *int) error {
+	slog.Debug("running Lua step", "step", i+1, "bytes", len(code))
+	if p.lua == nil {
+		p.lua = p.newLua()
+	}
+	p.luaStep = &luaStep{ctx: ctx, index: i, shown: shown, commands: commands}
+	defer func() { p.luaStep = nil }()
+
+	p.lua.SetContext(ctx)
+	p.lua.GetGlobal("demo").(*lua.LTable).RawSetString("step", lua.LNumber(i+1))
+	err := p.lua.DoString(code)
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	if err != nil {
+		return fmt.Errorf("step %d: %w", i+1, err)
+	}
+
+	// Print a new prompt below the output
+	if p.luaStep.printed {
+		if !p.line.atStart() {
+			fmt.Fprintln(p.out)
+		}
+		cli.PrintPrompt(*shown, p.out)
+	}
+	return nil
+}
+
+// newLua returns a Lua interpreter with the demo table. Only the base,
+// table, string and math libraries are opened, without the functions
+// loading files, so that the Lua steps can only run the commands with
+// demo.run, which checks them like the other steps
+func (p *Player) newLua() *lua.LState {
+	L := lua.NewState(lua.Options{SkipOpenLibs: true})
+	for _, lib := range []struct {
+		name string
+		open lua.LGFunction
+	}{
+		{lua.BaseLibName, lua.OpenBase},
+		{lua.TabLibName, lua.OpenTable},
+		{lua.StringLibName, lua.OpenString},
+		{lua.MathLibName, lua.OpenMath},
+	} {
+		L.Push(L.NewFunction(lib.open))
+		L.Push(lua.LString(lib.name))
+		L.Call(1, 0)
+	}
+	for _, name := range []string{"dofile", "loadfile", "require", "module"} {
+		L.SetGlobal(name, lua.LNil)
+	}
+	demo := L.SetFuncs(L.NewTable(), map[string]lua.LGFunction{
+		"run":   p.luaRun,
+		"print": p.luaPrint,
+		"write": p.luaWrite,
+		"sleep": p.luaSleep,
+		"think": p.luaThink,
+		"get":   p.luaGet,
+		"set":   p.luaSet,
+	})
+	L.SetGlobal("demo", demo)
+	L.SetGlobal("print", L.GetField(demo, "print"))
+	return L
+}
+
+// luaRun types and runs a command: demo.run(command [, options])
+func (p *Player) luaRun(L *lua.LState) int {
+	step := script.Step{Command: L.CheckString(1)}
+	if options := L.OptTable(2, nil); options != nil {
+		step.Output = luaString(options, "output")
+		step.Caption = luaString(options, "caption")
+		step.Timing = &script.Timing{
+			CharDelay: luaInt(options, "char_delay"),
+			PreDelay:  luaInt(options, "pre_delay"),
+			PostDelay: luaInt(options, "post_delay"),
+		}
+	}
+
+	s := p.luaStep
+	if s.printed {
+		// Start the command on a new prompt below the output
+		if !p.line.atStart() {
+			fmt.Fprintln(p.out)
+		}
+		cli.PrintPrompt(*s.shown, p.out)
+		s.printed = false
+	}
+	if err := p.playCommand(s.ctx, s.index, step, s.shown, s.commands, nil); err != nil {
+		L.RaiseError("%v", err)
+	}
+	return 0
+}
+
+// luaPrint prints the values separated by tabs, like print
+func (p *Player) luaPrint(L *lua.LState) int {
+	values := make([]string, L.GetTop())
+	for i := range values {
+		values[i] = L.ToStringMeta(L.Get(i + 1)).String()
+	}
+	p.luaOutput(strings.Join(values, "\t") + "\n")
+	return 0
+}
+
+// luaWrite prints the text without a newline: demo.write(text)
+func (p *Player) luaWrite(L *lua.LState) int {
+	p.luaOutput(L.CheckString(1))
+	return 0
+}
+
+// luaOutput prints output of the Lua step, which replaces
+// the prompt line if it is the first output after the prompt
+func (p *Player) luaOutput(text string) {
+	if !p.luaStep.printed {
+		p.eraseLine()
+		p.luaStep.printed = true
+	}
+	fmt.Fprint(p.out, text)
+}
+
+// luaSleep pauses: demo.sleep(ms)
+func (p *Player) luaSleep(L *lua.LState) int {
+	if err := p.sleep(p.luaStep.ctx, scale(L.CheckInt(1), p.delayScale)); err != nil {
+		L.RaiseError("%v", err)
+	}
+	return 0
+}
+
+// luaThink pauses at the prompt with a blinking cursor: demo.think(ms)
+func (p *Player) luaThink(L *lua.LState) int {
+	if err := p.think(p.luaStep.ctx, scale(L.CheckInt(1), p.delayScale)); err != nil {
+		L.RaiseError("%v", err)
+	}
+	return 0
+}
+
+// luaGet returns the value of a variable, or nil: demo.get(name)
+func (p *Player) luaGet(L *lua.LState) int {
+	value, ok := p.vars[L.CheckString(1)]
+	if !ok {
+		L.Push(lua.LNil)
+		return 1
+	}
+	L.Push(lua.LString(value))
+	return 1
+}
+
+// luaSet sets the value of a variable: demo.set(name, value)
+func (p *Player) luaSet(L *lua.LState) int {
+	name := L.CheckString(1)
+	if !script.ValidVariableName(name) {
+		L.ArgError(1, fmt.Sprintf("invalid variable name %q", name))
+	}
+	p.vars[name] = L.ToStringMeta(L.CheckAny(2)).String()
+	return 0
+}
+
+// luaString returns a string field of the table, or an empty string
+func luaString(t *lua.LTable, name string) string {
+	if s, ok := t.RawGetString(name).(lua.LString); ok {
+		return string(s)
+	}
+	return ""
+}
+
+// luaInt returns a number field of the table, or nil
+func luaInt(t *lua.LTable, name string) *int {
+	if n, ok := t.RawGetString(name).(lua.LNumber); ok {
+		i := int(n)
+		return &i
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+package player_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bitcanon/autotyper/player"
+	"github.com/bitcanon/autotyper/script"
+)
+
+// TestPlayerLua tests that Lua steps run commands, print output
+// and share variables with the other steps
+func TestPlayerLua(t *testing.T) {
+	s := &script.Scenario{Steps: []script.Step{
+		{Lua: `for i = 1, 2 do demo.run("echo " .. i, {output = "line " .. i * 10}) end`},
+		{Lua: `count = 3; print("step", demo.step); demo.set("pods", count)`},
+		{Command: "kubectl scale --replicas=${pods}", Output: "scaled"},
+		{Lua: `if demo.get("pods") == "3" and demo.get("missing") == nil then demo.write("ok") end`},
+	}}
+
+	var out syncBuffer
+	p := player.New(s, &out, testOptions())
+	if err := p.Run(context.Background()); err != nil {
+		t.Fatalf("expected no error, but got %v", err)
+	}
+
+	expected := "\033[H\033[2JC:\\> echo 1\nline 10\nC:\\> echo 2\nline 20\nC:\\> \r\033[Kstep\t2\nC:\\> kubectl scale --replicas=3\nscaled\nC:\\> \r\033[Kok\nC:\\> "
+	if out.String() != expected {
+		t.Errorf("expected %q, but got %q", expected, out.String())
+	}
+	if commands := len(p.Report().Steps); commands != 3 {
+		t.Errorf("expected 3 commands in the report, but got %d", commands)
+	}
+}
+
+// TestPlayerLuaErrors tests that errors in the Lua code stop the
+// playback, and that running code is stopped when cancelled
+func TestPlayerLuaErrors(t *testing.T) {
+	s := &script.Scenario{Steps: []script.Step{{Lua: `error("no cluster")`}}}
+	err := player.New(s, &syncBuffer{}, testOptions()).Run(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "step 1:") || !strings.Contains(err.Error(), "no cluster") {
+		t.Errorf("expected the error of the Lua code, but got %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	s = &script.Scenario{Steps: []script.Step{{Lua: `while true do end`}}}
+	if err := player.New(s, &syncBuffer{}, testOptions()).Run(ctx); err != context.DeadlineExceeded {
+		t.Errorf("expected %v, but got %v", context.DeadlineExceeded, err)
+	}
+}
+
+// TestPlayerLuaLibraries tests that the Lua steps can use the string,
+// table and math libraries, but not the libraries of the system, so
+// that commands are only run with demo.run
+func TestPlayerLuaLibraries(t *testing.T) {
+	s := &script.Scenario{Steps: []script.Step{{Lua: `
+		local names = {}
+		for _, name in ipairs({"os", "io", "package", "require", "module", "dofile", "loadfile", "debug"}) do
+			if _G[name] ~= nil then table.insert(names, name) end
+		end
+		if os == nil or os.execute == nil then
+			demo.write(string.format("%s|%d|%s", table.concat(names, ","), math.max(1, 2), string.upper("ok")))
+		end`}}}
+
+	var out syncBuffer
+	if err := player.New(s, &out, testOptions()).Run(context.Background()); err != nil {
+		t.Fatalf("expected no error, but got %v", err)
+	}
+	if !strings.Contains(out.String(), "\r\033[K|2|OK\n") {
+		t.Errorf("expected no library of the system, but got %q", out.String())
+	}
+}
@@ -0,0 +1,99 @@
+/*
+Copyright © 2023 Mikael Schultz <bitcanon@proton.me>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package player
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+
+	"github.com/bitcanon/autotyper/script"
+)
+
+// Define the defaults of the breaks of canned outputs, a page of a
+// terminal of 24 rows and the prompt of the CLI of a network device
+const (
+	moreLines  = 23
+	moreDelay  = 1500
+	morePrompt = " --More-- "
+)
+
+// page prints the canned output with a break after each page of lines.
+// The prompt of the break is shown for the delay, and then erased, as
+// if the presenter pressed the space bar to see the next page
+func (p *Player) page(ctx context.Context, out io.Writer, output string, more *script.More) error {
+	lines, delay, prompt := p.moreOptions(more)
+	for i, line := range splitLines(output) {
+		if i > 0 && i%lines == 0 {
+			slog.Debug("paging canned output", "line", i, "delay", delay)
+			fmt.Fprint(out, prompt)
+			err := p.sleep(ctx, delay)
+			fmt.Fprint(out, "\r\033[K")
+			if err != nil {
+				return err
+			}
+		}
+		fmt.Fprint(out, line)
+	}
+	return nil
+}
+
+// moreOptions returns the page size, the scaled delay and the
+// prompt of the breaks, with the defaults for zero values
+func (p *Player) moreOptions(more *script.More) (lines, delay int, prompt string) {
+	lines, delay, prompt = moreLines, moreDelay, morePrompt
+	if more.Lines > 0 {
+		lines = more.Lines
+	}
+	if more.Delay > 0 {
+		delay = more.Delay
+	}
+	if more.Prompt != "" {
+		prompt = more.Prompt
+	}
+	return lines, scale(delay, p.delayScale), prompt
+}
+
+// moreDuration returns the time spent in the breaks of the
+// canned output, counted from the lines of its template
+func (p *Player) moreDuration(output string, more *script.More) int {
+	if more == nil {
+		return 0
+	}
+	lines, delay, _ := p.moreOptions(more)
+	n := len(splitLines(output))
+	if n == 0 {
+		return 0
+	}
+	return (n - 1) / lines * delay
+}
+
+// splitLines splits the output after each newline
+func splitLines(output string) []string {
+	lines := strings.SplitAfter(output, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
@@ -0,0 +1,54 @@
+package player_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/bitcanon/autotyper/player"
+	"github.com/bitcanon/autotyper/script"
+)
+
+// TestPlayerMore tests that the canned output is broken into pages,
+// with the prompt shown and erased between them
+func TestPlayerMore(t *testing.T) {
+	tests := []struct {
+		name     string
+		output   string
+		more     script.More
+		expected string
+	}{
+		{
+			name:     "Pages",
+			output:   "1\n2\n3\n4\n5\n",
+			more:     script.More{Lines: 2, Delay: 1},
+			expected: "1\n2\n --More-- \r\033[K3\n4\n --More-- \r\033[K5\n",
+		},
+		{
+			name:     "LastPageFull",
+			output:   "1\n2\n3\n4",
+			more:     script.More{Lines: 2, Delay: 1, Prompt: "-- more --"},
+			expected: "1\n2\n-- more --\r\033[K3\n4\n",
+		},
+		{
+			name:     "SinglePage",
+			output:   "1\n2\n",
+			more:     script.More{Lines: 2, Delay: 1},
+			expected: "1\n2\n",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s := &script.Scenario{Steps: []script.Step{{Command: "show run", Output: test.output, More: &test.more}}}
+
+			var out syncBuffer
+			if err := player.New(s, &out, testOptions()).Run(context.Background()); err != nil {
+				t.Fatalf("expected no error, but got %v", err)
+			}
+			if !strings.Contains(out.String(), "show run\n"+test.expected) {
+				t.Errorf("expected the output %q, but got %q", test.expected, out.String())
+			}
+		})
+	}
+}
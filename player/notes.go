@@ -0,0 +1,56 @@
+/*
+Copyright © 2023 Mikael Schultz <bitcanon@proton.me>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package player
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/bitcanon/autotyper/locale"
+	"github.com/bitcanon/autotyper/script"
+)
+
+// writeNotes writes the presenter notes of the step with the index to
+// the notes output, under the number and the command of the step.
+// Since the audience does not see the notes, a failure to write them
+// is logged instead of stopping the demo
+func (p *Player) writeNotes(i int, step script.Step, command string) {
+	if p.opts.Notes == nil || step.Notes == "" {
+		return
+	}
+
+	var b strings.Builder
+	b.WriteString(locale.T("Step %d of %d", i+1, len(p.steps)))
+	if command != "" {
+		b.WriteString(": " + command)
+	}
+	b.WriteString("\n")
+	for _, line := range strings.Split(script.Expand(step.Notes, p.vars), "\n") {
+		b.WriteString("  " + line + "\n")
+	}
+	b.WriteString("\n")
+
+	if _, err := fmt.Fprint(p.opts.Notes, b.String()); err != nil {
+		slog.Warn("failed to write the presenter notes", "step", i+1, "error", err)
+	}
+}
@@ -0,0 +1,37 @@
+package player_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/bitcanon/autotyper/player"
+	"github.com/bitcanon/autotyper/script"
+)
+
+// TestPlayerNotes tests that the notes of the steps are written to
+// the notes output only, with the variables replaced
+func TestPlayerNotes(t *testing.T) {
+	s := &script.Scenario{Steps: []script.Step{
+		{Command: "echo ${name}", Output: "demo\n", Notes: "Greet ${name}\nThen move on"},
+		{Command: "ls", Output: "a.txt\n"},
+		{Think: 1, Notes: "Wait for questions"},
+	}}
+	opts := testOptions()
+	opts.Variables = map[string]string{"name": "demo"}
+	var notes strings.Builder
+	opts.Notes = &notes
+
+	var out strings.Builder
+	if err := player.New(s, &out, opts).Run(context.Background()); err != nil {
+		t.Fatalf("expected no error, but got %v", err)
+	}
+
+	expected := "Step 1 of 3: echo demo\n  Greet demo\n  Then move on\n\nStep 3 of 3\n  Wait for questions\n\n"
+	if notes.String() != expected {
+		t.Errorf("expected notes %q, but got %q", expected, notes.String())
+	}
+	if strings.Contains(out.String(), "Greet") {
+		t.Errorf("expected no notes on the screen, but got %q", out.String())
+	}
+}
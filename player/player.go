@@ -0,0 +1,1285 @@
+/*
+Copyright © 2023 Mikael Schultz <bitcanon@proton.me>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package player
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bitcanon/autotyper/audio"
+	"github.com/bitcanon/autotyper/audit"
+	"github.com/bitcanon/autotyper/cache"
+	"github.com/bitcanon/autotyper/cli"
+	"github.com/bitcanon/autotyper/graphics"
+	"github.com/bitcanon/autotyper/hooks"
+	"github.com/bitcanon/autotyper/locale"
+	"github.com/bitcanon/autotyper/script"
+	"github.com/bitcanon/autotyper/simulate"
+	"github.com/bitcanon/autotyper/wasm"
+	"github.com/bitcanon/autotyper/webhook"
+	lua "github.com/yuin/gopher-lua"
+)
+
+// State describes what the player is currently doing
+type State int
+
+// Define constants for the player states
+const (
+	Idle State = iota
+	Playing
+	Paused
+	Finished
+)
+
+// String returns the name of the state (e.g. "playing")
+func (s State) String() string {
+	switch s {
+	case Idle:
+		return "idle"
+	case Playing:
+		return "playing"
+	case Paused:
+		return "paused"
+	case Finished:
+		return "finished"
+	default:
+		return fmt.Sprintf("unknown(%d)", int(s))
+	}
+}
+
+// EventType describes what happened during the playback
+type EventType int
+
+// Define constants for the event types
+const (
+	StepStarted EventType = iota
+	StepFinished
+	StateChanged
+)
+
+// String returns the name of the event type (e.g. "step-started")
+func (t EventType) String() string {
+	switch t {
+	case StepStarted:
+		return "step-started"
+	case StepFinished:
+		return "step-finished"
+	case StateChanged:
+		return "state-changed"
+	default:
+		return fmt.Sprintf("unknown(%d)", int(t))
+	}
+}
+
+// Event is emitted by the player as the playback progresses
+type Event struct {
+	Type EventType
+
+	// The index and the text of the command, for step events
+	Step    int
+	Command string
+
+	// The new state of the player, for state events
+	State State
+}
+
+// Options holds the settings used when playing back commands
+type Options struct {
+	// The prompt printed before each command
+	Prompt cli.Prompt
+
+	// Delay between each character in milliseconds
+	CharDelay int
+
+	// The input method simulated when typing CJK text
+	InputMethod cli.InputMethod
+
+	// Ramp slows down the typing of the first commands
+	Ramp Ramp
+
+	// Hesitation lengthens the delay before the keys typed with
+	// shift or an awkward reach (see cli.Typist)
+	Hesitation float64
+
+	// Pauses before the pipes, flags and path separators
+	Pauses cli.TokenPauses
+
+	// Delay before and after each command in milliseconds
+	PreDelay  int
+	PostDelay int
+
+	// Disable clearing the screen between commands
+	NoClear bool
+
+	// Type the clear command of the shell (e.g. "clear") before
+	// the screen is cleared, as a human operator would do
+	TypeClear bool
+
+	// Type "exit" after the last command and print the closing
+	// message, or the closing line of the shell if it is empty
+	// (e.g. "logout"). If ExitClear is set, the screen is cleared
+	// afterwards, as if the session had been left
+	TypeExit    bool
+	ExitMessage string
+	ExitClear   bool
+
+	// Clear the scrollback buffer of the terminal as well
+	// when the screen is cleared
+	ClearScrollback bool
+
+	// Clear the screen whenever the output of a command exceeds
+	// this many lines, so that chatty commands do not scroll the
+	// screen. Zero disables it
+	ClearAfter int
+
+	// Play on the alternate screen buffer, restoring the
+	// contents of the terminal when the playback ends
+	AltScreen bool
+
+	// Keep the output easy to follow with a screen reader: the
+	// screen is only cleared before the first command, the cursor
+	// does not blink, and a plain status line precedes each command
+	Accessible bool
+
+	// The message of the day and the summary of the system printed
+	// by motd steps. If empty, cli.DefaultMotd is printed, and the
+	// empty fields of the summary are those of cli.DefaultSystemInfo
+	Motd       string
+	SystemInfo cli.SystemInfo
+
+	// Place each executed command on the clipboard of the terminal
+	// with OSC 52, so the viewers can paste it
+	CopyCommands bool
+
+	// The protocol the images of the image steps are drawn with.
+	// With graphics.None, the name of the image is printed instead
+	Images graphics.Protocol
+
+	// How long the QR codes of the qrcode steps are shown
+	// for in milliseconds
+	QRCodeDuration int
+
+	// Play the audio clips of the wait-audio steps, instead of
+	// only waiting for as long as they last
+	PlayAudio bool
+
+	// The speaker of the captions and of the commands that are
+	// comments, spoken while the commands are typed, none if nil
+	Speaker *audio.Speaker
+
+	// The presenter notes of the steps are written to Notes as each
+	// step begins, away from the audience, none if nil
+	Notes io.Writer
+
+	// Cue the chapters of the scenario as they begin: ring the bell
+	// of the terminal, and run the command (e.g. a script adding a
+	// chapter marker to a recording), none if empty
+	ChapterBell    bool
+	ChapterCommand string
+
+	// Values of the variables (e.g. "ticket") replacing
+	// the references (e.g. "${ticket}") in the commands
+	Variables map[string]string
+
+	// Input is read for the answers of the presenter to the
+	// questions of the player. If nil, os.Stdin is read
+	Input io.Reader
+
+	// Clock tells the time and waits for the typing and the
+	// delays. If nil, the clock of the system is used
+	Clock cli.Clock
+
+	// Ask is called by steps asking the presenter for the value
+	// of a variable. If nil, the value is read from stdin
+	Ask func(name string) (string, error)
+
+	// Patterns of dangerous commands (e.g. "rm -rf") that must be
+	// confirmed before they are executed, or are only typed if
+	// TypeOnlyDangerous is set
+	Dangerous         []*regexp.Regexp
+	TypeOnlyDangerous bool
+
+	// Confirm is called before a dangerous command is executed.
+	// If nil, the confirmation is read from stdin
+	Confirm func(command string) (bool, error)
+
+	// Sandbox simulates the output of the commands instead of
+	// executing them. If nil, the commands are executed
+	Sandbox *simulate.Sandbox
+
+	// Hooks are the JavaScript hooks called before and after each
+	// command and for each line of its output. If nil, no hooks run
+	Hooks *hooks.Hooks
+
+	// Audit records the commands executed, as coming from the script
+	// named Source. If nil, the commands are not recorded
+	Audit  *audit.Log
+	Source string
+
+	// The webhooks fired when the playback starts, finishes or
+	// fails, none if nil
+	Webhooks *webhook.Notifier
+
+	// Highlights are the rules highlighting the output of all
+	// commands, after the rules of the step and of the scenario
+	Highlights []cli.Highlight
+
+	// Filters are the WebAssembly modules the output of the commands
+	// is streamed through, in order, before it is printed
+	Filters []*wasm.Module
+
+	// Stream is a plain text script parsed while it is read, whose
+	// steps are played after those of the scenario. If nil, only the
+	// steps of the scenario are played
+	Stream *script.Stream
+
+	// Cache replays the output of the commands cached by a previous
+	// run, and caches the output of the commands run, for the commands
+	// nothing is typed into. If RefreshCache is set, the commands are
+	// run and cached again. If nil, the commands always run
+	Cache        *cache.Cache
+	RefreshCache bool
+
+	// Play only the steps from the index Start up to, but not
+	// including, the index End, by index from 0 (see script.Range).
+	// If End is zero, the steps are played up to the last one
+	Start int
+	End   int
+
+	// Play only the steps with one of the Tags, if any,
+	// and skip the steps with one of the SkipTags
+	Tags     []string
+	SkipTags []string
+
+	// Width returns the width of the terminal in columns, used to
+	// erase and redraw lines wrapped onto more than one row. If nil
+	// or if it returns 0, lines are assumed to fit on one row
+	Width func() int
+}
+
+// Status is a snapshot of the player progress
+type Status struct {
+	// The current state of the player
+	State State
+
+	// The index of the next command to be played
+	// and the total number of commands
+	Step  int
+	Total int
+}
+
+// Player types and executes the steps of a scenario, one by one. The
+// playback can be paused, resumed and stepped from other goroutines
+type Player struct {
+	scenario *script.Scenario
+	steps    []script.Step
+	login    *script.Login
+	out      io.Writer
+	line     *lineWriter
+	opts     Options
+	vars     map[string]string
+
+	// The indices of the steps skipped for another system
+	// or because their requirements are not met
+	unmet map[int]bool
+
+	// The highlight rules of the scenario and the options
+	highlights []cli.Highlight
+
+	mu       sync.Mutex
+	handlers []func(Event)
+	state    State
+	paused   bool
+	pending  int
+	current  int
+	seeking  bool
+	changed  chan struct{}
+	report   Report
+
+	// When the playback was suspended by job control, if it is,
+	// and the time spent suspended so far
+	suspendedAt time.Time
+	suspended   time.Duration
+
+	// How late the last pause ended, made up for by the next one
+	overslept time.Duration
+
+	// Scales of the delays and the typing speed set by Fit
+	delayScale  float64
+	typingScale float64
+
+	// The Lua interpreter of the Lua steps, and the step being run
+	lua     *lua.LState
+	luaStep *luaStep
+
+	// The exit code of the last command executed, and the
+	// number of jumps of each goto step, by index
+	exitCode int
+	jumps    map[int]int
+
+	// The shell simulated for the commands of the playback, and
+	// the prompt it started with, before changing directories
+	shell  *cli.Shell
+	prompt cli.Prompt
+}
+
+// New creates a player for the scenario that writes to out. The
+// prompt and timing of the scenario override those in opts
+func New(s *script.Scenario, out io.Writer, opts Options) *Player {
+	// Keep track of the line on screen, for redrawing it
+	line := &lineWriter{out: out}
+
+	// The rules of the scenario are validated with the scenario
+	highlights := parseHighlights(s.Highlights)
+	highlights = append(highlights, opts.Highlights...)
+
+	// Play the variants of the steps for the system, and skip
+	// the steps whose requirements are not met or print their
+	// fallback instead
+	steps, unmet := guard(s.Steps)
+
+	p := &Player{
+		scenario: s,
+		steps:    steps,
+		unmet:    unmet,
+		login:    s.Login,
+		out:      line,
+		line:     line,
+		opts:     opts.override(s.Prompt, s.Timing),
+		changed:  make(chan struct{}),
+
+		highlights: highlights,
+
+		delayScale:  1,
+		typingScale: 1,
+	}
+	p.current, _ = p.bounds()
+	p.prompt = p.opts.Prompt
+	p.reset()
+	return p
+}
+
+// reset starts the state of the playback again, as the playback
+// starts or is restarted: the shell of the commands, in the directory
+// and with the environment of autotyper, the prompt in its first
+// directory, the variables, the exit code, the jumps of the goto
+// steps and the Lua interpreter
+func (p *Player) reset() {
+	p.shell = &cli.Shell{}
+	p.opts.Prompt = p.prompt
+
+	// Copy the variables, so that the values
+	// asked during the playback are not shared
+	p.vars = make(map[string]string, len(p.opts.Variables))
+	for name, value := range p.opts.Variables {
+		p.vars[name] = value
+	}
+	p.exitCode = 0
+	p.jumps = make(map[int]int)
+	if p.lua != nil {
+		p.lua.Close()
+		p.lua = nil
+	}
+}
+
+// bounds returns the index of the first step played and of the
+// step after the last one, clamped to the steps of the scenario
+func (p *Player) bounds() (int, int) {
+	end := len(p.steps)
+	if p.opts.End > 0 {
+		end = min(p.opts.End, end)
+	}
+	return max(0, min(p.opts.Start, end)), end
+}
+
+// selected reports whether the step with the index i is played
+// with the tag filters and the requirements of the step
+func (p *Player) selected(i int) bool {
+	step := p.steps[i]
+	if p.unmet[i] {
+		return false
+	}
+	if len(p.opts.Tags) > 0 && !step.HasTag(p.opts.Tags...) {
+		return false
+	}
+	return !step.HasTag(p.opts.SkipTags...)
+}
+
+// lastStep returns the index of the last step played,
+// or -1 if no step is played
+func (p *Player) lastStep() int {
+	start, end := p.bounds()
+	for i := end - 1; i >= start; i-- {
+		if p.selected(i) {
+			return i
+		}
+	}
+	return -1
+}
+
+// clears reports whether the screen is cleared after the command of
+// the step with the index i: as set by the step, or unless NoClear is
+// set, but never after the last step played
+func (p *Player) clears(i int, step script.Step) bool {
+	if i >= p.lastStep() || p.opts.Accessible {
+		return false
+	}
+	if step.Clear != nil {
+		return *step.Clear
+	}
+	return !p.opts.NoClear
+}
+
+// override returns the options with the prompt and timing overrides
+func (o Options) override(prompt *script.Prompt, timing *script.Timing) Options {
+	o.Prompt = prompt.Apply(o.Prompt)
+	if timing == nil {
+		return o
+	}
+	if timing.CharDelay != nil {
+		o.CharDelay = *timing.CharDelay
+	}
+	if timing.PreDelay != nil {
+		o.PreDelay = *timing.PreDelay
+	}
+	if timing.PostDelay != nil {
+		o.PostDelay = *timing.PostDelay
+	}
+	return o
+}
+
+// OnEvent registers a function that is called for each playback
+// event. The function is called from the goroutine running the
+// playback, so it should return quickly
+func (p *Player) OnEvent(fn func(Event)) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.handlers = append(p.handlers, fn)
+}
+
+// emit calls the registered event handlers
+func (p *Player) emit(e Event) {
+	p.mu.Lock()
+	handlers := p.handlers
+	p.mu.Unlock()
+
+	for _, fn := range handlers {
+		fn(e)
+	}
+}
+
+// Pause pauses the playback before the next command is typed
+func (p *Player) Pause() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.paused = true
+	p.pending = 0
+	p.notify()
+}
+
+// Resume continues a paused playback
+func (p *Player) Resume() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.paused = false
+	p.pending = 0
+	p.notify()
+}
+
+// Step plays the next command and then pauses the playback again
+func (p *Player) Step() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.paused = true
+	p.pending++
+	p.notify()
+}
+
+// Seek moves the playback to the step with the index, which is
+// played next. The index is clamped to the steps of the scenario
+func (p *Player) Seek(index int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.current = max(0, min(index, len(p.steps)))
+	p.seeking = true
+	p.notify()
+}
+
+// Status returns a snapshot of the player progress
+func (p *Player) Status() Status {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return Status{State: p.state, Step: p.current, Total: len(p.steps)}
+}
+
+// notify wakes up the goroutines waiting for a change in
+// the playback controls. The caller must hold the lock
+func (p *Player) notify() {
+	close(p.changed)
+	p.changed = make(chan struct{})
+}
+
+// setState updates the current state of the player
+// and emits an event if the state has changed
+func (p *Player) setState(state State) {
+	p.mu.Lock()
+	changed := p.state != state
+	p.state = state
+	p.mu.Unlock()
+
+	if changed {
+		slog.Debug("player state changed", "state", state)
+		p.emit(Event{Type: StateChanged, State: state})
+	}
+}
+
+// wait blocks while the playback is paused. It returns
+// an error if the context is cancelled while waiting
+func (p *Player) wait(ctx context.Context) error {
+	for {
+		p.mu.Lock()
+		proceed := !p.paused || p.pending > 0
+		if p.paused && p.pending > 0 {
+			p.pending--
+		}
+		changed := p.changed
+		p.mu.Unlock()
+
+		if proceed {
+			p.setState(Playing)
+			return nil
+		}
+		p.setState(Paused)
+
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// Run plays all steps and returns when the last command has
+// been executed or the context is cancelled
+func (p *Player) Run(ctx context.Context) error {
+	start := time.Now()
+	p.fireWebhooks(ctx, webhook.Start, start, nil)
+	err := p.play(ctx)
+	p.fireWebhooks(context.WithoutCancel(ctx), webhook.Finish, start, err)
+	return err
+}
+
+// play plays the steps of Run
+func (p *Player) play(ctx context.Context) error {
+	// Measure the total duration of the playback, on any return
+	start := p.now()
+	defer func() {
+		p.mu.Lock()
+		p.report.Total = p.since(start) - p.suspended
+		p.mu.Unlock()
+	}()
+
+	// Close the Lua interpreter of the Lua steps, if any
+	defer func() {
+		if p.lua != nil {
+			p.lua.Close()
+			p.lua = nil
+		}
+	}()
+
+	// Switch to the alternate screen, and back on any return
+	if p.opts.AltScreen && !p.opts.Accessible {
+		cli.EnterAltScreen(p.out)
+		defer cli.ExitAltScreen(p.out)
+	}
+
+	// Draw the output of the commands in the colors of the palette
+	if cli.ActivePalette != nil {
+		cli.SetSystemColors(p.out, cli.ActivePalette)
+		defer cli.ResetSystemColors(p.out)
+	}
+
+	// Clear the screen before printing the prompt
+	if err := cli.ClearScreen(p.out, p.opts.ClearScrollback); err != nil {
+		fmt.Fprintln(p.out, err)
+	}
+
+	// Log in to the server of the demo, if the scenario begins
+	// so and the playback starts at the first step
+	first, end := p.bounds()
+	if p.login != nil && first == 0 {
+		if err := p.logIn(ctx, p.login); err != nil {
+			return err
+		}
+	}
+
+	// Print the prompt and keep track of the prompt on screen
+	p.reset()
+	shown := p.opts.Prompt
+	cli.PrintPrompt(shown, p.out)
+
+	// Iterate over the steps of the scenario, counting
+	// the commands typed for the ramp of the typing speed
+	commands := 0
+	for i := first; ; i++ {
+		// Wait for the next steps of a streamed script
+		for i >= end {
+			more, err := p.receive(ctx)
+			if err != nil {
+				return err
+			}
+			if !more {
+				break
+			}
+			_, end = p.bounds()
+		}
+		if i >= end {
+			break
+		}
+
+		// Block here while the playback is paused
+		if err := p.wait(ctx); err != nil {
+			return err
+		}
+
+		// Continue from the step sought to, if any
+		p.mu.Lock()
+		if p.seeking {
+			i = p.current
+			p.seeking = false
+			if i == 0 {
+				p.reset()
+				commands = 0
+			}
+		}
+		if i >= end {
+			p.mu.Unlock()
+			break
+		}
+		p.current = i
+		p.mu.Unlock()
+		step := p.steps[i]
+		if !p.selected(i) {
+			slog.Debug("skipping step", "step", i+1, "tags", step.Tags)
+			p.mu.Lock()
+			p.current = i + 1
+			p.mu.Unlock()
+			continue
+		}
+		command := cli.StripReadings(script.Expand(step.Text(), p.vars))
+		p.emit(Event{Type: StepStarted, Step: i, Command: command})
+		p.startChapter(ctx, i, step)
+		p.writeNotes(i, step, command)
+
+		// Ask for the value of a variable instead of running a command
+		if step.Ask != "" {
+			started := p.now()
+			if err := p.ask(step.Ask); err != nil {
+				return err
+			}
+			cli.PrintPrompt(shown, p.out)
+			p.record(StepTiming{Step: i, Command: "#ask " + step.Ask, Pauses: p.since(started)})
+
+			p.mu.Lock()
+			p.current = i + 1
+			p.mu.Unlock()
+			p.emit(Event{Type: StepFinished, Step: i})
+			continue
+		}
+
+		// Think at the prompt instead of running a command
+		if step.Think > 0 {
+			started := p.now()
+			if err := p.think(ctx, scale(step.Think, p.delayScale)); err != nil {
+				return err
+			}
+			p.record(StepTiming{Step: i, Command: fmt.Sprintf("#think %d", step.Think), Pauses: p.since(started)})
+
+			p.mu.Lock()
+			p.current = i + 1
+			p.mu.Unlock()
+			p.emit(Event{Type: StepFinished, Step: i})
+			continue
+		}
+
+		// Wait for the narration instead of running a command
+		if step.WaitAudio != "" {
+			started := p.now()
+			if err := p.waitAudio(ctx, step.WaitAudio); err != nil {
+				return fmt.Errorf("step %d: %w", i+1, err)
+			}
+			p.record(StepTiming{Step: i, Command: "#wait-audio " + step.WaitAudio, Pauses: p.since(started)})
+
+			p.mu.Lock()
+			p.current = i + 1
+			p.mu.Unlock()
+			p.emit(Event{Type: StepFinished, Step: i})
+			continue
+		}
+
+		// Draw the image instead of running a command
+		if step.Image != "" {
+			p.eraseLine()
+			if err := p.drawImage(step.Image); err != nil {
+				return fmt.Errorf("step %d: %w", i+1, err)
+			}
+			cli.PrintPrompt(shown, p.out)
+			p.record(StepTiming{Step: i, Command: "#image " + step.Image})
+
+			p.mu.Lock()
+			p.current = i + 1
+			p.mu.Unlock()
+			p.emit(Event{Type: StepFinished, Step: i})
+			continue
+		}
+
+		// Show the QR code instead of running a command
+		if step.QRCode != "" {
+			started := p.now()
+			p.eraseLine()
+			if err := p.showQRCode(ctx, script.Expand(step.QRCode, p.vars)); err != nil {
+				return fmt.Errorf("step %d: %w", i+1, err)
+			}
+			cli.PrintPrompt(shown, p.out)
+			p.record(StepTiming{Step: i, Command: "#qrcode " + step.QRCode, Pauses: p.since(started)})
+
+			p.mu.Lock()
+			p.current = i + 1
+			p.mu.Unlock()
+			p.emit(Event{Type: StepFinished, Step: i})
+			continue
+		}
+
+		// Print the message of the day instead of running a command
+		if step.Motd != "" {
+			p.eraseLine()
+			p.motd(step.Motd, shown)
+			cli.PrintPrompt(shown, p.out)
+			p.record(StepTiming{Step: i, Command: "#motd " + step.Motd})
+
+			p.mu.Lock()
+			p.current = i + 1
+			p.mu.Unlock()
+			p.emit(Event{Type: StepFinished, Step: i})
+			continue
+		}
+
+		// Go to another step instead of running a command
+		if step.Goto != nil {
+			target, jump, err := p.jump(i, step.Goto, shown)
+			if err != nil {
+				return err
+			}
+			p.record(StepTiming{Step: i, Command: "#goto " + step.Goto.Step})
+
+			next := i + 1
+			if jump {
+				slog.Debug("going to step", "step", target+1, "from", i+1)
+				next = target
+			}
+			p.mu.Lock()
+			p.current = next
+			p.mu.Unlock()
+			p.emit(Event{Type: StepFinished, Step: i})
+			i = next - 1
+			continue
+		}
+
+		// Run the Lua code of the step, which plays its own commands
+		if step.Lua != "" {
+			if err := p.runLua(ctx, i, step.Lua, &shown, &commands); err != nil {
+				return err
+			}
+
+			p.mu.Lock()
+			p.current = i + 1
+			p.mu.Unlock()
+			p.emit(Event{Type: StepFinished, Step: i})
+			continue
+		}
+
+		// Type and execute the command
+		err := p.playCommand(ctx, i, step, &shown, &commands, func(command string) {
+			p.mu.Lock()
+			p.current = i + 1
+			p.mu.Unlock()
+			p.emit(Event{Type: StepFinished, Step: i, Command: command})
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	// End the session as a human operator would do
+	if p.opts.TypeExit {
+		p.exit(shown)
+	}
+
+	p.setState(Finished)
+	return nil
+}
+
+// playCommand types and executes the command of the step with the
+// number i, printing the prompt shown afterwards and counting the
+// commands typed. The done function, if not nil, is called once the
+// output is printed and before the delay after the command
+func (p *Player) playCommand(ctx context.Context, i int, step script.Step, shown *cli.Prompt, commands *int, done func(command string)) error {
+	// Apply the overrides of the step and the variables, the
+	// ramp of the typing speed, and the scales of the delays
+	opts := p.stepOptions(step, *commands)
+	*commands++
+	step.Input = p.scaledInputs(step.Input)
+	typed := script.Expand(step.Text(), p.vars)
+	command := cli.StripReadings(typed)
+	if p.startHook(i, command, &opts) {
+		slog.Debug("skipping step", "step", i+1, "command", command)
+		if done != nil {
+			done(command)
+		}
+		return nil
+	}
+	slog.Debug("playing step", "step", i+1, "command", command,
+		"char_delay", opts.CharDelay, "pre_delay", opts.PreDelay, "post_delay", opts.PostDelay)
+
+	// Redraw the prompt line if there is a caption or a status
+	// line to print above it, or if the step uses a different prompt
+	if step.Caption != "" || p.opts.Accessible || opts.Prompt != *shown {
+		p.eraseLine()
+		switch {
+		case p.opts.Accessible:
+			p.printStatus(i, step.Caption)
+		case step.Caption != "":
+			cli.PrintCaption(step.Caption, p.out)
+		}
+		*shown = opts.Prompt
+		cli.PrintPrompt(*shown, p.out)
+	}
+
+	// Speak the narration of the step while the command is typed
+	spoken := p.speak(ctx, narration(step, command))
+
+	// Look the command up while it is typed, to run it at once
+	if p.executed(step) {
+		cli.Prepare(p.shell.Expand(command))
+	}
+
+	// Delay before starting to type the command
+	timing := StepTiming{Step: i, Command: command}
+	started := p.now()
+	if err := p.sleep(ctx, opts.PreDelay); err != nil {
+		return err
+	}
+	timing.Pauses = p.since(started)
+
+	// Record the command line and the output to annotate them
+	if len(step.Annotate) > 0 {
+		p.line.record()
+		defer p.line.stopRecording()
+	}
+
+	// Type command as human, with a delay between each character
+	started = p.now()
+	if err := p.typist(opts).Type(typed, p.out); err != nil {
+		fmt.Fprintln(p.out, locale.T("Error: %v", err))
+	}
+	timing.Typing = p.since(started)
+
+	// Wait for the end of the narration before running the command
+	started = p.now()
+	err := spoken()
+	timing.Pauses += p.since(started)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(p.out)
+	offset := p.line.recordedLen()
+
+	// Place the command on the clipboard of the viewers
+	if p.opts.CopyCommands {
+		cli.CopyOSC52(p.out, command)
+	}
+
+	// Execute the command and print the output
+	started = p.now()
+	if err := p.execute(ctx, i, step, command, opts); err != nil {
+		return err
+	}
+	timing.Execution = p.since(started)
+	p.endHook(i, command, &opts, timing.Execution)
+
+	// Point at the results with the annotations of the step
+	if len(step.Annotate) > 0 {
+		started = p.now()
+		err := p.annotate(ctx, step.Annotate, p.line.stopRecording(), offset)
+		timing.Pauses += p.since(started)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Print the prompt after the command output
+	*shown = p.opts.Prompt
+	cli.PrintPrompt(*shown, p.out)
+	if done != nil {
+		done(command)
+	}
+
+	// Delay between each command
+	started = p.now()
+	err = p.sleep(ctx, opts.PostDelay)
+	timing.Pauses += p.since(started)
+	p.record(timing)
+	if err != nil {
+		return err
+	}
+
+	// Clear the screen between commands (not the last command),
+	// typing the clear command first if asked to
+	if p.clears(i, step) {
+		if p.opts.TypeClear {
+			p.typist(opts).Type(cli.ClearCommand(shown.Shell), p.out)
+			fmt.Fprintln(p.out)
+		}
+		if err := cli.ClearScreen(p.out, p.opts.ClearScrollback); err != nil {
+			fmt.Fprintln(p.out, err)
+		}
+		*shown = p.opts.Prompt
+		cli.PrintPrompt(*shown, p.out)
+	}
+	return nil
+}
+
+// printStatus prints the plain status line of the step with the
+// number i in accessible mode, followed by its caption if any
+func (p *Player) printStatus(i int, caption string) {
+	status := locale.T("Step %d of %d", i+1, len(p.steps))
+	if caption != "" {
+		status += ": " + caption
+	}
+	fmt.Fprintln(p.out, status)
+}
+
+// exit types the exit command at the prompt and prints the closing
+// message of the session
+func (p *Player) exit(shown cli.Prompt) {
+	p.typist(p.opts).Type("exit", p.out)
+	fmt.Fprintln(p.out)
+
+	message := p.opts.ExitMessage
+	if message == "" {
+		message = cli.ExitMessage(shown.Shell)
+	}
+	if message != "" {
+		fmt.Fprintln(p.out, message)
+	}
+	if p.opts.ExitClear && !p.opts.Accessible {
+		if err := cli.ClearScreen(p.out, p.opts.ClearScrollback); err != nil {
+			fmt.Fprintln(p.out, err)
+		}
+	}
+}
+
+// typist returns the typist of the commands with the options
+func (p *Player) typist(opts Options) cli.Typist {
+	return cli.Typist{
+		Delay:       opts.CharDelay,
+		InputMethod: p.opts.InputMethod,
+		Hesitation:  p.opts.Hesitation,
+		Pauses:      p.opts.Pauses,
+		Clock:       p.clock(),
+	}
+}
+
+// motd prints the message of the day, or the summary of the
+// system with the user and the host of the prompt
+func (p *Player) motd(kind string, shown cli.Prompt) {
+	if kind == script.MotdSysinfo {
+		cli.PrintSystemInfo(p.opts.SystemInfo.WithDefaults(), shown.Username, shown.Hostname, p.out)
+		return
+	}
+
+	motd := p.opts.Motd
+	if motd == "" {
+		motd = cli.DefaultMotd
+	}
+	cli.PrintMotd(motd, p.out)
+}
+
+// think pauses at the prompt with a blinking cursor, as if the
+// presenter was deciding what to do next
+func (p *Player) think(ctx context.Context, ms int) error {
+	slog.Debug("thinking at the prompt", "delay", ms)
+	if p.opts.Accessible {
+		// Show a steady cursor instead of a blinking one
+		fmt.Fprint(p.out, "\033[?25h\033[2 q")
+	} else {
+		fmt.Fprint(p.out, "\033[?25h\033[1 q")
+	}
+	defer fmt.Fprint(p.out, "\033[0 q")
+	return p.sleep(ctx, ms)
+}
+
+// ask asks the presenter for the value of a variable. The question
+// replaces the prompt line, and the answer is erased afterwards
+func (p *Player) ask(name string) error {
+	slog.Debug("asking for variable", "name", name)
+	p.eraseLine()
+
+	var value string
+	var err error
+	if p.opts.Ask != nil {
+		value, err = p.opts.Ask(name)
+	} else {
+		value, err = cli.AskValue(name, p.input(), p.out)
+	}
+	if err != nil {
+		return err
+	}
+	p.vars[name] = value
+
+	// Move up to the line with the answer and erase it
+	fmt.Fprint(p.out, "\033[A\r\033[K")
+	return nil
+}
+
+// executed reports whether the command of the step is executed,
+// rather than simulated or printing a canned output
+func (p *Player) executed(step script.Step) bool {
+	return step.Simulate == "" && step.Output == "" && p.opts.Sandbox == nil
+}
+
+// execute prints the output of the step: the generated output of a
+// simulated command, the canned output if there is one, the simulated
+// output in sandbox mode, or the output of the executed command, with
+// the builtins of the shell (e.g. cd) interpreted instead. Dangerous
+// commands are only executed if confirmed. The input of the step is
+// typed into the command as it runs, or after the output when the
+// command is not executed. The output is passed through the output
+// hook and the filters, if there are any
+func (p *Player) execute(ctx context.Context, index int, step script.Step, command string, opts Options) error {
+	out, flush := p.outputFilter(ctx, index, step, command, opts)
+	defer flush()
+
+	// Commands that are not executed succeed, for the goto steps
+	p.exitCode = 0
+
+	if step.Simulate != "" {
+		slog.Debug("generating simulated output", "command", command)
+		simulator, ok := p.scenario.Simulator(command)
+		if !ok {
+			fmt.Fprintln(out, locale.T("Error: no simulator for %q", command))
+			return nil
+		}
+		sim := simulate.NewSimulation(command, step.Params, opts.Prompt.Shell != cli.Bash)
+		sim.Step = index + 1
+		if err := simulator.Simulate(ctx, sim, out); err != nil {
+			if ctx.Err() != nil {
+				return err
+			}
+			fmt.Fprintln(out, locale.T("Error: %v", err))
+		}
+		return nil
+	}
+
+	if step.Output != "" {
+		slog.Debug("printing canned output", "command", command, "bytes", len(step.Output))
+
+		// The canned output is a template, validated with the scenario
+		output := step.Output
+		if tmpl, err := simulate.ParseOutput("output", step.Output); err == nil {
+			var b strings.Builder
+			if err := tmpl.Execute(&b, simulate.FakeData{Command: command, Args: strings.Fields(command), Step: index + 1, Iteration: step.Iteration}); err != nil {
+				fmt.Fprintln(out, locale.T("Error: %v", err))
+			}
+			output = b.String()
+		}
+
+		// End the output with a newline, unless the output is empty
+		// or a question answered by the input (e.g. "Continue? ")
+		if step.More != nil {
+			if err := p.page(ctx, out, output, step.More); err != nil {
+				return err
+			}
+		} else {
+			fmt.Fprint(out, output)
+		}
+		if output != "" && !strings.HasSuffix(output, "\n") && len(step.Input) == 0 {
+			fmt.Fprintln(out)
+		}
+		return p.typeInput(ctx, step.Input, out, nil, opts.CharDelay)
+	}
+
+	if p.opts.Sandbox != nil {
+		slog.Debug("simulating command", "command", command)
+		if err := p.opts.Sandbox.Run(command, index+1, step.Iteration, out); err != nil {
+			fmt.Fprintln(out, locale.T("Error: %v", err))
+		}
+		return p.typeInput(ctx, step.Input, out, nil, opts.CharDelay)
+	}
+
+	// Interpret the builtins of the shell, which cannot be executed,
+	// after replacing the alias of the command
+	command = p.shell.Expand(command)
+	if len(step.Input) == 0 && len(step.Expect) == 0 && cli.CommandPolicy.Check(command) == nil {
+		if builtin, err := p.shell.Builtin(command, &p.opts.Prompt, out); builtin {
+			if err != nil {
+				p.exitCode = 1
+				fmt.Fprintln(out, locale.T("Error: %v", err))
+			}
+			return nil
+		}
+	}
+
+	// Replay the output cached by a previous run, if any
+	replayed, out, recording, err := p.cached(ctx, step, command, out)
+	if replayed || err != nil {
+		return err
+	}
+
+	execute, err := p.confirm(command)
+	if err != nil || !execute {
+		return err
+	}
+
+	var denied *cli.DeniedError
+	start := time.Now()
+	if len(step.Expect) > 0 {
+		err = p.runExpect(ctx, command, step, opts.CharDelay)
+	} else {
+		err = p.run(ctx, command, step.Input, out, opts.CharDelay)
+	}
+	saveCached(recording, err)
+	p.exitCode = exitCode(err)
+	if errors.As(err, &denied) {
+		// Refused commands are only typed, with a notice
+		// that the audience does not see in the demo
+		fmt.Fprintln(os.Stderr, denied)
+		return nil
+	}
+	p.audit(index, command, start, err)
+	if err != nil {
+		fmt.Fprintln(out, locale.T("Error: %v", err))
+	}
+	return nil
+}
+
+// fireWebhooks fires the webhooks of the event of the playback started
+// at the time. A playback ending with an error other than being stopped
+// fires the webhooks of failures instead
+func (p *Player) fireWebhooks(ctx context.Context, event string, start time.Time, err error) {
+	if p.opts.Webhooks == nil {
+		return
+	}
+	run := webhook.Run{Event: event, Time: time.Now(), Script: p.opts.Source}
+	run.Host, _ = os.Hostname()
+	if event != webhook.Start {
+		run.Steps = len(p.Report().Steps)
+		run.Duration = time.Since(start).Milliseconds()
+		if err != nil && !errors.Is(err, context.Canceled) {
+			run.Event, run.Error = webhook.Fail, err.Error()
+		}
+	}
+	p.opts.Webhooks.Notify(ctx, run)
+}
+
+// audit records the command executed by the step, started at the
+// time, in the audit log if there is one
+func (p *Player) audit(index int, command string, start time.Time, err error) {
+	if p.opts.Audit == nil {
+		return
+	}
+
+	r := audit.Record{
+		Time:     start,
+		Script:   p.opts.Source,
+		Step:     index + 1,
+		Command:  command,
+		ExitCode: exitCode(err),
+		Duration: time.Since(start).Milliseconds(),
+	}
+	r.Dir = p.shell.WorkDir()
+	if r.ExitCode == -1 && err != nil {
+		r.Error = err.Error()
+	}
+	if err := p.opts.Audit.Record(r); err != nil {
+		slog.Warn("cannot write the audit log", "error", err)
+	}
+}
+
+// confirm reports whether the command should be executed. Dangerous
+// commands are either confirmed by the presenter or only typed
+func (p *Player) confirm(command string) (bool, error) {
+	if !cli.MatchAny(command, p.opts.Dangerous) {
+		return true, nil
+	}
+	if p.opts.TypeOnlyDangerous {
+		fmt.Fprintln(os.Stderr, locale.T("Not executing dangerous command: %s", command))
+		return false, nil
+	}
+
+	if p.opts.Confirm != nil {
+		return p.opts.Confirm(command)
+	}
+	execute, err := cli.Confirm(locale.T("Really execute this command?"), p.input(), p.out)
+	if err != nil {
+		return false, err
+	}
+
+	// Move up to the line with the question and erase it
+	fmt.Fprint(p.out, "\033[A\r\033[K")
+	return execute, nil
+}
+
+// input returns the reader of the answers of the presenter
+func (p *Player) input() io.Reader {
+	if p.opts.Input != nil {
+		return p.opts.Input
+	}
+	return os.Stdin
+}
+
+// clock returns the clock of the typing and the delays
+func (p *Player) clock() cli.Clock {
+	if p.opts.Clock != nil {
+		return p.opts.Clock
+	}
+	return cli.SystemClock{}
+}
+
+// now returns the time of the clock of the typing and the delays
+func (p *Player) now() time.Time {
+	return p.clock().Now()
+}
+
+// since returns the time passed on the clock of the
+// typing and the delays since t
+func (p *Player) since(t time.Time) time.Duration {
+	return p.now().Sub(t)
+}
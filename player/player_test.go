@@ -0,0 +1,876 @@
+package player_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bitcanon/autotyper/audit"
+	"github.com/bitcanon/autotyper/cli"
+	"github.com/bitcanon/autotyper/player"
+	"github.com/bitcanon/autotyper/script"
+	"github.com/bitcanon/autotyper/simulate"
+	"github.com/bitcanon/autotyper/webhook"
+)
+
+// syncBuffer is a bytes.Buffer that is safe for concurrent use,
+// since the player writes from its own goroutine
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+// testOptions returns options without any delays
+func testOptions() player.Options {
+	return player.Options{
+		Prompt:  cli.Prompt{Path: "C:\\", Shell: cli.Cmd},
+		NoClear: true,
+	}
+}
+
+// mustParse parses a plain text script or fails the test
+func mustParse(t *testing.T, input string) *script.Scenario {
+	t.Helper()
+	s, err := script.ParseText(input)
+	if err != nil {
+		t.Fatalf("failed to parse script: %v", err)
+	}
+	return s
+}
+
+// waitFor polls the player until the condition is met
+func waitFor(t *testing.T, p *player.Player, cond func(player.Status) bool) player.Status {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if s := p.Status(); cond(s) {
+			return s
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("timed out waiting for player, status: %+v", p.Status())
+	return player.Status{}
+}
+
+// TestPlayerRun tests that all commands are typed
+// and executed, each followed by a new prompt
+func TestPlayerRun(t *testing.T) {
+	var out syncBuffer
+	p := player.New(mustParse(t, "echo first\necho second"), &out, testOptions())
+
+	if err := p.Run(context.Background()); err != nil {
+		t.Fatalf("expected no error, but got %v", err)
+	}
+
+	expected := "C:\\> echo first\nfirst\nC:\\> echo second\nsecond\nC:\\> "
+	if !strings.HasSuffix(out.String(), expected) {
+		t.Errorf("expected output to end with %q, but got %q", expected, out.String())
+	}
+	if s := p.Status(); s.State != player.Finished || s.Step != 2 {
+		t.Errorf("expected finished at step 2, but got %+v", s)
+	}
+}
+
+// TestPlayerRange tests that only the steps in the range are played
+// and estimated, and that the status starts at the first of them
+func TestPlayerRange(t *testing.T) {
+	tests := []struct {
+		name       string
+		start, end int
+		expected   string
+		steps      int
+	}{
+		{name: "StartAt", start: 1, expected: "C:\\> echo second\nsecond\nC:\\> echo third\nthird\nC:\\> ", steps: 2},
+		{name: "Range", start: 1, end: 2, expected: "C:\\> echo second\nsecond\nC:\\> ", steps: 1},
+		{name: "Clamped", start: 2, end: 9, expected: "C:\\> echo third\nthird\nC:\\> ", steps: 1},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var out syncBuffer
+			opts := testOptions()
+			opts.Start, opts.End = test.start, test.end
+			p := player.New(mustParse(t, "echo first\necho second\necho third"), &out, opts)
+			if s := p.Status(); s.Step != test.start {
+				t.Errorf("expected to start at step %d, but got %+v", test.start, s)
+			}
+			if steps := len(p.Estimate().Steps); steps != test.steps {
+				t.Errorf("expected %d estimated steps, but got %d", test.steps, steps)
+			}
+
+			if err := p.Run(context.Background()); err != nil {
+				t.Fatalf("expected no error, but got %v", err)
+			}
+			if out.String() != "\033[H\033[2J"+test.expected {
+				t.Errorf("expected output %q, but got %q", test.expected, out.String())
+			}
+		})
+	}
+}
+
+// TestPlayerTags tests that the steps are played or skipped
+// by their tags
+func TestPlayerTags(t *testing.T) {
+	tests := []struct {
+		name           string
+		tags, skipTags []string
+		expected       []string
+	}{
+		{name: "All", expected: []string{"one", "two", "three"}},
+		{name: "Tags", tags: []string{"advanced", "setup"}, expected: []string{"one", "three"}},
+		{name: "SkipTags", skipTags: []string{"advanced"}, expected: []string{"one", "two"}},
+		{name: "Both", tags: []string{"setup"}, skipTags: []string{"optional"}},
+	}
+
+	s := mustParse(t, "#tags setup optional\necho one\necho two\n#tags advanced\necho three")
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var out syncBuffer
+			opts := testOptions()
+			opts.Tags, opts.SkipTags = test.tags, test.skipTags
+			p := player.New(s, &out, opts)
+			if err := p.Run(context.Background()); err != nil {
+				t.Fatalf("expected no error, but got %v", err)
+			}
+
+			expected := "\033[H\033[2JC:\\> "
+			for _, word := range test.expected {
+				expected += "echo " + word + "\n" + word + "\nC:\\> "
+			}
+			if out.String() != expected {
+				t.Errorf("expected output %q, but got %q", expected, out.String())
+			}
+			if status := p.Status(); status.State != player.Finished || status.Step != 3 {
+				t.Errorf("expected finished at step 3, but got %+v", status)
+			}
+		})
+	}
+}
+
+// TestPlayerClearSteps tests that the steps force or suppress
+// clearing the screen after their command, whatever the option
+func TestPlayerClearSteps(t *testing.T) {
+	const cls = "\033[H\033[2J"
+	tests := []struct {
+		name     string
+		noClear  bool
+		input    string
+		expected string
+	}{
+		{
+			name:     "Keep",
+			input:    "echo first\n#keep\necho second\necho third",
+			expected: cls + "C:\\> echo first\nfirst\nC:\\> echo second\nsecond\nC:\\> " + cls + "C:\\> echo third\nthird\nC:\\> ",
+		},
+		{
+			name:     "Cls",
+			noClear:  true,
+			input:    "echo first\necho second\n#cls\necho third\n#cls",
+			expected: cls + "C:\\> echo first\nfirst\nC:\\> echo second\nsecond\nC:\\> " + cls + "C:\\> echo third\nthird\nC:\\> ",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var out syncBuffer
+			opts := testOptions()
+			opts.NoClear = test.noClear
+			p := player.New(mustParse(t, test.input), &out, opts)
+			if err := p.Run(context.Background()); err != nil {
+				t.Fatalf("expected no error, but got %v", err)
+			}
+			if out.String() != test.expected {
+				t.Errorf("expected %q, but got %q", test.expected, out.String())
+			}
+		})
+	}
+}
+
+// TestPlayerOverrides tests that the prompt of the scenario
+// and the caption and prompt of a step are printed
+func TestPlayerOverrides(t *testing.T) {
+	var out syncBuffer
+	s := &script.Scenario{
+		Prompt: &script.Prompt{Path: "D:\\"},
+		Steps: []script.Step{
+			{Command: "echo first", Caption: "Say hello"},
+			{Command: "echo second", Prompt: &script.Prompt{Path: "E:\\"}},
+		},
+	}
+	p := player.New(s, &out, testOptions())
+
+	if err := p.Run(context.Background()); err != nil {
+		t.Fatalf("expected no error, but got %v", err)
+	}
+
+	for _, expected := range []string{
+		"\033[38;5;244mSay hello\033[0m\nD:\\> echo first\nfirst\nD:\\> ",
+		"\r\033[KE:\\> echo second\nsecond\nD:\\> ",
+	} {
+		if !strings.Contains(out.String(), expected) {
+			t.Errorf("expected output to contain %q, but got %q", expected, out.String())
+		}
+	}
+}
+
+// TestPlayerAsk tests that the values asked during the playback
+// and given in the options replace the variables in the commands
+func TestPlayerAsk(t *testing.T) {
+	var out syncBuffer
+	opts := testOptions()
+	opts.Variables = map[string]string{"greeting": "hello"}
+	opts.Ask = func(name string) (string, error) { return "JIRA-42", nil }
+
+	p := player.New(mustParse(t, "#ask ticket\necho ${greeting} ${ticket}"), &out, opts)
+	if err := p.Run(context.Background()); err != nil {
+		t.Fatalf("expected no error, but got %v", err)
+	}
+
+	expected := "echo hello JIRA-42\nhello JIRA-42\n"
+	if !strings.Contains(out.String(), expected) {
+		t.Errorf("expected output to contain %q, but got %q", expected, out.String())
+	}
+}
+
+// TestPlayerDangerous tests that dangerous commands are only
+// executed when confirmed, or only typed in type-only mode
+func TestPlayerDangerous(t *testing.T) {
+	tests := []struct {
+		name     string
+		typeOnly bool
+		confirm  bool
+		executed bool
+	}{
+		{name: "Confirmed", confirm: true, executed: true},
+		{name: "Declined", confirm: false, executed: false},
+		{name: "TypeOnly", typeOnly: true, confirm: true, executed: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var out syncBuffer
+			var asked bool
+			opts := testOptions()
+			opts.Dangerous = []*regexp.Regexp{regexp.MustCompile(`danger`)}
+			opts.TypeOnlyDangerous = test.typeOnly
+			opts.Confirm = func(command string) (bool, error) {
+				asked = true
+				return test.confirm, nil
+			}
+
+			p := player.New(mustParse(t, "echo danger\necho safe"), &out, opts)
+			if err := p.Run(context.Background()); err != nil {
+				t.Fatalf("expected no error, but got %v", err)
+			}
+
+			if asked == test.typeOnly {
+				t.Errorf("expected asked to be %v, but got %v", !test.typeOnly, asked)
+			}
+			if got := strings.Contains(out.String(), "\ndanger\n"); got != test.executed {
+				t.Errorf("expected executed to be %v, but got output %q", test.executed, out.String())
+			}
+			if !strings.Contains(out.String(), "echo danger") || !strings.Contains(out.String(), "\nsafe\n") {
+				t.Errorf("expected both commands to be typed, but got %q", out.String())
+			}
+		})
+	}
+}
+
+// TestPlayerSimulatedOutput tests that canned outputs and the sandbox
+// are used instead of executing the commands
+func TestPlayerSimulatedOutput(t *testing.T) {
+	var out syncBuffer
+	opts := testOptions()
+	opts.Sandbox = &simulate.Sandbox{Username: "bitcanon"}
+
+	s := &script.Scenario{Steps: []script.Step{
+		{Command: "kubectl get pods", Output: "No resources found"},
+		{Command: "whoami"},
+		{Command: "rm -rf /"},
+	}}
+	p := player.New(s, &out, opts)
+	if err := p.Run(context.Background()); err != nil {
+		t.Fatalf("expected no error, but got %v", err)
+	}
+
+	expected := "C:\\> kubectl get pods\nNo resources found\nC:\\> whoami\nbitcanon\nC:\\> rm -rf /\nC:\\> "
+	if !strings.HasSuffix(out.String(), expected) {
+		t.Errorf("expected output to end with %q, but got %q", expected, out.String())
+	}
+}
+
+// TestPlayerAudit tests that the executed commands are recorded in
+// the audit log, and the commands that are not executed are not
+func TestPlayerAudit(t *testing.T) {
+	var out syncBuffer
+	var records bytes.Buffer
+	opts := testOptions()
+	opts.Audit = audit.New(&records)
+	opts.Source = "demo.txt"
+
+	s := &script.Scenario{Steps: []script.Step{
+		{Command: "echo first"},
+		{Command: "kubectl get pods", Output: "No resources found"},
+		{Command: "autotyper-missing-command"},
+	}}
+	p := player.New(s, &out, opts)
+	if err := p.Run(context.Background()); err != nil {
+		t.Fatalf("expected no error, but got %v", err)
+	}
+
+	dir, _ := os.Getwd()
+	lines := strings.Split(strings.TrimSpace(records.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 records, but got %q", records.String())
+	}
+	expected := []audit.Record{
+		{Script: "demo.txt", Step: 1, Command: "echo first", Dir: dir},
+		{Script: "demo.txt", Step: 3, Command: "autotyper-missing-command", Dir: dir, ExitCode: -1},
+	}
+	for i, line := range lines {
+		var r audit.Record
+		if err := json.Unmarshal([]byte(line), &r); err != nil {
+			t.Fatalf("invalid record %q: %v", line, err)
+		}
+		if r.Time.IsZero() || (r.Error != "") != (expected[i].ExitCode == -1) {
+			t.Errorf("record %d: expected a time and an error only if not run, but got %+v", i+1, r)
+		}
+		r.Time, r.Error, r.Duration = time.Time{}, "", 0
+		if r != expected[i] {
+			t.Errorf("record %d: expected %+v, but got %+v", i+1, expected[i], r)
+		}
+	}
+}
+
+// TestPlayerPauseAndStep tests that a paused player
+// only plays one command for each step
+func TestPlayerPauseAndStep(t *testing.T) {
+	var out syncBuffer
+	p := player.New(mustParse(t, "echo first\necho second"), &out, testOptions())
+	p.Pause()
+
+	done := make(chan error)
+	go func() { done <- p.Run(context.Background()) }()
+
+	waitFor(t, p, func(s player.Status) bool { return s.State == player.Paused })
+	if strings.Contains(out.String(), "first") {
+		t.Fatalf("expected nothing to be typed while paused, but got %q", out.String())
+	}
+
+	p.Step()
+	s := waitFor(t, p, func(s player.Status) bool { return s.State == player.Paused && s.Step == 1 })
+	if s.Total != 2 {
+		t.Errorf("expected 2 commands in total, but got %d", s.Total)
+	}
+	if strings.Contains(out.String(), "second") {
+		t.Errorf("expected only the first command to be played, but got %q", out.String())
+	}
+
+	p.Resume()
+	if err := <-done; err != nil {
+		t.Fatalf("expected no error, but got %v", err)
+	}
+	if !strings.Contains(out.String(), "second") {
+		t.Errorf("expected the second command to be played, but got %q", out.String())
+	}
+}
+
+// TestPlayerSeek tests that a paused player continues
+// from the step sought to, forwards or backwards
+func TestPlayerSeek(t *testing.T) {
+	var out syncBuffer
+	p := player.New(mustParse(t, "echo first\necho second\necho third"), &out, testOptions())
+	p.Pause()
+
+	done := make(chan error)
+	go func() { done <- p.Run(context.Background()) }()
+
+	waitFor(t, p, func(s player.Status) bool { return s.State == player.Paused })
+	p.Seek(1)
+	if s := p.Status(); s.Step != 1 {
+		t.Errorf("expected step 1 after seeking, but got %d", s.Step)
+	}
+	p.Step()
+	waitFor(t, p, func(s player.Status) bool { return s.State == player.Paused && s.Step == 2 })
+	if strings.Contains(out.String(), "first") || !strings.Contains(out.String(), "second") {
+		t.Errorf("expected only the second command to be played, but got %q", out.String())
+	}
+
+	p.Seek(-1)
+	p.Step()
+	waitFor(t, p, func(s player.Status) bool { return s.State == player.Paused && s.Step == 1 })
+	if !strings.Contains(out.String(), "first") {
+		t.Errorf("expected the first command to be played, but got %q", out.String())
+	}
+
+	p.Seek(10)
+	p.Resume()
+	if err := <-done; err != nil {
+		t.Fatalf("expected no error, but got %v", err)
+	}
+	if strings.Contains(out.String(), "third") {
+		t.Errorf("expected the third command to be skipped, but got %q", out.String())
+	}
+}
+
+// TestPlayerCancel tests that a paused player
+// returns when the context is cancelled
+func TestPlayerCancel(t *testing.T) {
+	var out syncBuffer
+	p := player.New(mustParse(t, "echo first"), &out, testOptions())
+	p.Pause()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- p.Run(ctx) }()
+
+	waitFor(t, p, func(s player.Status) bool { return s.State == player.Paused })
+	cancel()
+
+	if err := <-done; err != context.Canceled {
+		t.Errorf("expected %v, but got %v", context.Canceled, err)
+	}
+}
+
+// TestPlayerEvents tests that step and state events
+// are emitted in the order of the playback
+func TestPlayerEvents(t *testing.T) {
+	var out syncBuffer
+	p := player.New(mustParse(t, "echo first"), &out, testOptions())
+
+	var events []player.Event
+	p.OnEvent(func(e player.Event) { events = append(events, e) })
+
+	if err := p.Run(context.Background()); err != nil {
+		t.Fatalf("expected no error, but got %v", err)
+	}
+
+	expected := []player.Event{
+		{Type: player.StateChanged, State: player.Playing},
+		{Type: player.StepStarted, Step: 0, Command: "echo first"},
+		{Type: player.StepFinished, Step: 0, Command: "echo first"},
+		{Type: player.StateChanged, State: player.Finished},
+	}
+	if len(events) != len(expected) {
+		t.Fatalf("expected %d events, but got %d: %+v", len(expected), len(events), events)
+	}
+	for i := range expected {
+		if events[i] != expected[i] {
+			t.Errorf("expected event %d to be %+v, but got %+v", i, expected[i], events[i])
+		}
+	}
+}
+
+// TestPlayerAltScreen tests that the playback switches to the
+// alternate screen and back, also when it is cancelled
+func TestPlayerAltScreen(t *testing.T) {
+	tests := []struct {
+		name   string
+		cancel bool
+	}{
+		{name: "finished", cancel: false},
+		{name: "cancelled", cancel: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			if test.cancel {
+				cancel()
+			}
+
+			var out syncBuffer
+			opts := testOptions()
+			opts.AltScreen = true
+			p := player.New(mustParse(t, "echo first"), &out, opts)
+			p.Run(ctx)
+
+			if !strings.HasPrefix(out.String(), "\033[?1049h") {
+				t.Errorf("expected output to start on the alternate screen, but got %q", out.String())
+			}
+			if !strings.HasSuffix(out.String(), "\033[?1049l") {
+				t.Errorf("expected output to end on the main screen, but got %q", out.String())
+			}
+		})
+	}
+}
+
+// TestPlayerReadings tests that the readings of CJK words are
+// typed with the input method, but not executed
+func TestPlayerReadings(t *testing.T) {
+	var out syncBuffer
+	opts := testOptions()
+	opts.CharDelay = 1
+	opts.InputMethod = cli.Japanese
+
+	p := player.New(mustParse(t, "echo {日本語|nihongo}"), &out, opts)
+	if err := p.Run(context.Background()); err != nil {
+		t.Fatalf("expected no error, but got %v", err)
+	}
+
+	if !strings.Contains(out.String(), "\033[4mにほんご\033[24m") {
+		t.Errorf("expected the reading to be composed, but got %q", out.String())
+	}
+	if !strings.HasSuffix(out.String(), "\n日本語\nC:\\> ") {
+		t.Errorf("expected the text to be executed, but got %q", out.String())
+	}
+}
+
+// TestPlayerThink tests that a think step pauses at the prompt
+// with a blinking cursor before the next command is typed
+func TestPlayerThink(t *testing.T) {
+	var out syncBuffer
+	p := player.New(mustParse(t, "#think 50\necho done"), &out, testOptions())
+
+	start := time.Now()
+	if err := p.Run(context.Background()); err != nil {
+		t.Fatalf("expected no error, but got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		t.Errorf("expected a pause of at least 50ms, but took %v", elapsed)
+	}
+
+	expected := "C:\\> \033[?25h\033[1 q\033[0 qecho done\ndone\nC:\\> "
+	if !strings.HasSuffix(out.String(), expected) {
+		t.Errorf("expected output to end with %q, but got %q", expected, out.String())
+	}
+}
+
+// TestPlayerAccessible tests that the screen is only cleared before
+// the first command, and that a status line is printed before each command in accessible
+// mode, with the caption of the step
+func TestPlayerAccessible(t *testing.T) {
+	s := &script.Scenario{Steps: []script.Step{
+		{Command: "echo first", Caption: "Say hello"},
+		{Think: 10},
+		{Command: "echo second", Clear: &[]bool{true}[0]},
+		{Command: "echo third"},
+	}}
+	var out syncBuffer
+	opts := testOptions()
+	opts.NoClear = false
+	opts.AltScreen = true
+	opts.Accessible = true
+	if err := player.New(s, &out, opts).Run(context.Background()); err != nil {
+		t.Fatalf("expected no error, but got %v", err)
+	}
+
+	expected := "\033[H\033[2JC:\\> \r\033[KStep 1 of 4: Say hello\nC:\\> echo first\nfirst\nC:\\> \033[?25h\033[2 q\033[0 q" +
+		"\r\033[KStep 3 of 4\nC:\\> echo second\nsecond\nC:\\> \r\033[KStep 4 of 4\nC:\\> echo third\nthird\nC:\\> "
+	if out.String() != expected {
+		t.Errorf("expected %q, but got %q", expected, out.String())
+	}
+}
+
+// TestPlayerCopyCommands tests that each executed command is placed
+// on the clipboard of the terminal once it has been typed
+func TestPlayerCopyCommands(t *testing.T) {
+	s := &script.Scenario{Steps: []script.Step{
+		{Command: "echo first", Output: "first\n"},
+		{Think: 10},
+	}}
+	var out syncBuffer
+	opts := testOptions()
+	opts.CopyCommands = true
+	if err := player.New(s, &out, opts).Run(context.Background()); err != nil {
+		t.Fatalf("expected no error, but got %v", err)
+	}
+
+	expected := "C:\\> echo first\n\033]52;c;ZWNobyBmaXJzdA==\afirst\nC:\\> "
+	if !strings.Contains(out.String(), expected) || strings.Count(out.String(), "\033]52;") != 1 {
+		t.Errorf("expected the command copied once as %q, but got %q", expected, out.String())
+	}
+}
+
+// TestPlayerWebhooks tests that the webhooks are fired when the
+// playback starts and finishes, or fails
+func TestPlayerWebhooks(t *testing.T) {
+	var mu sync.Mutex
+	var runs []webhook.Run
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var run webhook.Run
+		json.NewDecoder(r.Body).Decode(&run)
+		mu.Lock()
+		runs = append(runs, run)
+		mu.Unlock()
+	}))
+	defer srv.Close()
+	h, err := webhook.New(srv.URL, nil, "")
+	if err != nil {
+		t.Fatalf("expected no error, but got %v", err)
+	}
+
+	opts := testOptions()
+	opts.Source = "demo.txt"
+	opts.Webhooks = &webhook.Notifier{Hooks: []*webhook.Hook{h}}
+	if err := player.New(mustParse(t, "echo first\necho second"), &syncBuffer{}, opts).Run(context.Background()); err != nil {
+		t.Fatalf("expected no error, but got %v", err)
+	}
+	s := &script.Scenario{Steps: []script.Step{{Command: "echo first"}, {WaitAudio: "missing.wav"}}}
+	if err := player.New(s, &syncBuffer{}, opts).Run(context.Background()); err == nil {
+		t.Fatalf("expected error for a missing narration, but got nil")
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	expected := []struct {
+		event string
+		steps int
+	}{{webhook.Start, 0}, {webhook.Finish, 2}, {webhook.Start, 0}, {webhook.Fail, 1}}
+	if len(runs) != len(expected) {
+		t.Fatalf("expected %d webhooks, but got %+v", len(expected), runs)
+	}
+	for i, e := range expected {
+		if runs[i].Event != e.event || runs[i].Steps != e.steps || runs[i].Script != "demo.txt" {
+			t.Errorf("webhook %d: expected %s after %d steps, but got %+v", i+1, e.event, e.steps, runs[i])
+		}
+	}
+	if !strings.Contains(runs[3].Error, "missing.wav") {
+		t.Errorf("expected the error of the failure, but got %q", runs[3].Error)
+	}
+}
+
+// TestPlayerTypeClear tests that the clear command of the shell is
+// typed before the screen is cleared, except after the last command
+func TestPlayerTypeClear(t *testing.T) {
+	tests := []struct {
+		shell    cli.ShellOption
+		expected string
+	}{
+		{shell: cli.Cmd, expected: "C:\\> cls\n\033[H\033[2JC:\\> "},
+		{shell: cli.Bash, expected: "$ clear\n\033[H\033[2J"},
+	}
+
+	for _, test := range tests {
+		var out syncBuffer
+		opts := testOptions()
+		opts.Prompt.Shell = test.shell
+		opts.Prompt.Path = ""
+		opts.NoClear = false
+		opts.TypeClear = true
+
+		p := player.New(mustParse(t, "echo first\necho second"), &out, opts)
+		if err := p.Run(context.Background()); err != nil {
+			t.Fatalf("expected no error, but got %v", err)
+		}
+
+		if n := strings.Count(out.String(), "\033[2J"); n != 2 {
+			t.Errorf("%v: expected the screen to be cleared twice, but got %d in %q", test.shell, n, out.String())
+		}
+		if !strings.Contains(out.String(), test.expected) {
+			t.Errorf("%v: expected %q, but got %q", test.shell, test.expected, out.String())
+		}
+	}
+}
+
+// TestPlayerTypeExit tests that exit is typed after the last command,
+// followed by the closing line and optionally a cleared screen
+func TestPlayerTypeExit(t *testing.T) {
+	tests := []struct {
+		name     string
+		shell    cli.ShellOption
+		message  string
+		clear    bool
+		expected string
+	}{
+		{name: "Cmd", shell: cli.Cmd, expected: "C:\\> exit\n"},
+		{name: "Bash", shell: cli.Bash, expected: "$ exit\nlogout\n"},
+		{name: "Message", shell: cli.Cmd, message: "Connection closed.", expected: "C:\\> exit\nConnection closed.\n"},
+		{name: "Clear", shell: cli.Cmd, clear: true, expected: "C:\\> exit\n\033[H\033[2J"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var out syncBuffer
+			opts := testOptions()
+			opts.Prompt.Shell = test.shell
+			opts.TypeExit = true
+			opts.ExitMessage = test.message
+			opts.ExitClear = test.clear
+
+			p := player.New(mustParse(t, "echo done"), &out, opts)
+			if err := p.Run(context.Background()); err != nil {
+				t.Fatalf("expected no error, but got %v", err)
+			}
+			if !strings.HasSuffix(out.String(), test.expected) {
+				t.Errorf("expected output ending with %q, but got %q", test.expected, out.String())
+			}
+		})
+	}
+}
+
+// TestPlayerMotd tests that the message of the day and the summary
+// of the system are printed at the prompt, with the defaults used
+// for what has not been configured
+func TestPlayerMotd(t *testing.T) {
+	var out syncBuffer
+	opts := testOptions()
+	opts.Prompt.Username, opts.Prompt.Hostname = "root", "box"
+	opts.SystemInfo = cli.SystemInfo{OS: "Debian GNU/Linux 12"}
+
+	p := player.New(mustParse(t, "#motd\n#motd sysinfo\necho done"), &out, opts)
+	if err := p.Run(context.Background()); err != nil {
+		t.Fatalf("expected no error, but got %v", err)
+	}
+
+	for _, expected := range []string{"Welcome to Ubuntu", "root@box", ": Debian GNU/Linux 12\n", ": " + cli.DefaultSystemInfo.Kernel + "\n", "C:\\> echo done"} {
+		if !strings.Contains(out.String(), expected) {
+			t.Errorf("expected %q in the output, but got %q", expected, out.String())
+		}
+	}
+
+	out = syncBuffer{}
+	opts.Motd = "Authorized access only"
+	if err := player.New(mustParse(t, "#motd"), &out, opts).Run(context.Background()); err != nil {
+		t.Fatalf("expected no error, but got %v", err)
+	}
+	if !strings.Contains(out.String(), "Authorized access only\nC:\\> ") {
+		t.Errorf("expected the configured motd, but got %q", out.String())
+	}
+}
+
+// TestPlayerOutputTemplate tests that the canned output is executed
+// as a template with the command and the number of the step
+func TestPlayerOutputTemplate(t *testing.T) {
+	var out syncBuffer
+	s := &script.Scenario{Steps: []script.Step{
+		{Command: "echo first", Output: "skipped"},
+		{Command: "kubectl get pod web", Output: "{{index .Args 3}} step {{.Step}} of {{add .Step 1}}"},
+	}}
+	if err := player.New(s, &out, testOptions()).Run(context.Background()); err != nil {
+		t.Fatalf("expected no error, but got %v", err)
+	}
+	if !strings.Contains(out.String(), "\nweb step 2 of 3\n") {
+		t.Errorf("expected the output template to be executed, but got %q", out.String())
+	}
+}
+
+// TestPlayerSimulate tests that simulated commands are typed and
+// followed by the generated output, in the style of the shell
+func TestPlayerSimulate(t *testing.T) {
+	s := &script.Scenario{Steps: []script.Step{
+		{Simulate: "ping -n 2 dns.google", Params: simulate.Params{"interval": 0, "latency": 1}},
+	}}
+
+	var out syncBuffer
+	if err := player.New(s, &out, testOptions()).Run(context.Background()); err != nil {
+		t.Fatalf("expected no error, but got %v", err)
+	}
+	for _, expected := range []string{"C:\\> ping -n 2 dns.google\n", "Pinging dns.google [8.8.8.8]", "Sent = 2, Received = 2"} {
+		if !strings.Contains(out.String(), expected) {
+			t.Errorf("expected %q in the output, but got %q", expected, out.String())
+		}
+	}
+
+	opts := testOptions()
+	opts.Prompt.Shell = cli.Bash
+	s.Steps[0].Simulate = "ping -c 2 dns.google"
+	out = syncBuffer{}
+	if err := player.New(s, &out, opts).Run(context.Background()); err != nil {
+		t.Fatalf("expected no error, but got %v", err)
+	}
+	if !strings.Contains(out.String(), "2 packets transmitted, 2 received, 0% packet loss") {
+		t.Errorf("expected the statistics of ping, but got %q", out.String())
+	}
+}
+
+// TestPlayerSimulatorPlugin tests that the external simulators of
+// the scenario run in the directory of the scenario
+func TestPlayerSimulatorPlugin(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh is not available")
+	}
+	dir := t.TempDir()
+	plugin := `cat > /dev/null; printf '{"text": "%s\\n"}\n' "$(basename "$PWD")"`
+	if err := os.WriteFile(filepath.Join(dir, "kubectl.sh"), []byte(plugin), 0o644); err != nil {
+		t.Fatalf("failed to write plugin: %v", err)
+	}
+
+	s := &script.Scenario{
+		Simulators: map[string]string{"kubectl": "sh kubectl.sh"},
+		Steps:      []script.Step{{Simulate: "kubectl get pods"}},
+		Dir:        dir,
+	}
+	var out syncBuffer
+	if err := player.New(s, &out, testOptions()).Run(context.Background()); err != nil {
+		t.Fatalf("expected no error, but got %v", err)
+	}
+	if !strings.Contains(out.String(), "C:\\> kubectl get pods\n"+filepath.Base(dir)+"\n") {
+		t.Errorf("expected the output of the plugin, but got %q", out.String())
+	}
+}
+
+// TestPlayerPalette tests that the system colors of the terminal are
+// redefined with the active palette during the playback only
+func TestPlayerPalette(t *testing.T) {
+	defer func(p *cli.Palette) { cli.ActivePalette = p }(cli.ActivePalette)
+	cli.ActivePalette, _ = cli.ParsePalette("protanopia")
+
+	s := &script.Scenario{Steps: []script.Step{{Command: "echo hello", Output: "hello"}}}
+	var out syncBuffer
+	if err := player.New(s, &out, testOptions()).Run(context.Background()); err != nil {
+		t.Fatalf("expected no error, but got %v", err)
+	}
+	got := out.String()
+	if !strings.HasPrefix(got, "\033]4;1;rgb:e6/61/00\a") {
+		t.Errorf("expected the red of the palette first, but got %q", got)
+	}
+	if !strings.HasSuffix(got, "\033]104\a") {
+		t.Errorf("expected the colors to be restored at the end, but got %q", got)
+	}
+}
+
+// TestPlayerShellBuiltins tests that the builtins of the shell are
+// interpreted instead of executed, changing the working directory of
+// the commands that follow, and that each playback starts again in
+// the directory of the prompt
+func TestPlayerShellBuiltins(t *testing.T) {
+	if _, err := exec.LookPath("pwd"); err != nil {
+		t.Skip("pwd is not available")
+	}
+	root := t.TempDir()
+	if err := os.Mkdir(filepath.Join(root, "src"), 0o755); err != nil {
+		t.Fatalf("failed to create the directory: %v", err)
+	}
+
+	opts := testOptions()
+	opts.Prompt.Path = root
+	var out syncBuffer
+	p := player.New(mustParse(t, "cd src\npwd\ncd nowhere"), &out, opts)
+	for run := 0; run < 2; run++ {
+		if err := p.Run(context.Background()); err != nil {
+			t.Fatalf("expected no error, but got %v", err)
+		}
+	}
+	for _, expected := range []string{"> pwd\n" + filepath.Join(root, "src") + "\n", "src> cd nowhere\nError: cd: nowhere: no such file or directory\n"} {
+		if n := strings.Count(out.String(), expected); n != 2 {
+			t.Errorf("expected %q in both runs, but got %q", expected, out.String())
+		}
+	}
+	if strings.Contains(out.String(), "cd: src") {
+		t.Errorf("expected the second run to start in %s, but got %q", root, out.String())
+	}
+}
@@ -0,0 +1,167 @@
+/*
+Copyright © 2023 Mikael Schultz <bitcanon@proton.me>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package player
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bitcanon/autotyper/audio"
+	"github.com/bitcanon/autotyper/cli"
+	"github.com/bitcanon/autotyper/graphics"
+	"github.com/bitcanon/autotyper/script"
+	"github.com/bitcanon/autotyper/simulate"
+)
+
+// Issue is a problem found by Preflight
+type Issue struct {
+	// The step with the problem, from 0, or -1 for the whole scenario
+	Step int
+
+	Message string
+}
+
+// String returns the message of the issue, after its step if any
+func (i Issue) String() string {
+	if i.Step < 0 {
+		return i.Message
+	}
+	return fmt.Sprintf("step %d: %s", i.Step+1, i.Message)
+}
+
+// Preflight checks that the scenario can be played here, without
+// playing it: the programs of the executed commands, of the external
+// simulators and of the chapter command are found, the modules of the
+// simulators exist, and the estimated duration is within the bounds
+// (if not zero). Commands with variables that are not set yet, and the
+// commands of Lua code, are not checked. The builtins of the shell are
+// interpreted as in the playback, so that aliases are expanded
+func (p *Player) Preflight(shortest, longest time.Duration) []Issue {
+	var issues []Issue
+	shell, prompt := &cli.Shell{}, p.prompt
+	start, end := p.bounds()
+	for i := start; i < end; i++ {
+		step := p.steps[i]
+		if step.WaitAudio != "" && p.selected(i) {
+			if _, err := audio.Duration(p.scenario.AudioFile(step.WaitAudio)); err != nil {
+				issues = append(issues, Issue{Step: i, Message: err.Error()})
+			}
+			continue
+		}
+		if step.Image != "" && p.selected(i) {
+			if err := graphics.Check(p.scenario.ImageFile(step.Image)); err != nil {
+				issues = append(issues, Issue{Step: i, Message: err.Error()})
+			}
+			continue
+		}
+		if !p.selected(i) || step.Goto != nil || step.Ask != "" || step.Motd != "" || step.Lua != "" || step.Think > 0 || step.QRCode != "" {
+			continue
+		}
+		command := cli.StripReadings(script.Expand(step.Text(), p.vars))
+		if step.Simulate != "" {
+			if _, ok := p.scenario.Simulator(command); !ok {
+				issues = append(issues, Issue{Step: i, Message: fmt.Sprintf("no simulator for %q", command)})
+			}
+			continue
+		}
+		if step.Output != "" || p.opts.Sandbox != nil || strings.Contains(command, "${") {
+			continue
+		}
+		if err := cli.CommandPolicy.Check(command); err != nil {
+			issues = append(issues, Issue{Step: i, Message: err.Error() + ", it is only typed"})
+			continue
+		}
+		if p.opts.TypeOnlyDangerous && cli.MatchAny(command, p.opts.Dangerous) {
+			continue
+		}
+		command = shell.Expand(command)
+		if len(step.Input) == 0 && len(step.Expect) == 0 {
+			if builtin, _ := shell.Builtin(command, &prompt, io.Discard); builtin {
+				continue
+			}
+		}
+		if fields := strings.Fields(command); len(fields) > 0 {
+			if _, err := exec.LookPath(fields[0]); err != nil {
+				issues = append(issues, Issue{Step: i, Message: fmt.Sprintf("%s is not installed", fields[0])})
+			}
+		}
+	}
+	issues = append(issues, p.checkSimulators()...)
+	issues = append(issues, p.checkChapterCommand()...)
+
+	total := p.Estimate().Total
+	if longest > 0 && total > longest {
+		issues = append(issues, Issue{Step: -1, Message: fmt.Sprintf("the estimated duration %s is over %s", seconds(total), longest)})
+	}
+	if shortest > 0 && total < shortest {
+		issues = append(issues, Issue{Step: -1, Message: fmt.Sprintf("the estimated duration %s is under %s", seconds(total), shortest)})
+	}
+	return issues
+}
+
+// checkSimulators checks that the external simulators of the scenario
+// can run: the programs are found, and the modules exist
+func (p *Player) checkSimulators() []Issue {
+	names := make([]string, 0, len(p.scenario.Simulators))
+	for name := range p.scenario.Simulators {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var issues []Issue
+	for _, name := range names {
+		simulator, _ := p.scenario.Simulator(name)
+		switch sim := simulator.(type) {
+		case simulate.WASM:
+			path := sim.Path
+			if !filepath.IsAbs(path) {
+				path = filepath.Join(sim.Dir, path)
+			}
+			if _, err := os.Stat(path); err != nil {
+				issues = append(issues, Issue{Step: -1, Message: fmt.Sprintf("simulator of %s: %v", name, err)})
+			}
+		case simulate.Plugin:
+			fields := strings.Fields(sim.Command)
+			if len(fields) == 0 {
+				continue
+			}
+			program := fields[0]
+			if strings.ContainsRune(program, filepath.Separator) || strings.ContainsRune(program, '/') {
+				if !filepath.IsAbs(program) {
+					program = filepath.Join(sim.Dir, program)
+				}
+				if _, err := os.Stat(program); err != nil {
+					issues = append(issues, Issue{Step: -1, Message: fmt.Sprintf("simulator of %s: %v", name, err)})
+				}
+			} else if _, err := exec.LookPath(program); err != nil {
+				issues = append(issues, Issue{Step: -1, Message: fmt.Sprintf("simulator of %s: %s is not installed", name, program)})
+			}
+		}
+	}
+	return issues
+}
@@ -0,0 +1,92 @@
+package player_test
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bitcanon/autotyper/player"
+	"github.com/bitcanon/autotyper/script"
+)
+
+// TestPlayerPreflight tests that the missing programs, simulators and
+// modules are reported, but not the builtins of the shell and the
+// aliases, and that the estimated duration is checked
+func TestPlayerPreflight(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the fake program is a shell script")
+	}
+	bin := t.TempDir()
+	if err := os.WriteFile(filepath.Join(bin, "kubectl"), []byte("#!/bin/sh\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", bin)
+
+	s := &script.Scenario{
+		Dir: t.TempDir(),
+		Simulators: map[string]string{
+			"gcloud": "missing.wasm",
+			"helm":   "./helm.py",
+			"aws":    "python9 aws.py",
+		},
+		Steps: []script.Step{
+			{Command: "kubectl get pods"},
+			{Command: "terraform apply"},
+			{Command: "terraform plan", Output: "No changes."},
+			{Simulate: "ping dns.google"},
+			{Simulate: "az login"},
+			{Ask: "tool"},
+			{Command: "${tool} version"},
+			{Lua: `demo.run("missing")`},
+			{Command: "cd /tmp"},
+			{Command: "export KUBECONFIG=demo"},
+			{Command: "alias k=kubectl"},
+			{Command: "k get nodes"},
+		},
+	}
+	opts := testOptions()
+	opts.PostDelay = 2000
+
+	tests := []struct {
+		name              string
+		shortest, longest time.Duration
+		expected          []string
+	}{
+		{
+			name: "NoBounds",
+			expected: []string{
+				"step 2: terraform is not installed",
+				`step 5: no simulator for "az login"`,
+				"simulator of aws: python9 is not installed",
+				"simulator of gcloud: stat " + filepath.Join(s.Dir, "missing.wasm") + ": no such file or directory",
+				"simulator of helm: stat " + filepath.Join(s.Dir, "helm.py") + ": no such file or directory",
+			},
+		},
+		{name: "TooLong", longest: time.Second, expected: []string{"the estimated duration"}},
+		{name: "TooShort", shortest: time.Hour, expected: []string{"the estimated duration"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			issues := player.New(s, io.Discard, opts).Preflight(test.shortest, test.longest)
+			if test.shortest == 0 && test.longest == 0 {
+				if len(issues) != len(test.expected) {
+					t.Fatalf("expected %d issues, but got %v", len(test.expected), issues)
+				}
+				for i, issue := range issues {
+					if issue.String() != test.expected[i] {
+						t.Errorf("expected %q, but got %q", test.expected[i], issue)
+					}
+				}
+				return
+			}
+			last := issues[len(issues)-1]
+			if last.Step != -1 || !strings.HasPrefix(last.Message, test.expected[0]) {
+				t.Errorf("expected an issue with the duration, but got %v", issues)
+			}
+		})
+	}
+}
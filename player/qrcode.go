@@ -0,0 +1,43 @@
+/*
+Copyright © 2023 Mikael Schultz <bitcanon@proton.me>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package player
+
+import (
+	"context"
+
+	"github.com/bitcanon/autotyper/cli"
+	"github.com/bitcanon/autotyper/qrcode"
+)
+
+// showQRCode draws the QR code of the text below the prompt, with the
+// text as a caption under it, and holds the demo while it is scanned
+func (p *Player) showQRCode(ctx context.Context, text string) error {
+	code, err := qrcode.Encode(text)
+	if err != nil {
+		return err
+	}
+	if err := code.Draw(p.out); err != nil {
+		return err
+	}
+	cli.PrintCaption(text, p.out)
+	return p.sleep(ctx, p.opts.QRCodeDuration)
+}
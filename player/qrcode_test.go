@@ -0,0 +1,40 @@
+package player_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bitcanon/autotyper/player"
+	"github.com/bitcanon/autotyper/script"
+)
+
+// TestPlayerQRCode tests that the QR code is drawn with the text
+// under it, with the variables expanded, and shown for its duration
+func TestPlayerQRCode(t *testing.T) {
+	s := &script.Scenario{Steps: []script.Step{{QRCode: "https://github.com/${repo}"}}}
+	opts := testOptions()
+	opts.Variables = map[string]string{"repo": "bitcanon/autotyper"}
+	opts.QRCodeDuration = 200
+
+	var out syncBuffer
+	p := player.New(s, &out, opts)
+	started := time.Now()
+	if err := p.Run(context.Background()); err != nil {
+		t.Fatalf("expected no error, but got %v", err)
+	}
+	if elapsed := time.Since(started); elapsed < 200*time.Millisecond {
+		t.Errorf("expected the QR code to be shown for 200ms, but it took %v", elapsed)
+	}
+	for _, expected := range []string{"\033[30;107m    █▀▀▀▀▀█", "https://github.com/bitcanon/autotyper\033[0m\nC:\\> "} {
+		if !strings.Contains(out.String(), expected) {
+			t.Errorf("expected %q in %q", expected, out.String())
+		}
+	}
+
+	report := p.Estimate()
+	if len(report.Steps) != 1 || report.Steps[0].Pauses != 200*time.Millisecond {
+		t.Errorf("expected the QR code to last 200ms in the estimate, but got %+v", report.Steps)
+	}
+}
@@ -0,0 +1,78 @@
+/*
+Copyright © 2023 Mikael Schultz <bitcanon@proton.me>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package player
+
+import "fmt"
+
+// Curve describes how the typing speeds up along a ramp
+type Curve int
+
+// Define constants for the curves
+const (
+	Linear Curve = iota
+	EaseIn
+	EaseOut
+)
+
+// ParseCurve returns the curve for its name ("linear",
+// "ease-in" or "ease-out")
+func ParseCurve(name string) (Curve, error) {
+	switch name {
+	case "linear":
+		return Linear, nil
+	case "ease-in":
+		return EaseIn, nil
+	case "ease-out":
+		return EaseOut, nil
+	default:
+		return Linear, fmt.Errorf("invalid curve %q, expected linear, ease-in or ease-out", name)
+	}
+}
+
+// Ramp slows down the typing of the first commands: the delay
+// between each character of the first command is multiplied by
+// Factor, and decreases along the curve to the normal delay at
+// the command with the index Commands
+type Ramp struct {
+	Factor   float64
+	Commands int
+	Curve    Curve
+}
+
+// Scale returns the multiplier of the delay between each
+// character for the command with the index
+func (r Ramp) Scale(index int) float64 {
+	if r.Factor <= 0 || r.Commands <= 0 || index >= r.Commands {
+		return 1
+	}
+
+	t := float64(index) / float64(r.Commands)
+	switch r.Curve {
+	case EaseIn:
+		// Slow at first, then speeding up quickly
+		t = t * t
+	case EaseOut:
+		// Speeding up quickly at first, then slowly
+		t = 1 - (1-t)*(1-t)
+	}
+	return r.Factor + (1-r.Factor)*t
+}
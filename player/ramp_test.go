@@ -0,0 +1,45 @@
+package player_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/bitcanon/autotyper/player"
+)
+
+// TestRampScale tests that the typing speeds up along the
+// curve until the normal speed is reached
+func TestRampScale(t *testing.T) {
+	tests := []struct {
+		name     string
+		ramp     player.Ramp
+		expected []float64
+	}{
+		{name: "Disabled", ramp: player.Ramp{}, expected: []float64{1, 1, 1}},
+		{name: "Linear", ramp: player.Ramp{Factor: 3, Commands: 4}, expected: []float64{3, 2.5, 2, 1.5, 1, 1}},
+		{name: "EaseIn", ramp: player.Ramp{Factor: 3, Commands: 2, Curve: player.EaseIn}, expected: []float64{3, 2.5, 1}},
+		{name: "EaseOut", ramp: player.Ramp{Factor: 3, Commands: 2, Curve: player.EaseOut}, expected: []float64{3, 1.5, 1}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			for i, expected := range test.expected {
+				if scale := test.ramp.Scale(i); math.Abs(scale-expected) > 1e-9 {
+					t.Errorf("command %d: expected %v, but got %v", i, expected, scale)
+				}
+			}
+		})
+	}
+}
+
+// TestParseCurve tests the names of the curves
+func TestParseCurve(t *testing.T) {
+	for name, expected := range map[string]player.Curve{"linear": player.Linear, "ease-in": player.EaseIn, "ease-out": player.EaseOut} {
+		if curve, err := player.ParseCurve(name); err != nil || curve != expected {
+			t.Errorf("%q: expected %v, but got %v (%v)", name, expected, curve, err)
+		}
+	}
+	if _, err := player.ParseCurve("bounce"); err == nil {
+		t.Errorf("expected error for an unknown curve")
+	}
+}
@@ -0,0 +1,203 @@
+/*
+Copyright © 2023 Mikael Schultz <bitcanon@proton.me>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package player
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/bitcanon/autotyper/vt"
+)
+
+// lineWriter keeps track of the line the cursor is on (e.g. the
+// prompt and the command typed so far), so the line can be erased
+// and drawn again whatever the number of rows it has wrapped onto
+type lineWriter struct {
+	mu   sync.Mutex
+	out  io.Writer
+	line []byte
+
+	// The output written since record was called, if recording
+	recorded *bytes.Buffer
+}
+
+// Write writes to the output, remembering the text after the last
+// line feed. The escape sequences are kept, to draw the line again
+// with its colors.
+func (w *lineWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	n, err := w.out.Write(p)
+	if w.recorded != nil {
+		w.recorded.Write(p[:n])
+	}
+	if i := bytes.LastIndexByte(p[:n], '\n'); i >= 0 {
+		w.line = append(w.line[:0], p[i+1:n]...)
+	} else {
+		w.line = append(w.line, p[:n]...)
+	}
+	return n, err
+}
+
+// record starts recording the output, from the start of the line
+// the cursor is on
+func (w *lineWriter) record() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.recorded = bytes.NewBuffer(append([]byte{}, w.line...))
+}
+
+// recordedLen returns the length of the output recorded so far
+func (w *lineWriter) recordedLen() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.recorded == nil {
+		return 0
+	}
+	return w.recorded.Len()
+}
+
+// stopRecording stops recording and returns the output recorded
+func (w *lineWriter) stopRecording() []byte {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.recorded == nil {
+		return nil
+	}
+	recorded := w.recorded.Bytes()
+	w.recorded = nil
+	return recorded
+}
+
+// aside returns a writer to the output that does not change the
+// line, for drawings erased afterwards (e.g. the annotations)
+func (w *lineWriter) aside() io.Writer {
+	return asideWriter{w}
+}
+
+// asideWriter writes to the output of a line writer, without
+// recording the output or changing the line
+type asideWriter struct {
+	w *lineWriter
+}
+
+func (a asideWriter) Write(p []byte) (int, error) {
+	a.w.mu.Lock()
+	defer a.w.mu.Unlock()
+	return a.w.out.Write(p)
+}
+
+// atStart reports whether nothing was written after the last line
+// feed, so that the cursor is at the start of a line
+func (w *lineWriter) atStart() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return len(w.line) == 0
+}
+
+// erase erases the line, moving the cursor to the first column of
+// the first row of the line. If the width is unknown (0), only the
+// row of the cursor is erased.
+func (w *lineWriter) erase(width int) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.moveToStart(width)
+	w.line = w.line[:0]
+}
+
+// redraw erases and draws the line again, after the terminal has
+// been resized to the width and has reflowed its contents
+func (w *lineWriter) redraw(width int) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if width <= 0 || len(w.line) == 0 {
+		return
+	}
+	w.moveToStart(width)
+	w.out.Write(w.line)
+}
+
+// reprint draws the line again on the row of the cursor, after
+// other programs (e.g. the shell) have printed below it
+func (w *lineWriter) reprint() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if len(w.line) == 0 {
+		return
+	}
+	fmt.Fprint(w.out, "\r\033[K")
+	w.out.Write(w.line)
+}
+
+// moveToStart erases the rows of the line, from the last
+// one to the first one where the cursor is left
+func (w *lineWriter) moveToStart(width int) {
+	if up := rows(w.line, width) - 1; up > 0 {
+		fmt.Fprintf(w.out, "\033[%dA", up)
+	}
+	if width > 0 {
+		fmt.Fprint(w.out, "\r\033[J")
+	} else {
+		fmt.Fprint(w.out, "\r\033[K")
+	}
+}
+
+// rows returns the number of rows used by the line on a terminal
+// of the width, or 1 if the width is unknown (0)
+func rows(line []byte, width int) int {
+	if width <= 0 || len(line) == 0 {
+		return 1
+	}
+
+	// Lay the line out on a screen tall enough to never scroll,
+	// each wrapped row taking at least width bytes
+	screen := vt.NewScreen(width, len(line)/width+2)
+	screen.Write(line)
+	_, row := screen.Cursor()
+	return row + 1
+}
+
+// width returns the width of the terminal, 0 if unknown
+func (p *Player) width() int {
+	if p.opts.Width == nil {
+		return 0
+	}
+	return p.opts.Width()
+}
+
+// eraseLine erases the line the cursor is on, including
+// the rows it has wrapped onto
+func (p *Player) eraseLine() {
+	p.line.erase(p.width())
+}
+
+// Resize draws the line being typed again after the terminal has
+// been resized, since the terminal may break it at other columns
+func (p *Player) Resize() {
+	p.line.redraw(p.width())
+}
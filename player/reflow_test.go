@@ -0,0 +1,53 @@
+package player_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/bitcanon/autotyper/cli"
+	"github.com/bitcanon/autotyper/player"
+)
+
+// TestPlayerReflow tests that the prompt wrapped onto several rows
+// is erased as a whole, and drawn again when the terminal is resized
+func TestPlayerReflow(t *testing.T) {
+	// The prompt is 30 characters long, 3 rows of 10 columns
+	prompt := "C:\\Users\\presenter\\Documents> "
+
+	tests := []struct {
+		name     string
+		input    string
+		resize   bool
+		expected string
+	}{
+		{name: "Erase", input: "#ask ticket\necho ${ticket}", expected: prompt + "\033[2A\r\033[J"},
+		{name: "Resize", input: "echo hi", resize: true, expected: prompt + "\033[2A\r\033[J" + prompt},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var out syncBuffer
+			opts := testOptions()
+			opts.Prompt = cli.Prompt{Path: "C:\\Users\\presenter\\Documents", Shell: cli.Cmd}
+			opts.Ask = func(name string) (string, error) { return "JIRA-42", nil }
+			opts.Width = func() int { return 10 }
+
+			p := player.New(mustParse(t, test.input), &out, opts)
+			if err := p.Run(context.Background()); err != nil {
+				t.Fatalf("expected no error, but got %v", err)
+			}
+			if test.resize {
+				p.Resize()
+				if !strings.HasSuffix(out.String(), test.expected) {
+					t.Errorf("expected output to end with %q, but got %q", test.expected, out.String())
+				}
+				return
+			}
+
+			if !strings.Contains(out.String(), test.expected) {
+				t.Errorf("expected output to contain %q, but got %q", test.expected, out.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,125 @@
+/*
+Copyright © 2023 Mikael Schultz <bitcanon@proton.me>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package player
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"github.com/bitcanon/autotyper/locale"
+)
+
+// StepTiming is the time spent on one step of the playback
+type StepTiming struct {
+	// The index and the text of the command (e.g. "#think 500"
+	// for steps without a command)
+	Step    int
+	Command string
+
+	// Time spent typing the command, executing it, and
+	// pausing before and after it or at the prompt
+	Typing    time.Duration
+	Execution time.Duration
+	Pauses    time.Duration
+}
+
+// Total returns the time spent on the step
+func (t StepTiming) Total() time.Duration {
+	return t.Typing + t.Execution + t.Pauses
+}
+
+// Report is the time spent on each step of a playback. The total
+// duration also includes the time the playback was paused
+type Report struct {
+	Steps []StepTiming
+	Total time.Duration
+}
+
+// stepJSON and reportJSON are the JSON forms of the
+// timings, with the durations in milliseconds
+type stepJSON struct {
+	Step      int    `json:"step"`
+	Command   string `json:"command"`
+	Typing    int64  `json:"typing-ms"`
+	Execution int64  `json:"execution-ms"`
+	Pauses    int64  `json:"pauses-ms"`
+	Total     int64  `json:"total-ms"`
+}
+
+type reportJSON struct {
+	Steps []stepJSON `json:"steps"`
+	Total int64      `json:"total-ms"`
+}
+
+// MarshalJSON encodes the report with the durations in milliseconds
+// and the steps numbered from 1
+func (r Report) MarshalJSON() ([]byte, error) {
+	out := reportJSON{Steps: make([]stepJSON, len(r.Steps)), Total: r.Total.Milliseconds()}
+	for i, step := range r.Steps {
+		out.Steps[i] = stepJSON{
+			Step:      step.Step + 1,
+			Command:   step.Command,
+			Typing:    step.Typing.Milliseconds(),
+			Execution: step.Execution.Milliseconds(),
+			Pauses:    step.Pauses.Milliseconds(),
+			Total:     step.Total().Milliseconds(),
+		}
+	}
+	return json.Marshal(out)
+}
+
+// Print writes the report as a table, one row per step
+func (r Report) Print(out io.Writer) error {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t  %s\n", locale.T("STEP"), locale.T("TYPING"),
+		locale.T("EXECUTION"), locale.T("PAUSES"), locale.T("TOTAL"), locale.T("COMMAND"))
+	for _, step := range r.Steps {
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t  %s\n", step.Step+1, seconds(step.Typing),
+			seconds(step.Execution), seconds(step.Pauses), seconds(step.Total()), step.Command)
+	}
+	fmt.Fprintf(w, "\t\t\t\t%s\t  %s\n", seconds(r.Total), locale.T("total"))
+	return w.Flush()
+}
+
+// seconds formats a duration in seconds with one decimal (e.g. "2.5s")
+func seconds(d time.Duration) string {
+	return fmt.Sprintf("%.1fs", d.Seconds())
+}
+
+// record adds the timing of a step to the report
+func (p *Player) record(timing StepTiming) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.report.Steps = append(p.report.Steps, timing)
+}
+
+// Report returns the time spent on the steps played so far
+func (p *Player) Report() Report {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	report := p.report
+	report.Steps = append([]StepTiming(nil), p.report.Steps...)
+	return report
+}
@@ -0,0 +1,108 @@
+package player_test
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bitcanon/autotyper/cli"
+	"github.com/bitcanon/autotyper/player"
+)
+
+// TestPlayerReport tests that the typing, execution and pauses
+// of each step are measured during the playback
+func TestPlayerReport(t *testing.T) {
+	opts := testOptions()
+	opts.CharDelay = 5
+	opts.PreDelay = 20
+	opts.PostDelay = 30
+
+	var out syncBuffer
+	p := player.New(mustParse(t, "echo one\n#think 40\necho two"), &out, opts)
+	if err := p.Run(context.Background()); err != nil {
+		t.Fatalf("expected no error, but got %v", err)
+	}
+
+	report := p.Report()
+	if len(report.Steps) != 3 {
+		t.Fatalf("expected 3 steps, but got %d", len(report.Steps))
+	}
+	commands := []string{"echo one", "#think 40", "echo two"}
+	for i, step := range report.Steps {
+		if step.Step != i || step.Command != commands[i] {
+			t.Errorf("expected step %d %q, but got %d %q", i, commands[i], step.Step, step.Command)
+		}
+	}
+
+	first, think := report.Steps[0], report.Steps[1]
+	if first.Typing < 40*time.Millisecond {
+		t.Errorf("expected typing of at least 40ms, but got %v", first.Typing)
+	}
+	if first.Pauses < 50*time.Millisecond {
+		t.Errorf("expected pauses of at least 50ms, but got %v", first.Pauses)
+	}
+	if think.Pauses < 40*time.Millisecond || think.Typing != 0 || think.Execution != 0 {
+		t.Errorf("expected only a pause of at least 40ms, but got %+v", think)
+	}
+	if report.Total < first.Total()+think.Total()+report.Steps[2].Total() {
+		t.Errorf("expected a total of at least the sum of the steps, but got %v", report.Total)
+	}
+}
+
+// TestReportOutput tests the table and the JSON form of a report
+func TestReportOutput(t *testing.T) {
+	report := player.Report{
+		Steps: []player.StepTiming{
+			{Step: 0, Command: "ls", Typing: 1500 * time.Millisecond, Execution: 300 * time.Millisecond, Pauses: 2 * time.Second},
+		},
+		Total: 3800 * time.Millisecond,
+	}
+
+	var table strings.Builder
+	if err := report.Print(&table); err != nil {
+		t.Fatalf("expected no error, but got %v", err)
+	}
+	for _, expected := range []string{"TYPING", "1.5s", "0.3s", "2.0s", "3.8s  total", "ls"} {
+		if !strings.Contains(table.String(), expected) {
+			t.Errorf("expected %q in the table, but got:\n%s", expected, table.String())
+		}
+	}
+
+	data, err := json.Marshal(report)
+	if err != nil {
+		t.Fatalf("expected no error, but got %v", err)
+	}
+	expected := `{"steps":[{"step":1,"command":"ls","typing-ms":1500,"execution-ms":300,"pauses-ms":2000,"total-ms":3800}],"total-ms":3800}`
+	if string(data) != expected {
+		t.Errorf("expected %s, but got %s", expected, data)
+	}
+}
+
+// TestPlayerReportFakeClock tests that a playback on a fake clock
+// skips the delays, while the report measures them as if they
+// had been waited for
+func TestPlayerReportFakeClock(t *testing.T) {
+	opts := testOptions()
+	opts.PostDelay = 1000
+	opts.Clock = cli.NewFakeClock(time.Now())
+
+	var out syncBuffer
+	p := player.New(mustParse(t, "#think 5000\necho done"), &out, opts)
+	start := time.Now()
+	if err := p.Run(context.Background()); err != nil {
+		t.Fatalf("expected no error, but got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("expected the delays to be skipped, but took %v", elapsed)
+	}
+
+	report := p.Report()
+	if report.Steps[0].Pauses != 5*time.Second {
+		t.Errorf("expected a pause of 5s, but got %v", report.Steps[0].Pauses)
+	}
+	if report.Total < 6*time.Second {
+		t.Errorf("expected a total of at least 6s, but got %v", report.Total)
+	}
+}
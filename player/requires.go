@@ -0,0 +1,62 @@
+/*
+Copyright © 2023 Mikael Schultz <bitcanon@proton.me>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package player
+
+import (
+	"log/slog"
+	"runtime"
+
+	"github.com/bitcanon/autotyper/script"
+)
+
+// guard checks the operating systems and the requirements of the
+// steps when the player is created. It returns the steps, with the
+// commands of the variants for the system and the fallback of the
+// commands whose requirements are not met as their canned output, and
+// the indices of the steps skipped, for another system or because
+// their requirements are not met
+func guard(steps []script.Step) ([]script.Step, map[int]bool) {
+	unmet := map[int]bool{}
+	guarded := make([]script.Step, len(steps))
+	for i, step := range steps {
+		step, ok := step.ForOS(runtime.GOOS)
+		guarded[i] = step
+		if !ok {
+			slog.Debug("skipping step for another system", "step", i+1, "os", step.OS)
+			unmet[i] = true
+			continue
+		}
+
+		req := step.Unmet()
+		if req == "" {
+			continue
+		}
+		if step.Fallback == "" {
+			slog.Info("skipping step with unmet requirement", "step", i+1, "requires", req)
+			unmet[i] = true
+			continue
+		}
+		slog.Info("printing fallback of step with unmet requirement", "step", i+1, "requires", req)
+		guarded[i].Output = step.Fallback
+	}
+	return guarded, unmet
+}
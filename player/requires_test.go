@@ -0,0 +1,65 @@
+package player_test
+
+import (
+	"context"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/bitcanon/autotyper/player"
+	"github.com/bitcanon/autotyper/script"
+)
+
+// TestPlayerRequires tests that the steps whose requirements are not
+// met are skipped, or print their fallback instead of running
+func TestPlayerRequires(t *testing.T) {
+	t.Setenv("AUTOTYPER_TEST_SET", "1")
+	s := &script.Scenario{Steps: []script.Step{
+		{Command: "echo set", Requires: []string{"$AUTOTYPER_TEST_SET"}},
+		{Command: "echo unset", Requires: []string{"$AUTOTYPER_TEST_UNSET"}},
+		{Command: "kubectl get pods", Requires: []string{"autotyper-missing-program"}, Fallback: "No resources found"},
+		{Think: 1000, Requires: []string{"autotyper-missing-program"}},
+	}}
+
+	var out syncBuffer
+	p := player.New(s, &out, testOptions())
+	if err := p.Run(context.Background()); err != nil {
+		t.Fatalf("expected no error, but got %v", err)
+	}
+
+	expected := "C:\\> echo set\nset\nC:\\> kubectl get pods\nNo resources found\nC:\\> "
+	if !strings.HasSuffix(out.String(), expected) {
+		t.Errorf("expected output to end with %q, but got %q", expected, out.String())
+	}
+	if report := p.Estimate(); len(report.Steps) != 2 {
+		t.Errorf("expected 2 steps estimated, but got %+v", report.Steps)
+	}
+	if s.Steps[2].Output != "" {
+		t.Errorf("expected the steps of the scenario to be unchanged, but got %+v", s.Steps[2])
+	}
+}
+
+// TestPlayerOS tests that the steps for other systems are skipped,
+// and that the variants of the commands for the system are played
+func TestPlayerOS(t *testing.T) {
+	other := "plan9"
+	if runtime.GOOS == other {
+		other = "linux"
+	}
+	s := &script.Scenario{Steps: []script.Step{
+		{Command: "echo elsewhere", OS: []string{other}},
+		{Command: "echo default", Variants: map[string]string{runtime.GOOS: "echo variant"}, Output: "done"},
+		{Command: "echo here", OS: []string{runtime.GOOS}, Output: "here"},
+	}}
+
+	var out syncBuffer
+	p := player.New(s, &out, testOptions())
+	if err := p.Run(context.Background()); err != nil {
+		t.Fatalf("expected no error, but got %v", err)
+	}
+
+	expected := "C:\\> echo variant\ndone\nC:\\> echo here\nhere\nC:\\> "
+	if !strings.HasSuffix(out.String(), expected) {
+		t.Errorf("expected output to end with %q, but got %q", expected, out.String())
+	}
+}
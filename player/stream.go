@@ -0,0 +1,55 @@
+/*
+Copyright © 2023 Mikael Schultz <bitcanon@proton.me>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package player
+
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+// receive waits for the next part of the streamed script, if any, and
+// adds its steps to the steps played. It reports whether there may be
+// more steps, which there are not once the stream or the range ends
+func (p *Player) receive(ctx context.Context) (bool, error) {
+	if p.opts.Stream == nil || (p.opts.End > 0 && len(p.steps) >= p.opts.End) {
+		return false, nil
+	}
+	part, err := p.opts.Stream.Next(ctx)
+	if errors.Is(err, io.EOF) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	// The variants and the requirements are resolved part by part
+	steps, unmet := guard(part.Steps)
+	p.mu.Lock()
+	for i := range unmet {
+		p.unmet[len(p.steps)+i] = true
+	}
+	p.steps = append(p.steps, steps...)
+	p.mu.Unlock()
+	p.highlights = append(p.highlights, parseHighlights(part.Highlights)...)
+	return true, nil
+}
@@ -0,0 +1,40 @@
+package player_test
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/bitcanon/autotyper/player"
+	"github.com/bitcanon/autotyper/script"
+)
+
+// TestPlayerStream tests that the steps of a streamed script are
+// played as they are written, after the steps of the scenario
+func TestPlayerStream(t *testing.T) {
+	r, w := io.Pipe()
+	opts := testOptions()
+	opts.Stream = script.NewStream(r)
+
+	var out syncBuffer
+	p := player.New(mustParse(t, "echo one"), &out, opts)
+	done := make(chan error, 1)
+	go func() { done <- p.Run(context.Background()) }()
+
+	// The playback waits at the prompt for the next line
+	waitFor(t, p, func(s player.Status) bool { return s.Step == 1 })
+	w.Write([]byte("echo two\n"))
+	waitFor(t, p, func(s player.Status) bool { return s.Step == 2 && s.Total == 2 })
+	w.Write([]byte("echo three\n"))
+	w.Close()
+	if err := <-done; err != nil {
+		t.Fatalf("expected no error, but got %v", err)
+	}
+
+	for _, expected := range []string{"echo one\none\n", "echo two\ntwo\n", "echo three\nthree\n"} {
+		if !strings.Contains(out.String(), expected) {
+			t.Errorf("expected %q in the output, but got %q", expected, out.String())
+		}
+	}
+}
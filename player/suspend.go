@@ -0,0 +1,129 @@
+/*
+Copyright © 2023 Mikael Schultz <bitcanon@proton.me>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package player
+
+import (
+	"context"
+	"time"
+
+	"github.com/bitcanon/autotyper/cli"
+)
+
+// Suspend holds the timers of the playback while the process is
+// stopped by job control (e.g. Ctrl+Z), so that the pauses resume
+// with the time they had left. The system colors of the palette are
+// reset for the shell. It is called before the process is stopped.
+func (p *Player) Suspend() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if !p.suspendedAt.IsZero() {
+		return
+	}
+	p.suspendedAt = p.now()
+	if cli.ActivePalette != nil {
+		cli.ResetSystemColors(p.line.aside())
+	}
+	p.notify()
+}
+
+// Continue continues the timers held by Suspend once the process
+// has been continued (e.g. with fg), and draws the line being typed
+// again below the messages of the shell
+func (p *Player) Continue() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.suspendedAt.IsZero() {
+		return
+	}
+	p.suspended += p.since(p.suspendedAt)
+	p.suspendedAt = time.Time{}
+	if cli.ActivePalette != nil {
+		cli.SetSystemColors(p.line.aside(), cli.ActivePalette)
+	}
+	p.line.reprint()
+	p.notify()
+}
+
+// continued blocks while the player is suspended, and returns
+// the time spent suspended so far. It returns an error if the
+// context is cancelled while waiting
+func (p *Player) continued(ctx context.Context) (time.Duration, error) {
+	for {
+		p.mu.Lock()
+		suspended, waiting := p.suspended, !p.suspendedAt.IsZero()
+		changed := p.changed
+		p.mu.Unlock()
+
+		if !waiting {
+			return suspended, nil
+		}
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		}
+	}
+}
+
+// maxOversleep is the longest a pause may have overslept to
+// be made up for by the next one
+const maxOversleep = time.Second
+
+// sleep pauses for the number of milliseconds or until the
+// context is cancelled, whichever happens first. The time
+// spent suspended by job control is not counted, and the time
+// the previous pause overslept is made up for, so the pauses
+// of the playback do not drift from its timing
+func (p *Player) sleep(ctx context.Context, ms int) error {
+	if ms <= 0 {
+		return ctx.Err()
+	}
+
+	before, err := p.continued(ctx)
+	if err != nil {
+		return err
+	}
+	p.mu.Lock()
+	deadline := p.now().Add(time.Duration(ms)*time.Millisecond - p.overslept)
+	p.mu.Unlock()
+	defer func() {
+		p.mu.Lock()
+		p.overslept = min(max(p.since(deadline), 0), maxOversleep)
+		p.mu.Unlock()
+	}()
+	for {
+		select {
+		case <-p.clock().After(deadline.Sub(p.now())):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+
+		// The timer keeps running while the process is stopped, so
+		// the pause is made longer by the time spent suspended
+		suspended, err := p.continued(ctx)
+		if err != nil || suspended == before {
+			return err
+		}
+		deadline = deadline.Add(suspended - before)
+		before = suspended
+	}
+}
@@ -0,0 +1,56 @@
+package player_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bitcanon/autotyper/cli"
+	"github.com/bitcanon/autotyper/player"
+)
+
+// TestPlayerSuspend tests that the pauses are made longer by the
+// time spent suspended, which is not counted in the total duration,
+// and that the line being typed is drawn again once continued
+func TestPlayerSuspend(t *testing.T) {
+	var out syncBuffer
+	p := player.New(mustParse(t, "#think 200"), &out, testOptions())
+
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		p.Suspend()
+		time.Sleep(300 * time.Millisecond)
+		p.Continue()
+	}()
+
+	start := time.Now()
+	if err := p.Run(context.Background()); err != nil {
+		t.Fatalf("expected no error, but got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 500*time.Millisecond {
+		t.Errorf("expected the pause to last 500ms with the suspension, but took %v", elapsed)
+	}
+	if total := p.Report().Total; total >= 450*time.Millisecond {
+		t.Errorf("expected the suspension not to be counted in the total, but got %v", total)
+	}
+	if _, again, _ := strings.Cut(out.String(), "\r\033[K"); !strings.Contains(again, "C:\\> ") {
+		t.Errorf("expected the prompt to be drawn again, but got %q", out.String())
+	}
+}
+
+// TestPlayerOversleep tests that the time a pause oversleeps is
+// made up for by the next pause, so the playback does not drift
+func TestPlayerOversleep(t *testing.T) {
+	opts := testOptions()
+	opts.Clock = cli.NewLateFakeClock(time.Now(), 7*time.Millisecond)
+
+	var out syncBuffer
+	p := player.New(mustParse(t, "#think 100\n#think 100\n#think 100"), &out, opts)
+	if err := p.Run(context.Background()); err != nil {
+		t.Fatalf("expected no error, but got %v", err)
+	}
+	if total := p.Report().Total; total != 307*time.Millisecond {
+		t.Errorf("expected a total of 307ms, but got %v", total)
+	}
+}
@@ -0,0 +1,153 @@
+/*
+Copyright © 2023 Mikael Schultz <bitcanon@proton.me>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package qrcode
+
+// encode lays out the text in a code of the version
+func encode(text []byte, n int, v version, countBits int) *Code {
+	c := newCode(n, v)
+	data := codewords(text, v, countBits)
+	c.place(interleave(data, v))
+
+	// Keep the mask with the lowest penalty
+	best, lowest := 0, -1
+	for mask := 0; mask < 8; mask++ {
+		c.apply(mask)
+		c.drawFormat(mask)
+		if penalty := c.penalty(); lowest < 0 || penalty < lowest {
+			best, lowest = mask, penalty
+		}
+		c.apply(mask)
+	}
+	c.apply(best)
+	c.drawFormat(best)
+	return c
+}
+
+// codewords returns the data codewords of the text in byte mode,
+// padded to the capacity of the version
+func codewords(text []byte, v version, countBits int) []byte {
+	var bits bitBuffer
+	bits.append(0b0100, 4)
+	bits.append(len(text), countBits)
+	for _, b := range text {
+		bits.append(int(b), 8)
+	}
+
+	capacity := 8 * v.dataCodewords()
+	bits.append(0, min(4, capacity-len(bits)))
+	bits.append(0, (8-len(bits)%8)%8)
+	for pad := 0xec; len(bits) < capacity; pad ^= 0xec ^ 0x11 {
+		bits.append(pad, 8)
+	}
+
+	data := make([]byte, len(bits)/8)
+	for i, bit := range bits {
+		if bit {
+			data[i/8] |= 1 << (7 - i%8)
+		}
+	}
+	return data
+}
+
+// bitBuffer is a sequence of bits
+type bitBuffer []bool
+
+// append appends the n lowest bits of the value, highest first
+func (b *bitBuffer) append(value, n int) {
+	for i := n - 1; i >= 0; i-- {
+		*b = append(*b, (value>>i)&1 != 0)
+	}
+}
+
+// interleave splits the data into the blocks of the version, adds
+// their error correction codewords, and interleaves the blocks
+func interleave(data []byte, v version) []byte {
+	divisor := rsDivisor(v.ecPerBlock)
+	var blocks, ecc [][]byte
+	for i := 0; i < v.blocks1+v.blocks2; i++ {
+		size := v.data1
+		if i >= v.blocks1 {
+			size++
+		}
+		blocks = append(blocks, data[:size])
+		ecc = append(ecc, rsRemainder(data[:size], divisor))
+		data = data[size:]
+	}
+
+	var result []byte
+	for i := 0; i <= v.data1; i++ {
+		for _, block := range blocks {
+			if i < len(block) {
+				result = append(result, block[i])
+			}
+		}
+	}
+	for i := 0; i < v.ecPerBlock; i++ {
+		for _, block := range ecc {
+			result = append(result, block[i])
+		}
+	}
+	return result
+}
+
+// rsDivisor returns the generator polynomial of the Reed-Solomon
+// code of the degree, without its leading coefficient
+func rsDivisor(degree int) []byte {
+	result := make([]byte, degree)
+	result[degree-1] = 1
+	root := byte(1)
+	for i := 0; i < degree; i++ {
+		for j := range result {
+			result[j] = gfMultiply(result[j], root)
+			if j+1 < len(result) {
+				result[j] ^= result[j+1]
+			}
+		}
+		root = gfMultiply(root, 0x02)
+	}
+	return result
+}
+
+// rsRemainder returns the error correction codewords of the data
+func rsRemainder(data, divisor []byte) []byte {
+	result := make([]byte, len(divisor))
+	for _, b := range data {
+		factor := b ^ result[0]
+		copy(result, result[1:])
+		result[len(result)-1] = 0
+		for i := range result {
+			result[i] ^= gfMultiply(divisor[i], factor)
+		}
+	}
+	return result
+}
+
+// gfMultiply multiplies two elements of the Galois field GF(2^8)
+// of QR codes, modulo x^8 + x^4 + x^3 + x^2 + 1
+func gfMultiply(x, y byte) byte {
+	z := 0
+	for i := 7; i >= 0; i-- {
+		z = (z << 1) ^ ((z >> 7) * 0x11d)
+		z ^= int((y>>i)&1) * int(x)
+	}
+	return byte(z)
+}
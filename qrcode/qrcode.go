@@ -0,0 +1,132 @@
+/*
+Copyright © 2023 Mikael Schultz <bitcanon@proton.me>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package qrcode
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ErrTooLong is returned for text too long to fit in a QR code
+var ErrTooLong = errors.New("text too long for a QR code")
+
+// version describes the blocks of a version of QR codes at the
+// medium (M) error correction level
+type version struct {
+	// The error correction codewords of each block
+	ecPerBlock int
+
+	// The blocks of the two groups and the data codewords of
+	// each block (a block of the second group holds one more)
+	blocks1, data1, blocks2 int
+
+	// The centers of the alignment patterns
+	alignment []int
+}
+
+// versions are the versions 1 to 10, holding up to 213 bytes
+var versions = []version{
+	{ecPerBlock: 10, blocks1: 1, data1: 16},
+	{ecPerBlock: 16, blocks1: 1, data1: 28, alignment: []int{6, 18}},
+	{ecPerBlock: 26, blocks1: 1, data1: 44, alignment: []int{6, 22}},
+	{ecPerBlock: 18, blocks1: 2, data1: 32, alignment: []int{6, 26}},
+	{ecPerBlock: 24, blocks1: 2, data1: 43, alignment: []int{6, 30}},
+	{ecPerBlock: 16, blocks1: 4, data1: 27, alignment: []int{6, 34}},
+	{ecPerBlock: 18, blocks1: 4, data1: 31, alignment: []int{6, 22, 38}},
+	{ecPerBlock: 22, blocks1: 2, data1: 38, blocks2: 2, alignment: []int{6, 24, 42}},
+	{ecPerBlock: 22, blocks1: 3, data1: 36, blocks2: 2, alignment: []int{6, 26, 46}},
+	{ecPerBlock: 26, blocks1: 4, data1: 43, blocks2: 1, alignment: []int{6, 28, 50}},
+}
+
+// dataCodewords returns the number of data codewords of the version
+func (v version) dataCodewords() int {
+	return v.blocks1*v.data1 + v.blocks2*(v.data1+1)
+}
+
+// Code is a QR code, a square of dark and light modules
+type Code struct {
+	size     int
+	modules  [][]bool
+	function [][]bool
+}
+
+// Encode encodes the text in byte mode in the smallest QR code it
+// fits in, at the medium error correction level (15% of the code
+// can be damaged)
+func Encode(text string) (*Code, error) {
+	for i, v := range versions {
+		n := i + 1
+		countBits := 8
+		if n >= 10 {
+			countBits = 16
+		}
+		if 4+countBits+8*len(text) <= 8*v.dataCodewords() {
+			return encode([]byte(text), n, v, countBits), nil
+		}
+	}
+	return nil, fmt.Errorf("%w (%d bytes, at most 213)", ErrTooLong, len(text))
+}
+
+// Size returns the number of modules on each side of the code,
+// without the quiet zone around it
+func (c *Code) Size() int {
+	return c.size
+}
+
+// Dark reports whether the module in column x and row y is dark.
+// The modules outside of the code are light
+func (c *Code) Dark(x, y int) bool {
+	if x < 0 || y < 0 || x >= c.size || y >= c.size {
+		return false
+	}
+	return c.modules[y][x]
+}
+
+// quiet is the width of the light margin around a drawn code in
+// modules, as required by the readers
+const quiet = 4
+
+// Draw draws the code with Unicode half blocks, two rows of modules
+// on each line, in black on white whatever the colors of the terminal
+func (c *Code) Draw(out io.Writer) error {
+	var b strings.Builder
+	for y := -quiet; y < c.size+quiet; y += 2 {
+		b.WriteString("\033[30;107m")
+		for x := -quiet; x < c.size+quiet; x++ {
+			switch top, bottom := c.Dark(x, y), c.Dark(x, y+1); {
+			case top && bottom:
+				b.WriteString("█")
+			case top:
+				b.WriteString("▀")
+			case bottom:
+				b.WriteString("▄")
+			default:
+				b.WriteString(" ")
+			}
+		}
+		b.WriteString("\033[0m\n")
+	}
+	_, err := io.WriteString(out, b.String())
+	return err
+}
@@ -0,0 +1,132 @@
+package qrcode_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/bitcanon/autotyper/qrcode"
+)
+
+// TestEncodeSize tests that the text is encoded in the
+// smallest version it fits in
+func TestEncodeSize(t *testing.T) {
+	tests := []struct {
+		length int
+		size   int
+	}{
+		{length: 1, size: 21},
+		{length: 14, size: 21},
+		{length: 15, size: 25},
+		{length: 40, size: 29},
+		{length: 100, size: 41},
+		{length: 122, size: 45},
+		{length: 213, size: 57},
+	}
+
+	for _, test := range tests {
+		code, err := qrcode.Encode(strings.Repeat("a", test.length))
+		if err != nil {
+			t.Fatalf("%d bytes: expected no error, but got %v", test.length, err)
+		}
+		if code.Size() != test.size {
+			t.Errorf("%d bytes: expected size %d, but got %d", test.length, test.size, code.Size())
+		}
+	}
+
+	if _, err := qrcode.Encode(strings.Repeat("a", 214)); !errors.Is(err, qrcode.ErrTooLong) {
+		t.Errorf("expected ErrTooLong, but got %v", err)
+	}
+}
+
+// TestEncodePatterns tests that the finder patterns are drawn in
+// three corners, and that both copies of the format information
+// are valid codewords of the medium error correction level
+func TestEncodePatterns(t *testing.T) {
+	code, err := qrcode.Encode("https://github.com/bitcanon/autotyper")
+	if err != nil {
+		t.Fatalf("expected no error, but got %v", err)
+	}
+	size := code.Size()
+
+	finder := []string{"#######", "#.....#", "#.###.#", "#.###.#", "#.###.#", "#.....#", "#######"}
+	for _, corner := range [][2]int{{0, 0}, {size - 7, 0}, {0, size - 7}} {
+		for dy, row := range finder {
+			for dx, module := range row {
+				if code.Dark(corner[0]+dx, corner[1]+dy) != (module == '#') {
+					t.Fatalf("corner %v: unexpected module at %d,%d", corner, dx, dy)
+				}
+			}
+		}
+	}
+
+	// Read the 15 bits of both copies, lowest first
+	var first, second int
+	for i := 0; i < 15; i++ {
+		var x1, y1, x2, y2 int
+		switch {
+		case i <= 5:
+			x1, y1 = 8, i
+		case i <= 7:
+			x1, y1 = 8, i+1
+		case i == 8:
+			x1, y1 = 7, 8
+		default:
+			x1, y1 = 14-i, 8
+		}
+		if i < 8 {
+			x2, y2 = size-1-i, 8
+		} else {
+			x2, y2 = 8, size-15+i
+		}
+		if code.Dark(x1, y1) {
+			first |= 1 << i
+		}
+		if code.Dark(x2, y2) {
+			second |= 1 << i
+		}
+	}
+	if first != second {
+		t.Fatalf("expected the same format information, but got %015b and %015b", first, second)
+	}
+	format := first ^ 0x5412
+	if format>>13 != 0b00 {
+		t.Errorf("expected level M, but got %02b", format>>13)
+	}
+	rem := format >> 10
+	for i := 0; i < 10; i++ {
+		rem = (rem << 1) ^ ((rem >> 9) * 0x537)
+	}
+	if rem != format&0x3ff {
+		t.Errorf("expected a valid format codeword, but got %015b", first)
+	}
+	if !code.Dark(8, size-8) {
+		t.Errorf("expected the dark module")
+	}
+}
+
+// TestDraw tests that the code is drawn with two rows of modules on
+// each line, inside a quiet zone, in black on white
+func TestDraw(t *testing.T) {
+	code, err := qrcode.Encode("hi")
+	if err != nil {
+		t.Fatalf("expected no error, but got %v", err)
+	}
+
+	var out strings.Builder
+	if err := code.Draw(&out); err != nil {
+		t.Fatalf("expected no error, but got %v", err)
+	}
+	lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+	if len(lines) != 15 {
+		t.Fatalf("expected 15 lines for 21 modules and the quiet zone, but got %d", len(lines))
+	}
+	blank := "\033[30;107m" + strings.Repeat(" ", 29) + "\033[0m"
+	if lines[0] != blank || lines[1] != blank {
+		t.Errorf("expected a blank quiet zone, but got %q", lines[:2])
+	}
+	expected := "\033[30;107m    █▀▀▀▀▀█ "
+	if !strings.HasPrefix(lines[2], expected) {
+		t.Errorf("expected the top of the finder in %q", lines[2])
+	}
+}
@@ -0,0 +1,330 @@
+/*
+Copyright © 2023 Mikael Schultz <bitcanon@proton.me>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package remote
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"sync"
+
+	"github.com/bitcanon/autotyper/player"
+	"github.com/bitcanon/autotyper/script"
+)
+
+// maxScriptSize is the largest script loaded over HTTP, in bytes, the
+// largest message received by the gRPC server by default
+const maxScriptSize = 4 << 20
+
+// ErrNoScript is returned when a playback command is
+// received before a script has been loaded
+var ErrNoScript = errors.New("no script loaded")
+
+// ErrBusy is returned when a script is loaded while the
+// queue of scripts waiting for the playback is full
+var ErrBusy = errors.New("busy: the queue of scripts is full")
+
+// Status is the playback status reported by the agent
+type Status struct {
+	State string `json:"state"`
+	Step  int    `json:"step"`
+	Total int    `json:"total"`
+
+	// The number of scripts waiting for the playback to end
+	Queued int `json:"queued,omitempty"`
+}
+
+// Agent runs on the demo machine. It receives scripts and playback
+// commands from a controller and renders the demo on its own output
+type Agent struct {
+	// The number of scripts waiting for the current playback to end.
+	// If zero, a loaded script replaces the current playback
+	Queue int
+
+	out  io.Writer
+	opts player.Options
+
+	metrics *Metrics
+
+	// Held while a script is loaded, so only one playback
+	// ever renders on the output
+	loading sync.Mutex
+
+	mu          sync.Mutex
+	player      *player.Player
+	cancel      context.CancelFunc
+	done        chan struct{}
+	queue       []*script.Scenario
+	subscribers map[chan player.Event]struct{}
+}
+
+// NewAgent creates an agent that plays scripts on out
+func NewAgent(out io.Writer, opts player.Options) *Agent {
+	return &Agent{
+		out:         out,
+		opts:        opts,
+		metrics:     newMetrics(),
+		subscribers: make(map[chan player.Event]struct{}),
+	}
+}
+
+// Subscribe returns a channel receiving the playback events of all
+// scripts loaded from now on. Events are dropped if the channel is
+// full. Call the returned function to unsubscribe
+func (a *Agent) Subscribe() (<-chan player.Event, func()) {
+	ch := make(chan player.Event, 64)
+
+	a.mu.Lock()
+	a.subscribers[ch] = struct{}{}
+	a.mu.Unlock()
+
+	return ch, func() {
+		a.mu.Lock()
+		delete(a.subscribers, ch)
+		a.mu.Unlock()
+	}
+}
+
+// publish sends an event to all subscribers without blocking
+func (a *Agent) publish(e player.Event) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for ch := range a.subscribers {
+		select {
+		case ch <- e:
+		default:
+		}
+	}
+}
+
+// Load stops the current playback (if any) and loads a new script,
+// either plain text or a JSON scenario. The prompt is printed right
+// away, but the first command is not typed until the playback is
+// started or stepped. If the agent has a queue, the script waits in
+// the queue until the current playback ends instead, and ErrBusy is
+// returned if the queue is full
+func (a *Agent) Load(input string) error {
+	s, err := script.Parse(input)
+	if err != nil {
+		return err
+	}
+
+	a.loading.Lock()
+	defer a.loading.Unlock()
+
+	if a.Queue > 0 {
+		a.mu.Lock()
+		defer a.mu.Unlock()
+		if a.playing() || len(a.queue) > 0 {
+			if len(a.queue) >= a.Queue {
+				return ErrBusy
+			}
+			a.queue = append(a.queue, s)
+			return nil
+		}
+		a.start(s)
+		return nil
+	}
+
+	a.Stop()
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.start(s)
+	return nil
+}
+
+// start starts the playback of a script, paused at the first
+// prompt. The caller must hold the loading and mu locks
+func (a *Agent) start(s *script.Scenario) {
+	ctx, cancel := context.WithCancel(context.Background())
+	p := player.New(s, a.out, a.opts)
+	p.OnEvent(a.publish)
+	watch, update := a.metrics.watch(p)
+	p.OnEvent(watch)
+	p.Pause()
+
+	done := make(chan struct{})
+	a.metrics.runStarted()
+	go func() {
+		err := p.Run(ctx)
+		update()
+		a.metrics.runEnded(result(err))
+		close(done)
+		a.next(done)
+	}()
+
+	a.player = p
+	a.cancel = cancel
+	a.done = done
+}
+
+// next starts the first script of the queue once the playback
+// that closed done has ended, unless it has been replaced
+func (a *Agent) next(done chan struct{}) {
+	a.loading.Lock()
+	defer a.loading.Unlock()
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.done != done || len(a.queue) == 0 {
+		return
+	}
+	s := a.queue[0]
+	a.queue = a.queue[1:]
+	a.start(s)
+}
+
+// playing reports whether the current playback is still running.
+// The caller must hold the mu lock
+func (a *Agent) playing() bool {
+	if a.done == nil {
+		return false
+	}
+	select {
+	case <-a.done:
+		return false
+	default:
+		return true
+	}
+}
+
+// Stop cancels the current playback, waits for it to return
+// and drops the scripts waiting in the queue
+func (a *Agent) Stop() {
+	a.mu.Lock()
+	cancel, done := a.cancel, a.done
+	a.player, a.cancel, a.done, a.queue = nil, nil, nil, nil
+	a.mu.Unlock()
+
+	if cancel != nil {
+		cancel()
+		<-done
+	}
+}
+
+// Play starts or resumes the playback of the loaded script
+func (a *Agent) Play() error {
+	return a.control((*player.Player).Resume)
+}
+
+// Pause pauses the playback before the next command
+func (a *Agent) Pause() error {
+	return a.control((*player.Player).Pause)
+}
+
+// Step plays the next command only
+func (a *Agent) Step() error {
+	return a.control((*player.Player).Step)
+}
+
+// Status returns the playback status of the loaded script
+func (a *Agent) Status() (Status, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.player == nil {
+		return Status{}, ErrNoScript
+	}
+	s := a.player.Status()
+	return Status{State: s.State.String(), Step: s.Step, Total: s.Total, Queued: len(a.queue)}, nil
+}
+
+// control applies a playback command to the loaded script
+func (a *Agent) control(fn func(*player.Player)) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.player == nil {
+		return ErrNoScript
+	}
+	fn(a.player)
+	return nil
+}
+
+// Handler returns the HTTP handler exposing the agent to controllers
+func (a *Agent) Handler() http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/v1/script", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		script, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxScriptSize))
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+			return
+		} else if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err := a.Load(string(script)); errors.Is(err, ErrBusy) {
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		} else if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		a.writeStatus(w)
+	})
+
+	for path, fn := range map[string]func() error{
+		"/v1/play":  a.Play,
+		"/v1/pause": a.Pause,
+		"/v1/step":  a.Step,
+	} {
+		fn := fn
+		mux.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodPost {
+				http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+				return
+			}
+			if err := fn(); err != nil {
+				http.Error(w, err.Error(), http.StatusConflict)
+				return
+			}
+			a.writeStatus(w)
+		})
+	}
+
+	mux.HandleFunc("/v1/status", func(w http.ResponseWriter, r *http.Request) {
+		a.writeStatus(w)
+	})
+
+	// Expose the metrics of the playbacks to Prometheus
+	mux.Handle("/metrics", a.metrics)
+
+	return mux
+}
+
+// writeStatus writes the playback status as JSON
+func (a *Agent) writeStatus(w http.ResponseWriter) {
+	status, err := a.Status()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(status)
+}
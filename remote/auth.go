@@ -0,0 +1,189 @@
+/*
+Copyright © 2023 Mikael Schultz <bitcanon@proton.me>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package remote
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// Auth holds the credentials of the control API. The agent refuses
+// requests without them, and the controller sends them with every
+// request. A zero Auth does not authenticate at all
+type Auth struct {
+	// The bearer token (sent as "Authorization: Bearer <token>")
+	Token string
+
+	// The user name and password of HTTP Basic authentication
+	Username string
+	Password string
+}
+
+// ParseBasicAuth parses the credentials of Basic authentication
+// given as "user:password"
+func ParseBasicAuth(s string) (username, password string, err error) {
+	username, password, ok := strings.Cut(s, ":")
+	if !ok || username == "" {
+		return "", "", fmt.Errorf("invalid basic auth %q: expected user:password", s)
+	}
+	return username, password, nil
+}
+
+// Enabled reports whether any credentials have been set
+func (a Auth) Enabled() bool {
+	return a.Token != "" || a.Username != ""
+}
+
+// Handler returns a handler refusing the requests without valid
+// credentials with 401 Unauthorized, and passing the others to h
+func (a Auth) Handler(h http.Handler) http.Handler {
+	if !a.Enabled() {
+		return h
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !a.authorized(r.Header.Get("Authorization")) {
+			slog.Warn("unauthorized request", "remote", r.RemoteAddr, "path", r.URL.Path)
+			if a.Username != "" {
+				w.Header().Set("WWW-Authenticate", `Basic realm="autotyper"`)
+			} else {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="autotyper"`)
+			}
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
+// ServerOptions returns the options of a gRPC server refusing the
+// calls without valid credentials in their "authorization" metadata
+func (a Auth) ServerOptions() []grpc.ServerOption {
+	if !a.Enabled() {
+		return nil
+	}
+	unary := func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if err := a.check(ctx); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+	stream := func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := a.check(ss.Context()); err != nil {
+			return err
+		}
+		return handler(srv, ss)
+	}
+	return []grpc.ServerOption{grpc.ChainUnaryInterceptor(unary), grpc.ChainStreamInterceptor(stream)}
+}
+
+// PerRPCCredentials returns the credentials sent by gRPC clients,
+// for use with grpc.WithPerRPCCredentials. If secure is set, they
+// are only sent over TLS connections
+func (a Auth) PerRPCCredentials(secure bool) credentials.PerRPCCredentials {
+	return rpcCredentials{auth: a, secure: secure}
+}
+
+// check returns an error unless the metadata of the gRPC call
+// carries valid credentials
+func (a Auth) check(ctx context.Context) error {
+	md, _ := metadata.FromIncomingContext(ctx)
+	for _, value := range md.Get("authorization") {
+		if a.authorized(value) {
+			return nil
+		}
+	}
+	slog.Warn("unauthorized gRPC call")
+	return status.Error(codes.Unauthenticated, "invalid or missing credentials")
+}
+
+// authorized reports whether the value of an Authorization
+// header carries valid credentials
+func (a Auth) authorized(header string) bool {
+	if !a.Enabled() {
+		return true
+	}
+	scheme, value, _ := strings.Cut(header, " ")
+	switch {
+	case strings.EqualFold(scheme, "Bearer") && a.Token != "":
+		return equal(value, a.Token)
+	case strings.EqualFold(scheme, "Basic") && a.Username != "":
+		decoded, err := base64.StdEncoding.DecodeString(value)
+		if err != nil {
+			return false
+		}
+		username, password, _ := strings.Cut(string(decoded), ":")
+		// Compare both, so the time does not tell which one is wrong
+		validUser := equal(username, a.Username)
+		validPassword := equal(password, a.Password)
+		return validUser && validPassword
+	default:
+		return false
+	}
+}
+
+// header returns the value of the Authorization header sent with
+// the credentials, or an empty string if there are none
+func (a Auth) header() string {
+	switch {
+	case a.Token != "":
+		return "Bearer " + a.Token
+	case a.Username != "":
+		return "Basic " + base64.StdEncoding.EncodeToString([]byte(a.Username+":"+a.Password))
+	default:
+		return ""
+	}
+}
+
+// equal compares two secrets in constant time
+func equal(a, b string) bool {
+	return subtle.ConstantTimeCompare([]byte(a), []byte(b)) == 1
+}
+
+// rpcCredentials sends the credentials of an Auth with gRPC calls
+type rpcCredentials struct {
+	auth   Auth
+	secure bool
+}
+
+// GetRequestMetadata returns the authorization metadata of a call
+func (c rpcCredentials) GetRequestMetadata(ctx context.Context, uri ...string) (map[string]string, error) {
+	if header := c.auth.header(); header != "" {
+		return map[string]string{"authorization": header}, nil
+	}
+	return nil, nil
+}
+
+// RequireTransportSecurity reports whether TLS is required
+func (c rpcCredentials) RequireTransportSecurity() bool {
+	return c.secure
+}
@@ -0,0 +1,117 @@
+package remote_test
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/bitcanon/autotyper/cli"
+	"github.com/bitcanon/autotyper/player"
+	"github.com/bitcanon/autotyper/remote"
+	"github.com/bitcanon/autotyper/remote/pb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+)
+
+// newAuthAgent creates an agent for the authentication tests
+func newAuthAgent(t *testing.T) *remote.Agent {
+	t.Helper()
+	agent := remote.NewAgent(io.Discard, player.Options{
+		Prompt:  cli.Prompt{Path: "~", Shell: cli.Bash},
+		NoClear: true,
+	})
+	t.Cleanup(agent.Stop)
+	return agent
+}
+
+// TestAuthHandler tests that the agent only answers controllers
+// sending the configured token or basic auth credentials
+func TestAuthHandler(t *testing.T) {
+	auth := remote.Auth{Token: "s3cret", Username: "presenter", Password: "pa:ss"}
+	server := httptest.NewTLSServer(auth.Handler(newAuthAgent(t).Handler()))
+	defer server.Close()
+
+	tests := []struct {
+		name  string
+		auth  remote.Auth
+		valid bool
+	}{
+		{name: "Token", auth: remote.Auth{Token: "s3cret"}, valid: true},
+		{name: "BasicAuth", auth: remote.Auth{Username: "presenter", Password: "pa:ss"}, valid: true},
+		{name: "NoCredentials", auth: remote.Auth{}, valid: false},
+		{name: "WrongToken", auth: remote.Auth{Token: "guess"}, valid: false},
+		{name: "WrongPassword", auth: remote.Auth{Username: "presenter", Password: "guess"}, valid: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := remote.NewController(server.URL)
+			c.Client = server.Client()
+			c.Auth = test.auth
+
+			_, err := c.Load("echo first")
+			if test.valid && err != nil {
+				t.Errorf("expected no error, but got %v", err)
+			}
+			if !test.valid && (err == nil || !strings.Contains(err.Error(), "unauthorized")) {
+				t.Errorf("expected unauthorized error, but got %v", err)
+			}
+		})
+	}
+}
+
+// TestAuthGRPC tests that gRPC calls without valid credentials
+// are refused as unauthenticated
+func TestAuthGRPC(t *testing.T) {
+	auth := remote.Auth{Token: "s3cret"}
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	server := remote.NewGRPCServer(newAuthAgent(t), auth.ServerOptions()...)
+	go server.Serve(lis)
+	defer server.Stop()
+
+	tests := []struct {
+		name     string
+		auth     remote.Auth
+		expected codes.Code
+	}{
+		{name: "Token", auth: remote.Auth{Token: "s3cret"}, expected: codes.OK},
+		{name: "NoCredentials", auth: remote.Auth{}, expected: codes.Unauthenticated},
+		{name: "WrongToken", auth: remote.Auth{Token: "guess"}, expected: codes.Unauthenticated},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			conn, err := grpc.NewClient(lis.Addr().String(),
+				grpc.WithTransportCredentials(insecure.NewCredentials()),
+				grpc.WithPerRPCCredentials(test.auth.PerRPCCredentials(false)))
+			if err != nil {
+				t.Fatalf("failed to connect: %v", err)
+			}
+			defer conn.Close()
+
+			_, err = pb.NewPlayerClient(conn).LoadScript(context.Background(), &pb.LoadScriptRequest{Script: "echo first"})
+			if status.Code(err) != test.expected {
+				t.Errorf("expected %v, but got %v", test.expected, err)
+			}
+		})
+	}
+}
+
+// TestParseBasicAuth tests the parsing of user:password
+func TestParseBasicAuth(t *testing.T) {
+	username, password, err := remote.ParseBasicAuth("presenter:pa:ss")
+	if err != nil || username != "presenter" || password != "pa:ss" {
+		t.Errorf("expected presenter and pa:ss, but got %q, %q, %v", username, password, err)
+	}
+	if _, _, err := remote.ParseBasicAuth("presenter"); err == nil {
+		t.Errorf("expected error without password, but got nil")
+	}
+}
@@ -0,0 +1,116 @@
+/*
+Copyright © 2023 Mikael Schultz <bitcanon@proton.me>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package remote
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// Controller sends scripts and playback commands to an agent
+type Controller struct {
+	// The base URL of the agent (e.g. "http://stage:7070")
+	URL string
+
+	// The HTTP client used for the requests
+	Client *http.Client
+
+	// The credentials sent to the agent
+	Auth Auth
+}
+
+// NewController creates a controller for the agent at addr. The
+// address can be given as "host:port" or as a full URL
+func NewController(addr string) *Controller {
+	if !strings.Contains(addr, "://") {
+		addr = "http://" + addr
+	}
+	return &Controller{URL: strings.TrimRight(addr, "/"), Client: http.DefaultClient}
+}
+
+// Load sends a script to the agent
+func (c *Controller) Load(script string) (Status, error) {
+	return c.post("/v1/script", strings.NewReader(script))
+}
+
+// Play starts or resumes the playback on the agent
+func (c *Controller) Play() (Status, error) {
+	return c.post("/v1/play", nil)
+}
+
+// Pause pauses the playback on the agent
+func (c *Controller) Pause() (Status, error) {
+	return c.post("/v1/pause", nil)
+}
+
+// Step plays the next command on the agent
+func (c *Controller) Step() (Status, error) {
+	return c.post("/v1/step", nil)
+}
+
+// Status returns the playback status of the agent
+func (c *Controller) Status() (Status, error) {
+	return c.do(http.MethodGet, "/v1/status", nil)
+}
+
+// post sends a POST request to the agent
+func (c *Controller) post(path string, body io.Reader) (Status, error) {
+	return c.do(http.MethodPost, path, body)
+}
+
+// do sends a request with the credentials to the agent
+func (c *Controller) do(method, path string, body io.Reader) (Status, error) {
+	req, err := http.NewRequest(method, c.URL+path, body)
+	if err != nil {
+		return Status{}, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "text/plain")
+	}
+	if header := c.Auth.header(); header != "" {
+		req.Header.Set("Authorization", header)
+	}
+	resp, err := c.Client.Do(req)
+	if err != nil {
+		return Status{}, err
+	}
+	return decodeStatus(resp)
+}
+
+// decodeStatus decodes the status in the response from the agent
+func decodeStatus(resp *http.Response) (Status, error) {
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(resp.Body)
+		return Status{}, fmt.Errorf("agent: %s", strings.TrimSpace(string(msg)))
+	}
+
+	var status Status
+	if err := json.NewDecoder(resp.Body).Decode(&status); err != nil {
+		return Status{}, err
+	}
+	return status, nil
+}
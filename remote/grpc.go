@@ -0,0 +1,134 @@
+/*
+Copyright © 2023 Mikael Schultz <bitcanon@proton.me>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package remote
+
+import (
+	"context"
+	"errors"
+
+	"github.com/bitcanon/autotyper/player"
+	"github.com/bitcanon/autotyper/remote/pb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// grpcServer exposes an agent over gRPC
+type grpcServer struct {
+	pb.UnimplementedPlayerServer
+	agent *Agent
+}
+
+// NewGRPCServer creates a gRPC server exposing the agent
+// to controllers using the generated pb.PlayerClient
+func NewGRPCServer(a *Agent, opts ...grpc.ServerOption) *grpc.Server {
+	server := grpc.NewServer(opts...)
+	pb.RegisterPlayerServer(server, &grpcServer{agent: a})
+	return server
+}
+
+// LoadScript stops the current playback and loads a new script
+func (s *grpcServer) LoadScript(ctx context.Context, req *pb.LoadScriptRequest) (*pb.Status, error) {
+	if err := s.agent.Load(req.GetScript()); errors.Is(err, ErrBusy) {
+		return nil, status.Error(codes.ResourceExhausted, err.Error())
+	} else if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	return s.status()
+}
+
+// Play starts or resumes the playback
+func (s *grpcServer) Play(ctx context.Context, req *pb.PlayRequest) (*pb.Status, error) {
+	if err := s.agent.Play(); err != nil {
+		return nil, grpcError(err)
+	}
+	return s.status()
+}
+
+// Pause pauses the playback before the next command
+func (s *grpcServer) Pause(ctx context.Context, req *pb.PauseRequest) (*pb.Status, error) {
+	if err := s.agent.Pause(); err != nil {
+		return nil, grpcError(err)
+	}
+	return s.status()
+}
+
+// Step plays the next command only
+func (s *grpcServer) Step(ctx context.Context, req *pb.StepRequest) (*pb.Status, error) {
+	if err := s.agent.Step(); err != nil {
+		return nil, grpcError(err)
+	}
+	return s.status()
+}
+
+// GetStatus returns the playback status
+func (s *grpcServer) GetStatus(ctx context.Context, req *pb.GetStatusRequest) (*pb.Status, error) {
+	return s.status()
+}
+
+// Subscribe streams the playback events until the client disconnects.
+// The stream starts with the state of the loaded script, if any
+func (s *grpcServer) Subscribe(req *pb.SubscribeRequest, stream pb.Player_SubscribeServer) error {
+	events, unsubscribe := s.agent.Subscribe()
+	defer unsubscribe()
+
+	// Start the stream with the current state of the loaded script
+	if st, err := s.agent.Status(); err == nil {
+		if err := stream.Send(&pb.Event{Type: player.StateChanged.String(), State: st.State}); err != nil {
+			return err
+		}
+	}
+
+	for {
+		select {
+		case e := <-events:
+			err := stream.Send(&pb.Event{
+				Type:    e.Type.String(),
+				Step:    int32(e.Step),
+				Command: e.Command,
+				State:   e.State.String(),
+			})
+			if err != nil {
+				return err
+			}
+		case <-stream.Context().Done():
+			return nil
+		}
+	}
+}
+
+// status returns the playback status of the agent
+func (s *grpcServer) status() (*pb.Status, error) {
+	st, err := s.agent.Status()
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return &pb.Status{State: st.State, Step: int32(st.Step), Total: int32(st.Total)}, nil
+}
+
+// grpcError converts an agent error to a gRPC status error
+func grpcError(err error) error {
+	if errors.Is(err, ErrNoScript) {
+		return status.Error(codes.FailedPrecondition, err.Error())
+	}
+	return status.Error(codes.Internal, err.Error())
+}
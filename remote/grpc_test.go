@@ -0,0 +1,110 @@
+package remote_test
+
+import (
+	"context"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/bitcanon/autotyper/cli"
+	"github.com/bitcanon/autotyper/player"
+	"github.com/bitcanon/autotyper/remote"
+	"github.com/bitcanon/autotyper/remote/pb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+)
+
+// newTestGRPCAgent starts an agent behind a gRPC server
+// and returns a generated client connected to it
+func newTestGRPCAgent(t *testing.T) pb.PlayerClient {
+	t.Helper()
+	agent := remote.NewAgent(io.Discard, player.Options{
+		Prompt:  cli.Prompt{Path: "~", Shell: cli.Bash},
+		NoClear: true,
+	})
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	server := remote.NewGRPCServer(agent)
+	go server.Serve(lis)
+
+	conn, err := grpc.NewClient(lis.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+	t.Cleanup(func() {
+		conn.Close()
+		server.Stop()
+		agent.Stop()
+	})
+	return pb.NewPlayerClient(conn)
+}
+
+// TestGRPCWithoutScript tests that playback commands fail
+// with a precondition error until a script has been loaded
+func TestGRPCWithoutScript(t *testing.T) {
+	client := newTestGRPCAgent(t)
+
+	_, err := client.Play(context.Background(), &pb.PlayRequest{})
+	if status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("expected %v, but got %v", codes.FailedPrecondition, err)
+	}
+}
+
+// TestGRPCSubscribe tests that the events of a loaded
+// script are streamed to subscribers
+func TestGRPCSubscribe(t *testing.T) {
+	client := newTestGRPCAgent(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if _, err := client.LoadScript(ctx, &pb.LoadScriptRequest{Script: "echo first"}); err != nil {
+		t.Fatalf("expected no error, but got %v", err)
+	}
+
+	stream, err := client.Subscribe(ctx, &pb.SubscribeRequest{})
+	if err != nil {
+		t.Fatalf("expected no error, but got %v", err)
+	}
+
+	// The stream starts with the current state, which also
+	// means that the subscription has been registered
+	first, err := stream.Recv()
+	if err != nil {
+		t.Fatalf("expected no error, but got %v", err)
+	}
+	if first.GetType() != "state-changed" {
+		t.Errorf("expected a state-changed event, but got %v", first)
+	}
+
+	var events []*pb.Event
+	if _, err := client.Play(ctx, &pb.PlayRequest{}); err != nil {
+		t.Fatalf("expected no error, but got %v", err)
+	}
+
+	for {
+		e, err := stream.Recv()
+		if err != nil {
+			t.Fatalf("expected no error, but got %v", err)
+		}
+		events = append(events, e)
+		if e.GetType() == "state-changed" && e.GetState() == "finished" {
+			break
+		}
+	}
+
+	var started bool
+	for _, e := range events {
+		if e.GetType() == "step-started" && e.GetCommand() == "echo first" {
+			started = true
+		}
+	}
+	if !started {
+		t.Errorf("expected a step-started event for the command, but got %v", events)
+	}
+}
@@ -0,0 +1,175 @@
+/*
+Copyright © 2023 Mikael Schultz <bitcanon@proton.me>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package remote
+
+import (
+	"context"
+	"log/slog"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// maxClients is the number of clients tracked by a limiter. The idle
+// ones are forgotten first, then the ones seen last the longest ago
+const maxClients = 1024
+
+// Limiter limits the rate of the requests of each client (by IP
+// address) with a token bucket, so a misbehaving controller cannot
+// flood the agent with scripts and playback commands
+type Limiter struct {
+	rate  float64
+	burst float64
+
+	mu      sync.Mutex
+	buckets map[string]*bucket
+}
+
+// bucket holds the tokens left to a client
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// NewLimiter creates a limiter allowing each client rate requests
+// per second on average, and bursts of up to burst requests
+func NewLimiter(rate float64, burst int) *Limiter {
+	if burst < 1 {
+		burst = 1
+	}
+	return &Limiter{rate: rate, burst: float64(burst), buckets: make(map[string]*bucket)}
+}
+
+// Allow reports whether a request of the client is allowed now,
+// and takes a token from its bucket if so
+func (l *Limiter) Allow(client string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	b, ok := l.buckets[client]
+	if !ok {
+		if len(l.buckets) >= maxClients {
+			l.forget(now)
+		}
+		b = &bucket{tokens: l.burst, last: now}
+		l.buckets[client] = b
+	}
+
+	// Refill the bucket for the time elapsed since the last request
+	b.tokens = min(l.burst, b.tokens+now.Sub(b.last).Seconds()*l.rate)
+	b.last = now
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// forget removes the clients whose bucket is full again, or else the
+// client seen last the longest ago, so that the clients tracked stay
+// under maxClients even when every bucket is in use
+func (l *Limiter) forget(now time.Time) {
+	var oldest string
+	for client, b := range l.buckets {
+		if b.tokens+now.Sub(b.last).Seconds()*l.rate >= l.burst {
+			delete(l.buckets, client)
+			continue
+		}
+		if oldest == "" || b.last.Before(l.buckets[oldest].last) {
+			oldest = client
+		}
+	}
+	if len(l.buckets) >= maxClients {
+		delete(l.buckets, oldest)
+	}
+}
+
+// Handler returns a handler refusing the requests over the limit
+// with 429 Too Many Requests, and passing the others to h
+func (l *Limiter) Handler(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		client := clientHost(r.RemoteAddr)
+		if !l.Allow(client) {
+			slog.Warn("rate limit exceeded", "client", client, "path", r.URL.Path)
+			w.Header().Set("Retry-After", strconv.Itoa(l.retryAfter()))
+			http.Error(w, "too many requests", http.StatusTooManyRequests)
+			return
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
+// ServerOptions returns the options of a gRPC server refusing the
+// calls over the limit with ResourceExhausted
+func (l *Limiter) ServerOptions() []grpc.ServerOption {
+	unary := func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if err := l.check(ctx); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+	stream := func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := l.check(ss.Context()); err != nil {
+			return err
+		}
+		return handler(srv, ss)
+	}
+	return []grpc.ServerOption{grpc.ChainUnaryInterceptor(unary), grpc.ChainStreamInterceptor(stream)}
+}
+
+// check returns an error if the client of the gRPC call is over the limit
+func (l *Limiter) check(ctx context.Context) error {
+	var client string
+	if p, ok := peer.FromContext(ctx); ok {
+		client = clientHost(p.Addr.String())
+	}
+	if !l.Allow(client) {
+		slog.Warn("rate limit exceeded", "client", client)
+		return status.Error(codes.ResourceExhausted, "too many requests")
+	}
+	return nil
+}
+
+// retryAfter returns the seconds until a token is available again
+func (l *Limiter) retryAfter() int {
+	if l.rate <= 0 {
+		return 60
+	}
+	return max(1, int(1/l.rate+0.5))
+}
+
+// clientHost returns the host of a remote address, so the clients
+// are limited by IP address and not by connection
+func clientHost(addr string) string {
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		return host
+	}
+	return addr
+}
@@ -0,0 +1,74 @@
+package remote_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bitcanon/autotyper/remote"
+)
+
+// TestLimiter tests that each client gets its own burst of requests
+func TestLimiter(t *testing.T) {
+	limiter := remote.NewLimiter(0.001, 2)
+
+	tests := []struct {
+		client   string
+		expected bool
+	}{
+		{client: "10.0.0.1", expected: true},
+		{client: "10.0.0.1", expected: true},
+		{client: "10.0.0.1", expected: false},
+		{client: "10.0.0.2", expected: true},
+		{client: "10.0.0.1", expected: false},
+	}
+
+	for i, test := range tests {
+		if allowed := limiter.Allow(test.client); allowed != test.expected {
+			t.Errorf("request %d of %s: expected %v, but got %v", i+1, test.client, test.expected, allowed)
+		}
+	}
+}
+
+// TestLimiterHandler tests that requests over the limit are
+// refused with 429 Too Many Requests and a Retry-After header
+func TestLimiterHandler(t *testing.T) {
+	limiter := remote.NewLimiter(0.5, 1)
+	handler := limiter.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	expected := []int{http.StatusOK, http.StatusTooManyRequests}
+	for i, code := range expected {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/v1/play", nil))
+		if rec.Code != code {
+			t.Errorf("request %d: expected %d, but got %d", i+1, code, rec.Code)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/v1/play", nil))
+	if rec.Header().Get("Retry-After") != "2" {
+		t.Errorf("expected Retry-After 2, but got %q", rec.Header().Get("Retry-After"))
+	}
+}
+
+// TestLimiterClients tests that the limiter tracks at most 1024
+// clients, forgetting the client seen last the longest ago when
+// none of the buckets is full again
+func TestLimiterClients(t *testing.T) {
+	limiter := remote.NewLimiter(0.001, 1)
+	limiter.Allow("oldest")
+	for i := 0; i < 1022; i++ {
+		limiter.Allow(fmt.Sprintf("10.0.%d.%d", i/256, i%256))
+	}
+	limiter.Allow("recent")
+	limiter.Allow("new")
+
+	if !limiter.Allow("oldest") {
+		t.Errorf("expected the oldest client to be forgotten")
+	}
+	if limiter.Allow("recent") {
+		t.Errorf("expected the recent client to be limited still")
+	}
+}
@@ -0,0 +1,169 @@
+/*
+Copyright © 2023 Mikael Schultz <bitcanon@proton.me>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package remote
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/bitcanon/autotyper/player"
+)
+
+// durationBuckets are the upper bounds in seconds of the buckets
+// of the histogram of the command durations
+var durationBuckets = []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60}
+
+// Define the results of the playbacks counted by the metrics
+const (
+	resultFinished = "finished"
+	resultStopped  = "stopped"
+	resultFailed   = "failed"
+)
+
+// Metrics counts the playbacks of an agent, the steps played and the
+// durations of the commands, for monitoring with Prometheus
+type Metrics struct {
+	mu      sync.Mutex
+	started uint64
+	results map[string]uint64
+	steps   uint64
+
+	// The histogram of the command durations: the number of
+	// commands in each bucket, their total duration and count
+	buckets  []uint64
+	sum      float64
+	commands uint64
+}
+
+// newMetrics returns metrics with nothing counted yet
+func newMetrics() *Metrics {
+	return &Metrics{
+		results: map[string]uint64{resultFinished: 0, resultStopped: 0, resultFailed: 0},
+		buckets: make([]uint64, len(durationBuckets)),
+	}
+}
+
+// runStarted counts a playback started
+func (m *Metrics) runStarted() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.started++
+}
+
+// runEnded counts a playback ended with the result
+func (m *Metrics) runEnded(result string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.results[result]++
+}
+
+// observe counts the steps played, and the time spent executing
+// their commands. Steps without a command are
+// named after their directive in the report (e.g. "#think 500")
+func (m *Metrics) observe(steps []player.StepTiming) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, step := range steps {
+		m.steps++
+		if strings.HasPrefix(step.Command, "#") {
+			continue
+		}
+		seconds := step.Execution.Seconds()
+		for i, le := range durationBuckets {
+			if seconds <= le {
+				m.buckets[i]++
+			}
+		}
+		m.sum += seconds
+		m.commands++
+	}
+}
+
+// watch returns the event handler counting the steps of the playback
+// as they are played. Call the returned function once the playback
+// has returned, to count its last step
+func (m *Metrics) watch(p *player.Player) (func(player.Event), func()) {
+	var mu sync.Mutex
+	seen := 0
+	update := func() {
+		mu.Lock()
+		defer mu.Unlock()
+		steps := p.Report().Steps
+		m.observe(steps[seen:])
+		seen = len(steps)
+	}
+	return func(player.Event) { update() }, update
+}
+
+// WriteTo writes the metrics in the text format of Prometheus
+func (m *Metrics) WriteTo(w io.Writer) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var b strings.Builder
+	metric := func(name, kind, help string) {
+		fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+	}
+	metric("autotyper_runs_started_total", "counter", "Playbacks started by the agent.")
+	fmt.Fprintf(&b, "autotyper_runs_started_total %d\n", m.started)
+	metric("autotyper_runs_total", "counter", "Playbacks ended, by result: finished, stopped or failed.")
+	for _, result := range []string{resultFinished, resultStopped, resultFailed} {
+		fmt.Fprintf(&b, "autotyper_runs_total{result=%q} %d\n", result, m.results[result])
+	}
+	metric("autotyper_steps_total", "counter", "Steps played.")
+	fmt.Fprintf(&b, "autotyper_steps_total %d\n", m.steps)
+	metric("autotyper_command_duration_seconds", "histogram", "Time spent executing the commands.")
+	for i, le := range durationBuckets {
+		fmt.Fprintf(&b, "autotyper_command_duration_seconds_bucket{le=%q} %d\n", strconv.FormatFloat(le, 'g', -1, 64), m.buckets[i])
+	}
+	fmt.Fprintf(&b, "autotyper_command_duration_seconds_bucket{le=\"+Inf\"} %d\n", m.commands)
+	fmt.Fprintf(&b, "autotyper_command_duration_seconds_sum %s\n", strconv.FormatFloat(m.sum, 'g', -1, 64))
+	fmt.Fprintf(&b, "autotyper_command_duration_seconds_count %d\n", m.commands)
+
+	n, err := io.WriteString(w, b.String())
+	return int64(n), err
+}
+
+// ServeHTTP serves the metrics to Prometheus
+func (m *Metrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	m.WriteTo(w)
+}
+
+// result returns the result of a playback that returned the error:
+// stopped if it was cancelled, for example by loading another script
+func result(err error) string {
+	switch {
+	case errors.Is(err, context.Canceled):
+		return resultStopped
+	case err != nil:
+		return resultFailed
+	default:
+		return resultFinished
+	}
+}
@@ -0,0 +1,350 @@
+// Protocol for controlling an autotyper agent over gRPC.
+
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.4.0
+// - protoc             (unknown)
+// source: player.proto
+
+package pb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.62.0 or later.
+const _ = grpc.SupportPackageIsVersion8
+
+const (
+	Player_LoadScript_FullMethodName = "/autotyper.v1.Player/LoadScript"
+	Player_Play_FullMethodName       = "/autotyper.v1.Player/Play"
+	Player_Pause_FullMethodName      = "/autotyper.v1.Player/Pause"
+	Player_Step_FullMethodName       = "/autotyper.v1.Player/Step"
+	Player_GetStatus_FullMethodName  = "/autotyper.v1.Player/GetStatus"
+	Player_Subscribe_FullMethodName  = "/autotyper.v1.Player/Subscribe"
+)
+
+// PlayerClient is the client API for Player service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// Player controls the playback on an agent
+type PlayerClient interface {
+	// LoadScript stops the current playback and loads a new script.
+	// The script waits at the first prompt until it is played or stepped
+	LoadScript(ctx context.Context, in *LoadScriptRequest, opts ...grpc.CallOption) (*Status, error)
+	// Play starts or resumes the playback
+	Play(ctx context.Context, in *PlayRequest, opts ...grpc.CallOption) (*Status, error)
+	// Pause pauses the playback before the next command
+	Pause(ctx context.Context, in *PauseRequest, opts ...grpc.CallOption) (*Status, error)
+	// Step plays the next command only
+	Step(ctx context.Context, in *StepRequest, opts ...grpc.CallOption) (*Status, error)
+	// GetStatus returns the playback status
+	GetStatus(ctx context.Context, in *GetStatusRequest, opts ...grpc.CallOption) (*Status, error)
+	// Subscribe streams the playback events until the client disconnects.
+	// The stream starts with the state of the loaded script, if any
+	Subscribe(ctx context.Context, in *SubscribeRequest, opts ...grpc.CallOption) (Player_SubscribeClient, error)
+}
+
+type playerClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewPlayerClient(cc grpc.ClientConnInterface) PlayerClient {
+	return &playerClient{cc}
+}
+
+func (c *playerClient) LoadScript(ctx context.Context, in *LoadScriptRequest, opts ...grpc.CallOption) (*Status, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Status)
+	err := c.cc.Invoke(ctx, Player_LoadScript_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *playerClient) Play(ctx context.Context, in *PlayRequest, opts ...grpc.CallOption) (*Status, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Status)
+	err := c.cc.Invoke(ctx, Player_Play_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *playerClient) Pause(ctx context.Context, in *PauseRequest, opts ...grpc.CallOption) (*Status, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Status)
+	err := c.cc.Invoke(ctx, Player_Pause_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *playerClient) Step(ctx context.Context, in *StepRequest, opts ...grpc.CallOption) (*Status, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Status)
+	err := c.cc.Invoke(ctx, Player_Step_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *playerClient) GetStatus(ctx context.Context, in *GetStatusRequest, opts ...grpc.CallOption) (*Status, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Status)
+	err := c.cc.Invoke(ctx, Player_GetStatus_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *playerClient) Subscribe(ctx context.Context, in *SubscribeRequest, opts ...grpc.CallOption) (Player_SubscribeClient, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Player_ServiceDesc.Streams[0], Player_Subscribe_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &playerSubscribeClient{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Player_SubscribeClient interface {
+	Recv() (*Event, error)
+	grpc.ClientStream
+}
+
+type playerSubscribeClient struct {
+	grpc.ClientStream
+}
+
+func (x *playerSubscribeClient) Recv() (*Event, error) {
+	m := new(Event)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// PlayerServer is the server API for Player service.
+// All implementations must embed UnimplementedPlayerServer
+// for forward compatibility
+//
+// Player controls the playback on an agent
+type PlayerServer interface {
+	// LoadScript stops the current playback and loads a new script.
+	// The script waits at the first prompt until it is played or stepped
+	LoadScript(context.Context, *LoadScriptRequest) (*Status, error)
+	// Play starts or resumes the playback
+	Play(context.Context, *PlayRequest) (*Status, error)
+	// Pause pauses the playback before the next command
+	Pause(context.Context, *PauseRequest) (*Status, error)
+	// Step plays the next command only
+	Step(context.Context, *StepRequest) (*Status, error)
+	// GetStatus returns the playback status
+	GetStatus(context.Context, *GetStatusRequest) (*Status, error)
+	// Subscribe streams the playback events until the client disconnects.
+	// The stream starts with the state of the loaded script, if any
+	Subscribe(*SubscribeRequest, Player_SubscribeServer) error
+	mustEmbedUnimplementedPlayerServer()
+}
+
+// UnimplementedPlayerServer must be embedded to have forward compatible implementations.
+type UnimplementedPlayerServer struct {
+}
+
+func (UnimplementedPlayerServer) LoadScript(context.Context, *LoadScriptRequest) (*Status, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method LoadScript not implemented")
+}
+func (UnimplementedPlayerServer) Play(context.Context, *PlayRequest) (*Status, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Play not implemented")
+}
+func (UnimplementedPlayerServer) Pause(context.Context, *PauseRequest) (*Status, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Pause not implemented")
+}
+func (UnimplementedPlayerServer) Step(context.Context, *StepRequest) (*Status, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Step not implemented")
+}
+func (UnimplementedPlayerServer) GetStatus(context.Context, *GetStatusRequest) (*Status, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetStatus not implemented")
+}
+func (UnimplementedPlayerServer) Subscribe(*SubscribeRequest, Player_SubscribeServer) error {
+	return status.Errorf(codes.Unimplemented, "method Subscribe not implemented")
+}
+func (UnimplementedPlayerServer) mustEmbedUnimplementedPlayerServer() {}
+
+// UnsafePlayerServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to PlayerServer will
+// result in compilation errors.
+type UnsafePlayerServer interface {
+	mustEmbedUnimplementedPlayerServer()
+}
+
+func RegisterPlayerServer(s grpc.ServiceRegistrar, srv PlayerServer) {
+	s.RegisterService(&Player_ServiceDesc, srv)
+}
+
+func _Player_LoadScript_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LoadScriptRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PlayerServer).LoadScript(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Player_LoadScript_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PlayerServer).LoadScript(ctx, req.(*LoadScriptRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Player_Play_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PlayRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PlayerServer).Play(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Player_Play_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PlayerServer).Play(ctx, req.(*PlayRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Player_Pause_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PauseRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PlayerServer).Pause(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Player_Pause_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PlayerServer).Pause(ctx, req.(*PauseRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Player_Step_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(StepRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PlayerServer).Step(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Player_Step_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PlayerServer).Step(ctx, req.(*StepRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Player_GetStatus_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetStatusRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PlayerServer).GetStatus(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Player_GetStatus_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PlayerServer).GetStatus(ctx, req.(*GetStatusRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Player_Subscribe_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(SubscribeRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(PlayerServer).Subscribe(m, &playerSubscribeServer{ServerStream: stream})
+}
+
+type Player_SubscribeServer interface {
+	Send(*Event) error
+	grpc.ServerStream
+}
+
+type playerSubscribeServer struct {
+	grpc.ServerStream
+}
+
+func (x *playerSubscribeServer) Send(m *Event) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+// Player_ServiceDesc is the grpc.ServiceDesc for Player service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Player_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "autotyper.v1.Player",
+	HandlerType: (*PlayerServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "LoadScript",
+			Handler:    _Player_LoadScript_Handler,
+		},
+		{
+			MethodName: "Play",
+			Handler:    _Player_Play_Handler,
+		},
+		{
+			MethodName: "Pause",
+			Handler:    _Player_Pause_Handler,
+		},
+		{
+			MethodName: "Step",
+			Handler:    _Player_Step_Handler,
+		},
+		{
+			MethodName: "GetStatus",
+			Handler:    _Player_GetStatus_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Subscribe",
+			Handler:       _Player_Subscribe_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "player.proto",
+}
@@ -0,0 +1,196 @@
+package remote_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bitcanon/autotyper/cli"
+	"github.com/bitcanon/autotyper/player"
+	"github.com/bitcanon/autotyper/remote"
+)
+
+// newTestAgent starts an agent behind a test HTTP server
+// and returns a controller connected to it
+func newTestAgent(t *testing.T) *remote.Controller {
+	t.Helper()
+	agent := remote.NewAgent(io.Discard, player.Options{
+		Prompt:  cli.Prompt{Path: "~", Shell: cli.Bash},
+		NoClear: true,
+	})
+	server := httptest.NewServer(agent.Handler())
+	t.Cleanup(func() {
+		server.Close()
+		agent.Stop()
+	})
+	return remote.NewController(server.URL)
+}
+
+// TestControllerWithoutScript tests that playback commands
+// fail until a script has been loaded
+func TestControllerWithoutScript(t *testing.T) {
+	c := newTestAgent(t)
+
+	if _, err := c.Play(); err == nil {
+		t.Errorf("expected error, but got nil")
+	}
+	if _, err := c.Status(); err == nil {
+		t.Errorf("expected error, but got nil")
+	}
+}
+
+// TestControllerLoadAndStep tests that a loaded script is paused
+// until the controller steps or plays it
+func TestControllerLoadAndStep(t *testing.T) {
+	c := newTestAgent(t)
+
+	status, err := c.Load("echo first\necho second")
+	if err != nil {
+		t.Fatalf("expected no error, but got %v", err)
+	}
+	if status.Total != 2 {
+		t.Errorf("expected 2 commands, but got %d", status.Total)
+	}
+
+	if _, err := c.Step(); err != nil {
+		t.Fatalf("expected no error, but got %v", err)
+	}
+	waitForStatus(t, c, remote.Status{State: "paused", Step: 1, Total: 2})
+
+	if _, err := c.Play(); err != nil {
+		t.Fatalf("expected no error, but got %v", err)
+	}
+	waitForStatus(t, c, remote.Status{State: "finished", Step: 2, Total: 2})
+}
+
+// TestAgentScriptSize tests that the scripts larger than the
+// limit are refused before they are read entirely
+func TestAgentScriptSize(t *testing.T) {
+	agent := remote.NewAgent(io.Discard, player.Options{NoClear: true})
+	server := httptest.NewServer(agent.Handler())
+	defer server.Close()
+	defer agent.Stop()
+
+	body := strings.NewReader(strings.Repeat("echo too large\n", 5<<20/15))
+	resp, err := http.Post(server.URL+"/v1/script", "text/plain", body)
+	if err != nil {
+		t.Fatalf("expected no error, but got %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusRequestEntityTooLarge {
+		t.Errorf("expected status %d, but got %d", http.StatusRequestEntityTooLarge, resp.StatusCode)
+	}
+}
+
+// TestAgentMetrics tests that the playbacks, the steps and the
+// commands are counted in the metrics served to Prometheus
+func TestAgentMetrics(t *testing.T) {
+	c := newTestAgent(t)
+	if _, err := c.Load("echo first\n#think 10\necho second"); err != nil {
+		t.Fatalf("expected no error, but got %v", err)
+	}
+	if _, err := c.Play(); err != nil {
+		t.Fatalf("expected no error, but got %v", err)
+	}
+	waitForStatus(t, c, remote.Status{State: "finished", Step: 3, Total: 3})
+	if _, err := c.Load("echo third"); err != nil {
+		t.Fatalf("expected no error, but got %v", err)
+	}
+	if _, err := c.Load("echo fourth"); err != nil {
+		t.Fatalf("expected no error, but got %v", err)
+	}
+
+	expected := []string{
+		"# TYPE autotyper_runs_started_total counter\nautotyper_runs_started_total 3\n",
+		`autotyper_runs_total{result="finished"} 1`,
+		`autotyper_runs_total{result="stopped"} 1`,
+		`autotyper_runs_total{result="failed"} 0`,
+		"autotyper_steps_total 3\n",
+		"# TYPE autotyper_command_duration_seconds histogram\n",
+		`autotyper_command_duration_seconds_bucket{le="+Inf"} 2`,
+		"autotyper_command_duration_seconds_count 2\n",
+	}
+	var metrics string
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		resp, err := http.Get(c.URL + "/metrics")
+		if err != nil {
+			t.Fatalf("expected no error, but got %v", err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if metrics = string(body); containsAll(metrics, expected) {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Errorf("expected the metrics to contain %q, but got:\n%s", expected, metrics)
+}
+
+// TestAgentQueue tests that scripts loaded during a playback wait
+// for it to end, and are refused once the queue is full
+func TestAgentQueue(t *testing.T) {
+	agent := remote.NewAgent(io.Discard, player.Options{
+		Prompt:  cli.Prompt{Path: "~", Shell: cli.Bash},
+		NoClear: true,
+	})
+	agent.Queue = 1
+	server := httptest.NewServer(agent.Handler())
+	t.Cleanup(func() {
+		server.Close()
+		agent.Stop()
+	})
+	c := remote.NewController(server.URL)
+
+	if _, err := c.Load("echo first"); err != nil {
+		t.Fatalf("expected no error, but got %v", err)
+	}
+	status, err := c.Load("echo second\necho third")
+	if err != nil {
+		t.Fatalf("expected no error, but got %v", err)
+	}
+	if status.Total != 1 || status.Queued != 1 {
+		t.Errorf("expected the first script with 1 queued, but got %+v", status)
+	}
+	if _, err := c.Load("echo fourth"); err == nil || !strings.Contains(err.Error(), "busy") {
+		t.Errorf("expected busy error, but got %v", err)
+	}
+
+	// The queued script is loaded once the first one has finished
+	if _, err := c.Play(); err != nil {
+		t.Fatalf("expected no error, but got %v", err)
+	}
+	waitForStatus(t, c, remote.Status{State: "paused", Step: 0, Total: 2})
+}
+
+// containsAll reports whether s contains all the substrings
+func containsAll(s string, substrings []string) bool {
+	for _, sub := range substrings {
+		if !strings.Contains(s, sub) {
+			return false
+		}
+	}
+	return true
+}
+
+// waitForStatus polls the agent until it reports the expected status
+func waitForStatus(t *testing.T, c *remote.Controller, expected remote.Status) {
+	t.Helper()
+	var status remote.Status
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		var err error
+		status, err = c.Status()
+		if err != nil {
+			t.Fatalf("expected no error, but got %v", err)
+		}
+		if status == expected {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("expected status %+v, but got %+v", expected, status)
+}
@@ -0,0 +1,47 @@
+/*
+Copyright © 2023 Mikael Schultz <bitcanon@proton.me>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package script
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// validAudio checks that the file of a wait-audio step
+// is an audio clip of a supported format
+func validAudio(filename string) error {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".mp3", ".wav":
+		return nil
+	}
+	return fmt.Errorf("unsupported audio file %q (mp3 or wav)", filename)
+}
+
+// AudioFile returns the path of the audio clip of a wait-audio
+// step, relative to the directory of the scenario
+func (s *Scenario) AudioFile(name string) string {
+	if filepath.IsAbs(name) || s.Dir == "" {
+		return name
+	}
+	return filepath.Join(s.Dir, name)
+}
@@ -0,0 +1,102 @@
+package script_test
+
+import (
+	"testing"
+
+	"github.com/bitcanon/autotyper/script"
+)
+
+// TestParseJSON tests parsing of valid and invalid JSON scenarios
+func TestParseJSON(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		wantErr bool
+	}{
+		{
+			name: "ValidScenario",
+			input: `{
+				"prompt": {"shell": "bash", "path": "/tmp"},
+				"timing": {"char-delay": 25},
+				"steps": [
+					{"command": "ls", "caption": "List the files"},
+					{"command": "pwd", "timing": {"post-delay": 0}}
+				]
+			}`,
+		},
+		{
+			name:    "UnknownField",
+			input:   `{"steps": [{"command": "ls", "comand": "pwd"}]}`,
+			wantErr: true,
+		},
+		{
+			name:    "UnknownShell",
+			input:   `{"prompt": {"shell": "zsh"}, "steps": [{"command": "ls"}]}`,
+			wantErr: true,
+		},
+		{
+			name:    "MissingCommand",
+			input:   `{"steps": [{"caption": "Nothing to type"}]}`,
+			wantErr: true,
+		},
+		{
+			name:    "NegativeDelay",
+			input:   `{"steps": [{"command": "ls", "timing": {"pre-delay": -1}}]}`,
+			wantErr: true,
+		},
+		{
+			name:  "ValidLogin",
+			input: `{"login": {"style": "ssh", "host": "stage", "banner": "Welcome"}, "steps": [{"command": "ls"}]}`,
+		},
+		{
+			name:    "UnknownLoginStyle",
+			input:   `{"login": {"style": "telnet"}, "steps": [{"command": "ls"}]}`,
+			wantErr: true,
+		},
+		{
+			name:  "SimulatedCommand",
+			input: `{"steps": [{"simulate": "ping -c 2 dns.google", "params": {"latency": 12.5, "loss": "25"}}]}`,
+		},
+		{
+			name:    "UnknownSimulator",
+			input:   `{"steps": [{"simulate": "traceroute dns.google"}]}`,
+			wantErr: true,
+		},
+		{
+			name:    "InvalidOutputTemplate",
+			input:   `{"steps": [{"command": "date", "output": "{{now"}]}`,
+			wantErr: true,
+		},
+		{
+			name:  "MoreBreaks",
+			input: `{"steps": [{"command": "show running-config", "output": "hostname R1\n", "more": {"lines": 20, "delay": 800}}]}`,
+		},
+		{
+			name:    "MoreWithoutOutput",
+			input:   `{"steps": [{"command": "show running-config", "more": {"lines": 20}}]}`,
+			wantErr: true,
+		},
+		{
+			name:    "NegativeMoreLines",
+			input:   `{"steps": [{"command": "show running-config", "output": "hostname R1\n", "more": {"lines": -1}}]}`,
+			wantErr: true,
+		},
+		{
+			name:    "InvalidJSON",
+			input:   `{"steps": [`,
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := script.ParseJSON([]byte(test.input))
+			if test.wantErr && err == nil {
+				t.Errorf("expected error, but got nil")
+			}
+			if !test.wantErr && err != nil {
+				t.Errorf("expected no error, but got %v", err)
+			}
+		})
+	}
+}
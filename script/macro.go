@@ -0,0 +1,257 @@
+/*
+Copyright © 2023 Mikael Schultz <bitcanon@proton.me>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package script
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Macro is a named group of steps, played wherever a step uses it
+// (e.g. "deploy(app=web)"). The references to the parameters (e.g.
+// "${app}") in the steps are replaced by the values given by the step
+// using the macro
+type Macro struct {
+	Params []string `json:"params,omitempty" toml:"params,omitempty" yaml:"params,omitempty"`
+	Steps  []Step   `json:"steps" toml:"steps" yaml:"steps"`
+}
+
+// parseUse parses a use of a macro: its name, and the values of its
+// parameters in parentheses, if any (e.g. "deploy(app=web, env=prod)")
+func parseUse(use string) (string, map[string]string, error) {
+	name, rest, hasArgs := strings.Cut(strings.TrimSpace(use), "(")
+	name = strings.TrimSpace(name)
+	if !validStepName(name) {
+		return "", nil, fmt.Errorf("invalid macro name %q", name)
+	}
+
+	args := map[string]string{}
+	if !hasArgs {
+		return name, args, nil
+	}
+	rest, ok := strings.CutSuffix(strings.TrimSpace(rest), ")")
+	if !ok {
+		return "", nil, fmt.Errorf("invalid use %q, expected <macro>(<param>=<value>, ...)", use)
+	}
+	if strings.TrimSpace(rest) == "" {
+		return name, args, nil
+	}
+	for _, arg := range strings.Split(rest, ",") {
+		param, value, ok := strings.Cut(arg, "=")
+		param = strings.TrimSpace(param)
+		if !ok || !ValidVariableName(param) {
+			return "", nil, fmt.Errorf("invalid argument %q of macro %s, expected <param>=<value>", strings.TrimSpace(arg), name)
+		}
+		if _, ok := args[param]; ok {
+			return "", nil, fmt.Errorf("parameter %s of macro %s given twice", param, name)
+		}
+		args[param] = strings.TrimSpace(value)
+	}
+	return name, args, nil
+}
+
+// validateMacros checks the names and the parameters of the macros
+func validateMacros(macros map[string]Macro) error {
+	for name, m := range macros {
+		if !validStepName(name) {
+			return fmt.Errorf("invalid macro name %q", name)
+		}
+		if len(m.Steps) == 0 {
+			return fmt.Errorf("macro %s has no steps", name)
+		}
+		seen := map[string]bool{}
+		for _, param := range m.Params {
+			if !ValidVariableName(param) {
+				return fmt.Errorf("macro %s: invalid parameter name %q", name, param)
+			}
+			if seen[param] {
+				return fmt.Errorf("macro %s: parameter %s declared twice", name, param)
+			}
+			seen[param] = true
+		}
+	}
+	return nil
+}
+
+// expandMacros returns the steps with the steps using a macro replaced
+// by the steps of the macro, with the values of the parameters. Macros
+// may use other macros, and the steps of repeat blocks are expanded
+func expandMacros(steps []Step, macros map[string]Macro) ([]Step, error) {
+	if err := validateMacros(macros); err != nil {
+		return nil, err
+	}
+	return (&macroExpander{macros: macros}).expand(steps)
+}
+
+// macroExpander expands the macros, keeping track of the macros
+// being expanded so that recursive macros can be detected
+type macroExpander struct {
+	macros map[string]Macro
+	stack  []string
+}
+
+// expand expands the macros used by the steps, see expandMacros
+func (e *macroExpander) expand(steps []Step) ([]Step, error) {
+	var expanded []Step
+	for i, step := range steps {
+		if len(step.Steps) > 0 {
+			block, err := e.expand(step.Steps)
+			if err != nil {
+				return nil, fmt.Errorf("step %d: %w", i+1, err)
+			}
+			step.Steps = block
+		}
+		if step.Use == "" {
+			expanded = append(expanded, step)
+			continue
+		}
+
+		if !reflect.DeepEqual(Step{Name: step.Name, Tags: step.Tags, Requires: step.Requires, OS: step.OS, Use: step.Use}, step) {
+			return nil, fmt.Errorf("step %d: a step using a macro cannot run a command, only the steps of the macro", i+1)
+		}
+		macro, err := e.use(step.Use)
+		if err != nil {
+			return nil, fmt.Errorf("step %d: %w", i+1, err)
+		}
+
+		// The name is given to the first step of the macro, and the
+		// tags, the requirements and the systems to all of them
+		if step.Name != "" {
+			macro[0].Name = step.Name
+		}
+		for j := range macro {
+			macro[j].Tags = append(append([]string(nil), macro[j].Tags...), step.Tags...)
+			macro[j].Requires = append(append([]string(nil), macro[j].Requires...), step.Requires...)
+			if len(step.OS) > 0 {
+				macro[j].OS = step.OS
+			}
+		}
+		expanded = append(expanded, macro...)
+	}
+	return expanded, nil
+}
+
+// use returns the steps of the macro used, with the values of the
+// parameters, and the macros and the repeat blocks expanded
+func (e *macroExpander) use(use string) ([]Step, error) {
+	name, args, err := parseUse(use)
+	if err != nil {
+		return nil, err
+	}
+	m, ok := e.macros[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown macro %s", name)
+	}
+	for _, used := range e.stack {
+		if used == name {
+			return nil, fmt.Errorf("macro cycle: %s -> %s", strings.Join(e.stack, " -> "), name)
+		}
+	}
+
+	// Every parameter needs a value, and only the parameters
+	var missing []string
+	for _, param := range m.Params {
+		if _, ok := args[param]; !ok {
+			missing = append(missing, param)
+		}
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("macro %s: missing value for %s", name, strings.Join(missing, ", "))
+	}
+	if unknown := unknownParams(args, m.Params); unknown != "" {
+		return nil, fmt.Errorf("macro %s has no parameter %s", name, unknown)
+	}
+
+	steps := make([]Step, len(m.Steps))
+	for i, step := range m.Steps {
+		steps[i] = expandParams(step, args)
+	}
+	e.stack = append(e.stack, name)
+	defer func() { e.stack = e.stack[:len(e.stack)-1] }()
+	steps, err = e.expand(steps)
+	if err == nil {
+		steps, err = expandRepeats(steps)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("macro %s: %w", name, err)
+	}
+	return steps, nil
+}
+
+// unknownParams returns the sorted names of the arguments that are
+// not parameters, separated by commas
+func unknownParams(args map[string]string, params []string) string {
+	var unknown []string
+	for param := range args {
+		if !contains(params, param) {
+			unknown = append(unknown, param)
+		}
+	}
+	sort.Strings(unknown)
+	return strings.Join(unknown, ", ")
+}
+
+// contains reports whether the name is in the list
+func contains(names []string, name string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// expandParams returns a copy of the step with the references to the
+// parameters replaced by their values in the texts of the step and of
+// its nested steps. Other references are left for the variables
+func expandParams(step Step, args map[string]string) Step {
+	step.Command = Expand(step.Command, args)
+	step.Caption = Expand(step.Caption, args)
+	step.Output = Expand(step.Output, args)
+	step.Simulate = Expand(step.Simulate, args)
+	step.Lua = Expand(step.Lua, args)
+	step.Use = Expand(step.Use, args)
+
+	step.Input = append([]Input(nil), step.Input...)
+	for i := range step.Input {
+		step.Input[i].Text = Expand(step.Input[i].Text, args)
+	}
+	step.Expect = append([]Expect(nil), step.Expect...)
+	for i := range step.Expect {
+		step.Expect[i].Expect = Expand(step.Expect[i].Expect, args)
+		step.Expect[i].Send = Expand(step.Expect[i].Send, args)
+	}
+	step.Annotate = append([]Annotation(nil), step.Annotate...)
+	for i := range step.Annotate {
+		step.Annotate[i].Match = Expand(step.Annotate[i].Match, args)
+		step.Annotate[i].Text = Expand(step.Annotate[i].Text, args)
+	}
+
+	steps := step.Steps
+	step.Steps = nil
+	for _, s := range steps {
+		step.Steps = append(step.Steps, expandParams(s, args))
+	}
+	return step
+}
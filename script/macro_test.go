@@ -0,0 +1,97 @@
+package script_test
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/bitcanon/autotyper/script"
+)
+
+// TestMacros tests that the steps using macros are replaced by the
+// steps of the macros, with the values of the parameters
+func TestMacros(t *testing.T) {
+	dir := t.TempDir()
+	shared := "#macro deploy app env\n#repeat 2\nkubectl apply -f ${app}.yaml -n ${env}\n#end\n#use status(app=${app})\n#end\n#macro status app\nkubectl get pods -l app=${app} ${other}\n#end"
+	if err := os.WriteFile(filepath.Join(dir, "macros.txt"), []byte(shared), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "demo.txt"), []byte("#include macros.txt\n#name web\n#tags short\n#use deploy(app=web, env=prod)\n#use status( app = db )"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		parse func() (*script.Scenario, error)
+	}{
+		{name: "Text", parse: func() (*script.Scenario, error) {
+			return script.Load(filepath.Join(dir, "demo.txt"))
+		}},
+		{name: "YAML", parse: func() (*script.Scenario, error) {
+			return script.ParseYAML([]byte(`
+macros:
+  deploy:
+    params: [app, env]
+    steps:
+      - repeat: 2
+        steps:
+          - command: kubectl apply -f ${app}.yaml -n ${env}
+      - use: status(app=${app})
+  status:
+    params: [app]
+    steps:
+      - command: kubectl get pods -l app=${app} ${other}
+steps:
+  - use: deploy(app=web, env=prod)
+    name: web
+    tags: [short]
+  - use: status( app = db )
+`))
+		}},
+	}
+
+	expected := []script.Step{
+		{Name: "web", Tags: []string{"short"}, Command: "kubectl apply -f web.yaml -n prod", Iteration: 1},
+		{Tags: []string{"short"}, Command: "kubectl apply -f web.yaml -n prod", Iteration: 2},
+		{Tags: []string{"short"}, Command: "kubectl get pods -l app=web ${other}"},
+		{Command: "kubectl get pods -l app=db ${other}"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s, err := test.parse()
+			if err != nil {
+				t.Fatalf("expected no error, but got %v", err)
+			}
+			if !reflect.DeepEqual(s.Steps, expected) {
+				t.Errorf("expected %+v, but got %+v", expected, s.Steps)
+			}
+		})
+	}
+}
+
+// TestMacrosInvalid tests that invalid macros and invalid uses
+// of macros are refused
+func TestMacrosInvalid(t *testing.T) {
+	for _, input := range []string{
+		"#use missing",
+		"#macro greet name\necho ${name}\n#end\n#use greet",
+		"#macro greet name\necho ${name}\n#end\n#use greet(name=a, other=b)",
+		"#macro greet name\necho ${name}\n#end\n#use greet(name=a, name=b)",
+		"#macro greet name\necho ${name}\n#end\n#use greet(name=a",
+		"#macro greet\necho hi",
+		"#macro greet\n#end\n#use greet",
+		"#macro greet\necho hi\n#end\n#macro greet\necho hello\n#end",
+		"#repeat 2\n#macro greet\necho hi\n#end\n#end",
+		"#macro ping\n#use pong\n#end\n#macro pong\n#use ping\n#end\n#use ping",
+		"#macro greet 1name\necho hi\n#end\n#use greet",
+		"#macro\necho hi\n#end",
+	} {
+		if _, err := script.ParseText(input); err == nil {
+			t.Errorf("%q: expected error, but got nil", input)
+		}
+	}
+	if _, err := script.ParseJSON([]byte(`{"macros": {"greet": {"steps": [{"command": "echo hi"}]}}, "steps": [{"use": "greet", "command": "ls"}]}`)); err == nil {
+		t.Errorf("expected error for a step with both use and command, but got nil")
+	}
+}
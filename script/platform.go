@@ -0,0 +1,81 @@
+/*
+Copyright © 2023 Mikael Schultz <bitcanon@proton.me>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package script
+
+import (
+	"fmt"
+	"slices"
+)
+
+// operatingSystems are the names of the operating systems the steps
+// are played on, as reported by runtime.GOOS
+var operatingSystems = map[string]bool{
+	"aix":       true,
+	"android":   true,
+	"darwin":    true,
+	"dragonfly": true,
+	"freebsd":   true,
+	"illumos":   true,
+	"ios":       true,
+	"linux":     true,
+	"netbsd":    true,
+	"openbsd":   true,
+	"plan9":     true,
+	"solaris":   true,
+	"windows":   true,
+}
+
+// ForOS returns the step as played on the operating system (e.g.
+// "linux"), with the command of its variant for the system if there
+// is one. The second return value reports whether the step is played
+// on the system at all
+func (s Step) ForOS(goos string) (Step, bool) {
+	if len(s.OS) > 0 && !slices.Contains(s.OS, goos) {
+		return s, false
+	}
+	if command, ok := s.Variants[goos]; ok {
+		s.Command = command
+	}
+	return s, true
+}
+
+// validateOS checks the operating systems of the step and of the
+// variants of its command
+func (s Step) validateOS() error {
+	for _, goos := range s.OS {
+		if !operatingSystems[goos] {
+			return fmt.Errorf("unknown operating system %q (e.g. linux, darwin or windows)", goos)
+		}
+	}
+	if len(s.Variants) > 0 && s.Command == "" {
+		return fmt.Errorf("only a command can have variants")
+	}
+	for goos, command := range s.Variants {
+		if !operatingSystems[goos] {
+			return fmt.Errorf("unknown operating system %q of a variant (e.g. linux, darwin or windows)", goos)
+		}
+		if command == "" {
+			return fmt.Errorf("missing command of the variant for %s", goos)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package script_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bitcanon/autotyper/script"
+)
+
+// TestForOS tests that the steps are only played on their systems,
+// with the command of the variant for the system if there is one
+func TestForOS(t *testing.T) {
+	step := script.Step{Command: "ls -la", OS: []string{"linux", "windows"}, Variants: map[string]string{"windows": "dir"}}
+	tests := []struct {
+		goos    string
+		command string
+		played  bool
+	}{
+		{goos: "linux", command: "ls -la", played: true},
+		{goos: "windows", command: "dir", played: true},
+		{goos: "darwin", played: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.goos, func(t *testing.T) {
+			got, played := step.ForOS(test.goos)
+			if played != test.played {
+				t.Fatalf("expected played %v, but got %v", test.played, played)
+			}
+			if played && got.Command != test.command {
+				t.Errorf("expected command %q, but got %q", test.command, got.Command)
+			}
+		})
+	}
+
+	if _, played := (script.Step{Command: "ls"}).ForOS("plan9"); !played {
+		t.Errorf("expected a step without systems to be played on all of them")
+	}
+}
+
+// TestOSSteps tests that the os directive gives the systems to the
+// next step, and that unknown systems and variants are refused
+func TestOSSteps(t *testing.T) {
+	s, err := script.ParseText("#os windows\ndir\n#os linux, darwin\nls -la\necho done")
+	if err != nil {
+		t.Fatalf("expected no error, but got %v", err)
+	}
+	for i, expected := range []string{"windows", "linux darwin", ""} {
+		if got := strings.Join(s.Steps[i].OS, " "); got != expected {
+			t.Errorf("step %d: expected systems %q, but got %q", i+1, expected, got)
+		}
+	}
+
+	for _, input := range []string{"#os\nls", "#os beos\nls", "ls\n#os linux"} {
+		if _, err := script.ParseText(input); err == nil {
+			t.Errorf("%q: expected error, but got nil", input)
+		}
+	}
+	for _, input := range []string{
+		`{"steps": [{"command": "ls", "os": ["beos"]}]}`,
+		`{"steps": [{"command": "ls", "variants": {"beos": "dir"}}]}`,
+		`{"steps": [{"command": "ls", "variants": {"windows": ""}}]}`,
+		`{"steps": [{"think": 500, "variants": {"windows": "dir"}}]}`,
+	} {
+		if _, err := script.ParseJSON([]byte(input)); err == nil {
+			t.Errorf("%s: expected error, but got nil", input)
+		}
+	}
+}
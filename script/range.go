@@ -0,0 +1,111 @@
+/*
+Copyright © 2023 Mikael Schultz <bitcanon@proton.me>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package script
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// stepNamePattern matches a valid step name (e.g. "deploy-app"),
+// starting with a letter so that it is not taken for a number
+var stepNamePattern = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_-]*$`)
+
+// validStepName reports whether name can be used as a step name
+func validStepName(name string) bool {
+	return stepNamePattern.MatchString(name)
+}
+
+// validateStepNames checks that the names and the tags of the steps
+// are valid and that no two steps have the same name
+func validateStepNames(steps []Step) error {
+	names := make(map[string]int)
+	for i, step := range steps {
+		for _, tag := range step.Tags {
+			if !validStepName(tag) {
+				return fmt.Errorf("step %d: invalid tag %q", i+1, tag)
+			}
+		}
+		if step.Name == "" {
+			continue
+		}
+		if !validStepName(step.Name) {
+			return fmt.Errorf("step %d: invalid step name %q", i+1, step.Name)
+		}
+		if first, ok := names[step.Name]; ok {
+			return fmt.Errorf("step %d: step name %s is already used by step %d", i+1, step.Name, first+1)
+		}
+		names[step.Name] = i
+	}
+	return nil
+}
+
+// StepIndex returns the index, from 0, of the step referenced by
+// its number, from 1, or by its name
+func (s *Scenario) StepIndex(ref string) (int, error) {
+	if n, err := strconv.Atoi(ref); err == nil {
+		if n < 1 || n > len(s.Steps) {
+			return 0, fmt.Errorf("no step %d, the script has %d steps", n, len(s.Steps))
+		}
+		return n - 1, nil
+	}
+	for i, step := range s.Steps {
+		if step.Name != "" && step.Name == ref {
+			return i, nil
+		}
+	}
+	return 0, fmt.Errorf("no step named %q", ref)
+}
+
+// Range returns the indexes of the first step of the range of steps
+// and of the step after its last one. The range is "first..last",
+// both included and referenced by their number or name, with the
+// first or the last step of the scenario if omitted (e.g. "4..9",
+// "deploy.." or "..cleanup"), or a single step
+func (s *Scenario) Range(expr string) (int, int, error) {
+	first, last, isRange := strings.Cut(expr, "..")
+	if !isRange {
+		last = first
+	}
+
+	start, end := 0, len(s.Steps)
+	if first = strings.TrimSpace(first); first != "" {
+		i, err := s.StepIndex(first)
+		if err != nil {
+			return 0, 0, err
+		}
+		start = i
+	}
+	if last = strings.TrimSpace(last); last != "" {
+		i, err := s.StepIndex(last)
+		if err != nil {
+			return 0, 0, err
+		}
+		end = i + 1
+	}
+	if start >= end {
+		return 0, 0, fmt.Errorf("invalid range %q, the first step is after the last one", expr)
+	}
+	return start, end, nil
+}
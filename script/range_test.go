@@ -0,0 +1,90 @@
+package script_test
+
+import (
+	"testing"
+
+	"github.com/bitcanon/autotyper/script"
+)
+
+// TestRange tests that ranges of steps are resolved by the numbers and
+// the names of the steps, and that invalid ranges are refused
+func TestRange(t *testing.T) {
+	s, err := script.ParseText("#name setup\necho one\necho two\n#name deploy\necho three\necho four\n#name cleanup\necho five")
+	if err != nil {
+		t.Fatalf("expected no error, but got %v", err)
+	}
+
+	tests := []struct {
+		expr       string
+		start, end int
+		invalid    bool
+	}{
+		{expr: "2..4", start: 1, end: 4},
+		{expr: "deploy..", start: 2, end: 5},
+		{expr: "..deploy", start: 0, end: 3},
+		{expr: "setup..cleanup", start: 0, end: 5},
+		{expr: "4", start: 3, end: 4},
+		{expr: "cleanup", start: 4, end: 5},
+		{expr: "4..2", invalid: true},
+		{expr: "0..2", invalid: true},
+		{expr: "2..6", invalid: true},
+		{expr: "missing..", invalid: true},
+	}
+	for _, test := range tests {
+		start, end, err := s.Range(test.expr)
+		if test.invalid {
+			if err == nil {
+				t.Errorf("%s: expected error, but got %d..%d", test.expr, start, end)
+			}
+			continue
+		}
+		if err != nil || start != test.start || end != test.end {
+			t.Errorf("%s: expected %d..%d, but got %d..%d (%v)", test.expr, test.start, test.end, start, end, err)
+		}
+	}
+}
+
+// TestStepNames tests that the names of the steps are validated
+// both in plain text scripts and in scenario files
+func TestStepNames(t *testing.T) {
+	s, err := script.ParseText("#name intro\n#highlight error\necho one")
+	if err != nil {
+		t.Fatalf("expected no error, but got %v", err)
+	}
+	if len(s.Steps) != 1 || s.Steps[0].Name != "intro" {
+		t.Errorf("expected a step named intro, but got %+v", s.Steps)
+	}
+
+	for _, input := range []string{"#name 42\necho one", "#name my step\necho one", "echo one\n#name last", "#name a\necho one\n#name a\necho two"} {
+		if _, err := script.ParseText(input); err == nil {
+			t.Errorf("%q: expected error, but got nil", input)
+		}
+	}
+	if _, err := script.ParseJSON([]byte(`{"steps": [{"name": "a", "command": "ls"}, {"name": "a", "command": "pwd"}]}`)); err == nil {
+		t.Errorf("expected error for duplicate step names, but got nil")
+	}
+}
+
+// TestStepTags tests that the tags directives tag the next step,
+// and that invalid tags are refused
+func TestStepTags(t *testing.T) {
+	s, err := script.ParseText("#tags setup, optional\n#name intro\necho one\necho two")
+	if err != nil {
+		t.Fatalf("expected no error, but got %v", err)
+	}
+	if len(s.Steps) != 2 || s.Steps[0].Name != "intro" || !s.Steps[0].HasTag("optional") || len(s.Steps[1].Tags) != 0 {
+		t.Errorf("expected a tagged first step, but got %+v", s.Steps)
+	}
+	if s.Steps[0].HasTag("advanced") || !s.Steps[0].HasTag("advanced", "setup") {
+		t.Errorf("expected the step to have any of the tags, but got %v", s.Steps[0].Tags)
+	}
+
+	for _, input := range []string{"#tags\necho one", "#tags a!b\necho one", "echo one\n#tags last"} {
+		if _, err := script.ParseText(input); err == nil {
+			t.Errorf("%q: expected error, but got nil", input)
+		}
+	}
+	if _, err := script.ParseJSON([]byte(`{"steps": [{"tags": ["my tag"], "command": "ls"}]}`)); err == nil {
+		t.Errorf("expected error for an invalid tag, but got nil")
+	}
+}
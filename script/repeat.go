@@ -0,0 +1,68 @@
+/*
+Copyright © 2023 Mikael Schultz <bitcanon@proton.me>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package script
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// maxRepeat is the number of times a block of steps is repeated at most
+const maxRepeat = 1000
+
+// expandRepeats returns the steps with the repeat blocks replaced by
+// their steps, repeated, and the iterations of the repeated steps
+// numbered from 1. Nested blocks are expanded first
+func expandRepeats(steps []Step) ([]Step, error) {
+	var expanded []Step
+	for i, step := range steps {
+		if step.Repeat == 0 && len(step.Steps) == 0 {
+			expanded = append(expanded, step)
+			continue
+		}
+
+		switch {
+		case step.Repeat < 0 || step.Repeat > maxRepeat:
+			return nil, fmt.Errorf("step %d: repeat must be between 1 and %d", i+1, maxRepeat)
+		case step.Repeat == 0:
+			return nil, fmt.Errorf("step %d: steps need a repeat count", i+1)
+		case len(step.Steps) == 0:
+			return nil, fmt.Errorf("step %d: repeat needs steps", i+1)
+		case !reflect.DeepEqual(Step{Repeat: step.Repeat, Steps: step.Steps}, step):
+			return nil, fmt.Errorf("step %d: a repeat block cannot run a command, only its steps", i+1)
+		}
+
+		block, err := expandRepeats(step.Steps)
+		if err != nil {
+			return nil, fmt.Errorf("step %d: %w", i+1, err)
+		}
+		for n := 1; n <= step.Repeat; n++ {
+			for _, s := range block {
+				if s.Iteration == 0 {
+					s.Iteration = n
+				}
+				expanded = append(expanded, s)
+			}
+		}
+	}
+	return expanded, nil
+}
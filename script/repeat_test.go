@@ -0,0 +1,90 @@
+package script_test
+
+import (
+	"testing"
+
+	"github.com/bitcanon/autotyper/script"
+)
+
+// TestRepeat tests that the repeat blocks of the scenarios and of the
+// plain text scripts are expanded, with the iterations numbered
+func TestRepeat(t *testing.T) {
+	tests := []struct {
+		name  string
+		parse func() (*script.Scenario, error)
+	}{
+		{name: "Text", parse: func() (*script.Scenario, error) {
+			return script.ParseText("#repeat 2\nkubectl get pods\n#repeat 2\nsleep 1\n#end\n#end\necho done")
+		}},
+		{name: "JSON", parse: func() (*script.Scenario, error) {
+			return script.ParseJSON([]byte(`{"steps": [{"repeat": 2, "steps": [{"command": "kubectl get pods"}, {"repeat": 2, "steps": [{"command": "sleep 1"}]}]}, {"command": "echo done"}]}`))
+		}},
+		{name: "YAML", parse: func() (*script.Scenario, error) {
+			return script.ParseYAML([]byte("steps:\n  - repeat: 2\n    steps:\n      - command: kubectl get pods\n      - repeat: 2\n        steps:\n          - command: sleep 1\n  - command: echo done\n"))
+		}},
+	}
+
+	expected := []script.Step{
+		{Command: "kubectl get pods", Iteration: 1},
+		{Command: "sleep 1", Iteration: 1},
+		{Command: "sleep 1", Iteration: 2},
+		{Command: "kubectl get pods", Iteration: 2},
+		{Command: "sleep 1", Iteration: 1},
+		{Command: "sleep 1", Iteration: 2},
+		{Command: "echo done"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s, err := test.parse()
+			if err != nil {
+				t.Fatalf("expected no error, but got %v", err)
+			}
+			if len(s.Steps) != len(expected) {
+				t.Fatalf("expected %d steps, but got %+v", len(expected), s.Steps)
+			}
+			for i, step := range s.Steps {
+				if step.Command != expected[i].Command || step.Iteration != expected[i].Iteration || step.Repeat != 0 {
+					t.Errorf("step %d: expected %+v, but got %+v", i+1, expected[i], step)
+				}
+			}
+		})
+	}
+}
+
+// TestRepeatInvalid tests that invalid repeat blocks are refused
+func TestRepeatInvalid(t *testing.T) {
+	for _, input := range []string{"#repeat\nls\n#end", "#repeat 0\nls\n#end", "#repeat 2\nls", "ls\n#end", "#repeat 2\n#end", "#repeat 2\n#name twice\nls\n#end"} {
+		if _, err := script.ParseText(input); err == nil {
+			t.Errorf("%q: expected error, but got nil", input)
+		}
+	}
+	for _, input := range []string{
+		`{"steps": [{"repeat": 2}]}`,
+		`{"steps": [{"steps": [{"command": "ls"}]}]}`,
+		`{"steps": [{"repeat": -1, "steps": [{"command": "ls"}]}]}`,
+		`{"steps": [{"repeat": 5000, "steps": [{"command": "ls"}]}]}`,
+		`{"steps": [{"repeat": 2, "command": "ls", "steps": [{"command": "ls"}]}]}`,
+	} {
+		if _, err := script.ParseJSON([]byte(input)); err == nil {
+			t.Errorf("%s: expected error, but got nil", input)
+		}
+	}
+}
+
+// TestIterationNotRead tests that the iteration of a step is only
+// set by the expansion of the repeat blocks, not by the script
+func TestIterationNotRead(t *testing.T) {
+	for name, parse := range map[string]func() (*script.Scenario, error){
+		"JSON": func() (*script.Scenario, error) {
+			return script.ParseJSON([]byte(`{"steps": [{"command": "ls", "iteration": 3}]}`))
+		},
+		"YAML": func() (*script.Scenario, error) {
+			return script.ParseYAML([]byte("steps:\n  - command: ls\n    iteration: 3\n"))
+		},
+	} {
+		s, err := parse()
+		if err == nil && s.Steps[0].Iteration != 0 {
+			t.Errorf("%s: expected no iteration, but got %d", name, s.Steps[0].Iteration)
+		}
+	}
+}
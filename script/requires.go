@@ -0,0 +1,69 @@
+/*
+Copyright © 2023 Mikael Schultz <bitcanon@proton.me>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package script
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// Unmet returns the first requirement of the step that is not met, or
+// an empty string if they all are. A requirement starting with "$" is
+// an environment variable that must be set (e.g. "$KUBECONFIG"), and
+// any other requirement a program that must be found in the PATH
+func (s Step) Unmet() string {
+	for _, req := range s.Requires {
+		if name, ok := strings.CutPrefix(req, "$"); ok {
+			if _, set := os.LookupEnv(name); !set {
+				return req
+			}
+		} else if _, err := exec.LookPath(req); err != nil {
+			return req
+		}
+	}
+	return ""
+}
+
+// validateRequirements checks the requirements and the fallback of
+// the step, which only a command printing it as its output can have
+func (s Step) validateRequirements() error {
+	for _, req := range s.Requires {
+		if name, ok := strings.CutPrefix(req, "$"); ok && !ValidVariableName(name) {
+			return fmt.Errorf("invalid environment variable %q in requires", req)
+		}
+		if req == "" || strings.ContainsAny(req, " \t") {
+			return fmt.Errorf("invalid requirement %q, expected a program or an environment variable", req)
+		}
+	}
+	if s.Fallback == "" {
+		return nil
+	}
+	if len(s.Requires) == 0 {
+		return fmt.Errorf("a fallback needs requirements")
+	}
+	if s.Command == "" {
+		return fmt.Errorf("only a command can have a fallback")
+	}
+	return nil
+}
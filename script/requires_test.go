@@ -0,0 +1,42 @@
+package script_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/bitcanon/autotyper/script"
+)
+
+// TestRequires tests that the requirements are given to the next step
+// and checked, and that invalid requirements are refused
+func TestRequires(t *testing.T) {
+	t.Setenv("AUTOTYPER_TEST_SET", "1")
+	s, err := script.ParseText("#requires autotyper-missing-program $AUTOTYPER_TEST_SET\nkubectl get pods\n#requires $AUTOTYPER_TEST_SET\necho set")
+	if err != nil {
+		t.Fatalf("expected no error, but got %v", err)
+	}
+	if expected := []string{"autotyper-missing-program", "$AUTOTYPER_TEST_SET"}; !reflect.DeepEqual(s.Steps[0].Requires, expected) {
+		t.Errorf("expected requires %q, but got %q", expected, s.Steps[0].Requires)
+	}
+	if unmet := s.Steps[0].Unmet(); unmet != "autotyper-missing-program" {
+		t.Errorf("expected unmet requirement %q, but got %q", "autotyper-missing-program", unmet)
+	}
+	if unmet := s.Steps[1].Unmet(); unmet != "" {
+		t.Errorf("expected no unmet requirement, but got %q", unmet)
+	}
+
+	for _, input := range []string{"#requires\nls", "ls\n#requires kubectl", "#requires $1X\nls"} {
+		if _, err := script.ParseText(input); err == nil {
+			t.Errorf("%q: expected error, but got nil", input)
+		}
+	}
+	for _, input := range []string{
+		`{"steps": [{"command": "ls", "fallback": "a b"}]}`,
+		`{"steps": [{"think": 500, "requires": ["kubectl"], "fallback": "a b"}]}`,
+		`{"steps": [{"command": "ls", "requires": ["kubectl"], "fallback": "{{.Missing"}]}`,
+	} {
+		if _, err := script.ParseJSON([]byte(input)); err == nil {
+			t.Errorf("%s: expected error, but got nil", input)
+		}
+	}
+}
@@ -0,0 +1,790 @@
+/*
+Copyright © 2023 Mikael Schultz <bitcanon@proton.me>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package script
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/bitcanon/autotyper/cli"
+	"github.com/bitcanon/autotyper/locale"
+	"github.com/bitcanon/autotyper/qrcode"
+	"github.com/bitcanon/autotyper/simulate"
+	"github.com/yuin/gopher-lua/parse"
+)
+
+// Scenario is a demo script: a list of steps that are typed and
+// executed in order. The prompt and timing of the scenario override
+// the settings from the flags and the config file
+type Scenario struct {
+	// An optional reference to the JSON schema, for editor support
+	Schema string `json:"$schema,omitempty" toml:"-" yaml:"-"`
+
+	Prompt *Prompt `json:"prompt,omitempty" toml:"prompt,omitempty" yaml:"prompt,omitempty"`
+	Timing *Timing `json:"timing,omitempty" toml:"timing,omitempty" yaml:"timing,omitempty"`
+
+	// An optional login simulated before the first prompt
+	Login *Login `json:"login,omitempty" toml:"login,omitempty" yaml:"login,omitempty"`
+
+	// External simulators of the commands without a built-in
+	// simulator, by command name (e.g. "kubectl": "python3 kubectl.py").
+	// Simulators ending with ".wasm" are WebAssembly modules
+	// (e.g. "kubectl": "kubectl.wasm")
+	Simulators map[string]string `json:"simulators,omitempty" toml:"simulators,omitempty" yaml:"simulators,omitempty"`
+
+	// Rules highlighting the output of all steps (e.g.
+	// `"error|failed" style=red-bold`, see cli.ParseHighlight)
+	Highlights []string `json:"highlights,omitempty" toml:"highlights,omitempty" yaml:"highlights,omitempty"`
+
+	Steps []Step `json:"steps" toml:"steps" yaml:"steps"`
+
+	// Macros are the named groups of steps used by the steps, by
+	// name (e.g. "deploy" used as "deploy(app=web)")
+	Macros map[string]Macro `json:"macros,omitempty" toml:"macros,omitempty" yaml:"macros,omitempty"`
+
+	// The directory of the scenario file, where the external simulators
+	// run. It is empty if the scenario was not read from a file
+	Dir string `json:"-" toml:"-" yaml:"-"`
+
+	// The flags of the interpreter line of the scenario file (e.g.
+	// "#!/usr/bin/env -S autotyper --shell bash"), see Shebang
+	Flags []string `json:"-" toml:"-" yaml:"-"`
+}
+
+// Define the styles of the simulated logins
+const (
+	LoginConsole    = "login"
+	LoginSSH        = "ssh"
+	LoginCloudShell = "cloud-shell"
+)
+
+// Define the marks drawn by annotations
+const (
+	MarkBox       = "box"
+	MarkUnderline = "underline"
+	MarkArrow     = "arrow"
+)
+
+// Define the kinds of messages printed by motd steps
+const (
+	MotdMessage = "message"
+	MotdSysinfo = "sysinfo"
+)
+
+// Define the conditions of the goto steps
+const (
+	GotoFailed    = "failed"
+	GotoSucceeded = "succeeded"
+	GotoKey       = "key"
+)
+
+// Login is a login simulated before the first prompt, for demos that
+// begin by connecting to a server: the login: and Password: prompts
+// of a console, the password prompt of SSH, or the connect message
+// of a cloud shell, followed by an optional banner
+type Login struct {
+	// The style of the login: "login", "ssh" or "cloud-shell"
+	Style string `json:"style" toml:"style" yaml:"style"`
+
+	// The username and the host logged in to. If empty, the
+	// username and the hostname of the prompt are used
+	Username string `json:"username,omitempty" toml:"username,omitempty" yaml:"username,omitempty"`
+	Host     string `json:"host,omitempty" toml:"host,omitempty" yaml:"host,omitempty"`
+
+	// The password, typed without echo, so only its length matters
+	Password string `json:"password,omitempty" toml:"password,omitempty" yaml:"password,omitempty"`
+
+	// A banner printed after logging in (e.g. "Welcome to Ubuntu")
+	Banner string `json:"banner,omitempty" toml:"banner,omitempty" yaml:"banner,omitempty"`
+}
+
+// Step is a single command in a scenario
+type Step struct {
+	// An optional name the step is referenced by, instead of
+	// its number, when starting the playback at it (e.g. "deploy")
+	Name string `json:"name,omitempty" toml:"name,omitempty" yaml:"name,omitempty"`
+
+	// Tags selecting or skipping the step with the tag filters of
+	// the player, so that one scenario has a short and a long
+	// version (e.g. "setup", "optional", "advanced")
+	Tags []string `json:"tags,omitempty" toml:"tags,omitempty" yaml:"tags,omitempty"`
+
+	// Requirements of the step: programs found in the PATH (e.g.
+	// "kubectl") or environment variables that are set (e.g.
+	// "$KUBECONFIG"). If one is not met, the step is skipped, or the
+	// command prints the fallback as its canned output if there is one
+	Requires []string `json:"requires,omitempty" toml:"requires,omitempty" yaml:"requires,omitempty"`
+	Fallback string   `json:"fallback,omitempty" toml:"fallback,omitempty" yaml:"fallback,omitempty"`
+
+	// The operating systems the step is played on (e.g. "linux",
+	// "darwin"), all if empty, and the commands typed instead of the
+	// command on some of them (e.g. "windows": "dir")
+	OS       []string          `json:"os,omitempty" toml:"os,omitempty" yaml:"os,omitempty"`
+	Variants map[string]string `json:"variants,omitempty" toml:"variants,omitempty" yaml:"variants,omitempty"`
+
+	// The command to type and execute
+	Command string `json:"command" toml:"command" yaml:"command"`
+
+	// An optional caption printed above the prompt before
+	// the command is typed (e.g. "Let's list the files")
+	Caption string `json:"caption,omitempty" toml:"caption,omitempty" yaml:"caption,omitempty"`
+
+	// Presenter notes written to a secondary output as the step
+	// begins, so the presenter sees what to say while the audience
+	// only sees the demo (e.g. "Mention the cache warm-up")
+	Notes string `json:"notes,omitempty" toml:"notes,omitempty" yaml:"notes,omitempty"`
+
+	// The title of the chapter (or scene) beginning with the step,
+	// marked with a cue for the presenter and the recording software
+	// (e.g. "Deploying the app")
+	Chapter string `json:"chapter,omitempty" toml:"chapter,omitempty" yaml:"chapter,omitempty"`
+
+	// Canned output printed instead of executing the command
+	Output string `json:"output,omitempty" toml:"output,omitempty" yaml:"output,omitempty"`
+
+	// Breaks inserted into a long canned output, like a pager
+	// or the CLI of a network device (e.g. "--More--")
+	More *More `json:"more,omitempty" toml:"more,omitempty" yaml:"more,omitempty"`
+
+	// Clear the screen whenever the output of this step exceeds
+	// this many lines, instead of the option of the player
+	ClearAfter int `json:"clear-after,omitempty" toml:"clear-after,omitempty" yaml:"clear-after,omitempty"`
+
+	// Clear the screen after the command (true), even if the player
+	// does not clear it between commands, or keep the output on the
+	// screen (false). If nil, the option of the player is used
+	Clear *bool `json:"clear,omitempty" toml:"clear,omitempty" yaml:"clear,omitempty"`
+
+	// Input typed into the running command at the given times
+	Input []Input `json:"input,omitempty" toml:"input,omitempty" yaml:"input,omitempty"`
+
+	// Rules answering the prompts of an interactive command, in order.
+	// The command runs in a pseudo-terminal when there are rules
+	Expect []Expect `json:"expect,omitempty" toml:"expect,omitempty" yaml:"expect,omitempty"`
+
+	// Ask the presenter for the value of a variable instead of
+	// running a command. The value replaces ${name} in the
+	// commands of the following steps
+	Ask string `json:"ask,omitempty" toml:"ask,omitempty" yaml:"ask,omitempty"`
+
+	// Pause at the prompt for this many milliseconds instead of
+	// running a command, as if the presenter was deciding what
+	// to do next
+	Think int `json:"think,omitempty" toml:"think,omitempty" yaml:"think,omitempty"`
+
+	// Hold the demo at the prompt until the audio clip of the narration
+	// in this file (MP3 or WAV) has been played, or for as long as it
+	// lasts, instead of running a command. A relative path is relative
+	// to the directory of the scenario
+	WaitAudio string `json:"wait-audio,omitempty" toml:"wait-audio,omitempty" yaml:"wait-audio,omitempty"`
+
+	// Draw the image in this file (PNG, JPEG or GIF) inline instead of
+	// running a command, for terminals supporting the images of iTerm2
+	// or Sixel graphics. A relative path is relative to the directory
+	// of the scenario
+	Image string `json:"image,omitempty" toml:"image,omitempty" yaml:"image,omitempty"`
+
+	// Show a QR code of this text (e.g. the link to the slides) for
+	// a while instead of running a command, so the viewers can scan it
+	QRCode string `json:"qrcode,omitempty" toml:"qrcode,omitempty" yaml:"qrcode,omitempty"`
+
+	// Print the message of the day ("message") or a summary of the
+	// system ("sysinfo") instead of running a command, like a server
+	// does right after logging in
+	Motd string `json:"motd,omitempty" toml:"motd,omitempty" yaml:"motd,omitempty"`
+
+	// A command typed like any other, with an output generated by a
+	// simulator instead of running it (e.g. "ping dns.google"). The
+	// parameters tune the simulator (e.g. the latency of ping)
+	Simulate string          `json:"simulate,omitempty" toml:"simulate,omitempty" yaml:"simulate,omitempty"`
+	Params   simulate.Params `json:"params,omitempty" toml:"params,omitempty" yaml:"params,omitempty"`
+
+	// Jump to another step instead of running a command, always or
+	// if the condition is met (e.g. back to a retry point)
+	Goto *Goto `json:"goto,omitempty" toml:"goto,omitempty" yaml:"goto,omitempty"`
+
+	// Repeat the block of steps this many times instead of running a
+	// command (e.g. a watch-style command shown three times). The
+	// blocks are expanded when the scenario is parsed
+	Repeat int    `json:"repeat,omitempty" toml:"repeat,omitempty" yaml:"repeat,omitempty"`
+	Steps  []Step `json:"steps,omitempty" toml:"steps,omitempty" yaml:"steps,omitempty"`
+
+	// Play the steps of a macro instead of running a command, with
+	// the values of its parameters (e.g. "deploy(app=web)"). The
+	// macros are expanded when the scenario is parsed
+	Use string `json:"use,omitempty" toml:"use,omitempty" yaml:"use,omitempty"`
+
+	// The iteration of the innermost repeat block the step was
+	// expanded from, from 1, or 0 if it is not repeated. It is
+	// set by the expansion only, never read from the script
+	Iteration int `json:"-" toml:"-" yaml:"-"`
+
+	// Lua code run instead of a command, for demos too dynamic for a
+	// list of steps. The code can type and run commands, print output
+	// and pause with the functions of the demo table (e.g. demo.run)
+	Lua string `json:"lua,omitempty" toml:"lua,omitempty" yaml:"lua,omitempty"`
+
+	// Annotations drawn on the output of this step once it is
+	// printed, one after the other
+	Annotate []Annotation `json:"annotate,omitempty" toml:"annotate,omitempty" yaml:"annotate,omitempty"`
+
+	// Rules highlighting the output of this step, before
+	// the highlight rules of the scenario
+	Highlight []string `json:"highlight,omitempty" toml:"highlight,omitempty" yaml:"highlight,omitempty"`
+
+	// Prompt and timing overrides for this step only
+	Prompt *Prompt `json:"prompt,omitempty" toml:"prompt,omitempty" yaml:"prompt,omitempty"`
+	Timing *Timing `json:"timing,omitempty" toml:"timing,omitempty" yaml:"timing,omitempty"`
+}
+
+// Input is text written to the standard input of a running command.
+// The text is also typed on the screen, since the command does not
+// echo it (e.g. "y\n" to answer a question)
+type Input struct {
+	// Delay in milliseconds after the command started,
+	// or after the previous input was typed
+	After int `json:"after,omitempty" toml:"after,omitempty" yaml:"after,omitempty"`
+
+	// The text to write, including any newline, with the keys of
+	// the key notation (e.g. "<Enter>", "<C-c>", see cli.ExpandKeys)
+	Text string `json:"text" toml:"text" yaml:"text"`
+}
+
+// Expect waits for a regular expression to match the output of the
+// command (e.g. "Password:") and then sends a response, followed
+// by Enter unless the response already ends with a newline or a key
+// of the key notation (e.g. "<Tab>", see cli.ExpandKeys)
+type Expect struct {
+	Expect string `json:"expect" toml:"expect" yaml:"expect"`
+	Send   string `json:"send" toml:"send" yaml:"send"`
+}
+
+// Goto jumps to the step with the name or the number, always or if
+// the condition is met: the previous command "failed", "succeeded" or
+// exited with the code (e.g. "2"), or the presenter answered yes to
+// the question ("key"). A goto jumps at most Max times, 3 by default,
+// so that a loop always ends
+type Goto struct {
+	Step   string `json:"step" toml:"step" yaml:"step"`
+	If     string `json:"if,omitempty" toml:"if,omitempty" yaml:"if,omitempty"`
+	Prompt string `json:"prompt,omitempty" toml:"prompt,omitempty" yaml:"prompt,omitempty"`
+	Max    int    `json:"max,omitempty" toml:"max,omitempty" yaml:"max,omitempty"`
+}
+
+// MaxJumps returns the number of times the goto jumps at most
+func (g *Goto) MaxJumps() int {
+	if g.Max > 0 {
+		return g.Max
+	}
+	return 3
+}
+
+// Question returns the question asked to the presenter
+// by a goto with the key condition
+func (g *Goto) Question() string {
+	if g.Prompt != "" {
+		return g.Prompt
+	}
+	return locale.T("Go to step %s?", g.Step)
+}
+
+// More breaks a canned output into pages. After each page, the prompt
+// is shown until the output goes on automatically after the delay in
+// milliseconds, as if the presenter pressed the space bar. Zero values
+// are the defaults
+type More struct {
+	Lines  int    `json:"lines,omitempty" toml:"lines,omitempty" yaml:"lines,omitempty"`
+	Delay  int    `json:"delay,omitempty" toml:"delay,omitempty" yaml:"delay,omitempty"`
+	Prompt string `json:"prompt,omitempty" toml:"prompt,omitempty" yaml:"prompt,omitempty"`
+}
+
+// Annotation draws a mark (a box, an underline or an arrow) on a
+// line of the output for a few seconds, pointing the audience at a
+// result. The line is the first one matching the pattern, or the line
+// with the number, from 1, counted from the end if it is negative
+type Annotation struct {
+	Mark  string `json:"mark" toml:"mark" yaml:"mark"`
+	Match string `json:"match,omitempty" toml:"match,omitempty" yaml:"match,omitempty"`
+	Line  int    `json:"line,omitempty" toml:"line,omitempty" yaml:"line,omitempty"`
+
+	// The text written next to the mark (e.g. "all pods are up")
+	Text string `json:"text,omitempty" toml:"text,omitempty" yaml:"text,omitempty"`
+
+	// The style of the mark (see cli.ParseStyle), and how long it
+	// is shown in milliseconds. Zero values are the defaults
+	Style    string `json:"style,omitempty" toml:"style,omitempty" yaml:"style,omitempty"`
+	Duration int    `json:"duration,omitempty" toml:"duration,omitempty" yaml:"duration,omitempty"`
+}
+
+// Prompt overrides the prompt settings. Empty fields are not overridden
+type Prompt struct {
+	Shell    string `json:"shell,omitempty" toml:"shell,omitempty" yaml:"shell,omitempty"`
+	Username string `json:"username,omitempty" toml:"username,omitempty" yaml:"username,omitempty"`
+	Hostname string `json:"hostname,omitempty" toml:"hostname,omitempty" yaml:"hostname,omitempty"`
+	Path     string `json:"path,omitempty" toml:"path,omitempty" yaml:"path,omitempty"`
+}
+
+// Timing overrides the delays in milliseconds. Nil fields are not overridden
+type Timing struct {
+	CharDelay *int `json:"char-delay,omitempty" toml:"char-delay,omitempty" yaml:"char-delay,omitempty"`
+	PreDelay  *int `json:"pre-delay,omitempty" toml:"pre-delay,omitempty" yaml:"pre-delay,omitempty"`
+	PostDelay *int `json:"post-delay,omitempty" toml:"post-delay,omitempty" yaml:"post-delay,omitempty"`
+}
+
+// Apply returns the prompt with the overrides applied
+func (p *Prompt) Apply(prompt cli.Prompt) cli.Prompt {
+	if p == nil {
+		return prompt
+	}
+	if shell, ok := cli.ParseShellOption(p.Shell); ok {
+		prompt.Shell = shell
+	}
+	if p.Username != "" {
+		prompt.Username = p.Username
+	}
+	if p.Hostname != "" {
+		prompt.Hostname = p.Hostname
+	}
+	if p.Path != "" {
+		prompt.Path = p.Path
+	}
+	return prompt
+}
+
+// HasTag reports whether the step has any of the tags
+func (s Step) HasTag(tags ...string) bool {
+	for _, tag := range tags {
+		for _, t := range s.Tags {
+			if t == tag {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Text returns the command typed by the step: the command,
+// or the simulated command
+func (s Step) Text() string {
+	if s.Simulate != "" {
+		return s.Simulate
+	}
+	return s.Command
+}
+
+// Simulator returns the simulator of the command: the external
+// simulator of the scenario, or else the built-in simulator. The
+// second return value reports whether the command has a simulator
+func (s *Scenario) Simulator(command string) (simulate.Simulator, bool) {
+	if plugin, ok := s.Simulators[simulate.Name(command)]; ok {
+		if strings.HasSuffix(plugin, ".wasm") {
+			return simulate.WASM{Path: plugin, Dir: s.Dir}, true
+		}
+		return simulate.Plugin{Command: plugin, Dir: s.Dir}, true
+	}
+	return simulate.Lookup(command)
+}
+
+// Commands returns the commands of all steps in the scenario
+func (s *Scenario) Commands() []string {
+	commands := make([]string, len(s.Steps))
+	for i, step := range s.Steps {
+		commands[i] = step.Text()
+	}
+	return commands
+}
+
+// expand replaces the steps using macros by the steps of the macros,
+// and then the repeat blocks by their steps, repeated
+func (s *Scenario) expand() error {
+	steps, err := expandMacros(s.Steps, s.Macros)
+	if err != nil {
+		return err
+	}
+	s.Steps, err = expandRepeats(steps)
+	return err
+}
+
+// Validate checks that the scenario can be played
+func (s *Scenario) Validate() error {
+	if err := s.Prompt.validate(); err != nil {
+		return err
+	}
+	if err := s.Timing.validate(); err != nil {
+		return err
+	}
+	if err := s.Login.validate(); err != nil {
+		return err
+	}
+	for name, command := range s.Simulators {
+		if name == "" || strings.ContainsAny(name, " \t") {
+			return fmt.Errorf("invalid simulator name %q", name)
+		}
+		if strings.TrimSpace(command) == "" {
+			return fmt.Errorf("missing command of the simulator for %s", name)
+		}
+	}
+	for _, rule := range s.Highlights {
+		if _, err := cli.ParseHighlight(rule); err != nil {
+			return err
+		}
+	}
+	if err := validateStepNames(s.Steps); err != nil {
+		return err
+	}
+	for i, step := range s.Steps {
+		kinds := step.kinds()
+		if len(kinds) > 1 {
+			return fmt.Errorf("step %d: a step cannot be both %s and %s", i+1, kinds[0], kinds[1])
+		}
+		kind := kindCommand
+		if len(kinds) == 1 {
+			kind = kinds[0]
+		}
+		if err := s.validateKind(i, step, kind); err != nil {
+			return fmt.Errorf("step %d: %w", i+1, err)
+		}
+		for _, input := range step.Input {
+			if input.After < 0 {
+				return fmt.Errorf("step %d: input delay must not be negative", i+1)
+			}
+		}
+		if _, err := simulate.ParseOutput("output", step.Output); err != nil {
+			return fmt.Errorf("step %d: invalid output template: %w", i+1, err)
+		}
+		if err := step.validateRequirements(); err != nil {
+			return fmt.Errorf("step %d: %w", i+1, err)
+		}
+		if err := step.validateOS(); err != nil {
+			return fmt.Errorf("step %d: %w", i+1, err)
+		}
+		if _, err := simulate.ParseOutput("fallback", step.Fallback); err != nil {
+			return fmt.Errorf("step %d: invalid fallback template: %w", i+1, err)
+		}
+		if step.ClearAfter < 0 {
+			return fmt.Errorf("step %d: clear-after must not be negative", i+1)
+		}
+		if err := step.More.validate(step.Output); err != nil {
+			return fmt.Errorf("step %d: %w", i+1, err)
+		}
+		for _, rule := range step.Expect {
+			if _, err := regexp.Compile(rule.Expect); err != nil {
+				return fmt.Errorf("step %d: invalid expect pattern %q: %w", i+1, rule.Expect, err)
+			}
+		}
+		for _, a := range step.Annotate {
+			if err := a.validate(); err != nil {
+				return fmt.Errorf("step %d: %w", i+1, err)
+			}
+		}
+		for _, rule := range step.Highlight {
+			if _, err := cli.ParseHighlight(rule); err != nil {
+				return fmt.Errorf("step %d: %w", i+1, err)
+			}
+		}
+		if err := step.Prompt.validate(); err != nil {
+			return fmt.Errorf("step %d: %w", i+1, err)
+		}
+		if err := step.Timing.validate(); err != nil {
+			return fmt.Errorf("step %d: %w", i+1, err)
+		}
+	}
+	return nil
+}
+
+// The kinds of steps, by what they do instead of running a command
+const (
+	kindGoto      = "goto"
+	kindAsk       = "ask"
+	kindThink     = "think"
+	kindWaitAudio = "wait-audio"
+	kindImage     = "image"
+	kindQRCode    = "qrcode"
+	kindMotd      = "motd"
+	kindLua       = "lua"
+	kindSimulate  = "simulate"
+	kindCommand   = "command"
+)
+
+// kinds returns the kinds of the step, by the fields it sets. The
+// command, its canned output, its input and its expect rules are all
+// of a command step. A valid step is of one kind at most
+func (step Step) kinds() []string {
+	var kinds []string
+	for _, k := range []struct {
+		kind string
+		set  bool
+	}{
+		{kindGoto, step.Goto != nil},
+		{kindAsk, step.Ask != ""},
+		{kindThink, step.Think != 0},
+		{kindWaitAudio, step.WaitAudio != ""},
+		{kindImage, step.Image != ""},
+		{kindQRCode, step.QRCode != ""},
+		{kindMotd, step.Motd != ""},
+		{kindLua, step.Lua != ""},
+		{kindSimulate, step.Simulate != ""},
+		{kindCommand, step.Command != "" || step.Output != "" || len(step.Input) > 0 || len(step.Expect) > 0},
+	} {
+		if k.set {
+			kinds = append(kinds, k.kind)
+		}
+	}
+	return kinds
+}
+
+// validateKind validates the fields of the step with the index i
+// that are specific to its kind
+func (s *Scenario) validateKind(i int, step Step, kind string) error {
+	switch kind {
+	case kindGoto:
+		return s.validateGoto(step.Goto)
+	case kindAsk:
+		if !ValidVariableName(step.Ask) {
+			return fmt.Errorf("invalid variable name %q", step.Ask)
+		}
+	case kindThink:
+		if step.Think < 0 {
+			return fmt.Errorf("think delay must not be negative")
+		}
+	case kindWaitAudio:
+		return validAudio(step.WaitAudio)
+	case kindImage:
+		return validImage(step.Image)
+	case kindQRCode:
+		_, err := qrcode.Encode(step.QRCode)
+		return err
+	case kindMotd:
+		if step.Motd != MotdMessage && step.Motd != MotdSysinfo {
+			return fmt.Errorf("unknown motd %q (expected message or sysinfo)", step.Motd)
+		}
+	case kindLua:
+		if _, err := parse.Parse(strings.NewReader(step.Lua), fmt.Sprintf("step %d", i+1)); err != nil {
+			return fmt.Errorf("invalid Lua code: %w", err)
+		}
+	case kindSimulate:
+		if _, ok := s.Simulator(step.Simulate); !ok {
+			return fmt.Errorf("no simulator for %q (expected %s, or an external simulator)", step.Simulate, strings.Join(simulate.Names(), ", "))
+		}
+	case kindCommand:
+		if strings.TrimSpace(step.Command) == "" {
+			return fmt.Errorf("missing command")
+		}
+	}
+	return nil
+}
+
+// validateGoto checks that the step gone to exists, that the
+// condition is known and that the number of jumps is not negative
+func (s *Scenario) validateGoto(g *Goto) error {
+	if _, err := s.StepIndex(g.Step); err != nil {
+		return fmt.Errorf("invalid goto: %w", err)
+	}
+	switch g.If {
+	case "", GotoFailed, GotoSucceeded, GotoKey:
+	default:
+		if _, err := strconv.Atoi(g.If); err != nil {
+			return fmt.Errorf("unknown goto condition %q (expected failed, succeeded, key or an exit code)", g.If)
+		}
+	}
+	if g.Max < 0 {
+		return fmt.Errorf("goto max must not be negative")
+	}
+	return nil
+}
+
+// validate checks that the shell is known
+func (p *Prompt) validate() error {
+	if p == nil || p.Shell == "" {
+		return nil
+	}
+	if _, ok := cli.ParseShellOption(p.Shell); !ok {
+		return fmt.Errorf("unknown shell %q (expected bash, cmd or ps)", p.Shell)
+	}
+	return nil
+}
+
+// validate checks that the breaks are inserted into a canned
+// output and that the page size and the delay are not negative
+func (m *More) validate(output string) error {
+	if m == nil {
+		return nil
+	}
+	if output == "" {
+		return fmt.Errorf("more breaks need a canned output")
+	}
+	if m.Lines < 0 {
+		return fmt.Errorf("more lines must not be negative")
+	}
+	if m.Delay < 0 {
+		return fmt.Errorf("more delay must not be negative")
+	}
+	return nil
+}
+
+// validate checks that the mark is known and that the line is
+// set either by a valid pattern or by its number
+func (a Annotation) validate() error {
+	switch a.Mark {
+	case MarkBox, MarkUnderline, MarkArrow:
+	default:
+		return fmt.Errorf("unknown annotation mark %q (expected box, underline or arrow)", a.Mark)
+	}
+	if (a.Match == "") == (a.Line == 0) {
+		return fmt.Errorf("annotation %s: expected either match or line", a.Mark)
+	}
+	if _, err := regexp.Compile(a.Match); err != nil {
+		return fmt.Errorf("annotation %s: invalid pattern %q: %w", a.Mark, a.Match, err)
+	}
+	if a.Duration < 0 {
+		return fmt.Errorf("annotation %s: duration must not be negative", a.Mark)
+	}
+	if a.Style != "" {
+		if _, err := cli.ParseStyle(a.Style); err != nil {
+			return fmt.Errorf("annotation %s: %w", a.Mark, err)
+		}
+	}
+	return nil
+}
+
+// validate checks that the style of the login is known
+func (l *Login) validate() error {
+	if l == nil {
+		return nil
+	}
+	switch l.Style {
+	case LoginConsole, LoginSSH, LoginCloudShell:
+		return nil
+	default:
+		return fmt.Errorf("unknown login style %q (expected login, ssh or cloud-shell)", l.Style)
+	}
+}
+
+// validate checks that no delay is negative
+func (t *Timing) validate() error {
+	if t == nil {
+		return nil
+	}
+	for name, delay := range map[string]*int{
+		"char-delay": t.CharDelay,
+		"pre-delay":  t.PreDelay,
+		"post-delay": t.PostDelay,
+	} {
+		if delay != nil && *delay < 0 {
+			return fmt.Errorf("%s must not be negative", name)
+		}
+	}
+	return nil
+}
+
+// Parse parses a script from a string. JSON scenarios are
+// recognized by their opening brace, anything else is parsed
+// as a plain text script with one command per line
+func Parse(input string) (*Scenario, error) {
+	if strings.HasPrefix(strings.TrimSpace(input), "{") {
+		slog.Debug("parsing script", "format", "json", "bytes", len(input))
+		return ParseJSON([]byte(input))
+	}
+	slog.Debug("parsing script", "format", "text", "bytes", len(input))
+	return ParseText(input)
+}
+
+// Load reads a script from a file. The format is chosen by the file
+// extension: .json, .toml, .yaml and .yml files are parsed as
+// scenarios, other files as plain text scripts
+func Load(filename string) (*Scenario, error) {
+	return (&loader{}).load(filename)
+}
+
+// Verify checks the contents of each script file read, including the
+// included files, before it is parsed (e.g. that it is signed with a
+// trusted key). The file is not loaded if an error is returned. A nil
+// Verify accepts all files
+var Verify func(filename string, data []byte) error
+
+// loader loads scripts and keeps track of the files being
+// included, so that include cycles can be detected
+type loader struct {
+	stack []string
+
+	// The number of lines and the macros of a streamed script
+	// before the part being parsed
+	line   int
+	macros map[string]Macro
+}
+
+// load reads a script from a file, see Load
+func (l *loader) load(filename string) (*Scenario, error) {
+	s, err := l.read(filename)
+	if err != nil {
+		slog.Debug("failed to load script", "file", filename, "error", err)
+		return nil, err
+	}
+	slog.Debug("loaded script", "file", filename, "steps", len(s.Steps))
+	return s, nil
+}
+
+// read parses a script file in the format of its extension
+func (l *loader) read(filename string) (*Scenario, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	if Verify != nil {
+		if err := Verify(filename, data); err != nil {
+			return nil, err
+		}
+	}
+
+	var parse func([]byte) (*Scenario, error)
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".json":
+		parse = ParseJSON
+	case ".toml":
+		parse = ParseTOML
+	case ".yaml", ".yml":
+		parse = ParseYAML
+	default:
+		// Lines end with a newline, without the
+		// newlines at the end of the file
+		input := strings.ReplaceAll(string(data), "\r\n", "\n")
+		s, err := l.parseText(strings.TrimRight(input, "\n"), filename)
+		if err != nil {
+			return nil, err
+		}
+		s.Flags, _ = Shebang(data)
+		return s, nil
+	}
+
+	s, err := parse(stripShebang(data))
+	if err != nil {
+		return nil, err
+	}
+	s.Dir = filepath.Dir(filename)
+	s.Flags, _ = Shebang(data)
+	return s, nil
+}
@@ -0,0 +1,179 @@
+package script_test
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/bitcanon/autotyper/cli"
+	"github.com/bitcanon/autotyper/script"
+	"github.com/bitcanon/autotyper/simulate"
+)
+
+// TestParse tests that plain text and JSON
+// scripts are both recognized by Parse
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected []string
+	}{
+		{
+			name:     "PlainText",
+			input:    "echo first\r\necho second",
+			expected: []string{"echo first", "echo second"},
+		},
+		{
+			name:     "JSON",
+			input:    `  {"steps": [{"command": "echo first"}]}`,
+			expected: []string{"echo first"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s, err := script.Parse(test.input)
+			if err != nil {
+				t.Fatalf("expected no error, but got %v", err)
+			}
+			if !reflect.DeepEqual(s.Commands(), test.expected) {
+				t.Errorf("expected %q, but got %q", test.expected, s.Commands())
+			}
+		})
+	}
+}
+
+// TestLoad tests that files are parsed based on their extension
+func TestLoad(t *testing.T) {
+	// The scenario files describe the same scenario in each format
+	for _, name := range []string{"scenario.json", "scenario.toml", "scenario.yaml"} {
+		t.Run(name, func(t *testing.T) {
+			s, err := script.Load(filepath.Join("..", "testdata", name))
+			if err != nil {
+				t.Fatalf("expected no error, but got %v", err)
+			}
+			expected := []string{"ping one.one.one.one", "netsh wlan show profiles"}
+			if !reflect.DeepEqual(s.Commands(), expected) {
+				t.Errorf("expected %q, but got %q", expected, s.Commands())
+			}
+			if s.Prompt == nil || s.Prompt.Shell != "bash" {
+				t.Errorf("expected the scenario prompt to be loaded, but got %+v", s.Prompt)
+			}
+			if s.Timing == nil || s.Timing.CharDelay == nil || *s.Timing.CharDelay != 50 {
+				t.Errorf("expected the scenario timing to be loaded, but got %+v", s.Timing)
+			}
+			if s.Steps[0].Caption != "# Check the connectivity" {
+				t.Errorf("expected the caption to be loaded, but got %q", s.Steps[0].Caption)
+			}
+			if s.Steps[1].Prompt == nil || s.Steps[1].Prompt.Shell != "cmd" {
+				t.Errorf("expected the step prompt to be loaded, but got %+v", s.Steps[1].Prompt)
+			}
+			if s.Dir != filepath.Join("..", "testdata") {
+				t.Errorf("expected the directory of the scenario, but got %q", s.Dir)
+			}
+		})
+	}
+
+	t.Run("TextFile", func(t *testing.T) {
+		s, err := script.Load(filepath.Join("..", "testdata", "commands.txt"))
+		if err != nil {
+			t.Fatalf("expected no error, but got %v", err)
+		}
+		expected := []string{"netsh wlan show profiles", "ping one.one.one.one"}
+		if !reflect.DeepEqual(s.Commands(), expected) {
+			t.Errorf("expected %q, but got %q", expected, s.Commands())
+		}
+	})
+
+	t.Run("FileNotFound", func(t *testing.T) {
+		tempDir := t.TempDir()
+		if _, err := script.Load(filepath.Join(tempDir, "missing.json")); !os.IsNotExist(err) {
+			t.Errorf("expected a not exist error, but got %v", err)
+		}
+	})
+}
+
+// TestPromptApply tests that only the fields set in
+// the override are applied to the prompt
+func TestPromptApply(t *testing.T) {
+	prompt := cli.Prompt{Username: "bitcanon", Hostname: "code", Path: "~", Shell: cli.PS}
+
+	var none *script.Prompt
+	if got := none.Apply(prompt); got != prompt {
+		t.Errorf("expected %+v, but got %+v", prompt, got)
+	}
+
+	override := &script.Prompt{Shell: "bash", Hostname: "box"}
+	expected := cli.Prompt{Username: "bitcanon", Hostname: "box", Path: "~", Shell: cli.Bash}
+	if got := override.Apply(prompt); got != expected {
+		t.Errorf("expected %+v, but got %+v", expected, got)
+	}
+}
+
+// TestScenarioSimulator tests that the external simulators of the
+// scenario take precedence over the built-in simulators
+func TestScenarioSimulator(t *testing.T) {
+	s, err := script.ParseJSON([]byte(`{
+		"simulators": {"kubectl": "python3 kubectl.py", "ping": "./ping.sh", "helm": "plugins/helm.wasm"},
+		"steps": [{"simulate": "kubectl get pods"}, {"simulate": "sudo ping dns.google"}, {"simulate": "docker pull nginx"}]
+	}`))
+	if err != nil {
+		t.Fatalf("expected no error, but got %v", err)
+	}
+	s.Dir = "demo"
+
+	for command, expected := range map[string]simulate.Simulator{
+		"kubectl get pods":   simulate.Plugin{Command: "python3 kubectl.py", Dir: "demo"},
+		"ping dns.google":    simulate.Plugin{Command: "./ping.sh", Dir: "demo"},
+		"sudo ping -c 1 ::1": simulate.Plugin{Command: "./ping.sh", Dir: "demo"},
+		"helm install web":   simulate.WASM{Path: "plugins/helm.wasm", Dir: "demo"},
+	} {
+		if simulator, ok := s.Simulator(command); !ok || simulator != expected {
+			t.Errorf("%s: expected %+v, but got %+v", command, expected, simulator)
+		}
+	}
+	if _, ok := s.Simulator("docker pull nginx"); !ok {
+		t.Errorf("expected the built-in simulator of docker")
+	}
+	if _, ok := s.Simulator("terraform apply"); ok {
+		t.Errorf("expected no simulator for terraform")
+	}
+
+	for _, input := range []string{
+		`{"steps": [{"simulate": "kubectl get pods"}]}`,
+		`{"simulators": {"kubectl": " "}, "steps": [{"simulate": "kubectl get pods"}]}`,
+		`{"simulators": {"kube ctl": "./kubectl.sh"}, "steps": [{"command": "ls"}]}`,
+	} {
+		if _, err := script.ParseJSON([]byte(input)); err == nil {
+			t.Errorf("expected error for %s, but got nil", input)
+		}
+	}
+}
+
+// TestValidateKinds tests that a step is of one kind at most, and
+// that the fields of its kind are validated
+func TestValidateKinds(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{input: `{"steps": [{"ask": "name"}, {"command": "ls", "output": "demo.txt"}, {"think": 500}]}`},
+		{input: `{"steps": [{"command": "ls"}, {"ask": "name", "command": "ls"}]}`, expected: "step 2: a step cannot be both ask and command"},
+		{input: `{"steps": [{"think": 500, "motd": "message"}]}`, expected: "step 1: a step cannot be both think and motd"},
+		{input: `{"steps": [{"simulate": "ping dns.google", "output": "pong"}]}`, expected: "step 1: a step cannot be both simulate and command"},
+		{input: `{"steps": [{"motd": "banner"}]}`, expected: `step 1: unknown motd "banner" (expected message or sysinfo)`},
+		{input: `{"steps": [{"output": "demo.txt"}]}`, expected: "step 1: missing command"},
+	}
+
+	for _, test := range tests {
+		_, err := script.ParseJSON([]byte(test.input))
+		if test.expected == "" && err != nil {
+			t.Errorf("%s: expected no error, but got %v", test.input, err)
+		}
+		if test.expected != "" && (err == nil || !strings.HasSuffix(err.Error(), test.expected)) {
+			t.Errorf("%s: expected error %q, but got %v", test.input, test.expected, err)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+/*
+Copyright © 2023 Mikael Schultz <bitcanon@proton.me>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package script
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+)
+
+// Shebang returns the flags of the interpreter line of a script file
+// run directly (e.g. "#!/usr/bin/env -S autotyper --shell bash"): the
+// arguments after the program and its play command, if any. The second
+// return value reports whether the file starts with such a line
+func Shebang(data []byte) ([]string, bool) {
+	line, ok := shebangLine(data)
+	if !ok {
+		return nil, false
+	}
+	fields := strings.Fields(line)
+	for i, field := range fields {
+		name := strings.TrimSuffix(filepath.Base(field), ".exe")
+		if name != "autotyper" {
+			continue
+		}
+		args := fields[i+1:]
+		if len(args) > 0 && args[0] == "play" {
+			args = args[1:]
+		}
+		return args, true
+	}
+	return nil, false
+}
+
+// shebangLine returns the interpreter line at the start of the
+// file, without the "#!". The second return value reports whether
+// the file starts with an interpreter line
+func shebangLine(data []byte) (string, bool) {
+	if !bytes.HasPrefix(data, []byte("#!")) {
+		return "", false
+	}
+	line, _, _ := bytes.Cut(data[2:], []byte("\n"))
+	return strings.TrimSuffix(string(line), "\r"), true
+}
+
+// stripShebang blanks the interpreter line at the start of the file,
+// keeping its line ending so that the lines keep their numbers
+func stripShebang(data []byte) []byte {
+	if !bytes.HasPrefix(data, []byte("#!")) {
+		return data
+	}
+	if i := bytes.IndexByte(data, '\n'); i >= 0 {
+		return data[i:]
+	}
+	return nil
+}
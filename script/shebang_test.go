@@ -0,0 +1,66 @@
+package script_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bitcanon/autotyper/script"
+)
+
+// TestShebang tests that the flags of the interpreter line are the
+// arguments after the program and its play command
+func TestShebang(t *testing.T) {
+	tests := []struct {
+		input string
+		flags string
+		ok    bool
+	}{
+		{input: "#!/usr/bin/env autotyper\nls", flags: "", ok: true},
+		{input: "#!/usr/bin/env -S autotyper --shell bash -c 50\r\nls", flags: "--shell bash -c 50", ok: true},
+		{input: "#!/usr/local/bin/autotyper play --no-cls", flags: "--no-cls", ok: true},
+		{input: "#!/bin/sh\nls", ok: false},
+		{input: "ls\n#!/usr/bin/env autotyper", ok: false},
+	}
+
+	for _, test := range tests {
+		flags, ok := script.Shebang([]byte(test.input))
+		if ok != test.ok {
+			t.Errorf("%q: expected ok %v, but got %v", test.input, test.ok, ok)
+		}
+		if got := strings.Join(flags, " "); got != test.flags {
+			t.Errorf("%q: expected flags %q, but got %q", test.input, test.flags, got)
+		}
+	}
+}
+
+// TestLoadShebang tests that the interpreter line is skipped in all
+// formats and that its flags are kept in the scenario
+func TestLoadShebang(t *testing.T) {
+	files := map[string]string{
+		"demo.txt":  "#!/usr/bin/env -S autotyper --shell bash\necho one\necho two",
+		"demo.json": "#!/usr/bin/env -S autotyper --shell bash\n{\"steps\": [{\"command\": \"echo one\"}, {\"command\": \"echo two\"}]}",
+		"demo.yaml": "#!/usr/bin/env -S autotyper --shell bash\nsteps:\n  - command: echo one\n  - command: echo two\n",
+		"demo.toml": "#!/usr/bin/env -S autotyper --shell bash\n[[steps]]\ncommand = \"echo one\"\n[[steps]]\ncommand = \"echo two\"\n",
+	}
+	dir := t.TempDir()
+	for name, content := range files {
+		t.Run(name, func(t *testing.T) {
+			filename := filepath.Join(dir, name)
+			if err := os.WriteFile(filename, []byte(content), 0755); err != nil {
+				t.Fatalf("failed to write the script: %v", err)
+			}
+			s, err := script.Load(filename)
+			if err != nil {
+				t.Fatalf("expected no error, but got %v", err)
+			}
+			if len(s.Steps) != 2 || s.Steps[0].Command != "echo one" || s.Steps[1].Command != "echo two" {
+				t.Errorf("expected the steps echo one and echo two, but got %+v", s.Steps)
+			}
+			if got := strings.Join(s.Flags, " "); got != "--shell bash" {
+				t.Errorf("expected flags %q, but got %q", "--shell bash", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,162 @@
+/*
+Copyright © 2023 Mikael Schultz <bitcanon@proton.me>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package script
+
+import (
+	"context"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/bitcanon/autotyper/cli"
+)
+
+// streamIdle is how long a streamed script may stay silent before
+// the step read so far is played, when no more lines are waiting
+const streamIdle = 100 * time.Millisecond
+
+// The directives given to the next step, and those changing
+// the step before them
+var (
+	attributes = map[string]bool{"name": true, "tags": true, "requires": true, "os": true, "note": true, "chapter": true}
+	modifiers  = map[string]bool{"annotate": true, "cls": true, "keep": true}
+)
+
+// Stream parses a plain text script while it is read, so that the
+// playback can start with the first line and go on for as long as
+// lines are written (e.g. to a pipe). The lines are read by a reader
+// goroutine and given to Next, which parses the lines of each step
+// on their own. Blocks and macros are parsed once they are ended,
+// and gotos can only go to steps of the same block.
+type Stream struct {
+	lines chan string
+	err   error
+
+	// The lines read but not parsed yet, the number of blocks open
+	// in them, and whether they end with a directive of the next step
+	pending  []string
+	depth    int
+	attached bool
+
+	loader loader
+}
+
+// NewStream starts reading the lines of the script from r
+func NewStream(r io.Reader) *Stream {
+	s := &Stream{lines: make(chan string, 64)}
+	go s.read(r)
+	return s
+}
+
+// read sends the lines of the script to Next until the end of the
+// input. The error of the input is set before the lines are closed
+func (s *Stream) read(r io.Reader) {
+	defer close(s.lines)
+	scanner := cli.NewLineScanner(r)
+	lines := 0
+	for ; scanner.Scan(); lines++ {
+		s.lines <- scanner.Text()
+	}
+	if err := scanner.Err(); err != nil {
+		s.err = cli.LineError(err, lines)
+	}
+}
+
+// Next waits for the lines of the next step and returns them parsed as
+// a part of the script, with the highlights and the macros defined in
+// them. It returns io.EOF once all lines have been parsed.
+func (s *Stream) Next(ctx context.Context) (*Scenario, error) {
+	idle := time.NewTimer(streamIdle)
+	defer idle.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-idle.C:
+			// Play the step read so far while waiting for more
+			if s.complete() {
+				return s.parse()
+			}
+			idle.Reset(streamIdle)
+		case line, ok := <-s.lines:
+			if !ok {
+				if s.err != nil {
+					return nil, s.err
+				}
+				if len(s.pending) == 0 {
+					return nil, io.EOF
+				}
+				return s.parse()
+			}
+
+			// A new step ends the step before it, while the
+			// directives changing a step are kept with it
+			name, _, _ := parseDirective(line)
+			var part []string
+			if s.complete() && !modifiers[name] {
+				part, s.pending = s.pending, nil
+			}
+			s.add(line, name)
+			if part != nil {
+				return s.parsePart(part)
+			}
+			idle.Reset(streamIdle)
+		}
+	}
+}
+
+// add adds a line to the pending lines, keeping track of
+// the blocks and of the directives of the next step
+func (s *Stream) add(line, directive string) {
+	s.pending = append(s.pending, line)
+	switch directive {
+	case "repeat", "macro":
+		s.depth++
+	case "end":
+		s.depth = max(0, s.depth-1)
+	}
+	s.attached = attributes[directive]
+}
+
+// complete reports whether the pending lines hold a whole step
+func (s *Stream) complete() bool {
+	return len(s.pending) > 0 && s.depth == 0 && !s.attached
+}
+
+// parse parses the pending lines
+func (s *Stream) parse() (*Scenario, error) {
+	part := s.pending
+	s.pending = nil
+	return s.parsePart(part)
+}
+
+// parsePart parses the lines of a part, numbered after those
+// of the parts before it
+func (s *Stream) parsePart(lines []string) (*Scenario, error) {
+	part, err := s.loader.parseText(strings.Join(lines, "\n"), "")
+	s.loader.line += len(lines)
+	if err != nil {
+		return nil, err
+	}
+	s.loader.macros = part.Macros
+	return part, nil
+}
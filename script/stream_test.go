@@ -0,0 +1,121 @@
+package script_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bitcanon/autotyper/script"
+)
+
+// texts returns the texts of the steps of a part
+func texts(s *script.Scenario) []string {
+	var texts []string
+	for _, step := range s.Steps {
+		texts = append(texts, step.Text())
+	}
+	return texts
+}
+
+// TestStream tests that a streamed script is parsed step by step,
+// with the directives of a step and the blocks kept together
+func TestStream(t *testing.T) {
+	input := strings.Join([]string{
+		"echo one",
+		"#name two",
+		"echo two",
+		"#cls",
+		"#repeat 2",
+		"ls",
+		"#end",
+		"#macro greet",
+		"pwd",
+		"#end",
+		"#use greet",
+	}, "\n")
+	expected := [][]string{{"echo one"}, {"echo two"}, {"ls", "ls"}, nil, {"pwd"}}
+
+	stream := script.NewStream(strings.NewReader(input))
+	var parts []*script.Scenario
+	for {
+		part, err := stream.Next(context.Background())
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatalf("expected no error, but got %v", err)
+		}
+		parts = append(parts, part)
+	}
+
+	if len(parts) != len(expected) {
+		t.Fatalf("expected %d parts, but got %d", len(expected), len(parts))
+	}
+	for i, part := range parts {
+		if got := texts(part); !reflect.DeepEqual(got, expected[i]) {
+			t.Errorf("part %d: expected steps %q, but got %q", i+1, expected[i], got)
+		}
+	}
+	if step := parts[1].Steps[0]; step.Name != "two" || step.Clear == nil || !*step.Clear {
+		t.Errorf("expected the step named two clearing the screen, but got %+v", step)
+	}
+}
+
+// TestStreamErrors tests that the errors of a streamed script
+// are reported with the line in the whole stream
+func TestStreamErrors(t *testing.T) {
+	stream := script.NewStream(strings.NewReader("echo one\necho two\n#think soon"))
+	for i := 0; i < 2; i++ {
+		if _, err := stream.Next(context.Background()); err != nil {
+			t.Fatalf("expected no error, but got %v", err)
+		}
+	}
+	_, err := stream.Next(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "<input>:3:") {
+		t.Errorf("expected an error on line 3, but got %v", err)
+	}
+}
+
+// TestStreamIdle tests that a step is given as soon as the input
+// stays silent, without waiting for the next line
+func TestStreamIdle(t *testing.T) {
+	r, w := io.Pipe()
+	defer w.Close()
+	stream := script.NewStream(r)
+	go w.Write([]byte("echo one\n"))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	part, err := stream.Next(ctx)
+	if err != nil {
+		t.Fatalf("expected no error, but got %v", err)
+	}
+	if got := texts(part); !reflect.DeepEqual(got, []string{"echo one"}) {
+		t.Errorf("expected the step %q, but got %q", "echo one", got)
+	}
+
+	// The next step is waited for until the context ends
+	ctx, cancel = context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	if _, err := stream.Next(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected the deadline to be exceeded, but got %v", err)
+	}
+}
+
+// TestStreamLongLines tests that a command far longer than the
+// default buffer of a scanner is streamed whole
+func TestStreamLongLines(t *testing.T) {
+	payload := "echo " + strings.Repeat("QUJD", 1<<18)
+	stream := script.NewStream(strings.NewReader(payload + "\nls"))
+	part, err := stream.Next(context.Background())
+	if err != nil {
+		t.Fatalf("expected no error, but got %v", err)
+	}
+	if len(part.Steps) != 1 || part.Steps[0].Command != payload {
+		t.Errorf("expected the command of %d bytes, but got %d steps", len(payload), len(part.Steps))
+	}
+}
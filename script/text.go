@@ -0,0 +1,455 @@
+/*
+Copyright © 2023 Mikael Schultz <bitcanon@proton.me>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package script
+
+import (
+	"fmt"
+	"log/slog"
+	"maps"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/bitcanon/autotyper/cli"
+	"github.com/bitcanon/autotyper/qrcode"
+	"github.com/bitcanon/autotyper/simulate"
+)
+
+// directives are the names of the lines starting with "#" that
+// are interpreted by the text parser. Other lines starting with
+// "#" are typed and executed like any other command
+var directives = map[string]bool{
+	"annotate":   true,
+	"ask":        true,
+	"chapter":    true,
+	"cls":        true,
+	"end":        true,
+	"goto":       true,
+	"highlight":  true,
+	"image":      true,
+	"include":    true,
+	"keep":       true,
+	"lua":        true,
+	"macro":      true,
+	"motd":       true,
+	"name":       true,
+	"note":       true,
+	"os":         true,
+	"qrcode":     true,
+	"repeat":     true,
+	"requires":   true,
+	"tags":       true,
+	"simulate":   true,
+	"think":      true,
+	"use":        true,
+	"wait-audio": true,
+}
+
+// parseDirective splits a directive line (e.g. "#include setup.txt")
+// into its name and argument. The last return value reports
+// whether the line is a known directive
+func parseDirective(line string) (string, string, bool) {
+	line = strings.TrimSpace(line)
+	if !strings.HasPrefix(line, "#") {
+		return "", "", false
+	}
+
+	name, arg, _ := strings.Cut(line[1:], " ")
+	if !directives[name] {
+		return "", "", false
+	}
+	return name, strings.TrimSpace(arg), true
+}
+
+// ParseText parses a plain text script with one command per line.
+// Included files are resolved relative to the working directory
+func ParseText(input string) (*Scenario, error) {
+	return (&loader{}).parseText(input, "")
+}
+
+// parseText parses a plain text script read from filename, which
+// is empty if the script was not read from a file
+func (l *loader) parseText(input, filename string) (*Scenario, error) {
+	// Keep track of the file to detect include cycles
+	if filename != "" {
+		abs, err := filepath.Abs(filename)
+		if err != nil {
+			return nil, err
+		}
+		for i, f := range l.stack {
+			if f == abs {
+				cycle := append(append([]string{}, l.stack[i:]...), abs)
+				return nil, fmt.Errorf("include cycle: %s", strings.Join(cycle, " -> "))
+			}
+		}
+		l.stack = append(l.stack, abs)
+		defer func() { l.stack = l.stack[:len(l.stack)-1] }()
+	}
+
+	// The lines of a part of a streamed script are numbered from the
+	// start of the stream, and the macros defined before it can be used
+	s, offset := &Scenario{}, 0
+	if filename == "" {
+		s.Macros, offset = maps.Clone(l.macros), l.line
+	}
+
+	// The name, the tags, the requirements, the systems, the notes and
+	// the chapter of the name, tags, requires, os, note and chapter
+	// directives are given to the next step, and the repeat blocks and
+	// the macros are open until their end directive
+	stepName, stepTags, stepRequires, stepOS := "", []string(nil), []string(nil), []string(nil)
+	stepChapter := ""
+	var stepNotes []string
+	var blocks []textBlock
+	for i, line := range cli.SplitCommands(input) {
+		// The interpreter line of a script run directly is skipped
+		if i == 0 && filename != "" && strings.HasPrefix(line, "#!") {
+			continue
+		}
+		first := len(s.Steps)
+		name, arg, ok := parseDirective(line)
+		if !ok {
+			name = ""
+			s.Steps = append(s.Steps, Step{Command: line})
+		}
+
+		switch name {
+		case "name":
+			if !validStepName(arg) {
+				return nil, fmt.Errorf("%s:%d: invalid step name %q", displayName(filename), offset+i+1, arg)
+			}
+			stepName = arg
+		case "tags":
+			tags := strings.Fields(strings.ReplaceAll(arg, ",", " "))
+			for _, tag := range tags {
+				if !validStepName(tag) {
+					return nil, fmt.Errorf("%s:%d: invalid tag %q", displayName(filename), offset+i+1, tag)
+				}
+			}
+			if len(tags) == 0 {
+				return nil, fmt.Errorf("%s:%d: missing tags after #tags", displayName(filename), offset+i+1)
+			}
+			stepTags = append(stepTags, tags...)
+		case "os":
+			systems := strings.Fields(strings.ReplaceAll(arg, ",", " "))
+			if len(systems) == 0 {
+				return nil, fmt.Errorf("%s:%d: missing operating systems after #os", displayName(filename), offset+i+1)
+			}
+			if err := (Step{OS: systems}).validateOS(); err != nil {
+				return nil, fmt.Errorf("%s:%d: %w", displayName(filename), offset+i+1, err)
+			}
+			stepOS = systems
+		case "requires":
+			requires := strings.Fields(arg)
+			if len(requires) == 0 {
+				return nil, fmt.Errorf("%s:%d: missing requirements after #requires", displayName(filename), offset+i+1)
+			}
+			if err := (Step{Requires: requires}).validateRequirements(); err != nil {
+				return nil, fmt.Errorf("%s:%d: %w", displayName(filename), offset+i+1, err)
+			}
+			stepRequires = append(stepRequires, requires...)
+		case "note":
+			stepNotes = append(stepNotes, arg)
+		case "chapter":
+			if arg == "" {
+				return nil, fmt.Errorf("%s:%d: missing title after #chapter", displayName(filename), offset+i+1)
+			}
+			stepChapter = arg
+		case "ask":
+			if !ValidVariableName(arg) {
+				return nil, fmt.Errorf("%s:%d: invalid variable name %q", displayName(filename), offset+i+1, arg)
+			}
+			s.Steps = append(s.Steps, Step{Ask: arg})
+		case "think":
+			ms, err := strconv.Atoi(arg)
+			if err != nil || ms <= 0 {
+				return nil, fmt.Errorf("%s:%d: invalid think delay %q, expected milliseconds", displayName(filename), offset+i+1, arg)
+			}
+			s.Steps = append(s.Steps, Step{Think: ms})
+		case "wait-audio":
+			if err := validAudio(arg); err != nil {
+				return nil, fmt.Errorf("%s:%d: %w", displayName(filename), offset+i+1, err)
+			}
+			if filename != "" && !filepath.IsAbs(arg) {
+				// Resolve the clip against the included file, not the
+				// directory of the scenario it is included in
+				if abs, err := filepath.Abs(filepath.Join(filepath.Dir(filename), arg)); err == nil {
+					arg = abs
+				}
+			}
+			s.Steps = append(s.Steps, Step{WaitAudio: arg})
+		case "image":
+			if err := validImage(arg); err != nil {
+				return nil, fmt.Errorf("%s:%d: %w", displayName(filename), offset+i+1, err)
+			}
+			if filename != "" && !filepath.IsAbs(arg) {
+				// Resolve the image against the included file, like
+				// the clips of the wait-audio steps
+				if abs, err := filepath.Abs(filepath.Join(filepath.Dir(filename), arg)); err == nil {
+					arg = abs
+				}
+			}
+			s.Steps = append(s.Steps, Step{Image: arg})
+		case "qrcode":
+			if arg == "" {
+				return nil, fmt.Errorf("%s:%d: missing text of the QR code", displayName(filename), offset+i+1)
+			}
+			if _, err := qrcode.Encode(arg); err != nil {
+				return nil, fmt.Errorf("%s:%d: %w", displayName(filename), offset+i+1, err)
+			}
+			s.Steps = append(s.Steps, Step{QRCode: arg})
+		case "motd":
+			motd := MotdMessage
+			if arg != "" {
+				motd = arg
+			}
+			if motd != MotdMessage && motd != MotdSysinfo {
+				return nil, fmt.Errorf("%s:%d: unknown motd %q, expected message or sysinfo", displayName(filename), offset+i+1, arg)
+			}
+			s.Steps = append(s.Steps, Step{Motd: motd})
+		case "goto":
+			g, err := parseGoto(arg)
+			if err != nil {
+				return nil, fmt.Errorf("%s:%d: %w", displayName(filename), offset+i+1, err)
+			}
+			s.Steps = append(s.Steps, Step{Goto: g})
+		case "repeat":
+			n, err := strconv.Atoi(arg)
+			if err != nil || n <= 0 {
+				return nil, fmt.Errorf("%s:%d: invalid repeat count %q", displayName(filename), offset+i+1, arg)
+			}
+			blocks = append(blocks, textBlock{start: len(s.Steps), repeat: n, line: offset + i + 1})
+		case "macro":
+			fields := strings.Fields(arg)
+			if len(fields) == 0 {
+				return nil, fmt.Errorf("%s:%d: missing name after #macro", displayName(filename), offset+i+1)
+			}
+			if len(blocks) > 0 {
+				return nil, fmt.Errorf("%s:%d: #macro %s must not be inside another block", displayName(filename), offset+i+1, fields[0])
+			}
+			if _, ok := s.Macros[fields[0]]; ok {
+				return nil, fmt.Errorf("%s:%d: macro %s defined twice", displayName(filename), offset+i+1, fields[0])
+			}
+			blocks = append(blocks, textBlock{start: len(s.Steps), macro: fields[0], params: fields[1:], line: offset + i + 1})
+		case "use":
+			s.Steps = append(s.Steps, Step{Use: arg})
+		case "end":
+			if len(blocks) == 0 {
+				return nil, fmt.Errorf("%s:%d: #end without #repeat or #macro", displayName(filename), offset+i+1)
+			}
+			block := blocks[len(blocks)-1]
+			blocks = blocks[:len(blocks)-1]
+			steps := append([]Step(nil), s.Steps[block.start:]...)
+			if block.macro != "" {
+				if s.Macros == nil {
+					s.Macros = map[string]Macro{}
+				}
+				s.Macros[block.macro] = Macro{Params: block.params, Steps: steps}
+				s.Steps = s.Steps[:block.start]
+				break
+			}
+			s.Steps = append(s.Steps[:block.start], Step{Repeat: block.repeat, Steps: steps})
+		case "lua":
+			s.Steps = append(s.Steps, Step{Lua: arg})
+		case "simulate":
+			if _, ok := simulate.Lookup(arg); !ok {
+				return nil, fmt.Errorf("%s:%d: no simulator for %q", displayName(filename), offset+i+1, arg)
+			}
+			s.Steps = append(s.Steps, Step{Simulate: arg})
+		case "annotate":
+			if len(s.Steps) == 0 || s.Steps[len(s.Steps)-1].Text() == "" {
+				return nil, fmt.Errorf("%s:%d: #annotate must follow a command", displayName(filename), offset+i+1)
+			}
+			a, err := parseAnnotation(arg)
+			if err != nil {
+				return nil, fmt.Errorf("%s:%d: %w", displayName(filename), offset+i+1, err)
+			}
+			last := &s.Steps[len(s.Steps)-1]
+			last.Annotate = append(last.Annotate, a)
+		case "cls", "keep":
+			if len(s.Steps) == 0 || s.Steps[len(s.Steps)-1].Text() == "" {
+				return nil, fmt.Errorf("%s:%d: #%s must follow a command", displayName(filename), offset+i+1, name)
+			}
+			cls := name == "cls"
+			s.Steps[len(s.Steps)-1].Clear = &cls
+		case "highlight":
+			if _, err := cli.ParseHighlight(arg); err != nil {
+				return nil, fmt.Errorf("%s:%d: %w", displayName(filename), offset+i+1, err)
+			}
+			s.Highlights = append(s.Highlights, arg)
+		case "include":
+			included, err := l.include(arg, filename)
+			if err != nil {
+				return nil, fmt.Errorf("%s:%d: %w", displayName(filename), offset+i+1, err)
+			}
+			s.Steps = append(s.Steps, included.Steps...)
+			s.Highlights = append(s.Highlights, included.Highlights...)
+			for name, m := range included.Macros {
+				if _, ok := s.Macros[name]; ok {
+					return nil, fmt.Errorf("%s:%d: macro %s defined twice", displayName(filename), offset+i+1, name)
+				}
+				if s.Macros == nil {
+					s.Macros = map[string]Macro{}
+				}
+				s.Macros[name] = m
+			}
+		}
+
+		if len(s.Steps) > first {
+			if stepName != "" {
+				s.Steps[first].Name = stepName
+			}
+			s.Steps[first].Tags = append(s.Steps[first].Tags, stepTags...)
+			s.Steps[first].Requires = append(s.Steps[first].Requires, stepRequires...)
+			if stepOS != nil {
+				s.Steps[first].OS = stepOS
+			}
+			if stepNotes != nil {
+				s.Steps[first].Notes = strings.Join(stepNotes, "\n")
+			}
+			if stepChapter != "" {
+				s.Steps[first].Chapter = stepChapter
+			}
+			stepName, stepTags, stepRequires, stepOS, stepNotes, stepChapter = "", nil, nil, nil, nil, ""
+		}
+	}
+	if stepName != "" {
+		return nil, fmt.Errorf("%s: #name %s must precede a step", displayName(filename), stepName)
+	}
+	if len(stepTags) > 0 {
+		return nil, fmt.Errorf("%s: #tags %s must precede a step", displayName(filename), strings.Join(stepTags, " "))
+	}
+	if len(stepRequires) > 0 {
+		return nil, fmt.Errorf("%s: #requires %s must precede a step", displayName(filename), strings.Join(stepRequires, " "))
+	}
+	if len(stepOS) > 0 {
+		return nil, fmt.Errorf("%s: #os %s must precede a step", displayName(filename), strings.Join(stepOS, " "))
+	}
+	if len(stepNotes) > 0 {
+		return nil, fmt.Errorf("%s: #note %s must precede a step", displayName(filename), stepNotes[0])
+	}
+	if stepChapter != "" {
+		return nil, fmt.Errorf("%s: #chapter %s must precede a step", displayName(filename), stepChapter)
+	}
+	if len(blocks) > 0 {
+		block := blocks[len(blocks)-1]
+		if block.macro != "" {
+			return nil, fmt.Errorf("%s:%d: #macro without #end", displayName(filename), block.line)
+		}
+		return nil, fmt.Errorf("%s:%d: #repeat without #end", displayName(filename), block.line)
+	}
+	if err := s.expand(); err != nil {
+		return nil, fmt.Errorf("%s: %w", displayName(filename), err)
+	}
+	if err := validateStepNames(s.Steps); err != nil {
+		return nil, fmt.Errorf("%s: %w", displayName(filename), err)
+	}
+
+	// The steps gone to by included files may be in the including
+	// file, so the gotos are checked once all files are included
+	if len(l.stack) <= 1 {
+		for i, step := range s.Steps {
+			if step.Goto == nil {
+				continue
+			}
+			if err := s.validateGoto(step.Goto); err != nil {
+				return nil, fmt.Errorf("%s: step %d: %w", displayName(filename), offset+i+1, err)
+			}
+		}
+	}
+	return s, nil
+}
+
+// parseGoto parses the argument of a goto directive: the step gone
+// to, and the optional condition after "if", followed by the question
+// for the key condition (e.g. "retry if failed" or "cleanup if key
+// Skip the details?")
+func parseGoto(arg string) (*Goto, error) {
+	fields := strings.Fields(arg)
+	switch {
+	case len(fields) == 0:
+		return nil, fmt.Errorf("missing step after #goto")
+	case len(fields) == 1:
+		return &Goto{Step: fields[0]}, nil
+	case fields[1] != "if" || len(fields) < 3:
+		return nil, fmt.Errorf("invalid goto %q, expected <step> [if <condition>]", arg)
+	}
+
+	g := &Goto{Step: fields[0], If: fields[2]}
+	if len(fields) > 3 {
+		if g.If != GotoKey {
+			return nil, fmt.Errorf("invalid goto %q, only the key condition has a question", arg)
+		}
+		g.Prompt = strings.Join(fields[3:], " ")
+	}
+	return g, nil
+}
+
+// textBlock is a repeat block or the definition of a macro with
+// the parameters in a plain text script, from the index of its first
+// step, opened by the directive on the line
+type textBlock struct {
+	start  int
+	repeat int
+	macro  string
+	params []string
+	line   int
+}
+
+// parseAnnotation parses the argument of an annotate directive: the
+// mark, the pattern of the line, and the optional text next to the
+// mark (e.g. `arrow "Running$" all pods are up`)
+func parseAnnotation(arg string) (Annotation, error) {
+	mark, rest, _ := strings.Cut(arg, " ")
+	pattern, text, err := cli.CutPattern(rest)
+	if err != nil {
+		return Annotation{}, fmt.Errorf("invalid annotation %q: %w", arg, err)
+	}
+	a := Annotation{Mark: mark, Match: pattern, Text: text}
+	if err := a.validate(); err != nil {
+		return Annotation{}, err
+	}
+	return a, nil
+}
+
+// include loads the steps and the highlight rules of an included
+// file. Relative paths are resolved from the directory of the
+// including file
+func (l *loader) include(path, from string) (*Scenario, error) {
+	if path == "" {
+		return nil, fmt.Errorf("missing file name after #include")
+	}
+	if !filepath.IsAbs(path) && from != "" {
+		path = filepath.Join(filepath.Dir(from), path)
+	}
+	slog.Debug("including script", "file", path, "from", displayName(from))
+
+	return l.load(path)
+}
+
+// displayName returns the name of the script used in error messages
+func displayName(filename string) string {
+	if filename == "" {
+		return "<input>"
+	}
+	return filename
+}
@@ -0,0 +1,486 @@
+package script_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/bitcanon/autotyper/script"
+)
+
+// writeFiles creates the files in a temporary directory
+// and returns the path of the directory
+func writeFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("failed to write file: %v", err)
+		}
+	}
+	return dir
+}
+
+// TestParseTextComments tests that lines starting with "#" that
+// are not directives are kept as commands
+func TestParseTextComments(t *testing.T) {
+	s, err := script.ParseText("#comment\n#includes nothing")
+	if err != nil {
+		t.Fatalf("expected no error, but got %v", err)
+	}
+	expected := []string{"#comment", "#includes nothing"}
+	if !reflect.DeepEqual(s.Commands(), expected) {
+		t.Errorf("expected %q, but got %q", expected, s.Commands())
+	}
+}
+
+// TestInclude tests the #include directive in text scripts
+func TestInclude(t *testing.T) {
+	t.Run("RelativePaths", func(t *testing.T) {
+		dir := writeFiles(t, map[string]string{
+			"demo.txt":             "#include common/setup.txt\necho demo\n#include common/teardown.json",
+			"common/setup.txt":     "echo setup\n#include helper.txt",
+			"common/helper.txt":    "echo helper",
+			"common/teardown.json": `{"steps": [{"command": "echo teardown"}]}`,
+		})
+
+		s, err := script.Load(filepath.Join(dir, "demo.txt"))
+		if err != nil {
+			t.Fatalf("expected no error, but got %v", err)
+		}
+		expected := []string{"echo setup", "echo helper", "echo demo", "echo teardown"}
+		if !reflect.DeepEqual(s.Commands(), expected) {
+			t.Errorf("expected %q, but got %q", expected, s.Commands())
+		}
+	})
+
+	t.Run("Highlights", func(t *testing.T) {
+		dir := writeFiles(t, map[string]string{
+			"demo.txt":   "#include common.txt\n#highlight WARN style=yellow\nmake",
+			"common.txt": `#highlight "error|failed" style=red-bold`,
+		})
+
+		s, err := script.Load(filepath.Join(dir, "demo.txt"))
+		if err != nil {
+			t.Fatalf("expected no error, but got %v", err)
+		}
+		expected := []string{`"error|failed" style=red-bold`, "WARN style=yellow"}
+		if !reflect.DeepEqual(s.Highlights, expected) {
+			t.Errorf("expected %q, but got %q", expected, s.Highlights)
+		}
+	})
+
+	t.Run("Verified", func(t *testing.T) {
+		dir := writeFiles(t, map[string]string{
+			"demo.txt":     "#include setup.txt\r\necho demo\r\n\r\n",
+			"setup.txt":    "echo setup",
+			"tampered.txt": "#include setup.txt\nrm -rf /",
+		})
+		defer func() { script.Verify = nil }()
+		var verified []string
+		script.Verify = func(filename string, data []byte) error {
+			verified = append(verified, filepath.Base(filename))
+			if strings.Contains(string(data), "rm -rf") {
+				return os.ErrPermission
+			}
+			return nil
+		}
+
+		s, err := script.Load(filepath.Join(dir, "demo.txt"))
+		if err != nil {
+			t.Fatalf("expected no error, but got %v", err)
+		}
+		expected := []string{"echo setup", "echo demo"}
+		if !reflect.DeepEqual(s.Commands(), expected) {
+			t.Errorf("expected %q, but got %q", expected, s.Commands())
+		}
+		if !reflect.DeepEqual(verified, []string{"demo.txt", "setup.txt"}) {
+			t.Errorf("expected the files to be verified, but got %q", verified)
+		}
+		if _, err := script.Load(filepath.Join(dir, "tampered.txt")); err == nil {
+			t.Errorf("expected error for a file not verified, but got nil")
+		}
+	})
+
+	t.Run("SameFileTwice", func(t *testing.T) {
+		dir := writeFiles(t, map[string]string{
+			"demo.txt":  "#include clear.txt\necho demo\n#include clear.txt",
+			"clear.txt": "echo clear",
+		})
+
+		s, err := script.Load(filepath.Join(dir, "demo.txt"))
+		if err != nil {
+			t.Fatalf("expected no error, but got %v", err)
+		}
+		expected := []string{"echo clear", "echo demo", "echo clear"}
+		if !reflect.DeepEqual(s.Commands(), expected) {
+			t.Errorf("expected %q, but got %q", expected, s.Commands())
+		}
+	})
+
+	t.Run("Cycle", func(t *testing.T) {
+		dir := writeFiles(t, map[string]string{
+			"a.txt": "echo a\n#include b.txt",
+			"b.txt": "#include a.txt",
+		})
+
+		_, err := script.Load(filepath.Join(dir, "a.txt"))
+		if err == nil || !strings.Contains(err.Error(), "include cycle") {
+			t.Errorf("expected an include cycle error, but got %v", err)
+		}
+	})
+
+	t.Run("MissingFile", func(t *testing.T) {
+		dir := writeFiles(t, map[string]string{
+			"demo.txt": "echo demo\n#include missing.txt",
+		})
+
+		_, err := script.Load(filepath.Join(dir, "demo.txt"))
+		if err == nil || !strings.Contains(err.Error(), "demo.txt:2:") {
+			t.Errorf("expected an error with the line number, but got %v", err)
+		}
+	})
+
+	t.Run("MissingFileName", func(t *testing.T) {
+		if _, err := script.ParseText("#include"); err == nil {
+			t.Errorf("expected error, but got nil")
+		}
+	})
+}
+
+// TestThinkSteps tests that think steps are parsed and validated
+func TestThinkSteps(t *testing.T) {
+	s, err := script.ParseText("#think 1500\nls")
+	if err != nil {
+		t.Fatalf("expected no error, but got %v", err)
+	}
+	if len(s.Steps) != 2 || s.Steps[0].Think != 1500 {
+		t.Errorf("expected a think step, but got %+v", s.Steps)
+	}
+
+	for _, input := range []string{"#think", "#think soon", "#think -5", "#think 0"} {
+		if _, err := script.ParseText(input); err == nil {
+			t.Errorf("%q: expected error for an invalid delay, but got nil", input)
+		}
+	}
+	if _, err := script.ParseJSON([]byte(`{"steps": [{"think": 500, "command": "ls"}]}`)); err == nil {
+		t.Errorf("expected error for a step with both think and command, but got nil")
+	}
+	if _, err := script.ParseJSON([]byte(`{"steps": [{"think": -500}]}`)); err == nil {
+		t.Errorf("expected error for a negative think delay, but got nil")
+	}
+}
+
+// TestWaitAudioSteps tests that wait-audio steps are validated and
+// that the clips are found next to the file naming them
+func TestWaitAudioSteps(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"demo.txt":        "#include parts/intro.txt\n#wait-audio outro.mp3",
+		"parts/intro.txt": "#wait-audio intro.WAV\nls",
+	})
+	s, err := script.Load(filepath.Join(dir, "demo.txt"))
+	if err != nil {
+		t.Fatalf("expected no error, but got %v", err)
+	}
+	expected := []string{filepath.Join(dir, "parts", "intro.WAV"), "", filepath.Join(dir, "outro.mp3")}
+	if len(s.Steps) != len(expected) {
+		t.Fatalf("expected %d steps, but got %+v", len(expected), s.Steps)
+	}
+	for i, file := range expected {
+		if file != "" && s.AudioFile(s.Steps[i].WaitAudio) != file {
+			t.Errorf("step %d: expected %q, but got %q", i+1, file, s.AudioFile(s.Steps[i].WaitAudio))
+		}
+	}
+
+	for _, input := range []string{"#wait-audio", "#wait-audio intro.ogg"} {
+		if _, err := script.ParseText(input); err == nil {
+			t.Errorf("%q: expected error for an invalid clip, but got nil", input)
+		}
+	}
+	if _, err := script.ParseJSON([]byte(`{"steps": [{"wait-audio": "intro.mp3", "command": "ls"}]}`)); err == nil {
+		t.Errorf("expected error for a step with both wait-audio and command, but got nil")
+	}
+}
+
+// TestImageSteps tests that image steps are validated and
+// that the images are found next to the file naming them
+func TestImageSteps(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"demo.txt":        "#include parts/intro.txt\n#image diagram.PNG",
+		"parts/intro.txt": "#image screenshot.jpg\nls",
+	})
+	s, err := script.Load(filepath.Join(dir, "demo.txt"))
+	if err != nil {
+		t.Fatalf("expected no error, but got %v", err)
+	}
+	expected := []string{filepath.Join(dir, "parts", "screenshot.jpg"), "", filepath.Join(dir, "diagram.PNG")}
+	if len(s.Steps) != len(expected) {
+		t.Fatalf("expected %d steps, but got %+v", len(expected), s.Steps)
+	}
+	for i, file := range expected {
+		if file != "" && s.ImageFile(s.Steps[i].Image) != file {
+			t.Errorf("step %d: expected %q, but got %q", i+1, file, s.ImageFile(s.Steps[i].Image))
+		}
+	}
+
+	for _, input := range []string{"#image", "#image diagram.svg"} {
+		if _, err := script.ParseText(input); err == nil {
+			t.Errorf("%q: expected error for an invalid image, but got nil", input)
+		}
+	}
+	if _, err := script.ParseJSON([]byte(`{"steps": [{"image": "diagram.png", "command": "ls"}]}`)); err == nil {
+		t.Errorf("expected error for a step with both image and command, but got nil")
+	}
+}
+
+// TestQRCodeSteps tests that qrcode steps are parsed, and that
+// texts too long for a QR code are refused
+func TestQRCodeSteps(t *testing.T) {
+	s, err := script.ParseText("ls\n#qrcode https://github.com/bitcanon/autotyper")
+	if err != nil {
+		t.Fatalf("expected no error, but got %v", err)
+	}
+	if len(s.Steps) != 2 || s.Steps[1].QRCode != "https://github.com/bitcanon/autotyper" {
+		t.Fatalf("expected a qrcode step, but got %+v", s.Steps)
+	}
+
+	for _, input := range []string{"#qrcode", "#qrcode https://example.com/" + strings.Repeat("a", 200)} {
+		if _, err := script.ParseText(input); err == nil {
+			t.Errorf("%q: expected error, but got nil", input)
+		}
+	}
+	if _, err := script.ParseJSON([]byte(`{"steps": [{"qrcode": "https://example.com", "command": "ls"}]}`)); err == nil {
+		t.Errorf("expected error for a step with both qrcode and command, but got nil")
+	}
+}
+
+// TestNoteDirectives tests that the notes are given to the next
+// step, one line per directive
+func TestNoteDirectives(t *testing.T) {
+	s, err := script.ParseText("#note Explain the flags\n#note Then the output\nls -la\necho done")
+	if err != nil {
+		t.Fatalf("expected no error, but got %v", err)
+	}
+	if len(s.Steps) != 2 || s.Steps[0].Notes != "Explain the flags\nThen the output" || s.Steps[1].Notes != "" {
+		t.Errorf("expected the notes on the first step, but got %+v", s.Steps)
+	}
+
+	if _, err := script.ParseText("ls\n#note Too late"); err == nil {
+		t.Errorf("expected error for a note without a step, but got nil")
+	}
+}
+
+// TestChapterDirectives tests that the title of the chapter is
+// given to the next step
+func TestChapterDirectives(t *testing.T) {
+	s, err := script.ParseText("#chapter Setting up\nls -la\necho done\n#chapter Deploying the app\n#think 500")
+	if err != nil {
+		t.Fatalf("expected no error, but got %v", err)
+	}
+	if len(s.Steps) != 3 || s.Steps[0].Chapter != "Setting up" || s.Steps[1].Chapter != "" || s.Steps[2].Chapter != "Deploying the app" {
+		t.Errorf("expected the chapters on the first and the last step, but got %+v", s.Steps)
+	}
+
+	for _, input := range []string{"#chapter\nls", "ls\n#chapter Too late"} {
+		if _, err := script.ParseText(input); err == nil {
+			t.Errorf("%q: expected error, but got nil", input)
+		}
+	}
+}
+
+// TestMotdSteps tests that motd steps are parsed and validated
+func TestMotdSteps(t *testing.T) {
+	s, err := script.ParseText("#motd\n#motd sysinfo\nls")
+	if err != nil {
+		t.Fatalf("expected no error, but got %v", err)
+	}
+	if len(s.Steps) != 3 || s.Steps[0].Motd != script.MotdMessage || s.Steps[1].Motd != script.MotdSysinfo {
+		t.Errorf("expected two motd steps, but got %+v", s.Steps)
+	}
+
+	if _, err := script.ParseText("#motd fortune"); err == nil {
+		t.Errorf("expected error for an unknown motd, but got nil")
+	}
+	if _, err := script.ParseJSON([]byte(`{"steps": [{"motd": "message", "command": "ls"}]}`)); err == nil {
+		t.Errorf("expected error for a step with both motd and command, but got nil")
+	}
+	if _, err := script.ParseJSON([]byte(`{"steps": [{"motd": "fortune"}]}`)); err == nil {
+		t.Errorf("expected error for an unknown motd, but got nil")
+	}
+}
+
+// TestSimulateSteps tests that simulated commands are parsed and
+// validated, with the parameters of the simulator
+func TestSimulateSteps(t *testing.T) {
+	s, err := script.ParseText("#simulate ping dns.google\nls")
+	if err != nil {
+		t.Fatalf("expected no error, but got %v", err)
+	}
+	if len(s.Steps) != 2 || s.Steps[0].Simulate != "ping dns.google" || s.Steps[0].Text() != "ping dns.google" {
+		t.Errorf("expected a simulated ping, but got %+v", s.Steps)
+	}
+
+	if _, err := script.ParseText("#simulate traceroute dns.google"); err == nil {
+		t.Errorf("expected error for a command without simulator, but got nil")
+	}
+	if _, err := script.ParseJSON([]byte(`{"steps": [{"simulate": "ping dns.google", "output": "pong"}]}`)); err == nil {
+		t.Errorf("expected error for a step with both simulate and output, but got nil")
+	}
+
+	s, err = script.ParseYAML([]byte("steps:\n  - simulate: ping dns.google\n    params:\n      loss: 25\n"))
+	if err != nil {
+		t.Fatalf("expected no error, but got %v", err)
+	}
+	if loss := s.Steps[0].Params.Float("loss", 0); loss != 25 {
+		t.Errorf("expected loss 25, but got %v", loss)
+	}
+}
+
+// TestLuaSteps tests that Lua steps are parsed and that their
+// code is checked for syntax errors
+func TestLuaSteps(t *testing.T) {
+	s, err := script.ParseText(`#lua for i = 1, 3 do demo.run("echo " .. i) end`)
+	if err != nil {
+		t.Fatalf("expected no error, but got %v", err)
+	}
+	if len(s.Steps) != 1 || !strings.HasPrefix(s.Steps[0].Lua, "for i = 1, 3") {
+		t.Errorf("expected a Lua step, but got %+v", s.Steps)
+	}
+
+	if _, err := script.ParseJSON([]byte(`{"steps": [{"lua": "for i = 1 do"}]}`)); err == nil {
+		t.Errorf("expected error for invalid Lua code, but got nil")
+	}
+	if _, err := script.ParseJSON([]byte(`{"steps": [{"lua": "x = 1", "command": "ls"}]}`)); err == nil {
+		t.Errorf("expected error for a step with both Lua code and command, but got nil")
+	}
+}
+
+// TestHighlights tests that the highlight rules of the scenario
+// and of the steps are checked
+func TestHighlights(t *testing.T) {
+	s, err := script.ParseYAML([]byte("highlights:\n  - '\"error|failed\" style=red-bold'\nsteps:\n  - command: make\n    highlight: [OK style=green line]\n"))
+	if err != nil {
+		t.Fatalf("expected no error, but got %v", err)
+	}
+	if len(s.Highlights) != 1 || len(s.Steps[0].Highlight) != 1 || s.Steps[0].Highlight[0] != "OK style=green line" {
+		t.Errorf("expected the highlight rules, but got %+v", s)
+	}
+
+	for _, input := range []string{
+		"#highlight",
+		"#highlight error style=rainbow",
+		"#highlight error\n#highlight (oops",
+	} {
+		if _, err := script.ParseText(input); err == nil {
+			t.Errorf("%q: expected error, but got nil", input)
+		}
+	}
+	if _, err := script.ParseJSON([]byte(`{"steps": [{"command": "make", "highlight": ["error size=2"]}]}`)); err == nil {
+		t.Errorf("expected error for an unknown option, but got nil")
+	}
+	if _, err := script.ParseJSON([]byte(`{"highlights": ["\"unclosed"], "steps": [{"command": "make"}]}`)); err == nil {
+		t.Errorf("expected error for a missing quote, but got nil")
+	}
+}
+
+// TestAnnotations tests that annotate directives are added to the
+// previous command, and that the annotations are checked
+func TestAnnotations(t *testing.T) {
+	s, err := script.ParseText("kubectl get pods\n#annotate box \"web-\\d  Running\" all pods are up\n#annotate arrow Pending")
+	if err != nil {
+		t.Fatalf("expected no error, but got %v", err)
+	}
+	expected := []script.Annotation{
+		{Mark: script.MarkBox, Match: `web-\d  Running`, Text: "all pods are up"},
+		{Mark: script.MarkArrow, Match: "Pending"},
+	}
+	if len(s.Steps) != 1 || !reflect.DeepEqual(s.Steps[0].Annotate, expected) {
+		t.Errorf("expected %+v, but got %+v", expected, s.Steps)
+	}
+
+	for _, input := range []string{
+		"#annotate box Running",
+		"#think 500\n#annotate box Running",
+		"ls\n#annotate circle Running",
+		"ls\n#annotate arrow",
+		"ls\n#annotate arrow (oops",
+	} {
+		if _, err := script.ParseText(input); err == nil {
+			t.Errorf("%q: expected error, but got nil", input)
+		}
+	}
+	for _, input := range []string{
+		`{"steps": [{"command": "ls", "annotate": [{"mark": "box"}]}]}`,
+		`{"steps": [{"command": "ls", "annotate": [{"mark": "box", "match": "a", "line": 1}]}]}`,
+		`{"steps": [{"command": "ls", "annotate": [{"mark": "box", "line": 1, "duration": -1}]}]}`,
+		`{"steps": [{"command": "ls", "annotate": [{"mark": "box", "line": 1, "style": "plaid"}]}]}`,
+	} {
+		if _, err := script.ParseJSON([]byte(input)); err == nil {
+			t.Errorf("%s: expected error, but got nil", input)
+		}
+	}
+}
+
+// TestGotoSteps tests that goto steps are parsed and validated
+func TestGotoSteps(t *testing.T) {
+	s, err := script.ParseText("#name retry\nmake test\n#goto retry if failed\n#goto 1 if key Run it again?\n#goto 5\necho done")
+	if err != nil {
+		t.Fatalf("expected no error, but got %v", err)
+	}
+	expected := []script.Goto{
+		{Step: "retry", If: script.GotoFailed},
+		{Step: "1", If: script.GotoKey, Prompt: "Run it again?"},
+		{Step: "5"},
+	}
+	for i, g := range expected {
+		if step := s.Steps[i+1]; step.Goto == nil || *step.Goto != g {
+			t.Errorf("step %d: expected goto %+v, but got %+v", i+2, g, step.Goto)
+		}
+	}
+
+	for _, input := range []string{"#goto", "#goto missing", "#goto 9", "echo one\n#goto 1 if maybe", "echo one\n#goto 1 unless failed", "echo one\n#goto 1 if failed Really?"} {
+		if _, err := script.ParseText(input); err == nil {
+			t.Errorf("%q: expected error, but got nil", input)
+		}
+	}
+	if _, err := script.ParseJSON([]byte(`{"steps": [{"command": "ls"}, {"goto": {"step": "1", "if": "2"}}]}`)); err != nil {
+		t.Errorf("expected no error for an exit code condition, but got %v", err)
+	}
+	if _, err := script.ParseJSON([]byte(`{"steps": [{"command": "ls", "goto": {"step": "1"}}]}`)); err == nil {
+		t.Errorf("expected error for a step with both goto and command, but got nil")
+	}
+}
+
+// TestClearDirectives tests that the cls and keep directives force
+// or suppress clearing the screen after the previous command
+func TestClearDirectives(t *testing.T) {
+	s, err := script.ParseText("ls\n#cls\necho one\n#keep\necho two")
+	if err != nil {
+		t.Fatalf("expected no error, but got %v", err)
+	}
+	if len(s.Steps) != 3 {
+		t.Fatalf("expected 3 steps, but got %+v", s.Steps)
+	}
+	for i, expected := range []string{"true", "false", "<nil>"} {
+		got := "<nil>"
+		if s.Steps[i].Clear != nil {
+			got = fmt.Sprint(*s.Steps[i].Clear)
+		}
+		if got != expected {
+			t.Errorf("step %d: expected clear %s, but got %s", i+1, expected, got)
+		}
+	}
+
+	for _, input := range []string{"#cls\nls", "#think 500\n#keep"} {
+		if _, err := script.ParseText(input); err == nil {
+			t.Errorf("%q: expected error, but got nil", input)
+		}
+	}
+}
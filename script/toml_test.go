@@ -0,0 +1,59 @@
+package script_test
+
+import (
+	"testing"
+
+	"github.com/bitcanon/autotyper/script"
+)
+
+// TestParseTOML tests parsing of valid and invalid TOML scenarios
+func TestParseTOML(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		wantErr bool
+	}{
+		{
+			name: "ValidScenario",
+			input: `
+[timing]
+char-delay = 25
+
+[[steps]]
+command = "ls"
+caption = "List the files"
+`,
+		},
+		{
+			name:    "UnknownField",
+			input:   "[[steps]]\ncommand = \"ls\"\ncomand = \"pwd\"\n",
+			wantErr: true,
+		},
+		{
+			name:    "UnknownShell",
+			input:   "[prompt]\nshell = \"zsh\"\n\n[[steps]]\ncommand = \"ls\"\n",
+			wantErr: true,
+		},
+		{
+			name:  "SimulatedCommand",
+			input: "[[steps]]\nsimulate = \"ping dns.google\"\nparams = { count = 2, latency = 12.5 }\n",
+		},
+		{
+			name:    "InvalidTOML",
+			input:   "[[steps]\n",
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := script.ParseTOML([]byte(test.input))
+			if test.wantErr && err == nil {
+				t.Errorf("expected error, but got nil")
+			}
+			if !test.wantErr && err != nil {
+				t.Errorf("expected no error, but got %v", err)
+			}
+		})
+	}
+}
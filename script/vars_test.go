@@ -0,0 +1,61 @@
+package script_test
+
+import (
+	"testing"
+
+	"github.com/bitcanon/autotyper/script"
+)
+
+// TestExpand tests that references to known variables
+// are replaced and unknown references are kept
+func TestExpand(t *testing.T) {
+	vars := map[string]string{"ticket": "JIRA-42", "host": "box"}
+
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			name:     "KnownVariables",
+			input:    "ssh ${host} && git checkout ${ticket}",
+			expected: "ssh box && git checkout JIRA-42",
+		},
+		{
+			name:     "UnknownVariable",
+			input:    "echo ${HOME} $host",
+			expected: "echo ${HOME} $host",
+		},
+		{
+			name:     "NoVariables",
+			input:    "ping one.one.one.one",
+			expected: "ping one.one.one.one",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := script.Expand(test.input, vars); got != test.expected {
+				t.Errorf("expected %q, but got %q", test.expected, got)
+			}
+		})
+	}
+}
+
+// TestAskSteps tests that ask steps are parsed and validated
+func TestAskSteps(t *testing.T) {
+	s, err := script.ParseText("#ask ticket\necho ${ticket}")
+	if err != nil {
+		t.Fatalf("expected no error, but got %v", err)
+	}
+	if len(s.Steps) != 2 || s.Steps[0].Ask != "ticket" {
+		t.Errorf("expected an ask step, but got %+v", s.Steps)
+	}
+
+	if _, err := script.ParseText("#ask not-a-name"); err == nil {
+		t.Errorf("expected error for an invalid name, but got nil")
+	}
+	if _, err := script.ParseJSON([]byte(`{"steps": [{"ask": "ticket", "command": "ls"}]}`)); err == nil {
+		t.Errorf("expected error for a step with both ask and command, but got nil")
+	}
+}
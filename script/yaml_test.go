@@ -0,0 +1,52 @@
+package script_test
+
+import (
+	"testing"
+
+	"github.com/bitcanon/autotyper/script"
+)
+
+// TestParseYAML tests parsing of valid and invalid YAML scenarios
+func TestParseYAML(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		wantErr bool
+	}{
+		{
+			name:  "ValidScenario",
+			input: "timing:\n  char-delay: 25\nsteps:\n  - command: ls\n    caption: List the files\n",
+		},
+		{
+			name:  "EmptyScenario",
+			input: "",
+		},
+		{
+			name:    "UnknownField",
+			input:   "steps:\n  - command: ls\n    comand: pwd\n",
+			wantErr: true,
+		},
+		{
+			name:    "MissingCommand",
+			input:   "steps:\n  - caption: Nothing to type\n",
+			wantErr: true,
+		},
+		{
+			name:    "InvalidYAML",
+			input:   "steps: [",
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := script.ParseYAML([]byte(test.input))
+			if test.wantErr && err == nil {
+				t.Errorf("expected error, but got nil")
+			}
+			if !test.wantErr && err != nil {
+				t.Errorf("expected no error, but got %v", err)
+			}
+		})
+	}
+}
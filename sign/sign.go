@@ -0,0 +1,119 @@
+/*
+Copyright © 2023 Mikael Schultz <bitcanon@proton.me>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package sign
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+
+	"aead.dev/minisign"
+)
+
+// Extension is the extension of the signature files, written next to
+// the signed files (e.g. demo.yaml.minisig), like minisign does
+const Extension = ".minisig"
+
+// Errors returned when a script is not trusted
+var (
+	ErrUnsigned = errors.New("the script is not signed")
+	ErrInvalid  = errors.New("the signature does not match a trusted key, the script may have been tampered with")
+)
+
+// Policy decides which scripts are trusted, from their signatures made
+// with minisign or "autotyper sign". Signed scripts must be signed with
+// one of the keys, and unsigned scripts are refused if Require is set
+type Policy struct {
+	Keys    []minisign.PublicKey
+	Require bool
+}
+
+// Check checks the contents of the script file against the
+// signature in the file next to it, if there is one
+func (p Policy) Check(filename string, data []byte) error {
+	signature, err := os.ReadFile(filename + Extension)
+	if errors.Is(err, fs.ErrNotExist) {
+		signature, err = nil, nil
+	}
+	if err != nil {
+		return err
+	}
+	return p.Verify(filename, data, signature)
+}
+
+// Verify checks the contents of the named script against the
+// signature, or nil if the script is not signed
+func (p Policy) Verify(name string, data, signature []byte) error {
+	if signature == nil {
+		if p.Require {
+			return fmt.Errorf("%s: %w", name, ErrUnsigned)
+		}
+		return nil
+	}
+	if len(p.Keys) == 0 && !p.Require {
+		slog.Debug("not verifying signature without trusted keys", "script", name)
+		return nil
+	}
+
+	for _, key := range p.Keys {
+		if minisign.Verify(key, data, signature) {
+			slog.Debug("verified signature", "script", name, "key", fmt.Sprintf("%X", key.ID()))
+			return nil
+		}
+	}
+	return fmt.Errorf("%s: %w", name, ErrInvalid)
+}
+
+// LoadPublicKey returns the public key in the file, or the key itself
+// (e.g. "RWQf6LRCGA9i53mlYecO4IzT51TGPpvWucNSCh1CBM0QTaLn73Y7GFO3")
+func LoadPublicKey(key string) (minisign.PublicKey, error) {
+	if _, err := os.Stat(key); err == nil {
+		return minisign.PublicKeyFromFile(key)
+	}
+	var public minisign.PublicKey
+	if err := public.UnmarshalText([]byte(key)); err != nil {
+		return minisign.PublicKey{}, fmt.Errorf("invalid public key %q: %w", key, err)
+	}
+	return public, nil
+}
+
+// Sign returns the signature of the contents of the named
+// script, with the time and the name of the file as the
+// trusted comment, like minisign does
+func Sign(name string, data []byte, key minisign.PrivateKey) []byte {
+	comment := fmt.Sprintf("timestamp:%d\tfile:%s", time.Now().Unix(), filepath.Base(name))
+	return minisign.SignWithComments(key, data, comment, "signature from autotyper secret key")
+}
+
+// SignFile signs the script file, and writes the
+// signature to the file next to it
+func SignFile(filename string, key minisign.PrivateKey) error {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filename+Extension, Sign(filename, data, key), 0644)
+}
@@ -0,0 +1,103 @@
+package sign_test
+
+import (
+	"crypto/rand"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"aead.dev/minisign"
+	"github.com/bitcanon/autotyper/sign"
+)
+
+// TestPolicy tests that signed scripts are only trusted if they are
+// signed with a trusted key, and unsigned scripts only if allowed
+func TestPolicy(t *testing.T) {
+	public, private, err := minisign.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate a key: %v", err)
+	}
+	other, stranger, err := minisign.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate a key: %v", err)
+	}
+
+	data := []byte("echo hello\n")
+	tests := []struct {
+		name      string
+		policy    sign.Policy
+		data      []byte
+		signature []byte
+		expected  error
+	}{
+		{name: "Signed", policy: sign.Policy{Keys: []minisign.PublicKey{other, public}, Require: true}, data: data, signature: sign.Sign("demo.txt", data, private)},
+		{name: "Tampered", policy: sign.Policy{Keys: []minisign.PublicKey{public}}, data: []byte("rm -rf /\n"), signature: sign.Sign("demo.txt", data, private), expected: sign.ErrInvalid},
+		{name: "UntrustedKey", policy: sign.Policy{Keys: []minisign.PublicKey{public}}, data: data, signature: sign.Sign("demo.txt", data, stranger), expected: sign.ErrInvalid},
+		{name: "Unsigned", policy: sign.Policy{Keys: []minisign.PublicKey{public}}, data: data},
+		{name: "UnsignedRequired", policy: sign.Policy{Keys: []minisign.PublicKey{public}, Require: true}, data: data, expected: sign.ErrUnsigned},
+		{name: "NoTrustedKeys", policy: sign.Policy{}, data: data, signature: sign.Sign("demo.txt", data, stranger)},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := test.policy.Verify("demo.txt", test.data, test.signature)
+			if !errors.Is(err, test.expected) {
+				t.Errorf("expected %v, but got %v", test.expected, err)
+			}
+		})
+	}
+}
+
+// TestSignFile tests that the signature written next to the
+// file is checked, and that a modified file is refused
+func TestSignFile(t *testing.T) {
+	public, private, err := minisign.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate a key: %v", err)
+	}
+	filename := filepath.Join(t.TempDir(), "demo.yaml")
+	if err := os.WriteFile(filename, []byte("steps:\n  - command: ls\n"), 0644); err != nil {
+		t.Fatalf("failed to write the script: %v", err)
+	}
+	if err := sign.SignFile(filename, private); err != nil {
+		t.Fatalf("expected no error, but got %v", err)
+	}
+
+	policy := sign.Policy{Keys: []minisign.PublicKey{public}, Require: true}
+	data, _ := os.ReadFile(filename)
+	if err := policy.Check(filename, data); err != nil {
+		t.Errorf("expected no error, but got %v", err)
+	}
+	if err := policy.Check(filename, append(data, "  - command: rm -rf /\n"...)); !errors.Is(err, sign.ErrInvalid) {
+		t.Errorf("expected ErrInvalid, but got %v", err)
+	}
+	if err := policy.Check(filepath.Join(t.TempDir(), "other.yaml"), data); !errors.Is(err, sign.ErrUnsigned) {
+		t.Errorf("expected ErrUnsigned, but got %v", err)
+	}
+}
+
+// TestLoadPublicKey tests that public keys are read from
+// files or given as text, and invalid keys are rejected
+func TestLoadPublicKey(t *testing.T) {
+	public, _, err := minisign.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate a key: %v", err)
+	}
+	text, _ := public.MarshalText()
+	filename := filepath.Join(t.TempDir(), "minisign.pub")
+	os.WriteFile(filename, text, 0644)
+
+	for _, key := range []string{public.String(), filename} {
+		loaded, err := sign.LoadPublicKey(key)
+		if err != nil {
+			t.Fatalf("%s: expected no error, but got %v", key, err)
+		}
+		if !loaded.Equal(public) {
+			t.Errorf("%s: expected the key %s, but got %s", key, public, loaded)
+		}
+	}
+	if _, err := sign.LoadPublicKey("not a key"); err == nil {
+		t.Errorf("expected error for an invalid key, but got nil")
+	}
+}
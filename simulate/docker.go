@@ -0,0 +1,432 @@
+/*
+Copyright © 2023 Mikael Schultz <bitcanon@proton.me>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package simulate
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Define the default durations of the docker commands in milliseconds
+const (
+	defaultPullDuration  = 5000
+	defaultBuildDuration = 8000
+)
+
+// frameDuration is the time between the redraws of the progress
+const frameDuration = 100 * time.Millisecond
+
+// defaultDockerfile is built when the build context has no Dockerfile
+const defaultDockerfile = `FROM node:20-alpine
+WORKDIR /app
+COPY package*.json ./
+RUN npm ci
+COPY . .
+CMD ["node", "server.js"]`
+
+// docker simulates docker pull and docker build, redrawing the
+// progress of the layers and the build steps in place, with the
+// parameters:
+//
+//	duration  the time of the command in milliseconds (5000 for
+//	          pull, 8000 for build)
+//	layers    the number of layers pulled (3 to 6, by image)
+type docker struct{}
+
+// command returns the docker command (pull or build) and its arguments
+func (docker) command(sim Simulation) (string, []string) {
+	args := sim.args()[1:]
+	if len(args) > 1 && (args[0] == "image" && args[1] == "pull" || args[0] == "buildx" && args[1] == "build") {
+		args = args[1:]
+	}
+	if len(args) == 0 {
+		return "", nil
+	}
+	return args[0], args[1:]
+}
+
+// Duration returns the configured time of the command
+func (d docker) Duration(sim Simulation) time.Duration {
+	switch command, _ := d.command(sim); command {
+	case "pull":
+		return time.Duration(sim.Params.Int("duration", defaultPullDuration)) * time.Millisecond
+	case "build":
+		return time.Duration(sim.Params.Int("duration", defaultBuildDuration)) * time.Millisecond
+	default:
+		return 0
+	}
+}
+
+// Simulate writes the output of docker pull or docker build
+func (d docker) Simulate(ctx context.Context, sim Simulation, out io.Writer) error {
+	command, args := d.command(sim)
+	switch command {
+	case "pull":
+		return d.pull(ctx, sim, args, out)
+	case "build":
+		return d.build(ctx, sim, args, out)
+	case "":
+		fmt.Fprintln(out, "Usage:  docker [OPTIONS] COMMAND")
+	default:
+		fmt.Fprintf(out, "docker: '%s' is not a docker command.\nSee 'docker --help'\n", command)
+	}
+	return nil
+}
+
+// layer is a layer of a pulled image, with the times it is
+// downloaded and extracted from 0 to 1
+type layer struct {
+	id                   string
+	size                 float64 // bytes
+	download, downloaded float64
+	extract, extracted   float64
+}
+
+// pull writes the output of docker pull, with the progress of the
+// downloads and the extractions of the layers
+func (d docker) pull(ctx context.Context, sim Simulation, args []string, out io.Writer) error {
+	image := ""
+	for _, arg := range args {
+		if !strings.HasPrefix(arg, "-") {
+			image = arg
+		}
+	}
+	if image == "" {
+		fmt.Fprintln(out, `"docker pull" requires exactly 1 argument.`)
+		return nil
+	}
+
+	ref := canonicalImage(image)
+	name, tag := ref[:strings.LastIndex(ref, ":")], ref[strings.LastIndex(ref, ":")+1:]
+	if !strings.Contains(image[strings.LastIndex(image, "/")+1:], ":") {
+		fmt.Fprintln(out, "Using default tag: latest")
+	}
+	fmt.Fprintf(out, "%s: Pulling from %s\n", tag, strings.TrimPrefix(name, "docker.io/"))
+
+	sum := sha256.Sum256([]byte(ref))
+	layers := make([]layer, sim.Params.Int("layers", 3+int(sum[0])%4))
+	for i := range layers {
+		h := sha256.Sum256([]byte(fmt.Sprintf("%s#%d", ref, i)))
+		layers[i].id = fmt.Sprintf("%x", h[:6])
+
+		// The base layer is large, the others get smaller
+		layers[i].size = float64(1+int(h[6])%100) * 1e5 / float64(i+1)
+		if i == 0 {
+			layers[i].size = float64(25+int(h[6])%10) * 1e6
+		}
+	}
+	schedule(layers)
+
+	// Redraw the layers until all are extracted
+	frames := max(1, int(d.Duration(sim)/frameDuration))
+	var s redraw
+	for frame := 0; frame <= frames; frame++ {
+		t := float64(frame) / float64(frames)
+		lines := make([]string, len(layers))
+		for i, l := range layers {
+			lines[i] = l.id + ": " + l.status(t, i)
+		}
+		s.draw(out, lines)
+		if frame < frames {
+			if err := pause(ctx, frameDuration); err != nil {
+				return err
+			}
+		}
+	}
+
+	fmt.Fprintf(out, "Digest: sha256:%x\n", sum)
+	fmt.Fprintf(out, "Status: Downloaded newer image for %s\n", strings.TrimPrefix(strings.TrimPrefix(ref, "docker.io/"), "library/"))
+	fmt.Fprintln(out, ref)
+	return nil
+}
+
+// schedule sets the times the layers are downloaded, three at a
+// time, and extracted in order, scaled so that the last extraction
+// ends at 1
+func schedule(layers []layer) {
+	var end float64
+	for i := range layers {
+		l := &layers[i]
+		if i >= 3 {
+			l.download = layers[i-3].downloaded
+		} else {
+			l.download = 0.2 * float64(i)
+		}
+		l.downloaded = l.download + 0.5 + l.size/1e7
+		l.extract = l.downloaded
+		if i > 0 {
+			l.extract = math.Max(l.extract, layers[i-1].extracted)
+		}
+		l.extracted = l.extract + 0.2 + l.size/4e7
+		end = l.extracted
+	}
+	for i := range layers {
+		layers[i].download /= end
+		layers[i].downloaded /= end
+		layers[i].extract /= end
+		layers[i].extracted /= end
+	}
+}
+
+// status returns the status of the layer at the time
+func (l layer) status(t float64, i int) string {
+	switch {
+	case t < l.download && i < 3:
+		return "Pulling fs layer"
+	case t < l.download:
+		return "Waiting"
+	case t < l.downloaded:
+		done := (t - l.download) / (l.downloaded - l.download)
+		return "Downloading  " + dockerProgress(done, l.size)
+	case t < l.extract:
+		return "Download complete"
+	case t < l.extracted:
+		done := (t - l.extract) / (l.extracted - l.extract)
+		return "Extracting  " + dockerProgress(done, l.size)
+	default:
+		return "Pull complete"
+	}
+}
+
+// dockerProgress returns the progress bar of docker
+// (e.g. "[=====>        ]  3.2MB/31.4MB")
+func dockerProgress(done, size float64) string {
+	const width = 50
+	filled := int(width * done)
+	return fmt.Sprintf("[%s>%s]  %s/%s", strings.Repeat("=", filled), strings.Repeat(" ", width-filled),
+		humanSize(done*size), humanSize(size))
+}
+
+// humanSize formats a size with three significant digits
+// like docker (e.g. "512B", "2.56kB" or "31.4MB")
+func humanSize(bytes float64) string {
+	units := []string{"B", "kB", "MB", "GB"}
+	i := 0
+	for bytes >= 999.5 && i < len(units)-1 {
+		bytes /= 1000
+		i++
+	}
+	if i == 0 {
+		return fmt.Sprintf("%.0fB", bytes)
+	}
+	return fmt.Sprintf("%.3g%s", bytes, units[i])
+}
+
+// canonicalImage returns the full reference of an image
+// (e.g. "docker.io/library/nginx:latest" for "nginx")
+func canonicalImage(image string) string {
+	if !strings.Contains(image[strings.LastIndex(image, "/")+1:], ":") {
+		image += ":latest"
+	}
+	first, _, found := strings.Cut(image, "/")
+	if !found {
+		return "docker.io/library/" + image
+	}
+	if !strings.ContainsAny(first, ".:") && first != "localhost" {
+		return "docker.io/" + image
+	}
+	return image
+}
+
+// redraw draws lines over the lines drawn before, so that
+// progress is updated in place
+type redraw struct {
+	lines int
+}
+
+// draw moves the cursor up to the first line drawn before and
+// draws the lines over them
+func (r *redraw) draw(out io.Writer, lines []string) {
+	if r.lines > 0 {
+		fmt.Fprintf(out, "\033[%dA", r.lines)
+	}
+	for _, line := range lines {
+		fmt.Fprintf(out, "\r\033[K%s\n", line)
+	}
+	r.lines = len(lines)
+}
+
+// job is a line of the output of docker build, with the
+// lines shown under it when it is done
+type job struct {
+	name       string
+	details    []string
+	weight     float64
+	start, end float64
+}
+
+// build writes the output of BuildKit building the Dockerfile of the
+// context, which is read if it exists, redrawing the started jobs
+func (d docker) build(ctx context.Context, sim Simulation, args []string, out io.Writer) error {
+	dir, file, tag := ".", "", ""
+	for i := 0; i < len(args); i++ {
+		switch arg := args[i]; {
+		case (arg == "-t" || arg == "--tag") && i+1 < len(args):
+			tag = args[i+1]
+			i++
+		case (arg == "-f" || arg == "--file") && i+1 < len(args):
+			file = args[i+1]
+			i++
+		case strings.HasPrefix(arg, "-"):
+			// Ignore the other options (e.g. "--no-cache")
+		default:
+			dir = arg
+		}
+	}
+	if file == "" {
+		file = filepath.Join(dir, "Dockerfile")
+	}
+
+	// Reading the Dockerfile is harmless, so the real file is used
+	dockerfile := defaultDockerfile
+	if data, err := os.ReadFile(file); err == nil {
+		dockerfile = string(data)
+	}
+	jobs := buildJobs(dockerfile, tag)
+
+	// Spread the jobs over the duration by their weights
+	var total float64
+	for _, j := range jobs {
+		total += j.weight
+	}
+	var at float64
+	for i := range jobs {
+		jobs[i].start = at / total
+		at += jobs[i].weight
+		jobs[i].end = at / total
+	}
+
+	duration := d.Duration(sim)
+	seconds := duration.Seconds()
+	frames := max(1, int(duration/frameDuration))
+	var s redraw
+	for frame := 0; frame <= frames; frame++ {
+		t := float64(frame) / float64(frames)
+
+		var lines []string
+		done := 0
+		for _, j := range jobs {
+			if t < j.start {
+				break
+			}
+			elapsed := (math.Min(t, j.end) - j.start) * seconds
+			line := fmt.Sprintf(" => %-68s %5.1fs", truncate(j.name, 68), elapsed)
+			if t >= j.end {
+				done++
+				lines = append(lines, "\033[34m"+line+"\033[0m")
+				for _, detail := range j.details {
+					lines = append(lines, fmt.Sprintf("\033[34m => => %-65s %5.1fs\033[0m", truncate(detail, 65), 0.0))
+				}
+			} else {
+				lines = append(lines, line)
+			}
+		}
+
+		header := fmt.Sprintf("[+] Building %.1fs (%d/%d)", t*seconds, done, len(jobs))
+		if frame == frames {
+			header = "\033[34m" + header + " FINISHED\033[0m"
+		}
+		s.draw(out, append([]string{header}, lines...))
+		if frame < frames {
+			if err := pause(ctx, frameDuration); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// buildJobs returns the jobs of building the Dockerfile, with
+// weights for the time they take
+func buildJobs(dockerfile, tag string) []job {
+	type instruction struct{ name, args string }
+	var steps []instruction
+	base := ""
+	lines := strings.Split(strings.ReplaceAll(dockerfile, "\\\n", " "), "\n")
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		name, args, _ := strings.Cut(line, " ")
+		name = strings.ToUpper(name)
+		switch name {
+		case "FROM":
+			args, _, _ = strings.Cut(strings.TrimSpace(args), " ")
+			if base == "" {
+				base = args
+			}
+			args = canonicalImage(args)
+		case "RUN", "COPY", "ADD", "WORKDIR":
+		default:
+			// Other instructions only change the metadata of the image
+			continue
+		}
+		steps = append(steps, instruction{name, strings.Join(strings.Fields(args), " ")})
+	}
+	if base == "" {
+		base = "scratch"
+	}
+
+	digest := sha256.Sum256([]byte(dockerfile))
+	jobs := []job{
+		{name: "[internal] load build definition from Dockerfile", details: []string{fmt.Sprintf("transferring dockerfile: %s", humanSize(float64(len(dockerfile))))}, weight: 0.1},
+		{name: "[internal] load metadata for " + strings.TrimSuffix(canonicalImage(base), ":latest"), weight: 1},
+		{name: "[internal] load .dockerignore", details: []string{"transferring context: 2B"}, weight: 0.1},
+	}
+	for i, step := range steps {
+		name := fmt.Sprintf("[%d/%d] %s %s", i+1, len(steps), step.name, step.args)
+		weight := 0.1
+		switch step.name {
+		case "FROM":
+			name += fmt.Sprintf("@sha256:%x", sha256.Sum256([]byte(step.args)))
+			weight = 2.5
+		case "RUN":
+			weight = 4
+		}
+		if i == 1 {
+			jobs = append(jobs, job{name: "[internal] load build context", details: []string{fmt.Sprintf("transferring context: %s", humanSize(float64(1000+int(digest[0])*40)))}, weight: 0.2})
+		}
+		jobs = append(jobs, job{name: name, weight: weight})
+	}
+
+	export := []string{"exporting layers", fmt.Sprintf("writing image sha256:%x", digest)}
+	if tag != "" {
+		export = append(export, "naming to "+canonicalImage(tag))
+	}
+	return append(jobs, job{name: "exporting to image", details: export, weight: 0.8})
+}
+
+// truncate shortens the text to the width, if it is longer
+func truncate(text string, width int) string {
+	if len(text) <= width {
+		return text
+	}
+	return text[:width]
+}
@@ -0,0 +1,88 @@
+package simulate_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bitcanon/autotyper/simulate"
+)
+
+// TestDocker tests the simulated output of docker pull and docker
+// build, ending with all layers pulled and all steps built
+func TestDocker(t *testing.T) {
+	dir := t.TempDir()
+	dockerfile := "FROM golang:1.22 AS build\n# Build the binary\nRUN go build \\\n  -o /app .\nEXPOSE 8080\n"
+	if err := os.WriteFile(filepath.Join(dir, "Dockerfile"), []byte(dockerfile), 0o644); err != nil {
+		t.Fatalf("failed to write Dockerfile: %v", err)
+	}
+	fast := simulate.Params{"duration": 0}
+
+	tests := []struct {
+		name     string
+		command  string
+		params   simulate.Params
+		expected []string
+	}{
+		{
+			name:    "Pull",
+			command: "docker pull nginx",
+			params:  simulate.Params{"duration": 0, "layers": 2},
+			expected: []string{
+				"Using default tag: latest\nlatest: Pulling from library/nginx\n",
+				": Pull complete\n",
+				"Status: Downloaded newer image for nginx:latest\ndocker.io/library/nginx:latest\n",
+			},
+		},
+		{
+			name:    "PullTag",
+			command: "sudo docker image pull ghcr.io/acme/api:1.4",
+			params:  fast,
+			expected: []string{
+				"1.4: Pulling from ghcr.io/acme/api\n",
+				"Status: Downloaded newer image for ghcr.io/acme/api:1.4\n",
+			},
+		},
+		{
+			name:    "Build",
+			command: "docker build -t web " + dir,
+			params:  fast,
+			expected: []string{
+				"[+] Building 0.0s (0/7)",
+				"(7/7) FINISHED",
+				"[internal] load metadata for docker.io/library/golang:1.22 ",
+				"[1/2] FROM docker.io/library/golang:1.22@sha256:",
+				"[2/2] RUN go build -o /app . ",
+				"naming to docker.io/library/web:latest ",
+			},
+		},
+		{
+			name:     "BuildDefault",
+			command:  "docker buildx build " + t.TempDir(),
+			params:   fast,
+			expected: []string{"[4/5] RUN npm ci ", "(10/10) FINISHED"},
+		},
+		{
+			name:     "UnknownCommand",
+			command:  "docker frobnicate",
+			expected: []string{"docker: 'frobnicate' is not a docker command.\n"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var out strings.Builder
+			sim := simulate.NewSimulation(test.command, test.params, false)
+			if err := simulate.Simulate(context.Background(), sim, &out); err != nil {
+				t.Fatalf("expected no error, but got %v", err)
+			}
+			for _, expected := range test.expected {
+				if !strings.Contains(out.String(), expected) {
+					t.Errorf("expected %q in the output, but got %q", expected, out.String())
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,140 @@
+/*
+Copyright © 2023 Mikael Schultz <bitcanon@proton.me>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package simulate
+
+import (
+	"crypto/rand"
+	"fmt"
+	"math/big"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/brianvoe/gofakeit/v6"
+)
+
+// Funcs are the functions available to the output templates, so
+// that simulated outputs contain fresh timestamps and identifiers
+// each time they are printed:
+//
+//	{{now}}              the current time
+//	{{date "15:04"}}     the current time in a Go layout
+//	{{uuid}}             a random UUID
+//	{{randomIP}}         a random private IPv4 address
+//	{{randomInt 1 100}}  a random integer from 1 to 100
+//	{{randomHex 12}}     a random string of 12 hex digits
+//	{{add .Step 1}}      the sum or the difference of integers
+//	{{sub .Step 1}}
+//	{{range seq 10}}     the integers from 1 to 10, for bulk output
+//
+// Fake data is generated with gofakeit: name, firstName, lastName,
+// username, email, phone, company, city, country, domainName, url,
+// ipv4, ipv6, macAddress, serial, word and sentence (with a number
+// of words). Other data can be generated from the gofakeit tags
+// with fake (e.g. {{fake "{firstname}.{lastname}@example.com"}})
+var Funcs = template.FuncMap{
+	"now":       func() time.Time { return time.Now().Truncate(time.Second) },
+	"date":      func(layout string) string { return time.Now().Format(layout) },
+	"uuid":      uuid,
+	"randomIP":  randomIP,
+	"randomInt": randomInt,
+	"randomHex": randomHex,
+	"add":       func(a, b int) int { return a + b },
+	"sub":       func(a, b int) int { return a - b },
+	"seq":       seq,
+
+	// Fake data
+	"name":       gofakeit.Name,
+	"firstName":  gofakeit.FirstName,
+	"lastName":   gofakeit.LastName,
+	"username":   gofakeit.Username,
+	"email":      gofakeit.Email,
+	"phone":      gofakeit.Phone,
+	"company":    gofakeit.Company,
+	"city":       gofakeit.City,
+	"country":    gofakeit.Country,
+	"domainName": gofakeit.DomainName,
+	"url":        gofakeit.URL,
+	"ipv4":       gofakeit.IPv4Address,
+	"ipv6":       gofakeit.IPv6Address,
+	"macAddress": gofakeit.MacAddress,
+	"serial":     func() string { return strings.ToUpper(gofakeit.Lexify("???")) + gofakeit.Numerify("########") },
+	"word":       gofakeit.Word,
+	"sentence":   gofakeit.Sentence,
+	"fake":       gofakeit.Generate,
+}
+
+// seq returns the integers from 1 to n
+func seq(n int) []int {
+	s := make([]int, max(n, 0))
+	for i := range s {
+		s[i] = i + 1
+	}
+	return s
+}
+
+// ParseOutput parses the text of an output template
+// with the functions of Funcs
+func ParseOutput(name, text string) (*template.Template, error) {
+	return template.New(name).Funcs(Funcs).Parse(text)
+}
+
+// EscapeOutput returns an output template printing the text as is,
+// with the actions (e.g. "{{") in the text escaped
+func EscapeOutput(text string) string {
+	return strings.ReplaceAll(text, "{{", `{{"{{"}}`)
+}
+
+// randomIP returns a random address of the 10.0.0.0/8 network
+func randomIP() string {
+	return fmt.Sprintf("10.%d.%d.%d", randomInt(0, 255), randomInt(0, 255), randomInt(1, 254))
+}
+
+// randomInt returns a random integer from min to max
+func randomInt(min, max int) int {
+	if max <= min {
+		return min
+	}
+	n, err := rand.Int(rand.Reader, big.NewInt(int64(max-min+1)))
+	if err != nil {
+		return min
+	}
+	return min + int(n.Int64())
+}
+
+// randomHex returns a random string of n hex digits
+func randomHex(n int) string {
+	var b strings.Builder
+	for i := 0; i < n; i++ {
+		b.WriteByte("0123456789abcdef"[randomInt(0, 15)])
+	}
+	return b.String()
+}
+
+// uuid returns a random (version 4) UUID
+func uuid() string {
+	var b [16]byte
+	rand.Read(b[:])
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
@@ -0,0 +1,82 @@
+package simulate_test
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/bitcanon/autotyper/simulate"
+)
+
+// TestFuncs tests the output of the template functions
+func TestFuncs(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		expected string
+	}{
+		{name: "Now", text: `{{now}}`, expected: `^\d{4}-\d\d-\d\d \d\d:\d\d:\d\d [+-]\d{4} \w+$`},
+		{name: "NowFormat", text: `{{(now).Format "2006"}}`, expected: `^\d{4}$`},
+		{name: "Date", text: `{{date "15:04"}}`, expected: `^\d\d:\d\d$`},
+		{name: "UUID", text: `{{uuid}}`, expected: `^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`},
+		{name: "RandomIP", text: `{{randomIP}}`, expected: `^10\.\d{1,3}\.\d{1,3}\.\d{1,3}$`},
+		{name: "RandomInt", text: `{{randomInt 5 7}}`, expected: `^[5-7]$`},
+		{name: "RandomHex", text: `{{randomHex 12}}`, expected: `^[0-9a-f]{12}$`},
+		{name: "Add", text: `{{add .Step 1}}`, expected: `^4$`},
+		{name: "Sub", text: `{{sub .Step 1}}`, expected: `^2$`},
+		{name: "Seq", text: `{{range seq 3}}{{.}} {{end}}`, expected: `^1 2 3 $`},
+		{name: "Name", text: `{{name}}`, expected: `^\S+ \S+`},
+		{name: "Email", text: `{{email}}`, expected: `^\S+@\S+\.\w+$`},
+		{name: "MacAddress", text: `{{macAddress}}`, expected: `^([0-9a-f]{2}:){5}[0-9a-f]{2}$`},
+		{name: "IPv6", text: `{{ipv6}}`, expected: `^[0-9a-f:]+$`},
+		{name: "Serial", text: `{{serial}}`, expected: `^[A-Z]{3}\d{8}$`},
+		{name: "Fake", text: `{{fake "{number:1,9}-{firstname}"}}`, expected: `^[1-9]-\w+$`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			tmpl, err := simulate.ParseOutput(test.name, test.text)
+			if err != nil {
+				t.Fatalf("expected no error, but got %v", err)
+			}
+			var out strings.Builder
+			if err := tmpl.Execute(&out, simulate.FakeData{Step: 3}); err != nil {
+				t.Fatalf("expected no error, but got %v", err)
+			}
+			if !regexp.MustCompile(test.expected).MatchString(out.String()) {
+				t.Errorf("expected output matching %s, but got %q", test.expected, out.String())
+			}
+		})
+	}
+}
+
+// TestFuncsFresh tests that the random identifiers differ each time
+func TestFuncsFresh(t *testing.T) {
+	tmpl, err := simulate.ParseOutput("uuid", "{{uuid}}")
+	if err != nil {
+		t.Fatalf("expected no error, but got %v", err)
+	}
+	var first, second strings.Builder
+	tmpl.Execute(&first, nil)
+	tmpl.Execute(&second, nil)
+	if first.String() == second.String() {
+		t.Errorf("expected different UUIDs, but got %q twice", first.String())
+	}
+}
+
+// TestEscapeOutput tests that escaped texts are printed as is
+func TestEscapeOutput(t *testing.T) {
+	for _, text := range []string{"", "plain\n", "{{.Step}} {{ now }}\n", `{{"{{"}}`, "}} {{"} {
+		tmpl, err := simulate.ParseOutput("escaped", simulate.EscapeOutput(text))
+		if err != nil {
+			t.Fatalf("%q: expected no error, but got %v", text, err)
+		}
+		var out strings.Builder
+		if err := tmpl.Execute(&out, simulate.FakeData{Step: 3}); err != nil {
+			t.Fatalf("%q: expected no error, but got %v", text, err)
+		}
+		if out.String() != text {
+			t.Errorf("expected %q, but got %q", text, out.String())
+		}
+	}
+}
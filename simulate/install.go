@@ -0,0 +1,400 @@
+/*
+Copyright © 2023 Mikael Schultz <bitcanon@proton.me>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package simulate
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"math/rand"
+	"strings"
+	"time"
+)
+
+// defaultInstallDuration is the time in milliseconds that a
+// simulated installation takes, unless set with "duration"
+const defaultInstallDuration = 4000
+
+// installer simulates the install command of a package manager,
+// with the parameters:
+//
+//	duration  the time of the installation in milliseconds (4000)
+type installer struct {
+	// Write the output of the package manager
+	install func(p *pacer, sim Simulation, packages []pkg, out io.Writer) error
+
+	// The number of pauses in the output for the packages
+	ticks func(packages int) int
+
+	// The message printed for commands other than install
+	unknown string
+}
+
+// pkg is a package to install, with a made up version and size
+type pkg struct {
+	name    string
+	version string
+	size    float64 // kB
+}
+
+// newPkg makes up the version and the size of a package from
+// its name, so that the same package always looks the same
+func newPkg(name string) pkg {
+	h := fnv.New32a()
+	h.Write([]byte(name))
+	sum := h.Sum32()
+	return pkg{
+		name:    name,
+		version: fmt.Sprintf("%d.%d.%d", 1+sum%4, (sum>>4)%20, (sum>>9)%10),
+		size:    float64(20 + (sum>>13)%2000),
+	}
+}
+
+// Duration returns the configured time of the installation
+func (installer) Duration(sim Simulation) time.Duration {
+	return time.Duration(sim.Params.Int("duration", defaultInstallDuration)) * time.Millisecond
+}
+
+// Simulate writes the output of installing the packages given in
+// the command, spreading the lines over the duration
+func (i installer) Simulate(ctx context.Context, sim Simulation, out io.Writer) error {
+	args := sim.args()
+	if len(args) < 2 || args[1] != "install" {
+		operation := ""
+		if len(args) > 1 {
+			operation = args[1]
+		}
+		fmt.Fprintf(out, i.unknown+"\n", operation)
+		return nil
+	}
+
+	var packages []pkg
+	for j := 2; j < len(args); j++ {
+		switch arg := args[j]; {
+		case arg == "-r" || arg == "--requirement":
+			// Skip the file name after the option as well
+			j++
+		case strings.HasPrefix(arg, "-"):
+			// Ignore the other options (e.g. "-y")
+		default:
+			packages = append(packages, newPkg(arg))
+		}
+	}
+
+	p := newPacer(ctx, i.Duration(sim), i.ticks(len(packages)))
+	return i.install(p, sim, packages, out)
+}
+
+// pacer spreads the pauses of a simulated output evenly over its
+// duration, so that the output takes as long as configured
+type pacer struct {
+	ctx  context.Context
+	step time.Duration
+}
+
+// newPacer returns a pacer pausing ticks times during the duration
+func newPacer(ctx context.Context, d time.Duration, ticks int) *pacer {
+	return &pacer{ctx: ctx, step: d / time.Duration(max(1, ticks))}
+}
+
+// tick pauses for one step
+func (p *pacer) tick() error {
+	return pause(p.ctx, p.step)
+}
+
+// apt writes the output of apt-get install, with the progress bar of
+// the installation at the bottom of the screen when bar is set (apt)
+func apt(bar bool) func(p *pacer, sim Simulation, packages []pkg, out io.Writer) error {
+	return func(p *pacer, sim Simulation, packages []pkg, out io.Writer) error {
+		for _, line := range []string{"Reading package lists... Done", "Building dependency tree... Done", "Reading state information... Done"} {
+			if err := p.tick(); err != nil {
+				return err
+			}
+			fmt.Fprintln(out, line)
+		}
+
+		var names []string
+		var total float64
+		for _, pkg := range packages {
+			names = append(names, pkg.name)
+			total += pkg.size
+		}
+		if len(packages) > 0 {
+			fmt.Fprintln(out, "The following NEW packages will be installed:")
+			fmt.Fprintf(out, "  %s\n", strings.Join(names, " "))
+		}
+		fmt.Fprintf(out, "0 upgraded, %d newly installed, 0 to remove and 0 not upgraded.\n", len(packages))
+		if len(packages) == 0 {
+			return nil
+		}
+		fmt.Fprintf(out, "Need to get %s of archives.\n", kilobytes(total))
+		fmt.Fprintf(out, "After this operation, %s of additional disk space will be used.\n", kilobytes(total*3.2))
+
+		for i, pkg := range packages {
+			if err := p.tick(); err != nil {
+				return err
+			}
+			fmt.Fprintf(out, "Get:%d http://archive.ubuntu.com/ubuntu jammy/main amd64 %s amd64 %s-1 [%s]\n", i+1, pkg.name, pkg.version, kilobytes(pkg.size))
+		}
+		seconds := max(1, int(p.step*time.Duration(len(packages))/time.Second))
+		fmt.Fprintf(out, "Fetched %s in %ds (%s/s)\n", kilobytes(total), seconds, kilobytes(total/float64(seconds)))
+
+		// The progress of dpkg is shown on the last line, and the
+		// lines above are printed over it
+		steps := 2*len(packages) + 1
+		done := 0
+		line := func(format string, args ...any) {
+			if bar {
+				fmt.Fprint(out, "\r\033[K")
+			}
+			fmt.Fprintf(out, format+"\n", args...)
+			if bar {
+				fmt.Fprint(out, aptProgress(done*100/steps))
+			}
+		}
+
+		database := 70000 + int(total)%9000
+		for i, pkg := range packages {
+			if err := p.tick(); err != nil {
+				return err
+			}
+			line("Selecting previously unselected package %s.", pkg.name)
+			if i == 0 {
+				line("(Reading database ... %d files and directories currently installed.)", database)
+			}
+			line("Preparing to unpack .../%s_%s-1_amd64.deb ...", pkg.name, pkg.version)
+			done++
+			line("Unpacking %s (%s-1) ...", pkg.name, pkg.version)
+		}
+		for _, pkg := range packages {
+			if err := p.tick(); err != nil {
+				return err
+			}
+			done++
+			line("Setting up %s (%s-1) ...", pkg.name, pkg.version)
+		}
+
+		if err := p.tick(); err != nil {
+			return err
+		}
+		done++
+		line("Processing triggers for man-db (2.10.2-1) ...")
+		if bar {
+			fmt.Fprint(out, "\r\033[K")
+		}
+		return nil
+	}
+}
+
+// aptProgress returns the progress bar of apt at the percentage
+func aptProgress(percent int) string {
+	const width = 50
+	filled := width * percent / 100
+	return fmt.Sprintf("\033[42m\033[30mProgress: [%3d%%]\033[49m\033[39m [%s%s] ",
+		percent, strings.Repeat("#", filled), strings.Repeat(".", width-filled))
+}
+
+// kilobytes formats a size like apt (e.g. "52.5 kB" or "1,234 kB")
+func kilobytes(kb float64) string {
+	if kb < 100 {
+		return fmt.Sprintf("%.1f kB", kb)
+	}
+	n := fmt.Sprintf("%.0f", kb)
+	for i := len(n) - 3; i > 0; i -= 3 {
+		n = n[:i] + "," + n[i:]
+	}
+	return n + " kB"
+}
+
+// yum writes the output of yum and dnf install
+func yum(p *pacer, sim Simulation, packages []pkg, out io.Writer) error {
+	if err := p.tick(); err != nil {
+		return err
+	}
+	ago := time.Duration(rand.Intn(3600)) * time.Second
+	fmt.Fprintf(out, "Last metadata expiration check: 0:%02d:%02d ago on %s.\n",
+		int(ago.Minutes()), int(ago.Seconds())%60, time.Now().Add(-ago).Format("Mon 02 Jan 2006 03:04:05 PM MST"))
+	if len(packages) == 0 {
+		fmt.Fprintln(out, "Error: Need to pass a list of pkgs to install")
+		return nil
+	}
+	if err := p.tick(); err != nil {
+		return err
+	}
+	fmt.Fprintln(out, "Dependencies resolved.")
+
+	rule := strings.Repeat("=", 80)
+	var total float64
+	fmt.Fprintln(out, rule)
+	fmt.Fprintf(out, " %-20s %-14s %-20s %-14s %6s\n", "Package", "Architecture", "Version", "Repository", "Size")
+	fmt.Fprintln(out, rule)
+	fmt.Fprintln(out, "Installing:")
+	for _, pkg := range packages {
+		fmt.Fprintf(out, " %-20s %-14s %-20s %-14s %6s\n", pkg.name, "x86_64", pkg.version+"-1.el9", "appstream", fmt.Sprintf("%.0f k", pkg.size))
+		total += pkg.size
+	}
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, "Transaction Summary")
+	fmt.Fprintln(out, rule)
+	fmt.Fprintf(out, "Install  %d Package%s\n\n", len(packages), plural(len(packages)))
+	fmt.Fprintf(out, "Total download size: %s\n", megabytes(total))
+	fmt.Fprintf(out, "Installed size: %s\n", megabytes(total*3.2))
+	if !hasOption(sim.args(), "-y", "--assumeyes") {
+		fmt.Fprintln(out, "Is this ok [y/N]: y")
+	}
+
+	fmt.Fprintln(out, "Downloading Packages:")
+	for i, pkg := range packages {
+		if err := p.tick(); err != nil {
+			return err
+		}
+		file := fmt.Sprintf("(%d/%d): %s-%s-1.el9.x86_64.rpm", i+1, len(packages), pkg.name, pkg.version)
+		fmt.Fprintf(out, "%-48s %s/s | %6s     00:00\n", file, megabytes(pkg.size*4), fmt.Sprintf("%.0f kB", pkg.size))
+	}
+	fmt.Fprintln(out, strings.Repeat("-", 80))
+	fmt.Fprintf(out, "%-48s %s/s | %6s     00:01\n", "Total", megabytes(total*3), megabytes(total))
+
+	if err := p.tick(); err != nil {
+		return err
+	}
+	for _, line := range []string{"Running transaction check", "Transaction check succeeded.", "Running transaction test", "Transaction test succeeded.", "Running transaction"} {
+		fmt.Fprintln(out, line)
+	}
+	fmt.Fprintf(out, "  %-17s: %-52s %d/%d\n", "Preparing", "", 1, 1)
+	for _, action := range []string{"Installing", "Verifying"} {
+		for i, pkg := range packages {
+			if err := p.tick(); err != nil {
+				return err
+			}
+			fmt.Fprintf(out, "  %-17s: %-52s %d/%d\n", action, pkg.name+"-"+pkg.version+"-1.el9.x86_64", i+1, len(packages))
+		}
+	}
+
+	fmt.Fprintln(out, "\nInstalled:")
+	for _, pkg := range packages {
+		fmt.Fprintf(out, "  %s-%s-1.el9.x86_64\n", pkg.name, pkg.version)
+	}
+	fmt.Fprintln(out, "\nComplete!")
+	return nil
+}
+
+// megabytes formats a size in kB like yum (e.g. "1.2 M")
+func megabytes(kb float64) string {
+	return fmt.Sprintf("%.1f M", kb/1024)
+}
+
+// pipFrames is the number of times the progress bar of a
+// download is drawn by pip
+const pipFrames = 5
+
+// pip writes the output of pip install, with an animated progress
+// bar for each download
+func pip(p *pacer, sim Simulation, packages []pkg, out io.Writer) error {
+	if len(packages) == 0 {
+		fmt.Fprintln(out, `ERROR: You must give at least one requirement to install (see "pip help install")`)
+		return nil
+	}
+
+	var installed []string
+	for i, pkg := range packages {
+		// Use the version of the requirement, if pinned (e.g. "flask==3.0.0")
+		if name, version, ok := strings.Cut(pkg.name, "=="); ok {
+			pkg.name, pkg.version = name, version
+		} else if j := strings.IndexAny(pkg.name, "<>=!~["); j > 0 {
+			pkg.name = pkg.name[:j]
+		}
+		packages[i] = pkg
+
+		if err := p.tick(); err != nil {
+			return err
+		}
+		wheel := fmt.Sprintf("%s-%s-py3-none-any.whl", strings.ReplaceAll(pkg.name, "-", "_"), pkg.version)
+		fmt.Fprintf(out, "Collecting %s\n", pkg.name)
+		fmt.Fprintf(out, "  Downloading %s (%s)\n", wheel, pipSize(pkg.size))
+		for frame := 1; frame <= pipFrames; frame++ {
+			if err := p.tick(); err != nil {
+				return err
+			}
+			fmt.Fprint(out, "\r\033[K"+pipProgress(pkg.size*float64(frame)/pipFrames, pkg.size))
+		}
+		fmt.Fprintln(out)
+		installed = append(installed, pkg.name+"-"+pkg.version)
+	}
+
+	var names []string
+	for _, pkg := range packages {
+		names = append(names, pkg.name)
+	}
+	if err := p.tick(); err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "Installing collected packages: %s\n", strings.Join(names, ", "))
+	fmt.Fprintf(out, "Successfully installed %s\n", strings.Join(installed, " "))
+	return nil
+}
+
+// pipProgress returns the progress bar of pip for a download
+func pipProgress(done, size float64) string {
+	const width = 40
+	filled := int(width * done / size)
+	bar := "\033[38;5;197m" + strings.Repeat("━", filled) + "\033[38;5;237m" + strings.Repeat("━", width-filled)
+	eta := "0:00:01"
+	if filled == width {
+		bar = "\033[32m" + strings.Repeat("━", width)
+		eta = "0:00:00"
+	}
+	unit, scale := "kB", 1.0
+	if size >= 1000 {
+		unit, scale = "MB", 1000
+	}
+	return fmt.Sprintf("     %s\033[0m \033[32m%.1f/%.1f %s\033[0m \033[31m%.1f MB/s\033[0m eta \033[36m%s\033[0m",
+		bar, done/scale, size/scale, unit, size/400, eta)
+}
+
+// pipSize formats the size of a download like pip (e.g. "62 kB" or "1.3 MB")
+func pipSize(kb float64) string {
+	if kb >= 1000 {
+		return fmt.Sprintf("%.1f MB", kb/1000)
+	}
+	return fmt.Sprintf("%.0f kB", kb)
+}
+
+// hasOption reports whether any of the options is in the arguments
+func hasOption(args []string, options ...string) bool {
+	for _, arg := range args {
+		for _, option := range options {
+			if arg == option {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// plural returns "s" unless there is one of something
+func plural(n int) string {
+	if n == 1 {
+		return ""
+	}
+	return "s"
+}
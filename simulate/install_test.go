@@ -0,0 +1,111 @@
+package simulate_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bitcanon/autotyper/simulate"
+)
+
+// TestInstall tests the simulated output of the install
+// commands of the package managers
+func TestInstall(t *testing.T) {
+	fast := simulate.Params{"duration": 0}
+
+	tests := []struct {
+		name     string
+		command  string
+		expected []string
+	}{
+		{
+			name:    "Apt",
+			command: "sudo apt install -y curl jq",
+			expected: []string{
+				"Reading package lists... Done\n",
+				"The following NEW packages will be installed:\n  curl jq\n",
+				"0 upgraded, 2 newly installed, 0 to remove and 0 not upgraded.\n",
+				"Get:2 http://archive.ubuntu.com/ubuntu jammy/main amd64 jq amd64 ",
+				"Setting up jq (",
+				"Progress: [ 80%]",
+				"Processing triggers for man-db",
+			},
+		},
+		{
+			name:     "AptGet",
+			command:  "apt-get install nginx",
+			expected: []string{"Unpacking nginx (", "Setting up nginx ("},
+		},
+		{
+			name:     "AptUnknownOperation",
+			command:  "apt frobnicate",
+			expected: []string{"E: Invalid operation frobnicate\n"},
+		},
+		{
+			name:    "Yum",
+			command: "yum install git",
+			expected: []string{
+				"Dependencies resolved.\n",
+				"Install  1 Package\n",
+				"Is this ok [y/N]: y\n",
+				"  Installing       : git-",
+				"\nComplete!\n",
+			},
+		},
+		{
+			name:     "Dnf",
+			command:  "sudo dnf install -y git vim",
+			expected: []string{"Install  2 Packages\n", "  Verifying        : vim-"},
+		},
+		{
+			name:    "Pip",
+			command: "pip install flask==3.0.0 requests>=2",
+			expected: []string{
+				"Collecting flask\n  Downloading flask-3.0.0-py3-none-any.whl (",
+				"Collecting requests\n",
+				"eta \033[36m0:00:00",
+				"Installing collected packages: flask, requests\n",
+				"Successfully installed flask-3.0.0 requests-",
+			},
+		},
+		{
+			name:     "PipWithoutPackages",
+			command:  "pip3 install -r requirements.txt",
+			expected: []string{"ERROR: You must give at least one requirement"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var out strings.Builder
+			sim := simulate.NewSimulation(test.command, fast, false)
+			if err := simulate.Simulate(context.Background(), sim, &out); err != nil {
+				t.Fatalf("expected no error, but got %v", err)
+			}
+			for _, expected := range test.expected {
+				if !strings.Contains(out.String(), expected) {
+					t.Errorf("expected %q in the output, but got %q", expected, out.String())
+				}
+			}
+		})
+	}
+}
+
+// TestInstallDuration tests that an installation takes about
+// the configured duration
+func TestInstallDuration(t *testing.T) {
+	sim := simulate.NewSimulation("apt-get install jq", simulate.Params{"duration": 200}, false)
+	s, _ := simulate.Lookup(sim.Command)
+	if d := s.Duration(sim); d != 200*time.Millisecond {
+		t.Errorf("expected 200ms, but got %v", d)
+	}
+
+	start := time.Now()
+	if err := s.Simulate(context.Background(), sim, &strings.Builder{}); err != nil {
+		t.Fatalf("expected no error, but got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 200*time.Millisecond || elapsed > time.Second {
+		t.Errorf("expected the installation to take 200ms, but it took %v", elapsed)
+	}
+}
@@ -0,0 +1,238 @@
+/*
+Copyright © 2023 Mikael Schultz <bitcanon@proton.me>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package simulate
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"strings"
+	"time"
+)
+
+// routeCodes is the legend printed by show ip route
+const routeCodes = `Codes: L - local, C - connected, S - static, R - RIP, M - mobile, B - BGP
+       D - EIGRP, EX - EIGRP external, O - OSPF, IA - OSPF inter area
+       N1 - OSPF NSSA external type 1, N2 - OSPF NSSA external type 2
+       E1 - OSPF external type 1, E2 - OSPF external type 2, m - OMP
+       n - NAT, Ni - NAT inside, No - NAT outside, Nd - NAT DIA
+       i - IS-IS, su - IS-IS summary, L1 - IS-IS level-1, L2 - IS-IS level-2
+       ia - IS-IS inter area, * - candidate default, U - per-user static route
+       H - NHRP, G - NHRP registered, g - NHRP registration summary
+       o - ODR, P - periodic downloaded static route, l - LISP
+       a - application route
+       + - replicated route, % - next hop override, p - overrides from PfR
+       & - replicated local route overrides by connected
+`
+
+// show simulates the show commands of a Cisco IOS XE router, with
+// the parameters:
+//
+//	hostname    the name of the router (Router)
+//	interfaces  the number of interfaces, the last one shut down (4)
+//	routes      the number of routes learned with OSPF (6)
+//	devices     the number of CDP neighbors (2)
+type show struct{}
+
+// router is the simulated device, made up from the parameters
+type router struct {
+	hostname   string
+	interfaces int
+	routes     int
+	devices    int
+	seed       uint32
+}
+
+// showCommands are the supported show commands, by their keywords
+var showCommands = []struct {
+	keywords []string
+	print    func(r router, out io.Writer)
+}{
+	{keywords: []string{"ip", "interface", "brief"}, print: router.interfaceBrief},
+	{keywords: []string{"ip", "route"}, print: router.ipRoute},
+	{keywords: []string{"version"}, print: router.version},
+	{keywords: []string{"cdp", "neighbors"}, print: router.cdpNeighbors},
+}
+
+// Duration returns 0, a router answers right away
+func (show) Duration(sim Simulation) time.Duration {
+	return 0
+}
+
+// Simulate writes the output of the show command. The keywords can
+// be abbreviated as on the router (e.g. "sh ip int br")
+func (show) Simulate(ctx context.Context, sim Simulation, out io.Writer) error {
+	hostname := sim.Params.String("hostname", "Router")
+	h := fnv.New32a()
+	h.Write([]byte(hostname))
+	r := router{
+		hostname:   hostname,
+		interfaces: max(1, sim.Params.Int("interfaces", 4)),
+		routes:     max(0, sim.Params.Int("routes", 6)),
+		devices:    max(0, sim.Params.Int("devices", 2)),
+		seed:       h.Sum32(),
+	}
+
+	words := sim.args()[1:]
+	for _, command := range showCommands {
+		if abbreviates(words, command.keywords) {
+			command.print(r, out)
+			return ctx.Err()
+		}
+	}
+	fmt.Fprintln(out, "% Invalid input detected at '^' marker.")
+	return nil
+}
+
+// abbreviates reports whether each word is the start of the keyword
+func abbreviates(words, keywords []string) bool {
+	if len(words) != len(keywords) {
+		return false
+	}
+	for i, word := range words {
+		if word == "" || !strings.HasPrefix(keywords[i], strings.ToLower(word)) {
+			return false
+		}
+	}
+	return true
+}
+
+// name returns the name of the interface with the number
+func (r router) name(i int) string {
+	return fmt.Sprintf("GigabitEthernet0/0/%d", i)
+}
+
+// up reports whether the interface with the number is up: all
+// interfaces are, except the last one of several
+func (r router) up(i int) bool {
+	return r.interfaces == 1 || i < r.interfaces-1
+}
+
+// interfaceBrief writes the output of show ip interface brief
+func (r router) interfaceBrief(out io.Writer) {
+	format := "%-26s %-15s %-3s %-6s %-21s %s\n"
+	fmt.Fprintf(out, format, "Interface", "IP-Address", "OK?", "Method", "Status", "Protocol")
+	for i := 0; i < r.interfaces; i++ {
+		if r.up(i) {
+			fmt.Fprintf(out, format, r.name(i), fmt.Sprintf("10.0.%d.1", i), "YES", "NVRAM", "up", "up")
+		} else {
+			fmt.Fprintf(out, format, r.name(i), "unassigned", "YES", "NVRAM", "administratively down", "down")
+		}
+	}
+}
+
+// ipRoute writes the output of show ip route: the default route, the
+// connected networks and the routes learned from the neighbors
+func (r router) ipRoute(out io.Writer) {
+	up := 0
+	for i := 0; i < r.interfaces; i++ {
+		if r.up(i) {
+			up++
+		}
+	}
+
+	fmt.Fprint(out, routeCodes)
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, "Gateway of last resort is 10.0.0.254 to network 0.0.0.0")
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, "S*    0.0.0.0/0 [1/0] via 10.0.0.254")
+	fmt.Fprintf(out, "      10.0.0.0/8 is variably subnetted, %d subnets, 2 masks\n", 2*up)
+	for i := 0; i < r.interfaces; i++ {
+		if r.up(i) {
+			fmt.Fprintf(out, "C        10.0.%d.0/24 is directly connected, %s\n", i, r.name(i))
+			fmt.Fprintf(out, "L        10.0.%d.1/32 is directly connected, %s\n", i, r.name(i))
+		}
+	}
+
+	if r.routes > 0 {
+		fmt.Fprintf(out, "      172.16.0.0/24 is subnetted, %d subnets\n", r.routes)
+	}
+	for i := 0; i < r.routes; i++ {
+		// Spread the routes over the interfaces that are up
+		via := i % up
+		age := time.Duration(r.seed%72+uint32(i)*7) * time.Hour
+		fmt.Fprintf(out, "O        172.16.%d.0 [110/%d] via 10.0.%d.2, %s, %s\n",
+			i+1, 2+i%3, via, routeAge(age), r.name(via))
+	}
+}
+
+// routeAge formats the age of a route like IOS (e.g. "1d02h" or "05:00:00")
+func routeAge(d time.Duration) string {
+	if d >= 24*time.Hour {
+		return fmt.Sprintf("%dd%02dh", int(d.Hours())/24, int(d.Hours())%24)
+	}
+	return fmt.Sprintf("%02d:%02d:%02d", int(d.Hours()), int(d.Minutes())%60, int(d.Seconds())%60)
+}
+
+// version writes the output of show version
+func (r router) version(out io.Writer) {
+	weeks, days, hours, minutes := r.seed%8, r.seed/8%7, r.seed/56%24, r.seed/1344%60
+	fmt.Fprint(out, `Cisco IOS XE Software, Version 17.09.04a
+Cisco IOS Software [Cupertino], ISR Software (X86_64_LINUX_IOSD-UNIVERSALK9-M), Version 17.9.4a, RELEASE SOFTWARE (fc3)
+Technical Support: http://www.cisco.com/techsupport
+Copyright (c) 1986-2023 by Cisco Systems, Inc.
+Compiled Fri 20-Oct-23 10:44 by mcpre
+
+ROM: 16.12(2r)
+
+`)
+	fmt.Fprintf(out, "%s uptime is %d weeks, %d days, %d hours, %d minutes\n", r.hostname, weeks, days, hours, minutes)
+	fmt.Fprintf(out, "Uptime for this control processor is %d weeks, %d days, %d hours, %d minutes\n", weeks, days, hours, (minutes+2)%60)
+	fmt.Fprint(out, `System returned to ROM by PowerOn
+System image file is "bootflash:isr4300-universalk9.17.09.04a.SPA.bin"
+Last reload reason: PowerOn
+
+cisco ISR4331/K9 (1RU) processor with 1795999K/6147K bytes of memory.
+`)
+	fmt.Fprintf(out, "Processor board ID FDO%04d%c%c%c\n", r.seed%10000, 'A'+rune(r.seed>>8%26), 'A'+rune(r.seed>>13%26), 'A'+rune(r.seed>>18%26))
+	fmt.Fprintln(out, "Router operating mode: Autonomous")
+	fmt.Fprintf(out, "%d Gigabit Ethernet interface%s\n", r.interfaces, plural(r.interfaces))
+	fmt.Fprint(out, `32768K bytes of non-volatile configuration memory.
+4194304K bytes of physical memory.
+3223551K bytes of flash memory at bootflash:.
+
+Configuration register is 0x2102
+`)
+}
+
+// cdpNeighbors writes the output of show cdp neighbors, with the
+// neighbors connected to the interfaces in turn
+func (r router) cdpNeighbors(out io.Writer) {
+	fmt.Fprint(out, `Capability Codes: R - Router, T - Trans Bridge, B - Source Route Bridge
+                  S - Switch, H - Host, I - IGMP, r - Repeater, P - Phone,
+                  D - Remote, C - CVTA, M - Two-port Mac Relay
+
+`)
+	format := "%-16s %-17s %-10s %-11s %-9s %s\n"
+	fmt.Fprintf(out, format, "Device ID", "Local Intrfce", "Holdtme", "Capability", "Platform", "Port ID")
+	for i := 0; i < r.devices; i++ {
+		local := fmt.Sprintf("Gig 0/0/%d", i%r.interfaces)
+		holdtime := fmt.Sprintf("%d", 120+(r.seed>>uint(i%16))%60)
+		if i%2 == 0 {
+			fmt.Fprintf(out, format, fmt.Sprintf("SW%d", i/2+1), local, holdtime, "      S I", "WS-C2960X", fmt.Sprintf("Gig 1/0/%d", i/2+1))
+		} else {
+			fmt.Fprintf(out, format, fmt.Sprintf("R%d", i/2+2), local, holdtime, "    R S I", "ISR4331/K", "Gig 0/0/0")
+		}
+	}
+	fmt.Fprintf(out, "\nTotal cdp entries displayed : %d\n", r.devices)
+}
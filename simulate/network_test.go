@@ -0,0 +1,86 @@
+package simulate_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/bitcanon/autotyper/simulate"
+)
+
+// TestShow tests the simulated show commands of a router, with
+// abbreviated keywords and the counts from the parameters
+func TestShow(t *testing.T) {
+	params := simulate.Params{"hostname": "R1", "interfaces": 3, "routes": 2, "devices": 3}
+
+	tests := []struct {
+		name       string
+		command    string
+		expected   []string
+		unexpected []string
+	}{
+		{
+			name:    "InterfaceBrief",
+			command: "show ip interface brief",
+			expected: []string{
+				"GigabitEthernet0/0/1       10.0.1.1        YES NVRAM  up                    up\n",
+				"GigabitEthernet0/0/2       unassigned      YES NVRAM  administratively down down\n",
+			},
+			unexpected: []string{"GigabitEthernet0/0/3"},
+		},
+		{
+			name:    "Abbreviated",
+			command: "sh ip int br",
+			expected: []string{
+				"Interface                  IP-Address      OK? Method Status                Protocol\n",
+			},
+		},
+		{
+			name:    "Route",
+			command: "show ip route",
+			expected: []string{
+				"Gateway of last resort is 10.0.0.254 to network 0.0.0.0\n",
+				"10.0.0.0/8 is variably subnetted, 4 subnets, 2 masks\n",
+				"L        10.0.1.1/32 is directly connected, GigabitEthernet0/0/1\n",
+				"172.16.0.0/24 is subnetted, 2 subnets\n",
+				"O        172.16.2.0 [110/3] via 10.0.1.2, ",
+			},
+			unexpected: []string{"10.0.2.0/24", "172.16.3.0"},
+		},
+		{
+			name:     "Version",
+			command:  "show ver",
+			expected: []string{"Cisco IOS XE Software", "\nR1 uptime is ", "3 Gigabit Ethernet interfaces\n"},
+		},
+		{
+			name:     "CdpNeighbors",
+			command:  "show cdp nei",
+			expected: []string{"SW1              Gig 0/0/0 ", "R2               Gig 0/0/1 ", "SW2              Gig 0/0/2 ", "Total cdp entries displayed : 3\n"},
+		},
+		{
+			name:     "InvalidInput",
+			command:  "show running-config",
+			expected: []string{"% Invalid input detected at '^' marker.\n"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var out strings.Builder
+			sim := simulate.NewSimulation(test.command, params, false)
+			if err := simulate.Simulate(context.Background(), sim, &out); err != nil {
+				t.Fatalf("expected no error, but got %v", err)
+			}
+			for _, expected := range test.expected {
+				if !strings.Contains(out.String(), expected) {
+					t.Errorf("expected %q in the output, but got %q", expected, out.String())
+				}
+			}
+			for _, unexpected := range test.unexpected {
+				if strings.Contains(out.String(), unexpected) {
+					t.Errorf("expected no %q in the output, but got %q", unexpected, out.String())
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,265 @@
+/*
+Copyright © 2023 Mikael Schultz <bitcanon@proton.me>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package simulate
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"math"
+	"math/rand"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// knownHosts are the addresses of the hosts commonly pinged in demos
+var knownHosts = map[string]string{
+	"localhost":       "127.0.0.1",
+	"one.one.one.one": "1.1.1.1",
+	"dns.google":      "8.8.8.8",
+	"google.com":      "142.250.74.46",
+	"example.com":     "93.184.215.14",
+}
+
+// ping simulates ping, with the parameters:
+//
+//	count     the number of echo requests, unless given with -c or -n (4)
+//	latency   the average round trip time in milliseconds (20)
+//	jitter    the variation of the round trip time in milliseconds (2)
+//	loss      the percentage of lost packets (0)
+//	interval  the time between the requests in milliseconds (1000)
+//	ttl       the time to live of the replies (57)
+type ping struct{}
+
+// pingOptions are the options of a simulated ping
+type pingOptions struct {
+	host     string
+	count    int
+	latency  float64
+	jitter   float64
+	loss     float64
+	interval time.Duration
+	ttl      int
+}
+
+// options reads the options from the arguments and the parameters
+func (ping) options(sim Simulation) pingOptions {
+	opts := pingOptions{
+		count:    sim.Params.Int("count", 4),
+		latency:  sim.Params.Float("latency", 20),
+		jitter:   sim.Params.Float("jitter", 2),
+		loss:     sim.Params.Float("loss", 0),
+		interval: time.Duration(sim.Params.Int("interval", 1000)) * time.Millisecond,
+		ttl:      sim.Params.Int("ttl", 57),
+	}
+
+	args := sim.args()
+	for i := 1; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case (arg == "-c" || arg == "-n" || arg == "/n") && i+1 < len(args):
+			if n, err := strconv.Atoi(args[i+1]); err == nil && n > 0 {
+				opts.count = n
+			}
+			i++
+		case strings.HasPrefix(arg, "-") || strings.HasPrefix(arg, "/"):
+			// Ignore the other options (e.g. "-4")
+		default:
+			opts.host = arg
+		}
+	}
+	return opts
+}
+
+// Duration returns the time of the requests and the last reply
+func (p ping) Duration(sim Simulation) time.Duration {
+	opts := p.options(sim)
+	if opts.host == "" {
+		return 0
+	}
+	return time.Duration(opts.count-1)*opts.interval + time.Duration(opts.latency*float64(time.Millisecond))
+}
+
+// Simulate writes the output of ping: the resolved address, a line
+// per reply (or timeout on Windows) and the statistics
+func (p ping) Simulate(ctx context.Context, sim Simulation, out io.Writer) error {
+	opts := p.options(sim)
+	if opts.host == "" {
+		if sim.Windows {
+			fmt.Fprintln(out, "IP address must be specified.")
+		} else {
+			fmt.Fprintln(out, "ping: usage error: Destination address required")
+		}
+		return nil
+	}
+
+	addr := resolve(opts.host)
+	if sim.Windows {
+		return p.windows(ctx, opts, addr, out)
+	}
+	return p.linux(ctx, opts, addr, out)
+}
+
+// linux writes the output of ping from iputils
+func (p ping) linux(ctx context.Context, opts pingOptions, addr string, out io.Writer) error {
+	from := addr
+	if opts.host != addr {
+		from = fmt.Sprintf("%s (%s)", opts.host, addr)
+	}
+	fmt.Fprintf(out, "PING %s (%s) 56(84) bytes of data.\n", opts.host, addr)
+
+	times, err := p.replies(ctx, opts, func(seq int, rtt float64) {
+		fmt.Fprintf(out, "64 bytes from %s: icmp_seq=%d ttl=%d time=%s ms\n", from, seq, opts.ttl, milliseconds(rtt))
+	}, nil)
+	if err != nil {
+		return err
+	}
+
+	elapsed := time.Duration(opts.count-1)*opts.interval + time.Duration(rand.Intn(10))*time.Millisecond
+	fmt.Fprintf(out, "\n--- %s ping statistics ---\n", opts.host)
+	fmt.Fprintf(out, "%d packets transmitted, %d received, %s%% packet loss, time %dms\n",
+		opts.count, len(times), percent(opts.count-len(times), opts.count), elapsed.Milliseconds())
+	if len(times) > 0 {
+		low, avg, high, mdev := stats(times)
+		fmt.Fprintf(out, "rtt min/avg/max/mdev = %.3f/%.3f/%.3f/%.3f ms\n", low, avg, high, mdev)
+	}
+	return nil
+}
+
+// windows writes the output of ping on Windows
+func (p ping) windows(ctx context.Context, opts pingOptions, addr string, out io.Writer) error {
+	target := addr
+	if opts.host != addr {
+		target = fmt.Sprintf("%s [%s]", opts.host, addr)
+	}
+	fmt.Fprintf(out, "\nPinging %s with 32 bytes of data:\n", target)
+
+	times, err := p.replies(ctx, opts, func(seq int, rtt float64) {
+		elapsed := fmt.Sprintf("=%dms", int(math.Round(rtt)))
+		if rtt < 1 {
+			elapsed = "<1ms"
+		}
+		fmt.Fprintf(out, "Reply from %s: bytes=32 time%s TTL=%d\n", addr, elapsed, opts.ttl)
+	}, func(seq int) {
+		fmt.Fprintln(out, "Request timed out.")
+	})
+	if err != nil {
+		return err
+	}
+
+	lost := opts.count - len(times)
+	fmt.Fprintf(out, "\nPing statistics for %s:\n", addr)
+	fmt.Fprintf(out, "    Packets: Sent = %d, Received = %d, Lost = %d (%d%% loss),\n",
+		opts.count, len(times), lost, lost*100/opts.count)
+	if len(times) > 0 {
+		low, avg, high, _ := stats(times)
+		fmt.Fprintln(out, "Approximate round trip times in milli-seconds:")
+		fmt.Fprintf(out, "    Minimum = %dms, Maximum = %dms, Average = %dms\n",
+			int(math.Round(low)), int(math.Round(high)), int(math.Round(avg)))
+	}
+	return nil
+}
+
+// replies sends the echo requests, calling reply for the packets
+// that come back and lost (if not nil) for the others. The round
+// trip times of the replies are returned
+func (ping) replies(ctx context.Context, opts pingOptions, reply func(int, float64), lost func(int)) ([]float64, error) {
+	var times []float64
+	for seq := 1; seq <= opts.count; seq++ {
+		rtt := math.Max(0.1, opts.latency+opts.jitter*(2*rand.Float64()-1))
+		wait := time.Duration(rtt * float64(time.Millisecond))
+		if seq > 1 {
+			wait = opts.interval
+		}
+		if err := pause(ctx, wait); err != nil {
+			return nil, err
+		}
+
+		if rand.Float64()*100 < opts.loss {
+			if lost != nil {
+				lost(seq)
+			}
+			continue
+		}
+		reply(seq, rtt)
+		times = append(times, rtt)
+	}
+	return times, nil
+}
+
+// resolve returns the address of the host: the host itself if it is
+// an IP address, a well-known address, or an address derived from
+// the name, so that the same host always resolves the same
+func resolve(host string) string {
+	if net.ParseIP(host) != nil {
+		return host
+	}
+	if addr, ok := knownHosts[strings.ToLower(host)]; ok {
+		return addr
+	}
+
+	h := fnv.New32a()
+	h.Write([]byte(strings.ToLower(host)))
+	sum := h.Sum32()
+	first := 20 + byte(sum>>24)%180
+	if first == 127 {
+		first++
+	}
+	return fmt.Sprintf("%d.%d.%d.%d", first, byte(sum>>16), byte(sum>>8), 1+byte(sum)%254)
+}
+
+// milliseconds formats a round trip time with three significant
+// digits, as ping does (e.g. "1.23", "12.3" or "123")
+func milliseconds(ms float64) string {
+	switch {
+	case ms >= 100:
+		return fmt.Sprintf("%.0f", ms)
+	case ms >= 10:
+		return fmt.Sprintf("%.1f", ms)
+	default:
+		return fmt.Sprintf("%.2f", ms)
+	}
+}
+
+// percent formats the share of the packets that were lost
+func percent(lost, count int) string {
+	return strconv.FormatFloat(math.Round(float64(lost)*100/float64(count)), 'f', -1, 64)
+}
+
+// stats returns the minimum, average, maximum and mean
+// deviation of the round trip times
+func stats(times []float64) (float64, float64, float64, float64) {
+	low, high := times[0], times[0]
+	var sum, squares float64
+	for _, t := range times {
+		low = math.Min(low, t)
+		high = math.Max(high, t)
+		sum += t
+		squares += t * t
+	}
+	avg := sum / float64(len(times))
+	mdev := math.Sqrt(math.Max(0, squares/float64(len(times))-avg*avg))
+	return low, avg, high, mdev
+}
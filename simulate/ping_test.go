@@ -0,0 +1,131 @@
+package simulate_test
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bitcanon/autotyper/simulate"
+)
+
+// TestPing tests the simulated output of ping in the styles
+// of Linux and Windows, with and without lost packets
+func TestPing(t *testing.T) {
+	fast := simulate.Params{"interval": 0, "latency": 5, "jitter": 0}
+	lossy := simulate.Params{"interval": 0, "latency": 5, "loss": 100}
+
+	tests := []struct {
+		name     string
+		command  string
+		params   simulate.Params
+		windows  bool
+		expected []string
+	}{
+		{
+			name:    "Linux",
+			command: "ping -c 3 one.one.one.one",
+			params:  fast,
+			expected: []string{
+				"PING one.one.one.one (1.1.1.1) 56(84) bytes of data.\n",
+				"64 bytes from one.one.one.one (1.1.1.1): icmp_seq=3 ttl=57 time=5.00 ms\n",
+				"--- one.one.one.one ping statistics ---\n3 packets transmitted, 3 received, 0% packet loss, time ",
+				"rtt min/avg/max/mdev = 5.000/5.000/5.000/0.000 ms\n",
+			},
+		},
+		{
+			name:    "LinuxAddress",
+			command: "ping 10.0.0.1",
+			params:  fast,
+			expected: []string{
+				"PING 10.0.0.1 (10.0.0.1) 56(84) bytes of data.\n",
+				"64 bytes from 10.0.0.1: icmp_seq=4 ttl=57",
+				"4 packets transmitted, 4 received",
+			},
+		},
+		{
+			name:     "LinuxLoss",
+			command:  "ping -c 2 dns.google",
+			params:   lossy,
+			expected: []string{"2 packets transmitted, 0 received, 100% packet loss, time "},
+		},
+		{
+			name:    "Windows",
+			command: "ping dns.google",
+			params:  fast,
+			windows: true,
+			expected: []string{
+				"\nPinging dns.google [8.8.8.8] with 32 bytes of data:\n",
+				"Reply from 8.8.8.8: bytes=32 time=5ms TTL=57\n",
+				"Packets: Sent = 4, Received = 4, Lost = 0 (0% loss),\n",
+				"Minimum = 5ms, Maximum = 5ms, Average = 5ms\n",
+			},
+		},
+		{
+			name:     "WindowsLoss",
+			command:  "ping -n 2 dns.google",
+			params:   lossy,
+			windows:  true,
+			expected: []string{"Request timed out.\nRequest timed out.\n", "Lost = 2 (100% loss),\n"},
+		},
+		{
+			name:     "MissingHost",
+			command:  "ping",
+			expected: []string{"Destination address required"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var out strings.Builder
+			sim := simulate.NewSimulation(test.command, test.params, test.windows)
+			if err := simulate.Simulate(context.Background(), sim, &out); err != nil {
+				t.Fatalf("expected no error, but got %v", err)
+			}
+			for _, expected := range test.expected {
+				if !strings.Contains(out.String(), expected) {
+					t.Errorf("expected %q in the output, but got %q", expected, out.String())
+				}
+			}
+		})
+	}
+}
+
+// TestPingResolve tests that a host without a well-known address
+// always resolves to the same address
+func TestPingResolve(t *testing.T) {
+	address := regexp.MustCompile(`PING build\.internal \((\d+\.\d+\.\d+\.\d+)\)`)
+	var first string
+	for i := 0; i < 2; i++ {
+		var out strings.Builder
+		sim := simulate.NewSimulation("ping -c 1 build.internal", simulate.Params{"latency": 1}, false)
+		if err := simulate.Simulate(context.Background(), sim, &out); err != nil {
+			t.Fatalf("expected no error, but got %v", err)
+		}
+		match := address.FindStringSubmatch(out.String())
+		if match == nil {
+			t.Fatalf("expected a resolved address, but got %q", out.String())
+		}
+		if first != "" && match[1] != first {
+			t.Errorf("expected %s again, but got %s", first, match[1])
+		}
+		first = match[1]
+	}
+}
+
+// TestPingDuration tests the expected duration of the requests
+// and that a cancelled ping returns early
+func TestPingDuration(t *testing.T) {
+	s, _ := simulate.Lookup("ping")
+	sim := simulate.NewSimulation("ping -c 5 localhost", simulate.Params{"latency": 10}, false)
+	if d := s.Duration(sim); d != 4010*time.Millisecond {
+		t.Errorf("expected 4.01s, but got %v", d)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := s.Simulate(ctx, sim, &strings.Builder{}); err == nil {
+		t.Errorf("expected error for a cancelled context, but got nil")
+	}
+}
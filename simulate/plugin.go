@@ -0,0 +1,148 @@
+/*
+Copyright © 2023 Mikael Schultz <bitcanon@proton.me>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package simulate
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// maxChunkSize is the maximum length of a line written by a plugin
+const maxChunkSize = 1 << 20
+
+// Plugin is an external simulator, for commands without a built-in
+// simulator. The plugin is a program of any language that receives
+// the simulation as a JSON object on its standard input:
+//
+//	{"command": "kubectl get pods", "args": ["kubectl", "get", "pods"],
+//	 "params": {"pods": 3}, "windows": false, "step": 2}
+//
+// and writes the output on its standard output as JSON chunks, one per
+// line, each printed after a delay in milliseconds:
+//
+//	{"delay": 500, "text": "NAME    READY   STATUS\n"}
+type Plugin struct {
+	// The command line of the program (e.g. "python3 kubectl.py")
+	Command string
+
+	// The working directory of the program, if not the current one
+	Dir string
+}
+
+// request is the simulation sent to a plugin
+type request struct {
+	Command string   `json:"command"`
+	Args    []string `json:"args"`
+	Params  Params   `json:"params,omitempty"`
+	Windows bool     `json:"windows"`
+	Step    int      `json:"step"`
+}
+
+// chunk is a part of the output of a plugin
+type chunk struct {
+	Delay int    `json:"delay,omitempty"`
+	Text  string `json:"text"`
+}
+
+// Duration returns 0, the time of the output is only known
+// once the plugin has run
+func (Plugin) Duration(sim Simulation) time.Duration {
+	return 0
+}
+
+// Simulate runs the plugin and writes the chunks of its output, as
+// they are received, after their delays
+func (p Plugin) Simulate(ctx context.Context, sim Simulation, out io.Writer) error {
+	args := strings.Fields(p.Command)
+	if len(args) == 0 {
+		return fmt.Errorf("missing command of the simulator for %q", sim.Command)
+	}
+
+	input, err := json.Marshal(request{Command: sim.Command, Args: sim.Args, Params: sim.Params, Windows: sim.Windows, Step: sim.Step})
+	if err != nil {
+		return err
+	}
+
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Dir = p.Dir
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Stderr = &stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("simulator %s: %w", args[0], err)
+	}
+
+	err = copyChunks(ctx, stdout, out)
+	if err != nil {
+		// Stop the plugin, the rest of its output is not wanted
+		cmd.Process.Kill()
+	}
+	if waitErr := cmd.Wait(); err == nil && waitErr != nil {
+		err = waitErr
+		if ctx.Err() == nil {
+			if msg := strings.TrimSpace(stderr.String()); msg != "" {
+				err = fmt.Errorf("%w: %s", waitErr, msg)
+			}
+		}
+	}
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	if err != nil {
+		return fmt.Errorf("simulator %s: %w", args[0], err)
+	}
+	return nil
+}
+
+// copyChunks writes the chunks read from a simulator to out
+func copyChunks(ctx context.Context, stdout io.Reader, out io.Writer) error {
+	scanner := bufio.NewScanner(stdout)
+	scanner.Buffer(nil, maxChunkSize)
+	for line := 1; scanner.Scan(); line++ {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+
+		var c chunk
+		if err := json.Unmarshal(scanner.Bytes(), &c); err != nil {
+			return fmt.Errorf("invalid chunk on line %d: %w", line, err)
+		}
+		if err := pause(ctx, time.Duration(c.Delay)*time.Millisecond); err != nil {
+			return err
+		}
+		if _, err := io.WriteString(out, c.Text); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
@@ -0,0 +1,102 @@
+package simulate_test
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bitcanon/autotyper/simulate"
+)
+
+// writePlugin writes a shell script plugin to a temporary directory,
+// which saves its input to request.json before running the body
+func writePlugin(t *testing.T, body string) string {
+	t.Helper()
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh is not available")
+	}
+	dir := t.TempDir()
+	script := "cat > request.json\n" + body + "\n"
+	if err := os.WriteFile(filepath.Join(dir, "plugin.sh"), []byte(script), 0o644); err != nil {
+		t.Fatalf("failed to write plugin: %v", err)
+	}
+	return dir
+}
+
+// TestPlugin tests that the simulation is sent to the plugin and
+// that the chunks of its output are written after their delays
+func TestPlugin(t *testing.T) {
+	dir := writePlugin(t, `printf '%s\n' '{"text": "NAME   READY\n"}' ''
+printf '%s\n' '{"delay": 100, "text": "web-1  1/1\n"}'`)
+	plugin := simulate.Plugin{Command: "sh plugin.sh", Dir: dir}
+
+	sim := simulate.NewSimulation("kubectl get pods", simulate.Params{"pods": 1}, false)
+	sim.Step = 3
+
+	var out strings.Builder
+	start := time.Now()
+	if err := plugin.Simulate(context.Background(), sim, &out); err != nil {
+		t.Fatalf("expected no error, but got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 100*time.Millisecond {
+		t.Errorf("expected a delay of 100ms, but the output took %v", elapsed)
+	}
+	if out.String() != "NAME   READY\nweb-1  1/1\n" {
+		t.Errorf("expected the chunks of the plugin, but got %q", out.String())
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "request.json"))
+	if err != nil {
+		t.Fatalf("failed to read the request: %v", err)
+	}
+	var request map[string]any
+	if err := json.Unmarshal(data, &request); err != nil {
+		t.Fatalf("expected a JSON request, but got %q: %v", data, err)
+	}
+	if request["command"] != "kubectl get pods" || request["step"] != 3.0 || request["windows"] != false {
+		t.Errorf("expected the simulation in the request, but got %s", data)
+	}
+	if args, _ := request["args"].([]any); len(args) != 3 {
+		t.Errorf("expected the arguments in the request, but got %s", data)
+	}
+	if params, _ := request["params"].(map[string]any); params["pods"] != 1.0 {
+		t.Errorf("expected the parameters in the request, but got %s", data)
+	}
+}
+
+// TestPluginErrors tests that invalid output, failures and
+// cancellations of the plugins are reported
+func TestPluginErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		expected string
+	}{
+		{name: "InvalidChunk", body: "echo 'NAME READY'", expected: "invalid chunk on line 1"},
+		{name: "Failure", body: "echo 'no cluster' >&2; exit 3", expected: "exit status 3: no cluster"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			plugin := simulate.Plugin{Command: "sh plugin.sh", Dir: writePlugin(t, test.body)}
+			err := plugin.Simulate(context.Background(), simulate.NewSimulation("kubectl get pods", nil, false), &strings.Builder{})
+			if err == nil || !strings.Contains(err.Error(), test.expected) {
+				t.Errorf("expected an error with %q, but got %v", test.expected, err)
+			}
+		})
+	}
+
+	t.Run("Cancelled", func(t *testing.T) {
+		plugin := simulate.Plugin{Command: "sh plugin.sh", Dir: writePlugin(t, `echo '{"delay": 10000, "text": "late"}'`)}
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+		if err := plugin.Simulate(ctx, simulate.NewSimulation("kubectl get pods", nil, false), &strings.Builder{}); err != context.DeadlineExceeded {
+			t.Errorf("expected %v, but got %v", context.DeadlineExceeded, err)
+		}
+	})
+}
@@ -0,0 +1,154 @@
+/*
+Copyright © 2023 Mikael Schultz <bitcanon@proton.me>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package simulate
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// Fake is a template of the output of the commands matching a
+// pattern. The template is executed with a FakeData value
+type Fake struct {
+	Pattern  *regexp.Regexp
+	Template *template.Template
+}
+
+// FakeData is the data available to the templates of fakes
+type FakeData struct {
+	// The full command and its arguments (the first
+	// argument is the name of the executable)
+	Command string
+	Args    []string
+
+	// The number of the step running the command, from 1
+	Step int
+
+	// The iteration of the repeat block of the step, from 1,
+	// or 0 if the step is not repeated
+	Iteration int
+}
+
+// NewFake creates a fake from a regular expression
+// and the text of an output template
+func NewFake(pattern, output string) (Fake, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return Fake{}, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+	}
+	tmpl, err := ParseOutput(pattern, output)
+	if err != nil {
+		return Fake{}, fmt.Errorf("invalid output for %q: %w", pattern, err)
+	}
+	return Fake{Pattern: re, Template: tmpl}, nil
+}
+
+// Sandbox simulates the output of commands without executing them.
+// The first fake matching a command is used. Otherwise, a few common
+// commands get a generated output and all other commands succeed
+// silently
+type Sandbox struct {
+	Fakes []Fake
+
+	// The user and host reported by whoami and hostname
+	Username string
+	Hostname string
+}
+
+// Run writes the simulated output of the command, run by the
+// step with the number in the iteration of its repeat block, to out
+func (s *Sandbox) Run(command string, step, iteration int, out io.Writer) error {
+	args := strings.Fields(command)
+	if len(args) == 0 {
+		return nil
+	}
+
+	for _, fake := range s.Fakes {
+		if fake.Pattern.MatchString(command) {
+			return fake.Template.Execute(out, FakeData{Command: command, Args: args, Step: step, Iteration: iteration})
+		}
+	}
+
+	switch args[0] {
+	case "echo":
+		fmt.Fprintln(out, strings.Join(args[1:], " "))
+	case "pwd":
+		dir, err := os.Getwd()
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(out, dir)
+	case "ls", "dir":
+		return list(args[1:], out)
+	case "whoami":
+		fmt.Fprintln(out, s.Username)
+	case "hostname":
+		fmt.Fprintln(out, s.Hostname)
+	case "date":
+		fmt.Fprintln(out, time.Now().Format("Mon Jan _2 15:04:05 MST 2006"))
+	}
+	return nil
+}
+
+// list writes the names of the files in the directory given in
+// the arguments (or the working directory) to out. Reading the
+// directory is harmless, so the real directory is listed
+func list(args []string, out io.Writer) error {
+	dir := "."
+	for _, arg := range args {
+		// Skip the options of ls (e.g. "-la") and dir (e.g. "/w")
+		if strings.HasPrefix(arg, "-") || len(arg) == 2 && arg[0] == '/' {
+			continue
+		}
+		dir = arg
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		// The directory may only exist in the demo environment
+		return nil
+	}
+
+	var names []string
+	for _, entry := range entries {
+		if strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		name := entry.Name()
+		if entry.IsDir() {
+			name += "/"
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	if len(names) > 0 {
+		fmt.Fprintln(out, strings.Join(names, "  "))
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+package simulate_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bitcanon/autotyper/simulate"
+)
+
+// TestSandboxRun tests the simulated output of commands
+func TestSandboxRun(t *testing.T) {
+	fake, err := simulate.NewFake(`^kubectl get pods`, "NAME READY\n{{index .Args 3}} 1/1\n")
+	if err != nil {
+		t.Fatalf("expected no error, but got %v", err)
+	}
+	sandbox := &simulate.Sandbox{
+		Fakes:    []simulate.Fake{fake},
+		Username: "bitcanon",
+		Hostname: "code",
+	}
+
+	tests := []struct {
+		name     string
+		command  string
+		expected string
+	}{
+		{name: "Fake", command: "kubectl get pods web", expected: "NAME READY\nweb 1/1\n"},
+		{name: "Echo", command: "echo hello  world", expected: "hello world\n"},
+		{name: "Whoami", command: "whoami", expected: "bitcanon\n"},
+		{name: "Hostname", command: "hostname", expected: "code\n"},
+		{name: "Unknown", command: "rm -rf /", expected: ""},
+		{name: "Empty", command: "", expected: ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var out strings.Builder
+			if err := sandbox.Run(test.command, 1, 0, &out); err != nil {
+				t.Fatalf("expected no error, but got %v", err)
+			}
+			if out.String() != test.expected {
+				t.Errorf("expected %q, but got %q", test.expected, out.String())
+			}
+		})
+	}
+}
+
+// TestSandboxList tests that ls lists the directory
+// given in the arguments, skipping the options
+func TestSandboxList(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"b.txt", "a.txt", ".hidden"} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0o644); err != nil {
+			t.Fatalf("failed to write file: %v", err)
+		}
+	}
+	if err := os.Mkdir(filepath.Join(dir, "docs"), 0o755); err != nil {
+		t.Fatalf("failed to create directory: %v", err)
+	}
+
+	var out strings.Builder
+	sandbox := &simulate.Sandbox{}
+	if err := sandbox.Run("ls -la "+dir, 1, 0, &out); err != nil {
+		t.Fatalf("expected no error, but got %v", err)
+	}
+
+	expected := "a.txt  b.txt  docs/\n"
+	if out.String() != expected {
+		t.Errorf("expected %q, but got %q", expected, out.String())
+	}
+}
+
+// TestNewFake tests that invalid patterns and templates are reported
+func TestNewFake(t *testing.T) {
+	if _, err := simulate.NewFake("(", "output"); err == nil {
+		t.Errorf("expected error for an invalid pattern, but got nil")
+	}
+	if _, err := simulate.NewFake("^ls", "{{.Command"); err == nil {
+		t.Errorf("expected error for an invalid template, but got nil")
+	}
+}
@@ -0,0 +1,194 @@
+/*
+Copyright © 2023 Mikael Schultz <bitcanon@proton.me>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package simulate
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Simulation is a command whose output is generated by a simulator
+type Simulation struct {
+	// The full command and its arguments (the first
+	// argument is the name of the simulated command)
+	Command string
+	Args    []string
+
+	// The parameters of the simulator from the scenario
+	Params Params
+
+	// Generate the output of the Windows version of the command
+	Windows bool
+
+	// The number of the step running the command, from 1
+	Step int
+}
+
+// NewSimulation splits the command into its arguments
+func NewSimulation(command string, params Params, windows bool) Simulation {
+	return Simulation{Command: command, Args: strings.Fields(command), Params: params, Windows: windows}
+}
+
+// Simulator generates the output of a command, pausing between the
+// lines as the real command would, without running anything
+type Simulator interface {
+	// Simulate writes the output to out. It returns early
+	// with the error of the context if it is cancelled
+	Simulate(ctx context.Context, sim Simulation, out io.Writer) error
+
+	// Duration returns how long the simulation is expected to take
+	Duration(sim Simulation) time.Duration
+}
+
+// simulators are the built-in simulators by command name
+var simulators = map[string]Simulator{
+	"docker": docker{},
+	"ping":   ping{},
+
+	// Network devices
+	"show": show{},
+	"sh":   show{},
+
+	// Package managers
+	"apt":     installer{install: apt(true), ticks: func(n int) int { return 4 + 3*n }, unknown: "E: Invalid operation %s"},
+	"apt-get": installer{install: apt(false), ticks: func(n int) int { return 4 + 3*n }, unknown: "E: Invalid operation %s"},
+	"yum":     installer{install: yum, ticks: func(n int) int { return 3 + 3*n }, unknown: "No such command: %s. Please use /usr/bin/yum --help"},
+	"dnf":     installer{install: yum, ticks: func(n int) int { return 3 + 3*n }, unknown: "No such command: %s. Please use /usr/bin/dnf --help"},
+	"pip":     installer{install: pip, ticks: func(n int) int { return 1 + (1+pipFrames)*n }, unknown: `ERROR: unknown command "%s"`},
+	"pip3":    installer{install: pip, ticks: func(n int) int { return 1 + (1+pipFrames)*n }, unknown: `ERROR: unknown command "%s"`},
+}
+
+// Lookup returns the built-in simulator of the command, by the name
+// of its executable. The second return value reports whether the
+// command has a simulator
+func Lookup(command string) (Simulator, bool) {
+	s, ok := simulators[Name(command)]
+	return s, ok
+}
+
+// Name returns the name of the executable of the command, after
+// any sudo, or an empty string if the command is empty
+func Name(command string) string {
+	args := withoutSudo(strings.Fields(command))
+	if len(args) == 0 {
+		return ""
+	}
+	return args[0]
+}
+
+// args returns the arguments of the simulated command, without
+// a leading sudo (e.g. "sudo apt install jq")
+func (s Simulation) args() []string {
+	return withoutSudo(s.Args)
+}
+
+// withoutSudo removes a leading sudo from the arguments
+func withoutSudo(args []string) []string {
+	if len(args) > 0 && args[0] == "sudo" {
+		return args[1:]
+	}
+	return args
+}
+
+// Names returns the names of the simulated commands, sorted
+func Names() []string {
+	names := make([]string, 0, len(simulators))
+	for name := range simulators {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Simulate writes the simulated output of the command to out
+func Simulate(ctx context.Context, sim Simulation, out io.Writer) error {
+	s, ok := Lookup(sim.Command)
+	if !ok {
+		return fmt.Errorf("no simulator for %q", sim.Command)
+	}
+	return s.Simulate(ctx, sim, out)
+}
+
+// Params are the parameters of a simulator. Values decoded from
+// JSON, TOML and YAML are accepted, as numbers or strings
+type Params map[string]any
+
+// Float returns the parameter as a number, or def if it is not set
+// or not a number
+func (p Params) Float(name string, def float64) float64 {
+	switch v := p[name].(type) {
+	case float64:
+		return v
+	case int:
+		return float64(v)
+	case int64:
+		return float64(v)
+	case uint64:
+		return float64(v)
+	case string:
+		if f, err := strconv.ParseFloat(v, 64); err == nil {
+			return f
+		}
+	}
+	return def
+}
+
+// Int returns the parameter as an integer, or def if it is not set
+// or not a number
+func (p Params) Int(name string, def int) int {
+	return int(p.Float(name, float64(def)))
+}
+
+// String returns the parameter as a string, or def if it is not set
+func (p Params) String(name, def string) string {
+	switch v := p[name].(type) {
+	case nil:
+		return def
+	case string:
+		return v
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// pause waits for the duration or until the context is
+// cancelled, whichever happens first
+func pause(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
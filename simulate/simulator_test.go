@@ -0,0 +1,55 @@
+package simulate_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/bitcanon/autotyper/simulate"
+)
+
+// TestParams tests that the parameters are read as numbers
+// and strings, falling back to the defaults
+func TestParams(t *testing.T) {
+	params := simulate.Params{"float": 2.5, "int": 3, "int64": int64(4), "string": "5", "word": "fast"}
+
+	tests := []struct {
+		name     string
+		expected float64
+	}{
+		{name: "float", expected: 2.5},
+		{name: "int", expected: 3},
+		{name: "int64", expected: 4},
+		{name: "string", expected: 5},
+		{name: "word", expected: 1},
+		{name: "missing", expected: 1},
+	}
+
+	for _, test := range tests {
+		if value := params.Float(test.name, 1); value != test.expected {
+			t.Errorf("%s: expected %v, but got %v", test.name, test.expected, value)
+		}
+	}
+	if value := params.String("int", ""); value != "3" {
+		t.Errorf("expected %q, but got %q", "3", value)
+	}
+	if value := params.String("missing", "slow"); value != "slow" {
+		t.Errorf("expected %q, but got %q", "slow", value)
+	}
+}
+
+// TestSimulate tests that the simulators are looked up by the
+// name of the command
+func TestSimulate(t *testing.T) {
+	if _, ok := simulate.Lookup("ping localhost"); !ok {
+		t.Errorf("expected a simulator for ping")
+	}
+	if _, ok := simulate.Lookup("  "); ok {
+		t.Errorf("expected no simulator for an empty command")
+	}
+
+	var out strings.Builder
+	if err := simulate.Simulate(context.Background(), simulate.NewSimulation("traceroute localhost", nil, false), &out); err == nil {
+		t.Errorf("expected error for a command without simulator, but got nil")
+	}
+}
@@ -0,0 +1,98 @@
+/*
+Copyright © 2023 Mikael Schultz <bitcanon@proton.me>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package simulate
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"time"
+
+	"github.com/bitcanon/autotyper/wasm"
+)
+
+// WASM is an external simulator built as a WebAssembly module for
+// WASI, run in a sandbox instead of a subprocess. The module follows
+// the protocol of a Plugin: it reads the simulation as JSON on its
+// standard input, and writes the chunks of output on its standard output
+type WASM struct {
+	// The path of the module (e.g. "kubectl.wasm")
+	Path string
+
+	// The directory of a relative path, if not the current one
+	Dir string
+}
+
+// Duration returns 0, the time of the output is only known
+// once the module has run
+func (WASM) Duration(sim Simulation) time.Duration {
+	return 0
+}
+
+// Simulate runs the module and writes the chunks of its output, as
+// they are received, after their delays
+func (w WASM) Simulate(ctx context.Context, sim Simulation, out io.Writer) error {
+	path := w.Path
+	if w.Dir != "" && !filepath.IsAbs(path) {
+		path = filepath.Join(w.Dir, path)
+	}
+	module, err := wasm.Load(path)
+	if err != nil {
+		return fmt.Errorf("simulator %s: %w", w.Path, err)
+	}
+
+	input, err := json.Marshal(request{Command: sim.Command, Args: sim.Args, Params: sim.Params, Windows: sim.Windows, Step: sim.Step})
+	if err != nil {
+		return err
+	}
+
+	// Stop the module if its output cannot be printed
+	run, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	r, stdout := io.Pipe()
+	done := make(chan error, 1)
+	go func() {
+		err := module.Run(run, bytes.NewReader(input), stdout, nil)
+		stdout.Close()
+		done <- err
+	}()
+
+	err = copyChunks(ctx, r, out)
+	if err != nil {
+		cancel()
+		r.CloseWithError(err)
+	}
+	if runErr := <-done; err == nil {
+		err = runErr
+	}
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	if err != nil {
+		return fmt.Errorf("simulator %s: %w", w.Path, err)
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+package simulate_test
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bitcanon/autotyper/simulate"
+)
+
+// buildModule builds the program of the tests in testdata/wasm for
+// WASI into dir and returns the name of the module
+func buildModule(t *testing.T, name, dir string) string {
+	t.Helper()
+	if testing.Short() {
+		t.Skip("building WebAssembly modules is slow")
+	}
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go is not available")
+	}
+
+	cmd := exec.Command("go", "build", "-o", filepath.Join(dir, name+".wasm"), filepath.Join("..", "testdata", "wasm", name))
+	cmd.Env = append(os.Environ(), "GOOS=wasip1", "GOARCH=wasm")
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("failed to build %s: %v\n%s", name, err, output)
+	}
+	return name + ".wasm"
+}
+
+// TestWASM tests that the simulation is sent to a WebAssembly module
+// in the directory of the scenario, and that its chunks are written
+func TestWASM(t *testing.T) {
+	dir := t.TempDir()
+	simulator := simulate.WASM{Path: buildModule(t, "kubectl", dir), Dir: dir}
+
+	var out strings.Builder
+	sim := simulate.NewSimulation("kubectl get pods", simulate.Params{"pods": 2}, false)
+	if err := simulator.Simulate(context.Background(), sim, &out); err != nil {
+		t.Fatalf("expected no error, but got %v", err)
+	}
+	expected := "NAME    STATUS\nweb-1   Running\nweb-2   Running\n"
+	if out.String() != expected {
+		t.Errorf("expected %q, but got %q", expected, out.String())
+	}
+
+	// A module that is not a simulator fails with the invalid chunk
+	simulator.Path = buildModule(t, "upper", dir)
+	if err := simulator.Simulate(context.Background(), sim, &out); err == nil || !strings.Contains(err.Error(), "invalid chunk") {
+		t.Errorf("expected an invalid chunk, but got %v", err)
+	}
+	simulator.Path = "missing.wasm"
+	if err := simulator.Simulate(context.Background(), sim, &out); err == nil {
+		t.Errorf("expected error for a missing module, but got nil")
+	}
+}
@@ -0,0 +1,95 @@
+/*
+Copyright © 2023 Mikael Schultz <bitcanon@proton.me>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package target
+
+import (
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// launchITerm opens a window of iTerm2 with the profile and types the
+// command into its session, or into the session of the front window
+func launchITerm(command []string, opts Options) error {
+	script := []string{`tell application "iTerm2"`, "activate"}
+	switch {
+	case opts.Attach:
+		script = append(script, "set w to current window")
+	case opts.Profile != "":
+		script = append(script, "set w to (create window with profile "+appleString(opts.Profile)+")")
+	default:
+		script = append(script, "set w to (create window with default profile)")
+	}
+	script = append(script,
+		"tell current session of w to write text "+appleString(windowCommand(command, opts)),
+		"end tell",
+	)
+	return osascript(script)
+}
+
+// launchTerminal opens a window of Terminal.app running the command,
+// or runs it in the front window, with the settings of the profile
+func launchTerminal(command []string, opts Options) error {
+	script := []string{`tell application "Terminal"`, "activate"}
+	if opts.Attach {
+		script = append(script, "set t to do script "+appleString(windowCommand(command, opts))+" in front window")
+	} else {
+		script = append(script, "set t to do script "+appleString(windowCommand(command, opts)))
+	}
+	if opts.Profile != "" {
+		script = append(script, "set current settings of t to settings set "+appleString(opts.Profile))
+	}
+	script = append(script, "end tell")
+	return osascript(script)
+}
+
+// windowCommand returns the line typed into the shell of the window.
+// A new window runs nothing else once the demo has ended
+func windowCommand(command []string, opts Options) string {
+	if opts.Attach {
+		return shellCommand(command)
+	}
+	return "exec " + shellCommand(command)
+}
+
+// osascript runs the AppleScript given line by line
+func osascript(script []string) error {
+	if runtime.GOOS != "darwin" {
+		return unavailable("macOS")
+	}
+	args := make([]string, 0, 2*len(script))
+	for _, line := range script {
+		args = append(args, "-e", line)
+	}
+	output, err := exec.Command("osascript", args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%w: %s", err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// appleString returns the text as an AppleScript string literal
+func appleString(text string) string {
+	text = strings.ReplaceAll(text, `\`, `\\`)
+	return `"` + strings.ReplaceAll(text, `"`, `\"`) + `"`
+}
@@ -0,0 +1,219 @@
+/*
+Copyright © 2023 Mikael Schultz <bitcanon@proton.me>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package target
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bitcanon/autotyper/terminal"
+)
+
+// A Launcher opens a window of a terminal application (or attaches
+// to one) and runs the command in it
+type Launcher func(command []string, opts Options) error
+
+// Launchers are the terminal applications the demo can be played in,
+// by name
+var Launchers = map[string]Launcher{
+	"iterm":    launchITerm,
+	"terminal": launchTerminal,
+	"vscode":   launchVSCode,
+	"wt":       launchWindowsTerminal,
+}
+
+// Options configure the window the demo is played in
+type Options struct {
+	// Command runs the sink of the window, usually the autotyper
+	// executable with its sink command. The address of the player
+	// and the token of the connection are appended to it
+	Command []string
+
+	// Profile is the profile of the terminal application the window
+	// is opened with (e.g. with a larger font for the recording), the
+	// default profile if empty
+	Profile string
+
+	// Attach plays in the front window of the application
+	// instead of opening a new one
+	Attach bool
+
+	// Timeout is how long to wait for the window
+	// to connect, 2 minutes if zero
+	Timeout time.Duration
+}
+
+// Window is the window of a terminal application the demo is played
+// in. The output written to the window is printed by its sink
+type Window struct {
+	conn  net.Conn
+	width int
+}
+
+// Names returns the names of the launchers, sorted
+func Names() []string {
+	names := make([]string, 0, len(Launchers))
+	for name := range Launchers {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// Open opens the window of the named terminal application and waits
+// for its sink to connect back over the loopback interface, until the
+// context is done. Only the sink given the token of the connection is
+// accepted
+func Open(ctx context.Context, name string, opts Options) (*Window, error) {
+	launch, ok := Launchers[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown target %q (%s)", name, strings.Join(Names(), ", "))
+	}
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+	defer ln.Close()
+	defer context.AfterFunc(ctx, func() { ln.Close() })()
+	key := make([]byte, 16)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	token := hex.EncodeToString(key)
+
+	command := append(slices.Clip(opts.Command), ln.Addr().String(), token)
+	slog.Debug("opening target window", "target", name, "profile", opts.Profile, "attach", opts.Attach)
+	if err := launch(command, opts); err != nil {
+		return nil, fmt.Errorf("failed to open the %s window: %w", name, err)
+	}
+
+	timeout := opts.Timeout
+	if timeout == 0 {
+		timeout = 2 * time.Minute
+	}
+	ln.(*net.TCPListener).SetDeadline(time.Now().Add(timeout))
+	for {
+		conn, err := ln.Accept()
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if err != nil {
+			return nil, fmt.Errorf("the %s window did not connect: %w", name, err)
+		}
+		width, err := handshake(conn, token)
+		if err != nil {
+			slog.Warn("refused target connection", "remote", conn.RemoteAddr(), "error", err)
+			conn.Close()
+			continue
+		}
+		return &Window{conn: conn, width: width}, nil
+	}
+}
+
+// handshake reads the token and the width of the window sent by
+// the sink, and returns the width if the token is the expected one
+func handshake(conn net.Conn, token string) (int, error) {
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	defer conn.SetReadDeadline(time.Time{})
+
+	line, err := bufio.NewReader(conn).ReadString('\n')
+	if err != nil {
+		return 0, err
+	}
+	got, width, _ := strings.Cut(strings.TrimSpace(line), " ")
+	if subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+		return 0, fmt.Errorf("invalid token")
+	}
+	cols, err := strconv.Atoi(width)
+	if err != nil {
+		return 0, fmt.Errorf("invalid width %q", width)
+	}
+	return cols, nil
+}
+
+// Write writes the output to the window
+func (w *Window) Write(p []byte) (int, error) {
+	return w.conn.Write(p)
+}
+
+// Width returns the width of the window in columns when it
+// connected, or 0 if it is unknown
+func (w *Window) Width() int {
+	return w.width
+}
+
+// Close closes the connection, which ends the sink of the window
+func (w *Window) Close() error {
+	return w.conn.Close()
+}
+
+// Sink connects to the player at the address with the token and
+// prints the output of the demo to out, the terminal of the window,
+// until the player closes the connection
+func Sink(addr, token string, out *os.File) error {
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if _, err := fmt.Fprintf(conn, "%s %d\n", token, terminal.Width(out)); err != nil {
+		return err
+	}
+
+	// Clear the sink command from the window
+	fmt.Fprint(out, "\033[H\033[2J")
+	_, err = io.Copy(out, conn)
+	return err
+}
+
+// unavailable returns the error of the launchers of the applications
+// of another system
+func unavailable(system string) error {
+	return fmt.Errorf("the application is only available on %s", system)
+}
+
+// shellCommand returns the command as a line typed into a POSIX
+// shell, with its arguments quoted where needed
+func shellCommand(command []string) string {
+	quoted := make([]string, len(command))
+	for i, arg := range command {
+		if arg != "" && strings.Trim(arg, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789-_./:=@") == "" {
+			quoted[i] = arg
+			continue
+		}
+		quoted[i] = "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+	}
+	return strings.Join(quoted, " ")
+}
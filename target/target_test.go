@@ -0,0 +1,93 @@
+package target_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bitcanon/autotyper/target"
+)
+
+// TestOpen tests that the output written to the window is printed by
+// its sink, and that connections without the token are refused
+func TestOpen(t *testing.T) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("failed to create a pipe: %v", err)
+	}
+	defer r.Close()
+
+	sinks := make(chan error, 1)
+	target.Launchers["test"] = func(command []string, opts target.Options) error {
+		if len(command) != 3 || command[0] != "sink" {
+			t.Errorf("expected the sink command with the address and the token, but got %q", command)
+		}
+		addr, token := command[1], command[2]
+		go func() {
+			// A connection with another token is refused
+			if conn, err := net.Dial("tcp", addr); err == nil {
+				conn.Write([]byte("wrong 80\n"))
+				io.ReadAll(conn)
+				conn.Close()
+			}
+			sinks <- target.Sink(addr, token, w)
+			w.Close()
+		}()
+		return nil
+	}
+	defer delete(target.Launchers, "test")
+
+	window, err := target.Open(context.Background(), "test", target.Options{Command: []string{"sink"}, Timeout: 5 * time.Second})
+	if err != nil {
+		t.Fatalf("expected no error, but got %v", err)
+	}
+	if _, err := window.Write([]byte("PS C:\\> echo hi\n")); err != nil {
+		t.Fatalf("expected no error writing to the window, but got %v", err)
+	}
+	window.Close()
+
+	output, _ := io.ReadAll(r)
+	if err := <-sinks; err != nil {
+		t.Errorf("expected no error from the sink, but got %v", err)
+	}
+	expected := "\033[H\033[2JPS C:\\> echo hi\n"
+	if string(output) != expected {
+		t.Errorf("expected output %q, but got %q", expected, output)
+	}
+}
+
+// TestOpenInvalid tests that unknown targets and windows
+// that do not connect are reported
+func TestOpenInvalid(t *testing.T) {
+	if _, err := target.Open(context.Background(), "xterm", target.Options{}); err == nil || !strings.Contains(err.Error(), "iterm, terminal, vscode, wt") {
+		t.Errorf("expected an error listing the targets, but got %v", err)
+	}
+
+	target.Launchers["test"] = func(command []string, opts target.Options) error { return nil }
+	defer delete(target.Launchers, "test")
+	if _, err := target.Open(context.Background(), "test", target.Options{Timeout: 50 * time.Millisecond}); err == nil {
+		t.Errorf("expected an error for a window that does not connect, but got nil")
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := target.Open(ctx, "test", target.Options{}); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled waiting for the window, but got %v", err)
+	}
+
+	if runtime.GOOS != "darwin" {
+		if _, err := target.Open(context.Background(), "iterm", target.Options{}); err == nil {
+			t.Errorf("expected an error opening iTerm2 on %s, but got nil", runtime.GOOS)
+		}
+	}
+	if runtime.GOOS != "windows" {
+		if _, err := target.Open(context.Background(), "wt", target.Options{}); err == nil {
+			t.Errorf("expected an error opening Windows Terminal on %s, but got nil", runtime.GOOS)
+		}
+	}
+}
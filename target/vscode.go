@@ -0,0 +1,61 @@
+/*
+Copyright © 2023 Mikael Schultz <bitcanon@proton.me>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package target
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+
+	"github.com/bitcanon/autotyper/locale"
+)
+
+// launchVSCode asks the presenter to run the command in the integrated
+// terminal of VS Code, as VS Code has no command line interface to run
+// commands in its terminal. The profile is the terminal profile to
+// open the terminal with
+func launchVSCode(command []string, opts Options) error {
+	line := shellCommand(command)
+	if runtime.GOOS == "windows" {
+		line = powerShellCommand(command)
+	}
+	message := locale.T("Run this command in a new terminal of VS Code (Terminal > New Terminal):")
+	if opts.Profile != "" {
+		message = locale.T("Run this command in a new %s terminal of VS Code (Terminal > New Terminal):", opts.Profile)
+	}
+	if opts.Attach {
+		message = locale.T("Run this command in the terminal of VS Code (Terminal > New Terminal):")
+	}
+	fmt.Fprintf(os.Stderr, "%s\n\n  %s\n\n", message, line)
+	return nil
+}
+
+// powerShellCommand returns the command as a line typed into
+// PowerShell, its default shell on Windows
+func powerShellCommand(command []string) string {
+	quoted := make([]string, len(command))
+	for i, arg := range command {
+		quoted[i] = "'" + strings.ReplaceAll(arg, "'", "''") + "'"
+	}
+	return "& " + strings.Join(quoted, " ")
+}
@@ -0,0 +1,125 @@
+/*
+Copyright © 2023 Mikael Schultz <bitcanon@proton.me>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package terminal
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sync"
+	"unicode"
+)
+
+// Keys reads the key presses of the presenter from a terminal with
+// the controls enabled. Keys is also an io.Reader of lines, so the
+// presenter can answer questions while the controls are active: the
+// keys pressed while a line is read are echoed and edited as in a
+// line-buffered terminal.
+type Keys struct {
+	in   *bufio.Reader
+	echo io.Writer
+
+	mu    sync.Mutex
+	lines chan string // non-nil while a line is read
+	line  []rune
+	done  bool
+
+	// The part of the last line not read yet
+	rest []byte
+}
+
+// NewKeys creates a reader of the key presses from in,
+// echoing the lines typed by the presenter to echo
+func NewKeys(in io.Reader, echo io.Writer) *Keys {
+	return &Keys{in: bufio.NewReader(in), echo: echo}
+}
+
+// Run reads the input until it ends, calling handle for each
+// key pressed while no line is read
+func (k *Keys) Run(handle func(key rune)) error {
+	for {
+		r, _, err := k.in.ReadRune()
+		if err != nil {
+			k.mu.Lock()
+			k.done = true
+			if k.lines != nil {
+				close(k.lines)
+				k.lines = nil
+			}
+			k.mu.Unlock()
+			return err
+		}
+
+		k.mu.Lock()
+		if k.lines == nil {
+			k.mu.Unlock()
+			handle(r)
+			continue
+		}
+		k.edit(r)
+		k.mu.Unlock()
+	}
+}
+
+// edit adds a key to the line being read, echoing it
+func (k *Keys) edit(r rune) {
+	switch {
+	case r == '\r' || r == '\n':
+		fmt.Fprintln(k.echo)
+		k.lines <- string(k.line) + "\n"
+		k.lines, k.line = nil, nil
+	case r == '\b' || r == 0x7f:
+		if len(k.line) > 0 {
+			k.line = k.line[:len(k.line)-1]
+			fmt.Fprint(k.echo, "\b \b")
+		}
+	case unicode.IsPrint(r):
+		k.line = append(k.line, r)
+		fmt.Fprint(k.echo, string(r))
+	}
+}
+
+// Read reads from the next line typed by the presenter, including
+// the line ending. It blocks until Enter is pressed, and returns
+// io.EOF when the input has ended.
+func (k *Keys) Read(p []byte) (int, error) {
+	if len(k.rest) == 0 {
+		k.mu.Lock()
+		if k.done {
+			k.mu.Unlock()
+			return 0, io.EOF
+		}
+		lines := make(chan string, 1)
+		k.lines = lines
+		k.mu.Unlock()
+
+		line, ok := <-lines
+		if !ok {
+			return 0, io.EOF
+		}
+		k.rest = []byte(line)
+	}
+
+	n := copy(p, k.rest)
+	k.rest = k.rest[n:]
+	return n, nil
+}
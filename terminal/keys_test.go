@@ -0,0 +1,75 @@
+package terminal_test
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bitcanon/autotyper/terminal"
+)
+
+// TestKeys tests that key presses are handled one by one, except
+// for the keys typed while a line is read as an answer
+func TestKeys(t *testing.T) {
+	r, w := io.Pipe()
+	var echo bytes.Buffer
+	keys := terminal.NewKeys(r, &echo)
+
+	var mu sync.Mutex
+	var handled []rune
+	pressed := make(chan struct{}, 16)
+	done := make(chan error, 1)
+	go func() {
+		done <- keys.Run(func(key rune) {
+			mu.Lock()
+			handled = append(handled, key)
+			mu.Unlock()
+			pressed <- struct{}{}
+		})
+	}()
+
+	w.Write([]byte(" n"))
+	<-pressed
+	<-pressed
+
+	// Read a line, with a typo erased with backspace
+	line := make(chan string, 1)
+	go func() {
+		answer, _ := bufio.NewReader(keys).ReadString('\n')
+		line <- answer
+	}()
+
+	// Give the reader time to start waiting for the line
+	time.Sleep(50 * time.Millisecond)
+	for _, key := range []string{"y", "x", "\x7f", "e", "s", "\r"} {
+		w.Write([]byte(key))
+	}
+	if answer := <-line; answer != "yes\n" {
+		t.Errorf("expected answer %q, but got %q", "yes\n", answer)
+	}
+
+	w.Write([]byte("q"))
+	<-pressed
+	w.Close()
+	if err := <-done; err != io.EOF {
+		t.Errorf("expected io.EOF, but got %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if string(handled) != " nq" {
+		t.Errorf("expected keys %q, but got %q", " nq", string(handled))
+	}
+	if !strings.HasPrefix(echo.String(), "yx\b \bes") {
+		t.Errorf("expected the answer to be echoed, but got %q", echo.String())
+	}
+
+	// The input has ended, a line cannot be read anymore
+	if _, err := keys.Read(make([]byte, 1)); err != io.EOF {
+		t.Errorf("expected io.EOF, but got %v", err)
+	}
+}
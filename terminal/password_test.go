@@ -0,0 +1,47 @@
+package terminal_test
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/bitcanon/autotyper/terminal"
+	"github.com/creack/pty"
+)
+
+// TestReadPassword tests that the password typed in a terminal is
+// read without its line ending, and that a pipe is not read
+func TestReadPassword(t *testing.T) {
+	ptmx, tty, err := pty.Open()
+	if err != nil {
+		t.Skipf("pseudo-terminals are not available: %v", err)
+	}
+	defer ptmx.Close()
+	defer tty.Close()
+
+	if _, err := ptmx.Write([]byte("s3cret\r")); err != nil {
+		t.Fatalf("failed to type the password: %v", err)
+	}
+	var out bytes.Buffer
+	password, err := terminal.ReadPassword("Password: ", tty, &out)
+	if err != nil {
+		t.Fatalf("expected no error, but got %v", err)
+	}
+	if password != "s3cret" {
+		t.Errorf("expected %q, but got %q", "s3cret", password)
+	}
+	if out.String() != "Password: \n" {
+		t.Errorf("expected the prompt, but got %q", out.String())
+	}
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("failed to create pipe: %v", err)
+	}
+	defer r.Close()
+	defer w.Close()
+	if _, err := terminal.ReadPassword("Password: ", r, &out); !errors.Is(err, terminal.ErrNotTerminal) {
+		t.Errorf("expected ErrNotTerminal, but got %v", err)
+	}
+}
@@ -0,0 +1,71 @@
+//go:build aix || darwin || dragonfly || freebsd || linux || netbsd || openbsd || solaris || zos
+
+/*
+Copyright © 2023 Mikael Schultz <bitcanon@proton.me>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package terminal
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// NotifySuspend calls suspend when the process is suspended by job
+// control (e.g. with Ctrl+Z), before it is stopped, and resume once
+// it has been continued (e.g. with fg), until the context is cancelled
+func NotifySuspend(ctx context.Context, suspend, resume func()) {
+	// Keep a suspension ignored by the parent ignored
+	if signal.Ignored(syscall.SIGTSTP) {
+		return
+	}
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGTSTP)
+	continued := make(chan os.Signal, 1)
+	signal.Notify(continued, syscall.SIGCONT)
+	go func() {
+		defer signal.Stop(signals)
+		defer signal.Stop(continued)
+		for {
+			select {
+			case <-signals:
+				suspend()
+
+				// Stop as the default action of SIGTSTP would, and
+				// wait until continued, since the stop is not
+				// immediate in a process with several threads
+				for len(continued) > 0 {
+					<-continued
+				}
+				syscall.Kill(os.Getpid(), syscall.SIGSTOP)
+				select {
+				case <-continued:
+				case <-ctx.Done():
+				}
+				resume()
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
@@ -0,0 +1,118 @@
+/*
+Copyright © 2023 Mikael Schultz <bitcanon@proton.me>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package terminal
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"golang.org/x/term"
+)
+
+// ErrNotTerminal is returned when the input is not a terminal
+var ErrNotTerminal = errors.New("input is not a terminal")
+
+// Terminal manages the mode and the cursor of the presenter's
+// terminal, so they can be restored when the playback ends
+type Terminal struct {
+	in  *os.File
+	out io.Writer
+
+	mu     sync.Mutex
+	saved  *state
+	hidden bool
+}
+
+// New creates a manager for the terminal reading from in
+// and writing to out (usually os.Stdin and os.Stdout)
+func New(in *os.File, out io.Writer) *Terminal {
+	return &Terminal{in: in, out: out}
+}
+
+// IsTerminal reports whether the input is a terminal
+func (t *Terminal) IsTerminal() bool {
+	return term.IsTerminal(int(t.in.Fd()))
+}
+
+// EnableControls puts the terminal into the mode used by the
+// interactive controls: key presses are read one by one, without
+// waiting for Enter and without being echoed. The output and the
+// signal keys (e.g. Ctrl+C) are processed as usual.
+func (t *Terminal) EnableControls() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.saved != nil {
+		return nil
+	}
+	if !t.IsTerminal() {
+		return ErrNotTerminal
+	}
+
+	saved, err := enableControls(int(t.in.Fd()))
+	if err != nil {
+		return fmt.Errorf("failed to enable controls: %w", err)
+	}
+	t.saved = saved
+	return nil
+}
+
+// HideCursor hides the cursor until ShowCursor or Restore is called
+func (t *Terminal) HideCursor() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	fmt.Fprint(t.out, "\033[?25l")
+	t.hidden = true
+}
+
+// ShowCursor shows the cursor again
+func (t *Terminal) ShowCursor() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	fmt.Fprint(t.out, "\033[?25h")
+	t.hidden = false
+}
+
+// Restore resets the colors, shows the cursor and restores the
+// mode the terminal was in before EnableControls. It is safe to
+// call Restore more than once, so it can be deferred to cover
+// errors and panics, and called again on signals.
+func (t *Terminal) Restore() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	fmt.Fprint(t.out, "\033[0m")
+	if t.hidden {
+		fmt.Fprint(t.out, "\033[?25h")
+		t.hidden = false
+	}
+
+	if t.saved == nil {
+		return nil
+	}
+	err := restore(int(t.in.Fd()), t.saved)
+	t.saved = nil
+	return err
+}
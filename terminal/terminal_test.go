@@ -0,0 +1,111 @@
+package terminal_test
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/bitcanon/autotyper/terminal"
+	"github.com/creack/pty"
+	"golang.org/x/term"
+)
+
+// TestEnableControls tests that key presses can be read without
+// Enter while the controls are enabled, and that the mode of the
+// terminal is restored afterwards
+func TestEnableControls(t *testing.T) {
+	ptmx, tty, err := pty.Open()
+	if err != nil {
+		t.Skipf("pseudo-terminals are not supported: %v", err)
+	}
+	defer ptmx.Close()
+	defer tty.Close()
+
+	before, err := term.GetState(int(tty.Fd()))
+	if err != nil {
+		t.Fatalf("failed to get the terminal state: %v", err)
+	}
+
+	var out bytes.Buffer
+	tm := terminal.New(tty, &out)
+	if !tm.IsTerminal() {
+		t.Fatalf("expected the pseudo-terminal to be a terminal")
+	}
+	if err := tm.EnableControls(); err != nil {
+		t.Fatalf("expected no error, but got %v", err)
+	}
+
+	// A single key press is readable without a line ending
+	if _, err := ptmx.Write([]byte("n")); err != nil {
+		t.Fatalf("failed to write to the terminal: %v", err)
+	}
+	read := make(chan string, 1)
+	go func() {
+		buf := make([]byte, 1)
+		n, _ := tty.Read(buf)
+		read <- string(buf[:n])
+	}()
+	select {
+	case key := <-read:
+		if key != "n" {
+			t.Errorf("expected key %q, but got %q", "n", key)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timed out waiting for the key press")
+	}
+
+	tm.HideCursor()
+	if err := tm.Restore(); err != nil {
+		t.Fatalf("expected no error, but got %v", err)
+	}
+	if err := tm.Restore(); err != nil {
+		t.Fatalf("expected no error restoring twice, but got %v", err)
+	}
+
+	after, err := term.GetState(int(tty.Fd()))
+	if err != nil {
+		t.Fatalf("failed to get the terminal state: %v", err)
+	}
+	if *before != *after {
+		t.Errorf("expected the terminal mode to be restored")
+	}
+
+	expected := "\033[?25l\033[0m\033[?25h\033[0m"
+	if out.String() != expected {
+		t.Errorf("expected output %q, but got %q", expected, out.String())
+	}
+}
+
+// TestEnableControlsNotTerminal tests that the controls
+// cannot be enabled when the input is not a terminal
+func TestEnableControlsNotTerminal(t *testing.T) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("failed to create pipe: %v", err)
+	}
+	defer r.Close()
+	defer w.Close()
+
+	tm := terminal.New(r, &bytes.Buffer{})
+	if err := tm.EnableControls(); !errors.Is(err, terminal.ErrNotTerminal) {
+		t.Errorf("expected ErrNotTerminal, but got %v", err)
+	}
+}
+
+// TestOpenTTY tests that the terminal opened directly is a terminal,
+// or that ErrNotTerminal is returned when the process has none
+func TestOpenTTY(t *testing.T) {
+	tty, err := terminal.OpenTTY()
+	if errors.Is(err, terminal.ErrNotTerminal) {
+		t.Skip("the process has no terminal")
+	}
+	if err != nil {
+		t.Fatalf("expected no error, but got %v", err)
+	}
+	defer tty.Close()
+	if !term.IsTerminal(int(tty.Fd())) {
+		t.Errorf("expected %s to be a terminal", tty.Name())
+	}
+}
@@ -0,0 +1,31 @@
+// Command kubectl is a WebAssembly simulator for the tests, printing
+// a pod per the "pods" parameter of the simulation
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+func main() {
+	var request struct {
+		Args   []string       `json:"args"`
+		Params map[string]any `json:"params"`
+	}
+	if err := json.NewDecoder(os.Stdin).Decode(&request); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	pods, _ := request.Params["pods"].(float64)
+	chunk(0, "NAME    STATUS\n")
+	for i := 1; i <= int(pods); i++ {
+		chunk(10, fmt.Sprintf("web-%d   Running\n", i))
+	}
+}
+
+// chunk writes a chunk of output printed after the delay
+func chunk(delay int, text string) {
+	json.NewEncoder(os.Stdout).Encode(map[string]any{"delay": delay, "text": text})
+}
@@ -0,0 +1,22 @@
+// Command upper is a WebAssembly filter for the tests, printing the
+// lines of its input in upper case after the number of the step. It
+// fails if the first line is "fail"
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+)
+
+func main() {
+	scanner := bufio.NewScanner(os.Stdin)
+	for scanner.Scan() {
+		if scanner.Text() == "fail" {
+			fmt.Fprintln(os.Stderr, "cannot filter")
+			os.Exit(3)
+		}
+		fmt.Printf("%s| %s\n", os.Getenv("AUTOTYPER_STEP"), strings.ToUpper(scanner.Text()))
+	}
+}
@@ -0,0 +1,144 @@
+/*
+Copyright © 2023 Mikael Schultz <bitcanon@proton.me>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package trigger
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrHotkeysUnsupported is returned when global hotkeys
+// are listened for on a system without support for them
+var ErrHotkeysUnsupported = errors.New("global hotkeys are only supported on Windows")
+
+// Modifier is a set of modifier keys of a hotkey
+type Modifier uint
+
+// The modifier keys, with the values of RegisterHotKey
+const (
+	Alt   Modifier = 0x1
+	Ctrl  Modifier = 0x2
+	Shift Modifier = 0x4
+	Win   Modifier = 0x8
+)
+
+// modifiers maps the names of the modifier keys to their values
+var modifiers = map[string]Modifier{
+	"alt":     Alt,
+	"ctrl":    Ctrl,
+	"control": Ctrl,
+	"shift":   Shift,
+	"win":     Win,
+	"super":   Win,
+	"cmd":     Win,
+}
+
+// keyCodes maps the names of the keys to their virtual-key codes
+var keyCodes = map[string]uint16{
+	"space": 0x20, "pageup": 0x21, "pagedown": 0x22, "end": 0x23, "home": 0x24,
+	"left": 0x25, "up": 0x26, "right": 0x27, "down": 0x28,
+	"insert": 0x2d, "delete": 0x2e, "pause": 0x13,
+}
+
+func init() {
+	for c := '0'; c <= '9'; c++ {
+		keyCodes[string(c)] = uint16(c)
+	}
+	for c := 'a'; c <= 'z'; c++ {
+		keyCodes[string(c)] = uint16(c - 'a' + 'A')
+	}
+	for n := 1; n <= 24; n++ {
+		keyCodes[fmt.Sprintf("f%d", n)] = uint16(0x70 + n - 1)
+	}
+}
+
+// Hotkey is a key pressed with modifiers, anywhere on the system
+type Hotkey struct {
+	Mods Modifier
+	Key  string
+}
+
+// ParseHotkey parses a hotkey such as "ctrl+alt+n" or "f9". Hotkeys
+// without modifiers are only allowed for the function keys, so the
+// keys typed in other applications are not taken away
+func ParseHotkey(s string) (Hotkey, error) {
+	var h Hotkey
+	parts := strings.Split(strings.ToLower(strings.TrimSpace(s)), "+")
+	for i, part := range parts {
+		part = strings.TrimSpace(part)
+		if i < len(parts)-1 {
+			mod, ok := modifiers[part]
+			if !ok {
+				return Hotkey{}, fmt.Errorf("invalid hotkey %q: unknown modifier %q", s, part)
+			}
+			h.Mods |= mod
+			continue
+		}
+		if _, ok := keyCodes[part]; !ok {
+			return Hotkey{}, fmt.Errorf("invalid hotkey %q: unknown key %q", s, part)
+		}
+		h.Key = part
+	}
+	if function := len(h.Key) > 1 && h.Key[0] == 'f'; h.Mods == 0 && !function {
+		return Hotkey{}, fmt.Errorf("invalid hotkey %q: only function keys can be used without a modifier", s)
+	}
+	return h, nil
+}
+
+// String returns the hotkey as parsed by ParseHotkey
+func (h Hotkey) String() string {
+	var parts []string
+	for _, mod := range []struct {
+		mod  Modifier
+		name string
+	}{{Ctrl, "ctrl"}, {Alt, "alt"}, {Shift, "shift"}, {Win, "win"}} {
+		if h.Mods&mod.mod != 0 {
+			parts = append(parts, mod.name)
+		}
+	}
+	return strings.Join(append(parts, h.Key), "+")
+}
+
+// Binding binds a hotkey to the action it fires
+type Binding struct {
+	Hotkey Hotkey
+	Action Action
+}
+
+// ParseBinding parses a binding given as "action=hotkey",
+// such as "next=ctrl+alt+n"
+func ParseBinding(s string) (Binding, error) {
+	name, key, ok := strings.Cut(s, "=")
+	if !ok {
+		return Binding{}, fmt.Errorf("invalid hotkey binding %q: expected action=hotkey", s)
+	}
+	action, err := ParseAction(strings.TrimSpace(name))
+	if err != nil {
+		return Binding{}, err
+	}
+	hotkey, err := ParseHotkey(key)
+	if err != nil {
+		return Binding{}, err
+	}
+	return Binding{Hotkey: hotkey, Action: action}, nil
+}
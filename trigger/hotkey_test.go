@@ -0,0 +1,56 @@
+package trigger_test
+
+import (
+	"testing"
+
+	"github.com/bitcanon/autotyper/trigger"
+)
+
+// TestParseBinding tests the parsing of the hotkey bindings
+func TestParseBinding(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected trigger.Binding
+		valid    bool
+	}{
+		{input: "next=ctrl+alt+n", expected: trigger.Binding{Hotkey: trigger.Hotkey{Mods: trigger.Ctrl | trigger.Alt, Key: "n"}, Action: trigger.Next}, valid: true},
+		{input: "pause = Shift+Win+Space", expected: trigger.Binding{Hotkey: trigger.Hotkey{Mods: trigger.Shift | trigger.Win, Key: "space"}, Action: trigger.Pause}, valid: true},
+		{input: "restart=f9", expected: trigger.Binding{Hotkey: trigger.Hotkey{Key: "f9"}, Action: trigger.Restart}, valid: true},
+		{input: "next=ctrl+f", expected: trigger.Binding{Hotkey: trigger.Hotkey{Mods: trigger.Ctrl, Key: "f"}, Action: trigger.Next}, valid: true},
+		{input: "next=n", valid: false},
+		{input: "next=f", valid: false},
+		{input: "next=hyper+n", valid: false},
+		{input: "next=ctrl+enter", valid: false},
+		{input: "quit=ctrl+q", valid: false},
+		{input: "ctrl+alt+n", valid: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.input, func(t *testing.T) {
+			b, err := trigger.ParseBinding(test.input)
+			if !test.valid {
+				if err == nil {
+					t.Errorf("expected error, but got %+v", b)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected no error, but got %v", err)
+			}
+			if b != test.expected {
+				t.Errorf("expected %+v, but got %+v", test.expected, b)
+			}
+		})
+	}
+}
+
+// TestHotkeyString tests that hotkeys are written as they are parsed
+func TestHotkeyString(t *testing.T) {
+	h, err := trigger.ParseHotkey("alt+CTRL+pagedown")
+	if err != nil {
+		t.Fatalf("expected no error, but got %v", err)
+	}
+	if h.String() != "ctrl+alt+pagedown" {
+		t.Errorf("expected %q, but got %q", "ctrl+alt+pagedown", h.String())
+	}
+}
@@ -0,0 +1,107 @@
+//go:build windows
+
+/*
+Copyright © 2023 Mikael Schultz <bitcanon@proton.me>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package trigger
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+var (
+	user32                 = windows.NewLazySystemDLL("user32.dll")
+	procRegisterHotKey     = user32.NewProc("RegisterHotKey")
+	procUnregisterHotKey   = user32.NewProc("UnregisterHotKey")
+	procGetMessageW        = user32.NewProc("GetMessageW")
+	procPostThreadMessageW = user32.NewProc("PostThreadMessageW")
+)
+
+// The window messages and the flags of the hotkeys
+const (
+	wmQuit      = 0x0012
+	wmHotkey    = 0x0312
+	modNoRepeat = 0x4000
+)
+
+// msg is the MSG structure of the window messages
+type msg struct {
+	hwnd    uintptr
+	message uint32
+	wParam  uintptr
+	lParam  uintptr
+	time    uint32
+	pt      struct{ x, y int32 }
+}
+
+// HotkeysSupported reports whether global hotkeys are supported
+func HotkeysSupported() bool {
+	return true
+}
+
+// ListenHotkeys registers the hotkeys of the bindings and fires
+// their actions when they are pressed, until the context is done
+func ListenHotkeys(ctx context.Context, bindings []Binding, fire func(Action)) error {
+	// The hotkeys are posted to the message queue of the
+	// thread registering them
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	for i, b := range bindings {
+		r, _, err := procRegisterHotKey.Call(0, uintptr(i+1), uintptr(b.Hotkey.Mods|modNoRepeat), uintptr(keyCodes[b.Hotkey.Key]))
+		if r == 0 {
+			unregisterHotkeys(i)
+			return fmt.Errorf("cannot register the hotkey %s: %w", b.Hotkey, err)
+		}
+	}
+	defer unregisterHotkeys(len(bindings))
+
+	thread := windows.GetCurrentThreadId()
+	defer context.AfterFunc(ctx, func() {
+		procPostThreadMessageW.Call(uintptr(thread), wmQuit, 0, 0)
+	})()
+
+	var m msg
+	for {
+		r, _, err := procGetMessageW.Call(uintptr(unsafe.Pointer(&m)), 0, 0, 0)
+		switch int32(r) {
+		case -1:
+			return err
+		case 0:
+			return ctx.Err()
+		}
+		if id := int(m.wParam); m.message == wmHotkey && id >= 1 && id <= len(bindings) {
+			fire(bindings[id-1].Action)
+		}
+	}
+}
+
+// unregisterHotkeys unregisters the first n hotkeys
+func unregisterHotkeys(n int) {
+	for i := 0; i < n; i++ {
+		procUnregisterHotKey.Call(0, uintptr(i+1))
+	}
+}
@@ -0,0 +1,111 @@
+/*
+Copyright © 2023 Mikael Schultz <bitcanon@proton.me>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package trigger
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// Action is a playback action fired by a button or a hotkey
+type Action string
+
+// The actions that can be triggered
+const (
+	Next    Action = "next"    // play the next command and pause again
+	Pause   Action = "pause"   // pause the playback, or resume it
+	Restart Action = "restart" // play again from the first step
+)
+
+// Actions lists all the actions that can be triggered
+var Actions = []Action{Next, Pause, Restart}
+
+// ParseAction returns the action with the name
+func ParseAction(name string) (Action, error) {
+	for _, a := range Actions {
+		if strings.EqualFold(name, string(a)) {
+			return a, nil
+		}
+	}
+	return "", fmt.Errorf("unknown action %q, expected next, pause or restart", name)
+}
+
+// Handler returns an HTTP handler firing the action named by the
+// path of the POST request (e.g. "/next"). Other requests are refused,
+// so that a web page cannot fire the actions with a link or an image
+func Handler(fire func(Action)) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		action, err := ParseAction(strings.Trim(r.URL.Path, "/"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		slog.Debug("action triggered", "action", action, "remote", r.RemoteAddr)
+		fire(action)
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+// ServeUDP fires the actions named by the datagrams received on
+// conn (e.g. "next"), until the context is done. If token is not
+// empty, the datagrams must begin with the token and a space
+// (e.g. "s3cret next"), and the others are ignored
+func ServeUDP(ctx context.Context, conn net.PacketConn, token string, fire func(Action)) error {
+	defer context.AfterFunc(ctx, func() { conn.Close() })()
+
+	buf := make([]byte, 512)
+	for {
+		n, addr, err := conn.ReadFrom(buf)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return err
+		}
+		name := strings.TrimSpace(string(buf[:n]))
+		if token != "" {
+			given, rest, _ := strings.Cut(name, " ")
+			if subtle.ConstantTimeCompare([]byte(given), []byte(token)) != 1 {
+				slog.Warn("unauthorized trigger", "remote", addr)
+				continue
+			}
+			name = strings.TrimSpace(rest)
+		}
+		action, err := ParseAction(name)
+		if err != nil {
+			slog.Warn("invalid trigger", "remote", addr, "error", err)
+			continue
+		}
+		slog.Debug("action triggered", "action", action, "remote", addr)
+		fire(action)
+	}
+}
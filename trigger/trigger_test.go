@@ -0,0 +1,99 @@
+package trigger_test
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/bitcanon/autotyper/trigger"
+)
+
+// TestHandler tests that the actions are fired by the path of POST
+// requests, that unknown actions are not found, and that the other
+// methods are refused, so a web page cannot fire them with a link
+func TestHandler(t *testing.T) {
+	tests := []struct {
+		method   string
+		path     string
+		code     int
+		expected trigger.Action
+	}{
+		{method: http.MethodPost, path: "/next", code: http.StatusNoContent, expected: trigger.Next},
+		{method: http.MethodPost, path: "/pause", code: http.StatusNoContent, expected: trigger.Pause},
+		{method: http.MethodPost, path: "/Restart", code: http.StatusNoContent, expected: trigger.Restart},
+		{method: http.MethodPost, path: "/quit", code: http.StatusNotFound},
+		{method: http.MethodGet, path: "/next", code: http.StatusMethodNotAllowed},
+		{method: http.MethodDelete, path: "/next", code: http.StatusMethodNotAllowed},
+	}
+
+	for _, test := range tests {
+		t.Run(test.method+test.path, func(t *testing.T) {
+			var fired trigger.Action
+			handler := trigger.Handler(func(a trigger.Action) { fired = a })
+
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(test.method, test.path, nil))
+			if rec.Code != test.code {
+				t.Errorf("expected %d, but got %d", test.code, rec.Code)
+			}
+			if fired != test.expected {
+				t.Errorf("expected action %q, but got %q", test.expected, fired)
+			}
+		})
+	}
+}
+
+// TestServeUDP tests that the actions named by the datagrams
+// are fired, skipping the invalid ones and those without the
+// token if there is one, until the context is done
+func TestServeUDP(t *testing.T) {
+	tests := []struct {
+		name      string
+		token     string
+		datagrams []string
+	}{
+		{name: "NoToken", datagrams: []string{"next\n", "bogus", "restart"}},
+		{name: "Token", token: "s3cret", datagrams: []string{"s3cret next\n", "pause", "wrong pause", "s3cret bogus", "s3cret restart"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+			if err != nil {
+				t.Fatalf("failed to listen: %v", err)
+			}
+			ctx, cancel := context.WithCancel(context.Background())
+			fired := make(chan trigger.Action, 8)
+			done := make(chan error)
+			go func() { done <- trigger.ServeUDP(ctx, conn, test.token, func(a trigger.Action) { fired <- a }) }()
+
+			client, err := net.Dial("udp", conn.LocalAddr().String())
+			if err != nil {
+				t.Fatalf("failed to dial: %v", err)
+			}
+			defer client.Close()
+			for _, datagram := range test.datagrams {
+				client.Write([]byte(datagram))
+			}
+
+			for _, expected := range []trigger.Action{trigger.Next, trigger.Restart} {
+				select {
+				case a := <-fired:
+					if a != expected {
+						t.Errorf("expected action %q, but got %q", expected, a)
+					}
+				case <-time.After(5 * time.Second):
+					t.Fatalf("expected action %q, but got none", expected)
+				}
+			}
+
+			cancel()
+			if err := <-done; err != context.Canceled {
+				t.Errorf("expected %v, but got %v", context.Canceled, err)
+			}
+		})
+	}
+}
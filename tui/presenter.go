@@ -0,0 +1,131 @@
+/*
+Copyright © 2023 Mikael Schultz <bitcanon@proton.me>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package tui
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bitcanon/autotyper/locale"
+	"github.com/bitcanon/autotyper/player"
+	"github.com/bitcanon/autotyper/script"
+)
+
+// Define the number of upcoming steps shown to the presenter
+const upcomingSteps = 5
+
+// Presenter draws the control view of the presenter on a second
+// terminal while the demo is played on the first one: the status
+// and the elapsed time, the current step with its notes, and the
+// upcoming steps. The view is drawn again on every event of the
+// player and every second
+type Presenter struct {
+	out    io.Writer
+	steps  []script.Step
+	status func() player.Status
+	width  func() int
+
+	mu      sync.Mutex
+	started time.Time
+}
+
+// NewPresenter creates the control view of the steps of a playback
+// drawn on out. The status is that of the player (e.g. p.Status),
+// and width returns the width of the terminal, if known
+func NewPresenter(out io.Writer, steps []script.Step, status func() player.Status, width func() int) *Presenter {
+	return &Presenter{out: out, steps: steps, status: status, width: width}
+}
+
+// Handle draws the view again for an event of the player.
+// The time is counted from the first step started
+func (v *Presenter) Handle(e player.Event) {
+	v.mu.Lock()
+	if v.started.IsZero() && e.Type == player.StepStarted {
+		v.started = time.Now()
+	}
+	v.mu.Unlock()
+	v.Draw()
+}
+
+// Run draws the view every second, for the elapsed
+// time, until the context is done
+func (v *Presenter) Run(ctx context.Context) {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for {
+		v.Draw()
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Draw draws the view over the previous one
+func (v *Presenter) Draw() {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	status := v.status()
+	width := 0
+	if v.width != nil {
+		width = v.width()
+	}
+	var elapsed time.Duration
+	if !v.started.IsZero() {
+		elapsed = time.Since(v.started)
+	}
+
+	var b strings.Builder
+	line := func(s string) { b.WriteString(fit(s, width) + "\033[K\n") }
+	b.WriteString("\033[H")
+
+	// Draw the status bar in reverse video
+	bar := fit(fmt.Sprintf(" %s  %s  %02d:%02d ", locale.T(status.State.String()),
+		locale.T("step %d/%d", min(status.Step+1, status.Total), status.Total),
+		int(elapsed.Minutes()), int(elapsed.Seconds())%60), width)
+	b.WriteString("\033[7m" + bar + "\033[0m\033[K\n")
+	line("")
+
+	// Draw the current step with its notes, then the upcoming steps
+	if status.Step < len(v.steps) {
+		step := v.steps[status.Step]
+		line(fmt.Sprintf("▶ %2d  %s", status.Step+1, describe(step)))
+		if step.Notes != "" {
+			for _, note := range strings.Split(step.Notes, "\n") {
+				line("      " + note)
+			}
+		}
+		line("")
+	}
+	for i := status.Step + 1; i < min(status.Step+1+upcomingSteps, len(v.steps)); i++ {
+		line(fmt.Sprintf("  %2d  %s", i+1, describe(v.steps[i])))
+	}
+	b.WriteString("\033[J")
+
+	fmt.Fprint(v.out, b.String())
+}
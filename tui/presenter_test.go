@@ -0,0 +1,48 @@
+package tui_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bitcanon/autotyper/player"
+	"github.com/bitcanon/autotyper/script"
+	"github.com/bitcanon/autotyper/tui"
+	"github.com/bitcanon/autotyper/vt"
+)
+
+// TestPresenter tests that the control view shows the status, the
+// current step with its notes and the upcoming steps, cut to the
+// width of the terminal
+func TestPresenter(t *testing.T) {
+	steps := []script.Step{
+		{Command: "ls"},
+		{Command: "kubectl get pods --all-namespaces", Notes: "Point at the pending pod\nExplain the restarts"},
+		{Think: 500},
+		{Command: "kubectl logs web-1"},
+	}
+	status := player.Status{State: player.Paused, Step: 1, Total: len(steps)}
+	screen := vt.NewScreen(30, 12)
+	v := tui.NewPresenter(screen, steps, func() player.Status { return status }, func() int { return 30 })
+
+	v.Draw()
+	expected := strings.Join([]string{
+		" paused  step 2/4  00:00",
+		"",
+		"▶  2  kubectl get pods --all-n",
+		"      Point at the pending pod",
+		"      Explain the restarts",
+		"",
+		"   3  #think 500",
+		"   4  kubectl logs web-1",
+	}, "\n")
+	if screen.String() != expected {
+		t.Errorf("expected view:\n%s\n\nbut got:\n%s", expected, screen.String())
+	}
+
+	// The view is drawn over the previous one
+	status = player.Status{State: player.Finished, Step: 4, Total: len(steps)}
+	v.Draw()
+	if screen.String() != " finished  step 4/4  00:00" {
+		t.Errorf("expected the finished view, but got:\n%s", screen.String())
+	}
+}
@@ -0,0 +1,242 @@
+/*
+Copyright © 2023 Mikael Schultz <bitcanon@proton.me>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package tui
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/bitcanon/autotyper/cli"
+)
+
+// Look is a theme of the prompt and the commands, and the palette
+// of the system colors going with it
+type Look struct {
+	Name    string
+	Theme   cli.Theme
+	Palette *cli.Palette
+}
+
+// Looks returns the built-in looks: the default theme, and the
+// theme of each colorblind-safe palette
+func Looks() []Look {
+	looks := []Look{{Name: "default", Theme: cli.DefaultTheme}}
+	for _, name := range cli.PaletteNames() {
+		palette := cli.Palettes[name]
+		looks = append(looks, Look{Name: name, Theme: palette.Theme, Palette: &palette})
+	}
+	return looks
+}
+
+// previewShells are the shells of the prompts in the preview
+var previewShells = []struct {
+	name  string
+	shell cli.ShellOption
+}{
+	{name: "ps", shell: cli.PS},
+	{name: "cmd", shell: cli.Cmd},
+	{name: "bash", shell: cli.Bash},
+}
+
+// previewGap is the space between the columns of the preview
+const previewGap = 4
+
+// Preview shows the prompt of each shell in each look side by side,
+// with a sample command, so that a look can be picked at a glance
+type Preview struct {
+	// The username, the hostname and the path of the prompts. The
+	// shell is ignored, and the path defaults to that of each shell
+	Prompt cli.Prompt
+
+	// The sample command typed after the prompts
+	Command string
+
+	// The looks shown in the columns
+	Looks []Look
+
+	// The width of the terminal in columns. The columns not fitting
+	// in the width are moved below the others. If 0, the columns are
+	// all drawn side by side
+	Width int
+}
+
+// Draw draws the preview with the whole command typed
+func (pv *Preview) Draw(out io.Writer) error {
+	_, err := io.WriteString(out, strings.Join(pv.lines(pv.Command), "\n")+"\n")
+	return err
+}
+
+// Type draws the preview while the command is typed in all the looks
+// at once, with the delay in milliseconds between each character
+func (pv *Preview) Type(ctx context.Context, out io.Writer, delayMs int) error {
+	command := []rune(pv.Command)
+	for n := 0; n <= len(command); n++ {
+		lines := pv.lines(string(command[:n]))
+		if n > 0 {
+			// Draw over the previous preview
+			fmt.Fprintf(out, "\033[%dA", len(lines))
+		}
+		for _, line := range lines {
+			if _, err := io.WriteString(out, "\r\033[K"+line+"\n"); err != nil {
+				return err
+			}
+		}
+		if n == len(command) {
+			break
+		}
+
+		select {
+		case <-time.After(time.Duration(delayMs) * time.Millisecond):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+// lines returns the lines of the preview with the typed part of the
+// command, in rows of columns fitting in the width
+func (pv *Preview) lines(typed string) []string {
+	labels := []string{""}
+	for _, s := range previewShells {
+		labels = append(labels, "\033[2m--shell "+s.name+"\033[0m")
+	}
+	labels = append(labels, "\033[2moutput\033[0m")
+
+	var columns [][]string
+	var widths []int
+	for _, look := range pv.Looks {
+		cells, width := pv.column(look, typed)
+		columns = append(columns, cells)
+		widths = append(widths, width)
+	}
+
+	// Fill the rows with as many columns as fit, after the labels
+	labelWidth := 0
+	for _, label := range labels {
+		labelWidth = max(labelWidth, visibleWidth(label)+previewGap)
+	}
+	var result []string
+	for start := 0; start < len(columns); {
+		end, used := start+1, labelWidth+widths[start]
+		for end < len(columns) && (pv.Width <= 0 || used+previewGap+widths[end] <= pv.Width) {
+			used += previewGap + widths[end]
+			end++
+		}
+
+		if start > 0 {
+			result = append(result, "")
+		}
+		for row := range labels {
+			line := pad(labels[row], labelWidth)
+			for i := start; i < end; i++ {
+				line += columns[i][row]
+				if i < end-1 {
+					line = pad(line, visibleWidth(line)-visibleWidth(columns[i][row])+widths[i]+previewGap)
+				}
+			}
+			result = append(result, strings.TrimRight(line, " "))
+		}
+		start = end
+	}
+	return result
+}
+
+// column returns the cells of a look, its name above the prompts of
+// the shells and a line of output in its system colors, and the width of the column once the whole command is
+// typed
+func (pv *Preview) column(look Look, typed string) ([]string, int) {
+	theme, palette := cli.ActiveTheme, cli.ActivePalette
+	defer func() { cli.ActiveTheme, cli.ActivePalette = theme, palette }()
+	cli.ActiveTheme, cli.ActivePalette = look.Theme, look.Palette
+
+	header := "--palette " + look.Name
+	cells := []string{"\033[1m" + header + "\033[0m"}
+	width := visibleWidth(header)
+	for _, s := range previewShells {
+		prompt := pv.Prompt
+		prompt.Shell = s.shell
+		var b strings.Builder
+		cli.PrintPrompt(prompt, &b)
+
+		cell := b.String()
+		if typed != "" {
+			cell += cli.ColorCommand(typed)
+		}
+		cells = append(cells, cell)
+		width = max(width, visibleWidth(b.String())+textWidth(pv.Command))
+	}
+
+	// Show the system colors of the palette in the output
+	var words []string
+	for _, w := range previewWords {
+		style, _ := cli.ParseStyle(w.style)
+		words = append(words, style.Sequence()+w.text+"\033[0m")
+	}
+	output := strings.Join(words, " ")
+	cells = append(cells, output)
+	width = max(width, visibleWidth(output))
+	return cells, width
+}
+
+// previewWords are the words of the output in the system colors
+var previewWords = []struct {
+	text, style string
+}{
+	{text: "error", style: "red"},
+	{text: "added", style: "green"},
+	{text: "warning", style: "yellow"},
+	{text: "info", style: "blue"},
+	{text: "debug", style: "magenta"},
+	{text: "trace", style: "cyan"},
+}
+
+// pad pads the line with spaces up to the width
+func pad(line string, width int) string {
+	if w := visibleWidth(line); w < width {
+		return line + strings.Repeat(" ", width-w)
+	}
+	return line
+}
+
+// visibleWidth returns the number of columns of the text,
+// without its escape sequences
+func visibleWidth(s string) int {
+	w, escape := 0, false
+	for _, r := range s {
+		switch {
+		case r == '\033':
+			escape = true
+		case escape:
+			// The escape sequences end with a letter
+			if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') {
+				escape = false
+			}
+		default:
+			w += textWidth(string(r))
+		}
+	}
+	return w
+}
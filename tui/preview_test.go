@@ -0,0 +1,69 @@
+package tui_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/bitcanon/autotyper/cli"
+	"github.com/bitcanon/autotyper/tui"
+	"github.com/bitcanon/autotyper/vt"
+)
+
+// TestLooks tests that the default theme and the theme
+// of each palette are shown
+func TestLooks(t *testing.T) {
+	var names []string
+	for _, look := range tui.Looks() {
+		names = append(names, look.Name)
+	}
+	if got := strings.Join(names, " "); got != "default deuteranopia protanopia" {
+		t.Errorf("expected the default theme and the palettes, but got %q", got)
+	}
+}
+
+// TestPreview tests that the prompts of the shells are laid out
+// side by side, with the looks not fitting in the width below
+func TestPreview(t *testing.T) {
+	preview := &tui.Preview{
+		Prompt:  cli.Prompt{Username: "demo", Hostname: "box"},
+		Command: "ls -l",
+		Looks:   tui.Looks(),
+		Width:   100,
+	}
+	screen := vt.NewScreen(100, 20)
+	if err := preview.Draw(screen); err != nil {
+		t.Fatalf("expected no error, but got %v", err)
+	}
+
+	expected := strings.Join([]string{
+		"                --palette default                       --palette deuteranopia",
+		"--shell ps      PS C:\\> ls -l                           PS C:\\> ls -l",
+		"--shell cmd     C:\\> ls -l                              C:\\> ls -l",
+		"--shell bash    demo@box:~$ ls -l                       demo@box:~$ ls -l",
+		"output          error added warning info debug trace    error added warning info debug trace",
+		"",
+		"                --palette protanopia",
+		"--shell ps      PS C:\\> ls -l",
+		"--shell cmd     C:\\> ls -l",
+		"--shell bash    demo@box:~$ ls -l",
+		"output          error added warning info debug trace",
+	}, "\n")
+	if screen.String() != expected {
+		t.Errorf("expected preview:\n%s\n\nbut got:\n%s", expected, screen.String())
+	}
+}
+
+// TestPreviewType tests that the command is typed over the previous
+// drawings of the preview, which ends as if drawn at once
+func TestPreviewType(t *testing.T) {
+	preview := &tui.Preview{Command: "ls -l", Looks: tui.Looks()[:1]}
+	typed, drawn := vt.NewScreen(80, 10), vt.NewScreen(80, 10)
+	if err := preview.Type(context.Background(), typed, 0); err != nil {
+		t.Fatalf("expected no error, but got %v", err)
+	}
+	preview.Draw(drawn)
+	if typed.String() != drawn.String() {
+		t.Errorf("expected preview:\n%s\n\nbut got:\n%s", drawn.String(), typed.String())
+	}
+}
@@ -0,0 +1,205 @@
+/*
+Copyright © 2023 Mikael Schultz <bitcanon@proton.me>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package tui
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bitcanon/autotyper/locale"
+	"github.com/bitcanon/autotyper/player"
+)
+
+// TimerMode describes where the session timer is drawn
+type TimerMode int
+
+// Define constants for the timer modes
+const (
+	// In the title of the terminal window
+	TimerTitle TimerMode = iota
+
+	// On the last row of the screen, kept out of the scroll region
+	TimerStatusRow
+)
+
+// ParseTimerMode returns the timer mode for its name ("title" or
+// "status"). The second return value reports whether the name is known
+func ParseTimerMode(name string) (TimerMode, bool) {
+	switch name {
+	case "title":
+		return TimerTitle, true
+	case "status":
+		return TimerStatusRow, true
+	default:
+		return TimerTitle, false
+	}
+}
+
+// Timer shows the time elapsed since the first step to the presenter
+// of a live demo, and the time left of the slot of the talk if there
+// is one, in the title of the terminal or on a status row. The timer
+// is drawn again on every event of the player and every second
+type Timer struct {
+	out  io.Writer
+	mode TimerMode
+	slot time.Duration
+	size func() (cols, rows int)
+
+	mu      sync.Mutex
+	started time.Time
+	drawn   bool
+	closed  bool
+
+	// The number of rows of the screen the scroll region was set
+	// for, 0 if it has not been set
+	rows int
+}
+
+// NewTimer creates a session timer drawn on out. The slot is the
+// length of the talk, none if zero, and size returns the size of
+// the terminal, for the status row
+func NewTimer(out io.Writer, mode TimerMode, slot time.Duration, size func() (cols, rows int)) *Timer {
+	return &Timer{out: out, mode: mode, slot: slot, size: size}
+}
+
+// Handle draws the timer again for an event of the player.
+// The time is counted from the first step started
+func (t *Timer) Handle(e player.Event) {
+	t.mu.Lock()
+	if t.started.IsZero() && e.Type == player.StepStarted {
+		t.started = time.Now()
+	}
+	t.mu.Unlock()
+	t.Draw()
+}
+
+// Run draws the timer every second until the context is done
+func (t *Timer) Run(ctx context.Context) {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for {
+		t.Draw()
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Draw draws the timer over the previous one
+func (t *Timer) Draw() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.closed {
+		return
+	}
+
+	var elapsed time.Duration
+	if !t.started.IsZero() {
+		elapsed = time.Since(t.started)
+	}
+	text, over := t.text(elapsed)
+
+	if t.mode == TimerTitle {
+		// Keep the title of the window, to restore it when closed
+		if !t.drawn {
+			fmt.Fprint(t.out, "\033[22;2t")
+		}
+		fmt.Fprintf(t.out, "\033]2;%s\a", text)
+		t.drawn = true
+		return
+	}
+
+	cols, rows := t.size()
+	if rows < 2 {
+		return
+	}
+	var b strings.Builder
+	b.WriteString("\0337")
+
+	// Keep the output of the demo above the status row. Setting
+	// the scroll region moves the cursor, which is restored after
+	if rows != t.rows {
+		fmt.Fprintf(&b, "\033[1;%dr", rows-1)
+		t.rows = rows
+	}
+	bar := fit(" "+text+" ", cols)
+	bar += strings.Repeat(" ", max(cols-textWidth(bar), 0))
+	style := "\033[7m"
+	if over {
+		style = "\033[7;31m"
+	}
+	fmt.Fprintf(&b, "\033[%d;1H%s%s\033[0m", rows, style, bar)
+	b.WriteString("\0338")
+	fmt.Fprint(t.out, b.String())
+	t.drawn = true
+}
+
+// Close removes the timer: the title of the window is restored,
+// or the status row is erased and the scroll region reset. The
+// timer is not drawn again after it is closed
+func (t *Timer) Close() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.closed || !t.drawn {
+		t.closed = true
+		return
+	}
+	t.closed = true
+
+	if t.mode == TimerTitle {
+		fmt.Fprint(t.out, "\033[23;2t")
+		return
+	}
+	if t.rows > 0 {
+		fmt.Fprintf(t.out, "\0337\033[r\033[%d;1H\033[2K\0338", t.rows)
+		t.rows = 0
+	}
+}
+
+// text returns the text of the timer for the elapsed time, and
+// whether the slot of the talk is over
+func (t *Timer) text(elapsed time.Duration) (string, bool) {
+	text := locale.T("%s elapsed", clock(elapsed))
+	if t.slot <= 0 {
+		return text, false
+	}
+	if elapsed > t.slot {
+		return text + "  " + locale.T("%s over %s", clock(elapsed-t.slot), clock(t.slot)), true
+	}
+	return text + "  " + locale.T("%s left of %s", clock(t.slot-elapsed), clock(t.slot)), false
+}
+
+// clock formats a duration as minutes and seconds (e.g. "05:07"),
+// with the hours if there are any (e.g. "1:05:07")
+func clock(d time.Duration) string {
+	seconds := int(d.Seconds())
+	if seconds >= 3600 {
+		return fmt.Sprintf("%d:%02d:%02d", seconds/3600, seconds/60%60, seconds%60)
+	}
+	return fmt.Sprintf("%02d:%02d", seconds/60, seconds%60)
+}
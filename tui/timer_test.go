@@ -0,0 +1,89 @@
+package tui_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bitcanon/autotyper/player"
+	"github.com/bitcanon/autotyper/tui"
+	"github.com/bitcanon/autotyper/vt"
+)
+
+// TestParseTimerMode tests that the modes of the timer are parsed
+// by their names
+func TestParseTimerMode(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected tui.TimerMode
+		ok       bool
+	}{
+		{name: "title", expected: tui.TimerTitle, ok: true},
+		{name: "status", expected: tui.TimerStatusRow, ok: true},
+		{name: "clock", ok: false},
+	}
+
+	for _, test := range tests {
+		mode, ok := tui.ParseTimerMode(test.name)
+		if ok != test.ok || (ok && mode != test.expected) {
+			t.Errorf("%q: expected %v (%v), but got %v (%v)", test.name, test.expected, test.ok, mode, ok)
+		}
+	}
+}
+
+// TestTimerStatusRow tests that the timer is drawn on the last row,
+// out of the scroll region of the demo, and erased when closed
+func TestTimerStatusRow(t *testing.T) {
+	screen := vt.NewScreen(40, 4)
+	timer := tui.NewTimer(screen, tui.TimerStatusRow, 20*time.Minute, screen.Size)
+
+	screen.Write([]byte("PS C:\\> "))
+	timer.Draw()
+	screen.Write([]byte("ls\n1\n2\n3\nPS C:\\> "))
+	expected := "2\n3\nPS C:\\>\n 00:00 elapsed  20:00 left of 20:00"
+	if screen.String() != expected {
+		t.Errorf("expected screen:\n%s\n\nbut got:\n%s", expected, screen.String())
+	}
+	if style := screen.Cells(3)[39].Style; style != "7" {
+		t.Errorf("expected the status row in reverse video, but got %q", style)
+	}
+
+	// The whole screen scrolls again once closed
+	timer.Close()
+	timer.Draw()
+	if expected := "2\n3\nPS C:\\>"; screen.String() != expected {
+		t.Errorf("expected the status row to be erased:\n%s\n\nbut got:\n%s", expected, screen.String())
+	}
+	screen.Write([]byte("\n4\n5"))
+	if expected := "3\nPS C:\\>\n4\n5"; screen.String() != expected {
+		t.Errorf("expected the screen to scroll:\n%s\n\nbut got:\n%s", expected, screen.String())
+	}
+}
+
+// TestTimerTitle tests that the timer is drawn in the title of the
+// window, which is restored when closed, and that it turns to the
+// time over the slot once the slot is over
+func TestTimerTitle(t *testing.T) {
+	var out strings.Builder
+	timer := tui.NewTimer(&out, tui.TimerTitle, time.Millisecond, nil)
+
+	timer.Draw()
+	if expected := "\033[22;2t\033]2;00:00 elapsed  00:00 left of 00:00\a"; out.String() != expected {
+		t.Errorf("expected %q, but got %q", expected, out.String())
+	}
+
+	out.Reset()
+	timer.Handle(player.Event{Type: player.StepStarted})
+	time.Sleep(5 * time.Millisecond)
+	timer.Draw()
+	if !strings.HasSuffix(out.String(), "\033]2;00:00 elapsed  00:00 over 00:00\a") {
+		t.Errorf("expected the time over the slot, but got %q", out.String())
+	}
+
+	out.Reset()
+	timer.Close()
+	timer.Draw()
+	if out.String() != "\033[23;2t" {
+		t.Errorf("expected the title to be restored, but got %q", out.String())
+	}
+}
@@ -0,0 +1,386 @@
+/*
+Copyright © 2023 Mikael Schultz <bitcanon@proton.me>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package tui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/bitcanon/autotyper/locale"
+	"github.com/bitcanon/autotyper/player"
+	"github.com/bitcanon/autotyper/script"
+	"github.com/bitcanon/autotyper/vt"
+)
+
+// Define the number of rows of the step list while presenting
+const stepRows = 6
+
+// Define the extensions of the files listed as scripts
+var scriptExtensions = []string{".txt", ".json", ".toml", ".yaml", ".yml"}
+
+// FindScripts returns the names of the scripts in the directory,
+// sorted by name
+func FindScripts(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, entry := range entries {
+		ext := strings.ToLower(filepath.Ext(entry.Name()))
+		for _, e := range scriptExtensions {
+			if !entry.IsDir() && ext == e {
+				names = append(names, entry.Name())
+				break
+			}
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// tickMsg refreshes the status bar and the screen
+type tickMsg time.Time
+
+// doneMsg is sent when the playback of a player has ended
+type doneMsg struct {
+	player *player.Player
+	err    error
+}
+
+// tick schedules the next refresh
+func tick() tea.Cmd {
+	return tea.Tick(100*time.Millisecond, func(t time.Time) tea.Msg { return tickMsg(t) })
+}
+
+// screen is a virtual screen safe for concurrent use, since the
+// player writes from its own goroutine while the view is drawn
+type screen struct {
+	mu     sync.Mutex
+	screen *vt.Screen
+}
+
+func (s *screen) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.screen.Write(p)
+}
+
+// lines returns the text of the rows of the screen
+func (s *screen) lines() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.screen.Lines()
+}
+
+// Model is the presenter console: a list of the scripts in a
+// directory, and the steps, the screen and the status of the
+// script being presented
+type Model struct {
+	dir      string
+	scripts  []string
+	selected int
+	opts     player.Options
+
+	width, height int
+
+	// The script being presented, if any
+	name     string
+	steps    []script.Step
+	player   *player.Player
+	screen   *screen
+	cancel   context.CancelFunc
+	paused   bool
+	elapsed  time.Duration
+	lastTick time.Time
+	err      error
+}
+
+// New creates a console for the scripts in the directory, played
+// with the options. Questions of the scripts cannot be answered in
+// the console, and dangerous commands are only typed
+func New(dir string, opts player.Options) (*Model, error) {
+	scripts, err := FindScripts(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	opts.Ask = func(name string) (string, error) {
+		return "", fmt.Errorf("cannot ask for the value of %s in the console", name)
+	}
+	opts.Confirm = func(string) (bool, error) { return false, nil }
+	return &Model{dir: dir, scripts: scripts, opts: opts}, nil
+}
+
+// Init starts refreshing the console
+func (m *Model) Init() tea.Cmd {
+	return tick()
+}
+
+// Update handles the keys, the size of the window and the
+// progress of the playback
+func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+	case tickMsg:
+		m.refresh(time.Time(msg))
+		return m, tick()
+	case doneMsg:
+		if msg.player == m.player {
+			if !errors.Is(msg.err, context.Canceled) {
+				m.err = msg.err
+			}
+		}
+	case tea.KeyMsg:
+		if msg.String() == "ctrl+c" {
+			m.stop()
+			return m, tea.Quit
+		}
+		if m.player != nil {
+			return m, m.presentKey(msg)
+		}
+		return m, m.listKey(msg)
+	}
+	return m, nil
+}
+
+// refresh updates the time spent playing
+func (m *Model) refresh(now time.Time) {
+	if m.player != nil && m.player.Status().State == player.Playing && !m.lastTick.IsZero() {
+		m.elapsed += now.Sub(m.lastTick)
+	}
+	m.lastTick = now
+}
+
+// listKey handles the keys of the script list
+func (m *Model) listKey(msg tea.KeyMsg) tea.Cmd {
+	switch msg.String() {
+	case "up", "k":
+		m.selected = max(m.selected-1, 0)
+	case "down", "j":
+		m.selected = min(m.selected+1, len(m.scripts)-1)
+	case "enter":
+		if len(m.scripts) > 0 {
+			return m.load(m.scripts[m.selected])
+		}
+	case "q":
+		return tea.Quit
+	}
+	return nil
+}
+
+// presentKey handles the playback controls
+func (m *Model) presentKey(msg tea.KeyMsg) tea.Cmd {
+	switch msg.String() {
+	case " ":
+		if m.paused {
+			m.player.Resume()
+		} else {
+			m.player.Pause()
+		}
+		m.paused = !m.paused
+	case "n":
+		m.player.Step()
+		m.paused = true
+	case "left", "[":
+		m.player.Seek(m.player.Status().Step - 1)
+	case "right", "]":
+		m.player.Seek(m.player.Status().Step + 1)
+	case "home", "g":
+		m.player.Seek(0)
+	case "esc":
+		m.stop()
+	case "q":
+		m.stop()
+		return tea.Quit
+	}
+	return nil
+}
+
+// load parses the script and starts a paused playback of it on a
+// virtual screen filling the window below the step list
+func (m *Model) load(name string) tea.Cmd {
+	s, err := script.Load(filepath.Join(m.dir, name))
+	if err != nil {
+		m.err = err
+		return nil
+	}
+
+	cols, rows := m.width, m.height-stepRows-3
+	if cols <= 0 || rows <= 0 {
+		cols, rows = 80, 24
+	}
+	m.screen = &screen{screen: vt.NewScreen(cols, rows)}
+	opts := m.opts
+	opts.Width = func() int { return cols }
+
+	p := player.New(s, m.screen, opts)
+	p.Pause()
+	ctx, cancel := context.WithCancel(context.Background())
+
+	m.name, m.steps, m.player, m.cancel = name, s.Steps, p, cancel
+	m.paused, m.elapsed, m.err = true, 0, nil
+	return func() tea.Msg { return doneMsg{player: p, err: p.Run(ctx)} }
+}
+
+// stop ends the playback and returns to the script list
+func (m *Model) stop() {
+	if m.cancel != nil {
+		m.cancel()
+	}
+	m.player, m.screen, m.cancel, m.steps = nil, nil, nil, nil
+}
+
+// View draws the script list, or the steps, the screen and the
+// status bar of the script being presented
+func (m *Model) View() string {
+	var b strings.Builder
+	if m.player == nil {
+		m.viewList(&b)
+	} else {
+		m.viewPresent(&b)
+	}
+	return b.String()
+}
+
+// viewList draws the scripts in the directory
+func (m *Model) viewList(b *strings.Builder) {
+	fmt.Fprintf(b, "%s\n\n", locale.T("Scripts in %s", m.dir))
+	if len(m.scripts) == 0 {
+		b.WriteString("  " + locale.T("No scripts found") + "\n")
+	}
+	for i, name := range m.scripts {
+		marker := "  "
+		if i == m.selected {
+			marker = "> "
+		}
+		b.WriteString(m.fit(marker+name) + "\n")
+	}
+	b.WriteString("\n")
+	if m.err != nil {
+		b.WriteString(m.fit(locale.T("Error: %v", m.err)) + "\n")
+	}
+	b.WriteString(m.fit(locale.T("↑/↓ select  enter present  q quit")))
+}
+
+// viewPresent draws the steps around the current one, the
+// screen of the playback and the status bar
+func (m *Model) viewPresent(b *strings.Builder) {
+	b.WriteString(m.fit(m.name) + "\n")
+
+	// Scroll the step list to keep the current step visible
+	status := m.player.Status()
+	first := max(0, min(status.Step-stepRows/2, len(m.steps)-stepRows))
+	for i := first; i < first+stepRows; i++ {
+		if i >= len(m.steps) {
+			b.WriteString("\n")
+			continue
+		}
+		marker := "  "
+		if i == status.Step {
+			marker = "▶ "
+		}
+		b.WriteString(m.fit(fmt.Sprintf("%s%2d  %s", marker, i+1, describe(m.steps[i]))) + "\n")
+	}
+	b.WriteString(strings.Repeat("─", max(m.width, 1)) + "\n")
+
+	for _, line := range m.screen.lines() {
+		b.WriteString(m.fit(line) + "\n")
+	}
+
+	// Draw the status bar in reverse video
+	bar := fmt.Sprintf(" %s  %s  %02d:%02d", locale.T(status.State.String()),
+		locale.T("step %d/%d", min(status.Step+1, status.Total), status.Total),
+		int(m.elapsed.Minutes()), int(m.elapsed.Seconds())%60)
+	if m.err != nil {
+		bar += "  " + locale.T("error: %v", m.err)
+	} else {
+		bar += "  " + locale.T("space play/pause  n step  ←/→ seek  esc back  q quit")
+	}
+	bar = m.fit(bar)
+	if pad := m.width - textWidth(bar); pad > 0 {
+		bar += strings.Repeat(" ", pad)
+	}
+	b.WriteString("\033[7m" + bar + "\033[0m")
+}
+
+// describe returns the text of a step in the step list
+func describe(step script.Step) string {
+	switch {
+	case step.Ask != "":
+		return "#ask " + step.Ask
+	case step.Think > 0:
+		return fmt.Sprintf("#think %d", step.Think)
+	case step.WaitAudio != "":
+		return "#wait-audio " + step.WaitAudio
+	case step.Image != "":
+		return "#image " + step.Image
+	case step.QRCode != "":
+		return "#qrcode " + step.QRCode
+	case step.Motd != "":
+		return "#motd " + step.Motd
+	case step.Lua != "":
+		return "#lua " + strings.Join(strings.Fields(step.Lua), " ")
+	default:
+		return step.Text()
+	}
+}
+
+// fit truncates the line to the width of the window
+func (m *Model) fit(line string) string {
+	return fit(line, m.width)
+}
+
+// fit truncates the line to the width, unless the width is unknown
+func fit(line string, width int) string {
+	if width <= 0 {
+		return line
+	}
+	w := 0
+	for i, r := range line {
+		w += vt.RuneWidth(r)
+		if w > width {
+			return line[:i]
+		}
+	}
+	return line
+}
+
+// textWidth returns the number of columns of the text
+func textWidth(s string) int {
+	w := 0
+	for _, r := range s {
+		w += vt.RuneWidth(r)
+	}
+	return w
+}
@@ -0,0 +1,112 @@
+package tui_test
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/bitcanon/autotyper/cli"
+	"github.com/bitcanon/autotyper/player"
+	"github.com/bitcanon/autotyper/tui"
+)
+
+// writeScripts creates a directory with the files
+func writeScripts(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+	}
+	return dir
+}
+
+// key returns the message of a key press
+func key(s string) tea.KeyMsg {
+	switch s {
+	case "enter":
+		return tea.KeyMsg{Type: tea.KeyEnter}
+	case "down":
+		return tea.KeyMsg{Type: tea.KeyDown}
+	case "esc":
+		return tea.KeyMsg{Type: tea.KeyEsc}
+	case " ":
+		return tea.KeyMsg{Type: tea.KeySpace, Runes: []rune{' '}}
+	}
+	return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(s)}
+}
+
+// TestFindScripts tests that only the files of the script
+// formats are listed, sorted by name
+func TestFindScripts(t *testing.T) {
+	dir := writeScripts(t, map[string]string{
+		"b.yaml": "", "a.txt": "", "c.json": "", "notes.md": "", "d.TOML": "",
+	})
+	names, err := tui.FindScripts(dir)
+	if err != nil {
+		t.Fatalf("expected no error, but got %v", err)
+	}
+	expected := []string{"a.txt", "b.yaml", "c.json", "d.TOML"}
+	if !reflect.DeepEqual(names, expected) {
+		t.Errorf("expected %q, but got %q", expected, names)
+	}
+}
+
+// TestModelPresent tests that a selected script is played
+// with the controls, and that esc goes back to the list
+func TestModelPresent(t *testing.T) {
+	dir := writeScripts(t, map[string]string{
+		"first.txt":  "echo one",
+		"second.txt": "echo hello\necho world",
+	})
+	m, err := tui.New(dir, player.Options{Prompt: cli.Prompt{Path: "C:\\", Shell: cli.Cmd}, NoClear: true})
+	if err != nil {
+		t.Fatalf("expected no error, but got %v", err)
+	}
+	m.Update(tea.WindowSizeMsg{Width: 60, Height: 20})
+
+	view := m.View()
+	if !strings.Contains(view, "> first.txt") || !strings.Contains(view, "  second.txt") {
+		t.Fatalf("expected the scripts with the first one selected, but got:\n%s", view)
+	}
+
+	// Present the second script, which starts paused
+	m.Update(key("down"))
+	_, run := m.Update(key("enter"))
+	if run == nil {
+		t.Fatalf("expected the playback to start")
+	}
+	done := make(chan tea.Msg)
+	go func() { done <- run() }()
+
+	view = m.View()
+	if !strings.Contains(view, "second.txt") || !strings.Contains(view, "▶  1  echo hello") {
+		t.Fatalf("expected the steps with the first one current, but got:\n%s", view)
+	}
+
+	// Play the first step only
+	m.Update(key("n"))
+	deadline := time.Now().Add(5 * time.Second)
+	for !strings.Contains(m.View(), "▶  2  echo world") {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for the first step, view:\n%s", m.View())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if view := m.View(); !strings.Contains(view, "C:\\> echo hello\nhello") || strings.Contains(view, "\nworld") {
+		t.Errorf("expected only the first command on the screen, but got:\n%s", view)
+	}
+
+	// Go back to the list, which stops the playback
+	m.Update(key("esc"))
+	<-done
+	if view := m.View(); !strings.Contains(view, "> second.txt") {
+		t.Errorf("expected the script list, but got:\n%s", view)
+	}
+}
@@ -0,0 +1,467 @@
+/*
+Copyright © 2023 Mikael Schultz <bitcanon@proton.me>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package vt
+
+import (
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/width"
+)
+
+// Cell is a character on the screen with its colors and attributes,
+// kept as the parameters of the SGR escape sequence (e.g. "38;5;82").
+// The cell right of a wide character is a continuation with Rune 0.
+type Cell struct {
+	Rune  rune
+	Style string
+}
+
+// Define the states of the escape sequence parser
+const (
+	ground = iota
+	escape
+	csi
+	osc
+	dcs
+)
+
+// Screen is a virtual terminal screen of a fixed size. The output
+// written to the screen is interpreted like a terminal would do:
+// long lines wrap at the last column, the screen scrolls up at the
+// last row (or of the scroll region), and the common escape sequences
+// (cursor movement, erasing, colors, scroll regions and the alternate
+// screen) are applied.
+type Screen struct {
+	cols, rows int
+	cells      [][]Cell
+	dirty      []bool
+
+	// The cursor position, col may be cols while a wrap is pending
+	col, row int
+	style    string
+
+	// The cursor saved by ESC 7, restored by ESC 8
+	savedCol, savedRow int
+	savedStyle         string
+
+	// The first and the last row of the scroll region
+	top, bottom int
+
+	// The main screen, saved while the alternate screen is used
+	main         [][]Cell
+	mainCol      int
+	mainRow      int
+	cursorHidden bool
+
+	// The state of the escape sequence parser
+	state  int
+	params []byte
+	utf8   []byte
+}
+
+// NewScreen creates an empty screen of cols columns and rows rows
+func NewScreen(cols, rows int) *Screen {
+	s := &Screen{cols: cols, rows: rows, bottom: rows - 1, dirty: make([]bool, rows)}
+	s.cells = s.blank()
+	return s
+}
+
+// Size returns the number of columns and rows of the screen
+func (s *Screen) Size() (cols, rows int) {
+	return s.cols, s.rows
+}
+
+// Cursor returns the column and the row of the cursor, from 0
+func (s *Screen) Cursor() (col, row int) {
+	if s.col >= s.cols {
+		return s.cols - 1, s.row
+	}
+	return s.col, s.row
+}
+
+// CursorHidden reports whether the cursor has been hidden
+func (s *Screen) CursorHidden() bool {
+	return s.cursorHidden
+}
+
+// Cells returns the cells of a row
+func (s *Screen) Cells(row int) []Cell {
+	return s.cells[row]
+}
+
+// Lines returns the text of the rows, without the trailing spaces
+func (s *Screen) Lines() []string {
+	lines := make([]string, s.rows)
+	for i := range s.cells {
+		lines[i] = s.Line(i)
+	}
+	return lines
+}
+
+// Line returns the text of a row, without the trailing spaces
+func (s *Screen) Line(row int) string {
+	var b strings.Builder
+	for _, c := range s.cells[row] {
+		if c.Rune != 0 {
+			b.WriteRune(c.Rune)
+		}
+	}
+	return strings.TrimRight(b.String(), " ")
+}
+
+// Render returns the text of the cells with the escape sequences
+// of their styles, ending with the style of the last cell
+func Render(cells []Cell) string {
+	var b strings.Builder
+	style := ""
+	for _, c := range cells {
+		if c.Rune == 0 {
+			continue
+		}
+		if c.Style != style {
+			if c.Style == "" {
+				b.WriteString("\033[0m")
+			} else {
+				b.WriteString("\033[0;" + c.Style + "m")
+			}
+			style = c.Style
+		}
+		b.WriteRune(c.Rune)
+	}
+	return b.String()
+}
+
+// String returns the text of the screen, one line per row
+// without the trailing empty rows
+func (s *Screen) String() string {
+	return strings.TrimRight(strings.Join(s.Lines(), "\n"), "\n")
+}
+
+// Write interprets the output written to the screen. Multi-byte
+// characters and escape sequences may be split across writes.
+func (s *Screen) Write(p []byte) (int, error) {
+	for _, b := range p {
+		s.feed(b)
+	}
+	return len(p), nil
+}
+
+// feed interprets the next byte of the output
+func (s *Screen) feed(b byte) {
+	switch s.state {
+	case escape:
+		switch b {
+		case '[':
+			s.state, s.params = csi, s.params[:0]
+		case ']':
+			s.state = osc
+		case 'P':
+			s.state = dcs
+		case '7':
+			s.state = ground
+			s.savedCol, s.savedRow, s.savedStyle = s.col, s.row, s.style
+		case '8':
+			s.state = ground
+			s.col, s.row, s.style = s.savedCol, s.savedRow, s.savedStyle
+		default:
+			s.state = ground
+		}
+		return
+	case csi:
+		if b >= 0x40 && b <= 0x7e {
+			s.state = ground
+			s.control(b, string(s.params))
+			return
+		}
+		s.params = append(s.params, b)
+		return
+	case osc:
+		// Ignore operating system commands (e.g. the window
+		// title), terminated by BEL or ESC \
+		if b == '\a' {
+			s.state = ground
+		} else if b == 0x1b {
+			s.state = escape
+		}
+		return
+	case dcs:
+		// Ignore device control strings (e.g. Sixel graphics),
+		// terminated by ESC \
+		if b == 0x1b {
+			s.state = escape
+		}
+		return
+	}
+
+	// Collect the bytes of multi-byte characters
+	if len(s.utf8) > 0 || b >= utf8.RuneSelf {
+		s.utf8 = append(s.utf8, b)
+		if !utf8.FullRune(s.utf8) {
+			return
+		}
+		r, _ := utf8.DecodeRune(s.utf8)
+		s.utf8 = s.utf8[:0]
+		s.print(r)
+		return
+	}
+
+	switch b {
+	case 0x1b:
+		s.state = escape
+	case '\r':
+		s.col = 0
+	case '\n':
+		// Output is written through a terminal translating
+		// line feeds to carriage returns and line feeds
+		s.col = 0
+		s.lineFeed()
+	case '\b':
+		if s.col >= s.cols {
+			s.col = s.cols - 1
+		}
+		if s.col > 0 {
+			s.col--
+		}
+	case '\t':
+		s.col = min((s.col/8+1)*8, s.cols-1)
+	default:
+		if b >= ' ' && b != 0x7f {
+			s.print(rune(b))
+		}
+	}
+}
+
+// print puts a character at the cursor, wrapping to the next
+// line when the character would not fit on the current line
+func (s *Screen) print(r rune) {
+	w := RuneWidth(r)
+	if w == 0 {
+		return
+	}
+	if s.col+w > s.cols {
+		s.col = 0
+		s.lineFeed()
+	}
+
+	s.cells[s.row][s.col] = Cell{Rune: r, Style: s.style}
+	if w == 2 {
+		s.cells[s.row][s.col+1] = Cell{Style: s.style}
+	}
+	s.dirty[s.row] = true
+	s.col += w
+}
+
+// lineFeed moves the cursor down, scrolling the scroll region
+// up at its last row
+func (s *Screen) lineFeed() {
+	if s.row != s.bottom {
+		s.row = min(s.row+1, s.rows-1)
+		return
+	}
+	copy(s.cells[s.top:s.bottom+1], s.cells[s.top+1:s.bottom+1])
+	s.cells[s.bottom] = s.blankRow()
+	s.touch(s.top, s.bottom+1)
+}
+
+// control applies the CSI escape sequence with the final byte
+func (s *Screen) control(final byte, params string) {
+	if strings.HasPrefix(params, "?") {
+		s.mode(final, params[1:])
+		return
+	}
+
+	args := parseParams(params)
+	arg := func(i, def int) int {
+		if i < len(args) && args[i] > 0 {
+			return args[i]
+		}
+		return def
+	}
+
+	switch final {
+	case 'm':
+		s.setStyle(params)
+	case 'A':
+		s.row = max(s.row-arg(0, 1), 0)
+		s.col = min(s.col, s.cols-1)
+	case 'B':
+		s.row = min(s.row+arg(0, 1), s.rows-1)
+		s.col = min(s.col, s.cols-1)
+	case 'C':
+		s.col = min(s.col+arg(0, 1), s.cols-1)
+	case 'D':
+		s.col = max(min(s.col, s.cols-1)-arg(0, 1), 0)
+	case 'G':
+		s.col = min(arg(0, 1), s.cols) - 1
+	case 'H', 'f':
+		s.row = min(arg(0, 1), s.rows) - 1
+		s.col = min(arg(1, 1), s.cols) - 1
+	case 'J':
+		s.eraseDisplay(arg(0, 0))
+	case 'K':
+		s.eraseLine(s.row, arg(0, 0))
+	case 'r':
+		if top, bottom := arg(0, 1)-1, min(arg(1, s.rows), s.rows)-1; top < bottom {
+			s.top, s.bottom = top, bottom
+			s.col, s.row = 0, 0
+		}
+	}
+}
+
+// mode applies the private mode sequences (e.g. "?1049h")
+func (s *Screen) mode(final byte, params string) {
+	switch params {
+	case "25":
+		s.cursorHidden = final == 'l'
+	case "1049":
+		if final == 'h' && s.main == nil {
+			s.main, s.mainCol, s.mainRow = s.cells, s.col, s.row
+			s.cells = s.blank()
+		} else if final == 'l' && s.main != nil {
+			s.cells, s.col, s.row = s.main, s.mainCol, s.mainRow
+			s.main = nil
+		}
+		s.touch(0, s.rows)
+	}
+}
+
+// setStyle applies the parameters of an SGR escape sequence
+func (s *Screen) setStyle(params string) {
+	if params == "" || params == "0" {
+		s.style = ""
+		return
+	}
+	if strings.HasPrefix(params, "0;") {
+		s.style = params[2:]
+		return
+	}
+	if s.style == "" {
+		s.style = params
+	} else {
+		s.style += ";" + params
+	}
+}
+
+// eraseDisplay erases the screen after the cursor (0), before
+// the cursor (1), or the whole screen (2, and 3 with scrollback)
+func (s *Screen) eraseDisplay(n int) {
+	switch n {
+	case 0:
+		s.eraseLine(s.row, 0)
+		s.clearRows(s.row+1, s.rows)
+	case 1:
+		s.eraseLine(s.row, 1)
+		s.clearRows(0, s.row)
+	default:
+		s.clearRows(0, s.rows)
+	}
+}
+
+// eraseLine erases a row after the cursor (0), before
+// the cursor (1), or the whole row (2)
+func (s *Screen) eraseLine(row, n int) {
+	from, to := 0, s.cols
+	switch n {
+	case 0:
+		from = min(s.col, s.cols)
+	case 1:
+		to = min(s.col+1, s.cols)
+	}
+	for i := from; i < to; i++ {
+		s.cells[row][i] = Cell{Rune: ' '}
+	}
+	s.dirty[row] = true
+}
+
+// clearRows blanks the rows from up to but not including to
+func (s *Screen) clearRows(from, to int) {
+	for i := from; i < to; i++ {
+		s.cells[i] = s.blankRow()
+	}
+	s.touch(from, to)
+}
+
+// touch marks the rows as changed
+func (s *Screen) touch(from, to int) {
+	for i := from; i < to; i++ {
+		s.dirty[i] = true
+	}
+}
+
+// changed returns the rows changed since the last call
+func (s *Screen) changed() []int {
+	var rows []int
+	for i, d := range s.dirty {
+		if d {
+			rows = append(rows, i)
+			s.dirty[i] = false
+		}
+	}
+	return rows
+}
+
+// blank returns the cells of an empty screen
+func (s *Screen) blank() [][]Cell {
+	cells := make([][]Cell, s.rows)
+	for i := range cells {
+		cells[i] = s.blankRow()
+	}
+	return cells
+}
+
+// blankRow returns the cells of an empty row
+func (s *Screen) blankRow() []Cell {
+	row := make([]Cell, s.cols)
+	for i := range row {
+		row[i] = Cell{Rune: ' '}
+	}
+	return row
+}
+
+// parseParams parses the numbers of an escape sequence (e.g. "3;7")
+func parseParams(params string) []int {
+	if params == "" {
+		return nil
+	}
+	var args []int
+	for _, p := range strings.Split(params, ";") {
+		n, _ := strconv.Atoi(p)
+		args = append(args, n)
+	}
+	return args
+}
+
+// RuneWidth returns the number of columns used by a character:
+// 2 for wide East Asian characters, 0 for control characters
+// and combining marks, and 1 for the others
+func RuneWidth(r rune) int {
+	if r < ' ' || r == 0x7f || (r >= 0x300 && r <= 0x36f) || r == 0x200b {
+		return 0
+	}
+	switch width.LookupRune(r).Kind() {
+	case width.EastAsianWide, width.EastAsianFullwidth:
+		return 2
+	}
+	return 1
+}
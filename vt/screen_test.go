@@ -0,0 +1,92 @@
+package vt_test
+
+import (
+	"testing"
+
+	"github.com/bitcanon/autotyper/vt"
+)
+
+// TestScreenWrite tests that the output is laid out on the screen
+// like a terminal would do, wrapping and scrolling at its edges
+func TestScreenWrite(t *testing.T) {
+	tests := []struct {
+		name     string
+		cols     int
+		rows     int
+		output   string
+		expected string
+	}{
+		{name: "lines", cols: 10, rows: 3, output: "one\ntwo", expected: "one\ntwo"},
+		{name: "wrap", cols: 5, rows: 3, output: "abcdefgh", expected: "abcde\nfgh"},
+		{name: "exact width", cols: 3, rows: 3, output: "abc\ndef", expected: "abc\ndef"},
+		{name: "scroll", cols: 5, rows: 2, output: "1\n2\n3", expected: "2\n3"},
+		{name: "carriage return", cols: 10, rows: 2, output: "hello\rJ", expected: "Jello"},
+		{name: "backspace", cols: 10, rows: 2, output: "ab\b\bx", expected: "xb"},
+		{name: "tab", cols: 20, rows: 2, output: "a\tb", expected: "a       b"},
+		{name: "colors", cols: 10, rows: 2, output: "\033[38;5;229mls\033[0m -l", expected: "ls -l"},
+		{name: "clear", cols: 10, rows: 2, output: "one\033[H\033[2Jtwo", expected: "two"},
+		{name: "erase line", cols: 10, rows: 2, output: "question\r\033[Kanswer", expected: "answer"},
+		{name: "cursor up", cols: 10, rows: 3, output: "one\ntwo\033[A\r\033[Kx", expected: "x\ntwo"},
+		{name: "position", cols: 10, rows: 3, output: "\033[2;3Hx", expected: "\n  x"},
+		{name: "wide", cols: 5, rows: 2, output: "日本語", expected: "日本\n語"},
+		{name: "title", cols: 10, rows: 2, output: "\033]0;title\ahi", expected: "hi"},
+		{name: "sixel", cols: 10, rows: 2, output: "\033P0;1q#1;2;0;0;0#1~~\033\\hi", expected: "hi"},
+		{name: "scroll region", cols: 10, rows: 3, output: "\033[3;1Hstatus\033[1;2r1\n2\n3", expected: "2\n3\nstatus"},
+		{name: "save cursor", cols: 10, rows: 3, output: "one\0337\033[3;1Hstatus\0338two", expected: "onetwo\n\nstatus"},
+		{name: "alternate screen", cols: 10, rows: 2, output: "main\033[?1049halt\033[?1049l", expected: "main"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s := vt.NewScreen(test.cols, test.rows)
+			s.Write([]byte(test.output))
+			if s.String() != test.expected {
+				t.Errorf("expected %q, but got %q", test.expected, s.String())
+			}
+		})
+	}
+}
+
+// TestScreenSplitWrites tests that characters and escape
+// sequences split across writes are interpreted as a whole
+func TestScreenSplitWrites(t *testing.T) {
+	s := vt.NewScreen(10, 2)
+	output := "\033[38;5;82mé\033[0m!"
+	for i := 0; i < len(output); i++ {
+		s.Write([]byte{output[i]})
+	}
+
+	if s.String() != "é!" {
+		t.Errorf("expected %q, but got %q", "é!", s.String())
+	}
+	if style := s.Cells(0)[0].Style; style != "38;5;82" {
+		t.Errorf("expected style %q, but got %q", "38;5;82", style)
+	}
+	if style := s.Cells(0)[1].Style; style != "" {
+		t.Errorf("expected no style, but got %q", style)
+	}
+	if col, row := s.Cursor(); col != 2 || row != 0 {
+		t.Errorf("expected cursor at 2,0, but got %d,%d", col, row)
+	}
+}
+
+// TestRuneWidth tests the number of columns used by characters
+func TestRuneWidth(t *testing.T) {
+	tests := []struct {
+		r        rune
+		expected int
+	}{
+		{r: 'a', expected: 1},
+		{r: 'é', expected: 1},
+		{r: '語', expected: 2},
+		{r: 'ｱ', expected: 1},
+		{r: '́', expected: 0},
+		{r: '\n', expected: 0},
+	}
+
+	for _, test := range tests {
+		if w := vt.RuneWidth(test.r); w != test.expected {
+			t.Errorf("%q: expected width %d, but got %d", test.r, test.expected, w)
+		}
+	}
+}
@@ -0,0 +1,160 @@
+/*
+Copyright © 2023 Mikael Schultz <bitcanon@proton.me>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package vt
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+)
+
+// Terminal is the output of a playback: a terminal of a size
+// showing the text and the control sequences written to it
+type Terminal interface {
+	io.Writer
+
+	// Size returns the number of columns and rows
+	Size() (cols, rows int)
+}
+
+// Memory is a terminal in memory. It keeps every byte written to it,
+// control sequences included, and shows them on a virtual screen, so
+// the output of a playback can be compared to golden files in tests
+// and exported without a real terminal. It is safe for concurrent use.
+type Memory struct {
+	mu     sync.Mutex
+	screen *Screen
+	output bytes.Buffer
+}
+
+// NewMemory creates a terminal in memory of cols columns and rows rows
+func NewMemory(cols, rows int) *Memory {
+	return &Memory{screen: NewScreen(cols, rows)}
+}
+
+// Write keeps the output and interprets it on the screen
+func (m *Memory) Write(p []byte) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.output.Write(p)
+	return m.screen.Write(p)
+}
+
+// Size returns the size of the screen
+func (m *Memory) Size() (cols, rows int) {
+	return m.screen.Size()
+}
+
+// Output returns all the bytes written to the terminal
+func (m *Memory) Output() string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.output.String()
+}
+
+// Text returns the text on the screen, one line per row without
+// the trailing spaces
+func (m *Memory) Text() string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.screen.String()
+}
+
+// Sequences returns the escape sequences written to the
+// terminal, in the order they were written
+func (m *Memory) Sequences() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var sequences []string
+	out := m.output.Bytes()
+	for i := 0; i < len(out); i++ {
+		if out[i] == 0x1b {
+			end := sequenceEnd(out, i)
+			sequences = append(sequences, string(out[i:end]))
+			i = end - 1
+		}
+	}
+	return sequences
+}
+
+// Transcript returns the output in a readable form for golden
+// files: ESC is written as \e, and the other control characters
+// but line feeds and tabs are escaped as well
+func (m *Memory) Transcript() string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var b strings.Builder
+	for _, c := range m.output.Bytes() {
+		switch {
+		case c == 0x1b:
+			b.WriteString(`\e`)
+		case c == '\r':
+			b.WriteString(`\r`)
+		case c == '\a':
+			b.WriteString(`\a`)
+		case c == '\b':
+			b.WriteString(`\b`)
+		case c < ' ' && c != '\n' && c != '\t', c == 0x7f:
+			fmt.Fprintf(&b, `\x%02x`, c)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+// sequenceEnd returns the end of the escape sequence starting at
+// start: a control sequence ends with its final byte, an operating
+// system command or a device control string with BEL or ESC \, and
+// any other sequence with the character after ESC
+func sequenceEnd(out []byte, start int) int {
+	i := start + 1
+	if i >= len(out) {
+		return len(out)
+	}
+	switch out[i] {
+	case '[':
+		for i++; i < len(out); i++ {
+			if out[i] >= 0x40 && out[i] <= 0x7e {
+				return i + 1
+			}
+		}
+		return len(out)
+	case ']', 'P':
+		for i++; i < len(out); i++ {
+			if out[i] == '\a' {
+				return i + 1
+			}
+			if out[i] == 0x1b && i+1 < len(out) && out[i+1] == '\\' {
+				return i + 2
+			}
+		}
+		return len(out)
+	default:
+		return i + 1
+	}
+}
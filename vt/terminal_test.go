@@ -0,0 +1,86 @@
+package vt_test
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/bitcanon/autotyper/vt"
+)
+
+// The screens, views and terminals in memory are all terminals
+var (
+	_ vt.Terminal = (*vt.Screen)(nil)
+	_ vt.Terminal = (*vt.View)(nil)
+	_ vt.Terminal = (*vt.Memory)(nil)
+)
+
+// TestMemory tests that a terminal in memory keeps the output,
+// its escape sequences and the text on its screen
+func TestMemory(t *testing.T) {
+	tests := []struct {
+		name       string
+		writes     []string
+		sequences  []string
+		transcript string
+		text       string
+	}{
+		{
+			name:       "Text",
+			writes:     []string{"ls\n", "a  b"},
+			transcript: "ls\na  b",
+			text:       "ls\na  b",
+		},
+		{
+			name:       "ControlSequences",
+			writes:     []string{"\033[H\033[2J", "\033[38;5;229mls\033[0m\r\n"},
+			sequences:  []string{"\033[H", "\033[2J", "\033[38;5;229m", "\033[0m"},
+			transcript: `\e[H\e[2J\e[38;5;229mls\e[0m\r` + "\n",
+			text:       "ls",
+		},
+		{
+			name:       "SplitAcrossWrites",
+			writes:     []string{"\033[3", "1mred", "\033[0m"},
+			sequences:  []string{"\033[31m", "\033[0m"},
+			transcript: `\e[31mred\e[0m`,
+			text:       "red",
+		},
+		{
+			name:       "TitleAndSaveCursor",
+			writes:     []string{"\033]2;demo\a\0337x\0338", "\033]2;end\033\\"},
+			sequences:  []string{"\033]2;demo\a", "\0337", "\0338", "\033]2;end\033\\"},
+			transcript: `\e]2;demo\a\e7x\e8\e]2;end\e\`,
+			text:       "x",
+		},
+		{
+			name:       "Backspace",
+			writes:     []string{"lx\b \bs"},
+			transcript: `lx\b \bs`,
+			text:       "ls",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			m := vt.NewMemory(20, 3)
+			for _, w := range test.writes {
+				if _, err := m.Write([]byte(w)); err != nil {
+					t.Fatalf("expected no error, but got %v", err)
+				}
+			}
+
+			if got := m.Sequences(); !slices.Equal(got, test.sequences) {
+				t.Errorf("expected sequences %q, but got %q", test.sequences, got)
+			}
+			if got := m.Transcript(); got != test.transcript {
+				t.Errorf("expected transcript %q, but got %q", test.transcript, got)
+			}
+			if got := m.Text(); got != test.text {
+				t.Errorf("expected text %q, but got %q", test.text, got)
+			}
+		})
+	}
+
+	if cols, rows := vt.NewMemory(20, 3).Size(); cols != 20 || rows != 3 {
+		t.Errorf("expected a size of 20x3, but got %dx%d", cols, rows)
+	}
+}
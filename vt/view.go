@@ -0,0 +1,95 @@
+/*
+Copyright © 2023 Mikael Schultz <bitcanon@proton.me>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package vt
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// View shows a virtual screen in the top left corner of a real
+// terminal. The output written to the view is interpreted by the
+// screen, and the changed rows are redrawn on the terminal, so the
+// session looks the same whatever the size of the terminal is.
+type View struct {
+	mu     sync.Mutex
+	screen *Screen
+	out    io.Writer
+	drawn  bool
+}
+
+// NewView creates a view of a screen of cols columns and
+// rows rows, drawn on the terminal written to by out
+func NewView(cols, rows int, out io.Writer) *View {
+	return &View{screen: NewScreen(cols, rows), out: out}
+}
+
+// Screen returns the virtual screen of the view. The screen
+// must not be used while the view is written to.
+func (v *View) Screen() *Screen {
+	return v.screen
+}
+
+// Size returns the size of the virtual screen
+func (v *View) Size() (cols, rows int) {
+	return v.screen.Size()
+}
+
+// Write interprets the output on the screen and redraws the
+// changed rows on the terminal
+func (v *View) Write(p []byte) (int, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	v.screen.Write(p)
+	return len(p), v.draw()
+}
+
+// draw redraws the changed rows, and moves the cursor of
+// the terminal to the cursor of the screen
+func (v *View) draw() error {
+	w := bufio.NewWriter(v.out)
+	if !v.drawn {
+		fmt.Fprint(w, "\033[H\033[2J")
+		v.screen.touch(0, v.screen.rows)
+		v.drawn = true
+	}
+
+	for _, row := range v.screen.changed() {
+		fmt.Fprintf(w, "\033[%d;1H", row+1)
+		w.WriteString(Render(v.screen.Cells(row)))
+
+		// Erase what is drawn right of the screen
+		fmt.Fprint(w, "\033[0m\033[K")
+	}
+
+	col, row := v.screen.Cursor()
+	fmt.Fprintf(w, "\033[%d;%dH", row+1, col+1)
+	if v.screen.CursorHidden() {
+		fmt.Fprint(w, "\033[?25l")
+	} else {
+		fmt.Fprint(w, "\033[?25h")
+	}
+	return w.Flush()
+}
@@ -0,0 +1,36 @@
+package vt_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bitcanon/autotyper/vt"
+)
+
+// TestViewWrite tests that the view draws the screen in
+// the top left corner of the terminal
+func TestViewWrite(t *testing.T) {
+	var out strings.Builder
+	v := vt.NewView(4, 2, &out)
+	v.Write([]byte("abcdef"))
+
+	if v.Screen().String() != "abcd\nef" {
+		t.Errorf("expected screen %q, but got %q", "abcd\nef", v.Screen().String())
+	}
+
+	expected := "\033[H\033[2J" +
+		"\033[1;1Habcd\033[0m\033[K" +
+		"\033[2;1Hef  \033[0m\033[K" +
+		"\033[2;3H\033[?25h"
+	if out.String() != expected {
+		t.Errorf("expected %q, but got %q", expected, out.String())
+	}
+
+	// Only the changed rows are drawn again
+	out.Reset()
+	v.Write([]byte("g"))
+	expected = "\033[2;1Hefg \033[0m\033[K\033[2;4H\033[?25h"
+	if out.String() != expected {
+		t.Errorf("expected %q, but got %q", expected, out.String())
+	}
+}
@@ -0,0 +1,63 @@
+/*
+Copyright © 2023 Mikael Schultz <bitcanon@proton.me>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package wasm
+
+import (
+	"context"
+	"io"
+)
+
+// Filter streams output through a module run as a filter: the output
+// written to the filter is the standard input of the module, and the
+// standard output of the module is written to the output of the filter
+type Filter struct {
+	w    *io.PipeWriter
+	done chan error
+}
+
+// NewFilter starts the module as a filter of the output written to
+// out, with the variables in env as its environment. The filter must
+// be closed to wait for the module to exit
+func NewFilter(ctx context.Context, m *Module, out io.Writer, env map[string]string) *Filter {
+	r, w := io.Pipe()
+	f := &Filter{w: w, done: make(chan error, 1)}
+	go func() {
+		err := m.Run(ctx, r, out, env)
+
+		// Fail the writes to a module that has exited
+		// without reading all of its input
+		r.CloseWithError(io.ErrClosedPipe)
+		f.done <- err
+	}()
+	return f
+}
+
+func (f *Filter) Write(p []byte) (int, error) {
+	return f.w.Write(p)
+}
+
+// Close ends the input of the module and waits for it to exit,
+// returning the error of the module if it failed
+func (f *Filter) Close() error {
+	f.w.Close()
+	return <-f.done
+}
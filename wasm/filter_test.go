@@ -0,0 +1,42 @@
+package wasm_test
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/bitcanon/autotyper/wasm"
+)
+
+// TestFilter tests that the output written to a filter is streamed
+// through the module, and that the error of the module is returned
+func TestFilter(t *testing.T) {
+	module, err := wasm.Load(buildModule(t, "upper"))
+	if err != nil {
+		t.Fatalf("expected no error, but got %v", err)
+	}
+
+	var out strings.Builder
+	filter := wasm.NewFilter(context.Background(), module, &out, nil)
+	io.WriteString(filter, "one\ntw")
+	io.WriteString(filter, "o\n")
+	if err := filter.Close(); err != nil {
+		t.Fatalf("expected no error, but got %v", err)
+	}
+	if out.String() != "| ONE\n| TWO\n" {
+		t.Errorf("expected %q, but got %q", "| ONE\n| TWO\n", out.String())
+	}
+
+	// The writes to a module that has exited fail
+	filter = wasm.NewFilter(context.Background(), module, io.Discard, nil)
+	io.WriteString(filter, "fail\n")
+	for i := 0; i < 100; i++ {
+		if _, err := io.WriteString(filter, "more\n"); err != nil {
+			break
+		}
+	}
+	if err := filter.Close(); err == nil || !strings.Contains(err.Error(), "exit code 3") {
+		t.Errorf("expected the error of the module, but got %v", err)
+	}
+}
@@ -0,0 +1,138 @@
+/*
+Copyright © 2023 Mikael Schultz <bitcanon@proton.me>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package wasm
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/imports/wasi_snapshot_preview1"
+	"github.com/tetratelabs/wazero/sys"
+)
+
+// Module is a WebAssembly module built for WASI (e.g. with GOOS=wasip1
+// or the wasm32-wasip1 target of Rust). The module runs in a sandbox:
+// it reads its standard input and writes its standard output, but has
+// no access to the files, the network or the environment of autotyper
+type Module struct {
+	name     string
+	runtime  wazero.Runtime
+	compiled wazero.CompiledModule
+}
+
+// The modules loaded, by absolute path, compiled only once
+var (
+	mu      sync.Mutex
+	modules = make(map[string]*Module)
+)
+
+// Load compiles the WebAssembly module in the file, or returns the
+// module compiled by a previous call with the same file
+func Load(filename string) (*Module, error) {
+	path, err := filepath.Abs(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if m, ok := modules[path]; ok {
+		return m, nil
+	}
+
+	code, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	m, err := Compile(filepath.Base(path), code)
+	if err != nil {
+		return nil, err
+	}
+	modules[path] = m
+	return m, nil
+}
+
+// Compile compiles the WebAssembly module in code. The name is the
+// name of the program in the arguments of the module
+func Compile(name string, code []byte) (*Module, error) {
+	ctx := context.Background()
+	runtime := wazero.NewRuntimeWithConfig(ctx, wazero.NewRuntimeConfig().WithCloseOnContextDone(true))
+	wasi_snapshot_preview1.MustInstantiate(ctx, runtime)
+
+	compiled, err := runtime.CompileModule(ctx, code)
+	if err != nil {
+		runtime.Close(ctx)
+		return nil, fmt.Errorf("invalid module %s: %w", name, err)
+	}
+	return &Module{name: name, runtime: runtime, compiled: compiled}, nil
+}
+
+// Name returns the name of the module
+func (m *Module) Name() string {
+	return m.name
+}
+
+// Run runs the module until it exits, with stdin and stdout as its
+// standard input and output, and the variables in env (e.g.
+// "AUTOTYPER_STEP") as its environment. The run is stopped when the
+// context is cancelled. The error of a failed run includes the
+// standard error of the module
+func (m *Module) Run(ctx context.Context, stdin io.Reader, stdout io.Writer, env map[string]string) error {
+	var stderr bytes.Buffer
+	config := wazero.NewModuleConfig().
+		WithName("").
+		WithArgs(m.name).
+		WithStdin(stdin).
+		WithStdout(stdout).
+		WithStderr(&stderr)
+	for key, value := range env {
+		config = config.WithEnv(key, value)
+	}
+
+	mod, err := m.runtime.InstantiateModule(ctx, m.compiled, config)
+	if mod != nil {
+		mod.Close(ctx)
+	}
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	if err == nil {
+		return nil
+	}
+
+	var exitErr *sys.ExitError
+	if errors.As(err, &exitErr) {
+		err = fmt.Errorf("exit code %d", exitErr.ExitCode())
+	}
+	if msg := strings.TrimSpace(stderr.String()); msg != "" {
+		err = fmt.Errorf("%w: %s", err, msg)
+	}
+	return err
+}
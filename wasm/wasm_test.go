@@ -0,0 +1,98 @@
+package wasm_test
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bitcanon/autotyper/wasm"
+)
+
+// buildModule builds the program of the tests in testdata/wasm
+// for WASI and returns the path of the module
+func buildModule(t *testing.T, name string) string {
+	t.Helper()
+	if testing.Short() {
+		t.Skip("building WebAssembly modules is slow")
+	}
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go is not available")
+	}
+
+	path := filepath.Join(t.TempDir(), name+".wasm")
+	cmd := exec.Command("go", "build", "-o", path, filepath.Join("..", "testdata", "wasm", name))
+	cmd.Env = append(os.Environ(), "GOOS=wasip1", "GOARCH=wasm")
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("failed to build %s: %v\n%s", name, err, output)
+	}
+	return path
+}
+
+// TestRun tests that a module reads its input and environment and
+// writes its output, and that failures include its standard error
+func TestRun(t *testing.T) {
+	module, err := wasm.Load(buildModule(t, "upper"))
+	if err != nil {
+		t.Fatalf("expected no error, but got %v", err)
+	}
+	if module.Name() != "upper.wasm" {
+		t.Errorf("expected the name %q, but got %q", "upper.wasm", module.Name())
+	}
+
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+		err      string
+	}{
+		{name: "Output", input: "hello\nworld\n", expected: "7| HELLO\n7| WORLD\n"},
+		{name: "EmptyInput", input: "", expected: ""},
+		{name: "Failure", input: "fail\n", err: "exit code 3: cannot filter"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var out strings.Builder
+			err := module.Run(context.Background(), strings.NewReader(test.input), &out, map[string]string{"AUTOTYPER_STEP": "7"})
+			if test.err != "" {
+				if err == nil || !strings.Contains(err.Error(), test.err) {
+					t.Errorf("expected error %q, but got %v", test.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected no error, but got %v", err)
+			}
+			if out.String() != test.expected {
+				t.Errorf("expected %q, but got %q", test.expected, out.String())
+			}
+		})
+	}
+}
+
+// TestLoad tests that modules are compiled once, and that invalid
+// modules are not loaded
+func TestLoad(t *testing.T) {
+	path := buildModule(t, "upper")
+	first, err := wasm.Load(path)
+	if err != nil {
+		t.Fatalf("expected no error, but got %v", err)
+	}
+	second, err := wasm.Load(path)
+	if err != nil {
+		t.Fatalf("expected no error, but got %v", err)
+	}
+	if first != second {
+		t.Errorf("expected the module to be compiled once")
+	}
+
+	if _, err := wasm.Compile("invalid.wasm", []byte("not a module")); err == nil {
+		t.Errorf("expected error for an invalid module, but got nil")
+	}
+	if _, err := wasm.Load(filepath.Join(t.TempDir(), "missing.wasm")); err == nil {
+		t.Errorf("expected error for a missing file, but got nil")
+	}
+}
@@ -0,0 +1,207 @@
+/*
+Copyright © 2023 Mikael Schultz <bitcanon@proton.me>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"slices"
+	"sync"
+	"text/template"
+	"time"
+)
+
+// Define the events of a run webhooks are fired for
+const (
+	Start  = "start"
+	Finish = "finish"
+	Fail   = "fail"
+)
+
+// DefaultTimeout is how long a webhook may take to respond
+const DefaultTimeout = 10 * time.Second
+
+// Run describes the run of a demo a webhook is fired for
+type Run struct {
+	// The event (Start, Finish or Fail) and the time it happened
+	Event string    `json:"event"`
+	Time  time.Time `json:"time"`
+
+	// The script being played, empty if it was not read from a file,
+	// and the host playing it
+	Script string `json:"script,omitempty"`
+	Host   string `json:"host"`
+
+	// The number of steps played, how long the run lasted in
+	// milliseconds, and why it failed, once it is over
+	Steps    int    `json:"steps"`
+	Duration int64  `json:"duration_ms"`
+	Error    string `json:"error,omitempty"`
+}
+
+// Hook is a URL notified of the events of the runs with a POST request
+type Hook struct {
+	URL string
+
+	// The events the hook is fired for, all if empty
+	Events []string
+
+	// The headers of the requests (e.g. "Authorization")
+	Headers map[string]string
+
+	// The template of the body, executed with the Run, or nil
+	// to send the Run as JSON
+	Payload *template.Template
+}
+
+// funcs are the functions of the payload templates
+var funcs = template.FuncMap{
+	// json encodes a value as JSON, to quote strings in JSON payloads
+	"json": func(v any) (string, error) {
+		data, err := json.Marshal(v)
+		return string(data), err
+	},
+}
+
+// New returns the hook of the URL for the events (all if empty), with
+// the body of its requests made from the payload template. The run is
+// sent as JSON if the payload is empty
+func New(rawURL string, events []string, payload string) (*Hook, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid webhook URL %q", rawURL)
+	}
+	for _, event := range events {
+		if event != Start && event != Finish && event != Fail {
+			return nil, fmt.Errorf("invalid webhook event %q, expected start, finish or fail", event)
+		}
+	}
+	h := &Hook{URL: rawURL, Events: events}
+	if payload != "" {
+		if h.Payload, err = template.New(rawURL).Funcs(funcs).Parse(payload); err != nil {
+			return nil, fmt.Errorf("invalid webhook payload: %w", err)
+		}
+	}
+	return h, nil
+}
+
+// Fires reports whether the hook is fired for the event
+func (h *Hook) Fires(event string) bool {
+	return len(h.Events) == 0 || slices.Contains(h.Events, event)
+}
+
+// body returns the body of the request for the run, and its type
+func (h *Hook) body(run Run) ([]byte, string, error) {
+	if h.Payload == nil {
+		data, err := json.Marshal(run)
+		return data, "application/json", err
+	}
+	var b bytes.Buffer
+	if err := h.Payload.Execute(&b, run); err != nil {
+		return nil, "", err
+	}
+	contentType := "text/plain; charset=utf-8"
+	if json.Valid(b.Bytes()) {
+		contentType = "application/json"
+	}
+	return b.Bytes(), contentType, nil
+}
+
+// Notifier fires the webhooks of the events of the runs
+type Notifier struct {
+	Hooks []*Hook
+
+	// The client of the requests, http.DefaultClient if nil,
+	// and the User-Agent header of the requests
+	Client    *http.Client
+	UserAgent string
+
+	// How long each webhook may take, DefaultTimeout if zero
+	Timeout time.Duration
+}
+
+// Notify fires the webhooks of the event of the run at the same time,
+// and returns once they have all responded or timed out. Failed
+// webhooks are logged, as they must not stop the demo
+func (n *Notifier) Notify(ctx context.Context, run Run) {
+	var wg sync.WaitGroup
+	for _, h := range n.Hooks {
+		if !h.Fires(run.Event) {
+			continue
+		}
+		wg.Add(1)
+		go func(h *Hook) {
+			defer wg.Done()
+			if err := n.fire(ctx, h, run); err != nil {
+				slog.Warn("webhook failed", "url", h.URL, "event", run.Event, "error", err)
+				return
+			}
+			slog.Debug("fired webhook", "url", h.URL, "event", run.Event)
+		}(h)
+	}
+	wg.Wait()
+}
+
+// fire sends the request of the hook for the run
+func (n *Notifier) fire(ctx context.Context, h *Hook, run Run) error {
+	timeout := n.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	body, contentType, err := h.body(run)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, h.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", contentType)
+	if n.UserAgent != "" {
+		req.Header.Set("User-Agent", n.UserAgent)
+	}
+	for name, value := range h.Headers {
+		req.Header.Set(name, value)
+	}
+
+	client := n.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return nil
+}
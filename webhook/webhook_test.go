@@ -0,0 +1,106 @@
+package webhook_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/bitcanon/autotyper/webhook"
+)
+
+// request is a request received by the test server
+type request struct {
+	Path, ContentType, Token, Body string
+}
+
+// newServer returns a server recording the requests it receives
+func newServer(t *testing.T) (*httptest.Server, func() []request) {
+	t.Helper()
+	var mu sync.Mutex
+	var requests []request
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		defer mu.Unlock()
+		requests = append(requests, request{r.URL.Path, r.Header.Get("Content-Type"), r.Header.Get("X-Token"), string(body)})
+		if r.URL.Path == "/broken" {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv, func() []request {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]request(nil), requests...)
+	}
+}
+
+// TestNotify tests that the webhooks of an event are fired, with the
+// run as JSON or with the body of their payload template
+func TestNotify(t *testing.T) {
+	srv, requests := newServer(t)
+	all, err := webhook.New(srv.URL+"/all", nil, "")
+	if err != nil {
+		t.Fatalf("expected no error, but got %v", err)
+	}
+	failures, err := webhook.New(srv.URL+"/failures", []string{webhook.Fail}, `{"text": {{json (printf "%s failed: %s" .Script .Error)}}}`)
+	if err != nil {
+		t.Fatalf("expected no error, but got %v", err)
+	}
+	failures.Headers = map[string]string{"X-Token": "secret"}
+	broken, err := webhook.New(srv.URL+"/broken", nil, "{{.Event}}")
+	if err != nil {
+		t.Fatalf("expected no error, but got %v", err)
+	}
+	n := &webhook.Notifier{Hooks: []*webhook.Hook{all, failures, broken}}
+
+	n.Notify(context.Background(), webhook.Run{Event: webhook.Start, Script: "demo.txt"})
+	if got := requests(); len(got) != 2 {
+		t.Fatalf("expected the start to be sent to 2 webhooks, but got %+v", got)
+	}
+
+	n.Notify(context.Background(), webhook.Run{Event: webhook.Fail, Script: "demo.txt", Steps: 3, Error: `step 3: "ls" failed`})
+	got := map[string]request{}
+	for _, r := range requests()[2:] {
+		got[r.Path] = r
+	}
+	if len(got) != 3 {
+		t.Fatalf("expected the failure to be sent to 3 webhooks, but got %+v", got)
+	}
+
+	var run webhook.Run
+	if err := json.Unmarshal([]byte(got["/all"].Body), &run); err != nil || run.Event != webhook.Fail || run.Steps != 3 {
+		t.Errorf("expected the run as JSON, but got %q (%v)", got["/all"].Body, err)
+	}
+	expected := `{"text": "demo.txt failed: step 3: \"ls\" failed"}`
+	if r := got["/failures"]; r.Body != expected || r.ContentType != "application/json" || r.Token != "secret" {
+		t.Errorf("expected %q with its header, but got %+v", expected, r)
+	}
+	if r := got["/broken"]; r.Body != "fail" || r.ContentType != "text/plain; charset=utf-8" {
+		t.Errorf("expected a plain text payload, but got %+v", r)
+	}
+}
+
+// TestNew tests that invalid webhooks are refused
+func TestNew(t *testing.T) {
+	tests := []struct {
+		url     string
+		events  []string
+		payload string
+	}{
+		{url: "ftp://example.com/hook"},
+		{url: "https://"},
+		{url: "https://example.com/hook", events: []string{"stop"}},
+		{url: "https://example.com/hook", payload: "{{.Event"},
+	}
+
+	for _, test := range tests {
+		if _, err := webhook.New(test.url, test.events, test.payload); err == nil {
+			t.Errorf("%+v: expected error, but got nil", test)
+		}
+	}
+}